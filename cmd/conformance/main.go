@@ -27,11 +27,11 @@ import (
 
 	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
-	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/conformance"
 	"github.com/sigstore/sigstore-go/pkg/tuf"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 )
@@ -42,6 +42,7 @@ var certPath *string
 var certOIDC *string
 var certSAN *string
 var identityToken *string
+var outputPath *string
 var signaturePath *string
 var staging = false
 var trustedRootPath *string
@@ -52,6 +53,7 @@ func usage() {
 	fmt.Printf("\t%s sign-bundle --identity-token TOKEN --bundle FILE [--staging] FILE", os.Args[0])
 	fmt.Printf("\t%s verify --signature FILE --certificate FILE --certificate-identity IDENTITY --certificate-oidc-issuer URL [--trusted-root FILE] [--staging] FILE\n", os.Args[0])
 	fmt.Printf("\t%s verify-bundle --bundle FILE --certificate-identity IDENTITY --certificate-oidc-issuer URL [--trusted-root FILE] [--staging] FILE\n", os.Args[0])
+	fmt.Printf("\t%s generate-vectors --output DIR\n", os.Args[0])
 }
 
 func getTrustedRoot(staging bool) root.TrustedMaterial {
@@ -119,6 +121,9 @@ func parseArgs() {
 		case "--identity-token":
 			identityToken = &os.Args[i+1]
 			i += 2
+		case "--output":
+			outputPath = &os.Args[i+1]
+			i += 2
 		case "--signature":
 			signaturePath = &os.Args[i+1]
 			i += 2
@@ -144,21 +149,23 @@ func signBundle(withRekor bool) (*protobundle.Bundle, error) {
 		instance = "sigstage"
 	}
 
-	fulcioOpts := &sign.FulcioOptions{
-		BaseURL:        fmt.Sprintf("https://fulcio.%s.dev", instance),
-		Timeout:        timeout,
-		LibraryVersion: Version,
+	fulcio, err := sign.NewFulcioClient(fmt.Sprintf("https://fulcio.%s.dev", instance),
+		sign.WithTimeout(timeout),
+		sign.WithLibraryVersion(Version))
+	if err != nil {
+		return nil, err
 	}
-	signingOptions.Fulcio = sign.NewFulcio(fulcioOpts)
+	signingOptions.Fulcio = fulcio
 	signingOptions.IDToken = *identityToken
 
 	if withRekor {
-		rekorOpts := &sign.RekorOptions{
-			BaseURL:        fmt.Sprintf("https://rekor.%s.dev", instance),
-			Timeout:        timeout,
-			LibraryVersion: Version,
+		rekor, err := sign.NewRekorClient(fmt.Sprintf("https://rekor.%s.dev", instance),
+			sign.WithTimeout(timeout),
+			sign.WithLibraryVersion(Version))
+		if err != nil {
+			return nil, err
 		}
-		signingOptions.Rekors = append(signingOptions.Rekors, sign.NewRekor(rekorOpts))
+		signingOptions.Rekors = append(signingOptions.Rekors, rekor)
 	}
 
 	fileBytes, err := os.ReadFile(os.Args[len(os.Args)-1])
@@ -224,7 +231,7 @@ func main() {
 			log.Fatal(err)
 		}
 
-		bundleBytes, err := protojson.Marshal(bundle)
+		bundleBytes, err := sign.MarshalBundleJSON(bundle, nil)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -378,6 +385,18 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
+	case "generate-vectors":
+		if outputPath == nil {
+			usage()
+			os.Exit(1)
+		}
+		vectors, err := conformance.Generate(conformance.Options{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = vectors.WriteDir(*outputPath); err != nil {
+			log.Fatal(err)
+		}
 	default:
 		log.Fatalf("Unsupported command %s", os.Args[1])
 	}
@@ -0,0 +1,69 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sigstore-devstack runs a private Sigstore deployment on local ports for
+// prototyping integrations, writing its trusted root and signing config to
+// a directory. It must never be used to sign anything real; see
+// pkg/testing/devstack.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/devstack"
+)
+
+var (
+	dir      = flag.String("dir", ".", "Directory to write trusted_root.json and signing_config.json to")
+	issuer   = flag.String("issuer", devstack.DefaultIssuer, "OIDC issuer whose identity tokens the certificate authority accepts")
+	validity = flag.Duration("validity", devstack.DefaultValidity, "How long the generated certificate authorities and keys are valid for")
+	identity = flag.String("identity", "jdoe@example.com", "Email address to print an identity token for")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	if err := os.MkdirAll(*dir, 0o750); err != nil {
+		return err
+	}
+	stack, err := devstack.New(&devstack.Options{Issuer: *issuer, Validity: *validity})
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	if err := stack.WriteFiles(*dir); err != nil {
+		return err
+	}
+
+	fmt.Printf("Fulcio:              %s\n", stack.FulcioURL())
+	fmt.Printf("Rekor:               %s\n", stack.RekorURL())
+	fmt.Printf("Timestamp authority: %s\n", stack.TimestampAuthorityURL())
+	fmt.Printf("Trusted root and signing config written to %s\n", *dir)
+	fmt.Printf("Identity token for %s:\n%s\n", *identity, stack.IDToken(*identity))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func setupCompletion(_ *flag.FlagSet) func(*env, []string) error {
+	return func(e *env, args []string) error {
+		if len(args) != 1 {
+			return usageError{"completion takes exactly one shell: " + strings.Join(completionShells, ", ")}
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(e.stdout)
+		case "zsh":
+			// zsh can run bash completion functions
+			fmt.Fprintln(e.stdout, "autoload -U +X bashcompinit && bashcompinit")
+			writeBashCompletion(e.stdout)
+		case "fish":
+			writeFishCompletion(e.stdout)
+		default:
+			return usageError{fmt.Sprintf("unsupported shell %q", args[0])}
+		}
+		return nil
+	}
+}
+
+// completionTree returns the words that may follow each command path, e.g.
+// "" for the top level or "trusted-root" for its subcommands, and the flags
+// of each command.
+func completionTree() (map[string][]string, map[string][]string) {
+	words := make(map[string][]string)
+	flags := make(map[string][]string)
+	for _, cmd := range commands {
+		path := ""
+		for _, word := range strings.Fields(cmd.name) {
+			if !containsString(words[path], word) {
+				words[path] = append(words[path], word)
+			}
+			path = strings.TrimPrefix(path+" "+word, " ")
+		}
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		cmd.setup(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			flags[cmd.name] = append(flags[cmd.name], "-"+f.Name)
+		})
+	}
+	words["completion"] = completionShells
+	return words, flags
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// completionPaths returns the command paths other than the top level, in
+// order.
+func completionPaths(words, flags map[string][]string) []string {
+	var paths []string
+	for path := range words {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	for path := range flags {
+		if _, ok := words[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func writeBashCompletion(w io.Writer) {
+	words, flags := completionTree()
+	paths := completionPaths(words, flags)
+
+	fmt.Fprintln(w, "_sigstore_go() {")
+	fmt.Fprintln(w, `	local cur="${COMP_WORDS[COMP_CWORD]}" path="" word subcommands="" flags=""`)
+	fmt.Fprintln(w, `	for word in "${COMP_WORDS[@]:1:COMP_CWORD-1}"; do`)
+	fmt.Fprintln(w, `		case "$word" in -*) continue ;; esac`)
+	fmt.Fprintln(w, `		case "${path:+$path }$word" in`)
+	for _, path := range paths {
+		fmt.Fprintf(w, "\t\t%q) path=\"${path:+$path }$word\" ;;\n", path)
+	}
+	fmt.Fprintln(w, `		*) break ;;`)
+	fmt.Fprintln(w, `		esac`)
+	fmt.Fprintln(w, `	done`)
+	fmt.Fprintln(w, `	case "$path" in`)
+	fmt.Fprintf(w, "\t\"\") subcommands=%q ;;\n", strings.Join(append(words[""], "help"), " "))
+	for _, path := range paths {
+		fmt.Fprintf(w, "\t%q) subcommands=%q flags=%q ;;\n", path, strings.Join(words[path], " "), strings.Join(flags[path], " "))
+	}
+	fmt.Fprintln(w, `	esac`)
+	fmt.Fprintln(w, `	if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$flags" -- "$cur"))`)
+	fmt.Fprintln(w, `	elif [[ -n "$subcommands" ]]; then`)
+	fmt.Fprintln(w, `		COMPREPLY=($(compgen -W "$subcommands" -- "$cur"))`)
+	fmt.Fprintln(w, `	fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _sigstore_go sigstore-go")
+}
+
+func writeFishCompletion(w io.Writer) {
+	words, _ := completionTree()
+	summaries := map[string]string{"help": "Print the commands"}
+	for _, cmd := range commands {
+		summaries[cmd.name] = cmd.summary
+	}
+
+	for _, word := range append(words[""], "help") {
+		summary, ok := summaries[word]
+		if !ok {
+			summary = "Commands: " + strings.Join(words[word], ", ")
+		}
+		fmt.Fprintf(w, "complete -c sigstore-go -f -n __fish_use_subcommand -a %s -d %s\n", word, fishQuote(summary))
+	}
+	for _, cmd := range commands {
+		cmdWords := strings.Fields(cmd.name)
+		last := cmdWords[len(cmdWords)-1]
+		if len(cmdWords) > 1 {
+			condition := fmt.Sprintf("__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s", cmdWords[0], strings.Join(words[cmdWords[0]], " "))
+			fmt.Fprintf(w, "complete -c sigstore-go -f -n '%s' -a %s -d %s\n", condition, last, fishQuote(cmd.summary))
+		}
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		cmd.setup(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "complete -c sigstore-go -n '__fish_seen_subcommand_from %s' -o %s -d %s\n", last, f.Name, fishQuote(f.Usage))
+		})
+	}
+	fmt.Fprintf(w, "complete -c sigstore-go -f -n '__fish_seen_subcommand_from completion' -a '%s'\n", strings.Join(completionShells, " "))
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
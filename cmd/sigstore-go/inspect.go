@@ -0,0 +1,45 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func setupBundleInspect(fs *flag.FlagSet) func(*env, []string) error {
+	jsonOutput := fs.Bool("json", false, "Print the contents of the bundle as JSON")
+	return func(e *env, args []string) error {
+		if len(args) != 1 {
+			return usageError{"bundle inspect takes exactly one bundle"}
+		}
+		b, err := bundle.LoadJSONFromPath(args[0])
+		if err != nil {
+			return err
+		}
+		summary, err := bundle.Inspect(b)
+		if err != nil {
+			return err
+		}
+		if *jsonOutput {
+			return writeJSON(e.stdout, summary)
+		}
+		fmt.Fprint(e.stdout, summary.String())
+		fmt.Fprintln(e.stdout, "Nothing above has been verified; use \"sigstore-go verify\" to verify the bundle.")
+		return nil
+	}
+}
@@ -12,228 +12,177 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// sigstore-go signs and verifies Sigstore bundles, and inspects bundles and
+// trusted roots. Run "sigstore-go help" for its commands.
+//
+// It exits with one of the following codes, so that scripts can react to
+// why a command failed:
+//
+//	0  success
+//	1  any other error
+//	2  invalid usage
+//	3  untrusted verification material, e.g. an out of date trusted root or a
+//	   certificate from an unknown CA
+//	4  cryptographic failure, e.g. a tampered bundle or artifact
+//	5  verification policy not satisfied, e.g. an unexpected identity
 package main
 
 import (
-	"crypto"
-	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/hex"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"strings"
 
-	"github.com/sigstore/sigstore-go/pkg/bundle"
-	"github.com/sigstore/sigstore-go/pkg/root"
-	"github.com/sigstore/sigstore-go/pkg/tuf"
 	"github.com/sigstore/sigstore-go/pkg/verify"
-	"github.com/sigstore/sigstore/pkg/signature"
 )
 
-var artifact *string
-var artifactDigest *string
-var artifactDigestAlgorithm *string
-var expectedOIDIssuer *string
-var expectedSAN *string
-var expectedSANRegex *string
-var requireTimestamp *bool
-var requireCTlog *bool
-var requireTlog *bool
-var minBundleVersion *string
-var onlineTlog *bool
-var trustedPublicKey *string
-var trustedrootJSONpath *string
-var tufRootURL *string
-var tufTrustedRoot *string
+const (
+	exitOK                   = 0
+	exitError                = 1
+	exitUsage                = 2
+	exitUntrustedMaterial    = 3
+	exitCryptographicFailure = 4
+	exitPolicyNotSatisfied   = 5
+)
 
-func init() {
-	artifact = flag.String("artifact", "", "Path to artifact to verify")
-	artifactDigest = flag.String("artifact-digest", "", "Hex-encoded digest of artifact to verify")
-	artifactDigestAlgorithm = flag.String("artifact-digest-algorithm", "sha256", "Digest algorithm")
-	expectedOIDIssuer = flag.String("expectedIssuer", "", "The expected OIDC issuer for the signing certificate")
-	expectedSAN = flag.String("expectedSAN", "", "The expected identity in the signing certificate's SAN extension")
-	expectedSANRegex = flag.String("expectedSANRegex", "", "The expected identity in the signing certificate's SAN extension")
-	requireTimestamp = flag.Bool("requireTimestamp", true, "Require either an RFC3161 signed timestamp or log entry integrated timestamp")
-	requireCTlog = flag.Bool("requireCTlog", true, "Require Certificate Transparency log entry")
-	requireTlog = flag.Bool("requireTlog", true, "Require Artifact Transparency log entry (Rekor)")
-	minBundleVersion = flag.String("minBundleVersion", "", "Minimum acceptable bundle version (e.g. '0.1')")
-	onlineTlog = flag.Bool("onlineTlog", false, "Verify Artifact Transparency log entry online (Rekor)")
-	trustedPublicKey = flag.String("publicKey", "", "Path to trusted public key")
-	trustedrootJSONpath = flag.String("trustedrootJSONpath", "examples/trusted-root-public-good.json", "Path to trustedroot JSON file")
-	tufRootURL = flag.String("tufRootURL", "", "URL of TUF root containing trusted root JSON file")
-	tufTrustedRoot = flag.String("tufTrustedRoot", "", "Path to the trusted TUF root.json to bootstrap trust in the remote TUF repository")
-	flag.Parse()
-	if flag.NArg() == 0 {
-		usage()
-		os.Exit(1)
-	}
+// command is a sigstore-go command. setup registers the command's flags
+// and returns the function that runs it with the remaining arguments.
+type command struct {
+	// name is the command's words, e.g. "trusted-root fetch"
+	name    string
+	args    string
+	summary string
+	setup   func(fs *flag.FlagSet) func(env *env, args []string) error
 }
 
-func usage() {
-	fmt.Printf("Usage: %s [OPTIONS] BUNDLE_FILE ...\n", os.Args[0])
-	flag.PrintDefaults()
+// env is where commands write their output.
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
 }
 
-func main() {
-	if err := run(); err != nil {
-		os.Stderr.WriteString(err.Error() + "\n")
-		os.Exit(1)
-	}
-}
-
-func run() error {
-	b, err := bundle.LoadJSONFromPath(flag.Arg(0))
-	if err != nil {
-		return err
-	}
+var commands []*command
 
-	if *minBundleVersion != "" {
-		if !b.MinVersion(*minBundleVersion) {
-			return fmt.Errorf("bundle is not of minimum version %s", *minBundleVersion)
-		}
+func init() {
+	// Set in init, as completion refers to the commands
+	commands = []*command{
+		{name: "verify", args: "BUNDLE_FILE", summary: "Verify a bundle against a trusted root or public key", setup: setupVerify},
+		{name: "sign", args: "FILE", summary: "Sign a file, writing a bundle", setup: setupSign},
+		{name: "attest", args: "FILE ...", summary: "Sign an in-toto statement about files, writing a bundle", setup: setupAttest},
+		{name: "trusted-root fetch", summary: "Fetch the trusted root from a TUF repository", setup: setupTrustedRootFetch},
+		{name: "trusted-root validate", summary: "Validate a trusted root and print any problems found", setup: setupTrustedRootValidate},
+		{name: "bundle inspect", args: "BUNDLE_FILE", summary: "Print the contents of a bundle without verifying it", setup: setupBundleInspect},
+		{name: "completion", args: "bash|zsh|fish", summary: "Print a shell completion script", setup: setupCompletion},
 	}
+}
 
-	verifierConfig := []verify.VerifierOption{}
-	identityPolicies := []verify.PolicyOption{}
-	var artifactPolicy verify.ArtifactPolicyOption
-
-	if *requireCTlog {
-		verifierConfig = append(verifierConfig, verify.WithSignedCertificateTimestamps(1))
-	}
+// usageError is returned by commands called with invalid arguments.
+type usageError struct {
+	msg string
+}
 
-	if *requireTimestamp {
-		verifierConfig = append(verifierConfig, verify.WithObserverTimestamps(1))
-	}
+func (e usageError) Error() string {
+	return e.msg
+}
 
-	if *requireTlog {
-		verifierConfig = append(verifierConfig, verify.WithTransparencyLog(1))
-	}
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdout: os.Stdout, stderr: os.Stderr}))
+}
 
-	if *onlineTlog {
-		verifierConfig = append(verifierConfig, verify.WithOnlineVerification())
+func run(args []string, e *env) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitUsage
 	}
-
-	certID, err := verify.NewShortCertificateIdentity(*expectedOIDIssuer, *expectedSAN, "", *expectedSANRegex)
-	if err != nil {
-		return err
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(e.stdout)
+		return exitOK
 	}
-	identityPolicies = append(identityPolicies, verify.WithCertificateIdentity(certID))
-
-	var trustedMaterial = make(root.TrustedMaterialCollection, 0)
-	var trustedRootJSON []byte
-
-	if *tufRootURL != "" {
-		opts := tuf.DefaultOptions()
-		opts.RepositoryBaseURL = *tufRootURL
-
-		// Load the tuf root.json if provided, if not use public good
-		if *tufTrustedRoot != "" {
-			rb, err := os.ReadFile(*tufTrustedRoot)
-			if err != nil {
-				return fmt.Errorf("failed to read %s: %w",
-					*tufTrustedRoot, err)
-			}
-			opts.Root = rb
-		}
 
-		client, err := tuf.New(opts)
-		if err != nil {
-			return err
-		}
-		trustedRootJSON, err = client.GetTarget("trusted_root.json")
-		if err != nil {
-			return err
-		}
-	} else if *trustedrootJSONpath != "" {
-		trustedRootJSON, err = os.ReadFile(*trustedrootJSONpath)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w",
-				*trustedrootJSONpath, err)
+	cmd, cmdArgs := findCommand(args)
+	if cmd == nil {
+		if _, err := os.Stat(args[0]); err != nil && !strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(e.stderr, "unknown command %q\n", strings.Join(args, " "))
+			usage(e.stderr)
+			return exitUsage
 		}
+		// Before it had commands, sigstore-go only verified bundles
+		cmd, cmdArgs = commands[0], args
 	}
 
-	if len(trustedRootJSON) > 0 {
-		var trustedRoot *root.TrustedRoot
-		trustedRoot, err = root.NewTrustedRootFromJSON(trustedRootJSON)
-		if err != nil {
-			return err
-		}
-		trustedMaterial = append(trustedMaterial, trustedRoot)
+	fs := flag.NewFlagSet("sigstore-go "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: sigstore-go %s [OPTIONS] %s\n\n%s.\n\nOptions:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
 	}
-	if *trustedPublicKey != "" {
-		pemBytes, err := os.ReadFile(*trustedPublicKey)
-		if err != nil {
-			return err
-		}
-		pemBlock, _ := pem.Decode(pemBytes)
-		if pemBlock == nil {
-			return errors.New("failed to decode pem block")
-		}
-		pubKey, err := x509.ParsePKIXPublicKey(pemBlock.Bytes)
-		if err != nil {
-			return err
+	runCmd := cmd.setup(fs)
+	if err := fs.Parse(cmdArgs); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
 		}
-		trustedMaterial = append(trustedMaterial, trustedPublicKeyMaterial(pubKey))
+		return exitUsage
 	}
 
-	if len(trustedMaterial) == 0 {
-		return errors.New("no trusted material provided")
+	err := runCmd(e, fs.Args())
+	if err == nil {
+		return exitOK
 	}
-
-	sev, err := verify.NewSignedEntityVerifier(trustedMaterial, verifierConfig...)
-	if err != nil {
-		return err
+	fmt.Fprintln(e.stderr, err.Error())
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		fs.Usage()
+		return exitUsage
 	}
+	return exitCode(err)
+}
 
-	if *artifactDigest != "" { //nolint:gocritic
-		artifactDigestBytes, err := hex.DecodeString(*artifactDigest)
-		if err != nil {
-			return err
+// findCommand returns the command named by the first arguments, and the
+// arguments that follow its name, or nil if none is.
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
 		}
-		artifactPolicy = verify.WithArtifactDigest(*artifactDigestAlgorithm, artifactDigestBytes)
-	} else if *artifact != "" {
-		file, err := os.Open(*artifact)
-		if err != nil {
-			return err
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):]
 		}
-		artifactPolicy = verify.WithArtifact(file)
-	} else {
-		artifactPolicy = verify.WithoutArtifactUnsafe()
-		fmt.Fprintf(os.Stderr, "No artifact provided, skipping artifact verification. This is unsafe!\n")
 	}
-
-	res, err := sev.Verify(b, verify.NewPolicy(artifactPolicy, identityPolicies...))
-	if err != nil {
-		return err
-	}
-
-	fmt.Fprintf(os.Stderr, "Verification successful!\n")
-	marshaled, err := json.MarshalIndent(res, "", "   ")
-	if err != nil {
-		return err
-	}
-	fmt.Println(string(marshaled))
-	return nil
+	return nil, nil
 }
 
-type nonExpiringVerifier struct {
-	signature.Verifier
+func exitCode(err error) int {
+	switch verify.ClassifyError(err) {
+	case verify.ErrorClassUntrustedMaterial:
+		return exitUntrustedMaterial
+	case verify.ErrorClassCryptographicFailure:
+		return exitCryptographicFailure
+	case verify.ErrorClassPolicyNotSatisfied:
+		return exitPolicyNotSatisfied
+	default:
+		return exitError
+	}
 }
 
-func (*nonExpiringVerifier) ValidAtTime(_ time.Time) bool {
-	return true
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: sigstore-go COMMAND [OPTIONS] ARGS\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-22s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun \"sigstore-go COMMAND -h\" for the options of a command.\n")
 }
 
-func trustedPublicKeyMaterial(pk crypto.PublicKey) *root.TrustedPublicKeyMaterial {
-	return root.NewTrustedPublicKeyMaterial(func(string) (root.TimeConstrainedVerifier, error) {
-		verifier, err := signature.LoadECDSAVerifier(pk.(*ecdsa.PublicKey), crypto.SHA256)
-		if err != nil {
-			return nil, err
-		}
-		return &nonExpiringVerifier{verifier}, nil
-	})
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	marshaled, err := json.MarshalIndent(v, "", "   ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(marshaled))
+	return err
 }
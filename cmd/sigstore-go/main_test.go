@@ -0,0 +1,157 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/devstack"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &env{stdout: &stdout, stderr: &stderr})
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSignAndVerify(t *testing.T) {
+	stack, err := devstack.New(nil)
+	require.NoError(t, err)
+	defer stack.Close()
+	dir := t.TempDir()
+	require.NoError(t, stack.WriteFiles(dir))
+	trustedRootPath := filepath.Join(dir, devstack.TrustedRootFileName)
+	signingConfigPath := filepath.Join(dir, devstack.SigningConfigFileName)
+
+	artifactPath := filepath.Join(dir, "artifact.txt")
+	require.NoError(t, os.WriteFile(artifactPath, []byte("hello, world"), 0o600))
+	bundlePath := filepath.Join(dir, "artifact.sigstore.json")
+	idToken := stack.IDToken("jdoe@example.com")
+
+	code, stdout, stderr := runCLI("sign", "-signingConfig", signingConfigPath, "-idToken", idToken, "-bundle", bundlePath, "-json", artifactPath)
+	require.Equal(t, exitOK, code, stderr)
+	var signed signOutput
+	require.NoError(t, json.Unmarshal([]byte(stdout), &signed))
+	assert.Equal(t, bundlePath, signed.BundlePath)
+	assert.Len(t, signed.TransparencyLogEntries, 1)
+	assert.Len(t, signed.Timestamps, 1)
+
+	verifyArgs := []string{"verify", "-trustedrootJSONpath", trustedRootPath, "-expectedIssuer", devstack.DefaultIssuer}
+	code, _, stderr = runCLI(append(verifyArgs, "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, bundlePath)...)
+	assert.Equal(t, exitOK, code, stderr)
+	code, _, stderr = runCLI(append(verifyArgs, "-profile", "cosign", "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, bundlePath)...)
+	assert.Equal(t, exitOK, code, stderr)
+	code, _, _ = runCLI(append(verifyArgs, "-profile", "unknown", "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, bundlePath)...)
+	assert.Equal(t, exitUsage, code)
+	code, _, stderr = runCLI(append(verifyArgs, "-minBundleVersion", "0.3", "-maxBundleVersion", "0.3", "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, bundlePath)...)
+	assert.Equal(t, exitOK, code, stderr)
+	code, _, stderr = runCLI(append(verifyArgs, "-maxBundleVersion", "0.2", "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, bundlePath)...)
+	assert.Equal(t, exitPolicyNotSatisfied, code)
+	assert.Contains(t, stderr, "newer than the maximum version v0.2")
+
+	// Without a command, bundles are verified
+	code, _, stderr = runCLI(append(verifyArgs[1:], "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, bundlePath)...)
+	assert.Equal(t, exitOK, code, stderr)
+
+	code, stdout, _ = runCLI(append(verifyArgs, "-expectedSAN", "other@example.com", "-artifact", artifactPath, "-json", bundlePath)...)
+	assert.Equal(t, exitPolicyNotSatisfied, code)
+	var decision verify.Decision
+	require.NoError(t, json.Unmarshal([]byte(stdout), &decision))
+	assert.False(t, decision.Allowed)
+	assert.Contains(t, decision.DeniedReasons, verify.ReasonIdentityMismatch)
+
+	otherArtifactPath := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(otherArtifactPath, []byte("goodbye, world"), 0o600))
+	code, _, _ = runCLI(append(verifyArgs, "-expectedSAN", "jdoe@example.com", "-artifact", otherArtifactPath, bundlePath)...)
+	assert.Equal(t, exitCryptographicFailure, code)
+
+	// Attestations are signed over the files given as subjects
+	attestationPath := filepath.Join(dir, "attestation.sigstore.json")
+	code, _, stderr = runCLI("attest", "-signingConfig", signingConfigPath, "-idToken", idToken, "-bundle", attestationPath,
+		"-predicateType", "https://example.com/predicate", artifactPath)
+	require.Equal(t, exitOK, code, stderr)
+	code, _, stderr = runCLI(append(verifyArgs, "-expectedSAN", "jdoe@example.com", "-artifact", artifactPath, attestationPath)...)
+	assert.Equal(t, exitOK, code, stderr)
+
+	code, stdout, stderr = runCLI("bundle", "inspect", "-json", attestationPath)
+	require.Equal(t, exitOK, code, stderr)
+	var summary bundle.Summary
+	require.NoError(t, json.Unmarshal([]byte(stdout), &summary))
+	assert.Equal(t, bundle.SignatureKindDSSEEnvelope, summary.SignatureKind)
+	assert.Equal(t, "https://example.com/predicate", summary.PredicateType)
+	assert.Len(t, summary.Subjects, 1)
+	require.NotNil(t, summary.Signer)
+	assert.Equal(t, "jdoe@example.com", summary.Signer.SubjectAlternativeName.Value)
+	assert.Len(t, summary.TransparencyLogEntries, 1)
+	assert.Len(t, summary.Timestamps, 1)
+
+	code, stdout, stderr = runCLI("bundle", "inspect", bundlePath)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "Content: message signature over sha256:")
+
+	code, _, stderr = runCLI("trusted-root", "validate", "-trustedrootJSONpath", trustedRootPath)
+	assert.Equal(t, exitOK, code, stderr)
+}
+
+func TestUsage(t *testing.T) {
+	code, _, _ := runCLI()
+	assert.Equal(t, exitUsage, code)
+
+	code, stdout, _ := runCLI("help")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "trusted-root fetch")
+
+	code, _, stderr := runCLI("frobnicate")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "unknown command")
+
+	code, _, _ = runCLI("sign", "-idToken", "token")
+	assert.Equal(t, exitUsage, code)
+
+	code, _, _ = runCLI("attest", "-idToken", "token", "file")
+	assert.Equal(t, exitUsage, code)
+
+	artifactPath := filepath.Join(t.TempDir(), "artifact.txt")
+	require.NoError(t, os.WriteFile(artifactPath, []byte("hello, world"), 0o600))
+	code, _, stderr = runCLI("sign", "-key", "key.pem", "-systemKey", "build01.example.com", artifactPath)
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "only one of -key and -systemKey")
+
+	code, _, _ = runCLI("verify", "-noSuchFlag", "bundle.json")
+	assert.Equal(t, exitUsage, code)
+}
+
+func TestCompletion(t *testing.T) {
+	code, stdout, _ := runCLI("completion", "bash")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, `"trusted-root") subcommands="fetch validate"`)
+	assert.Contains(t, stdout, "-expectedSAN")
+
+	code, stdout, _ = runCLI("completion", "fish")
+	assert.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "-o predicateType")
+
+	code, _, _ = runCLI("completion", "powershell")
+	assert.Equal(t, exitUsage, code)
+}
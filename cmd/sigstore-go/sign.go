@@ -0,0 +1,297 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+)
+
+// Version is the version of sigstore-go sent in the User-Agent of requests
+// to Sigstore services, set at build time.
+var Version string
+
+type signFlags struct {
+	bundlePath    *string
+	signingConfig *string
+	tuf           *tufFlags
+	idToken       *string
+	keyPath       *string
+	timeout       *time.Duration
+	jsonOutput    *bool
+	annotations   annotationFlag
+	detachedSCTs  *bool
+	rekorVersion  *uint
+	systemKey     *string
+}
+
+// annotationFlag collects repeated -annotation KEY=VALUE flags.
+type annotationFlag map[string]string
+
+func (a annotationFlag) String() string {
+	return ""
+}
+
+func (a annotationFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return errors.New("annotation must be KEY=VALUE")
+	}
+	a[key] = val
+	return nil
+}
+
+func addSignFlags(fs *flag.FlagSet) *signFlags {
+	f := &signFlags{
+		bundlePath:    fs.String("bundle", "", "Path to write the bundle to, instead of standard output"),
+		signingConfig: fs.String("signingConfig", "", "Path to the signing config selecting the services to sign with, instead of fetching it from TUF"),
+		tuf:           addTUFFlags(fs),
+		idToken:       fs.String("idToken", "", "OIDC identity token to get a signing certificate for (defaults to $SIGSTORE_ID_TOKEN)"),
+		keyPath:       fs.String("key", "", "Path to a PEM-encoded private key to sign with, instead of getting a signing certificate; encrypted keys are decrypted with $SIGSTORE_KEY_PASSPHRASE or a prompted passphrase"),
+		timeout:       fs.Duration("timeout", 30*time.Second, "Timeout for requests to each service"),
+		jsonOutput:    fs.Bool("json", false, "Print the bundle and details of its signing as JSON"),
+		annotations:   annotationFlag{},
+		detachedSCTs:  fs.Bool("detachedSCTs", false, "Write SCTs that Fulcio returned separately from the certificate to the bundle, as a member other clients may reject"),
+		systemKey:     fs.String("systemKey", "", "Sign with the key of a certificate in the Windows certificate store or macOS keychain, selected by subject common name or by \"sha256:\" and the certificate's hex SHA-256 fingerprint"),
+		rekorVersion:  fs.Uint("rekorVersion", 0, "Major API version of the Rekor logs to upload to, instead of the highest version listed in the signing config"),
+	}
+	fs.Var(f.annotations, "annotation", "Unauthenticated KEY=VALUE metadata to attach to the bundle, e.g. a build ID; may be repeated")
+	return f
+}
+
+func setupSign(fs *flag.FlagSet) func(*env, []string) error {
+	f := addSignFlags(fs)
+	return func(e *env, args []string) error {
+		if len(args) != 1 {
+			return usageError{"sign takes exactly one file"}
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		return f.sign(e, &sign.PlainData{Data: data})
+	}
+}
+
+func setupAttest(fs *flag.FlagSet) func(*env, []string) error {
+	f := addSignFlags(fs)
+	predicateType := fs.String("predicateType", "", "Predicate type of the statement, e.g. https://slsa.dev/provenance/v1")
+	predicatePath := fs.String("predicate", "", "Path to the JSON predicate of the statement (defaults to an empty predicate)")
+	return func(e *env, args []string) error {
+		if len(args) == 0 {
+			return usageError{"attest takes at least one file"}
+		}
+		if *predicateType == "" {
+			return usageError{"-predicateType is required"}
+		}
+		builder := sign.NewStatementBuilder(*predicateType)
+		for _, path := range args {
+			builder.AddSubjectFromFile(path)
+		}
+		if *predicatePath != "" {
+			predicate, err := os.ReadFile(*predicatePath)
+			if err != nil {
+				return err
+			}
+			builder.WithPredicate(json.RawMessage(predicate))
+		}
+		content, err := builder.DSSEData()
+		if err != nil {
+			return err
+		}
+		return f.sign(e, content)
+	}
+}
+
+func (f *signFlags) sign(e *env, content sign.Content) error {
+	// The token is not the flag's default, which would be printed in usage
+	idToken := *f.idToken
+	if idToken == "" {
+		idToken = os.Getenv("SIGSTORE_ID_TOKEN")
+	}
+	if *f.keyPath != "" && *f.systemKey != "" {
+		return usageError{"only one of -key and -systemKey may be given"}
+	}
+	if idToken == "" && *f.keyPath == "" && *f.systemKey == "" {
+		return usageError{"an identity token, from -idToken or $SIGSTORE_ID_TOKEN, a -key or a -systemKey is required"}
+	}
+
+	var signingConfig *root.SigningConfig
+	var err error
+	if *f.signingConfig != "" {
+		signingConfig, err = root.NewSigningConfigFromPath(*f.signingConfig)
+	} else {
+		var signingConfigJSON []byte
+		signingConfigJSON, err = f.tuf.getTarget(signingConfigTarget)
+		if err == nil {
+			signingConfig, err = root.NewSigningConfigFromJSON(signingConfigJSON)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load signing config: %w", err)
+	}
+	var selectOpts []sign.SelectOption
+	if *f.rekorVersion != 0 {
+		selectOpts = append(selectOpts, sign.WithRekorAPIVersion(uint32(*f.rekorVersion)))
+	}
+	services, err := sign.SelectSigningServices(signingConfig, time.Time{}, selectOpts...)
+	if err != nil {
+		return err
+	}
+
+	var keypair sign.Keypair
+	switch {
+	case *f.keyPath != "":
+		keypair, err = sign.LoadPrivateKeyKeypairFromPath(*f.keyPath, readPassphrase, nil)
+		// Bundles signed with a key have no certificate
+		services.Fulcio = nil
+	case *f.systemKey != "":
+		var identity *sign.SystemIdentity
+		identity, err = sign.LoadSystemIdentity(systemKeyStoreOptions(*f.systemKey))
+		if err != nil {
+			return err
+		}
+		defer identity.Close()
+		keypair, err = identity.Keypair(nil)
+		services.Fulcio = nil
+	default:
+		if services.Fulcio == nil {
+			return errors.New("signing config has no certificate authority to get a signing certificate from")
+		}
+		keypair, err = sign.NewEphemeralKeypair(nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	opts, err := services.NewBundleOptions(idToken, sign.WithTimeout(*f.timeout), sign.WithLibraryVersion(Version))
+	if err != nil {
+		return err
+	}
+	opts.Annotations = f.annotations
+	result, err := sign.BundleWithResult(content, keypair, opts)
+	if err != nil {
+		return err
+	}
+	// Annotations and detached SCTs are only written if requested, as
+	// other clients may reject bundles with them
+	bundleJSON, err := sign.MarshalBundleJSON(result.Bundle, &sign.BundleJSONOptions{Annotations: len(f.annotations) > 0, DetachedSCTs: *f.detachedSCTs})
+	if err != nil {
+		return err
+	}
+
+	if *f.bundlePath != "" {
+		if err := os.WriteFile(*f.bundlePath, bundleJSON, 0o600); err != nil {
+			return err
+		}
+	}
+	if *f.jsonOutput {
+		output := newSignOutput(result)
+		if *f.bundlePath != "" {
+			output.BundlePath = *f.bundlePath
+		} else {
+			output.Bundle = bundleJSON
+		}
+		return writeJSON(e.stdout, output)
+	}
+
+	if !result.CertificateNotAfter.IsZero() {
+		fmt.Fprintf(e.stderr, "Signing certificate expires at %s\n", result.CertificateNotAfter.Format(time.RFC3339))
+	}
+	for _, entry := range result.TransparencyLogEntries {
+		fmt.Fprintf(e.stderr, "Transparency log entry %d (%s) integrated at %s\n", entry.LogIndex, entry.UUID, entry.IntegratedTime.Format(time.RFC3339))
+	}
+	for _, ts := range result.Timestamps {
+		fmt.Fprintf(e.stderr, "Signed timestamp at %s\n", ts.GenTime.Format(time.RFC3339))
+	}
+	if *f.bundlePath != "" {
+		fmt.Fprintf(e.stderr, "Bundle written to %s\n", *f.bundlePath)
+		return nil
+	}
+	_, err = fmt.Fprintln(e.stdout, string(bundleJSON))
+	return err
+}
+
+// signOutput is the output of sign and attest with -json.
+type signOutput struct {
+	// BundlePath is where the bundle was written to, with -bundle
+	BundlePath string `json:"bundlePath,omitempty"`
+	// Bundle is the bundle, without -bundle
+	Bundle                 json.RawMessage  `json:"bundle,omitempty"`
+	CertificateNotAfter    *time.Time       `json:"certificateNotAfter,omitempty"`
+	TransparencyLogEntries []logEntryOutput `json:"transparencyLogEntries"`
+	Timestamps             []time.Time      `json:"timestamps"`
+}
+
+type logEntryOutput struct {
+	LogIndex       int64     `json:"logIndex"`
+	LogID          string    `json:"logId"`
+	UUID           string    `json:"uuid,omitempty"`
+	EntryID        string    `json:"entryId,omitempty"`
+	IntegratedTime time.Time `json:"integratedTime"`
+	Kind           string    `json:"kind"`
+	Version        string    `json:"version"`
+}
+
+func newSignOutput(result *sign.BundleResult) *signOutput {
+	output := &signOutput{TransparencyLogEntries: []logEntryOutput{}, Timestamps: []time.Time{}}
+	if !result.CertificateNotAfter.IsZero() {
+		output.CertificateNotAfter = &result.CertificateNotAfter
+	}
+	for _, entry := range result.TransparencyLogEntries {
+		output.TransparencyLogEntries = append(output.TransparencyLogEntries, logEntryOutput{
+			LogIndex:       entry.LogIndex,
+			LogID:          entry.LogID,
+			UUID:           entry.UUID,
+			EntryID:        entry.EntryID,
+			IntegratedTime: entry.IntegratedTime,
+			Kind:           entry.Kind,
+			Version:        entry.Version,
+		})
+	}
+	for _, ts := range result.Timestamps {
+		output.Timestamps = append(output.Timestamps, ts.GenTime)
+	}
+	return output
+}
+
+func readPassphrase() ([]byte, error) {
+	if passphrase, ok := os.LookupEnv("SIGSTORE_KEY_PASSPHRASE"); ok {
+		return []byte(passphrase), nil
+	}
+	fmt.Fprint(os.Stderr, "Enter passphrase for private key: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return passphrase, err
+}
+
+// systemKeyStoreOptions selects a system identity by subject common name,
+// or by certificate fingerprint if the selector starts with "sha256:".
+func systemKeyStoreOptions(selector string) *sign.SystemKeyStoreOptions {
+	if fingerprint, ok := strings.CutPrefix(selector, "sha256:"); ok {
+		return &sign.SystemKeyStoreOptions{CertificateFingerprint: fingerprint}
+	}
+	return &sign.SystemKeyStoreOptions{SubjectCommonName: selector}
+}
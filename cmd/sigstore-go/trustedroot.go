@@ -0,0 +1,166 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+const (
+	trustedRootTarget   = "trusted_root.json"
+	signingConfigTarget = "signing_config.v0.2.json"
+)
+
+// tufFlags select a TUF repository to fetch targets from.
+type tufFlags struct {
+	rootURL     *string
+	trustedRoot *string
+}
+
+func addTUFFlags(fs *flag.FlagSet) *tufFlags {
+	return &tufFlags{
+		rootURL:     fs.String("tufRootURL", "", "URL of TUF root containing trusted root JSON file"),
+		trustedRoot: fs.String("tufTrustedRoot", "", "Path to the trusted TUF root.json to bootstrap trust in the remote TUF repository"),
+	}
+}
+
+// getTarget fetches a target from the TUF repository, which is the public
+// good instance's unless -tufRootURL is set.
+func (f *tufFlags) getTarget(target string) ([]byte, error) {
+	opts := tuf.DefaultOptions()
+	if *f.rootURL != "" {
+		opts.RepositoryBaseURL = *f.rootURL
+	}
+
+	// Load the tuf root.json if provided, if not use public good
+	if *f.trustedRoot != "" {
+		rb, err := os.ReadFile(*f.trustedRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", *f.trustedRoot, err)
+		}
+		opts.Root = rb
+	}
+
+	client, err := tuf.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetTarget(target)
+}
+
+// trustedRootFlags select a trusted root from a file or a TUF repository.
+type trustedRootFlags struct {
+	*tufFlags
+	path *string
+}
+
+func addTrustedRootFlags(fs *flag.FlagSet) *trustedRootFlags {
+	return &trustedRootFlags{
+		tufFlags: addTUFFlags(fs),
+		path:     fs.String("trustedrootJSONpath", "examples/trusted-root-public-good.json", "Path to trustedroot JSON file"),
+	}
+}
+
+// load returns the trusted root from the TUF repository at -tufRootURL, or
+// from -trustedrootJSONpath, or nil if neither is set.
+func (f *trustedRootFlags) load() ([]byte, error) {
+	if *f.rootURL != "" {
+		return f.getTarget(trustedRootTarget)
+	} else if *f.path != "" {
+		trustedRootJSON, err := os.ReadFile(*f.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", *f.path, err)
+		}
+		return trustedRootJSON, nil
+	}
+	return nil, nil
+}
+
+func setupTrustedRootFetch(fs *flag.FlagSet) func(*env, []string) error {
+	tufFlags := addTUFFlags(fs)
+	out := fs.String("out", "", "Path to write the trusted root to, instead of standard output")
+	return func(e *env, args []string) error {
+		if len(args) != 0 {
+			return usageError{"trusted-root fetch takes no arguments"}
+		}
+		trustedRootJSON, err := tufFlags.getTarget(trustedRootTarget)
+		if err != nil {
+			return err
+		}
+		// Check that the trusted root can be used before writing it
+		if _, err := root.NewTrustedRootFromJSON(trustedRootJSON); err != nil {
+			return err
+		}
+		if *out == "" {
+			_, err = e.stdout.Write(trustedRootJSON)
+			return err
+		}
+		if err := os.WriteFile(*out, trustedRootJSON, 0o600); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.stderr, "Trusted root written to %s\n", *out)
+		return nil
+	}
+}
+
+func setupTrustedRootValidate(fs *flag.FlagSet) func(*env, []string) error {
+	trustedRootFlags := addTrustedRootFlags(fs)
+	jsonOutput := fs.Bool("json", false, "Print the problems found as JSON")
+	return func(e *env, args []string) error {
+		if len(args) != 0 {
+			return usageError{"trusted-root validate takes no arguments"}
+		}
+		return validateTrustedRoot(e, trustedRootFlags, *jsonOutput)
+	}
+}
+
+func validateTrustedRoot(e *env, trustedRootFlags *trustedRootFlags, jsonOutput bool) error {
+	trustedRootJSON, err := trustedRootFlags.load()
+	if err != nil {
+		return err
+	}
+	if len(trustedRootJSON) == 0 {
+		return errors.New("no trusted root provided")
+	}
+	trustedRoot, err := root.NewTrustedRootFromJSON(trustedRootJSON)
+	if err != nil {
+		return err
+	}
+
+	findings := root.Validate(trustedRoot)
+	if jsonOutput {
+		if findings == nil {
+			findings = root.ValidationFindings{}
+		}
+		if err := writeJSON(e.stdout, findings); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Fprintln(e.stdout, finding)
+		}
+	}
+	if findings.HasErrors() {
+		return errors.New("trusted root is invalid")
+	}
+	fmt.Fprintf(e.stderr, "Trusted root is valid\n")
+	return nil
+}
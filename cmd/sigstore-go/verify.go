@@ -0,0 +1,241 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+type verifyFlags struct {
+	artifact                *string
+	artifactDigest          *string
+	artifactDigestAlgorithm *string
+	expectedOIDIssuer       *string
+	expectedSAN             *string
+	expectedSANRegex        *string
+	requireTimestamp        *bool
+	requireCTlog            *bool
+	requireTlog             *bool
+	minBundleVersion        *string
+	maxBundleVersion        *string
+	onlineTlog              *bool
+	profile                 *string
+	trustedPublicKey        *string
+	trustedRoot             *trustedRootFlags
+	explain                 *bool
+	jsonOutput              *bool
+	validateTrustedRoot     *bool
+}
+
+func setupVerify(fs *flag.FlagSet) func(*env, []string) error {
+	f := &verifyFlags{
+		artifact:                fs.String("artifact", "", "Path to artifact to verify"),
+		artifactDigest:          fs.String("artifact-digest", "", "Hex-encoded digest of artifact to verify"),
+		artifactDigestAlgorithm: fs.String("artifact-digest-algorithm", "sha256", "Digest algorithm"),
+		expectedOIDIssuer:       fs.String("expectedIssuer", "", "The expected OIDC issuer for the signing certificate"),
+		expectedSAN:             fs.String("expectedSAN", "", "The expected identity in the signing certificate's SAN extension"),
+		expectedSANRegex:        fs.String("expectedSANRegex", "", "The expected identity in the signing certificate's SAN extension"),
+		requireTimestamp:        fs.Bool("requireTimestamp", true, "Require either an RFC3161 signed timestamp or log entry integrated timestamp"),
+		requireCTlog:            fs.Bool("requireCTlog", true, "Require Certificate Transparency log entry"),
+		requireTlog:             fs.Bool("requireTlog", true, "Require Artifact Transparency log entry (Rekor)"),
+		minBundleVersion:        fs.String("minBundleVersion", "", "Minimum acceptable bundle version (e.g. '0.1')"),
+		maxBundleVersion:        fs.String("maxBundleVersion", "", "Maximum acceptable bundle version (e.g. '0.3')"),
+		onlineTlog:              fs.Bool("onlineTlog", false, "Verify Artifact Transparency log entry online (Rekor)"),
+		profile:                 fs.String("profile", "", "Verify as an ecosystem does, instead of with -requireTimestamp, -requireCTlog and -requireTlog, defaulting -expectedIssuer to its issuers: one of "+strings.Join(verify.ProfileNames(), ", ")),
+		trustedPublicKey:        fs.String("publicKey", "", "Path to trusted public key"),
+		trustedRoot:             addTrustedRootFlags(fs),
+		explain:                 fs.Bool("explain", false, "Print a human-readable report of why verification succeeded instead of JSON"),
+		jsonOutput:              fs.Bool("json", false, "Print the verification decision as JSON, including why verification failed"),
+		validateTrustedRoot:     fs.Bool("validateTrustedRoot", false, "Deprecated: use \"sigstore-go trusted-root validate\""),
+	}
+	return func(e *env, args []string) error {
+		if *f.validateTrustedRoot {
+			return validateTrustedRoot(e, f.trustedRoot, *f.jsonOutput)
+		}
+		if len(args) != 1 {
+			return usageError{"verify takes exactly one bundle"}
+		}
+		res, err := f.verify(e, args[0])
+		if *f.jsonOutput {
+			if jsonErr := writeJSON(e.stdout, verify.NewDecision(res, err)); jsonErr != nil {
+				return jsonErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(e.stderr, "Verification successful!\n")
+		switch {
+		case *f.jsonOutput:
+			return nil
+		case *f.explain:
+			fmt.Fprint(e.stdout, res.Explain())
+			return nil
+		default:
+			return writeJSON(e.stdout, res)
+		}
+	}
+}
+
+func (f *verifyFlags) verify(e *env, bundlePath string) (*verify.VerificationResult, error) {
+	b, err := bundle.LoadJSONFromPath(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	verifierConfig := []verify.VerifierOption{}
+	identityPolicies := []verify.PolicyOption{}
+	var artifactPolicy verify.ArtifactPolicyOption
+
+	var profile *verify.Profile
+	if *f.profile != "" {
+		p, err := verify.GetProfile(*f.profile)
+		if err != nil {
+			return nil, usageError{err.Error()}
+		}
+		profile = &p
+		verifierConfig = append(verifierConfig, p.VerifierOptions...)
+	} else {
+		if *f.requireCTlog {
+			verifierConfig = append(verifierConfig, verify.WithSignedCertificateTimestamps(1))
+		}
+
+		if *f.requireTimestamp {
+			verifierConfig = append(verifierConfig, verify.WithObserverTimestamps(1))
+		}
+
+		if *f.requireTlog {
+			verifierConfig = append(verifierConfig, verify.WithTransparencyLog(1))
+		}
+	}
+
+	if *f.onlineTlog {
+		verifierConfig = append(verifierConfig, verify.WithOnlineVerification())
+	}
+
+	if *f.minBundleVersion != "" {
+		verifierConfig = append(verifierConfig, verify.WithMinBundleVersion(*f.minBundleVersion))
+	}
+
+	if *f.maxBundleVersion != "" {
+		verifierConfig = append(verifierConfig, verify.WithMaxBundleVersion(*f.maxBundleVersion))
+	}
+
+	if profile != nil && *f.expectedOIDIssuer == "" && len(profile.Issuers) > 0 {
+		identityPolicies, err = profile.PolicyOptions(*f.expectedSAN, *f.expectedSANRegex)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		certID, err := verify.NewShortCertificateIdentity(*f.expectedOIDIssuer, *f.expectedSAN, "", *f.expectedSANRegex)
+		if err != nil {
+			return nil, err
+		}
+		identityPolicies = append(identityPolicies, verify.WithCertificateIdentity(certID))
+	}
+
+	var trustedMaterial = make(root.TrustedMaterialCollection, 0)
+	trustedRootJSON, err := f.trustedRoot.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(trustedRootJSON) > 0 {
+		var trustedRoot *root.TrustedRoot
+		trustedRoot, err = root.NewTrustedRootFromJSON(trustedRootJSON)
+		if err != nil {
+			return nil, err
+		}
+		trustedMaterial = append(trustedMaterial, trustedRoot)
+	}
+	if *f.trustedPublicKey != "" {
+		pemBytes, err := os.ReadFile(*f.trustedPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		pemBlock, _ := pem.Decode(pemBytes)
+		if pemBlock == nil {
+			return nil, errors.New("failed to decode pem block")
+		}
+		pubKey, err := x509.ParsePKIXPublicKey(pemBlock.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		trustedMaterial = append(trustedMaterial, trustedPublicKeyMaterial(pubKey))
+	}
+
+	if len(trustedMaterial) == 0 {
+		return nil, errors.New("no trusted material provided")
+	}
+
+	sev, err := verify.NewSignedEntityVerifier(trustedMaterial, verifierConfig...)
+	if err != nil {
+		return nil, err
+	}
+
+	if *f.artifactDigest != "" { //nolint:gocritic
+		artifactDigestBytes, err := hex.DecodeString(*f.artifactDigest)
+		if err != nil {
+			return nil, err
+		}
+		artifactPolicy = verify.WithArtifactDigest(*f.artifactDigestAlgorithm, artifactDigestBytes)
+	} else if *f.artifact != "" {
+		file, err := os.Open(*f.artifact)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		artifactPolicy = verify.WithArtifact(file)
+	} else {
+		artifactPolicy = verify.WithoutArtifactUnsafe()
+		fmt.Fprintf(e.stderr, "No artifact provided, skipping artifact verification. This is unsafe!\n")
+	}
+
+	return sev.Verify(b, verify.NewPolicy(artifactPolicy, identityPolicies...))
+}
+
+type nonExpiringVerifier struct {
+	signature.Verifier
+}
+
+func (*nonExpiringVerifier) ValidAtTime(_ time.Time) bool {
+	return true
+}
+
+func trustedPublicKeyMaterial(pk crypto.PublicKey) *root.TrustedPublicKeyMaterial {
+	return root.NewTrustedPublicKeyMaterial(func(string) (root.TimeConstrainedVerifier, error) {
+		verifier, err := signature.LoadECDSAVerifier(pk.(*ecdsa.PublicKey), crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		return &nonExpiringVerifier{verifier}, nil
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sigstore-verifier is a reference HTTP service that verifies Sigstore
+// bundles, so that verification can be deployed as a sidecar by teams that
+// do not write Go. See docs/verifier-service.md for its API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/metrics"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+var (
+	addr                = flag.String("addr", ":8080", "Address to listen on")
+	requireTimestamp    = flag.Bool("requireTimestamp", true, "Require either an RFC3161 signed timestamp or log entry integrated timestamp")
+	requireCTlog        = flag.Bool("requireCTlog", true, "Require Certificate Transparency log entry")
+	requireTlog         = flag.Bool("requireTlog", true, "Require Artifact Transparency log entry (Rekor)")
+	trustedrootJSONpath = flag.String("trustedrootJSONpath", "", "Path to a trustedroot JSON file to use instead of fetching it from TUF; it is not refreshed")
+	tufRootURL          = flag.String("tufRootURL", "", "URL of TUF root containing trusted root JSON file (defaults to the public good instance)")
+	tufTrustedRoot      = flag.String("tufTrustedRoot", "", "Path to the trusted TUF root.json to bootstrap trust in the remote TUF repository")
+	tufCachePath        = flag.String("tufCachePath", "", "Directory to cache TUF metadata in (defaults to $HOME/.sigstore/tuf)")
+	refreshInterval     = flag.Duration("refreshInterval", root.DefaultLiveTrustedRootRefreshInterval, "How often to refresh the trusted root from TUF")
+	maxTrustedRootAge   = flag.Duration("maxTrustedRootAge", 3*root.DefaultLiveTrustedRootRefreshInterval, "Report the service as unhealthy once the trusted root has not been refreshed for this long")
+	maxRequestBytes     = flag.Int64("maxRequestBytes", 32<<20, "Maximum size of a verification request")
+)
+
+func main() {
+	flag.Parse()
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	if err := run(logger); err != nil {
+		logger.Error("verifier service failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(logger *slog.Logger) error {
+	trustedMaterial, health, closeTrustedRoot, err := loadTrustedMaterial(logger)
+	if err != nil {
+		return err
+	}
+	defer closeTrustedRoot()
+
+	registry := metrics.NewRegistry()
+	verifierConfig := []verify.VerifierOption{verify.WithMetrics(registry)}
+	if *requireCTlog {
+		verifierConfig = append(verifierConfig, verify.WithSignedCertificateTimestamps(1))
+	}
+	if *requireTimestamp {
+		verifierConfig = append(verifierConfig, verify.WithObserverTimestamps(1))
+	}
+	if *requireTlog {
+		verifierConfig = append(verifierConfig, verify.WithTransparencyLog(1))
+	}
+	sev, err := verify.NewSignedEntityVerifier(trustedMaterial, verifierConfig...)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(sev, registry, health, *maxRequestBytes, logger),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", *addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// loadTrustedMaterial returns the trusted root to verify against, a health
+// check reporting whether it is still being refreshed, and a function that
+// stops refreshing it.
+func loadTrustedMaterial(logger *slog.Logger) (root.TrustedMaterial, func() error, func(), error) {
+	if *trustedrootJSONpath != "" {
+		trustedRoot, err := root.NewTrustedRootFromPath(*trustedrootJSONpath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return trustedRoot, func() error { return nil }, func() {}, nil
+	}
+
+	opts := tuf.DefaultOptions().WithLogger(logger)
+	if *tufRootURL != "" {
+		opts.RepositoryBaseURL = *tufRootURL
+	}
+	if *tufTrustedRoot != "" {
+		rb, err := os.ReadFile(*tufTrustedRoot)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read %s: %w", *tufTrustedRoot, err)
+		}
+		opts.Root = rb
+	}
+	if *tufCachePath != "" {
+		opts.CachePath = *tufCachePath
+	}
+
+	live, err := root.NewLiveTrustedRootWithOptions(opts, root.LiveTrustedRootOptions{
+		RefreshInterval: *refreshInterval,
+		Jitter:          0.1,
+		Logger:          logger,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	health := func() error {
+		h := live.Health()
+		if !h.Stale(time.Now(), *maxTrustedRootAge) {
+			return nil
+		}
+		if h.LastError != nil {
+			return fmt.Errorf("trusted root last refreshed at %s: %w", h.LastRefresh.Format(time.RFC3339), h.LastError)
+		}
+		return fmt.Errorf("trusted root last refreshed at %s", h.LastRefresh.Format(time.RFC3339))
+	}
+	closeTrustedRoot := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = live.Close(ctx)
+	}
+	return live, health, closeTrustedRoot, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/metrics"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// verifyRequest is the body of a POST /verify request.
+type verifyRequest struct {
+	// Bundle is the Sigstore bundle, in its protobuf JSON encoding
+	Bundle json.RawMessage `json:"bundle"`
+	// Artifact is the signed artifact; either it or ArtifactDigest must be
+	// set
+	Artifact []byte `json:"artifact,omitempty"`
+	// ArtifactDigest is the digest of the signed artifact
+	ArtifactDigest *artifactDigest `json:"artifactDigest,omitempty"`
+	// Policy is the identity the bundle must be signed by
+	Policy identityPolicy `json:"policy"`
+}
+
+type artifactDigest struct {
+	Algorithm string `json:"algorithm"`
+	// Digest is hex encoded
+	Digest string `json:"digest"`
+}
+
+type identityPolicy struct {
+	Issuer   string `json:"issuer"`
+	SAN      string `json:"san,omitempty"`
+	SANType  string `json:"sanType,omitempty"`
+	SANRegex string `json:"sanRegex,omitempty"`
+}
+
+// verifyResponse is the body of a POST /verify response.
+type verifyResponse struct {
+	Verified   bool                       `json:"verified"`
+	Result     *verify.VerificationResult `json:"result,omitempty"`
+	Error      string                     `json:"error,omitempty"`
+	ErrorClass string                     `json:"errorClass,omitempty"`
+}
+
+type server struct {
+	verifier        *verify.SignedEntityVerifier
+	health          func() error
+	maxRequestBytes int64
+	logger          *slog.Logger
+	registry        *metrics.Registry
+	requests        metrics.Counter
+	duration        metrics.Histogram
+	healthy         metrics.Gauge
+}
+
+// newServer returns the handler of the verifier service. health reports
+// whether the trusted material is still up to date. The service's metrics
+// are recorded in registry, which the verifier may also record into.
+func newServer(verifier *verify.SignedEntityVerifier, registry *metrics.Registry, health func() error, maxRequestBytes int64, logger *slog.Logger) http.Handler {
+	s := &server{
+		verifier:        verifier,
+		health:          health,
+		maxRequestBytes: maxRequestBytes,
+		logger:          logger,
+		registry:        registry,
+		requests: registry.Counter("sigstore_verifier_requests_total",
+			"Verification requests by outcome.", "outcome"),
+		duration: registry.Histogram("sigstore_verifier_verification_duration_seconds",
+			"Time spent verifying bundles.", metrics.DurationBuckets),
+		healthy: registry.Gauge("sigstore_verifier_trusted_root_healthy",
+			"Whether the trusted root is up to date."),
+	}
+	s.requests.Add(0, outcomeVerified)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/verify", s.handleVerify)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+func (s *server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	start := time.Now()
+
+	var req verifyRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		s.badRequest(w, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	b, policy, err := req.parse()
+	if err != nil {
+		s.badRequest(w, err)
+		return
+	}
+
+	result, err := s.verifier.Verify(b, policy)
+	s.requests.Add(1, outcome(err))
+	s.duration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		class := verify.ClassifyError(err)
+		s.logger.Info("verification failed", "error", err, "errorClass", class.String())
+		writeJSON(w, http.StatusUnprocessableEntity, verifyResponse{Error: err.Error(), ErrorClass: class.String()})
+		return
+	}
+	writeJSON(w, http.StatusOK, verifyResponse{Verified: true, Result: result})
+}
+
+func (req *verifyRequest) parse() (*bundle.ProtobufBundle, verify.PolicyBuilder, error) {
+	if len(req.Bundle) == 0 {
+		return nil, verify.PolicyBuilder{}, errors.New("bundle is required")
+	}
+	b := &bundle.ProtobufBundle{}
+	if err := b.UnmarshalJSON(req.Bundle); err != nil {
+		return nil, verify.PolicyBuilder{}, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	var artifactPolicy verify.ArtifactPolicyOption
+	switch {
+	case req.Artifact != nil && req.ArtifactDigest != nil:
+		return nil, verify.PolicyBuilder{}, errors.New("only one of artifact and artifactDigest may be set")
+	case req.Artifact != nil:
+		artifactPolicy = verify.WithArtifact(bytes.NewReader(req.Artifact))
+	case req.ArtifactDigest != nil:
+		digest, err := hex.DecodeString(req.ArtifactDigest.Digest)
+		if err != nil {
+			return nil, verify.PolicyBuilder{}, fmt.Errorf("invalid artifact digest: %w", err)
+		}
+		artifactPolicy = verify.WithArtifactDigest(req.ArtifactDigest.Algorithm, digest)
+	default:
+		return nil, verify.PolicyBuilder{}, errors.New("one of artifact and artifactDigest is required")
+	}
+
+	certID, err := verify.NewShortCertificateIdentity(req.Policy.Issuer, req.Policy.SAN, req.Policy.SANType, req.Policy.SANRegex)
+	if err != nil {
+		return nil, verify.PolicyBuilder{}, fmt.Errorf("invalid policy: %w", err)
+	}
+	return b, verify.NewPolicy(artifactPolicy, verify.WithCertificateIdentity(certID)), nil
+}
+
+func (s *server) badRequest(w http.ResponseWriter, err error) {
+	s.requests.Add(1, outcomeBadRequest)
+	status := http.StatusBadRequest
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	writeJSON(w, status, verifyResponse{Error: err.Error()})
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	if err := s.health(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	healthy := 0.0
+	if s.health() == nil {
+		healthy = 1
+	}
+	s.healthy.Set(healthy)
+	s.registry.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+const (
+	outcomeVerified   = "verified"
+	outcomeBadRequest = "bad_request"
+)
+
+// outcome returns the metrics label of a verification result.
+func outcome(err error) string {
+	switch verify.ClassifyError(err) {
+	case verify.ErrorClassUntrustedMaterial:
+		return "untrusted_material"
+	case verify.ErrorClassCryptographicFailure:
+		return "cryptographic_failure"
+	case verify.ErrorClassPolicyNotSatisfied:
+		return "policy_not_satisfied"
+	}
+	if err == nil {
+		return outcomeVerified
+	}
+	return "error"
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/metrics"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const provenanceDigest = "76176ffa33808b54602c7c35de5c6e9a4deb96066dba6533f50ac234f4f1f4c6b3527515dc17c06fbe2860030f410eee69ea20079bd3a2c6f3dcf3b329b10751"
+
+func newTestServer(t *testing.T, health func() error) *httptest.Server {
+	trustedRoot, err := root.NewTrustedRootFromPath("../../examples/trusted-root-public-good.json")
+	require.NoError(t, err)
+	registry := metrics.NewRegistry()
+	sev, err := verify.NewSignedEntityVerifier(trustedRoot, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithSignedCertificateTimestamps(1), verify.WithMetrics(registry))
+	require.NoError(t, err)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ts := httptest.NewServer(newServer(sev, registry, health, 1<<20, logger))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postVerify(t *testing.T, ts *httptest.Server, req any) (int, verifyResponse) {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	resp, err := http.Post(ts.URL+"/verify", "application/json", bytes.NewReader(body)) //nolint:noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var verifyResp verifyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&verifyResp))
+	return resp.StatusCode, verifyResp
+}
+
+func TestVerify(t *testing.T) {
+	ts := newTestServer(t, func() error { return nil })
+	bundleJSON, err := os.ReadFile("../../examples/bundle-provenance.json")
+	require.NoError(t, err)
+
+	req := verifyRequest{
+		Bundle:         bundleJSON,
+		ArtifactDigest: &artifactDigest{Algorithm: "sha512", Digest: provenanceDigest},
+		Policy: identityPolicy{
+			Issuer:   "https://token.actions.githubusercontent.com",
+			SANRegex: "^https://github.com/sigstore/sigstore-js/",
+		},
+	}
+	status, resp := postVerify(t, ts, req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, resp.Verified)
+	require.NotNil(t, resp.Result)
+	assert.Equal(t, "https://slsa.dev/provenance/v0.2", resp.Result.Statement.PredicateType)
+
+	req.Policy.SANRegex = "^https://github.com/other/"
+	status, resp = postVerify(t, ts, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, verify.ErrorClassPolicyNotSatisfied.String(), resp.ErrorClass)
+
+	req.ArtifactDigest = nil
+	status, resp = postVerify(t, ts, req)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "one of artifact and artifactDigest is required", resp.Error)
+
+	metricsResp, err := http.Get(ts.URL + "/metrics") //nolint:noctx
+	require.NoError(t, err)
+	defer metricsResp.Body.Close()
+	metricsBody, err := io.ReadAll(metricsResp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(metricsBody), `sigstore_verifier_requests_total{outcome="verified"} 1`)
+	assert.Contains(t, string(metricsBody), `sigstore_verifier_requests_total{outcome="policy_not_satisfied"} 1`)
+	assert.Contains(t, string(metricsBody), `sigstore_verifier_requests_total{outcome="bad_request"} 1`)
+	assert.Contains(t, string(metricsBody), "sigstore_verifier_verification_duration_seconds_count 2")
+	assert.Contains(t, string(metricsBody), "sigstore_verifier_trusted_root_healthy 1")
+	assert.Contains(t, string(metricsBody), `sigstore_verifications_total{outcome="verified",policy="certificate_identity"} 1`)
+	assert.Contains(t, string(metricsBody), `sigstore_verifications_by_log_total{outcome="verified",log="https://rekor.sigstore.dev"} 1`)
+	assert.Contains(t, string(metricsBody), `sigstore_verification_phase_duration_seconds_count{phase="signature"} 2`)
+}
+
+func TestHealth(t *testing.T) {
+	var stale atomic.Bool
+	ts := newTestServer(t, func() error {
+		if stale.Load() {
+			return errors.New("trusted root is stale")
+		}
+		return nil
+	})
+
+	resp, err := http.Get(ts.URL + "/healthz") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	stale.Store(true)
+	resp, err = http.Get(ts.URL + "/healthz") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp, err = http.Get(ts.URL + "/verify") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
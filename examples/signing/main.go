@@ -21,7 +21,7 @@ import (
 	"os"
 	"time"
 
-	"google.golang.org/protobuf/encoding/protojson"
+	"golang.org/x/term"
 
 	"github.com/sigstore/sigstore-go/pkg/sign"
 )
@@ -31,12 +31,14 @@ var idToken *string
 var intoto *bool
 var tsa *bool
 var rekor *bool
+var keyPath *string
 
 func init() {
 	idToken = flag.String("id-token", "", "OIDC token to send to Fulcio")
 	intoto = flag.Bool("in-toto", false, "Content to sign is in-toto document")
 	tsa = flag.Bool("tsa", false, "Include signed timestamp from timestamp authority")
 	rekor = flag.Bool("rekor", false, "Including transparency log entry from Rekor")
+	keyPath = flag.String("key", "", "Path to a PEM-encoded private key to sign with, instead of an ephemeral key; encrypted keys are decrypted with $SIGSTORE_KEY_PASSPHRASE or a prompted passphrase")
 	flag.Parse()
 	if flag.NArg() == 0 {
 		usage()
@@ -68,7 +70,12 @@ func main() {
 		}
 	}
 
-	keypair, err := sign.NewEphemeralKeypair(nil)
+	var keypair sign.Keypair
+	if *keyPath != "" {
+		keypair, err = sign.LoadPrivateKeyKeypairFromPath(*keyPath, readPassphrase, nil)
+	} else {
+		keypair, err = sign.NewEphemeralKeypair(nil)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -81,42 +88,64 @@ func main() {
 	opts := sign.BundleOptions{}
 
 	if *idToken != "" {
-		fulcioOpts := &sign.FulcioOptions{
-			BaseURL:        "https://fulcio.sigstage.dev",
-			Timeout:        time.Duration(30 * time.Second),
-			LibraryVersion: Version,
+		fulcio, err := sign.NewFulcioClient("https://fulcio.sigstage.dev",
+			sign.WithTimeout(30*time.Second),
+			sign.WithLibraryVersion(Version))
+		if err != nil {
+			log.Fatal(err)
 		}
-		opts.Fulcio = sign.NewFulcio(fulcioOpts)
+		opts.Fulcio = fulcio
 		opts.IDToken = *idToken
 	}
 
 	if *tsa {
-		tsaOpts := &sign.TimestampAuthorityOptions{
-			BaseURL:        "https://timestamp.githubapp.com",
-			Timeout:        time.Duration(30 * time.Second),
-			LibraryVersion: Version,
+		tsa, err := sign.NewTimestampAuthorityClient("https://timestamp.githubapp.com",
+			sign.WithTimeout(30*time.Second),
+			sign.WithLibraryVersion(Version))
+		if err != nil {
+			log.Fatal(err)
 		}
-		opts.TimestampAuthorities = append(opts.TimestampAuthorities, sign.NewTimestampAuthority(tsaOpts))
+		opts.TimestampAuthorities = append(opts.TimestampAuthorities, tsa)
 	}
 
 	if *rekor {
-		rekorOpts := &sign.RekorOptions{
-			BaseURL:        "https://rekor.sigstage.dev",
-			Timeout:        time.Duration(90 * time.Second),
-			LibraryVersion: Version,
+		rekor, err := sign.NewRekorClient("https://rekor.sigstage.dev",
+			sign.WithTimeout(90*time.Second),
+			sign.WithLibraryVersion(Version))
+		if err != nil {
+			log.Fatal(err)
 		}
-		opts.Rekors = append(opts.Rekors, sign.NewRekor(rekorOpts))
+		opts.Rekors = append(opts.Rekors, rekor)
 	}
 
-	bundle, err := sign.Bundle(content, keypair, opts)
+	result, err := sign.BundleWithResult(content, keypair, opts)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if !result.CertificateNotAfter.IsZero() {
+		log.Printf("Signing certificate expires at %s", result.CertificateNotAfter.Format(time.RFC3339))
+	}
+	for _, entry := range result.TransparencyLogEntries {
+		log.Printf("Transparency log entry %d (%s) integrated at %s", entry.LogIndex, entry.UUID, entry.IntegratedTime.Format(time.RFC3339))
+	}
+	for _, ts := range result.Timestamps {
+		log.Printf("Signed timestamp at %s", ts.GenTime.Format(time.RFC3339))
+	}
 
-	bundleJSON, err := protojson.Marshal(bundle)
+	bundleJSON, err := sign.MarshalBundleJSON(result.Bundle, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fmt.Println(string(bundleJSON))
 }
+
+func readPassphrase() ([]byte, error) {
+	if passphrase, ok := os.LookupEnv("SIGSTORE_KEY_PASSPHRASE"); ok {
+		return []byte(passphrase), nil
+	}
+	fmt.Fprint(os.Stderr, "Enter passphrase for private key: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return passphrase, err
+}
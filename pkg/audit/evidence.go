@@ -0,0 +1,248 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the evidence gathered when verifying a bundle online
+// in an evidence packet, which can be stored as an auditable record of the
+// verification and re-verified offline later.
+package audit
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	rekorClient "github.com/sigstore/rekor/pkg/client"
+	rekorEntries "github.com/sigstore/rekor/pkg/generated/client/entries"
+	"github.com/sigstore/rekor/pkg/generated/models"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const EvidencePacketMediaType01 = "application/vnd.dev.sigstore.evidencepacket.v0.1+json"
+
+// EvidencePacket is the evidence of a bundle's verification: the bundle,
+// the log entries retrieved from each transparency log with their signed
+// entry timestamps, inclusion proofs and checkpoints, and the result of the
+// verification, which includes the verified timestamps.
+type EvidencePacket struct {
+	MediaType string `json:"mediaType"`
+	// Bundle is the JSON encoding of the verified bundle
+	Bundle     json.RawMessage  `json:"bundle"`
+	LogEntries []LogEntryRecord `json:"logEntries"`
+	// Result is the JSON encoding of the verification result, as it was
+	// when the packet was collected
+	Result      json.RawMessage `json:"result"`
+	CollectedAt time.Time       `json:"collectedAt"`
+}
+
+// LogEntryRecord is a log entry of the bundle as returned by its log.
+type LogEntryRecord struct {
+	LogURL string `json:"logUrl"`
+	UUID   string `json:"uuid"`
+	// Response is the log entry returned by the log's API
+	Response    models.LogEntryAnon `json:"response"`
+	RetrievedAt time.Time           `json:"retrievedAt"`
+}
+
+// LogEntryFetcher retrieves log entries from a transparency log.
+type LogEntryFetcher interface {
+	// GetLogEntryByIndex returns the UUID and the entry at the index
+	GetLogEntryByIndex(ctx context.Context, logIndex int64) (string, models.LogEntryAnon, error)
+}
+
+// CollectOptions configures Collect.
+type CollectOptions struct {
+	// Optional context for requests to transparency logs
+	Context context.Context
+	// Optional function returning the fetcher for a log. Defaults to a
+	// Rekor client for the log's base URL.
+	Fetcher func(log *root.TransparencyLog) (LogEntryFetcher, error)
+}
+
+// Collect verifies the bundle, then retrieves each of its log entries from
+// its transparency log and checks them, returning the evidence of both.
+// The trusted material must be the verifier's; the verifier may be online
+// or offline, as the log entries are retrieved regardless.
+func Collect(b *bundle.ProtobufBundle, trustedMaterial root.TrustedMaterial, verifier *verify.SignedEntityVerifier, policy verify.PolicyBuilder, opts *CollectOptions) (*EvidencePacket, *verify.VerificationResult, error) {
+	if opts == nil {
+		opts = &CollectOptions{}
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	newFetcher := opts.Fetcher
+	if newFetcher == nil {
+		newFetcher = newRekorFetcher
+	}
+
+	result, err := verifier.Verify(b, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := b.TlogEntries()
+	if err != nil {
+		return nil, nil, err
+	}
+	packet := &EvidencePacket{MediaType: EvidencePacketMediaType01}
+	for _, entry := range entries {
+		log, err := root.FindTransparencyLog(trustedMaterial.RekorLogs(), hex.EncodeToString([]byte(entry.LogKeyID())), entry.IntegratedTime())
+		if err != nil {
+			return nil, nil, err
+		}
+		fetcher, err := newFetcher(log)
+		if err != nil {
+			return nil, nil, err
+		}
+		uuid, response, err := fetcher.GetLogEntryByIndex(ctx, entry.LogIndex())
+		if err != nil {
+			return nil, nil, fmt.Errorf("retrieving log entry %d from %s: %w", entry.LogIndex(), log.BaseURL, err)
+		}
+		record := LogEntryRecord{LogURL: log.BaseURL, UUID: uuid, Response: response, RetrievedAt: time.Now().UTC()}
+		if err := checkRecord(record, entry, trustedMaterial); err != nil {
+			return nil, nil, err
+		}
+		packet.LogEntries = append(packet.LogEntries, record)
+	}
+
+	// Detached SCTs are kept so that the bundle can be verified again
+	if packet.Bundle, err = b.MarshalJSONWithDetachedSCTs(); err != nil {
+		return nil, nil, err
+	}
+	if packet.Result, err = json.Marshal(result); err != nil {
+		return nil, nil, err
+	}
+	packet.CollectedAt = time.Now().UTC()
+	return packet, result, nil
+}
+
+// Reverify verifies an evidence packet offline: each of the bundle's log
+// entries must have a record whose signed entry timestamp or inclusion
+// proof verifies with the trusted material, and the bundle must verify
+// with the verifier, which should be offline, and the policy.
+func Reverify(packet *EvidencePacket, trustedMaterial root.TrustedMaterial, verifier *verify.SignedEntityVerifier, policy verify.PolicyBuilder) (*verify.VerificationResult, error) {
+	if packet.MediaType != EvidencePacketMediaType01 {
+		return nil, fmt.Errorf("unsupported evidence packet media type %q", packet.MediaType)
+	}
+	var b bundle.ProtobufBundle
+	if err := b.UnmarshalJSON(packet.Bundle); err != nil {
+		return nil, fmt.Errorf("parsing bundle: %w", err)
+	}
+
+	entries, err := b.TlogEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		var checked bool
+		var errs []error
+		for _, record := range packet.LogEntries {
+			if record.Response.LogIndex == nil || *record.Response.LogIndex != entry.LogIndex() {
+				continue
+			}
+			if err := checkRecord(record, entry, trustedMaterial); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			checked = true
+			break
+		}
+		if !checked {
+			return nil, fmt.Errorf("no valid record of log entry %d: %w", entry.LogIndex(), errors.Join(errs...))
+		}
+	}
+
+	return verifier.Verify(&b, policy)
+}
+
+// checkRecord checks that a record is the bundle's log entry, and that the
+// log signed it.
+func checkRecord(record LogEntryRecord, bundleEntry *tlog.Entry, trustedMaterial root.TrustedMaterial) error {
+	entry, err := tlog.NewEntryFromLogEntry(record.UUID, record.Response)
+	if err != nil {
+		return fmt.Errorf("invalid log entry record: %w", err)
+	}
+	if entry.LogKeyID() != bundleEntry.LogKeyID() || entry.LogIndex() != bundleEntry.LogIndex() {
+		return fmt.Errorf("log entry record %s is not log entry %d of the bundle", record.UUID, bundleEntry.LogIndex())
+	}
+	body, err := entry.CanonicalizedBody()
+	if err != nil {
+		return err
+	}
+	bundleBody, err := bundleEntry.CanonicalizedBody()
+	if err != nil {
+		return err
+	}
+	if string(body) != string(bundleBody) {
+		return fmt.Errorf("log entry record %s does not match the bundle's log entry body", record.UUID)
+	}
+
+	log, err := root.FindTransparencyLog(trustedMaterial.RekorLogs(), hex.EncodeToString([]byte(entry.LogKeyID())), entry.IntegratedTime())
+	if err != nil {
+		return err
+	}
+	if !entry.HasInclusionPromise() && !entry.HasInclusionProof() {
+		return fmt.Errorf("log entry record %s has neither a signed entry timestamp nor an inclusion proof", record.UUID)
+	}
+	if entry.HasInclusionPromise() {
+		if err := tlog.VerifySignedEntryTimestamp(entry, log.PublicKey); err != nil {
+			return fmt.Errorf("log entry record %s: %w", record.UUID, err)
+		}
+	}
+	if _, _, err := entry.InclusionProof(); err == nil {
+		logVerifier, err := log.Verifier()
+		if err != nil {
+			return err
+		}
+		if err := tlog.VerifyInclusion(entry, logVerifier); err != nil {
+			return fmt.Errorf("log entry record %s: %w", record.UUID, err)
+		}
+	}
+	return nil
+}
+
+type rekorFetcher struct {
+	client rekorEntries.ClientService
+}
+
+func newRekorFetcher(log *root.TransparencyLog) (LogEntryFetcher, error) {
+	client, err := rekorClient.GetRekorClient(log.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &rekorFetcher{client: client.Entries}, nil
+}
+
+func (f *rekorFetcher) GetLogEntryByIndex(ctx context.Context, logIndex int64) (string, models.LogEntryAnon, error) {
+	params := rekorEntries.NewGetLogEntryByIndexParamsWithContext(ctx)
+	params.SetLogIndex(logIndex)
+	resp, err := f.client.GetLogEntryByIndex(params)
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+	if len(resp.Payload) != 1 {
+		return "", models.LogEntryAnon{}, fmt.Errorf("expected one log entry, got %d", len(resp.Payload))
+	}
+	for uuid, entry := range resp.Payload {
+		return uuid, entry, nil
+	}
+	return "", models.LogEntryAnon{}, nil
+}
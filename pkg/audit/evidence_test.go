@@ -0,0 +1,147 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/transparency-dev/merkle/rfc6962"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// fakeFetcher returns a log's response for the log entries of a bundle.
+type fakeFetcher struct {
+	entries map[int64]models.LogEntryAnon
+	err     error
+}
+
+func (f *fakeFetcher) GetLogEntryByIndex(_ context.Context, logIndex int64) (string, models.LogEntryAnon, error) {
+	if f.err != nil {
+		return "", models.LogEntryAnon{}, f.err
+	}
+	entry, ok := f.entries[logIndex]
+	if !ok {
+		return "", models.LogEntryAnon{}, errors.New("not found")
+	}
+	body, _ := base64.StdEncoding.DecodeString(entry.Body.(string))
+	return hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(body)), entry, nil
+}
+
+func testPolicy(t *testing.T) verify.PolicyBuilder {
+	digest, err := hex.DecodeString("46d4e2f74c4877316640000a6fdf8a8b59f1e0847667973e9859f774dd31b8f1e0937813b777fb66a2ac67d50540fe34640966eee9fc2ccca387082b4c85cd3c")
+	require.NoError(t, err)
+	certID, err := verify.NewShortCertificateIdentity("https://token.actions.githubusercontent.com", "", "", "^https://github.com/sigstore/sigstore-js/")
+	require.NoError(t, err)
+	return verify.NewPolicy(verify.WithArtifactDigest("sha512", digest), verify.WithCertificateIdentity(certID))
+}
+
+func TestCollectAndReverify(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	b := data.SigstoreJS200ProvenanceBundle(t)
+	verifier, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+
+	// The log's response for the bundle's entry is the entry itself
+	fetcher := &fakeFetcher{entries: map[int64]models.LogEntryAnon{}}
+	for _, tle := range b.VerificationMaterial.TlogEntries {
+		proof := tle.InclusionProof
+		var hashes []string
+		for _, h := range proof.Hashes {
+			hashes = append(hashes, hex.EncodeToString(h))
+		}
+		fetcher.entries[tle.LogIndex] = models.LogEntryAnon{
+			Body:           base64.StdEncoding.EncodeToString(tle.CanonicalizedBody),
+			IntegratedTime: swag.Int64(tle.IntegratedTime),
+			LogIndex:       swag.Int64(tle.LogIndex),
+			LogID:          swag.String(hex.EncodeToString(tle.LogId.KeyId)),
+			Verification: &models.LogEntryAnonVerification{
+				SignedEntryTimestamp: tle.InclusionPromise.SignedEntryTimestamp,
+				InclusionProof: &models.InclusionProof{
+					LogIndex:   swag.Int64(proof.LogIndex),
+					TreeSize:   swag.Int64(proof.TreeSize),
+					RootHash:   swag.String(hex.EncodeToString(proof.RootHash)),
+					Hashes:     hashes,
+					Checkpoint: swag.String(proof.Checkpoint.Envelope),
+				},
+			},
+		}
+	}
+	opts := &CollectOptions{Fetcher: func(*root.TransparencyLog) (LogEntryFetcher, error) { return fetcher, nil }}
+
+	packet, result, err := Collect(b, tr, verifier, testPolicy(t), opts)
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+	require.Len(t, packet.LogEntries, 1)
+	assert.Equal(t, "https://rekor.sigstore.dev", packet.LogEntries[0].LogURL)
+
+	// The packet survives serialization
+	packetJSON, err := json.Marshal(packet)
+	require.NoError(t, err)
+	var stored EvidencePacket
+	require.NoError(t, json.Unmarshal(packetJSON, &stored))
+
+	reverified, err := Reverify(&stored, tr, verifier, testPolicy(t))
+	require.NoError(t, err)
+	assert.Equal(t, result.Signature, reverified.Signature)
+
+	t.Run("tampered signed entry timestamp", func(t *testing.T) {
+		var tampered EvidencePacket
+		require.NoError(t, json.Unmarshal(packetJSON, &tampered))
+		set := tampered.LogEntries[0].Response.Verification.SignedEntryTimestamp
+		set[len(set)-1] ^= 1
+		_, err := Reverify(&tampered, tr, verifier, testPolicy(t))
+		assert.Error(t, err)
+	})
+
+	t.Run("missing record", func(t *testing.T) {
+		var tampered EvidencePacket
+		require.NoError(t, json.Unmarshal(packetJSON, &tampered))
+		tampered.LogEntries = nil
+		_, err := Reverify(&tampered, tr, verifier, testPolicy(t))
+		assert.ErrorContains(t, err, "no valid record")
+	})
+
+	t.Run("record of another entry", func(t *testing.T) {
+		var tampered EvidencePacket
+		require.NoError(t, json.Unmarshal(packetJSON, &tampered))
+		tampered.LogEntries[0].Response.Body = base64.StdEncoding.EncodeToString([]byte("{}"))
+		_, err := Reverify(&tampered, tr, verifier, testPolicy(t))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		_, err := Reverify(&EvidencePacket{MediaType: "application/json"}, tr, verifier, testPolicy(t))
+		assert.Error(t, err)
+	})
+
+	t.Run("log unavailable", func(t *testing.T) {
+		_, _, err := Collect(b, tr, verifier, testPolicy(t), &CollectOptions{Fetcher: func(*root.TransparencyLog) (LogEntryFetcher, error) {
+			return &fakeFetcher{err: errors.New("unavailable")}, nil
+		}})
+		assert.ErrorContains(t, err, "unavailable")
+	})
+}
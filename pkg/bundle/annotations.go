@@ -0,0 +1,187 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// annotationsJSONMember is the member of a bundle's JSON encoding that
+// holds its annotations, next to the members defined by the bundle format.
+const annotationsJSONMember = "annotations"
+
+// annotationFieldNumber is the field of the Bundle message that holds
+// annotations. The bundle format has no field for application-defined
+// metadata, so each annotation is stored as an extension field; see
+// SetExtensionFields.
+const annotationFieldNumber protowire.Number = 1001
+
+const (
+	annotationKeyField   protowire.Number = 1
+	annotationValueField protowire.Number = 2
+)
+
+// SetAnnotations replaces the annotations of a bundle, application-defined
+// metadata such as a build ID or the URL of a CI pipeline. Setting no
+// annotations removes them.
+//
+// Annotations are not signed, so they are NOT authenticated: anyone can
+// add, change or remove them without invalidating the bundle, and they are
+// not checked during verification. Metadata that verifiers rely on must be
+// part of the signed content instead, e.g. the predicate of an in-toto
+// statement.
+//
+// Annotations are kept when the bundle is serialized as a binary protobuf,
+// as unknown fields of the Bundle message. The JSON encodings produced by
+// MarshalJSON, ExportJSON and WriteTo conform to the bundle format and omit
+// them; MarshalJSONWithAnnotations adds them as an "annotations" member,
+// which UnmarshalJSON, ReadFrom and ImportJSON read back.
+func SetAnnotations(b *protobundle.Bundle, annotations map[string]string) {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	messages := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, MarshalExtensionMessage(map[protowire.Number][]byte{
+			annotationKeyField:   []byte(key),
+			annotationValueField: []byte(annotations[key]),
+		}))
+	}
+	SetExtensionFields(b, annotationFieldNumber, messages)
+}
+
+// GetAnnotations returns the annotations of a bundle set with
+// SetAnnotations, or nil if it has none. See SetAnnotations: annotations
+// are not authenticated, even if the bundle verifies.
+func GetAnnotations(b *protobundle.Bundle) (map[string]string, error) {
+	messages, err := GetExtensionFields(b, annotationFieldNumber)
+	if err != nil {
+		return nil, err
+	}
+	var annotations map[string]string
+	for _, message := range messages {
+		fields, err := UnmarshalExtensionMessage(message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation: %w", err)
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[string(fields[annotationKeyField])] = string(fields[annotationValueField])
+	}
+	return annotations, nil
+}
+
+// Annotations returns the bundle's annotations, or nil if it has none.
+// They are NOT authenticated; see SetAnnotations.
+func (b *ProtobufBundle) Annotations() (map[string]string, error) {
+	if b.Bundle == nil {
+		return nil, nil
+	}
+	return GetAnnotations(b.Bundle)
+}
+
+// AppendAnnotationsJSON adds the annotations of a bundle, if it has any, to
+// the bundle's JSON encoding, for encoders other than
+// MarshalJSONWithAnnotations such as sign.MarshalBundleJSON. The
+// annotations are encoded compactly, with sorted keys.
+func AppendAnnotationsJSON(bundleJSON []byte, b *protobundle.Bundle) ([]byte, error) {
+	annotations, err := GetAnnotations(b)
+	if err != nil || len(annotations) == 0 {
+		return bundleJSON, err
+	}
+	annotationsJSON, err := json.Marshal(annotations)
+	if err != nil {
+		return nil, err
+	}
+	return appendJSONMember(bundleJSON, annotationsJSONMember, annotationsJSON)
+}
+
+// appendJSONMember adds a member the bundle format does not define to the
+// end of a bundle's JSON encoding.
+func appendJSONMember(bundleJSON []byte, name string, value []byte) ([]byte, error) {
+	trimmed := bytes.TrimRight(bundleJSON, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[len(trimmed)-1] != '}' {
+		return nil, errors.New("bundle JSON is not an object")
+	}
+	body := bytes.TrimSpace(trimmed[:len(trimmed)-1])
+	out := make([]byte, 0, len(trimmed)+len(name)+len(value)+5)
+	out = append(out, body...)
+	if !bytes.Equal(body, []byte("{")) {
+		out = append(out, ',')
+	}
+	out = append(out, `"`+name+`":`...)
+	out = append(out, value...)
+	return append(out, '}'), nil
+}
+
+// jsonExtensions holds the members sigstore-go adds to a bundle's JSON
+// encoding next to those defined by the bundle format.
+type jsonExtensions struct {
+	annotations  map[string]string
+	detachedSCTs [][]byte
+}
+
+// splitExtensionsJSON removes the members the bundle format does not
+// define, annotations and detached SCTs, from a bundle's JSON encoding and
+// returns them separately.
+func splitExtensionsJSON(data []byte) ([]byte, *jsonExtensions, error) {
+	extensions := &jsonExtensions{}
+	// Most bundles have neither, and are not decoded twice
+	if !bytes.Contains(data, []byte(`"`+annotationsJSONMember+`"`)) && !bytes.Contains(data, []byte(`"`+detachedSCTsJSONMember+`"`)) {
+		return data, extensions, nil
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, nil, err
+	}
+	annotationsJSON, hasAnnotations := members[annotationsJSONMember]
+	detachedSCTsJSON, hasDetachedSCTs := members[detachedSCTsJSONMember]
+	if !hasAnnotations && !hasDetachedSCTs {
+		return data, extensions, nil
+	}
+	if hasAnnotations {
+		if err := json.Unmarshal(annotationsJSON, &extensions.annotations); err != nil {
+			return nil, nil, fmt.Errorf("invalid bundle annotations: %w", err)
+		}
+		delete(members, annotationsJSONMember)
+	}
+	if hasDetachedSCTs {
+		if err := json.Unmarshal(detachedSCTsJSON, &extensions.detachedSCTs); err != nil {
+			return nil, nil, fmt.Errorf("invalid detached SCTs: %w", err)
+		}
+		delete(members, detachedSCTsJSONMember)
+	}
+	rest, err := json.Marshal(members)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rest, extensions, nil
+}
+
+// apply stores the extensions in the unknown fields of a bundle.
+func (e *jsonExtensions) apply(b *protobundle.Bundle) {
+	SetAnnotations(b, e.annotations)
+	SetDetachedSCTs(b, e.detachedSCTs)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestAnnotations(t *testing.T) {
+	annotations := map[string]string{"buildID": "1234", "pipeline": "https://ci.example.com/run/1"}
+	b := modified(t, data.SigstoreJS200ProvenanceBundle(t), func(pb *protobundle.Bundle) {
+		bundle.SetAnnotations(pb, annotations)
+	})
+	got, err := b.Annotations()
+	require.NoError(t, err)
+	assert.Equal(t, annotations, got)
+
+	// The default JSON encodings conform to the bundle format and omit
+	// annotations
+	marshaled, err := b.MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(marshaled), "annotations")
+	exported, err := b.ExportJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(exported), "annotations")
+	var buf bytes.Buffer
+	_, err = b.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "annotations")
+	size, err := b.JSONSize()
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), size)
+
+	// Annotations survive the binary encoding and the opt-in JSON encoding
+	withAnnotations, err := b.MarshalJSONWithAnnotations()
+	require.NoError(t, err)
+	assert.Contains(t, string(withAnnotations), `"annotations":{"buildID":"1234","pipeline":"https://ci.example.com/run/1"}`)
+	var unmarshaled bundle.ProtobufBundle
+	require.NoError(t, unmarshaled.UnmarshalJSON(withAnnotations))
+
+	var read bundle.ProtobufBundle
+	_, err = read.ReadFrom(bytes.NewReader(withAnnotations))
+	require.NoError(t, err)
+
+	imported, err := bundle.ImportJSON(withAnnotations)
+	require.NoError(t, err)
+
+	binary, err := proto.Marshal(b.Bundle)
+	require.NoError(t, err)
+	pb := new(protobundle.Bundle)
+	require.NoError(t, proto.Unmarshal(binary, pb))
+
+	converted, err := bundle.Convert(b, "v0.3")
+	require.NoError(t, err)
+
+	for _, loaded := range []*bundle.ProtobufBundle{&unmarshaled, &read, imported, converted} {
+		got, err := loaded.Annotations()
+		require.NoError(t, err)
+		assert.Equal(t, annotations, got)
+	}
+	got, err = bundle.GetAnnotations(pb)
+	require.NoError(t, err)
+	assert.Equal(t, annotations, got)
+
+	// Annotations are not authenticated: changing them does not affect
+	// verification
+	changed := modified(t, b, func(pb *protobundle.Bundle) {
+		bundle.SetAnnotations(pb, map[string]string{"buildID": "5678"})
+	})
+	got, err = changed.Annotations()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"buildID": "5678"}, got)
+	verifier, err := verify.NewSignedEntityVerifier(data.PublicGoodTrustedMaterialRoot(t), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	_, err = verifier.Verify(changed, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	summary, err := bundle.Inspect(changed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"buildID": "5678"}, summary.Annotations)
+	assert.Contains(t, summary.String(), "Annotation (unauthenticated): buildID=\"5678\"\n")
+
+	// Removing annotations
+	removed := modified(t, b, func(pb *protobundle.Bundle) {
+		bundle.SetAnnotations(pb, nil)
+	})
+	got, err = removed.Annotations()
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	marshaled, err = removed.MarshalJSONWithAnnotations()
+	require.NoError(t, err)
+	assert.NotContains(t, string(marshaled), "annotations")
+}
+
+func TestAnnotationsInvalidJSON(t *testing.T) {
+	marshaled, err := data.SigstoreBundle(t).MarshalJSON()
+	require.NoError(t, err)
+	invalid := strings.TrimSuffix(strings.TrimSpace(string(marshaled)), "}") + `,"annotations":{"buildID":1234}}`
+
+	var b bundle.ProtobufBundle
+	assert.ErrorContains(t, b.UnmarshalJSON([]byte(invalid)), "invalid bundle annotations")
+	_, err = bundle.ImportJSON([]byte(invalid))
+	assert.Error(t, err)
+}
@@ -52,6 +52,7 @@ type ProtobufBundle struct {
 	*protobundle.Bundle
 	hasInclusionPromise bool
 	hasInclusionProof   bool
+	decompression       *PayloadDecompressionOptions
 }
 
 func NewProtobufBundle(pbundle *protobundle.Bundle) (*ProtobufBundle, error) {
@@ -179,12 +180,42 @@ func (b *ProtobufBundle) MarshalJSON() ([]byte, error) {
 	return protojson.Marshal(b.Bundle)
 }
 
+// MarshalJSONWithDetachedSCTs is like MarshalJSON, but also encodes the
+// bundle's detached SCTs, if it has any, as a "detachedScts" member. The
+// bundle format does not define that member, so other Sigstore clients may
+// reject the output; MarshalJSON omits detached SCTs for that reason. See
+// SetDetachedSCTs.
+func (b *ProtobufBundle) MarshalJSONWithDetachedSCTs() ([]byte, error) {
+	data, err := b.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return AppendDetachedSCTsJSON(data, b.Bundle)
+}
+
+// MarshalJSONWithAnnotations is like MarshalJSON, but also encodes the
+// bundle's annotations, if it has any, as an "annotations" member. The
+// bundle format does not define that member, so other Sigstore clients may
+// reject the output; MarshalJSON omits annotations for that reason.
+func (b *ProtobufBundle) MarshalJSONWithAnnotations() ([]byte, error) {
+	data, err := b.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return AppendAnnotationsJSON(data, b.Bundle)
+}
+
 func (b *ProtobufBundle) UnmarshalJSON(data []byte) error {
+	data, extensions, err := splitExtensionsJSON(data)
+	if err != nil {
+		return err
+	}
 	b.Bundle = new(protobundle.Bundle)
-	err := protojson.Unmarshal(data, b.Bundle)
+	err = protojson.Unmarshal(data, b.Bundle)
 	if err != nil {
 		return err
 	}
+	extensions.apply(b.Bundle)
 
 	err = b.validate()
 	if err != nil {
@@ -209,8 +240,13 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 		if err != nil {
 			return nil, ErrValidationError(err)
 		}
+		detachedSCTs, err := GetDetachedSCTs(b.Bundle)
+		if err != nil {
+			return nil, ErrValidationError(err)
+		}
 		cert := &Certificate{
-			Certificate: parsedCert,
+			Certificate:  parsedCert,
+			DetachedSCTs: detachedSCTs,
 		}
 		return cert, nil
 	case *protobundle.VerificationMaterial_Certificate:
@@ -218,8 +254,13 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 		if err != nil {
 			return nil, ErrValidationError(err)
 		}
+		detachedSCTs, err := GetDetachedSCTs(b.Bundle)
+		if err != nil {
+			return nil, ErrValidationError(err)
+		}
 		cert := &Certificate{
-			Certificate: parsedCert,
+			Certificate:  parsedCert,
+			DetachedSCTs: detachedSCTs,
 		}
 		return cert, nil
 	case *protobundle.VerificationMaterial_PublicKey:
@@ -233,6 +274,21 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 	}
 }
 
+// AddDetachedSCT attaches an SCT that was issued for the bundle's signing
+// certificate but not embedded in it, such as one returned by a Fulcio
+// deployment that detaches SCTs. The SCT is checked during verification
+// alongside any embedded SCTs. It is kept when the bundle is serialized as
+// a binary protobuf or with MarshalJSONWithDetachedSCTs; see
+// SetDetachedSCTs.
+func (b *ProtobufBundle) AddDetachedSCT(sct []byte) error {
+	scts, err := GetDetachedSCTs(b.Bundle)
+	if err != nil {
+		return err
+	}
+	SetDetachedSCTs(b.Bundle, append(scts, sct))
+	return nil
+}
+
 func (b *ProtobufBundle) HasInclusionPromise() bool {
 	return b.hasInclusionPromise
 }
@@ -272,6 +328,7 @@ func (b *ProtobufBundle) SignatureContent() (verify.SignatureContent, error) {
 		if err != nil {
 			return nil, err
 		}
+		envelope.decompression = b.decompression
 		return envelope, nil
 	case *protobundle.Bundle_MessageSignature:
 		return NewMessageSignature(
@@ -290,6 +347,7 @@ func (b *ProtobufBundle) Envelope() (*Envelope, error) {
 		if err != nil {
 			return nil, err
 		}
+		envelope.decompression = b.decompression
 		return envelope, nil
 	}
 	return nil, ErrMissingVerificationMaterial
@@ -313,13 +371,18 @@ func (b *ProtobufBundle) Timestamps() ([][]byte, error) {
 	return signedTimestamps, nil
 }
 
+// Version returns the bundle's version, e.g. "v0.3", from its media type.
+func (b *ProtobufBundle) Version() (string, error) {
+	return getBundleVersion(b.Bundle.MediaType)
+}
+
 func (b *ProtobufBundle) MinVersion(version string) bool {
-	mediaTypeParts := strings.Split(b.Bundle.MediaType, "version=")
-	if len(mediaTypeParts) < 2 {
+	bundleVersion, err := getBundleVersion(b.Bundle.MediaType)
+	if err != nil {
 		return false
 	}
 
-	return semver.Compare("v"+mediaTypeParts[1], "v"+version) >= 0
+	return semver.Compare(bundleVersion, "v"+strings.TrimPrefix(version, "v")) >= 0
 }
 
 func parseEnvelope(input *protodsse.Envelope) (*Envelope, error) {
@@ -327,9 +390,17 @@ func parseEnvelope(input *protodsse.Envelope) (*Envelope, error) {
 	output.Payload = base64.StdEncoding.EncodeToString([]byte(input.GetPayload()))
 	output.PayloadType = string(input.GetPayloadType())
 	output.Signatures = make([]dsse.Signature, len(input.GetSignatures()))
+	signatures := make([][]byte, len(input.GetSignatures()))
 	for i, sig := range input.GetSignatures() {
 		output.Signatures[i].KeyID = sig.GetKeyid()
 		output.Signatures[i].Sig = base64.StdEncoding.EncodeToString(sig.GetSig())
+		signatures[i] = sig.GetSig()
+	}
+	// The protobuf envelope is already decoded, so there is no need to
+	// decode the base64 encoding again during verification
+	payload := input.GetPayload()
+	if payload == nil {
+		payload = []byte{}
 	}
-	return &Envelope{Envelope: output}, nil
+	return &Envelope{Envelope: output, payload: payload, signatures: signatures}, nil
 }
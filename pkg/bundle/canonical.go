@@ -0,0 +1,55 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"crypto/sha256"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+// MarshalCanonical returns a byte-stable JSON encoding of the bundle, so
+// that bundles can be stored content-addressed and deduplicated. Unlike
+// MarshalJSON, whose output protojson deliberately varies between runs, it
+// returns the RFC 8785 (JCS) canonical form of the bundle's JSON encoding:
+// sorted object members, no insignificant whitespace, and numbers
+// serialized as ECMAScript does. Timestamps and 64-bit integers are
+// encoded as strings by the protobuf JSON mapping, so their formatting is
+// fixed as well.
+//
+// Bundles that are equal as protobuf messages, including their
+// annotations and detached SCTs, have the same canonical encoding.
+func (b *ProtobufBundle) MarshalCanonical() ([]byte, error) {
+	data, err := b.MarshalJSONWithDetachedSCTs()
+	if err != nil {
+		return nil, err
+	}
+	data, err = AppendAnnotationsJSON(data, b.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	return util.CanonicalizeJSON(data)
+}
+
+// CanonicalDigest returns the SHA-256 digest of the bundle's canonical
+// encoding, for use as its content address.
+func (b *ProtobufBundle) CanonicalDigest() ([]byte, error) {
+	data, err := b.MarshalCanonical()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(data)
+	return digest[:], nil
+}
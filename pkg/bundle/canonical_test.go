@@ -0,0 +1,62 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+)
+
+func TestMarshalCanonical(t *testing.T) {
+	b := modified(t, data.SigstoreJS200ProvenanceBundle(t), func(pb *protobundle.Bundle) {
+		bundle.SetAnnotations(pb, map[string]string{"pipeline": "release", "buildID": "1234"})
+	})
+	canonical, err := b.MarshalCanonical()
+	require.NoError(t, err)
+	assert.True(t, json.Valid(canonical))
+	assert.NotContains(t, string(canonical), "\n")
+	assert.True(t, bytes.HasPrefix(canonical, []byte(`{"annotations":{"buildID":"1234","pipeline":"release"},"dsseEnvelope":`)))
+
+	// The same bundle decoded from a differently formatted encoding
+	marshaled, err := b.MarshalJSONWithAnnotations()
+	require.NoError(t, err)
+	var indented bytes.Buffer
+	require.NoError(t, json.Indent(&indented, marshaled, "", "    "))
+	var decoded bundle.ProtobufBundle
+	require.NoError(t, decoded.UnmarshalJSON(indented.Bytes()))
+	decodedCanonical, err := decoded.MarshalCanonical()
+	require.NoError(t, err)
+	assert.Equal(t, canonical, decodedCanonical)
+
+	digest, err := b.CanonicalDigest()
+	require.NoError(t, err)
+	decodedDigest, err := decoded.CanonicalDigest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, decodedDigest)
+	assert.Len(t, digest, 32)
+
+	// Other bundles have other encodings
+	other, err := data.SigstoreJS200ProvenanceBundle(t).CanonicalDigest()
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, other)
+}
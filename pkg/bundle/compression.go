@@ -0,0 +1,162 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// IntotoGzipMediaType is the payload type of gzip-compressed in-toto
+	// statements
+	IntotoGzipMediaType = IntotoMediaType + "+gzip"
+	// IntotoZstdMediaType is the payload type of zstd-compressed in-toto
+	// statements
+	IntotoZstdMediaType = IntotoMediaType + "+zstd"
+
+	// DefaultMaxDecompressedPayloadSize is the default limit on the size of
+	// decompressed DSSE payloads
+	DefaultMaxDecompressedPayloadSize = 64 << 20
+)
+
+var ErrPayloadTooLarge = fmt.Errorf("%w: decompressed payload is too large", ErrInvalidAttestation)
+var ErrUnsupportedCompression = fmt.Errorf("%w: unsupported payload compression", ErrInvalidAttestation)
+
+// Decompressor returns a reader of the decompressed contents of r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// PayloadDecompressionOptions configure the decompression of DSSE payloads
+// when their in-toto statement is decoded. The envelope's signature covers
+// the compressed payload, which is what is verified.
+//
+// A payload is decompressed when its payload type is the in-toto media type
+// with the suffix of a compression algorithm, e.g. IntotoGzipMediaType, or,
+// with DetectCompression, when it starts with the magic bytes of gzip or
+// zstd.
+type PayloadDecompressionOptions struct {
+	// Optional limit on the size of decompressed payloads, to protect
+	// against decompression bombs. Defaults to
+	// DefaultMaxDecompressedPayloadSize.
+	MaxSize int64
+	// Optional decompressors by algorithm, as in the payload type suffix,
+	// in addition to or replacing the built-in gzip and zstd ones
+	Decompressors map[string]Decompressor
+	// Optional: also decompress in-toto payloads that are compressed
+	// without declaring it in their payload type
+	DetectCompression bool
+}
+
+var compressionMagic = map[string][]byte{
+	"gzip": {0x1f, 0x8b},
+	"zstd": {0x28, 0xb5, 0x2f, 0xfd},
+}
+
+// SetPayloadDecompression enables the decompression of the envelope's
+// payload when its statement is decoded. It must be called before the
+// statement is first decoded.
+func (e *Envelope) SetPayloadDecompression(opts *PayloadDecompressionOptions) {
+	e.decompression = opts
+}
+
+// SetPayloadDecompression enables the decompression of the payload of the
+// bundle's DSSE envelope when its statement is decoded, including during
+// verification.
+func (b *ProtobufBundle) SetPayloadDecompression(opts *PayloadDecompressionOptions) {
+	b.decompression = opts
+}
+
+// decompress returns the decompressed payload and its uncompressed payload
+// type, or the payload unchanged if it is not compressed.
+func (o *PayloadDecompressionOptions) decompress(payloadType string, payload []byte) (string, []byte, error) {
+	algorithm := ""
+	if base, suffix, ok := cutLast(payloadType, "+"); ok && base == IntotoMediaType {
+		algorithm = suffix
+		payloadType = base
+	} else if o.DetectCompression && payloadType == IntotoMediaType {
+		for name, magic := range compressionMagic {
+			if bytes.HasPrefix(payload, magic) {
+				algorithm = name
+			}
+		}
+	}
+	if algorithm == "" {
+		return payloadType, payload, nil
+	}
+
+	maxSize := o.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedPayloadSize
+	}
+	decompressor, ok := o.Decompressors[algorithm]
+	if !ok {
+		switch algorithm {
+		case "gzip":
+			decompressor = gzipDecompressor
+		case "zstd":
+			decompressor = zstdDecompressor(maxSize)
+		default:
+			return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, algorithm)
+		}
+	}
+	reader, err := decompressor(bytes.NewReader(payload))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		return "", nil, fmt.Errorf("%w: more than %d bytes", ErrPayloadTooLarge, maxSize)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidAttestation, err)
+	}
+	if int64(len(decompressed)) > maxSize {
+		return "", nil, fmt.Errorf("%w: more than %d bytes", ErrPayloadTooLarge, maxSize)
+	}
+	return payloadType, decompressed, nil
+}
+
+func gzipDecompressor(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// zstdDecompressor returns a zstd decompressor that also refuses frames
+// whose window is larger than maxSize, so that the decoder's buffers are
+// limited like its output.
+func zstdDecompressor(maxSize int64) Decompressor {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+)
+
+func gzipped(t *testing.T, payload []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(payload)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdCompressed(t *testing.T, payload []byte) []byte {
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer encoder.Close()
+	return encoder.EncodeAll(payload, nil)
+}
+
+func TestPayloadDecompression(t *testing.T) {
+	original := data.SigstoreJS200ProvenanceBundle(t)
+	envelope, err := original.Envelope()
+	require.NoError(t, err)
+	want, err := envelope.Statement()
+	require.NoError(t, err)
+	payload, err := envelope.DecodedPayload()
+	require.NoError(t, err)
+
+	compressed := func(payloadType string) *bundle.ProtobufBundle {
+		return modified(t, original, func(pb *protobundle.Bundle) {
+			pb.GetDsseEnvelope().Payload = gzipped(t, payload)
+			pb.GetDsseEnvelope().PayloadType = payloadType
+		})
+	}
+	zstdBundle := func(payloadType string) *bundle.ProtobufBundle {
+		return modified(t, original, func(pb *protobundle.Bundle) {
+			pb.GetDsseEnvelope().Payload = zstdCompressed(t, payload)
+			pb.GetDsseEnvelope().PayloadType = payloadType
+		})
+	}
+	statement := func(b *bundle.ProtobufBundle) error {
+		envelope, err := b.Envelope()
+		require.NoError(t, err)
+		got, err := envelope.Statement()
+		if err == nil {
+			assert.Equal(t, want, got)
+		}
+		return err
+	}
+
+	// Compressed payloads are only decoded when enabled
+	b := compressed(bundle.IntotoGzipMediaType)
+	assert.ErrorIs(t, statement(b), bundle.ErrUnsupportedMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{})
+	assert.NoError(t, statement(b))
+
+	// The signature is still over the compressed payload
+	envelope, err = b.Envelope()
+	require.NoError(t, err)
+	signedPayload, err := envelope.DecodedPayload()
+	require.NoError(t, err)
+	assert.Equal(t, gzipped(t, payload), signedPayload)
+
+	// Uncompressed payloads are unaffected
+	b = modified(t, original, func(*protobundle.Bundle) {})
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{})
+	assert.NoError(t, statement(b))
+
+	// Undeclared compression is only detected when enabled
+	b = compressed(bundle.IntotoMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{})
+	assert.ErrorIs(t, statement(b), bundle.ErrDecodingJSON)
+	b = compressed(bundle.IntotoMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{DetectCompression: true})
+	assert.NoError(t, statement(b))
+
+	// Decompressed payloads are limited in size
+	b = compressed(bundle.IntotoGzipMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{MaxSize: int64(len(payload)) - 1})
+	assert.ErrorIs(t, statement(b), bundle.ErrPayloadTooLarge)
+	b = compressed(bundle.IntotoGzipMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{MaxSize: int64(len(payload))})
+	assert.NoError(t, statement(b))
+
+	// zstd is built in, with the same limit
+	b = zstdBundle(bundle.IntotoZstdMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{})
+	assert.NoError(t, statement(b))
+	b = zstdBundle(bundle.IntotoMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{DetectCompression: true})
+	assert.NoError(t, statement(b))
+	b = zstdBundle(bundle.IntotoZstdMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{MaxSize: int64(len(payload)) - 1})
+	assert.ErrorIs(t, statement(b), bundle.ErrPayloadTooLarge)
+	b = zstdBundle(bundle.IntotoZstdMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{MaxSize: int64(len(payload))})
+	assert.NoError(t, statement(b))
+
+	// Callers can replace the built-in decompressors and add others
+	b = compressed("application/vnd.in-toto+json+br")
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{})
+	assert.ErrorIs(t, statement(b), bundle.ErrUnsupportedCompression)
+	b = compressed(bundle.IntotoZstdMediaType)
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{
+		Decompressors: map[string]bundle.Decompressor{
+			// Replaces the zstd decompressor
+			"zstd": func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		},
+	})
+	assert.NoError(t, statement(b))
+
+	// Other payload types are still unsupported
+	b = compressed("application/json+gzip")
+	b.SetPayloadDecompression(&bundle.PayloadDecompressionOptions{})
+	assert.ErrorIs(t, statement(b), bundle.ErrUnsupportedMediaType)
+}
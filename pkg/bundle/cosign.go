@@ -0,0 +1,248 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+)
+
+// Annotations set by cosign on the layers of an OCI signature image.
+const (
+	CosignSignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	CosignCertificateAnnotation = "dev.sigstore.cosign/certificate"
+	CosignChainAnnotation       = "dev.sigstore.cosign/chain"
+	CosignBundleAnnotation      = "dev.sigstore.cosign/bundle"
+	CosignRFC3161Annotation     = "dev.sigstore.cosign/rfc3161timestamp"
+)
+
+// CosignSimpleSigningMediaType is the media type of a cosign signature layer.
+const CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+// cosignRekorBundle is the value of the CosignBundleAnnotation.
+type cosignRekorBundle struct {
+	SignedEntryTimestamp []byte
+	Payload              struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogIndex       int64  `json:"logIndex"`
+		LogID          string `json:"logID"` //nolint:tagliatelle
+	}
+}
+
+// cosignRFC3161Timestamp is the value of the CosignRFC3161Annotation.
+type cosignRFC3161Timestamp struct {
+	SignedRFC3161Timestamp []byte
+}
+
+// NewProtobufBundleFromCosignLayer returns a bundle equivalent to a signature
+// layer attached to an OCI image by cosign, so that legacy attached signatures
+// can be verified in the same way as bundles.
+//
+// payload is the content of the layer, usually a simple signing payload of
+// media type CosignSimpleSigningMediaType, and annotations are the layer's
+// annotations. The signature is taken from CosignSignatureAnnotation. If the
+// layer has a CosignCertificateAnnotation, the bundle is verified with that
+// certificate (and CosignChainAnnotation, if present); otherwise the bundle
+// refers to a public key with an empty hint, which must be resolved by the
+// trusted material. The transparency log entry and signed timestamp are taken
+// from CosignBundleAnnotation and CosignRFC3161Annotation respectively.
+//
+// The returned bundle should be verified with the payload as the artifact.
+func NewProtobufBundleFromCosignLayer(payload []byte, annotations map[string]string) (*ProtobufBundle, error) {
+	sigB64, ok := annotations[CosignSignatureAnnotation]
+	if !ok {
+		return nil, errors.New("cosign layer missing signature annotation")
+	}
+	sig, err := base64.StdEncoding.DecodeString(sigB64)
+	if err != nil {
+		return nil, fmt.Errorf("decoding cosign signature: %w", err)
+	}
+
+	digest := sha256.Sum256(payload)
+	pb := &protobundle.Bundle{
+		VerificationMaterial: &protobundle.VerificationMaterial{},
+		Content: &protobundle.Bundle_MessageSignature{
+			MessageSignature: &protocommon.MessageSignature{
+				MessageDigest: &protocommon.HashOutput{
+					Algorithm: protocommon.HashAlgorithm_SHA2_256,
+					Digest:    digest[:],
+				},
+				Signature: sig,
+			},
+		},
+	}
+
+	if certPEM, ok := annotations[CosignCertificateAnnotation]; ok {
+		chainPEM := []byte(certPEM + annotations[CosignChainAnnotation])
+		var certs []*protocommon.X509Certificate
+		for {
+			var block *pem.Block
+			block, chainPEM = pem.Decode(chainPEM)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			certs = append(certs, &protocommon.X509Certificate{RawBytes: block.Bytes})
+		}
+		if len(certs) == 0 {
+			return nil, errors.New("cosign layer certificate annotation has no certificates")
+		}
+		pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_X509CertificateChain{
+			X509CertificateChain: &protocommon.X509CertificateChain{Certificates: certs},
+		}
+	} else {
+		pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_PublicKey{
+			PublicKey: &protocommon.PublicKeyIdentifier{},
+		}
+	}
+
+	if rekorBundleJSON, ok := annotations[CosignBundleAnnotation]; ok {
+		entry, err := cosignTlogEntry([]byte(rekorBundleJSON))
+		if err != nil {
+			return nil, err
+		}
+		pb.VerificationMaterial.TlogEntries = []*protorekor.TransparencyLogEntry{entry}
+	}
+
+	if tsJSON, ok := annotations[CosignRFC3161Annotation]; ok {
+		var ts cosignRFC3161Timestamp
+		if err := json.Unmarshal([]byte(tsJSON), &ts); err != nil {
+			return nil, fmt.Errorf("decoding cosign timestamp annotation: %w", err)
+		}
+		pb.VerificationMaterial.TimestampVerificationData = &protobundle.TimestampVerificationData{
+			Rfc3161Timestamps: []*protocommon.RFC3161SignedTimestamp{{SignedTimestamp: ts.SignedRFC3161Timestamp}},
+		}
+	}
+
+	// cosign layers only carry inclusion promises, which corresponds to v0.1
+	pb.MediaType, err = MediaTypeString("0.1")
+	if err != nil {
+		return nil, err
+	}
+	return NewProtobufBundle(pb)
+}
+
+// CosignLayerAnnotations returns the annotations of the signature layer that
+// cosign would attach to an OCI image for the bundle, the inverse of
+// NewProtobufBundleFromCosignLayer, so that signatures created with
+// sigstore-go can be verified by cosign versions that do not read bundles.
+// The layer's content is the signed payload.
+//
+// The bundle must contain a MessageSignature over a SHA-256 digest. Since
+// cosign layers only carry inclusion promises, the bundle's first
+// transparency log entry with one is used; entries without one, such as
+// those in Rekor v2, cannot be represented.
+func (b *ProtobufBundle) CosignLayerAnnotations() (map[string]string, error) {
+	msg := b.GetMessageSignature()
+	if msg == nil {
+		return nil, errors.New("cosign layers require a bundle with a message signature")
+	}
+	if msg.GetMessageDigest().GetAlgorithm() != protocommon.HashAlgorithm_SHA2_256 {
+		return nil, fmt.Errorf("cosign layers require a SHA-256 message digest, not %s", msg.GetMessageDigest().GetAlgorithm())
+	}
+	annotations := map[string]string{
+		CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(msg.GetSignature()),
+	}
+
+	var certs []*protocommon.X509Certificate
+	switch content := b.GetVerificationMaterial().GetContent().(type) {
+	case *protobundle.VerificationMaterial_Certificate:
+		certs = []*protocommon.X509Certificate{content.Certificate}
+	case *protobundle.VerificationMaterial_X509CertificateChain:
+		certs = content.X509CertificateChain.GetCertificates()
+	}
+	for i, cert := range certs {
+		certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.GetRawBytes()}))
+		if i == 0 {
+			annotations[CosignCertificateAnnotation] = certPEM
+		} else {
+			annotations[CosignChainAnnotation] += certPEM
+		}
+	}
+
+	for _, entry := range b.GetVerificationMaterial().GetTlogEntries() {
+		if entry.GetInclusionPromise() == nil {
+			continue
+		}
+		var rekorBundle cosignRekorBundle
+		rekorBundle.SignedEntryTimestamp = entry.GetInclusionPromise().GetSignedEntryTimestamp()
+		rekorBundle.Payload.Body = base64.StdEncoding.EncodeToString(entry.GetCanonicalizedBody())
+		rekorBundle.Payload.IntegratedTime = entry.GetIntegratedTime()
+		rekorBundle.Payload.LogIndex = entry.GetLogIndex()
+		rekorBundle.Payload.LogID = hex.EncodeToString(entry.GetLogId().GetKeyId())
+		rekorBundleJSON, err := json.Marshal(rekorBundle)
+		if err != nil {
+			return nil, err
+		}
+		annotations[CosignBundleAnnotation] = string(rekorBundleJSON)
+		break
+	}
+
+	if timestamps := b.GetVerificationMaterial().GetTimestampVerificationData().GetRfc3161Timestamps(); len(timestamps) > 0 {
+		tsJSON, err := json.Marshal(cosignRFC3161Timestamp{SignedRFC3161Timestamp: timestamps[0].GetSignedTimestamp()})
+		if err != nil {
+			return nil, err
+		}
+		annotations[CosignRFC3161Annotation] = string(tsJSON)
+	}
+	return annotations, nil
+}
+
+func cosignTlogEntry(rekorBundleJSON []byte) (*protorekor.TransparencyLogEntry, error) {
+	var rekorBundle cosignRekorBundle
+	if err := json.Unmarshal(rekorBundleJSON, &rekorBundle); err != nil {
+		return nil, fmt.Errorf("decoding cosign bundle annotation: %w", err)
+	}
+
+	logID, err := hex.DecodeString(rekorBundle.Payload.LogID)
+	if err != nil {
+		return nil, fmt.Errorf("decoding cosign bundle log ID: %w", err)
+	}
+	body, err := base64.StdEncoding.DecodeString(rekorBundle.Payload.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding cosign bundle body: %w", err)
+	}
+
+	var kindVersion struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(body, &kindVersion); err != nil {
+		return nil, fmt.Errorf("decoding cosign bundle body: %w", err)
+	}
+
+	return &protorekor.TransparencyLogEntry{
+		LogIndex:       rekorBundle.Payload.LogIndex,
+		LogId:          &protocommon.LogId{KeyId: logID},
+		KindVersion:    &protorekor.KindVersion{Kind: kindVersion.Kind, Version: kindVersion.APIVersion},
+		IntegratedTime: rekorBundle.Payload.IntegratedTime,
+		InclusionPromise: &protorekor.InclusionPromise{
+			SignedEntryTimestamp: rekorBundle.SignedEntryTimestamp,
+		},
+		CanonicalizedBody: body,
+	}, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// cosignLayerAnnotations returns the annotations cosign would attach to a
+// signature layer for the given test entity.
+func cosignLayerAnnotations(t *testing.T, entity *ca.TestEntity) map[string]string {
+	verificationContent, err := entity.VerificationContent()
+	require.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	require.True(t, ok)
+	certPEM, err := cryptoutils.MarshalCertificateToPEM(&leafCert)
+	require.NoError(t, err)
+
+	sigContent, err := entity.SignatureContent()
+	require.NoError(t, err)
+
+	entries, err := entity.TlogEntries()
+	require.NoError(t, err)
+	entry := entries[0]
+	rekorBundle := map[string]any{
+		"SignedEntryTimestamp": entry.SignedEntryTimestamp(),
+		"Payload": map[string]any{
+			"body":           entry.Body(),
+			"integratedTime": entry.IntegratedTime().Unix(),
+			"logIndex":       entry.LogIndex(),
+			"logID":          hex.EncodeToString([]byte(entry.LogKeyID())),
+		},
+	}
+	rekorBundleJSON, err := json.Marshal(rekorBundle)
+	require.NoError(t, err)
+
+	timestamps, err := entity.Timestamps()
+	require.NoError(t, err)
+	tsJSON, err := json.Marshal(map[string]any{"SignedRFC3161Timestamp": timestamps[0]})
+	require.NoError(t, err)
+
+	return map[string]string{
+		bundle.CosignSignatureAnnotation:   base64.StdEncoding.EncodeToString(sigContent.Signature()),
+		bundle.CosignCertificateAnnotation: string(certPEM),
+		bundle.CosignBundleAnnotation:      string(rekorBundleJSON),
+		bundle.CosignRFC3161Annotation:     string(tsJSON),
+	}
+}
+
+func TestCosignLayerVerifies(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"example.com/image"},"image":{"docker-manifest-digest":"sha256:deadbeef"},"type":"cosign container image signature"},"optional":null}`)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", payload)
+	require.NoError(t, err)
+
+	b, err := bundle.NewProtobufBundleFromCosignLayer(payload, cosignLayerAnnotations(t, entity))
+	require.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	require.NoError(t, err)
+
+	certID, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	require.NoError(t, err)
+
+	res, err := verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(payload)), verify.WithCertificateIdentity(certID)))
+	require.NoError(t, err)
+	require.Len(t, res.VerifiedTimestamps, 1)
+
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader([]byte("other payload"))), verify.WithCertificateIdentity(certID)))
+	require.Error(t, err)
+}
+
+func TestCosignLayerMissingSignature(t *testing.T) {
+	_, err := bundle.NewProtobufBundleFromCosignLayer([]byte("payload"), map[string]string{})
+	require.Error(t, err)
+
+	_, err = bundle.NewProtobufBundleFromCosignLayer([]byte("payload"), map[string]string{
+		bundle.CosignSignatureAnnotation:   "c2ln",
+		bundle.CosignCertificateAnnotation: "not a certificate",
+	})
+	require.Error(t, err)
+}
+
+func TestCosignLayerAnnotationsRoundTrip(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"example.com/image"},"image":{"docker-manifest-digest":"sha256:deadbeef"},"type":"cosign container image signature"},"optional":null}`)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", payload)
+	require.NoError(t, err)
+	annotations := cosignLayerAnnotations(t, entity)
+
+	b, err := bundle.NewProtobufBundleFromCosignLayer(payload, annotations)
+	require.NoError(t, err)
+	roundTripped, err := b.CosignLayerAnnotations()
+	require.NoError(t, err)
+
+	require.Len(t, roundTripped, len(annotations))
+	for _, key := range []string{bundle.CosignSignatureAnnotation, bundle.CosignCertificateAnnotation} {
+		require.Equal(t, annotations[key], roundTripped[key], key)
+	}
+	for _, key := range []string{bundle.CosignBundleAnnotation, bundle.CosignRFC3161Annotation} {
+		require.JSONEq(t, annotations[key], roundTripped[key], key)
+	}
+
+	// the annotations are accepted back, and the bundle still verifies
+	b, err = bundle.NewProtobufBundleFromCosignLayer(payload, roundTripped)
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	require.NoError(t, err)
+	certID, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	require.NoError(t, err)
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(payload)), verify.WithCertificateIdentity(certID)))
+	require.NoError(t, err)
+}
+
+func TestCosignLayerAnnotationsRequireMessageSignature(t *testing.T) {
+	b, err := bundle.LoadJSONFromPath("../../examples/bundle-provenance.json")
+	require.NoError(t, err)
+	_, err = b.CosignLayerAnnotations()
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// detachedSCTsJSONMember is the member of a bundle's JSON encoding that
+// holds its detached SCTs, as an array of base64-encoded SCTs.
+const detachedSCTsJSONMember = "detachedScts"
+
+// detachedSCTFieldNumber is the field of the Bundle message that holds
+// detached SCTs, one SCT per field. The bundle format has no field for
+// them, so they are stored as unknown fields, like annotations.
+const detachedSCTFieldNumber protowire.Number = 1002
+
+// SetDetachedSCTs replaces the detached SCTs of a bundle, SCTs that were
+// issued for its signing certificate but not embedded in it, such as those
+// returned by a Fulcio deployment that detaches SCTs. Setting no SCTs
+// removes them.
+//
+// The bundle format has no field for detached SCTs. They are kept when the
+// bundle is serialized as a binary protobuf, as unknown fields of the
+// Bundle message. The JSON encodings produced by MarshalJSON, ExportJSON
+// and WriteTo conform to the bundle format and omit them, since other
+// Sigstore clients may reject bundles with members the format does not
+// define; MarshalJSONWithDetachedSCTs adds them as a "detachedScts" member,
+// which UnmarshalJSON, ReadFrom and ImportJSON read back. Bundles whose
+// certificate has no embedded SCTs can only be verified from such an
+// encoding, or with the SCTs passed to the verifier separately.
+func SetDetachedSCTs(b *protobundle.Bundle, scts [][]byte) {
+	SetExtensionFields(b, detachedSCTFieldNumber, scts)
+}
+
+// GetDetachedSCTs returns the detached SCTs of a bundle set with
+// SetDetachedSCTs, or nil if it has none.
+func GetDetachedSCTs(b *protobundle.Bundle) ([][]byte, error) {
+	scts, err := GetExtensionFields(b, detachedSCTFieldNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid detached SCTs: %w", err)
+	}
+	return scts, nil
+}
+
+// AppendDetachedSCTsJSON adds the detached SCTs of a bundle, if it has any,
+// to the bundle's JSON encoding, for encoders other than
+// MarshalJSONWithDetachedSCTs such as sign.MarshalBundleJSON.
+func AppendDetachedSCTsJSON(bundleJSON []byte, b *protobundle.Bundle) ([]byte, error) {
+	scts, err := GetDetachedSCTs(b)
+	if err != nil || len(scts) == 0 {
+		return bundleJSON, err
+	}
+	sctsJSON, err := json.Marshal(scts)
+	if err != nil {
+		return nil, err
+	}
+	return appendJSONMember(bundleJSON, detachedSCTsJSONMember, sctsJSON)
+}
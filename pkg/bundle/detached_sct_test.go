@@ -0,0 +1,108 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestDetachedSCTs(t *testing.T) {
+	scts := [][]byte{[]byte(`{"sct_version":0,"id":"AAAA"}`), []byte(`{"sct_version":0,"id":"BBBB"}`)}
+	b := modified(t, data.SigstoreJS200ProvenanceBundle(t), func(*protobundle.Bundle) {})
+	for _, sct := range scts {
+		require.NoError(t, b.AddDetachedSCT(sct))
+	}
+
+	// Detached SCTs survive the binary encoding, and JSON encodings that
+	// include them on request
+	marshaled, err := b.MarshalJSONWithDetachedSCTs()
+	require.NoError(t, err)
+	assert.Contains(t, string(marshaled), `"detachedScts"`)
+	var unmarshaled bundle.ProtobufBundle
+	require.NoError(t, unmarshaled.UnmarshalJSON(marshaled))
+
+	var read bundle.ProtobufBundle
+	_, err = read.ReadFrom(bytes.NewReader(marshaled))
+	require.NoError(t, err)
+
+	imported, err := bundle.ImportJSON(marshaled)
+	require.NoError(t, err)
+
+	binary, err := proto.Marshal(b.Bundle)
+	require.NoError(t, err)
+	pb := new(protobundle.Bundle)
+	require.NoError(t, proto.Unmarshal(binary, pb))
+	decoded, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	converted, err := bundle.Convert(b, "v0.3")
+	require.NoError(t, err)
+
+	merged := modified(t, data.SigstoreJS200ProvenanceBundle(t), func(*protobundle.Bundle) {})
+	require.NoError(t, bundle.MergeVerificationMaterial(merged, b))
+
+	for _, loaded := range []*bundle.ProtobufBundle{b, &unmarshaled, &read, imported, decoded, converted, merged} {
+		got, err := bundle.GetDetachedSCTs(loaded.Bundle)
+		require.NoError(t, err)
+		assert.Equal(t, scts, got)
+
+		verificationContent, err := loaded.VerificationContent()
+		require.NoError(t, err)
+		got, ok := verificationContent.(verify.DetachedSCTProvider).HasDetachedSCTs()
+		assert.True(t, ok)
+		assert.Equal(t, scts, got)
+	}
+
+	// Other JSON encodings conform to the bundle format
+	marshaled, err = b.MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(marshaled), "detachedScts")
+	exported, err := b.ExportJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(exported), "detachedScts")
+	var buf bytes.Buffer
+	_, err = b.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "detachedScts")
+	size, err := b.JSONSize()
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), size)
+
+	// Bundles without detached SCTs are encoded as the bundle format
+	// specifies
+	bundle.SetDetachedSCTs(b.Bundle, nil)
+	marshaled, err = b.MarshalJSONWithDetachedSCTs()
+	require.NoError(t, err)
+	assert.NotContains(t, string(marshaled), "detachedScts")
+}
+
+func TestDetachedSCTsInvalidJSON(t *testing.T) {
+	marshaled, err := data.SigstoreBundle(t).MarshalJSON()
+	require.NoError(t, err)
+	invalid := append(bytes.TrimSuffix(bytes.TrimSpace(marshaled), []byte("}")), `,"detachedScts":"not a list"}`...)
+
+	var b bundle.ProtobufBundle
+	assert.ErrorContains(t, b.UnmarshalJSON(invalid), "invalid detached SCTs")
+}
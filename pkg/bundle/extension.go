@@ -0,0 +1,164 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The bundle format has no field for extension material, so sigstore-go
+// stores it as unknown fields of the Bundle message, with numbers well above
+// those used by the specification:
+//
+//   - 1000 holds witness evidence, see sign.AddWitnessEvidence
+//   - 1001 holds annotations, see SetAnnotations
+//   - 1002 holds detached SCTs, see SetDetachedSCTs
+//
+// Unknown fields are kept by the binary protobuf encoding, but dropped by
+// protojson. Each kind of extension material decides whether, and how, it
+// is carried in JSON.
+
+// AddExtensionField appends a length-delimited field with the given number
+// and value to the extension material of a bundle.
+func AddExtensionField(b *protobundle.Bundle, num protowire.Number, value []byte) {
+	reflectBundle := b.ProtoReflect()
+	unknown := reflectBundle.GetUnknown()
+	unknown = protowire.AppendTag(unknown, num, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, value)
+	reflectBundle.SetUnknown(unknown)
+}
+
+// SetExtensionFields replaces the fields with the given number in the
+// extension material of a bundle with one length-delimited field for each
+// value, in order. Setting no values removes the fields.
+func SetExtensionFields(b *protobundle.Bundle, num protowire.Number, values [][]byte) {
+	reflectBundle := b.ProtoReflect()
+	unknown := withoutUnknownField(reflectBundle.GetUnknown(), num)
+	for _, value := range values {
+		unknown = protowire.AppendTag(unknown, num, protowire.BytesType)
+		unknown = protowire.AppendBytes(unknown, value)
+	}
+	reflectBundle.SetUnknown(unknown)
+}
+
+// GetExtensionFields returns the values of the length-delimited fields with
+// the given number in the extension material of a bundle, in order, or nil
+// if it has none.
+func GetExtensionFields(b *protobundle.Bundle, num protowire.Number) ([][]byte, error) {
+	var values [][]byte
+	err := forEachUnknownField(b.ProtoReflect().GetUnknown(), func(fieldNum protowire.Number, typ protowire.Type, field []byte) error {
+		if fieldNum != num || typ != protowire.BytesType {
+			return nil
+		}
+		value, n := protowire.ConsumeBytes(field)
+		if n < 0 {
+			return fmt.Errorf("invalid extension material: %w", protowire.ParseError(n))
+		}
+		values = append(values, bytes.Clone(value))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// MarshalExtensionMessage encodes a message of length-delimited fields, such
+// as a structured extension field value, in order of field number.
+func MarshalExtensionMessage(fields map[protowire.Number][]byte) []byte {
+	nums := make([]protowire.Number, 0, len(fields))
+	for num := range fields {
+		nums = append(nums, num)
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] < nums[j] })
+
+	var message []byte
+	for _, num := range nums {
+		message = protowire.AppendTag(message, num, protowire.BytesType)
+		message = protowire.AppendBytes(message, fields[num])
+	}
+	return message
+}
+
+// UnmarshalExtensionMessage decodes a message encoded with
+// MarshalExtensionMessage. A field that occurs more than once takes its last
+// value.
+func UnmarshalExtensionMessage(message []byte) (map[protowire.Number][]byte, error) {
+	fields := make(map[protowire.Number][]byte)
+	err := forEachUnknownField(message, func(num protowire.Number, typ protowire.Type, field []byte) error {
+		if typ != protowire.BytesType {
+			return errors.New("invalid extension material: unexpected field type")
+		}
+		value, n := protowire.ConsumeBytes(field)
+		if n < 0 {
+			return fmt.Errorf("invalid extension material: %w", protowire.ParseError(n))
+		}
+		fields[num] = bytes.Clone(value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// withoutUnknownField returns the unknown fields of a message other than
+// those numbered num. Fields that can't be parsed are kept as they are.
+func withoutUnknownField(unknown []byte, num protowire.Number) []byte {
+	var kept []byte
+	rest := unknown
+	for len(rest) > 0 {
+		fieldNum, typ, n := protowire.ConsumeTag(rest)
+		if n < 0 {
+			return append(kept, rest...)
+		}
+		m := protowire.ConsumeFieldValue(fieldNum, typ, rest[n:])
+		if m < 0 {
+			return append(kept, rest...)
+		}
+		if fieldNum != num {
+			kept = append(kept, rest[:n+m]...)
+		}
+		rest = rest[n+m:]
+	}
+	return kept
+}
+
+// forEachUnknownField calls fn with the number, type and encoded value of
+// each field in the wire encoding of a message.
+func forEachUnknownField(message []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return fmt.Errorf("invalid extension material: %w", protowire.ParseError(n))
+		}
+		message = message[n:]
+		m := protowire.ConsumeFieldValue(num, typ, message)
+		if m < 0 {
+			return fmt.Errorf("invalid extension material: %w", protowire.ParseError(m))
+		}
+		if err := fn(num, typ, message[:m]); err != nil {
+			return err
+		}
+		message = message[m:]
+	}
+	return nil
+}
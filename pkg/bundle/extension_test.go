@@ -0,0 +1,77 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func TestExtensionFields(t *testing.T) {
+	pb := &protobundle.Bundle{MediaType: "application/vnd.dev.sigstore.bundle.v0.3+json"}
+	bundle.AddExtensionField(pb, 2000, []byte("first"))
+	bundle.AddExtensionField(pb, 2001, []byte("other"))
+	bundle.AddExtensionField(pb, 2000, []byte("second"))
+
+	// Extension fields survive the binary encoding
+	encoded, err := proto.Marshal(pb)
+	require.NoError(t, err)
+	decoded := &protobundle.Bundle{}
+	require.NoError(t, proto.Unmarshal(encoded, decoded))
+	values, err := bundle.GetExtensionFields(decoded, 2000)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("first"), []byte("second")}, values)
+
+	// Setting fields only replaces those with the same number
+	bundle.SetExtensionFields(decoded, 2000, [][]byte{[]byte("replaced")})
+	values, err = bundle.GetExtensionFields(decoded, 2000)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("replaced")}, values)
+	values, err = bundle.GetExtensionFields(decoded, 2001)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("other")}, values)
+
+	bundle.SetExtensionFields(decoded, 2000, nil)
+	values, err = bundle.GetExtensionFields(decoded, 2000)
+	require.NoError(t, err)
+	assert.Nil(t, values)
+	assert.Equal(t, "application/vnd.dev.sigstore.bundle.v0.3+json", decoded.MediaType)
+
+	// Truncated extension material is reported
+	decoded.ProtoReflect().SetUnknown(protowire.AppendTag(nil, 2000, protowire.BytesType))
+	_, err = bundle.GetExtensionFields(decoded, 2000)
+	assert.ErrorContains(t, err, "invalid extension material")
+}
+
+func TestExtensionMessage(t *testing.T) {
+	fields := map[protowire.Number][]byte{2: []byte("value"), 1: []byte("key")}
+	message := bundle.MarshalExtensionMessage(fields)
+	// Fields are encoded in order of field number
+	assert.Equal(t, []byte("\x0a\x03key\x12\x05value"), message)
+
+	decoded, err := bundle.UnmarshalExtensionMessage(message)
+	require.NoError(t, err)
+	assert.Equal(t, fields, decoded)
+
+	_, err = bundle.UnmarshalExtensionMessage(protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 1))
+	assert.ErrorContains(t, err, "unexpected field type")
+}
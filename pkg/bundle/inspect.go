@@ -0,0 +1,265 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitorus/timestamp"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+// SignatureKind is the kind of content signed in a bundle.
+type SignatureKind string
+
+const (
+	SignatureKindMessageSignature SignatureKind = "messageSignature"
+	SignatureKindDSSEEnvelope     SignatureKind = "dsseEnvelope"
+)
+
+// Summary describes the contents of a bundle, for display and debugging.
+// Nothing in it has been verified.
+type Summary struct {
+	MediaType     string        `json:"mediaType"`
+	SignatureKind SignatureKind `json:"signatureKind"`
+	// MessageDigest is the digest signed by a message signature, as
+	// algorithm:hex with an in-toto algorithm name such as sha256
+	MessageDigest string `json:"messageDigest,omitempty"`
+	PayloadType   string `json:"payloadType,omitempty"`
+	// PredicateType and Subjects are set when the payload of a DSSE
+	// envelope is an in-toto statement
+	PredicateType string           `json:"predicateType,omitempty"`
+	Subjects      []SubjectSummary `json:"subjects,omitempty"`
+	// Signer describes the signing certificate, if the bundle has one
+	Signer *SignerSummary `json:"signer,omitempty"`
+	// PublicKeyHint identifies the signing key of a bundle without a
+	// certificate
+	PublicKeyHint          string            `json:"publicKeyHint,omitempty"`
+	TransparencyLogEntries []LogEntrySummary `json:"transparencyLogEntries"`
+	// Timestamps are the times of the bundle's signed timestamps
+	Timestamps []time.Time `json:"timestamps"`
+	// Annotations are the bundle's unauthenticated annotations
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Sizes       Sizes             `json:"sizes"`
+}
+
+// SubjectSummary is a subject of an in-toto statement.
+type SubjectSummary struct {
+	Name string `json:"name"`
+	// Digests are algorithm:hex, sorted by algorithm
+	Digests []string `json:"digests"`
+}
+
+// SignerSummary describes a bundle's signing certificate.
+type SignerSummary struct {
+	certificate.Summary
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+// LogEntrySummary describes a transparency log entry of a bundle.
+type LogEntrySummary struct {
+	LogIndex int64 `json:"logIndex"`
+	// LogID is the hex-encoded ID of the log
+	LogID            string    `json:"logId"`
+	IntegratedTime   time.Time `json:"integratedTime"`
+	Kind             string    `json:"kind"`
+	Version          string    `json:"version"`
+	InclusionProof   bool      `json:"inclusionProof"`
+	InclusionPromise bool      `json:"inclusionPromise"`
+}
+
+// Sizes are the sizes in bytes of parts of a bundle.
+type Sizes struct {
+	// Bundle is the size of the bundle encoded as JSON
+	Bundle    int `json:"bundle"`
+	Signature int `json:"signature"`
+	// Payload is the size of the decoded payload of a DSSE envelope
+	Payload int `json:"payload,omitempty"`
+	// Certificate is the DER-encoded size of the signing certificate
+	Certificate int `json:"certificate,omitempty"`
+}
+
+// Inspect summarizes the contents of a bundle without verifying it, e.g.
+// to print it or to log why a bundle failed verification.
+func Inspect(b *ProtobufBundle) (*Summary, error) {
+	summary := &Summary{
+		MediaType:              b.GetMediaType(),
+		TransparencyLogEntries: []LogEntrySummary{},
+		Timestamps:             []time.Time{},
+	}
+
+	bundleJSON, err := b.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	summary.Sizes.Bundle = len(bundleJSON)
+
+	sigContent, err := b.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	summary.Sizes.Signature = len(sigContent.Signature())
+	if envelope, ok := sigContent.(*Envelope); ok {
+		summary.SignatureKind = SignatureKindDSSEEnvelope
+		summary.PayloadType = envelope.RawEnvelope().PayloadType
+		if payload, err := envelope.DecodedPayload(); err == nil {
+			summary.Sizes.Payload = len(payload)
+		}
+		if statement, err := envelope.Statement(); err == nil {
+			summary.PredicateType = statement.PredicateType
+			for _, subject := range statement.Subject {
+				digests := make([]string, 0, len(subject.Digest))
+				for alg, digest := range subject.Digest {
+					digests = append(digests, alg+":"+digest)
+				}
+				sort.Strings(digests)
+				summary.Subjects = append(summary.Subjects, SubjectSummary{Name: subject.Name, Digests: digests})
+			}
+		}
+	} else if msg := sigContent.MessageSignatureContent(); msg != nil {
+		summary.SignatureKind = SignatureKindMessageSignature
+		summary.MessageDigest = digestAlgorithmName(msg.DigestAlgorithm()) + ":" + hex.EncodeToString(msg.Digest())
+	}
+
+	verificationContent, err := b.VerificationContent()
+	if err != nil {
+		return nil, err
+	}
+	if cert, ok := verificationContent.HasCertificate(); ok {
+		certSummary, err := certificate.SummarizeCertificate(&cert)
+		if err != nil {
+			return nil, err
+		}
+		summary.Signer = &SignerSummary{Summary: certSummary, NotBefore: cert.NotBefore.UTC(), NotAfter: cert.NotAfter.UTC()}
+		summary.Sizes.Certificate = len(cert.Raw)
+	} else if pk, ok := verificationContent.HasPublicKey(); ok {
+		summary.PublicKeyHint = pk.Hint()
+	}
+
+	for _, entry := range b.GetVerificationMaterial().GetTlogEntries() {
+		summary.TransparencyLogEntries = append(summary.TransparencyLogEntries, LogEntrySummary{
+			LogIndex:         entry.GetLogIndex(),
+			LogID:            hex.EncodeToString(entry.GetLogId().GetKeyId()),
+			IntegratedTime:   time.Unix(entry.GetIntegratedTime(), 0).UTC(),
+			Kind:             entry.GetKindVersion().GetKind(),
+			Version:          entry.GetKindVersion().GetVersion(),
+			InclusionProof:   entry.GetInclusionProof() != nil,
+			InclusionPromise: entry.GetInclusionPromise() != nil,
+		})
+	}
+
+	signedTimestamps, err := b.Timestamps()
+	if err != nil {
+		return nil, err
+	}
+	for _, signedTimestamp := range signedTimestamps {
+		ts, err := timestamp.ParseResponse(signedTimestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed timestamp: %w", err)
+		}
+		summary.Timestamps = append(summary.Timestamps, ts.Time.UTC())
+	}
+
+	summary.Annotations, err = b.Annotations()
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// digestAlgorithmName returns the in-toto name of a protobuf hash algorithm,
+// e.g. sha256 for SHA2_256.
+func digestAlgorithmName(algorithm string) string {
+	switch algorithm {
+	case "SHA2_256":
+		return "sha256"
+	case "SHA2_384":
+		return "sha384"
+	case "SHA2_512":
+		return "sha512"
+	default:
+		return strings.ToLower(algorithm)
+	}
+}
+
+// String renders the summary as a human-readable report, with one line per
+// item, suitable for CLI output.
+func (s *Summary) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Media type: %s\n", s.MediaType)
+	switch s.SignatureKind {
+	case SignatureKindDSSEEnvelope:
+		fmt.Fprintf(&sb, "Content: DSSE envelope with payload type %s\n", s.PayloadType)
+		if s.PredicateType != "" {
+			fmt.Fprintf(&sb, "Predicate type: %s\n", s.PredicateType)
+		}
+		for _, subject := range s.Subjects {
+			fmt.Fprintf(&sb, "Subject: %s (%s)\n", subject.Name, strings.Join(subject.Digests, ", "))
+		}
+	case SignatureKindMessageSignature:
+		fmt.Fprintf(&sb, "Content: message signature over %s\n", s.MessageDigest)
+	}
+	if s.Signer != nil {
+		fmt.Fprintf(&sb, "Certificate: %s %q", s.Signer.SubjectAlternativeName.Type, s.Signer.SubjectAlternativeName.Value)
+		if s.Signer.Issuer != "" {
+			fmt.Fprintf(&sb, " from OIDC issuer %q", s.Signer.Issuer)
+		}
+		fmt.Fprintf(&sb, ", issued by %q, valid from %s to %s\n", s.Signer.CertificateIssuer,
+			s.Signer.NotBefore.Format(time.RFC3339), s.Signer.NotAfter.Format(time.RFC3339))
+	}
+	if s.PublicKeyHint != "" {
+		fmt.Fprintf(&sb, "Public key: hint %q\n", s.PublicKeyHint)
+	}
+	for _, entry := range s.TransparencyLogEntries {
+		var proofs []string
+		if entry.InclusionProof {
+			proofs = append(proofs, "inclusion proof")
+		}
+		if entry.InclusionPromise {
+			proofs = append(proofs, "inclusion promise")
+		}
+		if len(proofs) == 0 {
+			proofs = append(proofs, "no proof of inclusion")
+		}
+		fmt.Fprintf(&sb, "Transparency log entry: %s/%s at index %d of log %s, integrated at %s, with %s\n",
+			entry.Kind, entry.Version, entry.LogIndex, entry.LogID, entry.IntegratedTime.Format(time.RFC3339), strings.Join(proofs, " and "))
+	}
+	for _, ts := range s.Timestamps {
+		fmt.Fprintf(&sb, "Signed timestamp: %s\n", ts.Format(time.RFC3339))
+	}
+	keys := make([]string, 0, len(s.Annotations))
+	for key := range s.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "Annotation (unauthenticated): %s=%q\n", key, s.Annotations[key])
+	}
+	fmt.Fprintf(&sb, "Sizes: bundle %d bytes, signature %d bytes", s.Sizes.Bundle, s.Sizes.Signature)
+	if s.Sizes.Payload > 0 {
+		fmt.Fprintf(&sb, ", payload %d bytes", s.Sizes.Payload)
+	}
+	if s.Sizes.Certificate > 0 {
+		fmt.Fprintf(&sb, ", certificate %d bytes", s.Sizes.Certificate)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
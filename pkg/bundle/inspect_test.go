@@ -0,0 +1,95 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+)
+
+func TestInspect(t *testing.T) {
+	summary, err := bundle.Inspect(data.SigstoreJS200ProvenanceBundle(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/vnd.dev.sigstore.bundle+json;version=0.1", summary.MediaType)
+	assert.Equal(t, bundle.SignatureKindDSSEEnvelope, summary.SignatureKind)
+	assert.Equal(t, "application/vnd.in-toto+json", summary.PayloadType)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", summary.PredicateType)
+	require.Len(t, summary.Subjects, 1)
+	assert.Equal(t, "pkg:npm/sigstore@2.0.0", summary.Subjects[0].Name)
+	assert.Equal(t, []string{"sha512:46d4e2f74c4877316640000a6fdf8a8b59f1e0847667973e9859f774dd31b8f1e0937813b777fb66a2ac67d50540fe34640966eee9fc2ccca387082b4c85cd3c"}, summary.Subjects[0].Digests)
+
+	require.NotNil(t, summary.Signer)
+	assert.Equal(t, "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main", summary.Signer.SubjectAlternativeName.Value)
+	assert.Equal(t, "https://token.actions.githubusercontent.com", summary.Signer.Issuer)
+	assert.Equal(t, time.Date(2023, 8, 18, 16, 15, 35, 0, time.UTC), summary.Signer.NotAfter)
+	assert.Empty(t, summary.PublicKeyHint)
+
+	require.Len(t, summary.TransparencyLogEntries, 1)
+	entry := summary.TransparencyLogEntries[0]
+	assert.Equal(t, int64(31821305), entry.LogIndex)
+	assert.Equal(t, "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d", entry.LogID)
+	assert.Equal(t, "intoto", entry.Kind)
+	assert.True(t, entry.InclusionProof)
+	assert.True(t, entry.InclusionPromise)
+	assert.Empty(t, summary.Timestamps)
+
+	assert.Positive(t, summary.Sizes.Bundle)
+	assert.Equal(t, 70, summary.Sizes.Signature)
+	assert.Equal(t, 1025, summary.Sizes.Payload)
+	assert.Equal(t, 1723, summary.Sizes.Certificate)
+
+	text := summary.String()
+	assert.Contains(t, text, "Predicate type: https://slsa.dev/provenance/v1\n")
+	assert.Contains(t, text, "Certificate: URI \"https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main\" from OIDC issuer \"https://token.actions.githubusercontent.com\"")
+	assert.Contains(t, text, "at index 31821305 of log c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d, integrated at 2023-08-18T16:05:35Z, with inclusion proof and inclusion promise\n")
+}
+
+func TestInspectMessageSignature(t *testing.T) {
+	digest := sha256.Sum256([]byte("hello, world"))
+	b := modified(t, data.SigstoreBundle(t), func(pb *protobundle.Bundle) {
+		pb.Content = &protobundle.Bundle_MessageSignature{MessageSignature: &protocommon.MessageSignature{
+			MessageDigest: &protocommon.HashOutput{Algorithm: protocommon.HashAlgorithm_SHA2_256, Digest: digest[:]},
+			Signature:     []byte("signature"),
+		}}
+		pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_PublicKey{PublicKey: &protocommon.PublicKeyIdentifier{Hint: "release-key"}}
+	})
+
+	summary, err := bundle.Inspect(b)
+	require.NoError(t, err)
+	assert.Equal(t, bundle.SignatureKindMessageSignature, summary.SignatureKind)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(digest[:]), summary.MessageDigest)
+	assert.Empty(t, summary.Subjects)
+	assert.Nil(t, summary.Signer)
+	assert.Equal(t, "release-key", summary.PublicKeyHint)
+	assert.Equal(t, len("signature"), summary.Sizes.Signature)
+	assert.Zero(t, summary.Sizes.Payload)
+	assert.Zero(t, summary.Sizes.Certificate)
+
+	text := summary.String()
+	assert.Contains(t, text, "Content: message signature over sha256:"+hex.EncodeToString(digest[:])+"\n")
+	assert.Contains(t, text, "Public key: hint \"release-key\"\n")
+	assert.NotContains(t, text, "Certificate:")
+}
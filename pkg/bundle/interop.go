@@ -0,0 +1,144 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"golang.org/x/mod/semver"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ImportJSON parses a bundle produced by any Sigstore client, such as
+// sigstore-python, sigstore-java or sigstore-js. Unlike UnmarshalJSON,
+// fields unknown to this version of the bundle format are ignored, and the
+// bundle is normalized with Normalize before it is validated. Annotations
+// and detached SCTs, which only sigstore-go writes, are kept.
+func ImportJSON(data []byte) (*ProtobufBundle, error) {
+	data, extensions, err := splitExtensionsJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	pb := new(protobundle.Bundle)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, pb); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	extensions.apply(pb)
+	if err := Normalize(pb); err != nil {
+		return nil, err
+	}
+	return NewProtobufBundle(pb)
+}
+
+// ExportJSON returns the bundle's JSON encoding in the form the other
+// Sigstore clients produce: camelCase field names, unpopulated fields
+// omitted, and no insignificant whitespace. Unlike MarshalJSON, the output
+// is stable across releases of the protobuf library.
+func (b *ProtobufBundle) ExportJSON() ([]byte, error) {
+	data, err := protojson.Marshal(b.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, data); err != nil {
+		return nil, err
+	}
+	return compacted.Bytes(), nil
+}
+
+// Normalize rewrites, in place, the differences between bundles produced
+// by different clients that do not affect verification:
+//
+//   - the media type is rewritten to the form returned by MediaTypeString
+//     for the bundle's version, e.g. "+json;version=0.3" to ".v0.3+json"
+//   - in v0.3 and later bundles, a certificate chain is replaced by its leaf
+//     certificate, as CA certificates must come from the trusted root
+//   - empty timestamp verification data is removed
+//
+// Signed fields, such as a DSSE envelope's payload type, are never
+// rewritten.
+func Normalize(b *protobundle.Bundle) error {
+	version, err := getBundleVersion(b.GetMediaType())
+	if err != nil {
+		return err
+	}
+	b.MediaType, err = MediaTypeString(version)
+	if err != nil {
+		return err
+	}
+
+	if semver.Compare(version, "v0.3") >= 0 {
+		leafOnly(b)
+	}
+
+	if material := b.GetVerificationMaterial(); material != nil {
+		if tvd := material.TimestampVerificationData; tvd != nil && len(tvd.GetRfc3161Timestamps()) == 0 {
+			material.TimestampVerificationData = nil
+		}
+	}
+
+	return nil
+}
+
+// Convert returns a copy of the bundle in the format of another bundle
+// version, such as "v0.3", for clients that only accept that version.
+// Conversion fails if the bundle lacks material the version requires, such
+// as inclusion promises for v0.1 or inclusion proofs for v0.2 and later.
+func Convert(b *ProtobufBundle, version string) (*ProtobufBundle, error) {
+	mediaType, err := MediaTypeString(version)
+	if err != nil {
+		return nil, err
+	}
+	pb, ok := proto.Clone(b.Bundle).(*protobundle.Bundle)
+	if !ok {
+		return nil, errors.New("failed to copy bundle")
+	}
+	pb.MediaType = mediaType
+
+	if semver.Compare("v"+strings.TrimPrefix(version, "v"), "v0.3") >= 0 {
+		leafOnly(pb)
+	} else if cert := pb.GetVerificationMaterial().GetCertificate(); cert != nil {
+		pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_X509CertificateChain{
+			X509CertificateChain: &protocommon.X509CertificateChain{
+				Certificates: []*protocommon.X509Certificate{cert},
+			},
+		}
+	}
+
+	converted, err := NewProtobufBundle(pb)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert bundle to version %s: %w", version, err)
+	}
+	converted.decompression = b.decompression
+	return converted, nil
+}
+
+// leafOnly replaces a certificate chain with its leaf certificate.
+func leafOnly(b *protobundle.Bundle) {
+	chain := b.GetVerificationMaterial().GetX509CertificateChain()
+	if chain == nil || len(chain.GetCertificates()) == 0 {
+		return
+	}
+	b.VerificationMaterial.Content = &protobundle.VerificationMaterial_Certificate{
+		Certificate: chain.GetCertificates()[0],
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// The interop corpus holds the same signed provenance in the variations
+// observed across Sigstore clients: legacy and current media types,
+// certificate chains in v0.3 bundles, protobuf field names, int64 fields
+// as JSON numbers, empty optional messages and unknown fields.
+func TestInteropCorpus(t *testing.T) {
+	paths, err := filepath.Glob("testdata/interop/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	verifier, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe())
+
+	var v03Exports []string
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			b, err := bundle.ImportJSON(contents)
+			require.NoError(t, err)
+			_, err = verifier.Verify(b, policy)
+			require.NoError(t, err)
+
+			// Exported bundles round-trip through strict parsing
+			exported, err := b.ExportJSON()
+			require.NoError(t, err)
+			var roundTripped bundle.ProtobufBundle
+			require.NoError(t, roundTripped.UnmarshalJSON(exported))
+			assert.True(t, proto.Equal(b.Bundle, roundTripped.Bundle))
+
+			// Every variant converts to the same v0.3 bundle
+			converted, err := bundle.Convert(b, "v0.3")
+			require.NoError(t, err)
+			assert.True(t, converted.MinVersion("0.3"))
+			_, err = verifier.Verify(converted, policy)
+			require.NoError(t, err)
+			v03, err := converted.ExportJSON()
+			require.NoError(t, err)
+			v03Exports = append(v03Exports, string(v03))
+		})
+	}
+
+	for _, export := range v03Exports[1:] {
+		assert.Equal(t, v03Exports[0], export)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	contents, err := os.ReadFile("testdata/interop/v0.3-legacy-media-type-chain.json")
+	require.NoError(t, err)
+
+	// Strict parsing rejects a certificate chain in a v0.3 bundle
+	var strict bundle.ProtobufBundle
+	assert.Error(t, strict.UnmarshalJSON(contents))
+
+	b, err := bundle.ImportJSON(contents)
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.dev.sigstore.bundle.v0.3+json", b.MediaType)
+	assert.NotNil(t, b.VerificationMaterial.GetCertificate())
+	assert.Nil(t, b.VerificationMaterial.GetX509CertificateChain())
+
+	_, err = bundle.ImportJSON([]byte(`{"mediaType": "application/vnd.dev.sigstore.bundle+json;version=9"}`))
+	assert.ErrorIs(t, err, bundle.ErrUnsupportedMediaType)
+
+	// The payload type is signed, so it is never filled in
+	pb := proto.Clone(b.Bundle).(*protobundle.Bundle)
+	pb.GetDsseEnvelope().PayloadType = ""
+	require.NoError(t, bundle.Normalize(pb))
+	assert.Equal(t, "", pb.GetDsseEnvelope().PayloadType)
+}
+
+func TestConvert(t *testing.T) {
+	b := data.SigstoreJS200ProvenanceBundle(t)
+
+	v03, err := bundle.Convert(b, "0.3")
+	require.NoError(t, err)
+	assert.NotNil(t, v03.VerificationMaterial.GetCertificate())
+	// The original bundle is unchanged
+	assert.NotNil(t, b.VerificationMaterial.GetX509CertificateChain())
+
+	v01, err := bundle.Convert(v03, "v0.1")
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(b.Bundle, v01.Bundle))
+
+	// v0.2 requires inclusion proofs
+	withoutProof := proto.Clone(b.Bundle).(*protobundle.Bundle)
+	withoutProof.VerificationMaterial.TlogEntries[0].InclusionProof = nil
+	b, err = bundle.NewProtobufBundle(withoutProof)
+	require.NoError(t, err)
+	_, err = bundle.Convert(b, "v0.2")
+	assert.ErrorContains(t, err, "inclusion proof missing")
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrMismatchedContent is returned when merging bundles whose signed
+// content or signing key differ.
+var ErrMismatchedContent = fmt.Errorf("%w: bundles are for different signatures", ErrValidation)
+
+// MergeVerificationMaterial adds the transparency log entries, RFC 3161
+// timestamps and detached SCTs of src that dst does not already have to
+// dst, for example to add timestamps obtained after the bundle was created.
+//
+// Both bundles must hold the same signature over the same content, signed
+// with the same certificate or key; otherwise ErrMismatchedContent is
+// returned. The merged bundle must be valid for dst's version, e.g. log
+// entries merged into a v0.1 bundle must have inclusion promises. If
+// merging fails, dst is left unchanged.
+//
+// Merged material is not verified; the merged bundle must be verified as
+// usual before it is trusted.
+func MergeVerificationMaterial(dst, src *ProtobufBundle) error {
+	if dst == nil || dst.Bundle == nil || src == nil || src.Bundle == nil {
+		return errors.New("bundles to merge must not be nil")
+	}
+	if err := sameSignature(dst.Bundle, src.Bundle); err != nil {
+		return err
+	}
+
+	merged, ok := proto.Clone(dst.Bundle).(*protobundle.Bundle)
+	if !ok {
+		return errors.New("failed to copy bundle")
+	}
+	if merged.VerificationMaterial == nil {
+		merged.VerificationMaterial = &protobundle.VerificationMaterial{}
+	}
+	material := merged.VerificationMaterial
+	srcMaterial := src.GetVerificationMaterial()
+
+	for _, entry := range srcMaterial.GetTlogEntries() {
+		if !hasTlogEntry(material, entry) {
+			material.TlogEntries = append(material.TlogEntries, proto.Clone(entry).(*protorekor.TransparencyLogEntry))
+		}
+	}
+
+	for _, timestamp := range srcMaterial.GetTimestampVerificationData().GetRfc3161Timestamps() {
+		if material.TimestampVerificationData == nil {
+			material.TimestampVerificationData = &protobundle.TimestampVerificationData{}
+		}
+		if !hasTimestamp(material.TimestampVerificationData, timestamp.GetSignedTimestamp()) {
+			material.TimestampVerificationData.Rfc3161Timestamps = append(material.TimestampVerificationData.Rfc3161Timestamps, proto.Clone(timestamp).(*protocommon.RFC3161SignedTimestamp))
+		}
+	}
+
+	detachedSCTs, err := GetDetachedSCTs(merged)
+	if err != nil {
+		return err
+	}
+	srcSCTs, err := GetDetachedSCTs(src.Bundle)
+	if err != nil {
+		return err
+	}
+	for _, sct := range srcSCTs {
+		if !containsBytes(detachedSCTs, sct) {
+			detachedSCTs = append(detachedSCTs, sct)
+		}
+	}
+	SetDetachedSCTs(merged, detachedSCTs)
+
+	result, err := NewProtobufBundle(merged)
+	if err != nil {
+		return fmt.Errorf("merged bundle is invalid: %w", err)
+	}
+	result.decompression = dst.decompression
+	*dst = *result
+	return nil
+}
+
+// sameSignature checks that two bundles hold the same signature over the
+// same content, made with the same key.
+func sameSignature(a, b *protobundle.Bundle) error {
+	if a.GetDsseEnvelope() == nil && a.GetMessageSignature() == nil {
+		return ErrMissingVerificationMaterial
+	}
+	if !proto.Equal(a.GetDsseEnvelope(), b.GetDsseEnvelope()) || !proto.Equal(a.GetMessageSignature(), b.GetMessageSignature()) {
+		return fmt.Errorf("%w: signed content differs", ErrMismatchedContent)
+	}
+
+	aMaterial, bMaterial := a.GetVerificationMaterial(), b.GetVerificationMaterial()
+	if aMaterial.GetPublicKey() != nil || bMaterial.GetPublicKey() != nil {
+		if !proto.Equal(aMaterial.GetPublicKey(), bMaterial.GetPublicKey()) {
+			return fmt.Errorf("%w: public key differs", ErrMismatchedContent)
+		}
+		return nil
+	}
+	// A leaf certificate may be held on its own or at the start of a chain,
+	// depending on the bundle version
+	if !bytes.Equal(leafCertificate(aMaterial), leafCertificate(bMaterial)) {
+		return fmt.Errorf("%w: certificate differs", ErrMismatchedContent)
+	}
+	return nil
+}
+
+func leafCertificate(material *protobundle.VerificationMaterial) []byte {
+	if cert := material.GetCertificate(); cert != nil {
+		return cert.GetRawBytes()
+	}
+	if certs := material.GetX509CertificateChain().GetCertificates(); len(certs) > 0 {
+		return certs[0].GetRawBytes()
+	}
+	return nil
+}
+
+// hasTlogEntry reports whether the material has an entry from the same log
+// at the same index.
+func hasTlogEntry(material *protobundle.VerificationMaterial, entry *protorekor.TransparencyLogEntry) bool {
+	for _, existing := range material.TlogEntries {
+		if bytes.Equal(existing.GetLogId().GetKeyId(), entry.GetLogId().GetKeyId()) && existing.GetLogIndex() == entry.GetLogIndex() {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTimestamp(data *protobundle.TimestampVerificationData, signedTimestamp []byte) bool {
+	for _, existing := range data.Rfc3161Timestamps {
+		if bytes.Equal(existing.GetSignedTimestamp(), signedTimestamp) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBytes(list [][]byte, b []byte) bool {
+	for _, existing := range list {
+		if bytes.Equal(existing, b) {
+			return true
+		}
+	}
+	return false
+}
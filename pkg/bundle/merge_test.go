@@ -0,0 +1,137 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"os"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func leafOnly(pb *protobundle.Bundle) {
+	if chain := pb.VerificationMaterial.GetX509CertificateChain(); chain != nil {
+		pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_Certificate{Certificate: chain.Certificates[0]}
+	}
+}
+
+// modified returns a copy of the bundle changed by modify.
+func modified(t *testing.T, b *bundle.ProtobufBundle, modify func(*protobundle.Bundle)) *bundle.ProtobufBundle {
+	pb, ok := proto.Clone(b.Bundle).(*protobundle.Bundle)
+	require.True(t, ok)
+	modify(pb)
+	modifiedBundle, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+	return modifiedBundle
+}
+
+func TestMergeVerificationMaterial(t *testing.T) {
+	full := data.SigstoreJS200ProvenanceBundle(t)
+	dst := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.VerificationMaterial.TlogEntries = nil
+	})
+
+	verifier, err := verify.NewSignedEntityVerifier(data.PublicGoodTrustedMaterialRoot(t), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe())
+	_, err = verifier.Verify(dst, policy)
+	require.Error(t, err)
+
+	require.NoError(t, bundle.MergeVerificationMaterial(dst, full))
+	_, err = verifier.Verify(dst, policy)
+	assert.NoError(t, err)
+	assert.True(t, dst.HasInclusionProof())
+
+	// Material dst already has is not duplicated
+	require.NoError(t, bundle.MergeVerificationMaterial(dst, full))
+	assert.Len(t, dst.VerificationMaterial.TlogEntries, 1)
+
+	timestamped := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.VerificationMaterial.TimestampVerificationData = &protobundle.TimestampVerificationData{
+			Rfc3161Timestamps: []*protocommon.RFC3161SignedTimestamp{{SignedTimestamp: []byte("timestamp")}},
+		}
+	})
+	require.NoError(t, bundle.MergeVerificationMaterial(dst, timestamped))
+	require.NoError(t, bundle.MergeVerificationMaterial(dst, timestamped))
+	timestamps, err := dst.Timestamps()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("timestamp")}, timestamps)
+	// The source bundle's material is copied, not shared
+	timestamped.VerificationMaterial.TimestampVerificationData.Rfc3161Timestamps[0].SignedTimestamp[0] = 'T'
+	timestamps, err = dst.Timestamps()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("timestamp")}, timestamps)
+}
+
+func TestMergeVerificationMaterialCertificateChain(t *testing.T) {
+	// The same signature in a bundle holding the certificate in a chain
+	contents, err := os.ReadFile("testdata/interop/v0.3-legacy-media-type-chain.json")
+	require.NoError(t, err)
+	chainBundle, err := bundle.ImportJSON(contents)
+	require.NoError(t, err)
+
+	full := data.SigstoreJS200ProvenanceBundle(t)
+	dst := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.VerificationMaterial.TlogEntries = nil
+	})
+	require.NoError(t, bundle.MergeVerificationMaterial(dst, chainBundle))
+	assert.Len(t, dst.VerificationMaterial.TlogEntries, 1)
+	// dst keeps its own certificate
+	assert.True(t, proto.Equal(full.VerificationMaterial.GetX509CertificateChain(), dst.VerificationMaterial.GetX509CertificateChain()))
+}
+
+func TestMergeVerificationMaterialMismatch(t *testing.T) {
+	full := data.SigstoreJS200ProvenanceBundle(t)
+	dst := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.VerificationMaterial.TlogEntries = nil
+	})
+
+	otherSignature := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.GetDsseEnvelope().Signatures[0].Sig = []byte("other")
+	})
+	err := bundle.MergeVerificationMaterial(dst, otherSignature)
+	assert.ErrorIs(t, err, bundle.ErrMismatchedContent)
+	assert.Empty(t, dst.VerificationMaterial.TlogEntries)
+
+	otherCertificate := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.VerificationMaterial.Content = &protobundle.VerificationMaterial_Certificate{
+			Certificate: &protocommon.X509Certificate{RawBytes: []byte("other")},
+		}
+	})
+	err = bundle.MergeVerificationMaterial(dst, otherCertificate)
+	assert.ErrorIs(t, err, bundle.ErrMismatchedContent)
+	assert.Empty(t, dst.VerificationMaterial.TlogEntries)
+
+	// Log entries without inclusion promises can't be merged into a v0.1
+	// bundle
+	v01, err := bundle.Convert(dst, "v0.1")
+	require.NoError(t, err)
+	withoutPromise := modified(t, full, func(pb *protobundle.Bundle) {
+		pb.MediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+		pb.VerificationMaterial.TlogEntries[0].InclusionPromise = nil
+		leafOnly(pb)
+	})
+	err = bundle.MergeVerificationMaterial(v01, withoutPromise)
+	assert.ErrorContains(t, err, "merged bundle is invalid")
+	assert.Empty(t, v01.VerificationMaterial.TlogEntries)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+)
+
+// Attestation is a bundle whose content is a DSSE envelope. Its in-toto
+// statement is decoded when first requested.
+//
+// Attestations are selected before the bundle is verified, so the statement
+// must not be trusted until the bundle has been verified.
+type Attestation struct {
+	Bundle   *ProtobufBundle
+	Envelope *Envelope
+}
+
+// Statement returns the attestation's in-toto statement, decoding it on
+// first use.
+func (a *Attestation) Statement() (*in_toto.Statement, error) {
+	return a.Envelope.Statement()
+}
+
+// AttestationFilter reports whether an attestation's statement should be
+// selected.
+type AttestationFilter func(*in_toto.Statement) bool
+
+// PredicateTypeFilter selects statements with any of the given predicate
+// types.
+func PredicateTypeFilter(predicateTypes ...string) AttestationFilter {
+	return func(statement *in_toto.Statement) bool {
+		for _, predicateType := range predicateTypes {
+			if statement.PredicateType == predicateType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SubjectDigestFilter selects statements with a subject whose digest for
+// the given algorithm, such as "sha256", is digest.
+func SubjectDigestFilter(algorithm string, digest []byte) AttestationFilter {
+	return func(statement *in_toto.Statement) bool {
+		for _, subject := range statement.Subject {
+			subjectDigest, ok := subject.Digest[algorithm]
+			if !ok {
+				continue
+			}
+			if decoded, err := hex.DecodeString(strings.ToLower(subjectDigest)); err == nil && string(decoded) == string(digest) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Attestations returns the bundles whose content is a DSSE envelope,
+// skipping message signature bundles. Statements are not decoded.
+func Attestations(bundles []*ProtobufBundle) []*Attestation {
+	var attestations []*Attestation
+	for _, b := range bundles {
+		envelope, err := b.Envelope()
+		if err != nil {
+			continue
+		}
+		attestations = append(attestations, &Attestation{Bundle: b, Envelope: envelope})
+	}
+	return attestations
+}
+
+// SelectAttestations returns the attestations among bundles whose in-toto
+// statement matches all of the filters, in the order of bundles. Bundles
+// that are not DSSE envelopes, or whose payload is not a valid in-toto
+// statement, are skipped.
+//
+// The selected bundles must still be verified before their statements are
+// used.
+func SelectAttestations(bundles []*ProtobufBundle, filters ...AttestationFilter) []*Attestation {
+	var selected []*Attestation
+	for _, attestation := range Attestations(bundles) {
+		statement, err := attestation.Statement()
+		if err != nil {
+			continue
+		}
+		matches := true
+		for _, filter := range filters {
+			if !filter(statement) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			selected = append(selected, attestation)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func TestSelectAttestations(t *testing.T) {
+	slsaV1, err := bundle.LoadJSONFromPath("../testing/data/sigstore.js@2.0.0-provenanceBundle.json")
+	require.NoError(t, err)
+	slsaV02, err := bundle.LoadJSONFromPath("../testing/data/sigstoreBundle.json")
+	require.NoError(t, err)
+	messageSignature := &bundle.ProtobufBundle{Bundle: &protobundle.Bundle{
+		Content: &protobundle.Bundle_MessageSignature{MessageSignature: &protocommon.MessageSignature{}},
+	}}
+	bundles := []*bundle.ProtobufBundle{messageSignature, slsaV1, slsaV02}
+
+	attestations := bundle.Attestations(bundles)
+	require.Len(t, attestations, 2)
+	assert.Same(t, slsaV1, attestations[0].Bundle)
+	statement, err := attestations[0].Statement()
+	require.NoError(t, err)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", statement.PredicateType)
+
+	assert.Len(t, bundle.SelectAttestations(bundles), 2)
+
+	selected := bundle.SelectAttestations(bundles, bundle.PredicateTypeFilter("https://slsa.dev/provenance/v0.2"))
+	require.Len(t, selected, 1)
+	assert.Same(t, slsaV02, selected[0].Bundle)
+
+	assert.Len(t, bundle.SelectAttestations(bundles, bundle.PredicateTypeFilter("https://slsa.dev/provenance/v0.2", "https://slsa.dev/provenance/v1")), 2)
+	assert.Empty(t, bundle.SelectAttestations(bundles, bundle.PredicateTypeFilter("https://spdx.dev/Document")))
+
+	digest, err := hex.DecodeString("46d4e2f74c4877316640000a6fdf8a8b59f1e0847667973e9859f774dd31b8f1e0937813b777fb66a2ac67d50540fe34640966eee9fc2ccca387082b4c85cd3c")
+	require.NoError(t, err)
+	selected = bundle.SelectAttestations(bundles, bundle.SubjectDigestFilter("sha512", digest))
+	require.Len(t, selected, 1)
+	assert.Same(t, slsaV1, selected[0].Bundle)
+	assert.Empty(t, bundle.SelectAttestations(bundles, bundle.SubjectDigestFilter("sha256", digest)))
+
+	// filters must all match
+	assert.Empty(t, bundle.SelectAttestations(bundles,
+		bundle.SubjectDigestFilter("sha512", digest),
+		bundle.PredicateTypeFilter("https://slsa.dev/provenance/v0.2")))
+}
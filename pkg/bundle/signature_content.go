@@ -17,6 +17,7 @@ package bundle
 import (
 	"encoding/base64"
 	"encoding/json"
+	"sync"
 
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
@@ -47,20 +48,87 @@ func NewMessageSignature(digest []byte, digestAlgorithm string, signature []byte
 	}
 }
 
+// Envelope is a DSSE envelope. Its decoded payload, signatures and in-toto
+// statement are computed at most once, so that repeated verification steps
+// do not decode them again; an Envelope must not be modified after use.
 type Envelope struct {
 	*dsse.Envelope
+
+	decodeOnce sync.Once
+	payload    []byte
+	signatures [][]byte
+	decodeErr  error
+
+	statementOnce sync.Once
+	statement     *in_toto.Statement
+	statementErr  error
+
+	decompression *PayloadDecompressionOptions
+}
+
+func (e *Envelope) decode() {
+	e.decodeOnce.Do(func() {
+		if e.payload == nil {
+			e.payload, e.decodeErr = e.DecodeB64Payload()
+			if e.decodeErr != nil {
+				return
+			}
+		}
+		if e.signatures == nil {
+			e.signatures = make([][]byte, len(e.Envelope.Signatures))
+			for i, sig := range e.Envelope.Signatures {
+				e.signatures[i], e.decodeErr = base64.StdEncoding.DecodeString(sig.Sig)
+				if e.decodeErr != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// DecodedPayload returns the envelope's payload, base64-decoded.
+func (e *Envelope) DecodedPayload() ([]byte, error) {
+	e.decode()
+	return e.payload, e.decodeErr
+}
+
+// DecodedSignatures returns the envelope's signatures, base64-decoded, in
+// the same order as the envelope's Signatures.
+func (e *Envelope) DecodedSignatures() ([][]byte, error) {
+	e.decode()
+	return e.signatures, e.decodeErr
 }
 
+// Statement returns the in-toto statement carried by the envelope. The
+// statement is parsed once and shared between callers, which must not
+// modify it.
 func (e *Envelope) Statement() (*in_toto.Statement, error) {
-	if e.PayloadType != IntotoMediaType {
+	e.statementOnce.Do(func() {
+		e.statement, e.statementErr = e.parseStatement()
+	})
+	return e.statement, e.statementErr
+}
+
+func (e *Envelope) parseStatement() (*in_toto.Statement, error) {
+	payloadType := e.PayloadType
+	if e.decompression == nil && payloadType != IntotoMediaType {
 		return nil, ErrUnsupportedMediaType
 	}
 
 	var statement *in_toto.Statement
-	raw, err := e.DecodeB64Payload()
+	raw, err := e.DecodedPayload()
 	if err != nil {
 		return nil, ErrDecodingB64
 	}
+	if e.decompression != nil {
+		payloadType, raw, err = e.decompression.decompress(payloadType, raw)
+		if err != nil {
+			return nil, err
+		}
+		if payloadType != IntotoMediaType {
+			return nil, ErrUnsupportedMediaType
+		}
+	}
 	err = json.Unmarshal(raw, &statement)
 	if err != nil {
 		return nil, ErrDecodingJSON
@@ -93,14 +161,10 @@ func (m *MessageSignature) Signature() []byte {
 }
 
 func (e *Envelope) Signature() []byte {
-	if len(e.Envelope.Signatures) == 0 {
-		return []byte{}
-	}
-
-	sigBytes, err := base64.StdEncoding.DecodeString(e.Envelope.Signatures[0].Sig)
-	if err != nil {
+	signatures, err := e.DecodedSignatures()
+	if err != nil || len(signatures) == 0 {
 		return []byte{}
 	}
 
-	return sigBytes
+	return signatures[0]
 }
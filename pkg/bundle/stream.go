@@ -0,0 +1,181 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	_ io.WriterTo   = (*ProtobufBundle)(nil)
+	_ io.ReaderFrom = (*ProtobufBundle)(nil)
+)
+
+// WriteTo writes the bundle's JSON encoding to w, in the form returned by
+// ExportJSON. The DSSE payload, which may be tens of megabytes for an SBOM
+// attestation, is base64-encoded directly to w rather than into an
+// intermediate copy of the whole bundle.
+func (b *ProtobufBundle) WriteTo(w io.Writer) (int64, error) {
+	prefix, suffix, payload, err := b.splitJSON()
+	if err != nil {
+		return 0, err
+	}
+
+	cw := &countingWriter{w: w}
+	if _, err := cw.Write(prefix); err != nil {
+		return cw.n, err
+	}
+	if len(payload) > 0 {
+		encoder := base64.NewEncoder(base64.StdEncoding, cw)
+		if _, err := encoder.Write(payload); err != nil {
+			return cw.n, err
+		}
+		if err := encoder.Close(); err != nil {
+			return cw.n, err
+		}
+	}
+	_, err = cw.Write(suffix)
+	return cw.n, err
+}
+
+// JSONSize returns the number of bytes WriteTo writes, so that callers can
+// set a Content-Length or preallocate storage before serializing the bundle.
+// Only the bundle without its DSSE payload is encoded to compute it.
+func (b *ProtobufBundle) JSONSize() (int64, error) {
+	prefix, suffix, payload, err := b.splitJSON()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(prefix) + base64.StdEncoding.EncodedLen(len(payload)) + len(suffix)), nil
+}
+
+// ReadFrom replaces the bundle with one read from the JSON encoding in r,
+// as UnmarshalJSON does. It reads r to EOF; if r reports its length, as
+// bytes.Reader, strings.Reader and os.File do, the input is read into a
+// buffer of exactly that size.
+func (b *ProtobufBundle) ReadFrom(r io.Reader) (int64, error) {
+	var buf bytes.Buffer
+	if size := readerSize(r); size > 0 {
+		buf.Grow(int(size) + bytes.MinRead)
+	}
+	n, err := buf.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+
+	data, extensions, err := splitExtensionsJSON(buf.Bytes())
+	if err != nil {
+		return n, err
+	}
+	pb := new(protobundle.Bundle)
+	if err := protojson.Unmarshal(data, pb); err != nil {
+		return n, err
+	}
+	extensions.apply(pb)
+	parsed, err := NewProtobufBundle(pb)
+	if err != nil {
+		return n, err
+	}
+	*b = *parsed
+	return n, nil
+}
+
+// splitJSON returns the compact JSON encoding of the bundle with its DSSE
+// payload removed, split at the position of the base64-encoded payload.
+func (b *ProtobufBundle) splitJSON() (prefix, suffix, payload []byte, err error) {
+	if b.Bundle == nil {
+		return nil, nil, nil, errors.New("bundle is empty")
+	}
+
+	skeleton := b.Bundle
+	var placeholder []byte
+	if envelope := b.GetDsseEnvelope(); len(envelope.GetPayload()) > 0 {
+		payload = envelope.Payload
+
+		// The payload is replaced with a random placeholder, whose encoding
+		// marks where the payload's encoding is spliced in
+		placeholder = make([]byte, 24)
+		if _, err := rand.Read(placeholder); err != nil {
+			return nil, nil, nil, err
+		}
+		envelopeCopy, ok := proto.Clone(envelope).(*protodsse.Envelope)
+		if !ok {
+			return nil, nil, nil, errors.New("failed to copy DSSE envelope")
+		}
+		envelopeCopy.Payload = placeholder
+		skeleton = &protobundle.Bundle{
+			MediaType:            b.Bundle.MediaType,
+			VerificationMaterial: b.Bundle.VerificationMaterial,
+			Content:              &protobundle.Bundle_DsseEnvelope{DsseEnvelope: envelopeCopy},
+		}
+	}
+
+	data, err := protojson.Marshal(skeleton)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, data); err != nil {
+		return nil, nil, nil, err
+	}
+	encoded := compacted.Bytes()
+	if placeholder == nil {
+		return encoded, nil, nil, nil
+	}
+
+	marker := []byte(base64.StdEncoding.EncodeToString(placeholder))
+	before, after, found := bytes.Cut(encoded, marker)
+	if !found || bytes.Contains(after, marker) {
+		return nil, nil, nil, errors.New("failed to locate DSSE payload in bundle encoding")
+	}
+	return before, after, payload, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readerSize returns the number of bytes remaining in r, or 0 if unknown.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0
+		}
+		return info.Size()
+	}
+	return 0
+}
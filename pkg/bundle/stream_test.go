@@ -0,0 +1,80 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+)
+
+func TestWriteToReadFrom(t *testing.T) {
+	large := proto.Clone(data.SigstoreJS200ProvenanceBundle(t).Bundle).(*protobundle.Bundle)
+	large.GetDsseEnvelope().Payload = make([]byte, 4<<20+1)
+	_, err := rand.Read(large.GetDsseEnvelope().Payload)
+	require.NoError(t, err)
+	largeBundle, err := bundle.NewProtobufBundle(large)
+	require.NoError(t, err)
+
+	for name, b := range map[string]*bundle.ProtobufBundle{
+		"dsse":              data.SigstoreJS200ProvenanceBundle(t),
+		"message signature": data.SigstoreBundle(t),
+		"large payload":     largeBundle,
+	} {
+		t.Run(name, func(t *testing.T) {
+			expected, err := b.ExportJSON()
+			require.NoError(t, err)
+
+			size, err := b.JSONSize()
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(expected)), size)
+
+			var buf bytes.Buffer
+			n, err := b.WriteTo(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, size, n)
+			assert.Equal(t, expected, buf.Bytes())
+
+			var read bundle.ProtobufBundle
+			n, err = read.ReadFrom(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, size, n)
+			assert.True(t, proto.Equal(b.Bundle, read.Bundle))
+		})
+	}
+}
+
+func TestReadFromInvalid(t *testing.T) {
+	var b bundle.ProtobufBundle
+	_, err := b.ReadFrom(bytes.NewReader([]byte(`{"mediaType": "application/vnd.dev.sigstore.bundle.v0.9+json"}`)))
+	assert.ErrorIs(t, err, bundle.ErrUnsupportedMediaType)
+
+	_, err = b.ReadFrom(bytes.NewReader([]byte(`{`)))
+	assert.Error(t, err)
+}
+
+func TestWriteToEmpty(t *testing.T) {
+	var b bundle.ProtobufBundle
+	_, err := b.WriteTo(&bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -25,6 +25,9 @@ import (
 
 type Certificate struct {
 	*x509.Certificate
+	// DetachedSCTs holds SCTs issued for the certificate that are not
+	// embedded in it
+	DetachedSCTs [][]byte
 }
 
 type PublicKey struct {
@@ -52,6 +55,10 @@ func (c *Certificate) HasCertificate() (x509.Certificate, bool) {
 	return *c.Certificate, true
 }
 
+func (c *Certificate) HasDetachedSCTs() ([][]byte, bool) {
+	return c.DetachedSCTs, len(c.DetachedSCTs) > 0
+}
+
 func (c *Certificate) HasPublicKey() (verify.PublicKeyProvider, bool) {
 	return PublicKey{}, false
 }
@@ -0,0 +1,362 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config builds signing and verification options from a YAML
+// configuration file and environment variables, so that services and CI
+// jobs can be configured without their own flag handling.
+//
+// Environment variables override values from the file:
+//
+//	SIGSTORE_CONFIG                   path of the YAML configuration file
+//	SIGSTORE_FULCIO_URL               signing.fulcioURL
+//	SIGSTORE_REKOR_URLS               signing.rekorURLs (comma-separated)
+//	SIGSTORE_TSA_URLS                 signing.timestampAuthorityURLs (comma-separated)
+//	SIGSTORE_ID_TOKEN                 signing.idToken
+//	SIGSTORE_ID_TOKEN_PATH            signing.idTokenPath
+//	SIGSTORE_TIMEOUT                  signing.timeout (e.g. "30s")
+//	SIGSTORE_TRUSTED_ROOT             verification.trustedRootPath
+//	SIGSTORE_TUF_URL                  verification.tufURL
+//	SIGSTORE_TUF_ROOT                 verification.tufRootPath
+//	SIGSTORE_TUF_CACHE                verification.tufCachePath
+//	SIGSTORE_CERT_IDENTITY            identity SAN, added to verification.identities
+//	SIGSTORE_CERT_IDENTITY_REGEXP     identity SAN regular expression
+//	SIGSTORE_CERT_OIDC_ISSUER         identity OIDC issuer
+//	SIGSTORE_TLOG_THRESHOLD           verification.transparencyLogThreshold
+//	SIGSTORE_SCT_THRESHOLD            verification.sctThreshold
+//	SIGSTORE_TIMESTAMP_THRESHOLD      verification.observerTimestampThreshold
+//	SIGSTORE_ONLINE_VERIFICATION      verification.online ("true" or "false")
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// ConfigPathEnv names the environment variable holding the path of the YAML
+// configuration file read by Load.
+const ConfigPathEnv = "SIGSTORE_CONFIG"
+
+type Config struct {
+	Signing      SigningConfig      `yaml:"signing"`
+	Verification VerificationConfig `yaml:"verification"`
+}
+
+type SigningConfig struct {
+	// URL of the Fulcio instance; if unset, bundles are signed with the
+	// keypair's public key
+	FulcioURL string `yaml:"fulcioURL"`
+	// URLs of Rekor instances to upload to
+	RekorURLs []string `yaml:"rekorURLs"`
+	// URLs of timestamp authorities to request signed timestamps from
+	TimestampAuthorityURLs []string `yaml:"timestampAuthorityURLs"`
+	// OIDC identity token to send to Fulcio. Prefer IDTokenPath, or the
+	// SIGSTORE_ID_TOKEN environment variable, to keep tokens out of
+	// configuration files.
+	IDToken string `yaml:"idToken"`
+	// Path of a file holding the OIDC identity token, read when building
+	// bundle options, as with projected service account tokens
+	IDTokenPath string `yaml:"idTokenPath"`
+	// Timeout for requests to each service
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type VerificationConfig struct {
+	// Path of a trusted root JSON file; takes precedence over TUF
+	TrustedRootPath string `yaml:"trustedRootPath"`
+	// URL of the TUF repository to fetch the trusted root from; defaults to
+	// the public good instance
+	TUFURL string `yaml:"tufURL"`
+	// Path of the TUF root.json to bootstrap trust in TUFURL; required if
+	// TUFURL is not the public good instance
+	TUFRootPath string `yaml:"tufRootPath"`
+	// Path of the TUF cache directory
+	TUFCachePath string `yaml:"tufCachePath"`
+	// Identities trusted to have signed; verification succeeds if any of
+	// them matches
+	Identities []Identity `yaml:"identities"`
+	// Thresholds of verification material to require; nil uses the
+	// default of 1
+	TransparencyLogThreshold   *int `yaml:"transparencyLogThreshold"`
+	SCTThreshold               *int `yaml:"sctThreshold"`
+	ObserverTimestampThreshold *int `yaml:"observerTimestampThreshold"`
+	// Verify transparency log entries against the log online
+	Online bool `yaml:"online"`
+}
+
+// Identity is a certificate identity trusted to have signed, as accepted
+// by verify.NewShortCertificateIdentity.
+type Identity struct {
+	Issuer    string `yaml:"issuer"`
+	SAN       string `yaml:"san"`
+	SANRegexp string `yaml:"sanRegexp"`
+	SANType   string `yaml:"sanType"`
+}
+
+// Load reads the configuration file named by SIGSTORE_CONFIG, if set, and
+// applies overrides from the environment.
+func Load() (*Config, error) {
+	c := &Config{}
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		var err error
+		c, err = LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := c.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadFile reads a YAML configuration file. Unknown keys are rejected to
+// catch typos.
+func LoadFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c := &Config{}
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ApplyEnv overrides the configuration with the environment variables
+// listed in the package documentation, looked up with lookupEnv (for
+// example os.LookupEnv).
+func (c *Config) ApplyEnv(lookupEnv func(string) (string, bool)) error {
+	str := func(name string, out *string) {
+		if v, ok := lookupEnv(name); ok {
+			*out = v
+		}
+	}
+	list := func(name string, out *[]string) {
+		if v, ok := lookupEnv(name); ok {
+			*out = splitList(v)
+		}
+	}
+	threshold := func(name string, out **int) error {
+		v, ok := lookupEnv(name)
+		if !ok {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s: invalid threshold %q", name, v)
+		}
+		*out = &n
+		return nil
+	}
+
+	str("SIGSTORE_FULCIO_URL", &c.Signing.FulcioURL)
+	list("SIGSTORE_REKOR_URLS", &c.Signing.RekorURLs)
+	list("SIGSTORE_TSA_URLS", &c.Signing.TimestampAuthorityURLs)
+	str("SIGSTORE_ID_TOKEN", &c.Signing.IDToken)
+	str("SIGSTORE_ID_TOKEN_PATH", &c.Signing.IDTokenPath)
+	if v, ok := lookupEnv("SIGSTORE_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SIGSTORE_TIMEOUT: %w", err)
+		}
+		c.Signing.Timeout = timeout
+	}
+
+	str("SIGSTORE_TRUSTED_ROOT", &c.Verification.TrustedRootPath)
+	str("SIGSTORE_TUF_URL", &c.Verification.TUFURL)
+	str("SIGSTORE_TUF_ROOT", &c.Verification.TUFRootPath)
+	str("SIGSTORE_TUF_CACHE", &c.Verification.TUFCachePath)
+
+	var id Identity
+	str("SIGSTORE_CERT_IDENTITY", &id.SAN)
+	str("SIGSTORE_CERT_IDENTITY_REGEXP", &id.SANRegexp)
+	str("SIGSTORE_CERT_OIDC_ISSUER", &id.Issuer)
+	if id != (Identity{}) {
+		c.Verification.Identities = append(c.Verification.Identities, id)
+	}
+
+	if err := threshold("SIGSTORE_TLOG_THRESHOLD", &c.Verification.TransparencyLogThreshold); err != nil {
+		return err
+	}
+	if err := threshold("SIGSTORE_SCT_THRESHOLD", &c.Verification.SCTThreshold); err != nil {
+		return err
+	}
+	if err := threshold("SIGSTORE_TIMESTAMP_THRESHOLD", &c.Verification.ObserverTimestampThreshold); err != nil {
+		return err
+	}
+	if v, ok := lookupEnv("SIGSTORE_ONLINE_VERIFICATION"); ok {
+		online, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("SIGSTORE_ONLINE_VERIFICATION: %w", err)
+		}
+		c.Verification.Online = online
+	}
+	return nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// BundleOptions returns options for sign.Bundle that use the configured
+// services.
+func (c *Config) BundleOptions() (sign.BundleOptions, error) {
+	opts := sign.BundleOptions{}
+
+	var clientOpts []sign.ClientOption
+	if c.Signing.Timeout > 0 {
+		clientOpts = append(clientOpts, sign.WithTimeout(c.Signing.Timeout))
+	}
+
+	if c.Signing.FulcioURL != "" {
+		idToken := c.Signing.IDToken
+		if idToken == "" && c.Signing.IDTokenPath != "" {
+			tokenBytes, err := os.ReadFile(c.Signing.IDTokenPath)
+			if err != nil {
+				return opts, fmt.Errorf("failed to read identity token: %w", err)
+			}
+			idToken = strings.TrimSpace(string(tokenBytes))
+		}
+		if idToken == "" {
+			return opts, errors.New("an identity token is required to use Fulcio")
+		}
+		fulcio, err := sign.NewFulcioClient(c.Signing.FulcioURL, clientOpts...)
+		if err != nil {
+			return opts, err
+		}
+		opts.Fulcio = fulcio
+		opts.IDToken = idToken
+	}
+
+	for _, url := range c.Signing.RekorURLs {
+		rekor, err := sign.NewRekorClient(url, clientOpts...)
+		if err != nil {
+			return opts, err
+		}
+		opts.Rekors = append(opts.Rekors, rekor)
+	}
+
+	for _, url := range c.Signing.TimestampAuthorityURLs {
+		tsa, err := sign.NewTimestampAuthorityClient(url, clientOpts...)
+		if err != nil {
+			return opts, err
+		}
+		opts.TimestampAuthorities = append(opts.TimestampAuthorities, tsa)
+	}
+
+	return opts, nil
+}
+
+// TrustedMaterial loads the trusted root from TrustedRootPath if set, and
+// from the TUF repository otherwise.
+func (c *Config) TrustedMaterial() (root.TrustedMaterial, error) {
+	if c.Verification.TrustedRootPath != "" {
+		return root.NewTrustedRootFromPath(c.Verification.TrustedRootPath)
+	}
+
+	opts := tuf.DefaultOptions()
+	if c.Verification.TUFURL != "" {
+		opts.RepositoryBaseURL = c.Verification.TUFURL
+	}
+	if c.Verification.TUFRootPath != "" {
+		rootJSON, err := os.ReadFile(c.Verification.TUFRootPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TUF root: %w", err)
+		}
+		opts.Root = rootJSON
+	} else if c.Verification.TUFURL != "" && c.Verification.TUFURL != tuf.DefaultMirror {
+		return nil, errors.New("a TUF root is required for a TUF repository other than the public good instance")
+	}
+	if c.Verification.TUFCachePath != "" {
+		opts.CachePath = c.Verification.TUFCachePath
+	}
+
+	return root.FetchTrustedRootWithOptions(opts)
+}
+
+// VerifierOptions returns options for verify.NewSignedEntityVerifier.
+func (c *Config) VerifierOptions() []verify.VerifierOption {
+	orDefault := func(threshold *int) int {
+		if threshold == nil {
+			return 1
+		}
+		return *threshold
+	}
+
+	var opts []verify.VerifierOption
+	if n := orDefault(c.Verification.TransparencyLogThreshold); n > 0 {
+		opts = append(opts, verify.WithTransparencyLog(n))
+	}
+	if n := orDefault(c.Verification.SCTThreshold); n > 0 {
+		opts = append(opts, verify.WithSignedCertificateTimestamps(n))
+	}
+	if n := orDefault(c.Verification.ObserverTimestampThreshold); n > 0 {
+		opts = append(opts, verify.WithObserverTimestamps(n))
+	}
+	if c.Verification.Online {
+		opts = append(opts, verify.WithOnlineVerification())
+	}
+	return opts
+}
+
+// PolicyOptions returns a policy option per configured identity. An error
+// is returned if no identity is configured, since verifying without an
+// identity policy must be an explicit choice.
+func (c *Config) PolicyOptions() ([]verify.PolicyOption, error) {
+	if len(c.Verification.Identities) == 0 {
+		return nil, errors.New("no identities configured")
+	}
+
+	opts := make([]verify.PolicyOption, 0, len(c.Verification.Identities))
+	for i, id := range c.Verification.Identities {
+		certID, err := verify.NewShortCertificateIdentity(id.Issuer, id.SAN, id.SANType, id.SANRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("identity %d: %w", i, err)
+		}
+		opts = append(opts, verify.WithCertificateIdentity(certID))
+	}
+	return opts, nil
+}
+
+// SignedEntityVerifier returns a verifier using the configured trusted
+// material and verifier options.
+func (c *Config) SignedEntityVerifier() (*verify.SignedEntityVerifier, error) {
+	trustedMaterial, err := c.TrustedMaterial()
+	if err != nil {
+		return nil, err
+	}
+	return verify.NewSignedEntityVerifier(trustedMaterial, c.VerifierOptions()...)
+}
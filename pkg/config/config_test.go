@@ -0,0 +1,114 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sigstore.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+signing:
+  fulcioURL: https://fulcio.example.com
+  rekorURLs: [https://rekor.example.com]
+  timeout: 30s
+verification:
+  trustedRootPath: ../../examples/trusted-root-public-good.json
+  sctThreshold: 0
+  identities:
+    - issuer: https://token.actions.githubusercontent.com
+      sanRegexp: ^https://github.com/sigstore/
+`), 0o600))
+
+	c, err := LoadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, c.Signing.Timeout)
+	assert.Equal(t, []string{"https://rekor.example.com"}, c.Signing.RekorURLs)
+	assert.Len(t, c.Verification.Identities, 1)
+
+	assert.NoError(t, c.ApplyEnv(lookupFrom(map[string]string{
+		"SIGSTORE_REKOR_URLS":       "https://rekor1.example.com, https://rekor2.example.com",
+		"SIGSTORE_TSA_URLS":         "https://tsa.example.com/api/v1/timestamp",
+		"SIGSTORE_ID_TOKEN":         "token",
+		"SIGSTORE_CERT_IDENTITY":    "jdoe@example.com",
+		"SIGSTORE_CERT_OIDC_ISSUER": "https://accounts.example.com",
+		"SIGSTORE_TLOG_THRESHOLD":   "2",
+	})))
+	assert.Equal(t, []string{"https://rekor1.example.com", "https://rekor2.example.com"}, c.Signing.RekorURLs)
+	assert.Len(t, c.Verification.Identities, 2)
+	assert.Equal(t, 2, *c.Verification.TransparencyLogThreshold)
+
+	bundleOpts, err := c.BundleOptions()
+	assert.NoError(t, err)
+	assert.NotNil(t, bundleOpts.Fulcio)
+	assert.Equal(t, "token", bundleOpts.IDToken)
+	assert.Len(t, bundleOpts.Rekors, 2)
+	assert.Len(t, bundleOpts.TimestampAuthorities, 1)
+
+	// Transparency log and observer timestamps; SCTs disabled
+	assert.Len(t, c.VerifierOptions(), 2)
+
+	policyOpts, err := c.PolicyOptions()
+	assert.NoError(t, err)
+	assert.Len(t, policyOpts, 2)
+
+	verifier, err := c.SignedEntityVerifier()
+	assert.NoError(t, err)
+	assert.NotNil(t, verifier)
+}
+
+func TestConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sigstore.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("signing:\n  fulcioUrl: https://fulcio.example.com\n"), 0o600))
+	_, err := LoadFile(path)
+	assert.ErrorContains(t, err, "field fulcioUrl not found")
+
+	c := &Config{}
+	assert.ErrorContains(t, c.ApplyEnv(lookupFrom(map[string]string{"SIGSTORE_SCT_THRESHOLD": "-1"})), "invalid threshold")
+	assert.ErrorContains(t, c.ApplyEnv(lookupFrom(map[string]string{"SIGSTORE_TIMEOUT": "soon"})), "SIGSTORE_TIMEOUT")
+
+	c = &Config{Signing: SigningConfig{FulcioURL: "https://fulcio.example.com"}}
+	_, err = c.BundleOptions()
+	assert.ErrorContains(t, err, "identity token is required")
+
+	tokenPath := filepath.Join(dir, "token")
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("file-token\n"), 0o600))
+	c.Signing.IDTokenPath = tokenPath
+	bundleOpts, err := c.BundleOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, "file-token", bundleOpts.IDToken)
+
+	_, err = c.PolicyOptions()
+	assert.ErrorContains(t, err, "no identities configured")
+
+	c.Verification.TUFURL = "https://tuf.example.com"
+	_, err = c.TrustedMaterial()
+	assert.ErrorContains(t, err, "TUF root is required")
+}
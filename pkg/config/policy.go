@@ -0,0 +1,235 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// ErrNoMatchingPolicy is returned when no rule of a policy applies to an
+// artifact.
+var ErrNoMatchingPolicy = errors.New("no policy rule matches the artifact")
+
+// PolicyFile is a YAML or JSON file of the identities trusted to sign
+// artifacts, for example:
+//
+//	rules:
+//	  - artifacts: ["release/*.tar.gz"]
+//	    issuers: [https://token.actions.githubusercontent.com]
+//	    identities:
+//	      - sanRegexp: ^https://github.com/example/project/
+//	        extensions:
+//	          sourceRepositoryRef: refs/heads/main
+//	  - identities:
+//	      - issuer: https://accounts.example.com
+//	        san: release-bot@example.com
+//
+// The first rule with an artifact pattern matching an artifact's name
+// applies to it.
+type PolicyFile struct {
+	Rules []PolicyRule `yaml:"rules" json:"rules"`
+}
+
+// PolicyRule lists the identities trusted to sign some artifacts.
+type PolicyRule struct {
+	// Patterns of the artifact names the rule applies to, in the syntax of
+	// path.Match; a rule without patterns applies to all artifacts
+	Artifacts []string `yaml:"artifacts" json:"artifacts"`
+	// OIDC issuers of identities without an issuer of their own
+	Issuers []string `yaml:"issuers" json:"issuers"`
+	// Identities trusted to sign the artifacts; verification succeeds if
+	// any of them matches
+	Identities []PolicyIdentity `yaml:"identities" json:"identities"`
+}
+
+// PolicyIdentity is a certificate identity of a PolicyRule.
+type PolicyIdentity struct {
+	// Optional OIDC issuer; defaults to each of the rule's issuers
+	Issuer    string `yaml:"issuer" json:"issuer"`
+	SAN       string `yaml:"san" json:"san"`
+	SANRegexp string `yaml:"sanRegexp" json:"sanRegexp"`
+	SANType   string `yaml:"sanType" json:"sanType"`
+	// Values required of Fulcio certificate extensions, keyed by the JSON
+	// names of the fields of certificate.Extensions, e.g.
+	// "sourceRepositoryURI"
+	Extensions map[string]string `yaml:"extensions" json:"extensions"`
+	// Values required of custom extensions, keyed by the names they are
+	// registered under in the registry given to verify.WithExtensionRegistry
+	CustomExtensions map[string]string `yaml:"customExtensions" json:"customExtensions"`
+}
+
+// Policy is a compiled PolicyFile.
+type Policy struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	artifacts  []string
+	identities verify.CertificateIdentities
+}
+
+// LoadPolicyFile reads and compiles a policy file. JSON is parsed as YAML,
+// of which it is a subset. Unknown keys are rejected to catch typos.
+func LoadPolicyFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("policy file %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy parses and compiles a YAML or JSON policy file.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var file PolicyFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	return file.Compile()
+}
+
+// Compile checks the policy file and builds the certificate identities of
+// its rules.
+func (f *PolicyFile) Compile() (*Policy, error) {
+	if len(f.Rules) == 0 {
+		return nil, errors.New("policy has no rules")
+	}
+	policy := &Policy{}
+	for i, rule := range f.Rules {
+		compiled, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		policy.rules = append(policy.rules, compiled)
+	}
+	return policy, nil
+}
+
+func (r *PolicyRule) compile() (compiledRule, error) {
+	for _, pattern := range r.Artifacts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return compiledRule{}, fmt.Errorf("invalid artifact pattern %q: %w", pattern, err)
+		}
+	}
+	if len(r.Identities) == 0 {
+		return compiledRule{}, errors.New("no identities")
+	}
+
+	compiled := compiledRule{artifacts: r.Artifacts}
+	for i, id := range r.Identities {
+		issuers := r.Issuers
+		if id.Issuer != "" {
+			issuers = []string{id.Issuer}
+		}
+		if len(issuers) == 0 {
+			return compiledRule{}, fmt.Errorf("identity %d: no issuer", i)
+		}
+		extensions, err := policyExtensions(id.Extensions)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("identity %d: %w", i, err)
+		}
+		sanMatcher, err := verify.NewSANMatcher(id.SAN, id.SANType, id.SANRegexp)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("identity %d: %w", i, err)
+		}
+		for _, issuer := range issuers {
+			extensions.Issuer = issuer
+			certID, err := verify.NewCertificateIdentity(sanMatcher, extensions)
+			if err != nil {
+				return compiledRule{}, fmt.Errorf("identity %d: %w", i, err)
+			}
+			if len(id.CustomExtensions) > 0 {
+				certID = certID.WithCustomExtensions(id.CustomExtensions)
+			}
+			compiled.identities = append(compiled.identities, certID)
+		}
+	}
+	return compiled, nil
+}
+
+// policyExtensions converts extension values keyed by their JSON names to
+// certificate.Extensions, rejecting unknown names.
+func policyExtensions(values map[string]string) (certificate.Extensions, error) {
+	var extensions certificate.Extensions
+	if len(values) == 0 {
+		return extensions, nil
+	}
+	if _, ok := values["issuer"]; ok {
+		return extensions, errors.New("the issuer is not an extension constraint; use the issuer of the identity or rule")
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return extensions, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&extensions); err != nil {
+		return extensions, fmt.Errorf("invalid extensions: %w", err)
+	}
+	return extensions, nil
+}
+
+func (r *compiledRule) matches(artifactName string) bool {
+	if len(r.artifacts) == 0 {
+		return true
+	}
+	for _, pattern := range r.artifacts {
+		// Patterns were checked when compiling
+		if matched, _ := path.Match(pattern, artifactName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Identities returns the identities trusted to sign the named artifact,
+// from the first rule that applies to it.
+func (p *Policy) Identities(artifactName string) (verify.CertificateIdentities, error) {
+	for _, rule := range p.rules {
+		if rule.matches(artifactName) {
+			return rule.identities, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrNoMatchingPolicy, artifactName)
+}
+
+// PolicyOptions returns a policy option per identity trusted to sign the
+// named artifact, for verify.NewPolicy.
+func (p *Policy) PolicyOptions(artifactName string) ([]verify.PolicyOption, error) {
+	identities, err := p.Identities(artifactName)
+	if err != nil {
+		return nil, err
+	}
+	opts := make([]verify.PolicyOption, 0, len(identities))
+	for _, id := range identities {
+		opts = append(opts, verify.WithCertificateIdentity(id))
+	}
+	return opts, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const testPolicyYAML = `
+rules:
+  - artifacts: ["release/*.tar.gz", "release/*.zip"]
+    issuers: [https://issuer1.example.com, https://issuer2.example.com]
+    identities:
+      - sanRegexp: ^release-.*@example.com$
+      - issuer: https://accounts.example.com
+        san: admin@example.com
+        extensions:
+          sourceRepositoryURI: https://github.com/example/project
+  - identities:
+      - issuer: https://accounts.example.com
+        san: dev@example.com
+`
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicyYAML))
+	require.NoError(t, err)
+
+	// One identity per issuer of the rule, and one with its own issuer
+	identities, err := policy.Identities("release/app.tar.gz")
+	require.NoError(t, err)
+	require.Len(t, identities, 3)
+	assert.Equal(t, "https://issuer1.example.com", identities[0].Issuer)
+	assert.Equal(t, "https://issuer2.example.com", identities[1].Issuer)
+	assert.Equal(t, "https://accounts.example.com", identities[2].Issuer)
+	assert.Equal(t, "https://github.com/example/project", identities[2].SourceRepositoryURI)
+
+	identities, err = policy.Identities("nightly/app.tar.gz")
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, "dev@example.com", identities[0].SubjectAlternativeName.SubjectAlternativeName.Value)
+
+	opts, err := policy.PolicyOptions("release/app.zip")
+	require.NoError(t, err)
+	assert.Len(t, opts, 3)
+
+	// JSON is accepted as well
+	policy, err = ParsePolicy([]byte(`{"rules": [{"artifacts": ["*.whl"], "identities": [{"issuer": "https://accounts.example.com", "san": "dev@example.com"}]}]}`))
+	require.NoError(t, err)
+	_, err = policy.Identities("app.tar.gz")
+	assert.ErrorIs(t, err, ErrNoMatchingPolicy)
+
+	for name, invalid := range map[string]string{
+		"empty":             `rules: []`,
+		"unknown key":       `{"rules": [{"identity": [{"issuer": "https://accounts.example.com", "san": "dev@example.com"}]}]}`,
+		"no identities":     `{"rules": [{"artifacts": ["*"]}]}`,
+		"no issuer":         `{"rules": [{"identities": [{"san": "dev@example.com"}]}]}`,
+		"no SAN":            `{"rules": [{"identities": [{"issuer": "https://accounts.example.com"}]}]}`,
+		"invalid regexp":    `{"rules": [{"identities": [{"issuer": "https://accounts.example.com", "sanRegexp": "("}]}]}`,
+		"invalid pattern":   `{"rules": [{"artifacts": ["["], "identities": [{"issuer": "https://accounts.example.com", "san": "dev@example.com"}]}]}`,
+		"unknown extension": `{"rules": [{"identities": [{"issuer": "https://accounts.example.com", "san": "dev@example.com", "extensions": {"repository": "x"}}]}]}`,
+		"issuer extension":  `{"rules": [{"identities": [{"issuer": "https://accounts.example.com", "san": "dev@example.com", "extensions": {"issuer": "x"}}]}]}`,
+	} {
+		_, err := ParsePolicy([]byte(invalid))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestPolicyVerification(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	artifact := []byte("release")
+	entity, err := virtualSigstore.Sign("release-bot@example.com", "https://issuer2.example.com", artifact)
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+
+	policy, err := ParsePolicy([]byte(testPolicyYAML))
+	require.NoError(t, err)
+
+	opts, err := policy.PolicyOptions("release/app.tar.gz")
+	require.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), opts...))
+	assert.NoError(t, err)
+
+	opts, err = policy.PolicyOptions("nightly/app.tar.gz")
+	require.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), opts...))
+	assert.Error(t, err)
+}
+
+func TestWatchPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicyYAML), 0o600))
+
+	reloads := make(chan error, 10)
+	watcher, err := WatchPolicyFile(path, func(_ *Policy, err error) {
+		reloads <- err
+	})
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	_, err = watcher.Policy().Identities("nightly/app.tar.gz")
+	require.NoError(t, err)
+
+	waitForReload := func() error {
+		select {
+		case err := <-reloads:
+			return err
+		case <-time.After(10 * time.Second):
+			t.Fatal("policy was not reloaded")
+			return nil
+		}
+	}
+
+	// Replaced by renaming, as editors do
+	tmp := filepath.Join(dir, "policy.yaml.tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte(`{"rules": [{"artifacts": ["release/*"], "identities": [{"issuer": "https://accounts.example.com", "san": "dev@example.com"}]}]}`), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+	require.NoError(t, waitForReload())
+	_, err = watcher.Policy().Identities("nightly/app.tar.gz")
+	assert.ErrorIs(t, err, ErrNoMatchingPolicy)
+
+	// An invalid file keeps the previous policy
+	require.NoError(t, os.WriteFile(path, []byte(`rules: [`), 0o600))
+	assert.Error(t, waitForReload())
+	_, err = watcher.Policy().Identities("release/app.tar.gz")
+	assert.NoError(t, err)
+
+	assert.NoError(t, watcher.Close())
+	_, err = watcher.Policy().Identities("release/app.tar.gz")
+	assert.NoError(t, err)
+}
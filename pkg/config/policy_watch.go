@@ -0,0 +1,148 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// PolicyWatcher keeps a policy up to date with its policy file, for
+// long-running services that must pick up policy changes without a
+// restart. It is safe for concurrent use.
+type PolicyWatcher struct {
+	path      string
+	onReload  func(*Policy, error)
+	policy    atomic.Pointer[Policy]
+	watcher   *fsnotify.Watcher
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	// contents of the file the current policy was compiled from
+	contents []byte
+}
+
+// WatchPolicyFile loads a policy file and reloads it whenever it changes.
+// A reload that fails, for example because the new file is invalid, keeps
+// the previous policy. onReload, which may be nil, is called after each
+// reload attempt with the new policy or the error.
+//
+// The file's directory is watched rather than the file itself, so that
+// files replaced by renaming, as editors and Kubernetes ConfigMap volumes
+// do, are reloaded too. The watcher must be closed when no longer used.
+func WatchPolicyFile(path string, onReload func(*Policy, error)) (*PolicyWatcher, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParsePolicy(contents)
+	if err != nil {
+		return nil, fmt.Errorf("policy file %s: %w", path, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w := &PolicyWatcher{
+		path:     path,
+		onReload: onReload,
+		watcher:  watcher,
+		done:     make(chan struct{}),
+		contents: contents,
+	}
+	w.policy.Store(policy)
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Policy returns the current policy.
+func (w *PolicyWatcher) Policy() *Policy {
+	return w.policy.Load()
+}
+
+// Close stops watching the policy file. The current policy remains
+// available.
+func (w *PolicyWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+		w.wg.Wait()
+	})
+	return err
+}
+
+func (w *PolicyWatcher) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.done:
+			return
+		case _, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// Other files in the directory may be how the policy file
+			// changes, e.g. the "..data" symlink of ConfigMap volumes, so
+			// any event is checked against the file's contents
+			w.reload()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			if w.onReload != nil {
+				w.onReload(nil, fmt.Errorf("watching policy file %s: %w", w.path, err))
+			}
+		}
+	}
+}
+
+func (w *PolicyWatcher) reload() {
+	contents, err := os.ReadFile(w.path)
+	if os.IsNotExist(err) {
+		// The file is being replaced, and will be reloaded once it exists
+		return
+	}
+	if err == nil && bytes.Equal(contents, w.contents) {
+		return
+	}
+	var policy *Policy
+	if err == nil {
+		policy, err = ParsePolicy(contents)
+	}
+	if err != nil {
+		err = fmt.Errorf("policy file %s: %w", w.path, err)
+	} else {
+		w.contents = contents
+		w.policy.Store(policy)
+	}
+	if w.onReload != nil {
+		w.onReload(policy, err)
+	}
+}
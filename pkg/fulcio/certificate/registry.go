@@ -0,0 +1,149 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ExtensionDecoder decodes the value of a custom certificate extension.
+type ExtensionDecoder func(value []byte) (string, error)
+
+// DecodeDERString decodes an extension value holding a DER-encoded UTF8
+// string, as the Fulcio extensions since OID 1.3.6.1.4.1.57264.1.8 do.
+func DecodeDERString(value []byte) (string, error) {
+	var s string
+	if err := ParseDERString(value, &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// DecodeRawString decodes an extension value holding the raw string bytes,
+// as the deprecated Fulcio extensions do.
+func DecodeRawString(value []byte) (string, error) {
+	return string(value), nil
+}
+
+type registeredExtension struct {
+	name   string
+	decode ExtensionDecoder
+}
+
+// ExtensionRegistry maps the OIDs of custom certificate extensions, such as
+// those added by private Fulcio deployments, to names and decoders. The
+// decoded values appear in Summary.CustomExtensions under their names, and
+// can be matched by certificate identity policies.
+type ExtensionRegistry struct {
+	mu         sync.RWMutex
+	extensions map[string]registeredExtension
+	names      map[string]bool
+}
+
+// DefaultExtensionRegistry is used by SummarizeCertificate.
+var DefaultExtensionRegistry = NewExtensionRegistry()
+
+func NewExtensionRegistry() *ExtensionRegistry {
+	return &ExtensionRegistry{
+		extensions: make(map[string]registeredExtension),
+		names:      make(map[string]bool),
+	}
+}
+
+// Register adds a custom extension. Extensions defined by Fulcio, and OIDs
+// or names already registered, cannot be registered.
+func (r *ExtensionRegistry) Register(oid asn1.ObjectIdentifier, name string, decode ExtensionDecoder) error {
+	if len(oid) == 0 {
+		return errors.New("extension OID must not be empty")
+	}
+	if name == "" {
+		return errors.New("extension name must not be empty")
+	}
+	if decode == nil {
+		return errors.New("extension decoder must not be nil")
+	}
+	if isFulcioExtension(oid) {
+		return fmt.Errorf("extension %s is defined by Fulcio", oid)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.extensions[oid.String()]; ok {
+		return fmt.Errorf("extension %s is already registered", oid)
+	}
+	if r.names[name] {
+		return fmt.Errorf("extension name %q is already registered", name)
+	}
+	r.extensions[oid.String()] = registeredExtension{name: name, decode: decode}
+	r.names[name] = true
+	return nil
+}
+
+// RegisterExtension registers a custom extension in the
+// DefaultExtensionRegistry.
+func RegisterExtension(oid asn1.ObjectIdentifier, name string, decode ExtensionDecoder) error {
+	return DefaultExtensionRegistry.Register(oid, name, decode)
+}
+
+// Parse decodes the registered extensions among ext, keyed by name. Other
+// extensions are ignored. A nil registry parses no extensions.
+func (r *ExtensionRegistry) Parse(ext []pkix.Extension) (map[string]string, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.extensions) == 0 {
+		return nil, nil
+	}
+
+	var out map[string]string
+	for _, e := range ext {
+		registered, ok := r.extensions[e.Id.String()]
+		if !ok {
+			continue
+		}
+		value, err := registered.decode(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode extension %s (%s): %w", registered.name, e.Id, err)
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[registered.name] = value
+	}
+	return out, nil
+}
+
+func isFulcioExtension(oid asn1.ObjectIdentifier) bool {
+	for _, fulcioOID := range []asn1.ObjectIdentifier{
+		OIDIssuer, OIDGitHubWorkflowTrigger, OIDGitHubWorkflowSHA, OIDGitHubWorkflowName,
+		OIDGitHubWorkflowRepository, OIDGitHubWorkflowRef, OIDOtherName, OIDIssuerV2,
+		OIDBuildSignerURI, OIDBuildSignerDigest, OIDRunnerEnvironment, OIDSourceRepositoryURI,
+		OIDSourceRepositoryDigest, OIDSourceRepositoryRef, OIDSourceRepositoryIdentifier,
+		OIDSourceRepositoryOwnerURI, OIDSourceRepositoryOwnerIdentifier, OIDBuildConfigURI,
+		OIDBuildConfigDigest, OIDBuildTrigger, OIDRunInvocationURI, OIDSourceRepositoryVisibilityAtSigning,
+	} {
+		if oid.Equal(fulcioOID) {
+			return true
+		}
+	}
+	return false
+}
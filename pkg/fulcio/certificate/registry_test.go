@@ -0,0 +1,96 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	oidDepartment = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 1}
+	oidCostCenter = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 2}
+)
+
+func TestExtensionRegistry(t *testing.T) {
+	department, err := asn1.MarshalWithParams("release-engineering", "utf8")
+	assert.NoError(t, err)
+	issuer, err := asn1.MarshalWithParams("https://issuer.example.com", "utf8")
+	assert.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		NotBefore:      time.Now(),
+		NotAfter:       time.Now().Add(time.Hour),
+		EmailAddresses: []string{"jdoe@example.com"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: certificate.OIDIssuerV2, Value: issuer},
+			{Id: oidDepartment, Value: department},
+			{Id: oidCostCenter, Value: []byte("cc-42")},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	registry := certificate.NewExtensionRegistry()
+	assert.NoError(t, registry.Register(oidDepartment, "department", certificate.DecodeDERString))
+
+	summary, err := certificate.SummarizeCertificateWithRegistry(cert, registry)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://issuer.example.com", summary.Issuer)
+	assert.Equal(t, map[string]string{"department": "release-engineering"}, summary.CustomExtensions)
+
+	assert.NoError(t, registry.Register(oidCostCenter, "costCenter", certificate.DecodeRawString))
+	summary, err = certificate.SummarizeCertificateWithRegistry(cert, registry)
+	assert.NoError(t, err)
+	assert.Equal(t, "cc-42", summary.CustomExtensions["costCenter"])
+
+	// Without registration, custom extensions are ignored
+	summary, err = certificate.SummarizeCertificateWithRegistry(cert, certificate.NewExtensionRegistry())
+	assert.NoError(t, err)
+	assert.Nil(t, summary.CustomExtensions)
+
+	assert.ErrorContains(t, registry.Register(oidDepartment, "other", certificate.DecodeRawString), "already registered")
+	assert.ErrorContains(t, registry.Register(asn1.ObjectIdentifier{1, 2, 3}, "department", certificate.DecodeRawString), "already registered")
+	assert.ErrorContains(t, registry.Register(certificate.OIDIssuerV2, "issuer", certificate.DecodeDERString), "defined by Fulcio")
+
+	// A decoding failure is reported
+	failing := certificate.NewExtensionRegistry()
+	assert.NoError(t, failing.Register(oidCostCenter, "costCenter", certificate.DecodeDERString))
+	_, err = certificate.SummarizeCertificateWithRegistry(cert, failing)
+	assert.ErrorContains(t, err, "failed to decode extension costCenter")
+}
+
+func TestCompareCustomExtensions(t *testing.T) {
+	actual := map[string]string{"department": "release-engineering", "costCenter": "cc-42"}
+	assert.True(t, certificate.CompareCustomExtensions(nil, actual))
+	assert.True(t, certificate.CompareCustomExtensions(map[string]string{"department": "release-engineering"}, actual))
+	assert.False(t, certificate.CompareCustomExtensions(map[string]string{"department": "security"}, actual))
+	assert.False(t, certificate.CompareCustomExtensions(map[string]string{"team": "core"}, actual))
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+var oidSubjectAlternativeName = asn1.ObjectIdentifier{2, 5, 29, 17}
+
+// SubjectAlternativeNames returns every subject alternative name of the
+// certificate that Fulcio can issue or that private CAs commonly use: URIs,
+// email addresses, Fulcio OtherName SANs (OID 1.3.6.1.4.1.57264.1.7, used
+// for usernames) and DNS names, in that order.
+func SubjectAlternativeNames(cert *x509.Certificate) ([]SubjectAlternativeName, error) {
+	var sans []SubjectAlternativeName
+	for _, uri := range cert.URIs {
+		sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeURI, Value: uri.String()})
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeEmail, Value: email})
+	}
+	otherNames, err := parseOtherNames(cert)
+	if err != nil {
+		return nil, err
+	}
+	for _, otherName := range otherNames {
+		sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeOther, Value: otherName})
+	}
+	for _, dnsName := range cert.DNSNames {
+		sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeDNS, Value: dnsName})
+	}
+	return sans, nil
+}
+
+type otherName struct {
+	TypeID asn1.ObjectIdentifier
+	Value  asn1.RawValue `asn1:"tag:0,explicit"`
+}
+
+// parseOtherNames returns the UTF-8 string values of the certificate's
+// Fulcio OtherName SANs. OtherName SANs of other types are ignored.
+func parseOtherNames(cert *x509.Certificate) ([]string, error) {
+	var names []string
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAlternativeName) {
+			continue
+		}
+
+		var generalNames asn1.RawValue
+		if rest, err := asn1.Unmarshal(ext.Value, &generalNames); err != nil {
+			return nil, fmt.Errorf("invalid subject alternative name extension: %w", err)
+		} else if len(rest) > 0 {
+			return nil, errors.New("invalid subject alternative name extension: trailing data")
+		}
+
+		rest := generalNames.Bytes
+		for len(rest) > 0 {
+			var generalName asn1.RawValue
+			var err error
+			rest, err = asn1.Unmarshal(rest, &generalName)
+			if err != nil {
+				return nil, fmt.Errorf("invalid subject alternative name: %w", err)
+			}
+			// otherName is [0] IMPLICIT SEQUENCE { type-id, [0] EXPLICIT value }
+			if generalName.Class != asn1.ClassContextSpecific || generalName.Tag != 0 {
+				continue
+			}
+			var on otherName
+			if _, err := asn1.UnmarshalWithParams(generalName.FullBytes, &on, "tag:0"); err != nil {
+				return nil, fmt.Errorf("invalid OtherName subject alternative name: %w", err)
+			}
+			if !on.TypeID.Equal(OIDOtherName) {
+				continue
+			}
+			var value string
+			if _, err := asn1.UnmarshalWithParams(on.Value.Bytes, &value, "utf8"); err != nil {
+				return nil, fmt.Errorf("invalid OtherName subject alternative name value: %w", err)
+			}
+			names = append(names, value)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otherNameSANExtension builds a SAN extension holding a Fulcio OtherName
+// SAN and a DNS name, as x509.Certificate cannot express OtherName SANs.
+func otherNameSANExtension(t *testing.T, username, dnsName string) pkix.Extension {
+	value, err := asn1.MarshalWithParams(username, "utf8")
+	require.NoError(t, err)
+	otherName, err := asn1.Marshal(struct {
+		TypeID asn1.ObjectIdentifier
+		Value  asn1.RawValue
+	}{
+		TypeID: certificate.OIDOtherName,
+		Value:  asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: value},
+	})
+	require.NoError(t, err)
+	// Re-tag the SEQUENCE as the [0] IMPLICIT otherName GeneralName
+	otherName[0] = 0xa0
+
+	dns, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte(dnsName)})
+	require.NoError(t, err)
+
+	generalNames, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: append(otherName, dns...)})
+	require.NoError(t, err)
+	return pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: generalNames}
+}
+
+func TestSubjectAlternativeNamesOtherNameAndDNS(t *testing.T) {
+	ext := otherNameSANExtension(t, "foo!oidc.local", "build.example.com")
+	cert := &x509.Certificate{
+		Extensions: []pkix.Extension{ext},
+		DNSNames:   []string{"build.example.com"},
+	}
+
+	sans, err := certificate.SubjectAlternativeNames(cert)
+	require.NoError(t, err)
+	assert.Equal(t, []certificate.SubjectAlternativeName{
+		{Type: certificate.SubjectAlternativeNameTypeOther, Value: "foo!oidc.local"},
+		{Type: certificate.SubjectAlternativeNameTypeDNS, Value: "build.example.com"},
+	}, sans)
+
+	summary, err := certificate.SummarizeCertificate(cert)
+	require.NoError(t, err)
+	assert.Equal(t, sans[0], summary.SubjectAlternativeName)
+	assert.Equal(t, sans, summary.SubjectAlternativeNames)
+}
+
+func TestSubjectAlternativeNamesNone(t *testing.T) {
+	_, err := certificate.SummarizeCertificate(&x509.Certificate{})
+	assert.Error(t, err)
+}
+
+func TestAllSubjectAlternativeNames(t *testing.T) {
+	primary := certificate.SubjectAlternativeName{Type: certificate.SubjectAlternativeNameTypeEmail, Value: "foo@example.com"}
+	assert.Equal(t, []certificate.SubjectAlternativeName{primary}, certificate.Summary{SubjectAlternativeName: primary}.AllSubjectAlternativeNames())
+	assert.Empty(t, certificate.Summary{}.AllSubjectAlternativeNames())
+}
@@ -31,41 +31,75 @@ const (
 	SubjectAlternativeNameTypeEmail       SubjectAlternativeNameType = "Email"
 	SubjectAlternativeNameTypeURI         SubjectAlternativeNameType = "URI"
 	SubjectAlternativeNameTypeOther       SubjectAlternativeNameType = "Other"
+	SubjectAlternativeNameTypeDNS         SubjectAlternativeNameType = "DNS"
 )
 
 type SubjectAlternativeName struct {
-	Type  SubjectAlternativeNameType `json:"type,omitempty""`
+	Type  SubjectAlternativeNameType `json:"type,omitempty"`
 	Value string                     `json:"value,omitempty"`
 }
 
 type Summary struct {
-	CertificateIssuer      string                 `json:"certificateIssuer"`
+	CertificateIssuer string `json:"certificateIssuer"`
+	// SubjectAlternativeName is the certificate's primary SAN: the first of
+	// SubjectAlternativeNames
 	SubjectAlternativeName SubjectAlternativeName `json:"subjectAlternativeName"`
+	// SubjectAlternativeNames lists every SAN of the certificate, as
+	// returned by the SubjectAlternativeNames function
+	SubjectAlternativeNames []SubjectAlternativeName `json:"subjectAlternativeNames,omitempty"`
 	Extensions
+	// Values of custom extensions registered in an ExtensionRegistry, keyed
+	// by their registered names
+	CustomExtensions map[string]string `json:"customExtensions,omitempty"`
 }
 
+// SummarizeCertificate summarizes the certificate, including the custom
+// extensions registered in the DefaultExtensionRegistry.
 func SummarizeCertificate(cert *x509.Certificate) (Summary, error) {
+	return SummarizeCertificateWithRegistry(cert, DefaultExtensionRegistry)
+}
+
+// SummarizeCertificateWithRegistry summarizes the certificate, including
+// the custom extensions registered in registry.
+func SummarizeCertificateWithRegistry(cert *x509.Certificate, registry *ExtensionRegistry) (Summary, error) {
 	extensions, err := ParseExtensions(cert.Extensions)
 
 	if err != nil {
 		return Summary{}, err
 	}
 
-	san := SubjectAlternativeName{}
-
-	switch {
-	case len(cert.URIs) > 0:
-		san.Type = SubjectAlternativeNameTypeURI
-		san.Value = cert.URIs[0].String()
-	case len(cert.EmailAddresses) > 0:
-		san.Type = SubjectAlternativeNameTypeEmail
-		san.Value = cert.EmailAddresses[0]
-	default:
-		// TODO: Support OtherName SANs i.e. https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md#1361415726417--othername-san
+	customExtensions, err := registry.Parse(cert.Extensions)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	sans, err := SubjectAlternativeNames(cert)
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(sans) == 0 {
 		return Summary{}, errors.New("No Subject Alternative Name found")
 	}
 
-	return Summary{CertificateIssuer: cert.Issuer.String(), SubjectAlternativeName: san, Extensions: extensions}, nil
+	return Summary{
+		CertificateIssuer:       cert.Issuer.String(),
+		SubjectAlternativeName:  sans[0],
+		SubjectAlternativeNames: sans,
+		Extensions:              extensions,
+		CustomExtensions:        customExtensions,
+	}, nil
+}
+
+// AllSubjectAlternativeNames returns SubjectAlternativeNames, or only the
+// primary SAN for summaries that do not list every SAN.
+func (s Summary) AllSubjectAlternativeNames() []SubjectAlternativeName {
+	if len(s.SubjectAlternativeNames) > 0 {
+		return s.SubjectAlternativeNames
+	}
+	if s.SubjectAlternativeName == (SubjectAlternativeName{}) {
+		return nil
+	}
+	return []SubjectAlternativeName{s.SubjectAlternativeName}
 }
 
 // CompareExtensions compares two Extensions structs and returns true if their
@@ -92,3 +126,17 @@ func CompareExtensions(expectedExt, actualExt Extensions) bool {
 
 	return true
 }
+
+// CompareCustomExtensions returns true if every expected custom extension
+// value equals the actual value. Empty expected values are ignored.
+func CompareCustomExtensions(expected, actual map[string]string) bool {
+	for name, expectedValue := range expected {
+		if expectedValue == "" {
+			continue
+		}
+		if actualValue, ok := actual[name]; !ok || actualValue != expectedValue {
+			return false
+		}
+	}
+	return true
+}
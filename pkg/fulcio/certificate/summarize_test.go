@@ -44,6 +44,9 @@ func TestSummarizeCertificateWithActionsBundle(t *testing.T) {
 	expected := certificate.Summary{
 		CertificateIssuer:      "CN=sigstore-intermediate,O=sigstore.dev",
 		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "URI", Value: "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"},
+		SubjectAlternativeNames: []certificate.SubjectAlternativeName{
+			{Type: "URI", Value: "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"},
+		},
 		Extensions: certificate.Extensions{
 			Issuer:                              "https://token.actions.githubusercontent.com",
 			GithubWorkflowTrigger:               "push",
@@ -93,6 +96,9 @@ func TestSummarizeCertificateWithOauthBundle(t *testing.T) {
 	expected := certificate.Summary{
 		CertificateIssuer:      "CN=sigstore-intermediate,O=sigstore.dev",
 		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "Email", Value: "brian@dehamer.com"},
+		SubjectAlternativeNames: []certificate.SubjectAlternativeName{
+			{Type: "Email", Value: "brian@dehamer.com"},
+		},
 		Extensions: certificate.Extensions{
 			Issuer: "https://github.com/login/oauth",
 		},
@@ -0,0 +1,161 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package git extracts the signed payload from git commit and tag objects.
+//
+// Git signs commits and tags by passing the object, minus any existing
+// signature, to the signing program, so that is the payload returned here.
+package git
+
+import (
+	"bytes"
+	"errors"
+)
+
+type ObjectType string
+
+const (
+	ObjectTypeCommit ObjectType = "commit"
+	ObjectTypeTag    ObjectType = "tag"
+)
+
+// signatureHeaders are the commit headers that carry a signature. Git uses
+// gpgsig for SHA-1 repositories and gpgsig-sha256 for SHA-256 repositories,
+// regardless of the signature format.
+var signatureHeaders = [][]byte{[]byte("gpgsig"), []byte("gpgsig-sha256")}
+
+// signatureArmors are the armor headers of signatures appended to tag
+// messages: OpenPGP, SSH, and X.509 (as used by gitsign and smimesign).
+var signatureArmors = [][]byte{
+	[]byte("-----BEGIN PGP SIGNATURE-----"),
+	[]byte("-----BEGIN SSH SIGNATURE-----"),
+	[]byte("-----BEGIN SIGNED MESSAGE-----"),
+}
+
+var ErrUnknownObjectType = errors.New("git object is neither a commit nor a tag")
+
+// ParseObjectType returns the type of a raw git commit or tag object, as
+// output by `git cat-file commit|tag`.
+func ParseObjectType(object []byte) (ObjectType, error) {
+	switch {
+	case bytes.HasPrefix(object, []byte("tree ")):
+		return ObjectTypeCommit, nil
+	case bytes.HasPrefix(object, []byte("object ")):
+		return ObjectTypeTag, nil
+	default:
+		return "", ErrUnknownObjectType
+	}
+}
+
+// SignedPayload returns the bytes of a raw git commit or tag object that are
+// covered by its signature. For commits, the signature header is removed;
+// for tags, the signature appended to the tag message is removed. Objects
+// without a signature are returned unchanged.
+func SignedPayload(object []byte) ([]byte, error) {
+	objectType, err := ParseObjectType(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectType == ObjectTypeTag {
+		return stripTagSignature(object), nil
+	}
+	return stripCommitSignature(object), nil
+}
+
+// Signature returns the armored signature embedded in a raw git commit or tag
+// object, or nil if the object is not signed.
+func Signature(object []byte) ([]byte, error) {
+	objectType, err := ParseObjectType(object)
+	if err != nil {
+		return nil, err
+	}
+
+	if objectType == ObjectTypeTag {
+		payload := stripTagSignature(object)
+		if len(payload) == len(object) {
+			return nil, nil
+		}
+		return object[len(payload):], nil
+	}
+
+	var sig []byte
+	inSignature := false
+	for _, line := range headerLines(object) {
+		switch {
+		case isSignatureHeader(line):
+			inSignature = true
+			sig = append(sig, line[bytes.IndexByte(line, ' ')+1:]...)
+		case inSignature && bytes.HasPrefix(line, []byte(" ")):
+			sig = append(sig, line[1:]...)
+		default:
+			inSignature = false
+		}
+	}
+	return sig, nil
+}
+
+func stripCommitSignature(object []byte) []byte {
+	headerLen := len(object)
+	if i := bytes.Index(object, []byte("\n\n")); i >= 0 {
+		headerLen = i + 1
+	}
+
+	payload := make([]byte, 0, len(object))
+	inSignature := false
+	for _, line := range headerLines(object) {
+		switch {
+		case isSignatureHeader(line):
+			inSignature = true
+		case inSignature && bytes.HasPrefix(line, []byte(" ")):
+			// continuation of the signature header
+		default:
+			inSignature = false
+			payload = append(payload, line...)
+		}
+	}
+	return append(payload, object[headerLen:]...)
+}
+
+// headerLines returns the header lines of a commit object, each including
+// its trailing newline.
+func headerLines(object []byte) [][]byte {
+	header := object
+	if i := bytes.Index(object, []byte("\n\n")); i >= 0 {
+		header = object[:i+1]
+	}
+	return bytes.SplitAfter(header, []byte("\n"))
+}
+
+func isSignatureHeader(line []byte) bool {
+	for _, h := range signatureHeaders {
+		if bytes.HasPrefix(line, h) && len(line) > len(h) && line[len(h)] == ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+func stripTagSignature(object []byte) []byte {
+	start := -1
+	for _, armor := range signatureArmors {
+		if i := bytes.LastIndex(object, append([]byte("\n"), armor...)); i > start {
+			start = i
+		}
+	}
+	if start < 0 {
+		return object
+	}
+	return object[:start+1]
+}
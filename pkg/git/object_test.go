@@ -0,0 +1,89 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const unsignedCommit = `tree 5d0a5ff8a4b6b8ad6a3ff51fc7d8b4aec2fb4e1b
+parent 2a1c8a1c0d9b1ce3b7ab0f4c0b6d1e3f1cbd2f0a
+author Jane Doe <jane@example.com> 1700000000 +0000
+committer Jane Doe <jane@example.com> 1700000000 +0000
+
+Add feature
+
+With a longer description.
+`
+
+const signedCommit = `tree 5d0a5ff8a4b6b8ad6a3ff51fc7d8b4aec2fb4e1b
+parent 2a1c8a1c0d9b1ce3b7ab0f4c0b6d1e3f1cbd2f0a
+author Jane Doe <jane@example.com> 1700000000 +0000
+committer Jane Doe <jane@example.com> 1700000000 +0000
+gpgsig -----BEGIN SIGNED MESSAGE-----
+ MIIEAwYJKoZIhvcNAQcCoIID9DCCA/ACAQExDTALBglghkgBZQMEAgEwCwYJKoZI
+ -----END SIGNED MESSAGE-----
+
+Add feature
+
+With a longer description.
+`
+
+const unsignedTag = `object 2a1c8a1c0d9b1ce3b7ab0f4c0b6d1e3f1cbd2f0a
+type commit
+tag v1.0.0
+tagger Jane Doe <jane@example.com> 1700000000 +0000
+
+Release v1.0.0
+`
+
+const signedTag = unsignedTag + `-----BEGIN SIGNED MESSAGE-----
+MIIEAwYJKoZIhvcNAQcCoIID9DCCA/ACAQExDTALBglghkgBZQMEAgEwCwYJKoZI
+-----END SIGNED MESSAGE-----
+`
+
+func TestSignedPayload(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		object     string
+		objectType ObjectType
+		payload    string
+		signature  string
+	}{
+		{"unsigned commit", unsignedCommit, ObjectTypeCommit, unsignedCommit, ""},
+		{"signed commit", signedCommit, ObjectTypeCommit, unsignedCommit, "-----BEGIN SIGNED MESSAGE-----\nMIIEAwYJKoZIhvcNAQcCoIID9DCCA/ACAQExDTALBglghkgBZQMEAgEwCwYJKoZI\n-----END SIGNED MESSAGE-----\n"},
+		{"unsigned tag", unsignedTag, ObjectTypeTag, unsignedTag, ""},
+		{"signed tag", signedTag, ObjectTypeTag, unsignedTag, signedTag[len(unsignedTag):]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			objectType, err := ParseObjectType([]byte(tc.object))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.objectType, objectType)
+
+			payload, err := SignedPayload([]byte(tc.object))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.payload, string(payload))
+
+			sig, err := Signature([]byte(tc.object))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.signature, string(sig))
+		})
+	}
+
+	_, err := SignedPayload([]byte("blob contents"))
+	assert.ErrorIs(t, err, ErrUnknownObjectType)
+}
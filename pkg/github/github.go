@@ -0,0 +1,156 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package github verifies GitHub artifact attestations. Attestations from
+// public repositories are signed with the public good Sigstore instance,
+// while those from private repositories are signed with GitHub's own
+// instance, which has no transparency log: its bundles only have signed
+// timestamps from GitHub's timestamp authority. This package trusts both
+// instances, and requires the evidence each of them provides.
+package github
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const (
+	// TUFMirror is the TUF repository of GitHub's Sigstore instance
+	TUFMirror = "https://tuf-repo.github.com"
+	// ActionsIssuer is the OIDC issuer of GitHub Actions
+	ActionsIssuer = "https://token.actions.githubusercontent.com"
+)
+
+// Options configure the trusted material of GitHub artifact attestations.
+type Options struct {
+	// TUFRoot is the root.json of GitHub's TUF repository, which
+	// bootstraps trust in it. It is not embedded in this library, and must
+	// be obtained from a trusted source, such as the GitHub CLI. Required
+	// unless PublicGoodOnly is set.
+	TUFRoot []byte
+	// Optional base options for fetching from the TUF repositories, e.g.
+	// for a cache path. The repository URL and root are set for each
+	// repository. Defaults to tuf.DefaultOptions.
+	TUFOptions *tuf.Options
+	// Optional: only trust the public good instance, so that only
+	// attestations from public repositories verify
+	PublicGoodOnly bool
+}
+
+// TrustedMaterial fetches the trusted roots of GitHub's instance and of the
+// public good instance from their TUF repositories.
+func TrustedMaterial(opts *Options) (root.TrustedMaterial, error) {
+	githubOpts, publicGoodOpts, err := tufOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	publicGood, err := root.FetchTrustedRootWithOptions(publicGoodOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the public good trusted root: %w", err)
+	}
+	if githubOpts == nil {
+		return publicGood, nil
+	}
+	github, err := root.FetchTrustedRootWithOptions(githubOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch GitHub's trusted root: %w", err)
+	}
+	return root.TrustedMaterialCollection{publicGood, github}, nil
+}
+
+// tufOptions returns the options for GitHub's TUF repository, or nil if it
+// is not trusted, and for the public good one.
+func tufOptions(opts *Options) (*tuf.Options, *tuf.Options, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if !opts.PublicGoodOnly && len(opts.TUFRoot) == 0 {
+		return nil, nil, errors.New("the root.json of GitHub's TUF repository is required to trust GitHub's instance")
+	}
+	base := opts.TUFOptions
+	if base == nil {
+		base = tuf.DefaultOptions()
+	}
+
+	publicGood := *base
+	publicGood.RepositoryBaseURL = tuf.DefaultMirror
+	publicGood.Root = tuf.DefaultRoot()
+	if opts.PublicGoodOnly {
+		return nil, &publicGood, nil
+	}
+	github := *base
+	github.RepositoryBaseURL = TUFMirror
+	github.Root = opts.TUFRoot
+	return &github, &publicGood, nil
+}
+
+// NewVerifier returns a verifier for GitHub artifact attestations, which
+// requires an SCT, a transparency log entry and its integrated time for
+// bundles from the public good instance, and a signed timestamp for
+// bundles from GitHub's instance, as the
+// verify.ProfileGitHubArtifactAttestations profile does. options may add
+// e.g. verify.WithLogger.
+func NewVerifier(trustedMaterial root.TrustedMaterial, options ...verify.VerifierOption) (*verify.SignedEntityVerifier, error) {
+	profile, err := verify.GetProfile(verify.ProfileGitHubArtifactAttestations)
+	if err != nil {
+		return nil, err
+	}
+	return profile.NewVerifier(trustedMaterial, options...)
+}
+
+// RepositoryIdentity returns the identity of attestations made by GitHub
+// Actions workflows run in a repository, given as "owner/name". The
+// workflow that signed, which is the certificate's SAN, must be in the
+// same repository; use OwnerIdentity or verify.NewCertificateIdentity for
+// reusable workflows from other repositories.
+func RepositoryIdentity(repository string) (verify.CertificateIdentity, error) {
+	owner, name, ok := strings.Cut(repository, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return verify.CertificateIdentity{}, fmt.Errorf("invalid repository %q: must be owner/name", repository)
+	}
+	repositoryURI := "https://github.com/" + repository
+	sanMatcher, err := verify.NewSANMatcher("", "", "(?i)^"+regexp.QuoteMeta(repositoryURI)+"/")
+	if err != nil {
+		return verify.CertificateIdentity{}, err
+	}
+	return verify.NewCertificateIdentity(sanMatcher, certificate.Extensions{
+		Issuer:              ActionsIssuer,
+		SourceRepositoryURI: repositoryURI,
+	})
+}
+
+// OwnerIdentity returns the identity of attestations made by GitHub
+// Actions workflows run in any repository of an organization or user,
+// with workflows from the same owner.
+func OwnerIdentity(owner string) (verify.CertificateIdentity, error) {
+	if owner == "" || strings.Contains(owner, "/") {
+		return verify.CertificateIdentity{}, fmt.Errorf("invalid owner %q", owner)
+	}
+	ownerURI := "https://github.com/" + owner
+	sanMatcher, err := verify.NewSANMatcher("", "", "(?i)^"+regexp.QuoteMeta(ownerURI)+"/")
+	if err != nil {
+		return verify.CertificateIdentity{}, err
+	}
+	return verify.NewCertificateIdentity(sanMatcher, certificate.Extensions{
+		Issuer:                   ActionsIssuer,
+		SourceRepositoryOwnerURI: ownerURI,
+	})
+}
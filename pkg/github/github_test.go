@@ -0,0 +1,107 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestTUFOptions(t *testing.T) {
+	_, _, err := tufOptions(nil)
+	assert.Error(t, err)
+
+	base := tuf.DefaultOptions().WithCachePath("/tmp/tuf")
+	github, publicGood, err := tufOptions(&Options{TUFRoot: []byte("{}"), TUFOptions: base})
+	require.NoError(t, err)
+	assert.Equal(t, TUFMirror, github.RepositoryBaseURL)
+	assert.Equal(t, []byte("{}"), github.Root)
+	assert.Equal(t, "/tmp/tuf", github.CachePath)
+	assert.Equal(t, tuf.DefaultMirror, publicGood.RepositoryBaseURL)
+	assert.Equal(t, tuf.DefaultRoot(), publicGood.Root)
+	assert.Equal(t, "/tmp/tuf", publicGood.CachePath)
+	// The base options are not modified
+	assert.Equal(t, tuf.DefaultMirror, base.RepositoryBaseURL)
+
+	github, publicGood, err = tufOptions(&Options{PublicGoodOnly: true})
+	require.NoError(t, err)
+	assert.Nil(t, github)
+	assert.Equal(t, tuf.DefaultMirror, publicGood.RepositoryBaseURL)
+}
+
+func TestIdentities(t *testing.T) {
+	summary := func(san, repository string) certificate.Summary {
+		return certificate.Summary{
+			SubjectAlternativeName: certificate.SubjectAlternativeName{Type: certificate.SubjectAlternativeNameTypeURI, Value: san},
+			Extensions: certificate.Extensions{
+				Issuer:                   ActionsIssuer,
+				SourceRepositoryURI:      "https://github.com/" + repository,
+				SourceRepositoryOwnerURI: "https://github.com/" + strings.Split(repository, "/")[0],
+			},
+		}
+	}
+	workflow := "https://github.com/example/project/.github/workflows/release.yml@refs/heads/main"
+
+	repository, err := RepositoryIdentity("example/project")
+	require.NoError(t, err)
+	assert.True(t, repository.Verify(summary(workflow, "example/project")))
+	// Another repository with the same prefix
+	assert.False(t, repository.Verify(summary("https://github.com/example/project-fork/.github/workflows/release.yml@refs/heads/main", "example/project-fork")))
+	// A reusable workflow from the repository, run by another one
+	assert.False(t, repository.Verify(summary(workflow, "other/project")))
+
+	owner, err := OwnerIdentity("example")
+	require.NoError(t, err)
+	assert.True(t, owner.Verify(summary(workflow, "example/project")))
+	assert.False(t, owner.Verify(summary("https://github.com/example-fork/project/.github/workflows/release.yml@refs/heads/main", "example-fork/project")))
+
+	for _, invalid := range []string{"", "example", "example/", "/project", "example/project/sub"} {
+		_, err := RepositoryIdentity(invalid)
+		assert.Error(t, err, invalid)
+	}
+	_, err = OwnerIdentity("example/project")
+	assert.Error(t, err)
+}
+
+func TestNewVerifier(t *testing.T) {
+	// Bundles without SCTs, as from GitHub's instance, verify with a signed
+	// timestamp
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	workflow := "https://github.com/example/project/.github/workflows/release.yml@refs/heads/main"
+	entity, err := virtualSigstore.Sign(workflow, ActionsIssuer, []byte("artifact"))
+	require.NoError(t, err)
+
+	verifier, err := NewVerifier(virtualSigstore)
+	require.NoError(t, err)
+	identity, err := verify.NewShortCertificateIdentity(ActionsIssuer, workflow, "", "")
+	require.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithCertificateIdentity(identity)))
+	assert.NoError(t, err)
+
+	// The virtual certificate has no source repository extension
+	repository, err := RepositoryIdentity("example/project")
+	require.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithCertificateIdentity(repository)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics defines the counters, gauges and histograms that
+// sigstore-go records, and a Registry that exposes them in the Prometheus
+// text format.
+//
+// Applications that already use a metrics library can implement Provider
+// on top of it instead; for the Prometheus client library, each method maps
+// onto a CounterVec, GaugeVec or HistogramVec with the same label names.
+package metrics
+
+// Counter is a value that only increases, partitioned by label values.
+type Counter interface {
+	// Add increases the counter for the label values, which are given in
+	// the order of the label names the counter was created with.
+	Add(value float64, labelValues ...string)
+}
+
+// Gauge is a value that can go up and down, partitioned by label values.
+type Gauge interface {
+	// Set sets the gauge for the label values.
+	Set(value float64, labelValues ...string)
+}
+
+// Histogram counts observations in buckets, partitioned by label values.
+type Histogram interface {
+	// Observe records a value for the label values.
+	Observe(value float64, labelValues ...string)
+}
+
+// Provider creates metrics. Creating a metric with the name of an existing
+// one returns the existing metric.
+type Provider interface {
+	Counter(name, help string, labelNames ...string) Counter
+	Gauge(name, help string, labelNames ...string) Gauge
+	// Histogram creates a histogram with the given upper bounds of its
+	// buckets, in increasing order.
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// DurationBuckets are histogram buckets, in seconds, suited to the
+// duration of verification steps.
+var DurationBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
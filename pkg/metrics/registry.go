@@ -0,0 +1,236 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry is a Provider that keeps metrics in memory and writes them in the
+// Prometheus text exposition format. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+var _ Provider = &Registry{}
+var _ http.Handler = &Registry{}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+type metricType string
+
+const (
+	counterType   metricType = "counter"
+	gaugeType     metricType = "gauge"
+	histogramType metricType = "histogram"
+)
+
+type family struct {
+	registry   *Registry
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	// value of a counter or gauge, or the sum of a histogram's observations
+	value        float64
+	bucketCounts []uint64
+	count        uint64
+}
+
+// Counter returns the counter with the name, creating it if needed. It
+// panics if a metric of another type or with other label names was already
+// created with the name.
+func (r *Registry) Counter(name, help string, labelNames ...string) Counter {
+	return r.family(name, help, counterType, nil, labelNames)
+}
+
+// Gauge returns the gauge with the name, creating it if needed.
+func (r *Registry) Gauge(name, help string, labelNames ...string) Gauge {
+	return r.family(name, help, gaugeType, nil, labelNames)
+}
+
+// Histogram returns the histogram with the name, creating it if needed.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) Histogram {
+	return r.family(name, help, histogramType, buckets, labelNames)
+}
+
+func (r *Registry) family(name, help string, typ metricType, buckets []float64, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || strings.Join(f.labelNames, ",") != strings.Join(labelNames, ",") {
+			panic(fmt.Sprintf("metric %s already registered as a %s with labels %v", name, f.typ, f.labelNames))
+		}
+		return f
+	}
+	f := &family{
+		registry:   r,
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.families[name] = f
+	return f
+}
+
+// get returns the series for the label values, creating it if needed. The
+// registry must be locked.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s has labels %v, but %d label values were given", f.name, f.labelNames, len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.typ == histogramType {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) Add(value float64, labelValues ...string) {
+	f.registry.mu.Lock()
+	defer f.registry.mu.Unlock()
+	f.get(labelValues).value += value
+}
+
+func (f *family) Set(value float64, labelValues ...string) {
+	f.registry.mu.Lock()
+	defer f.registry.mu.Unlock()
+	f.get(labelValues).value = value
+}
+
+func (f *family) Observe(value float64, labelValues ...string) {
+	f.registry.mu.Lock()
+	defer f.registry.mu.Unlock()
+	s := f.get(labelValues)
+	for i, bound := range f.buckets {
+		if value <= bound {
+			s.bucketCounts[i]++
+		}
+	}
+	s.value += value
+	s.count++
+}
+
+// WriteText writes every metric in the Prometheus text exposition format,
+// sorted by name and label values.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := f.series[key]
+			if f.typ != histogramType {
+				fmt.Fprintf(bw, "%s%s %s\n", f.name, labels(f.labelNames, s.labelValues, "", ""), formatFloat(s.value))
+				continue
+			}
+			for i, bound := range f.buckets {
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", f.name, labels(f.labelNames, s.labelValues, "le", formatFloat(bound)), s.bucketCounts[i])
+			}
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", f.name, labels(f.labelNames, s.labelValues, "le", "+Inf"), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", f.name, labels(f.labelNames, s.labelValues, "", ""), formatFloat(s.value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", f.name, labels(f.labelNames, s.labelValues, "", ""), s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP writes the metrics in response to a Prometheus scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = r.WriteText(w)
+}
+
+// labels formats label names and values, with an extra label if extraName
+// is set.
+func labels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabelValue(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+	counter := r.Counter("requests_total", "Requests by outcome.", "outcome")
+	counter.Add(1, "ok")
+	counter.Add(2, "ok")
+	counter.Add(1, `bad "quoted"`+"\n")
+	r.Gauge("healthy", "Whether\nhealthy.").Set(1)
+	histogram := r.Histogram("duration_seconds", "Durations.", []float64{0.1, 1}, "phase")
+	histogram.Observe(0.05, "a")
+	histogram.Observe(0.5, "a")
+	histogram.Observe(5, "a")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+	assert.Equal(t, `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{phase="a",le="0.1"} 1
+duration_seconds_bucket{phase="a",le="1"} 2
+duration_seconds_bucket{phase="a",le="+Inf"} 3
+duration_seconds_sum{phase="a"} 5.55
+duration_seconds_count{phase="a"} 3
+# HELP healthy Whether\nhealthy.
+# TYPE healthy gauge
+healthy 1
+# HELP requests_total Requests by outcome.
+# TYPE requests_total counter
+requests_total{outcome="bad \"quoted\"\n"} 1
+requests_total{outcome="ok"} 3
+`, buf.String())
+}
+
+func TestRegistryReturnsExistingMetric(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("total", "Total.", "a").Add(1, "x")
+	r.Counter("total", "Total.", "a").Add(1, "x")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+	assert.Contains(t, buf.String(), `total{a="x"} 2`)
+
+	assert.Panics(t, func() { r.Gauge("total", "Total.", "a") })
+	assert.Panics(t, func() { r.Counter("total", "Total.", "b") })
+	assert.Panics(t, func() { r.Counter("total", "Total.", "a").Add(1) })
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("up", "Up.").Set(1)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "up 1\n")
+}
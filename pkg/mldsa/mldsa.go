@@ -0,0 +1,212 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mldsa provides experimental support for the ML-DSA (FIPS 204)
+// post-quantum signature algorithm, for pilots in private deployments.
+//
+// ML-DSA is disabled until Enable is called with a Backend that implements
+// it, such as StandardLibrary when built with Go 1.27 or later. Public
+// Sigstore instances do not yet issue certificates for or log ML-DSA keys,
+// and the encoding of ML-DSA keys in trusted roots may change.
+package mldsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// ParameterSet is an ML-DSA parameter set.
+type ParameterSet string
+
+const (
+	MLDSA65 ParameterSet = "ML-DSA-65"
+	MLDSA87 ParameterSet = "ML-DSA-87"
+)
+
+var (
+	oidMLDSA65 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 18}
+	oidMLDSA87 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 3, 19}
+)
+
+// ErrNotEnabled is returned when ML-DSA is used before Enable is called.
+var ErrNotEnabled = errors.New("ML-DSA support is experimental and not enabled")
+
+// Backend implements ML-DSA key generation and verification of signatures
+// in the pure (non-prehashed) mode with an empty context.
+type Backend interface {
+	GenerateKey(params ParameterSet) (PrivateKey, error)
+	Verify(publicKey *PublicKey, message, sig []byte) error
+}
+
+// PrivateKey is an ML-DSA private key held by a Backend.
+type PrivateKey interface {
+	Public() *PublicKey
+	Sign(message []byte) ([]byte, error)
+}
+
+// PublicKey is an encoded ML-DSA public key.
+type PublicKey struct {
+	Parameters ParameterSet
+	Bytes      []byte
+}
+
+// Equal reports whether x is the same public key.
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	return ok && pk.Parameters == other.Parameters && bytes.Equal(pk.Bytes, other.Bytes)
+}
+
+var (
+	mu      sync.RWMutex
+	backend Backend
+)
+
+// Enable turns on ML-DSA support in this package and in the sign and verify
+// packages, using b to implement it.
+func Enable(b Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	backend = b
+}
+
+// Disable turns off ML-DSA support.
+func Disable() {
+	Enable(nil)
+}
+
+// Enabled returns the backend passed to Enable, or ErrNotEnabled.
+func Enabled() (Backend, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if backend == nil {
+		return nil, ErrNotEnabled
+	}
+	return backend, nil
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// MarshalPKIXPublicKey returns the DER-encoded SubjectPublicKeyInfo of pk.
+func MarshalPKIXPublicKey(pk *PublicKey) ([]byte, error) {
+	var oid asn1.ObjectIdentifier
+	switch pk.Parameters {
+	case MLDSA65:
+		oid = oidMLDSA65
+	case MLDSA87:
+		oid = oidMLDSA87
+	default:
+		return nil, fmt.Errorf("unsupported ML-DSA parameter set %q", pk.Parameters)
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oid},
+		PublicKey: asn1.BitString{Bytes: pk.Bytes, BitLength: 8 * len(pk.Bytes)},
+	})
+}
+
+// ParsePKIXPublicKey parses a DER-encoded SubjectPublicKeyInfo holding an
+// ML-DSA public key.
+func ParsePKIXPublicKey(der []byte) (*PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after ML-DSA public key")
+	}
+	var params ParameterSet
+	switch {
+	case spki.Algorithm.Algorithm.Equal(oidMLDSA65):
+		params = MLDSA65
+	case spki.Algorithm.Algorithm.Equal(oidMLDSA87):
+		params = MLDSA87
+	default:
+		return nil, fmt.Errorf("not an ML-DSA public key: algorithm %s", spki.Algorithm.Algorithm)
+	}
+	return &PublicKey{Parameters: params, Bytes: spki.PublicKey.RightAlign()}, nil
+}
+
+// Verifier verifies ML-DSA signatures. It implements signature.Verifier so
+// that it can be used as trusted material and with signing certificates.
+type Verifier struct {
+	publicKey *PublicKey
+	backend   Backend
+}
+
+var _ signature.Verifier = (*Verifier)(nil)
+
+// NewVerifier returns a verifier for pk, or ErrNotEnabled.
+func NewVerifier(pk *PublicKey) (*Verifier, error) {
+	b, err := Enabled()
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{publicKey: pk, backend: b}, nil
+}
+
+// LoadVerifier returns a verifier for an ML-DSA public key, which may be a
+// *PublicKey or, when built with Go 1.27 or later, a *crypto/mldsa.PublicKey
+// parsed from a certificate. It returns false if pub is not an ML-DSA key.
+func LoadVerifier(pub crypto.PublicKey) (*Verifier, bool, error) {
+	pk, ok := pub.(*PublicKey)
+	if !ok {
+		pk, ok = fromStandardLibrary(pub)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	verifier, err := NewVerifier(pk)
+	return verifier, true, err
+}
+
+// PublicKey returns the verifier's public key.
+func (v *Verifier) PublicKey(_ ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return v.publicKey, nil
+}
+
+// VerifySignature verifies sig over the message. ML-DSA signs messages
+// rather than digests, so a digest passed with options.WithDigest cannot be
+// verified.
+func (v *Verifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	for _, opt := range opts {
+		var digest []byte
+		opt.ApplyDigest(&digest)
+		if digest != nil {
+			return errors.New("ML-DSA signatures can only be verified with the message, and not just its digest")
+		}
+	}
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return err
+	}
+	if message == nil {
+		return errors.New("message is required to verify an ML-DSA signature")
+	}
+	messageBytes, err := io.ReadAll(message)
+	if err != nil {
+		return err
+	}
+	return v.backend.Verify(v.publicKey, messageBytes, sigBytes)
+}
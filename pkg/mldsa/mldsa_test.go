@@ -0,0 +1,102 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mldsa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enableStandardLibrary(t *testing.T) {
+	backend, ok := StandardLibrary()
+	if !ok {
+		t.Skip("crypto/mldsa requires Go 1.27")
+	}
+	Enable(backend)
+	t.Cleanup(Disable)
+}
+
+func TestNotEnabled(t *testing.T) {
+	_, err := Enabled()
+	assert.ErrorIs(t, err, ErrNotEnabled)
+	_, err = NewVerifier(&PublicKey{Parameters: MLDSA65})
+	assert.ErrorIs(t, err, ErrNotEnabled)
+	_, ok, err := LoadVerifier(&PublicKey{Parameters: MLDSA65})
+	assert.True(t, ok)
+	assert.ErrorIs(t, err, ErrNotEnabled)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	enableStandardLibrary(t)
+	backend, err := Enabled()
+	require.NoError(t, err)
+
+	for _, params := range []ParameterSet{MLDSA65, MLDSA87} {
+		t.Run(string(params), func(t *testing.T) {
+			key, err := backend.GenerateKey(params)
+			require.NoError(t, err)
+			message := []byte("message")
+			sig, err := key.Sign(message)
+			require.NoError(t, err)
+
+			der, err := MarshalPKIXPublicKey(key.Public())
+			require.NoError(t, err)
+			parsed, err := ParsePKIXPublicKey(der)
+			require.NoError(t, err)
+			assert.True(t, parsed.Equal(key.Public()))
+
+			verifier, err := NewVerifier(parsed)
+			require.NoError(t, err)
+			assert.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+			assert.Error(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("other"))))
+
+			digest := sha256.Sum256(message)
+			err = verifier.VerifySignature(bytes.NewReader(sig), nil, options.WithDigest(digest[:]))
+			assert.ErrorContains(t, err, "not just its digest")
+
+			// the standard library parses the same encoding
+			stdPub, err := x509.ParsePKIXPublicKey(der)
+			require.NoError(t, err)
+			stdVerifier, ok, err := LoadVerifier(stdPub)
+			require.True(t, ok)
+			require.NoError(t, err)
+			assert.NoError(t, stdVerifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)))
+		})
+	}
+}
+
+func TestParsePKIXPublicKey(t *testing.T) {
+	_, err := MarshalPKIXPublicKey(&PublicKey{Parameters: "ML-DSA-44"})
+	assert.Error(t, err)
+
+	der, err := MarshalPKIXPublicKey(&PublicKey{Parameters: MLDSA87, Bytes: []byte{1, 2, 3}})
+	require.NoError(t, err)
+	pk, err := ParsePKIXPublicKey(der)
+	require.NoError(t, err)
+	assert.Equal(t, &PublicKey{Parameters: MLDSA87, Bytes: []byte{1, 2, 3}}, pk)
+
+	_, err = ParsePKIXPublicKey(append(der, 0))
+	assert.Error(t, err)
+
+	_, ok, err := LoadVerifier("not a key")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
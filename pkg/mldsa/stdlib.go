@@ -0,0 +1,96 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.27
+
+package mldsa
+
+import (
+	"crypto"
+	"crypto/mldsa"
+	"crypto/rand"
+	"fmt"
+)
+
+// StandardLibrary returns a Backend using crypto/mldsa, and true. It
+// returns false when built with a Go release before 1.27, which does not
+// include crypto/mldsa.
+func StandardLibrary() (Backend, bool) {
+	return stdlibBackend{}, true
+}
+
+type stdlibBackend struct{}
+
+func stdlibParameters(params ParameterSet) (mldsa.Parameters, error) {
+	switch params {
+	case MLDSA65:
+		return mldsa.MLDSA65(), nil
+	case MLDSA87:
+		return mldsa.MLDSA87(), nil
+	default:
+		return mldsa.Parameters{}, fmt.Errorf("unsupported ML-DSA parameter set %q", params)
+	}
+}
+
+func (stdlibBackend) GenerateKey(params ParameterSet) (PrivateKey, error) {
+	p, err := stdlibParameters(params)
+	if err != nil {
+		return nil, err
+	}
+	key, err := mldsa.GenerateKey(p)
+	if err != nil {
+		return nil, err
+	}
+	return &stdlibPrivateKey{key: key, params: params}, nil
+}
+
+func (stdlibBackend) Verify(publicKey *PublicKey, message, sig []byte) error {
+	p, err := stdlibParameters(publicKey.Parameters)
+	if err != nil {
+		return err
+	}
+	pub, err := mldsa.NewPublicKey(p, publicKey.Bytes)
+	if err != nil {
+		return err
+	}
+	return mldsa.Verify(pub, message, sig, nil)
+}
+
+type stdlibPrivateKey struct {
+	key    *mldsa.PrivateKey
+	params ParameterSet
+}
+
+func (k *stdlibPrivateKey) Public() *PublicKey {
+	return &PublicKey{Parameters: k.params, Bytes: k.key.PublicKey().Bytes()}
+}
+
+func (k *stdlibPrivateKey) Sign(message []byte) ([]byte, error) {
+	return k.key.Sign(rand.Reader, message, &mldsa.Options{})
+}
+
+func fromStandardLibrary(pub crypto.PublicKey) (*PublicKey, bool) {
+	pk, ok := pub.(*mldsa.PublicKey)
+	if !ok {
+		return nil, false
+	}
+	switch pk.Parameters() {
+	case mldsa.MLDSA65():
+		return &PublicKey{Parameters: MLDSA65, Bytes: pk.Bytes()}, true
+	case mldsa.MLDSA87():
+		return &PublicKey{Parameters: MLDSA87, Bytes: pk.Bytes()}, true
+	default:
+		return nil, false
+	}
+}
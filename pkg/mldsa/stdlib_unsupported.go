@@ -0,0 +1,30 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.27
+
+package mldsa
+
+import "crypto"
+
+// StandardLibrary returns a Backend using crypto/mldsa, and true. It
+// returns false when built with a Go release before 1.27, which does not
+// include crypto/mldsa.
+func StandardLibrary() (Backend, bool) {
+	return nil, false
+}
+
+func fromStandardLibrary(crypto.PublicKey) (*PublicKey, bool) {
+	return nil, false
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Media types of image indexes, which list the manifests of a multi-arch
+// image, and of the image manifests they list.
+const (
+	ImageIndexMediaType         = "application/vnd.oci.image.index.v1+json"
+	DockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ImageManifestMediaType      = "application/vnd.oci.image.manifest.v1+json"
+	DockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// docker buildx lists the attestation manifests of an image in its index,
+// annotated with this reference type.
+const (
+	referenceTypeAnnotation      = "vnd.docker.reference.type"
+	attestationManifestReference = "attestation-manifest"
+)
+
+// Platform is the platform an image manifest in an index runs on.
+type Platform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`  //nolint:tagliatelle
+	OSFeatures   []string `json:"os.features,omitempty"` //nolint:tagliatelle
+	Variant      string   `json:"variant,omitempty"`
+}
+
+// String returns the platform as "os/architecture[/variant]", e.g.
+// "linux/arm64/v8".
+func (p *Platform) String() string {
+	if p == nil {
+		return "unknown"
+	}
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// Descriptor references a manifest listed in an image index.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ImageIndex is an OCI image index or Docker manifest list.
+type ImageIndex struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ParseImageIndex parses an OCI image index or Docker manifest list,
+// checking that every manifest it lists has a valid digest.
+func ParseImageIndex(data []byte) (*ImageIndex, error) {
+	var index ImageIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("decoding image index: %w", err)
+	}
+	if index.SchemaVersion != 2 {
+		return nil, fmt.Errorf("unsupported image index schema version %d", index.SchemaVersion)
+	}
+	switch index.MediaType {
+	case "", ImageIndexMediaType, DockerManifestListMediaType:
+	default:
+		return nil, fmt.Errorf("unsupported image index media type %q", index.MediaType)
+	}
+	if len(index.Manifests) == 0 {
+		return nil, errors.New("image index has no manifests")
+	}
+	for _, manifest := range index.Manifests {
+		if err := validateManifestDigest(manifest.Digest); err != nil {
+			return nil, err
+		}
+	}
+	return &index, nil
+}
+
+// PlatformManifests returns the image manifests in the index that run on
+// a platform. Nested indexes, artifacts and the attestation manifests that
+// docker buildx adds to indexes are left out.
+func (i *ImageIndex) PlatformManifests() []Descriptor {
+	var manifests []Descriptor
+	for _, manifest := range i.Manifests {
+		switch manifest.MediaType {
+		case ImageManifestMediaType, DockerManifestMediaType:
+		default:
+			continue
+		}
+		if manifest.Platform == nil || manifest.Annotations[referenceTypeAnnotation] == attestationManifestReference {
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageIndex(t *testing.T) {
+	index, err := ParseImageIndex([]byte(`{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": [
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "` + testDigest + `", "size": 100, "platform": {"os": "linux", "architecture": "amd64"}},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:00ff", "size": 100, "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:0102", "size": 100, "platform": {"os": "unknown", "architecture": "unknown"}, "annotations": {"vnd.docker.reference.type": "attestation-manifest", "vnd.docker.reference.digest": "` + testDigest + `"}},
+			{"mediaType": "application/vnd.oci.image.index.v1+json", "digest": "sha256:0304", "size": 100}
+		]
+	}`))
+	require.NoError(t, err)
+	assert.Len(t, index.Manifests, 4)
+
+	manifests := index.PlatformManifests()
+	require.Len(t, manifests, 2)
+	assert.Equal(t, testDigest, manifests[0].Digest)
+	assert.Equal(t, "linux/amd64", manifests[0].Platform.String())
+	assert.Equal(t, "linux/arm64/v8", manifests[1].Platform.String())
+
+	var unknown *Platform
+	assert.Equal(t, "unknown", unknown.String())
+}
+
+func TestParseImageIndexInvalid(t *testing.T) {
+	for _, index := range []string{
+		"not json",
+		`{"schemaVersion": 1, "manifests": [{"digest": "` + testDigest + `"}]}`,
+		`{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "manifests": [{"digest": "` + testDigest + `"}]}`,
+		`{"schemaVersion": 2, "manifests": []}`,
+		`{"schemaVersion": 2, "manifests": [{"digest": "sha256:not-hex"}]}`,
+	} {
+		_, err := ParseImageIndex([]byte(index))
+		assert.Error(t, err, index)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oci handles the simple signing payloads that cosign signs for
+// container images, which bind the digest of an image's manifest, and the
+// image indexes that list the manifests of multi-arch images.
+package oci
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SimpleSigningType is the type of the simple signing payloads that
+// cosign signs for container images.
+const SimpleSigningType = "cosign container image signature"
+
+// SimpleSigningPayload is the payload that cosign signs for a container
+// image: the image's manifest digest, and the reference it was signed under.
+type SimpleSigningPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"` //nolint:tagliatelle
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"` //nolint:tagliatelle
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	// Optional holds the annotations given to cosign sign -a
+	Optional map[string]any `json:"optional"`
+}
+
+// NewSimpleSigningPayload returns the simple signing payload for the
+// image with the given manifest digest, e.g. "sha256:<hex>", pushed to the
+// repository dockerReference, e.g. "ghcr.io/sigstore/sigstore-go".
+func NewSimpleSigningPayload(dockerReference, manifestDigest string, optional map[string]any) (*SimpleSigningPayload, error) {
+	if err := validateManifestDigest(manifestDigest); err != nil {
+		return nil, err
+	}
+	payload := &SimpleSigningPayload{Optional: optional}
+	payload.Critical.Identity.DockerReference = dockerReference
+	payload.Critical.Image.DockerManifestDigest = manifestDigest
+	payload.Critical.Type = SimpleSigningType
+	return payload, nil
+}
+
+// ParseSimpleSigningPayload parses a simple signing payload, checking
+// that it is a cosign container image signature with a manifest digest.
+func ParseSimpleSigningPayload(data []byte) (*SimpleSigningPayload, error) {
+	var payload SimpleSigningPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("decoding simple signing payload: %w", err)
+	}
+	if payload.Critical.Type != SimpleSigningType {
+		return nil, fmt.Errorf("unsupported simple signing payload type %q", payload.Critical.Type)
+	}
+	if err := validateManifestDigest(payload.Critical.Image.DockerManifestDigest); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+func validateManifestDigest(manifestDigest string) error {
+	alg, encoded, ok := strings.Cut(manifestDigest, ":")
+	if !ok || alg == "" {
+		return fmt.Errorf("invalid image manifest digest %q", manifestDigest)
+	}
+	if _, err := hex.DecodeString(encoded); err != nil || encoded == "" {
+		return fmt.Errorf("invalid image manifest digest %q", manifestDigest)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDigest = "sha256:4c4b5e1a2d5b3c6a8e1f0d9c7b6a5e4d3c2b1a09f8e7d6c5b4a392817263544f"
+
+func TestSimpleSigningPayload(t *testing.T) {
+	payload, err := NewSimpleSigningPayload("ghcr.io/sigstore/sigstore-go", testDigest, nil)
+	require.NoError(t, err)
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"critical":{"identity":{"docker-reference":"ghcr.io/sigstore/sigstore-go"},"image":{"docker-manifest-digest":"`+testDigest+`"},"type":"cosign container image signature"},"optional":null}`, string(data))
+
+	parsed, err := ParseSimpleSigningPayload(data)
+	require.NoError(t, err)
+	assert.Equal(t, payload, parsed)
+
+	payload, err = NewSimpleSigningPayload("ghcr.io/sigstore/sigstore-go", testDigest, map[string]any{"env": "prod"})
+	require.NoError(t, err)
+	data, err = json.Marshal(payload)
+	require.NoError(t, err)
+	parsed, err = ParseSimpleSigningPayload(data)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", parsed.Optional["env"])
+}
+
+func TestSimpleSigningPayloadInvalid(t *testing.T) {
+	for _, digest := range []string{"", "sha256", "sha256:", ":abcd", "sha256:not-hex"} {
+		_, err := NewSimpleSigningPayload("ghcr.io/sigstore/sigstore-go", digest, nil)
+		assert.Error(t, err, digest)
+	}
+
+	_, err := ParseSimpleSigningPayload([]byte("not json"))
+	assert.Error(t, err)
+	_, err = ParseSimpleSigningPayload([]byte(`{"critical":{"image":{"docker-manifest-digest":"` + testDigest + `"},"type":"atomic container signature"}}`))
+	assert.Error(t, err)
+	_, err = ParseSimpleSigningPayload([]byte(`{"critical":{"type":"cosign container image signature"}}`))
+	assert.Error(t, err)
+}
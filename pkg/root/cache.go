@@ -0,0 +1,139 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"sync"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// Copies of an authority or log share its cache, and their exported fields
+// can be changed after they are parsed, so each cache records what its
+// material was built from and is only used while that is unchanged. The
+// check compares certificate and key pointers, which is much cheaper than
+// the material it saves rebuilding.
+
+// certPoolCache holds the certificate pools derived from a
+// CertificateAuthority, built the first time they are needed.
+type certPoolCache struct {
+	mu sync.Mutex
+	// certs are the root and intermediates the pools were built from
+	certs         []*x509.Certificate
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+}
+
+// verifierCache holds the signature verifier derived from a TransparencyLog's
+// public key, loaded the first time it is needed.
+type verifierCache struct {
+	mu sync.Mutex
+	// publicKey and hashFunc are what the verifier was loaded from
+	publicKey crypto.PublicKey
+	hashFunc  crypto.Hash
+	verifier  signature.Verifier
+	err       error
+}
+
+// CertPools returns pools containing the authority's root and intermediate
+// certificates, for use in x509.VerifyOptions.
+//
+// For authorities parsed from a trusted root, the pools are built once and
+// shared by all callers with the same certificates, so verifying many
+// certificates against the same trusted root doesn't rebuild them each time.
+// The returned pools must not be modified. Authorities constructed directly
+// get new pools on every call.
+func (ca *CertificateAuthority) CertPools() (roots *x509.CertPool, intermediates *x509.CertPool) {
+	if ca.pools == nil {
+		return ca.buildCertPools()
+	}
+	ca.pools.mu.Lock()
+	defer ca.pools.mu.Unlock()
+	if ca.pools.roots == nil || !ca.builtFrom(ca.pools.certs) {
+		ca.pools.roots, ca.pools.intermediates = ca.buildCertPools()
+		ca.pools.certs = append([]*x509.Certificate{ca.Root}, ca.Intermediates...)
+	}
+	return ca.pools.roots, ca.pools.intermediates
+}
+
+func (ca *CertificateAuthority) buildCertPools() (*x509.CertPool, *x509.CertPool) {
+	roots := x509.NewCertPool()
+	if ca.Root != nil {
+		roots.AddCert(ca.Root)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range ca.Intermediates {
+		intermediates.AddCert(cert)
+	}
+	return roots, intermediates
+}
+
+// builtFrom returns true if certs are the authority's root followed by its
+// intermediates.
+func (ca *CertificateAuthority) builtFrom(certs []*x509.Certificate) bool {
+	if len(certs) != len(ca.Intermediates)+1 || certs[0] != ca.Root {
+		return false
+	}
+	for i, cert := range ca.Intermediates {
+		if certs[i+1] != cert {
+			return false
+		}
+	}
+	return true
+}
+
+// Verifier returns a signature verifier for the log's public key, using
+// SignatureHashFunc.
+//
+// For logs parsed from a trusted root, the verifier is loaded once and shared
+// by all callers with the same key and hash function. Logs constructed
+// directly load a new verifier on every call.
+func (l *TransparencyLog) Verifier() (signature.Verifier, error) {
+	if l.verifier == nil {
+		return signature.LoadVerifier(l.PublicKey, l.SignatureHashFunc)
+	}
+	l.verifier.mu.Lock()
+	defer l.verifier.mu.Unlock()
+	if !sameKey(l.verifier.publicKey, l.PublicKey) || l.verifier.hashFunc != l.SignatureHashFunc {
+		l.verifier.verifier, l.verifier.err = signature.LoadVerifier(l.PublicKey, l.SignatureHashFunc)
+		l.verifier.publicKey = l.PublicKey
+		l.verifier.hashFunc = l.SignatureHashFunc
+	}
+	return l.verifier.verifier, l.verifier.err
+}
+
+// sameKey returns true if a and b are the same public key object. Keys of
+// other types than those trusted roots contain are never the same, so their
+// verifiers aren't cached.
+func sameKey(a, b crypto.PublicKey) bool {
+	switch a := a.(type) {
+	case *ecdsa.PublicKey:
+		b, ok := b.(*ecdsa.PublicKey)
+		return ok && a == b
+	case *rsa.PublicKey:
+		b, ok := b.(*rsa.PublicKey)
+		return ok && a == b
+	case ed25519.PublicKey:
+		b, ok := b.(ed25519.PublicKey)
+		return ok && bytes.Equal(a, b)
+	}
+	return false
+}
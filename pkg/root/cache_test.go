@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedRootCachesDerivedMaterial(t *testing.T) {
+	trustedRoot, err := NewTrustedRootFromPath("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+
+	cas := trustedRoot.FulcioCertificateAuthorities()
+	assert.NotEmpty(t, cas)
+	roots, intermediates := cas[0].CertPools()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, ca := range trustedRoot.FulcioCertificateAuthorities() {
+				ca.CertPools()
+			}
+			for _, tlog := range trustedRoot.RekorLogs() {
+				_, err := tlog.Verifier()
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// copies of the authority share the same pools
+	roots2, intermediates2 := trustedRoot.FulcioCertificateAuthorities()[0].CertPools()
+	assert.Same(t, roots, roots2)
+	assert.Same(t, intermediates, intermediates2)
+
+	for _, tlog := range trustedRoot.RekorLogs() {
+		v1, err := tlog.Verifier()
+		assert.NoError(t, err)
+		v2, err := tlog.Verifier()
+		assert.NoError(t, err)
+		assert.Same(t, v1, v2)
+	}
+
+	// cached material is returned without rebuilding or hashing anything
+	ca0 := cas[0]
+	assert.Zero(t, testing.AllocsPerRun(100, func() { ca0.CertPools() }))
+	for _, tlog := range trustedRoot.RekorLogs() {
+		assert.Zero(t, testing.AllocsPerRun(100, func() { _, _ = tlog.Verifier() }))
+	}
+
+	// copies that are changed after parsing don't get stale material
+	last := len(cas) - 1
+	lastRoots, _ := cas[last].CertPools()
+	changed := trustedRoot.FulcioCertificateAuthorities()[last]
+	changed.Root = cas[0].Root
+	changedRoots, _ := changed.CertPools()
+	assert.True(t, changedRoots.Equal(roots))
+	assert.False(t, changedRoots.Equal(lastRoots))
+	lastRoots2, _ := trustedRoot.FulcioCertificateAuthorities()[last].CertPools()
+	assert.True(t, lastRoots2.Equal(lastRoots))
+
+	tlogs := trustedRoot.RekorLogs()
+	for _, tlog := range tlogs {
+		changedLog := *tlog
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		changedLog.PublicKey = key.Public()
+		verifier, err := changedLog.Verifier()
+		assert.NoError(t, err)
+		publicKey, err := verifier.PublicKey()
+		assert.NoError(t, err)
+		assert.True(t, key.PublicKey.Equal(publicKey))
+	}
+
+	// authorities constructed directly still work, without caching
+	ca := CertificateAuthority{Root: cas[0].Root, Intermediates: cas[0].Intermediates}
+	roots3, _ := ca.CertPools()
+	assert.True(t, roots3.Equal(roots))
+	assert.NotSame(t, roots, roots3)
+}
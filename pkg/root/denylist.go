@@ -0,0 +1,291 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// ErrDenylisted is returned when verification material uses a key or
+// certificate on a Denylist.
+var ErrDenylisted = errors.New("denylisted")
+
+// DenylistEntries are the keys and certificates on a denylist.
+type DenylistEntries struct {
+	// KeyFingerprints are hex-encoded SHA-256 digests of the DER encoded
+	// SubjectPublicKeyInfo of denied keys, as computed by
+	// SHA256SPKIHexKeyHint
+	KeyFingerprints []string `json:"keyFingerprints,omitempty"`
+	// Certificates are the denied certificates, by issuer and serial number
+	Certificates []DenylistedCertificate `json:"certificates,omitempty"`
+}
+
+// DenylistedCertificate identifies a denied certificate. Serial numbers are
+// only unique per issuer, so certificates are identified by both.
+type DenylistedCertificate struct {
+	// IssuerKeyFingerprint is the hex-encoded SHA-256 digest of the DER
+	// encoded SubjectPublicKeyInfo of the certificate's issuer, as computed
+	// by SHA256SPKIHexKeyHint
+	IssuerKeyFingerprint string `json:"issuerKeyFingerprint"`
+	// Serial is the certificate's serial number
+	Serial *big.Int `json:"serial"`
+}
+
+// ParseDenylistEntries parses denylist entries from JSON, in the format
+// {"keyFingerprints": ["<hex>", ...], "certificates":
+// [{"issuerKeyFingerprint": "<hex>", "serial": <n>}, ...]}.
+func ParseDenylistEntries(data []byte) (*DenylistEntries, error) {
+	entries := &DenylistEntries{}
+	if err := json.Unmarshal(data, entries); err != nil {
+		return nil, fmt.Errorf("failed to parse denylist: %w", err)
+	}
+	return entries, nil
+}
+
+// DenylistFetcher fetches denylist entries from a remote source, such as a
+// URL or a TUF target published alongside the trusted root.
+type DenylistFetcher interface {
+	FetchDenylist(ctx context.Context) (*DenylistEntries, error)
+}
+
+// DenylistFetcherFunc adapts a function to a DenylistFetcher.
+type DenylistFetcherFunc func(ctx context.Context) (*DenylistEntries, error)
+
+func (f DenylistFetcherFunc) FetchDenylist(ctx context.Context) (*DenylistEntries, error) {
+	return f(ctx)
+}
+
+type denylistSet struct {
+	keys         map[string]bool
+	certificates map[denylistedCertificateKey]bool
+}
+
+type denylistedCertificateKey struct {
+	issuerKeyFingerprint string
+	serial               string
+}
+
+func newDenylistSet() denylistSet {
+	return denylistSet{keys: map[string]bool{}, certificates: map[denylistedCertificateKey]bool{}}
+}
+
+func (s denylistSet) add(entries *DenylistEntries) error {
+	for _, fingerprint := range entries.KeyFingerprints {
+		normalized, err := normalizeKeyFingerprint(fingerprint)
+		if err != nil {
+			return err
+		}
+		s.keys[normalized] = true
+	}
+	for _, cert := range entries.Certificates {
+		if cert.Serial == nil {
+			return errors.New("denylist has an empty certificate serial")
+		}
+		issuer, err := normalizeKeyFingerprint(cert.IssuerKeyFingerprint)
+		if err != nil {
+			return fmt.Errorf("invalid certificate issuer: %w", err)
+		}
+		s.certificates[denylistedCertificateKey{issuer, cert.Serial.String()}] = true
+	}
+	return nil
+}
+
+func normalizeKeyFingerprint(fingerprint string) (string, error) {
+	decoded, err := hex.DecodeString(fingerprint)
+	if err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid key fingerprint %q: must be a hex-encoded SHA-256 digest", fingerprint)
+	}
+	return hex.EncodeToString(decoded), nil
+}
+
+// Denylist is a set of keys and certificates that must not be trusted even
+// when they chain to trusted material, such as compromised keys or keys
+// known to be weak or only meant for debugging. Entries are registered
+// directly or fetched from a DenylistFetcher. A Denylist is safe for
+// concurrent use.
+type Denylist struct {
+	mu      sync.RWMutex
+	local   denylistSet
+	fetched denylistSet
+}
+
+// NewDenylist returns a denylist with the given entries.
+func NewDenylist(entries ...*DenylistEntries) (*Denylist, error) {
+	d := &Denylist{local: newDenylistSet(), fetched: newDenylistSet()}
+	for _, e := range entries {
+		if err := d.local.add(e); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// AddKeyFingerprint denies the key with the given hex-encoded SHA-256
+// SubjectPublicKeyInfo digest.
+func (d *Denylist) AddKeyFingerprint(fingerprint string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.local.add(&DenylistEntries{KeyFingerprints: []string{fingerprint}})
+}
+
+// AddPublicKey denies the given key.
+func (d *Denylist) AddPublicKey(pub crypto.PublicKey) error {
+	fingerprint, err := SHA256SPKIHexKeyHint(pub)
+	if err != nil {
+		return err
+	}
+	return d.AddKeyFingerprint(fingerprint)
+}
+
+// AddCertificateSerial denies the certificate with the given serial number
+// issued by the key with the given hex-encoded SHA-256
+// SubjectPublicKeyInfo digest.
+func (d *Denylist) AddCertificateSerial(issuerKeyFingerprint string, serial *big.Int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.local.add(&DenylistEntries{Certificates: []DenylistedCertificate{{IssuerKeyFingerprint: issuerKeyFingerprint, Serial: serial}}})
+}
+
+// AddCertificate denies the given certificate, issued by issuer.
+func (d *Denylist) AddCertificate(cert, issuer *x509.Certificate) error {
+	fingerprint, err := SHA256SPKIHexKeyHint(issuer.PublicKey)
+	if err != nil {
+		return err
+	}
+	return d.AddCertificateSerial(fingerprint, cert.SerialNumber)
+}
+
+// Fetch replaces the entries previously fetched into the denylist with the
+// ones returned by the fetcher. Entries added directly are kept. If the
+// fetch fails, the previously fetched entries are kept as well.
+func (d *Denylist) Fetch(ctx context.Context, fetcher DenylistFetcher) error {
+	entries, err := fetcher.FetchDenylist(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch denylist: %w", err)
+	}
+	fetched := newDenylistSet()
+	if err := fetched.add(entries); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fetched = fetched
+	return nil
+}
+
+// CheckPublicKey returns an error wrapping ErrDenylisted if the key is
+// denied.
+func (d *Denylist) CheckPublicKey(pub crypto.PublicKey) error {
+	fingerprint, err := SHA256SPKIHexKeyHint(pub)
+	if err != nil {
+		return err
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.local.keys[fingerprint] || d.fetched.keys[fingerprint] {
+		return fmt.Errorf("%w: key %s", ErrDenylisted, fingerprint)
+	}
+	return nil
+}
+
+// CheckCertificate returns an error wrapping ErrDenylisted if the
+// certificate, identified by its issuer and serial number, or its public
+// key is denied. The issuer is the certificate that issued cert.
+func (d *Denylist) CheckCertificate(cert, issuer *x509.Certificate) error {
+	if issuer == nil {
+		return errors.New("certificate issuer is required to check the denylist")
+	}
+	issuerFingerprint, err := SHA256SPKIHexKeyHint(issuer.PublicKey)
+	if err != nil {
+		return err
+	}
+	key := denylistedCertificateKey{issuerFingerprint, cert.SerialNumber.String()}
+	d.mu.RLock()
+	denied := d.local.certificates[key] || d.fetched.certificates[key]
+	d.mu.RUnlock()
+	if denied {
+		return fmt.Errorf("%w: certificate serial %s issued by key %s", ErrDenylisted, key.serial, issuerFingerprint)
+	}
+	return d.CheckPublicKey(cert.PublicKey)
+}
+
+// DenylistMaterial is implemented by trusted material that has denylists
+// of keys and certificates.
+type DenylistMaterial interface {
+	Denylists() []*Denylist
+}
+
+// Denylists returns the denylists of the trusted material, or nil if it
+// does not implement DenylistMaterial.
+func Denylists(tm TrustedMaterial) []*Denylist {
+	if denylists, ok := tm.(DenylistMaterial); ok {
+		return denylists.Denylists()
+	}
+	return nil
+}
+
+// TrustedMaterialWithDenylist adds a denylist to trusted material.
+type TrustedMaterialWithDenylist struct {
+	TrustedMaterial
+	denylist *Denylist
+}
+
+var _ DenylistMaterial = &TrustedMaterialWithDenylist{}
+
+// WithDenylist returns the trusted material with the given denylist, which
+// verifiers consult before accepting a signature.
+func WithDenylist(tm TrustedMaterial, denylist *Denylist) *TrustedMaterialWithDenylist {
+	return &TrustedMaterialWithDenylist{TrustedMaterial: tm, denylist: denylist}
+}
+
+// Unwrap returns the trusted material the denylist was added to.
+func (tm *TrustedMaterialWithDenylist) Unwrap() TrustedMaterial {
+	return tm.TrustedMaterial
+}
+
+func (tm *TrustedMaterialWithDenylist) Denylists() []*Denylist {
+	return append(Denylists(tm.TrustedMaterial), tm.denylist)
+}
+
+// OIDCProviders passes through the OIDC providers of the wrapped trusted
+// material.
+func (tm *TrustedMaterialWithDenylist) OIDCProviders() []OIDCProvider {
+	return OIDCProviders(tm.TrustedMaterial)
+}
+
+func (tm *TrustedMaterialWithOIDCProviders) Denylists() []*Denylist {
+	return Denylists(tm.TrustedMaterial)
+}
+
+func (tmc TrustedMaterialCollection) Denylists() []*Denylist {
+	var denylists []*Denylist
+	for _, tm := range tmc {
+		denylists = append(denylists, Denylists(tm)...)
+	}
+	return denylists
+}
+
+func (n NamedTrustedMaterial) Denylists() []*Denylist {
+	return Denylists(n.TrustedMaterial)
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenylist(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	fingerprint, err := SHA256SPKIHexKeyHint(key.Public())
+	require.NoError(t, err)
+
+	denylist, err := NewDenylist()
+	require.NoError(t, err)
+	assert.NoError(t, denylist.CheckPublicKey(key.Public()))
+
+	// Fingerprints are compared case-insensitively
+	require.NoError(t, denylist.AddKeyFingerprint(strings.ToUpper(fingerprint)))
+	err = denylist.CheckPublicKey(key.Public())
+	assert.ErrorIs(t, err, ErrDenylisted)
+	assert.Contains(t, err.Error(), fingerprint)
+	assert.NoError(t, denylist.CheckPublicKey(otherKey.Public()))
+
+	assert.Error(t, denylist.AddKeyFingerprint("not-hex"))
+	assert.Error(t, denylist.AddKeyFingerprint("abcd"))
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherIssuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuer := &x509.Certificate{PublicKey: issuerKey.Public()}
+	otherIssuer := &x509.Certificate{PublicKey: otherIssuerKey.Public()}
+	issuerFingerprint, err := SHA256SPKIHexKeyHint(issuerKey.Public())
+	require.NoError(t, err)
+	assert.Error(t, denylist.AddCertificateSerial(issuerFingerprint, nil))
+	assert.Error(t, denylist.AddCertificateSerial("not-hex", big.NewInt(42)))
+
+	cert := &x509.Certificate{SerialNumber: big.NewInt(42), PublicKey: otherKey.Public()}
+	assert.NoError(t, denylist.CheckCertificate(cert, issuer))
+	require.NoError(t, denylist.AddCertificate(cert, issuer))
+	err = denylist.CheckCertificate(cert, issuer)
+	assert.ErrorIs(t, err, ErrDenylisted)
+	assert.Contains(t, err.Error(), "certificate serial 42 issued by key "+issuerFingerprint)
+
+	// Serial numbers are only unique per issuer
+	assert.NoError(t, denylist.CheckCertificate(cert, otherIssuer))
+	assert.Error(t, denylist.CheckCertificate(cert, nil))
+
+	// A certificate for a denied key is denied
+	cert = &x509.Certificate{SerialNumber: big.NewInt(43), PublicKey: key.Public()}
+	assert.ErrorIs(t, denylist.CheckCertificate(cert, issuer), ErrDenylisted)
+}
+
+func TestDenylistFetch(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	localKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	fingerprint, err := SHA256SPKIHexKeyHint(key.Public())
+	require.NoError(t, err)
+
+	denylist, err := NewDenylist()
+	require.NoError(t, err)
+	require.NoError(t, denylist.AddPublicKey(localKey.Public()))
+
+	entries, err := ParseDenylistEntries([]byte(`{"keyFingerprints": ["` + fingerprint + `"], "certificates": [{"issuerKeyFingerprint": "` + fingerprint + `", "serial": 123456789012345678901234567890}]}`))
+	require.NoError(t, err)
+	serial, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	assert.Equal(t, []DenylistedCertificate{{IssuerKeyFingerprint: fingerprint, Serial: serial}}, entries.Certificates)
+
+	fetcher := DenylistFetcherFunc(func(_ context.Context) (*DenylistEntries, error) {
+		return entries, nil
+	})
+	require.NoError(t, denylist.Fetch(context.Background(), fetcher))
+	assert.ErrorIs(t, denylist.CheckPublicKey(key.Public()), ErrDenylisted)
+	certKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	assert.ErrorIs(t, denylist.CheckCertificate(&x509.Certificate{SerialNumber: serial, PublicKey: certKey.Public()}, &x509.Certificate{PublicKey: key.Public()}), ErrDenylisted)
+
+	// A failed fetch keeps the previously fetched entries
+	failing := DenylistFetcherFunc(func(_ context.Context) (*DenylistEntries, error) {
+		return nil, errors.New("unavailable")
+	})
+	assert.Error(t, denylist.Fetch(context.Background(), failing))
+	assert.ErrorIs(t, denylist.CheckPublicKey(key.Public()), ErrDenylisted)
+
+	// A new fetch replaces previously fetched entries, but not local ones
+	empty := DenylistFetcherFunc(func(_ context.Context) (*DenylistEntries, error) {
+		return &DenylistEntries{}, nil
+	})
+	require.NoError(t, denylist.Fetch(context.Background(), empty))
+	assert.NoError(t, denylist.CheckPublicKey(key.Public()))
+	assert.ErrorIs(t, denylist.CheckPublicKey(localKey.Public()), ErrDenylisted)
+
+	_, err = ParseDenylistEntries([]byte(`{"keyFingerprints": "abc"}`))
+	assert.Error(t, err)
+}
+
+func TestDenylists(t *testing.T) {
+	a, err := NewDenylist()
+	require.NoError(t, err)
+	b, err := NewDenylist()
+	require.NoError(t, err)
+
+	tm := &BaseTrustedMaterial{}
+	assert.Nil(t, Denylists(tm))
+	withA := WithDenylist(tm, a)
+	assert.Equal(t, []*Denylist{a}, Denylists(withA))
+	assert.Equal(t, []*Denylist{a, b}, Denylists(WithDenylist(withA, b)))
+
+	// Denylists and OIDC providers are passed through each other's wrappers
+	provider := OIDCProvider{Issuer: "https://issuer.example.com"}
+	assert.Equal(t, []*Denylist{a}, Denylists(WithOIDCProviders(withA, provider)))
+	assert.Equal(t, []OIDCProvider{provider}, OIDCProviders(WithDenylist(WithOIDCProviders(tm, provider), b)))
+
+	collection := TrustedMaterialCollection{withA, WithDenylist(tm, b)}
+	assert.Equal(t, []*Denylist{a, b}, Denylists(collection))
+	assert.Equal(t, []*Denylist{a}, Denylists(NamedTrustedMaterial{Name: "a", TrustedMaterial: withA}))
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// ErrStaleTrustedMaterial is returned when trusted material was fetched
+// longer ago than allowed, or the TUF metadata it was fetched with has
+// expired.
+var ErrStaleTrustedMaterial = errors.New("trusted material is stale")
+
+// ErrUnknownFreshness is returned when a freshness constraint applies to
+// trusted material that was not fetched from TUF, such as a trusted root
+// read from a file.
+var ErrUnknownFreshness = errors.New("freshness of trusted material is unknown")
+
+// Freshness describes when trusted material was fetched from a TUF
+// repository, and until when the TUF metadata it was fetched with is valid.
+type Freshness struct {
+	// Fetched is when the trusted material was fetched and checked against
+	// the TUF metadata
+	Fetched time.Time
+	// Expires is the earliest expiry of the TUF metadata, usually that of
+	// the timestamp role, after which the trusted material may be missing
+	// key rotations and revocations
+	Expires time.Time
+}
+
+// Check returns an error wrapping ErrStaleTrustedMaterial if the trusted
+// material was fetched more than maxAge before now, or its TUF metadata
+// has expired. A maxAge of zero only checks the expiry.
+func (f Freshness) Check(now time.Time, maxAge time.Duration) error {
+	if !f.Expires.IsZero() && now.After(f.Expires) {
+		return fmt.Errorf("%w: its TUF metadata expired at %s", ErrStaleTrustedMaterial, f.Expires.Format(time.RFC3339))
+	}
+	if maxAge > 0 && now.Sub(f.Fetched) > maxAge {
+		return fmt.Errorf("%w: fetched at %s, more than %s ago", ErrStaleTrustedMaterial, f.Fetched.Format(time.RFC3339), maxAge)
+	}
+	return nil
+}
+
+// FreshnessMaterial is implemented by trusted material that knows when it
+// was fetched from TUF.
+type FreshnessMaterial interface {
+	// Freshness returns the trusted material's freshness, or nil if it is
+	// unknown
+	Freshness() *Freshness
+}
+
+// TrustedMaterialFreshness returns the freshness of the trusted material,
+// or nil if it does not implement FreshnessMaterial or its freshness is
+// unknown.
+func TrustedMaterialFreshness(tm TrustedMaterial) *Freshness {
+	if freshness, ok := tm.(FreshnessMaterial); ok {
+		return freshness.Freshness()
+	}
+	return nil
+}
+
+// tufFreshness returns the freshness of a target just fetched with the
+// client: when its metadata was last refreshed from the repository, which
+// may be long ago for metadata loaded from the local cache, and when the
+// metadata expires. It returns nil if the client can't tell when its
+// metadata was refreshed, so that freshness constraints fail rather than
+// pass on a made-up fetch time.
+func tufFreshness(c *tuf.Client) *Freshness {
+	status, err := c.CacheStatus()
+	if err != nil || status.LastRefresh.IsZero() {
+		return nil
+	}
+	return &Freshness{Fetched: status.LastRefresh, Expires: status.Expires()}
+}
+
+// Freshness returns when the trusted root was fetched from TUF, or nil if
+// it was not, for example because it was read from a file.
+func (tr *TrustedRoot) Freshness() *Freshness {
+	return tr.freshness
+}
+
+// Freshness returns the freshness of the current trusted root, which is
+// replaced on each successful refresh.
+func (l *LiveTrustedRoot) Freshness() *Freshness {
+	return l.Current().Freshness()
+}
+
+// Freshness returns the freshness of the stalest trusted material in the
+// collection: the earliest fetch and expiry. It is nil if the freshness of
+// any of them is unknown.
+func (tmc TrustedMaterialCollection) Freshness() *Freshness {
+	var stalest *Freshness
+	for _, tm := range tmc {
+		freshness := TrustedMaterialFreshness(tm)
+		if freshness == nil {
+			return nil
+		}
+		if stalest == nil {
+			stalest = &Freshness{Fetched: freshness.Fetched, Expires: freshness.Expires}
+			continue
+		}
+		if freshness.Fetched.Before(stalest.Fetched) {
+			stalest.Fetched = freshness.Fetched
+		}
+		if !freshness.Expires.IsZero() && (stalest.Expires.IsZero() || freshness.Expires.Before(stalest.Expires)) {
+			stalest.Expires = freshness.Expires
+		}
+	}
+	return stalest
+}
+
+func (n NamedTrustedMaterial) Freshness() *Freshness {
+	return TrustedMaterialFreshness(n.TrustedMaterial)
+}
+
+// Freshness passes through the freshness of the wrapped trusted material.
+func (tm *TrustedMaterialWithDenylist) Freshness() *Freshness {
+	return TrustedMaterialFreshness(tm.TrustedMaterial)
+}
+
+// Freshness passes through the freshness of the wrapped trusted material.
+func (tm *TrustedMaterialWithOIDCProviders) Freshness() *Freshness {
+	return TrustedMaterialFreshness(tm.TrustedMaterial)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreshnessCheck(t *testing.T) {
+	now := time.Now()
+	freshness := Freshness{Fetched: now.Add(-time.Hour), Expires: now.Add(time.Hour)}
+	assert.NoError(t, freshness.Check(now, 2*time.Hour))
+	assert.NoError(t, freshness.Check(now, 0))
+	assert.ErrorIs(t, freshness.Check(now, 30*time.Minute), ErrStaleTrustedMaterial)
+	assert.ErrorIs(t, freshness.Check(now.Add(2*time.Hour), 0), ErrStaleTrustedMaterial)
+
+	// Unknown expiry
+	freshness.Expires = time.Time{}
+	assert.NoError(t, freshness.Check(now, 2*time.Hour))
+}
+
+func TestTrustedRootFreshness(t *testing.T) {
+	trustedRootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	require.NoError(t, err)
+
+	// Trusted roots not fetched from TUF have no freshness
+	trustedRoot, err := NewTrustedRootFromJSON(trustedRootJSON)
+	require.NoError(t, err)
+	assert.Nil(t, TrustedMaterialFreshness(trustedRoot))
+
+	// Nor do trusted roots fetched with a TUF client that can't tell when
+	// its metadata was refreshed
+	trustedRoot, err = fetchedTrustedRoot{trustedRootJSON, nil}.trustedRoot()
+	require.NoError(t, err)
+	assert.Nil(t, TrustedMaterialFreshness(trustedRoot))
+
+	fetched := fetchedTrustedRoot{trustedRootJSON, &Freshness{Fetched: time.Unix(100, 0), Expires: time.Unix(200, 0)}}
+	trustedRoot, err = fetched.trustedRoot()
+	require.NoError(t, err)
+	other, err := fetched.trustedRoot()
+	require.NoError(t, err)
+	assert.Equal(t, &Freshness{Fetched: time.Unix(100, 0), Expires: time.Unix(200, 0)}, TrustedMaterialFreshness(trustedRoot))
+	// Each trusted root has its own copy
+	assert.NotSame(t, trustedRoot.Freshness(), other.Freshness())
+
+	// Wrappers and collections pass the freshness through
+	assert.Equal(t, trustedRoot.Freshness(), TrustedMaterialFreshness(WithOIDCProviders(trustedRoot)))
+	assert.Equal(t, trustedRoot.Freshness(), TrustedMaterialFreshness(NamedTrustedMaterial{Name: "public good", TrustedMaterial: trustedRoot}))
+
+	newer, err := fetchedTrustedRoot{trustedRootJSON, &Freshness{Fetched: time.Unix(150, 0), Expires: time.Unix(180, 0)}}.trustedRoot()
+	require.NoError(t, err)
+	assert.Equal(t, &Freshness{Fetched: time.Unix(100, 0), Expires: time.Unix(180, 0)}, TrustedMaterialFreshness(TrustedMaterialCollection{trustedRoot, newer}))
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// LoadVerifierWithKeyDetails returns a verifier for the public key that uses
+// the signature algorithm described by keyDetails, for example RSASSA-PSS
+// for PKIX_RSA_PSS_2048_SHA256. The key must match the type and size that
+// keyDetails describes.
+//
+// keyDetails has no values for RSASSA-PSS with SHA-384 or SHA-512; verifiers
+// for those can be loaded with signature.LoadRSAPSSVerifier.
+func LoadVerifierWithKeyDetails(pub crypto.PublicKey, keyDetails protocommon.PublicKeyDetails) (signature.Verifier, error) {
+	switch keyDetails {
+	case protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256:
+		rsaKey, err := rsaPublicKey(pub, keyDetails)
+		if err != nil {
+			return nil, err
+		}
+		return signature.LoadRSAPKCS1v15Verifier(rsaKey, crypto.SHA256)
+	case protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256:
+		rsaKey, err := rsaPublicKey(pub, keyDetails)
+		if err != nil {
+			return nil, err
+		}
+		return signature.LoadRSAPSSVerifier(rsaKey, crypto.SHA256, nil)
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256:
+		return loadECDSAVerifier(pub, keyDetails, elliptic.P256(), crypto.SHA256)
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384:
+		return loadECDSAVerifier(pub, keyDetails, elliptic.P384(), crypto.SHA384)
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P521_SHA_512:
+		return loadECDSAVerifier(pub, keyDetails, elliptic.P521(), crypto.SHA512)
+	case protocommon.PublicKeyDetails_PKIX_ED25519:
+		edKey, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, not Ed25519 as %s requires", pub, keyDetails)
+		}
+		return signature.LoadED25519Verifier(edKey)
+	case protocommon.PublicKeyDetails_PKIX_ED25519_PH:
+		edKey, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, not Ed25519 as %s requires", pub, keyDetails)
+		}
+		return signature.LoadED25519phVerifier(edKey)
+	default:
+		return nil, fmt.Errorf("unsupported public key details: %s", keyDetails)
+	}
+}
+
+// rsaKeySizes are the RSA modulus sizes, in bits, of each RSA key details
+// value.
+var rsaKeySizes = map[protocommon.PublicKeyDetails]int{
+	protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256: 2048,
+	protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256: 3072,
+	protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256: 4096,
+	protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256:      2048,
+	protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256:      3072,
+	protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256:      4096,
+}
+
+func rsaPublicKey(pub crypto.PublicKey, keyDetails protocommon.PublicKeyDetails) (*rsa.PublicKey, error) {
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is %T, not RSA as %s requires", pub, keyDetails)
+	}
+	if rsaKey.N.BitLen() != rsaKeySizes[keyDetails] {
+		return nil, fmt.Errorf("RSA public key is %d bits, not %d as %s requires", rsaKey.N.BitLen(), rsaKeySizes[keyDetails], keyDetails)
+	}
+	return rsaKey, nil
+}
+
+func loadECDSAVerifier(pub crypto.PublicKey, keyDetails protocommon.PublicKeyDetails, curve elliptic.Curve, hashFunc crypto.Hash) (signature.Verifier, error) {
+	ecKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok || ecKey.Curve != curve {
+		return nil, fmt.Errorf("public key is not an ECDSA %s key as %s requires", curve.Params().Name, keyDetails)
+	}
+	return signature.LoadECDSAVerifier(ecKey, hashFunc)
+}
+
+// RSAKeyDetails returns the key details value for an RSA key used with
+// SHA-256 and either RSASSA-PSS or PKCS #1 v1.5 signatures, or
+// PUBLIC_KEY_DETAILS_UNSPECIFIED if there is no value for the key size.
+func RSAKeyDetails(pub *rsa.PublicKey, pss bool) protocommon.PublicKeyDetails {
+	switch {
+	case pss && pub.N.BitLen() == 2048:
+		return protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256
+	case pss && pub.N.BitLen() == 3072:
+		return protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256
+	case pss && pub.N.BitLen() == 4096:
+		return protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256
+	case !pss && pub.N.BitLen() == 2048:
+		return protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256
+	case !pss && pub.N.BitLen() == 3072:
+		return protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256
+	case !pss && pub.N.BitLen() == 4096:
+		return protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256
+	default:
+		return protocommon.PublicKeyDetails_PUBLIC_KEY_DETAILS_UNSPECIFIED
+	}
+}
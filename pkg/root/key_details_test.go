@@ -0,0 +1,69 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadVerifierWithKeyDetails(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256, RSAKeyDetails(&rsaKey.PublicKey, true))
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256, RSAKeyDetails(&rsaKey.PublicKey, false))
+
+	digest := sha256.Sum256([]byte("hello"))
+	pssSig, err := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest[:], nil)
+	require.NoError(t, err)
+
+	verifier, err := LoadVerifierWithKeyDetails(&rsaKey.PublicKey, protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256)
+	require.NoError(t, err)
+	assert.IsType(t, &signature.RSAPSSVerifier{}, verifier)
+	assert.NoError(t, verifier.VerifySignature(bytes.NewReader(pssSig), nil, options.WithDigest(digest[:])))
+
+	verifier, err = LoadVerifierWithKeyDetails(&rsaKey.PublicKey, protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256)
+	require.NoError(t, err)
+	assert.Error(t, verifier.VerifySignature(bytes.NewReader(pssSig), nil, options.WithDigest(digest[:])))
+
+	verifier, err = LoadVerifierWithKeyDetails(&ecKey.PublicKey, protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256)
+	require.NoError(t, err)
+	assert.IsType(t, &signature.ECDSAVerifier{}, verifier)
+
+	// The key must match the key details
+	_, err = LoadVerifierWithKeyDetails(&rsaKey.PublicKey, protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256)
+	assert.Error(t, err)
+	_, err = LoadVerifierWithKeyDetails(&ecKey.PublicKey, protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384)
+	assert.Error(t, err)
+	_, err = LoadVerifierWithKeyDetails(&ecKey.PublicKey, protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256)
+	assert.Error(t, err)
+	_, err = LoadVerifierWithKeyDetails(&rsaKey.PublicKey, protocommon.PublicKeyDetails_PUBLIC_KEY_DETAILS_UNSPECIFIED)
+	assert.Error(t, err)
+}
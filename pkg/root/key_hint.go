@@ -0,0 +1,129 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"crypto"
+	"crypto/sha1" //nolint:gosec // SHA-1 is how RFC 5280 derives key identifiers
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/mldsa"
+)
+
+// KeyHintScheme computes the hint used to identify a public key in a bundle's
+// verification material. Ecosystems compute hints differently, so signers
+// and verifiers may need to agree on a scheme other than the default.
+type KeyHintScheme func(crypto.PublicKey) (string, error)
+
+// DefaultKeyHintSchemes are the schemes KeyHintMatches and
+// NewTrustedPublicKeyMaterialFromMapping match hints under.
+var DefaultKeyHintSchemes = []KeyHintScheme{SHA256SPKIKeyHint, SHA256SPKIHexKeyHint}
+
+// SHA256SPKIKeyHint returns the base64-encoded SHA-256 digest of the DER
+// encoded SubjectPublicKeyInfo of the given key. This is the hint that
+// sign.EphemeralKeypair uses by default.
+func SHA256SPKIKeyHint(pub crypto.PublicKey) (string, error) {
+	digest, err := spkiDigest(pub)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(digest), nil
+}
+
+// SHA256SPKIHexKeyHint returns the hex-encoded SHA-256 digest of the DER
+// encoded SubjectPublicKeyInfo of the given key, as used for Rekor log IDs.
+func SHA256SPKIHexKeyHint(pub crypto.PublicKey) (string, error) {
+	digest, err := spkiDigest(pub)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+// SubjectKeyIDKeyHint returns the hex-encoded SHA-1 digest of the given
+// key's subjectPublicKey bit string, the key identifier of RFC 5280 section
+// 4.2.1.2 that X.509 tooling records as the subject key identifier.
+func SubjectKeyIDKeyHint(pub crypto.PublicKey) (string, error) {
+	der, err := marshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if rest, err := asn1.Unmarshal(der, &spki); err != nil {
+		return "", err
+	} else if len(rest) > 0 {
+		return "", errors.New("trailing data after SubjectPublicKeyInfo")
+	}
+	digest := sha1.Sum(spki.PublicKey.Bytes)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+func spkiDigest(pub crypto.PublicKey) ([]byte, error) {
+	der, err := marshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(der)
+	return digest[:], nil
+}
+
+// marshalPKIXPublicKey is x509.MarshalPKIXPublicKey, extended to ML-DSA keys.
+func marshalPKIXPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	if pk, ok := pub.(*mldsa.PublicKey); ok {
+		return mldsa.MarshalPKIXPublicKey(pk)
+	}
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+// KeyHintMatches returns true if the hint identifies the given public key
+// under any of the DefaultKeyHintSchemes. Hex encoded hints are compared
+// case-insensitively.
+func KeyHintMatches(hint string, pub crypto.PublicKey) bool {
+	return KeyHintMatchesAny(hint, pub, DefaultKeyHintSchemes)
+}
+
+// KeyHintMatchesAny returns true if the hint identifies the given public key
+// under any of the given schemes. Hints that a scheme computes as hex are
+// compared case-insensitively.
+func KeyHintMatchesAny(hint string, pub crypto.PublicKey, schemes []KeyHintScheme) bool {
+	if hint == "" {
+		return false
+	}
+	for _, scheme := range schemes {
+		computed, err := scheme(pub)
+		if err != nil || computed == "" {
+			continue
+		}
+		if hint == computed || (isHex(computed) && strings.EqualFold(hint, computed)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(strings.ToLower(s))
+	return err == nil
+}
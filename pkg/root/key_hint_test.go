@@ -0,0 +1,112 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyHintMatches(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	b64Hint, err := SHA256SPKIKeyHint(key.Public())
+	assert.NoError(t, err)
+	hexHint, err := SHA256SPKIHexKeyHint(key.Public())
+	assert.NoError(t, err)
+
+	assert.True(t, KeyHintMatches(b64Hint, key.Public()))
+	assert.True(t, KeyHintMatches(hexHint, key.Public()))
+	assert.True(t, KeyHintMatches(strings.ToUpper(hexHint), key.Public()))
+	assert.False(t, KeyHintMatches(b64Hint, otherKey.Public()))
+	assert.False(t, KeyHintMatches("", key.Public()))
+}
+
+func TestSubjectKeyIDKeyHint(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	// Go derives the subject key identifier of CA certificates the same way
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	hint, err := SubjectKeyIDKeyHint(key.Public())
+	assert.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(cert.SubjectKeyId), hint)
+
+	// It is only matched when asked for
+	assert.False(t, KeyHintMatches(hint, key.Public()))
+	assert.True(t, KeyHintMatchesAny(strings.ToUpper(hint), key.Public(), []KeyHintScheme{SubjectKeyIDKeyHint}))
+	b64Hint, err := SHA256SPKIKeyHint(key.Public())
+	assert.NoError(t, err)
+	assert.False(t, KeyHintMatchesAny(b64Hint, key.Public(), []KeyHintScheme{SubjectKeyIDKeyHint}))
+	assert.False(t, KeyHintMatchesAny(strings.ToLower(b64Hint), key.Public(), DefaultKeyHintSchemes))
+}
+
+func TestTrustedPublicKeyMaterialFromMappingHintSchemes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	verifier, err := signature.LoadECDSAVerifier(&key.PublicKey, crypto.SHA256)
+	assert.NoError(t, err)
+	expiringKey := NewExpiringKey(verifier, time.Time{}, time.Time{})
+
+	tm := NewTrustedPublicKeyMaterialFromMapping(map[string]*ExpiringKey{"my-key": expiringKey})
+
+	v, err := tm.PublicKeyVerifier("my-key")
+	assert.NoError(t, err)
+	assert.Equal(t, expiringKey, v)
+
+	hexHint, err := SHA256SPKIHexKeyHint(key.Public())
+	assert.NoError(t, err)
+	v, err = tm.PublicKeyVerifier(hexHint)
+	assert.NoError(t, err)
+	assert.Equal(t, expiringKey, v)
+
+	_, err = tm.PublicKeyVerifier("unknown")
+	assert.Error(t, err)
+
+	skidHint, err := SubjectKeyIDKeyHint(key.Public())
+	assert.NoError(t, err)
+	_, err = tm.PublicKeyVerifier(skidHint)
+	assert.Error(t, err)
+	tm = NewTrustedPublicKeyMaterialFromMappingWithHintSchemes(map[string]*ExpiringKey{"my-key": expiringKey}, []KeyHintScheme{SubjectKeyIDKeyHint})
+	v, err = tm.PublicKeyVerifier(skidHint)
+	assert.NoError(t, err)
+	assert.Equal(t, expiringKey, v)
+}
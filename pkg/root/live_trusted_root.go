@@ -0,0 +1,240 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+// DefaultLiveTrustedRootRefreshInterval is how often a LiveTrustedRoot
+// refreshes the trusted root by default.
+const DefaultLiveTrustedRootRefreshInterval = 24 * time.Hour
+
+type LiveTrustedRootOptions struct {
+	// RefreshInterval is how often the trusted root is refreshed; defaults
+	// to DefaultLiveTrustedRootRefreshInterval
+	RefreshInterval time.Duration
+	// Jitter randomizes each refresh interval by up to this fraction of it
+	// in either direction (e.g. 0.1 for ±10%), so that a fleet of servers
+	// does not refresh at the same time; it must be less than 1
+	Jitter float64
+	// Logger receives refresh failures; if nil, they are not logged, but
+	// are still reported by Health
+	Logger *slog.Logger
+	// OnRefresh, if set, is called after each refresh attempt with the new
+	// trusted root, or the error that caused the attempt to fail
+	OnRefresh func(*TrustedRoot, error)
+}
+
+// LiveTrustedRootHealth reports the outcome of a LiveTrustedRoot's refresh
+// attempts.
+type LiveTrustedRootHealth struct {
+	// LastRefresh is when the trusted root was last fetched successfully,
+	// including when the LiveTrustedRoot was created
+	LastRefresh time.Time
+	// LastAttempt is when a refresh was last attempted
+	LastAttempt time.Time
+	// LastError is the error of the last refresh attempt, if it failed
+	LastError error
+	// ConsecutiveFailures is the number of refresh attempts that failed
+	// since the last successful refresh
+	ConsecutiveFailures int
+}
+
+// Stale returns true if the trusted root was last refreshed longer than
+// maxAge ago, for health checks that should fail once the trusted root may
+// be missing key rotations.
+func (h LiveTrustedRootHealth) Stale(now time.Time, maxAge time.Duration) bool {
+	return now.Sub(h.LastRefresh) > maxAge
+}
+
+// LiveTrustedRoot is a wrapper around TrustedRoot that periodically
+// refreshes the trusted root from TUF. This is needed for long-running
+// processes to ensure that the trusted root does not expire.
+//
+// The TrustedMaterial methods of a LiveTrustedRoot are safe for concurrent
+// use with refreshes; use Current to access the rest of the trusted root.
+type LiveTrustedRoot struct {
+	*TrustedRoot
+	mu sync.RWMutex
+
+	fetch  func() (*TrustedRoot, error)
+	opts   LiveTrustedRootOptions
+	health LiveTrustedRootHealth
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewLiveTrustedRoot returns a LiveTrustedRoot that will periodically
+// refresh the trusted root from TUF.
+func NewLiveTrustedRoot(opts *tuf.Options) (*LiveTrustedRoot, error) {
+	return NewLiveTrustedRootWithOptions(opts, LiveTrustedRootOptions{})
+}
+
+// NewLiveTrustedRootWithOptions returns a LiveTrustedRoot that refreshes
+// the trusted root from TUF in a background goroutine, as configured by
+// liveOpts, until Close is called. The initial fetch must succeed.
+func NewLiveTrustedRootWithOptions(opts *tuf.Options, liveOpts LiveTrustedRootOptions) (*LiveTrustedRoot, error) {
+	return newLiveTrustedRoot(func() (*TrustedRoot, error) {
+		client, err := tuf.New(opts)
+		if err != nil {
+			return nil, err
+		}
+		return GetTrustedRoot(client)
+	}, liveOpts)
+}
+
+func newLiveTrustedRoot(fetch func() (*TrustedRoot, error), opts LiveTrustedRootOptions) (*LiveTrustedRoot, error) {
+	if opts.Jitter < 0 || opts.Jitter >= 1 {
+		return nil, fmt.Errorf("refresh jitter must be in [0, 1), got %v", opts.Jitter)
+	}
+	tr, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = DefaultLiveTrustedRootRefreshInterval
+	}
+
+	now := time.Now()
+	ltr := &LiveTrustedRoot{
+		TrustedRoot: tr,
+		fetch:       fetch,
+		opts:        opts,
+		health:      LiveTrustedRootHealth{LastRefresh: now, LastAttempt: now},
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go ltr.run()
+	return ltr, nil
+}
+
+func (l *LiveTrustedRoot) run() {
+	defer close(l.done)
+	timer := time.NewTimer(l.nextInterval())
+	defer timer.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-timer.C:
+			_ = l.Refresh()
+			timer.Reset(l.nextInterval())
+		}
+	}
+}
+
+func (l *LiveTrustedRoot) nextInterval() time.Duration {
+	interval := l.opts.RefreshInterval
+	if l.opts.Jitter > 0 {
+		jitter := (rand.Float64()*2 - 1) * l.opts.Jitter * float64(interval) //nolint:gosec
+		interval += time.Duration(jitter)
+	}
+	return interval
+}
+
+// Refresh fetches the trusted root immediately. On failure, the current
+// trusted root is kept.
+func (l *LiveTrustedRoot) Refresh() error {
+	tr, err := l.fetch()
+
+	l.mu.Lock()
+	l.health.LastAttempt = time.Now()
+	if err != nil {
+		l.health.LastError = err
+		l.health.ConsecutiveFailures++
+	} else {
+		l.TrustedRoot = tr
+		l.health.LastRefresh = l.health.LastAttempt
+		l.health.LastError = nil
+		l.health.ConsecutiveFailures = 0
+	}
+	failures := l.health.ConsecutiveFailures
+	l.mu.Unlock()
+
+	if err != nil {
+		util.Logger(l.opts.Logger).Warn("failed to refresh trusted root", "error", err, "consecutiveFailures", failures)
+	}
+	if l.opts.OnRefresh != nil {
+		l.opts.OnRefresh(tr, err)
+	}
+	return err
+}
+
+// Current returns the most recently fetched trusted root. The returned
+// trusted root is not modified by later refreshes.
+func (l *LiveTrustedRoot) Current() *TrustedRoot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot
+}
+
+// Health reports the outcome of refresh attempts.
+func (l *LiveTrustedRoot) Health() LiveTrustedRootHealth {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.health
+}
+
+// Close stops refreshing the trusted root, waiting for a refresh in
+// progress to finish or ctx to be done. The last fetched trusted root
+// remains usable.
+func (l *LiveTrustedRoot) Close(ctx context.Context) error {
+	l.once.Do(func() { close(l.stop) })
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *LiveTrustedRoot) TimestampingAuthorities() []CertificateAuthority {
+	return l.Current().TimestampingAuthorities()
+}
+
+func (l *LiveTrustedRoot) FulcioCertificateAuthorities() []CertificateAuthority {
+	return l.Current().FulcioCertificateAuthorities()
+}
+
+func (l *LiveTrustedRoot) RekorLogs() map[string]*TransparencyLog {
+	return l.Current().RekorLogs()
+}
+
+func (l *LiveTrustedRoot) CTLogs() map[string]*TransparencyLog {
+	return l.Current().CTLogs()
+}
+
+func (l *LiveTrustedRoot) ListActiveCTLogs(at time.Time) []*TransparencyLog {
+	return l.Current().ListActiveCTLogs(at)
+}
+
+func (l *LiveTrustedRoot) PublicKeyVerifier(keyID string) (TimeConstrainedVerifier, error) {
+	return l.Current().PublicKeyVerifier(keyID)
+}
+
+// MarshalJSON returns the JSON encoding of the current trusted root.
+func (l *LiveTrustedRoot) MarshalJSON() ([]byte, error) {
+	return l.Current().MarshalJSON()
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveTrustedRoot(t *testing.T) {
+	var mu sync.Mutex
+	var fetchErr error
+	fetches := 0
+	fetch := func() (*TrustedRoot, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return NewTrustedRootFromPath("../../examples/trusted-root-public-good.json")
+	}
+
+	refreshed := make(chan error, 100)
+	ltr, err := newLiveTrustedRoot(fetch, LiveTrustedRootOptions{
+		RefreshInterval: 10 * time.Millisecond,
+		Jitter:          0.5,
+		OnRefresh:       func(_ *TrustedRoot, err error) { refreshed <- err },
+	})
+	require.NoError(t, err)
+	initial := ltr.Current()
+	assert.NotEmpty(t, ltr.RekorLogs())
+
+	// Background refreshes replace the trusted root
+	require.NoError(t, <-refreshed)
+	assert.NotSame(t, initial, ltr.Current())
+	assert.Zero(t, ltr.Health().ConsecutiveFailures)
+
+	// Failed refreshes keep the last trusted root and are reported
+	mu.Lock()
+	fetchErr = errors.New("repository unavailable")
+	mu.Unlock()
+	require.Error(t, <-refreshed)
+	require.Error(t, <-refreshed)
+	health := ltr.Health()
+	assert.GreaterOrEqual(t, health.ConsecutiveFailures, 2)
+	assert.ErrorContains(t, health.LastError, "repository unavailable")
+	assert.True(t, health.LastAttempt.After(health.LastRefresh))
+	assert.True(t, health.Stale(health.LastRefresh.Add(time.Hour), time.Minute))
+	assert.NotNil(t, ltr.Current())
+	assert.NotEmpty(t, ltr.FulcioCertificateAuthorities())
+
+	mu.Lock()
+	fetchErr = nil
+	mu.Unlock()
+	require.NoError(t, ltr.Refresh())
+	assert.Zero(t, ltr.Health().ConsecutiveFailures)
+
+	// Close stops refreshing
+	require.NoError(t, ltr.Close(context.Background()))
+	require.NoError(t, ltr.Close(context.Background()))
+	mu.Lock()
+	stopped := fetches
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, stopped, fetches)
+	mu.Unlock()
+}
+
+func TestLiveTrustedRootOptions(t *testing.T) {
+	fetch := func() (*TrustedRoot, error) {
+		return NewTrustedRootFromPath("../../examples/trusted-root-public-good.json")
+	}
+
+	_, err := newLiveTrustedRoot(fetch, LiveTrustedRootOptions{Jitter: 1})
+	assert.Error(t, err)
+
+	_, err = newLiveTrustedRoot(func() (*TrustedRoot, error) { return nil, errors.New("offline") }, LiveTrustedRootOptions{})
+	assert.ErrorContains(t, err, "offline")
+
+	ltr, err := newLiveTrustedRoot(fetch, LiveTrustedRootOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLiveTrustedRootRefreshInterval, ltr.opts.RefreshInterval)
+	require.NoError(t, ltr.Close(context.Background()))
+}
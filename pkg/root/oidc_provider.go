@@ -0,0 +1,125 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultOIDCAudience is the audience Fulcio expects identity tokens to be
+// issued for, used for providers with no configured audiences.
+const DefaultOIDCAudience = "sigstore"
+
+// OIDCProvider is an OpenID Connect identity provider whose tokens are
+// accepted by a Fulcio instance. Fulcio records the provider's issuer URL in
+// the issuer extension of the certificates it issues.
+type OIDCProvider struct {
+	// Issuer is the issuer URL of the provider
+	Issuer string
+	// Audiences are the audiences tokens must be issued for
+	Audiences           []string
+	ValidityPeriodStart time.Time
+	ValidityPeriodEnd   time.Time
+}
+
+// ValidAtTime returns true if the provider was trusted at the given time.
+// An unset validity period end means the provider is still trusted.
+func (p OIDCProvider) ValidAtTime(t time.Time) bool {
+	if !p.ValidityPeriodStart.IsZero() && t.Before(p.ValidityPeriodStart) {
+		return false
+	}
+	if !p.ValidityPeriodEnd.IsZero() && t.After(p.ValidityPeriodEnd) {
+		return false
+	}
+	return true
+}
+
+// MatchesIssuer returns true if issuer, as recorded in a certificate's issuer
+// extension, is the provider's issuer URL. A trailing slash is ignored.
+func (p OIDCProvider) MatchesIssuer(issuer string) bool {
+	return issuer != "" && strings.TrimSuffix(issuer, "/") == strings.TrimSuffix(p.Issuer, "/")
+}
+
+// OIDCProviderMaterial is implemented by trusted material that lists the
+// OIDC providers trusted to issue identities.
+type OIDCProviderMaterial interface {
+	OIDCProviders() []OIDCProvider
+}
+
+// OIDCProviders returns the OIDC providers of the trusted material, or nil
+// if it does not implement OIDCProviderMaterial.
+func OIDCProviders(tm TrustedMaterial) []OIDCProvider {
+	if providers, ok := tm.(OIDCProviderMaterial); ok {
+		return providers.OIDCProviders()
+	}
+	return nil
+}
+
+// OIDCProvidersFromSigningConfig returns the OIDC providers listed in a
+// signing config. The signing config does not list audiences, so each
+// provider accepts the given audiences, or DefaultOIDCAudience if none are
+// given.
+func OIDCProvidersFromSigningConfig(sc *SigningConfig, audiences ...string) []OIDCProvider {
+	if len(audiences) == 0 {
+		audiences = []string{DefaultOIDCAudience}
+	}
+	var providers []OIDCProvider
+	for _, service := range sc.OIDCProviderURLs() {
+		providers = append(providers, OIDCProvider{
+			Issuer:              service.URL,
+			Audiences:           append([]string{}, audiences...),
+			ValidityPeriodStart: service.ValidityPeriodStart,
+			ValidityPeriodEnd:   service.ValidityPeriodEnd,
+		})
+	}
+	return providers
+}
+
+// TrustedMaterialWithOIDCProviders adds OIDC providers to trusted material
+// that does not list them, such as a TrustedRoot.
+type TrustedMaterialWithOIDCProviders struct {
+	TrustedMaterial
+	providers []OIDCProvider
+}
+
+var _ OIDCProviderMaterial = &TrustedMaterialWithOIDCProviders{}
+
+// WithOIDCProviders returns the trusted material with the given OIDC
+// providers, for example those returned by OIDCProvidersFromSigningConfig.
+func WithOIDCProviders(tm TrustedMaterial, providers ...OIDCProvider) *TrustedMaterialWithOIDCProviders {
+	return &TrustedMaterialWithOIDCProviders{TrustedMaterial: tm, providers: providers}
+}
+
+// Unwrap returns the trusted material the OIDC providers were added to.
+func (tm *TrustedMaterialWithOIDCProviders) Unwrap() TrustedMaterial {
+	return tm.TrustedMaterial
+}
+
+func (tm *TrustedMaterialWithOIDCProviders) OIDCProviders() []OIDCProvider {
+	return append(OIDCProviders(tm.TrustedMaterial), tm.providers...)
+}
+
+func (tmc TrustedMaterialCollection) OIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, tm := range tmc {
+		providers = append(providers, OIDCProviders(tm)...)
+	}
+	return providers
+}
+
+func (n NamedTrustedMaterial) OIDCProviders() []OIDCProvider {
+	return OIDCProviders(n.TrustedMaterial)
+}
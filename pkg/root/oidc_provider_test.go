@@ -0,0 +1,62 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCProvidersFromSigningConfig(t *testing.T) {
+	sc, err := NewSigningConfigFromJSON([]byte(signingConfigV02JSON))
+	assert.NoError(t, err)
+
+	providers := OIDCProvidersFromSigningConfig(sc)
+	assert.Equal(t, []OIDCProvider{{
+		Issuer:              "https://oauth2.example.com/auth",
+		Audiences:           []string{DefaultOIDCAudience},
+		ValidityPeriodStart: time.Date(2025, 4, 16, 0, 0, 0, 0, time.UTC),
+	}}, providers)
+
+	providers = OIDCProvidersFromSigningConfig(sc, "my-audience")
+	assert.Equal(t, []string{"my-audience"}, providers[0].Audiences)
+
+	provider := providers[0]
+	assert.True(t, provider.MatchesIssuer("https://oauth2.example.com/auth"))
+	assert.True(t, provider.MatchesIssuer("https://oauth2.example.com/auth/"))
+	assert.False(t, provider.MatchesIssuer("https://oauth2.example.com"))
+	assert.False(t, provider.MatchesIssuer(""))
+	assert.False(t, provider.ValidAtTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, provider.ValidAtTime(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestTrustedMaterialOIDCProviders(t *testing.T) {
+	a := OIDCProvider{Issuer: "https://a.example.com"}
+	b := OIDCProvider{Issuer: "https://b.example.com"}
+
+	assert.Nil(t, OIDCProviders(&BaseTrustedMaterial{}))
+
+	withA := WithOIDCProviders(&BaseTrustedMaterial{}, a)
+	assert.Equal(t, []OIDCProvider{a}, OIDCProviders(withA))
+	assert.Equal(t, []OIDCProvider{a, b}, OIDCProviders(WithOIDCProviders(withA, b)))
+
+	collection := TrustedMaterialCollection{withA, &BaseTrustedMaterial{}, WithOIDCProviders(&BaseTrustedMaterial{}, b)}
+	assert.Equal(t, []OIDCProvider{a, b}, OIDCProviders(collection))
+
+	named := NamedTrustedMaterial{Name: "a", TrustedMaterial: withA}
+	assert.Equal(t, []OIDCProvider{a}, OIDCProviders(named))
+}
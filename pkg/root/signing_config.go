@@ -0,0 +1,427 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// SigningConfigMediaType02 is the media type of signing configs in the v0.2
+// format, which adds service validity periods and selection configuration.
+const SigningConfigMediaType02 = "application/vnd.dev.sigstore.signingconfig.v0.2+json"
+
+// ErrNoValidService is returned when no configured service, or not enough
+// of them, can be selected.
+var ErrNoValidService = errors.New("no valid service found")
+
+// ServiceSelector determines how many of the configured services of a type
+// a client uses.
+type ServiceSelector int
+
+const (
+	// ServiceSelectorUndefined is treated as ServiceSelectorAny.
+	ServiceSelectorUndefined ServiceSelector = iota
+	// ServiceSelectorAll uses one service from every operator.
+	ServiceSelectorAll
+	// ServiceSelectorAny uses a single service.
+	ServiceSelectorAny
+	// ServiceSelectorExact uses services from exactly Count operators.
+	ServiceSelectorExact
+)
+
+func (s ServiceSelector) String() string {
+	switch s {
+	case ServiceSelectorAll:
+		return "ALL"
+	case ServiceSelectorAny:
+		return "ANY"
+	case ServiceSelectorExact:
+		return "EXACT"
+	default:
+		return "SERVICE_SELECTOR_UNDEFINED"
+	}
+}
+
+func (s *ServiceSelector) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("invalid service selector: %w", err)
+	}
+	switch name {
+	case "ALL":
+		*s = ServiceSelectorAll
+	case "ANY":
+		*s = ServiceSelectorAny
+	case "EXACT":
+		*s = ServiceSelectorExact
+	case "", "SERVICE_SELECTOR_UNDEFINED":
+		*s = ServiceSelectorUndefined
+	default:
+		return fmt.Errorf("unsupported service selector: %s", name)
+	}
+	return nil
+}
+
+// ServiceConfiguration describes how clients select from a list of services.
+type ServiceConfiguration struct {
+	Selector ServiceSelector `json:"selector"`
+	// Number of services to select, only used with ServiceSelectorExact
+	Count uint32 `json:"count,omitempty"`
+}
+
+// Service is a Sigstore service endpoint listed in a signing config.
+type Service struct {
+	URL                 string
+	MajorAPIVersion     uint32
+	ValidityPeriodStart time.Time
+	ValidityPeriodEnd   time.Time
+	// Operator identifies who runs the service. Services with the same
+	// operator are not independent of each other, so at most one is
+	// selected per operator.
+	Operator string
+}
+
+// ValidAtTime returns true if the service was valid at the given time. An
+// unset validity period end means the service is still active.
+func (s Service) ValidAtTime(t time.Time) bool {
+	if !s.ValidityPeriodStart.IsZero() && t.Before(s.ValidityPeriodStart) {
+		return false
+	}
+	if !s.ValidityPeriodEnd.IsZero() && t.After(s.ValidityPeriodEnd) {
+		return false
+	}
+	return true
+}
+
+// SigningConfig holds the service endpoints a Sigstore client needs to
+// sign, and how many of each service type to use.
+type SigningConfig struct {
+	fulcioCertificateAuthorities []Service
+	oidcProviders                []Service
+	rekorLogs                    []Service
+	rekorLogsConfig              ServiceConfiguration
+	timestampAuthorities         []Service
+	timestampAuthoritiesConfig   ServiceConfiguration
+}
+
+// FulcioCertificateAuthorityURLs returns the Fulcio certificate authorities
+// to request signing certificates from.
+func (sc *SigningConfig) FulcioCertificateAuthorityURLs() []Service {
+	return sc.fulcioCertificateAuthorities
+}
+
+// OIDCProviderURLs returns the OIDC providers to obtain identity tokens from.
+func (sc *SigningConfig) OIDCProviderURLs() []Service {
+	return sc.oidcProviders
+}
+
+// RekorLogURLs returns the Rekor transparency logs to upload entries to.
+func (sc *SigningConfig) RekorLogURLs() []Service {
+	return sc.rekorLogs
+}
+
+// RekorLogURLsConfig returns how many of the Rekor logs to use.
+func (sc *SigningConfig) RekorLogURLsConfig() ServiceConfiguration {
+	return sc.rekorLogsConfig
+}
+
+// TimestampAuthorityURLs returns the timestamp authorities to request
+// signed timestamps from.
+func (sc *SigningConfig) TimestampAuthorityURLs() []Service {
+	return sc.timestampAuthorities
+}
+
+// TimestampAuthorityURLsConfig returns how many of the timestamp
+// authorities to use.
+func (sc *SigningConfig) TimestampAuthorityURLsConfig() ServiceConfiguration {
+	return sc.timestampAuthoritiesConfig
+}
+
+type serviceJSON struct {
+	URL             string `json:"url"`
+	MajorAPIVersion uint32 `json:"majorApiVersion"`
+	ValidFor        *struct {
+		Start *time.Time `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"validFor"`
+	Operator string `json:"operator"`
+}
+
+type signingConfigJSON struct {
+	MediaType       string                `json:"mediaType"`
+	CAURLs          []serviceJSON         `json:"caUrls"`
+	OIDCURLs        []serviceJSON         `json:"oidcUrls"`
+	RekorTlogURLs   []serviceJSON         `json:"rekorTlogUrls"`
+	RekorTlogConfig *ServiceConfiguration `json:"rekorTlogConfig"`
+	TSAURLs         []serviceJSON         `json:"tsaUrls"`
+	TSAConfig       *ServiceConfiguration `json:"tsaConfig"`
+}
+
+// NewSigningConfigFromJSON parses a signing config. Both the v0.2 format,
+// with service validity periods and selection configuration, and the
+// earlier format listing only service URLs are supported.
+//
+// Services from a config in the earlier format are assumed to be at major
+// API version 1, to be run by distinct operators, and to all be used.
+func NewSigningConfigFromJSON(data []byte) (*SigningConfig, error) {
+	var header struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.MediaType {
+	case SigningConfigMediaType02:
+		return parseSigningConfigV02(data)
+	case "":
+		pbSigningConfig := &prototrustroot.SigningConfig{}
+		if err := protojson.Unmarshal(data, pbSigningConfig); err != nil {
+			return nil, err
+		}
+		return NewSigningConfigFromProtobuf(pbSigningConfig)
+	default:
+		return nil, fmt.Errorf("unsupported SigningConfig media type: %s", header.MediaType)
+	}
+}
+
+// NewSigningConfigFromPath reads and parses a signing config file.
+func NewSigningConfigFromPath(path string) (*SigningConfig, error) {
+	signingConfigJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigningConfigFromJSON(signingConfigJSON)
+}
+
+// NewSigningConfigFromProtobuf converts a signing config in the earlier
+// format, which lists only service URLs.
+func NewSigningConfigFromProtobuf(pbSigningConfig *prototrustroot.SigningConfig) (*SigningConfig, error) {
+	if pbSigningConfig == nil {
+		return nil, errors.New("SigningConfig is nil")
+	}
+
+	legacyService := func(url string) Service {
+		return Service{URL: url, MajorAPIVersion: 1, Operator: url}
+	}
+
+	sc := &SigningConfig{
+		rekorLogsConfig:            ServiceConfiguration{Selector: ServiceSelectorAll},
+		timestampAuthoritiesConfig: ServiceConfiguration{Selector: ServiceSelectorAll},
+	}
+	if url := pbSigningConfig.GetCaUrl(); url != "" {
+		sc.fulcioCertificateAuthorities = []Service{legacyService(url)}
+	}
+	if url := pbSigningConfig.GetOidcUrl(); url != "" {
+		sc.oidcProviders = []Service{legacyService(url)}
+	}
+	for _, url := range pbSigningConfig.GetTlogUrls() {
+		sc.rekorLogs = append(sc.rekorLogs, legacyService(url))
+	}
+	for _, url := range pbSigningConfig.GetTsaUrls() {
+		sc.timestampAuthorities = append(sc.timestampAuthorities, legacyService(url))
+	}
+	return sc, nil
+}
+
+func parseSigningConfigV02(data []byte) (*SigningConfig, error) {
+	var raw signingConfigJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	sc := &SigningConfig{}
+	var err error
+	if sc.fulcioCertificateAuthorities, err = parseServices("caUrls", raw.CAURLs); err != nil {
+		return nil, err
+	}
+	if sc.oidcProviders, err = parseServices("oidcUrls", raw.OIDCURLs); err != nil {
+		return nil, err
+	}
+	if sc.rekorLogs, err = parseServices("rekorTlogUrls", raw.RekorTlogURLs); err != nil {
+		return nil, err
+	}
+	if sc.timestampAuthorities, err = parseServices("tsaUrls", raw.TSAURLs); err != nil {
+		return nil, err
+	}
+
+	if raw.RekorTlogConfig != nil {
+		sc.rekorLogsConfig = *raw.RekorTlogConfig
+	}
+	if raw.TSAConfig != nil {
+		sc.timestampAuthoritiesConfig = *raw.TSAConfig
+	}
+	if sc.rekorLogsConfig.Selector == ServiceSelectorExact && sc.rekorLogsConfig.Count == 0 {
+		return nil, errors.New("rekorTlogConfig: EXACT selector requires a count")
+	}
+	if sc.timestampAuthoritiesConfig.Selector == ServiceSelectorExact && sc.timestampAuthoritiesConfig.Count == 0 {
+		return nil, errors.New("tsaConfig: EXACT selector requires a count")
+	}
+
+	return sc, nil
+}
+
+func parseServices(field string, rawServices []serviceJSON) ([]Service, error) {
+	services := make([]Service, 0, len(rawServices))
+	for i, raw := range rawServices {
+		if raw.URL == "" {
+			return nil, fmt.Errorf("%s[%d]: missing url", field, i)
+		}
+		if raw.MajorAPIVersion == 0 {
+			return nil, fmt.Errorf("%s[%d]: missing majorApiVersion", field, i)
+		}
+		if raw.ValidFor == nil || raw.ValidFor.Start == nil {
+			return nil, fmt.Errorf("%s[%d]: missing validity period start", field, i)
+		}
+		service := Service{
+			URL:                 raw.URL,
+			MajorAPIVersion:     raw.MajorAPIVersion,
+			ValidityPeriodStart: *raw.ValidFor.Start,
+			Operator:            raw.Operator,
+		}
+		if raw.ValidFor.End != nil {
+			service.ValidityPeriodEnd = *raw.ValidFor.End
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// SelectService returns the service valid at the given time with the
+// highest supported major API version, preferring the most recently started
+// service among equals. It is used for services of which a client only ever
+// needs one, such as a certificate authority.
+func SelectService(services []Service, supportedAPIVersions []uint32, currentTime time.Time) (Service, error) {
+	candidates := validServices(services, supportedAPIVersions, currentTime)
+	if len(candidates) == 0 {
+		return Service{}, fmt.Errorf("%w at %s for API versions %v", ErrNoValidService, currentTime.UTC().Format(time.RFC3339), supportedAPIVersions)
+	}
+	return candidates[0], nil
+}
+
+// SelectServices returns the services to use according to the service
+// configuration: one valid service per operator, picked as by SelectService,
+// for either every operator (ALL) or exactly config.Count operators (EXACT),
+// with operators taken in the order their services are listed. ANY selects
+// the single service SelectService would.
+//
+// An error is returned if the configuration cannot be satisfied. No
+// services and no error are returned if none are configured.
+func SelectServices(services []Service, config ServiceConfiguration, supportedAPIVersions []uint32, currentTime time.Time) ([]Service, error) {
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	candidates := validServices(services, supportedAPIVersions, currentTime)
+
+	// Keep the best service of each operator, in listing order
+	operatorOrder := make(map[string]int)
+	for i, service := range services {
+		if _, ok := operatorOrder[service.Operator]; !ok {
+			operatorOrder[service.Operator] = i
+		}
+	}
+	seen := make(map[string]bool)
+	var selected []Service
+	for _, service := range candidates {
+		if seen[service.Operator] {
+			continue
+		}
+		seen[service.Operator] = true
+		selected = append(selected, service)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return operatorOrder[selected[i].Operator] < operatorOrder[selected[j].Operator]
+	})
+
+	switch config.Selector {
+	case ServiceSelectorAll:
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("%w at %s for API versions %v", ErrNoValidService, currentTime.UTC().Format(time.RFC3339), supportedAPIVersions)
+		}
+		return selected, nil
+	case ServiceSelectorExact:
+		if uint32(len(selected)) < config.Count {
+			return nil, fmt.Errorf("%w: need %d services from distinct operators, found %d", ErrNoValidService, config.Count, len(selected))
+		}
+		return selected[:config.Count], nil
+	case ServiceSelectorAny, ServiceSelectorUndefined:
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%w at %s for API versions %v", ErrNoValidService, currentTime.UTC().Format(time.RFC3339), supportedAPIVersions)
+		}
+		return candidates[:1], nil
+	default:
+		return nil, fmt.Errorf("unsupported service selector: %s", config.Selector)
+	}
+}
+
+// NegotiateServices selects services as SelectServices does, but only from
+// services of a single major API version: the highest supported version for
+// which the service configuration can be satisfied. It returns the selected
+// services and their API version, so clients can move to a new API version
+// as soon as the signing config lists enough services for it.
+//
+// No services, no version and no error are returned if none are configured.
+func NegotiateServices(services []Service, config ServiceConfiguration, supportedAPIVersions []uint32, currentTime time.Time) ([]Service, uint32, error) {
+	if len(services) == 0 {
+		return nil, 0, nil
+	}
+	versions := slices.Clone(supportedAPIVersions)
+	slices.Sort(versions)
+	slices.Reverse(versions)
+	err := fmt.Errorf("%w: no supported API versions", ErrNoValidService)
+	for _, version := range slices.Compact(versions) {
+		var selected []Service
+		selected, err = SelectServices(services, config, []uint32{version}, currentTime)
+		if err == nil {
+			return selected, version, nil
+		}
+	}
+	return nil, 0, err
+}
+
+// validServices returns the services valid at the given time with a
+// supported API version, best first.
+func validServices(services []Service, supportedAPIVersions []uint32, currentTime time.Time) []Service {
+	var candidates []Service
+	for _, service := range services {
+		if !service.ValidAtTime(currentTime) {
+			continue
+		}
+		for _, version := range supportedAPIVersions {
+			if service.MajorAPIVersion == version {
+				candidates = append(candidates, service)
+				break
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].MajorAPIVersion != candidates[j].MajorAPIVersion {
+			return candidates[i].MajorAPIVersion > candidates[j].MajorAPIVersion
+		}
+		return candidates[i].ValidityPeriodStart.After(candidates[j].ValidityPeriodStart)
+	})
+	return candidates
+}
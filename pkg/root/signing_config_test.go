@@ -0,0 +1,159 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const signingConfigV02JSON = `{
+  "mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json",
+  "caUrls": [
+    {"url": "https://fulcio.example.com", "majorApiVersion": 1, "validFor": {"start": "2023-04-14T21:38:40Z"}, "operator": "example.com"}
+  ],
+  "oidcUrls": [
+    {"url": "https://oauth2.example.com/auth", "majorApiVersion": 1, "validFor": {"start": "2025-04-16T00:00:00Z"}, "operator": "example.com"}
+  ],
+  "rekorTlogUrls": [
+    {"url": "https://rekor.example.com", "majorApiVersion": 1, "validFor": {"start": "2021-01-12T11:53:27Z", "end": "2024-01-01T00:00:00Z"}, "operator": "example.com"},
+    {"url": "https://rekor2.example.com", "majorApiVersion": 1, "validFor": {"start": "2024-01-01T00:00:00Z"}, "operator": "example.com"},
+    {"url": "https://rekor.other.com", "majorApiVersion": 1, "validFor": {"start": "2021-01-12T11:53:27Z"}, "operator": "other.com"},
+    {"url": "https://rekor.future.com", "majorApiVersion": 2, "validFor": {"start": "2021-01-12T11:53:27Z"}, "operator": "future.com"}
+  ],
+  "rekorTlogConfig": {"selector": "ALL"},
+  "tsaUrls": [
+    {"url": "https://tsa.example.com/api/v1/timestamp", "majorApiVersion": 1, "validFor": {"start": "2025-04-09T00:00:00Z"}, "operator": "example.com"},
+    {"url": "https://tsa.other.com/api/v1/timestamp", "majorApiVersion": 1, "validFor": {"start": "2025-04-09T00:00:00Z"}, "operator": "other.com"}
+  ],
+  "tsaConfig": {"selector": "EXACT", "count": 1}
+}`
+
+func TestNewSigningConfigFromJSON(t *testing.T) {
+	sc, err := NewSigningConfigFromJSON([]byte(signingConfigV02JSON))
+	assert.NoError(t, err)
+	assert.Len(t, sc.FulcioCertificateAuthorityURLs(), 1)
+	assert.Len(t, sc.OIDCProviderURLs(), 1)
+	assert.Len(t, sc.RekorLogURLs(), 4)
+	assert.Equal(t, ServiceConfiguration{Selector: ServiceSelectorAll}, sc.RekorLogURLsConfig())
+	assert.Equal(t, ServiceConfiguration{Selector: ServiceSelectorExact, Count: 1}, sc.TimestampAuthorityURLsConfig())
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), sc.RekorLogURLs()[0].ValidityPeriodEnd)
+
+	legacy, err := NewSigningConfigFromJSON([]byte(`{"caUrl": "https://fulcio.example.com", "tlogUrls": ["https://rekor.example.com"], "tsaUrls": ["https://tsa.example.com"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Service{{URL: "https://fulcio.example.com", MajorAPIVersion: 1, Operator: "https://fulcio.example.com"}}, legacy.FulcioCertificateAuthorityURLs())
+	assert.Len(t, legacy.RekorLogURLs(), 1)
+	assert.Equal(t, ServiceSelectorAll, legacy.RekorLogURLsConfig().Selector)
+
+	_, err = NewSigningConfigFromJSON([]byte(`{"mediaType": "application/vnd.dev.sigstore.signingconfig.v0.3+json"}`))
+	assert.ErrorContains(t, err, "unsupported SigningConfig media type")
+
+	_, err = NewSigningConfigFromJSON([]byte(`{"mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json", "tsaConfig": {"selector": "EXACT"}}`))
+	assert.ErrorContains(t, err, "EXACT selector requires a count")
+
+	_, err = NewSigningConfigFromJSON([]byte(`{"mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json", "caUrls": [{"url": "https://fulcio.example.com", "majorApiVersion": 1}]}`))
+	assert.ErrorContains(t, err, "caUrls[0]: missing validity period start")
+
+	_, err = NewSigningConfigFromJSON([]byte(`{"mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json", "rekorTlogConfig": {"selector": "SOME"}}`))
+	assert.ErrorContains(t, err, "unsupported service selector")
+}
+
+func TestSelectServices(t *testing.T) {
+	sc, err := NewSigningConfigFromJSON([]byte(signingConfigV02JSON))
+	assert.NoError(t, err)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	urls := func(services []Service) []string {
+		var out []string
+		for _, s := range services {
+			out = append(out, s.URL)
+		}
+		return out
+	}
+
+	// One service per operator, skipping expired and unsupported services
+	rekors, err := SelectServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAll}, []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://rekor2.example.com", "https://rekor.other.com"}, urls(rekors))
+
+	rekors, err = SelectServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAny}, []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://rekor2.example.com"}, urls(rekors))
+
+	rekors, err = SelectServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorExact, Count: 2}, []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Len(t, rekors, 2)
+
+	_, err = SelectServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorExact, Count: 3}, []uint32{1}, now)
+	assert.True(t, errors.Is(err, ErrNoValidService))
+
+	// Higher supported API versions are preferred
+	rekors, err = SelectServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAny}, []uint32{1, 2}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://rekor.future.com"}, urls(rekors))
+
+	// Before the TSAs became valid
+	_, err = SelectServices(sc.TimestampAuthorityURLs(), sc.TimestampAuthorityURLsConfig(), []uint32{1}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, errors.Is(err, ErrNoValidService))
+
+	services, err := SelectServices(nil, ServiceConfiguration{Selector: ServiceSelectorAll}, []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+
+	fulcio, err := SelectService(sc.FulcioCertificateAuthorityURLs(), []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://fulcio.example.com", fulcio.URL)
+
+	_, err = SelectService(sc.FulcioCertificateAuthorityURLs(), []uint32{2}, now)
+	assert.True(t, errors.Is(err, ErrNoValidService))
+}
+
+func TestNegotiateServices(t *testing.T) {
+	sc, err := NewSigningConfigFromJSON([]byte(signingConfigV02JSON))
+	assert.NoError(t, err)
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// The highest supported version is used for all selected services
+	rekors, version, err := NegotiateServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAll}, []uint32{1, 2}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), version)
+	assert.Len(t, rekors, 1)
+	assert.Equal(t, "https://rekor.future.com", rekors[0].URL)
+
+	// Only version 1 has services from two operators
+	rekors, version, err = NegotiateServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorExact, Count: 2}, []uint32{2, 1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), version)
+	assert.Len(t, rekors, 2)
+
+	rekors, version, err = NegotiateServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAll}, []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), version)
+	assert.Len(t, rekors, 2)
+
+	_, _, err = NegotiateServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAll}, []uint32{3}, now)
+	assert.True(t, errors.Is(err, ErrNoValidService))
+
+	_, _, err = NegotiateServices(sc.RekorLogURLs(), ServiceConfiguration{Selector: ServiceSelectorAll}, nil, now)
+	assert.True(t, errors.Is(err, ErrNoValidService))
+
+	services, version, err := NegotiateServices(nil, ServiceConfiguration{Selector: ServiceSelectorAll}, []uint32{1}, now)
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+	assert.Zero(t, version)
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrTransparencyLogNotFound = errors.New("transparency log not found in trusted material")
+var ErrTransparencyLogNotValid = errors.New("transparency log not valid at time")
+
+// ValidAtTime returns true if the log's key was valid at the given time. An
+// unset validity period end means the log is still active.
+func (l *TransparencyLog) ValidAtTime(t time.Time) bool {
+	if !l.ValidityPeriodStart.IsZero() && t.Before(l.ValidityPeriodStart) {
+		return false
+	}
+	if !l.ValidityPeriodEnd.IsZero() && t.After(l.ValidityPeriodEnd) {
+		return false
+	}
+	return true
+}
+
+// OpenEnded returns true if the log's validity period has no end, meaning
+// the log is still accepting entries.
+func (l *TransparencyLog) OpenEnded() bool {
+	return l.ValidityPeriodEnd.IsZero()
+}
+
+// Shards returns every log instance in the trusted material that shares this
+// log's ID, ordered by validity period start. Log shards share an ID when
+// they are signed with the same key, as Rekor shards of the same deployment
+// may be. A log without other shards returns only itself.
+func (l *TransparencyLog) Shards() []*TransparencyLog {
+	if len(l.shards) == 0 {
+		return []*TransparencyLog{l}
+	}
+	return l.shards
+}
+
+// FindTransparencyLog returns the log shard with the given hex-encoded log
+// ID that was valid at the given time, such as a log entry's integrated
+// time. If t is zero, the log shard most recently made valid is returned.
+//
+// The returned error names the log and its shards when no shard was valid at
+// the given time.
+func FindTransparencyLog(logs map[string]*TransparencyLog, logID string, t time.Time) (*TransparencyLog, error) {
+	tlog, ok := logs[logID]
+	if !ok {
+		return nil, fmt.Errorf("%w: no log with ID %s", ErrTransparencyLogNotFound, logID)
+	}
+	if t.IsZero() {
+		return tlog, nil
+	}
+
+	shards := tlog.Shards()
+	descriptions := make([]string, 0, len(shards))
+	// Prefer the most recent shard when validity periods overlap
+	for i := len(shards) - 1; i >= 0; i-- {
+		if shards[i].ValidAtTime(t) {
+			return shards[i], nil
+		}
+	}
+	for _, shard := range shards {
+		descriptions = append(descriptions, describeShard(shard))
+	}
+	return nil, fmt.Errorf("%w: log ID %s at %s; known shards: %s", ErrTransparencyLogNotValid, logID, t.UTC().Format(time.RFC3339), strings.Join(descriptions, "; "))
+}
+
+// ActiveTransparencyLogs returns the log shards among logs that were valid
+// at the given time, including shards whose validity period has no end, and
+// excluding shards whose validity period starts after it. Shards are ordered
+// by base URL and validity period start.
+func ActiveTransparencyLogs(logs map[string]*TransparencyLog, at time.Time) []*TransparencyLog {
+	var active []*TransparencyLog
+	for _, tlog := range logs {
+		for _, shard := range tlog.Shards() {
+			if shard.ValidAtTime(at) {
+				active = append(active, shard)
+			}
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if active[i].BaseURL != active[j].BaseURL {
+			return active[i].BaseURL < active[j].BaseURL
+		}
+		return active[i].ValidityPeriodStart.Before(active[j].ValidityPeriodStart)
+	})
+	return active
+}
+
+// ListActiveCTLogs returns the certificate transparency log shards that were
+// valid at the given time, such as the time an SCT was issued.
+func (tr *TrustedRoot) ListActiveCTLogs(at time.Time) []*TransparencyLog {
+	return ActiveTransparencyLogs(tr.CTLogs(), at)
+}
+
+func describeShard(l *TransparencyLog) string {
+	end := "present"
+	if !l.ValidityPeriodEnd.IsZero() {
+		end = l.ValidityPeriodEnd.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%s valid from %s to %s", l.BaseURL, l.ValidityPeriodStart.UTC().Format(time.RFC3339), end)
+}
+
+// addTransparencyLogShard adds tlog as a shard of existing, which may be nil,
+// and returns the shard that should be indexed by their shared log ID: the
+// one most recently made valid.
+func addTransparencyLogShard(existing, tlog *TransparencyLog) *TransparencyLog {
+	if existing == nil {
+		return tlog
+	}
+
+	shards := append(append([]*TransparencyLog{}, existing.Shards()...), tlog)
+	sort.SliceStable(shards, func(i, j int) bool {
+		return shards[i].ValidityPeriodStart.Before(shards[j].ValidityPeriodStart)
+	})
+	for _, shard := range shards {
+		shard.shards = nil
+	}
+	primary := shards[len(shards)-1]
+	primary.shards = shards
+	return primary
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTransparencyLogShards(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NoError(t, err)
+	logID := sha256.Sum256(der)
+
+	shardStart := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rotation := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	instance := func(baseURL string, start time.Time, end *time.Time) *prototrustroot.TransparencyLogInstance {
+		validFor := &protocommon.TimeRange{Start: timestamppb.New(start)}
+		if end != nil {
+			validFor.End = timestamppb.New(*end)
+		}
+		return &prototrustroot.TransparencyLogInstance{
+			BaseUrl:       baseURL,
+			HashAlgorithm: protocommon.HashAlgorithm_SHA2_256,
+			PublicKey: &protocommon.PublicKey{
+				RawBytes:   der,
+				KeyDetails: protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256,
+				ValidFor:   validFor,
+			},
+			LogId: &protocommon.LogId{KeyId: logID[:]},
+		}
+	}
+
+	// Shards listed out of order
+	logs, err := ParseTransparencyLogs([]*prototrustroot.TransparencyLogInstance{
+		instance("https://rekor.example.com/shard-2", rotation, nil),
+		instance("https://rekor.example.com/shard-1", shardStart, &rotation),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+
+	encodedLogID := hex.EncodeToString(logID[:])
+	tlog := logs[encodedLogID]
+	assert.Equal(t, "https://rekor.example.com/shard-2", tlog.BaseURL)
+	assert.Len(t, tlog.Shards(), 2)
+
+	shard, err := FindTransparencyLog(logs, encodedLogID, shardStart.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://rekor.example.com/shard-1", shard.BaseURL)
+
+	shard, err = FindTransparencyLog(logs, encodedLogID, rotation.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://rekor.example.com/shard-2", shard.BaseURL)
+
+	shard, err = FindTransparencyLog(logs, encodedLogID, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://rekor.example.com/shard-2", shard.BaseURL)
+
+	_, err = FindTransparencyLog(logs, encodedLogID, shardStart.Add(-time.Hour))
+	assert.True(t, errors.Is(err, ErrTransparencyLogNotValid))
+	assert.ErrorContains(t, err, "https://rekor.example.com/shard-1 valid from 2022-01-01T00:00:00Z to 2023-01-01T00:00:00Z")
+	assert.ErrorContains(t, err, "https://rekor.example.com/shard-2 valid from 2023-01-01T00:00:00Z to present")
+
+	_, err = FindTransparencyLog(logs, "deadbeef", rotation)
+	assert.True(t, errors.Is(err, ErrTransparencyLogNotFound))
+	assert.ErrorContains(t, err, "deadbeef")
+}
+
+func TestListActiveCTLogs(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rotation := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	instance := func(pub *ecdsa.PublicKey, baseURL string, start time.Time, end *time.Time) *prototrustroot.TransparencyLogInstance {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		assert.NoError(t, err)
+		logID := sha256.Sum256(der)
+		validFor := &protocommon.TimeRange{Start: timestamppb.New(start)}
+		if end != nil {
+			validFor.End = timestamppb.New(*end)
+		}
+		return &prototrustroot.TransparencyLogInstance{
+			BaseUrl:       baseURL,
+			HashAlgorithm: protocommon.HashAlgorithm_SHA2_256,
+			PublicKey: &protocommon.PublicKey{
+				RawBytes:   der,
+				KeyDetails: protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256,
+				ValidFor:   validFor,
+			},
+			LogId: &protocommon.LogId{KeyId: logID[:]},
+		}
+	}
+
+	logs, err := ParseTransparencyLogs([]*prototrustroot.TransparencyLogInstance{
+		instance(&key.PublicKey, "https://ctfe.example.com/2022", start, &rotation),
+		instance(&key.PublicKey, "https://ctfe.example.com/2023", rotation, nil),
+		instance(&otherKey.PublicKey, "https://ctfe.example.com/2030", future, nil),
+	})
+	assert.NoError(t, err)
+	tr := &TrustedRoot{ctLogs: logs}
+
+	baseURLs := func(logs []*TransparencyLog) []string {
+		var urls []string
+		for _, l := range logs {
+			urls = append(urls, l.BaseURL)
+		}
+		return urls
+	}
+
+	assert.Empty(t, tr.ListActiveCTLogs(start.Add(-time.Hour)))
+	assert.Equal(t, []string{"https://ctfe.example.com/2022"}, baseURLs(tr.ListActiveCTLogs(start.Add(time.Hour))))
+	// The open-ended shard is active, but the shard starting in the future
+	// is not
+	active := tr.ListActiveCTLogs(rotation.Add(time.Hour))
+	assert.Equal(t, []string{"https://ctfe.example.com/2023"}, baseURLs(active))
+	assert.True(t, active[0].OpenEnded())
+	assert.Equal(t, []string{"https://ctfe.example.com/2023", "https://ctfe.example.com/2030"}, baseURLs(tr.ListActiveCTLogs(future)))
+}
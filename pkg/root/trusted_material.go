@@ -53,9 +53,65 @@ func (b *BaseTrustedMaterial) PublicKeyVerifier(_ string) (TimeConstrainedVerifi
 
 type TrustedMaterialCollection []TrustedMaterial
 
+// TrustedMaterialCollectionMode describes how a verifier combines several
+// trusted materials, for example those of the public good instance and of a
+// private deployment.
+type TrustedMaterialCollectionMode int
+
+const (
+	// TrustedMaterialUnion merges all trusted materials, so that each
+	// verification requirement may be satisfied by a different member. This
+	// is how TrustedMaterialCollection behaves.
+	TrustedMaterialUnion TrustedMaterialCollectionMode = iota
+	// TrustedMaterialFirstMatch verifies against each trusted material in
+	// turn, and succeeds as soon as one of them satisfies every requirement
+	// on its own.
+	TrustedMaterialFirstMatch
+	// TrustedMaterialAllMustPass requires every trusted material to satisfy
+	// every requirement on its own.
+	TrustedMaterialAllMustPass
+)
+
+func (m TrustedMaterialCollectionMode) String() string {
+	switch m {
+	case TrustedMaterialUnion:
+		return "union"
+	case TrustedMaterialFirstMatch:
+		return "firstMatch"
+	case TrustedMaterialAllMustPass:
+		return "allMustPass"
+	default:
+		return fmt.Sprintf("TrustedMaterialCollectionMode(%d)", int(m))
+	}
+}
+
+// NamedTrustedMaterial is a TrustedMaterial with a name, such as the
+// ecosystem it belongs to, used to report which trusted material verified
+// an entity.
+type NamedTrustedMaterial struct {
+	Name string
+	TrustedMaterial
+}
+
+// Unwrap returns the named trusted material.
+func (tm NamedTrustedMaterial) Unwrap() TrustedMaterial {
+	return tm.TrustedMaterial
+}
+
+// WrappedTrustedMaterial is implemented by trusted material that adds to
+// other trusted material, such as NamedTrustedMaterial, so that callers can
+// inspect the trusted material it wraps.
+type WrappedTrustedMaterial interface {
+	TrustedMaterial
+	Unwrap() TrustedMaterial
+}
+
 // Ensure types implement interfaces
 var _ TrustedMaterial = &BaseTrustedMaterial{}
 var _ TrustedMaterial = TrustedMaterialCollection{}
+var _ WrappedTrustedMaterial = NamedTrustedMaterial{}
+var _ WrappedTrustedMaterial = &TrustedMaterialWithOIDCProviders{}
+var _ WrappedTrustedMaterial = &TrustedMaterialWithDenylist{}
 
 func (tmc TrustedMaterialCollection) PublicKeyVerifier(keyID string) (TimeConstrainedVerifier, error) {
 	for _, tm := range tmc {
@@ -161,12 +217,33 @@ func NewExpiringKey(verifier signature.Verifier, validityPeriodStart, validityPe
 
 // NewTrustedPublicKeyMaterialFromMapping returns a TrustedPublicKeyMaterial from a map of key IDs to
 // ExpiringKeys.
+//
+// A key hint is first looked up as a key ID in the map. If there is no such
+// key ID, the hint is matched against each key using the
+// DefaultKeyHintSchemes, so that bundles whose hint is derived from the key
+// itself can be verified without knowing the key ID it was registered under.
 func NewTrustedPublicKeyMaterialFromMapping(trustedPublicKeys map[string]*ExpiringKey) *TrustedPublicKeyMaterial {
+	return NewTrustedPublicKeyMaterialFromMappingWithHintSchemes(trustedPublicKeys, DefaultKeyHintSchemes)
+}
+
+// NewTrustedPublicKeyMaterialFromMappingWithHintSchemes is like
+// NewTrustedPublicKeyMaterialFromMapping, but matches hints that are not key
+// IDs in the map under the given schemes, for bundles from signers that
+// compute hints differently.
+func NewTrustedPublicKeyMaterialFromMappingWithHintSchemes(trustedPublicKeys map[string]*ExpiringKey, schemes []KeyHintScheme) *TrustedPublicKeyMaterial {
 	return NewTrustedPublicKeyMaterial(func(keyID string) (TimeConstrainedVerifier, error) {
-		expiringKey, ok := trustedPublicKeys[keyID]
-		if !ok {
-			return nil, fmt.Errorf("public key not found for keyID: %s", keyID)
+		if expiringKey, ok := trustedPublicKeys[keyID]; ok {
+			return expiringKey, nil
+		}
+		for _, expiringKey := range trustedPublicKeys {
+			pub, err := expiringKey.PublicKey()
+			if err != nil {
+				continue
+			}
+			if KeyHintMatchesAny(keyID, pub, schemes) {
+				return expiringKey, nil
+			}
 		}
-		return expiringKey, nil
+		return nil, fmt.Errorf("public key not found for keyID: %s", keyID)
 	})
 }
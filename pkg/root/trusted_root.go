@@ -17,18 +17,21 @@ package root
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"os"
-	"sync"
 	"time"
 
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
 	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const TrustedRootMediaType01 = "application/vnd.dev.sigstore.trustedroot+json;version=0.1"
@@ -40,14 +43,20 @@ type TrustedRoot struct {
 	fulcioCertAuthorities   []CertificateAuthority
 	ctLogs                  map[string]*TransparencyLog
 	timestampingAuthorities []CertificateAuthority
+	// freshness is set for trusted roots fetched from TUF
+	freshness *Freshness
 }
 
 type CertificateAuthority struct {
+	// URI identifies the certificate authority, e.g. the base URL of a
+	// Fulcio or timestamp authority instance; it may be empty
+	URI                 string
 	Root                *x509.Certificate
 	Intermediates       []*x509.Certificate
 	Leaf                *x509.Certificate
 	ValidityPeriodStart time.Time
 	ValidityPeriodEnd   time.Time
+	pools               *certPoolCache
 }
 
 type TransparencyLog struct {
@@ -60,6 +69,10 @@ type TransparencyLog struct {
 	PublicKey crypto.PublicKey
 	// The hash algorithm used during signature creation
 	SignatureHashFunc crypto.Hash
+	verifier          *verifierCache
+	// shards holds every log instance sharing this log's ID, including
+	// this one, ordered by validity period start
+	shards []*TransparencyLog
 }
 
 func (tr *TrustedRoot) TimestampingAuthorities() []CertificateAuthority {
@@ -136,6 +149,7 @@ func ParseTransparencyLogs(tlogs []*prototrustroot.TransparencyLogInstance) (tra
 			return nil, fmt.Errorf("unsupported hash function for the tlog")
 		}
 
+		var transparencyLog *TransparencyLog
 		switch tlog.GetPublicKey().GetKeyDetails() {
 		case protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256:
 			key, err := x509.ParsePKIXPublicKey(tlog.GetPublicKey().GetRawBytes())
@@ -147,12 +161,13 @@ func ParseTransparencyLogs(tlogs []*prototrustroot.TransparencyLogInstance) (tra
 			if ecKey, ok = key.(*ecdsa.PublicKey); !ok {
 				return nil, fmt.Errorf("tlog public key is not ECDSA P256")
 			}
-			transparencyLogs[encodedKeyID] = &TransparencyLog{
+			transparencyLog = &TransparencyLog{
 				BaseURL:           tlog.GetBaseUrl(),
 				ID:                tlog.GetLogId().GetKeyId(),
 				HashFunc:          hashFunc,
 				PublicKey:         ecKey,
 				SignatureHashFunc: crypto.SHA256,
+				verifier:          &verifierCache{},
 			}
 		// This key format is deprecated, but currently in use for Sigstore staging instance
 		case protocommon.PublicKeyDetails_PKCS1_RSA_PKCS1V5: //nolint:staticcheck
@@ -160,12 +175,13 @@ func ParseTransparencyLogs(tlogs []*prototrustroot.TransparencyLogInstance) (tra
 			if err != nil {
 				return nil, err
 			}
-			transparencyLogs[encodedKeyID] = &TransparencyLog{
+			transparencyLog = &TransparencyLog{
 				BaseURL:           tlog.GetBaseUrl(),
 				ID:                tlog.GetLogId().GetKeyId(),
 				HashFunc:          hashFunc,
 				PublicKey:         key,
 				SignatureHashFunc: crypto.SHA256,
+				verifier:          &verifierCache{},
 			}
 		default:
 			return nil, fmt.Errorf("unsupported tlog public key type: %s", tlog.GetPublicKey().GetKeyDetails())
@@ -173,16 +189,19 @@ func ParseTransparencyLogs(tlogs []*prototrustroot.TransparencyLogInstance) (tra
 
 		if validFor := tlog.GetPublicKey().GetValidFor(); validFor != nil {
 			if validFor.GetStart() != nil {
-				transparencyLogs[encodedKeyID].ValidityPeriodStart = validFor.GetStart().AsTime()
+				transparencyLog.ValidityPeriodStart = validFor.GetStart().AsTime()
 			} else {
 				return nil, fmt.Errorf("tlog missing public key validity period start time")
 			}
 			if validFor.GetEnd() != nil {
-				transparencyLogs[encodedKeyID].ValidityPeriodEnd = validFor.GetEnd().AsTime()
+				transparencyLog.ValidityPeriodEnd = validFor.GetEnd().AsTime()
 			}
 		} else {
 			return nil, fmt.Errorf("tlog missing public key validity period")
 		}
+
+		// Log shards may share a signing key, and therefore a log ID
+		transparencyLogs[encodedKeyID] = addTransparencyLogShard(transparencyLogs[encodedKeyID], transparencyLog)
 	}
 	return transparencyLogs, nil
 }
@@ -212,7 +231,7 @@ func ParseCertificateAuthority(certAuthority *prototrustroot.CertificateAuthorit
 		return nil, fmt.Errorf("CertificateAuthority cert chain is empty")
 	}
 
-	certificateAuthority = &CertificateAuthority{}
+	certificateAuthority = &CertificateAuthority{URI: certAuthority.GetUri(), pools: &certPoolCache{}}
 	for i, cert := range certChain.GetCertificates() {
 		parsedCert, err := x509.ParseCertificate(cert.RawBytes)
 		if err != nil {
@@ -239,7 +258,7 @@ func ParseCertificateAuthority(certAuthority *prototrustroot.CertificateAuthorit
 		}
 	}
 
-	// TODO: Should we inspect/enforce ca.Subject and ca.Uri?
+	// TODO: Should we inspect/enforce ca.Subject?
 	// TODO: Handle validity period (ca.ValidFor)
 
 	return certificateAuthority, nil
@@ -279,96 +298,268 @@ func FetchTrustedRoot() (*TrustedRoot, error) {
 	return FetchTrustedRootWithOptions(tuf.DefaultOptions())
 }
 
+// fetchGroup shares concurrent fetches of the same trusted root, such as by
+// many goroutines at startup, so that each does not refresh the TUF
+// metadata.
+var fetchGroup singleflight.Group
+
 // FetchTrustedRootWithOptions fetches the trusted root from TUF with the given options and returns it.
+//
+// Concurrent calls for the same TUF repository, trust anchor and cache path
+// share a single fetch, but each returns its own TrustedRoot.
 func FetchTrustedRootWithOptions(opts *tuf.Options) (*TrustedRoot, error) {
-	client, err := tuf.New(opts)
+	rootDigest := sha256.Sum256(opts.Root)
+	key := fmt.Sprintf("%s|%x|%s|%t", opts.RepositoryBaseURL, rootDigest, opts.CachePath, opts.DisableLocalCache)
+	fetched, err, _ := fetchGroup.Do(key, func() (any, error) {
+		client, err := tuf.New(opts)
+		if err != nil {
+			return nil, err
+		}
+		jsonBytes, err := client.GetTarget(tuf.TrustedRootTarget)
+		if err != nil {
+			return nil, err
+		}
+		return fetchedTrustedRoot{jsonBytes, tufFreshness(client)}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return GetTrustedRoot(client)
+	return fetched.(fetchedTrustedRoot).trustedRoot()
+}
+
+// fetchedTrustedRoot is a trusted root fetched from TUF, shared between
+// concurrent fetches.
+type fetchedTrustedRoot struct {
+	json      []byte
+	freshness *Freshness
+}
+
+func (f fetchedTrustedRoot) trustedRoot() (*TrustedRoot, error) {
+	tr, err := NewTrustedRootFromJSON(f.json)
+	if err != nil {
+		return nil, err
+	}
+	if f.freshness != nil {
+		freshness := *f.freshness
+		tr.freshness = &freshness
+	}
+	return tr, nil
 }
 
 // GetTrustedRoot returns the trusted root
 func GetTrustedRoot(c *tuf.Client) (*TrustedRoot, error) {
-	jsonBytes, err := c.GetTarget("trusted_root.json")
+	jsonBytes, err := c.GetTarget(tuf.TrustedRootTarget)
+	if err != nil {
+		return nil, err
+	}
+	return fetchedTrustedRoot{jsonBytes, tufFreshness(c)}.trustedRoot()
+}
+
+// NewTrustedRootFromTUFBundle returns the trusted root in a TUF trusted
+// root bundle, once the bundle's metadata has been verified offline
+// against initialRoot, e.g. an initial TUF root embedded in the verifier
+// such as tuf.DefaultRoot().
+func NewTrustedRootFromTUFBundle(b *tuf.TrustedRootBundle, initialRoot []byte) (*TrustedRoot, error) {
+	jsonBytes, err := b.GetTarget(initialRoot, tuf.TrustedRootTarget)
 	if err != nil {
 		return nil, err
 	}
 	return NewTrustedRootFromJSON(jsonBytes)
 }
 
-// LiveTrustedRoot is a wrapper around TrustedRoot that periodically
-// refreshes the trusted root from TUF. This is needed for long-running
-// processes to ensure that the trusted root does not expire.
-type LiveTrustedRoot struct {
-	*TrustedRoot
-	mu sync.RWMutex
+// MarshalJSON returns the JSON encoding of the trusted root, including any
+// certificate authorities or logs added after it was parsed.
+func (tr *TrustedRoot) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(tr.protobuf())
 }
 
-// NewLiveTrustedRoot returns a LiveTrustedRoot that will periodically
-// refresh the trusted root from TUF.
-func NewLiveTrustedRoot(opts *tuf.Options) (*LiveTrustedRoot, error) {
-	client, err := tuf.New(opts)
+// protobuf returns the protobuf representation of the trusted root, creating
+// an empty one for a TrustedRoot that wasn't parsed, so that authorities and
+// logs can be added to its zero value.
+func (tr *TrustedRoot) protobuf() *prototrustroot.TrustedRoot {
+	if tr.trustedRoot == nil {
+		tr.trustedRoot = &prototrustroot.TrustedRoot{MediaType: TrustedRootMediaType01}
+	}
+	return tr.trustedRoot
+}
+
+// AddCertificateAuthority adds a Fulcio certificate authority to the trusted
+// root. The authority must have a root certificate.
+//
+// Like other TrustedRoot methods, this is not safe to call concurrently with
+// verification using the same trusted root.
+func (tr *TrustedRoot) AddCertificateAuthority(ca CertificateAuthority) error {
+	protoCA, parsed, err := certificateAuthorityToProtobuf(ca)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	tr, err := GetTrustedRoot(client)
+	pb := tr.protobuf()
+	pb.CertificateAuthorities = append(pb.CertificateAuthorities, protoCA)
+	tr.fulcioCertAuthorities = append(tr.fulcioCertAuthorities, *parsed)
+	return nil
+}
+
+// AddTimestampingAuthority adds a timestamping authority to the trusted root.
+// The authority must have a root certificate.
+//
+// Like other TrustedRoot methods, this is not safe to call concurrently with
+// verification using the same trusted root.
+func (tr *TrustedRoot) AddTimestampingAuthority(ca CertificateAuthority) error {
+	protoCA, parsed, err := certificateAuthorityToProtobuf(ca)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	ltr := &LiveTrustedRoot{
-		TrustedRoot: tr,
-		mu:          sync.RWMutex{},
-	}
-	ticker := time.NewTicker(time.Hour * 24)
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				client, err = tuf.New(opts)
-				if err != nil {
-					log.Printf("error creating TUF client: %v", err)
-				}
-				newTr, err := GetTrustedRoot(client)
-				if err != nil {
-					log.Printf("error fetching trusted root: %v", err)
-					continue
-				}
-				ltr.mu.Lock()
-				ltr.TrustedRoot = newTr
-				ltr.mu.Unlock()
-			}
-		}
-	}()
-	return ltr, nil
+	pb := tr.protobuf()
+	pb.TimestampAuthorities = append(pb.TimestampAuthorities, protoCA)
+	tr.timestampingAuthorities = append(tr.timestampingAuthorities, *parsed)
+	return nil
 }
 
-func (l *LiveTrustedRoot) TimestampingAuthorities() []CertificateAuthority {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.TimestampingAuthorities()
+// AddRekorLog adds a Rekor transparency log to the trusted root. The log
+// must have an ID, a public key, and a validity period start time. A log
+// with the ID of a log already in the trusted root is added as another shard
+// of it, unless it has the same base URL and validity period start.
+//
+// Like other TrustedRoot methods, this is not safe to call concurrently with
+// verification using the same trusted root.
+func (tr *TrustedRoot) AddRekorLog(tlog *TransparencyLog) error {
+	if tr.rekorLogs == nil {
+		tr.rekorLogs = make(map[string]*TransparencyLog)
+	}
+	protoLog, err := addTransparencyLog(tr.rekorLogs, tlog)
+	if err != nil {
+		return err
+	}
+	pb := tr.protobuf()
+	pb.Tlogs = append(pb.Tlogs, protoLog)
+	return nil
 }
 
-func (l *LiveTrustedRoot) FulcioCertificateAuthorities() []CertificateAuthority {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.FulcioCertificateAuthorities()
+// AddCTLog adds a certificate transparency log to the trusted root. The log
+// must have an ID, a public key, and a validity period start time. As with
+// AddRekorLog, a log with the ID of a log already in the trusted root is
+// added as another shard of it.
+//
+// Like other TrustedRoot methods, this is not safe to call concurrently with
+// verification using the same trusted root.
+func (tr *TrustedRoot) AddCTLog(tlog *TransparencyLog) error {
+	if tr.ctLogs == nil {
+		tr.ctLogs = make(map[string]*TransparencyLog)
+	}
+	protoLog, err := addTransparencyLog(tr.ctLogs, tlog)
+	if err != nil {
+		return err
+	}
+	pb := tr.protobuf()
+	pb.Ctlogs = append(pb.Ctlogs, protoLog)
+	return nil
 }
 
-func (l *LiveTrustedRoot) RekorLogs() map[string]*TransparencyLog {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.RekorLogs()
+// certificateAuthorityToProtobuf converts a CertificateAuthority to its
+// protobuf representation, and parses that back so that the returned
+// authority is equivalent to one read from a trusted root.
+func certificateAuthorityToProtobuf(ca CertificateAuthority) (*prototrustroot.CertificateAuthority, *CertificateAuthority, error) {
+	if ca.Root == nil {
+		return nil, nil, fmt.Errorf("CertificateAuthority missing root certificate")
+	}
+
+	chain := []*x509.Certificate{}
+	if ca.Leaf != nil {
+		chain = append(chain, ca.Leaf)
+	}
+	chain = append(chain, ca.Intermediates...)
+	chain = append(chain, ca.Root)
+
+	protoCA := &prototrustroot.CertificateAuthority{
+		Uri:       ca.URI,
+		Subject:   &protocommon.DistinguishedName{},
+		CertChain: &protocommon.X509CertificateChain{},
+	}
+	if len(ca.Root.Subject.Organization) > 0 {
+		protoCA.Subject.Organization = ca.Root.Subject.Organization[0]
+	}
+	protoCA.Subject.CommonName = ca.Root.Subject.CommonName
+	for _, cert := range chain {
+		protoCA.CertChain.Certificates = append(protoCA.CertChain.Certificates, &protocommon.X509Certificate{RawBytes: cert.Raw})
+	}
+	protoCA.ValidFor = timeRangeToProtobuf(ca.ValidityPeriodStart, ca.ValidityPeriodEnd)
+
+	parsed, err := ParseCertificateAuthority(protoCA)
+	if err != nil {
+		return nil, nil, err
+	}
+	return protoCA, parsed, nil
 }
 
-func (l *LiveTrustedRoot) CTLogs() map[string]*TransparencyLog {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.CTLogs()
+// addTransparencyLog converts a TransparencyLog to its protobuf
+// representation, and adds the equivalent parsed log to logs, as a shard of
+// any log with the same ID.
+func addTransparencyLog(logs map[string]*TransparencyLog, tlog *TransparencyLog) (*prototrustroot.TransparencyLogInstance, error) {
+	if tlog == nil {
+		return nil, fmt.Errorf("tlog is nil")
+	}
+	if len(tlog.ID) == 0 {
+		return nil, fmt.Errorf("tlog missing log ID")
+	}
+	if existing, ok := logs[hex.EncodeToString(tlog.ID)]; ok {
+		for _, shard := range existing.Shards() {
+			if shard.BaseURL == tlog.BaseURL && shard.ValidityPeriodStart.Equal(tlog.ValidityPeriodStart) {
+				return nil, fmt.Errorf("tlog with log ID %x already exists: %s", tlog.ID, describeShard(shard))
+			}
+		}
+	}
+	if tlog.HashFunc != crypto.SHA256 {
+		return nil, fmt.Errorf("unsupported tlog hash algorithm: %s", tlog.HashFunc)
+	}
+
+	publicKey := &protocommon.PublicKey{
+		ValidFor: timeRangeToProtobuf(tlog.ValidityPeriodStart, tlog.ValidityPeriodEnd),
+	}
+	switch key := tlog.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("tlog public key is not ECDSA P256")
+		}
+		rawBytes, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		publicKey.RawBytes = rawBytes
+		publicKey.KeyDetails = protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256
+	case *rsa.PublicKey:
+		publicKey.RawBytes = x509.MarshalPKCS1PublicKey(key)
+		publicKey.KeyDetails = protocommon.PublicKeyDetails_PKCS1_RSA_PKCS1V5 //nolint:staticcheck
+	default:
+		return nil, fmt.Errorf("unsupported tlog public key type: %T", tlog.PublicKey)
+	}
+
+	protoLog := &prototrustroot.TransparencyLogInstance{
+		BaseUrl:       tlog.BaseURL,
+		HashAlgorithm: protocommon.HashAlgorithm_SHA2_256,
+		PublicKey:     publicKey,
+		LogId:         &protocommon.LogId{KeyId: tlog.ID},
+	}
+
+	parsed, err := ParseTransparencyLogs([]*prototrustroot.TransparencyLogInstance{protoLog})
+	if err != nil {
+		return nil, err
+	}
+	for keyID, parsedLog := range parsed {
+		logs[keyID] = addTransparencyLogShard(logs[keyID], parsedLog)
+	}
+	return protoLog, nil
 }
 
-func (l *LiveTrustedRoot) PublicKeyVerifier(keyID string) (TimeConstrainedVerifier, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.PublicKeyVerifier(keyID)
+func timeRangeToProtobuf(start, end time.Time) *protocommon.TimeRange {
+	if start.IsZero() && end.IsZero() {
+		return nil
+	}
+	timeRange := &protocommon.TimeRange{}
+	if !start.IsZero() {
+		timeRange.Start = timestamppb.New(start)
+	}
+	if !end.IsZero() {
+		timeRange.End = timestamppb.New(end)
+	}
+	return timeRange
 }
@@ -19,6 +19,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"testing"
 	"time"
@@ -73,3 +74,154 @@ func TestTrustedMaterialCollection(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, verifier, verifier2)
 }
+
+func TestTrustedRootAdditions(t *testing.T) {
+	trustedRoot, err := NewTrustedRootFromPath("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+
+	numCAs := len(trustedRoot.FulcioCertificateAuthorities())
+	numTSAs := len(trustedRoot.TimestampingAuthorities())
+	numRekorLogs := len(trustedRoot.RekorLogs())
+	numCTLogs := len(trustedRoot.CTLogs())
+
+	// re-use material from the public good instance, to avoid depending on
+	// pkg/testing/ca from within this package
+	existingCA := trustedRoot.FulcioCertificateAuthorities()[0]
+	ca := CertificateAuthority{
+		Root:                existingCA.Root,
+		Intermediates:       existingCA.Intermediates,
+		ValidityPeriodStart: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, trustedRoot.AddCertificateAuthority(ca))
+	assert.NoError(t, trustedRoot.AddTimestampingAuthority(ca))
+	assert.Error(t, trustedRoot.AddCertificateAuthority(CertificateAuthority{}))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tlog := &TransparencyLog{
+		BaseURL:             "https://rekor.example.com",
+		ID:                  []byte("new-log-id"),
+		ValidityPeriodStart: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		HashFunc:            crypto.SHA256,
+		PublicKey:           key.Public(),
+		SignatureHashFunc:   crypto.SHA256,
+	}
+	assert.NoError(t, trustedRoot.AddRekorLog(tlog))
+	assert.Error(t, trustedRoot.AddRekorLog(tlog)) // duplicate log ID
+	assert.NoError(t, trustedRoot.AddCTLog(tlog))
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	assert.Error(t, trustedRoot.AddCTLog(&TransparencyLog{ID: []byte("p384"), HashFunc: crypto.SHA256, PublicKey: p384Key.Public(), ValidityPeriodStart: time.Now()}))
+
+	assert.Len(t, trustedRoot.FulcioCertificateAuthorities(), numCAs+1)
+	assert.Len(t, trustedRoot.TimestampingAuthorities(), numTSAs+1)
+	assert.Len(t, trustedRoot.RekorLogs(), numRekorLogs+1)
+	assert.Len(t, trustedRoot.CTLogs(), numCTLogs+1)
+
+	// the additions survive serialization
+	trustedRootJSON, err := trustedRoot.MarshalJSON()
+	assert.NoError(t, err)
+	reparsed, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.NoError(t, err)
+
+	assert.Len(t, reparsed.FulcioCertificateAuthorities(), numCAs+1)
+	assert.Len(t, reparsed.TimestampingAuthorities(), numTSAs+1)
+	assert.Len(t, reparsed.RekorLogs(), numRekorLogs+1)
+	assert.Len(t, reparsed.CTLogs(), numCTLogs+1)
+
+	addedCA := reparsed.FulcioCertificateAuthorities()[numCAs]
+	assert.True(t, addedCA.Root.Equal(ca.Root))
+	assert.True(t, addedCA.ValidityPeriodStart.Equal(ca.ValidityPeriodStart))
+
+	addedLog := reparsed.RekorLogs()[hex.EncodeToString([]byte("new-log-id"))]
+	assert.NotNil(t, addedLog)
+	assert.Equal(t, "https://rekor.example.com", addedLog.BaseURL)
+	assert.True(t, key.PublicKey.Equal(addedLog.PublicKey))
+}
+
+func TestTrustedRootAddTransparencyLogShards(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rotation := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldShard := &TransparencyLog{
+		BaseURL:             "https://rekor.example.com/2024",
+		ID:                  []byte("log-id"),
+		ValidityPeriodStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidityPeriodEnd:   rotation,
+		HashFunc:            crypto.SHA256,
+		PublicKey:           key.Public(),
+		SignatureHashFunc:   crypto.SHA256,
+	}
+	newShard := &TransparencyLog{
+		BaseURL:             "https://rekor.example.com/2025",
+		ID:                  []byte("log-id"),
+		ValidityPeriodStart: rotation,
+		HashFunc:            crypto.SHA256,
+		PublicKey:           key.Public(),
+		SignatureHashFunc:   crypto.SHA256,
+	}
+
+	var trustedRoot TrustedRoot
+	// Shards may be added in any order
+	assert.NoError(t, trustedRoot.AddRekorLog(newShard))
+	assert.NoError(t, trustedRoot.AddRekorLog(oldShard))
+	assert.Error(t, trustedRoot.AddRekorLog(oldShard)) // duplicate shard
+	assert.NoError(t, trustedRoot.AddCTLog(oldShard))
+	assert.NoError(t, trustedRoot.AddCTLog(newShard))
+
+	trustedRootJSON, err := trustedRoot.MarshalJSON()
+	assert.NoError(t, err)
+	reparsed, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.NoError(t, err)
+
+	logID := hex.EncodeToString([]byte("log-id"))
+	for _, tr := range []*TrustedRoot{&trustedRoot, reparsed} {
+		for _, logs := range []map[string]*TransparencyLog{tr.RekorLogs(), tr.CTLogs()} {
+			assert.Len(t, logs, 1)
+			assert.Len(t, logs[logID].Shards(), 2)
+
+			found, err := FindTransparencyLog(logs, logID, rotation.Add(-time.Hour))
+			assert.NoError(t, err)
+			assert.Equal(t, "https://rekor.example.com/2024", found.BaseURL)
+			found, err = FindTransparencyLog(logs, logID, rotation.Add(time.Hour))
+			assert.NoError(t, err)
+			assert.Equal(t, "https://rekor.example.com/2025", found.BaseURL)
+		}
+	}
+}
+
+func TestTrustedRootAdditionsToZeroValue(t *testing.T) {
+	publicGood, err := NewTrustedRootFromPath("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	existingCA := publicGood.FulcioCertificateAuthorities()[0]
+
+	var trustedRoot TrustedRoot
+	emptyJSON, err := trustedRoot.MarshalJSON()
+	assert.NoError(t, err)
+	_, err = NewTrustedRootFromJSON(emptyJSON)
+	assert.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tlog := &TransparencyLog{
+		ID:                  []byte("log-id"),
+		ValidityPeriodStart: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		HashFunc:            crypto.SHA256,
+		PublicKey:           key.Public(),
+		SignatureHashFunc:   crypto.SHA256,
+	}
+	assert.NoError(t, trustedRoot.AddCertificateAuthority(CertificateAuthority{Root: existingCA.Root}))
+	assert.NoError(t, trustedRoot.AddTimestampingAuthority(CertificateAuthority{Root: existingCA.Root}))
+	assert.NoError(t, trustedRoot.AddRekorLog(tlog))
+	assert.NoError(t, trustedRoot.AddCTLog(tlog))
+
+	trustedRootJSON, err := trustedRoot.MarshalJSON()
+	assert.NoError(t, err)
+	reparsed, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.NoError(t, err)
+	assert.Len(t, reparsed.FulcioCertificateAuthorities(), 1)
+	assert.Len(t, reparsed.TimestampingAuthorities(), 1)
+	assert.Len(t, reparsed.RekorLogs(), 1)
+	assert.Len(t, reparsed.CTLogs(), 1)
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+)
+
+// ValidationSeverity is the severity of a ValidationFinding.
+type ValidationSeverity string
+
+const (
+	// ValidationError is a problem that will cause verification against
+	// the trusted root to fail or to trust the wrong material.
+	ValidationError ValidationSeverity = "error"
+	// ValidationWarning is a problem that is unlikely to be intended, but
+	// that does not by itself break verification.
+	ValidationWarning ValidationSeverity = "warning"
+)
+
+// ValidationFinding is a problem found by Validate.
+type ValidationFinding struct {
+	Severity ValidationSeverity `json:"severity"`
+	// Path identifies the part of the trusted root with the problem, such
+	// as "tlogs[0]" or "certificateAuthorities[1].certChain[0]"
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (f ValidationFinding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Path, f.Message)
+}
+
+// ValidationFindings are the problems found by Validate.
+type ValidationFindings []ValidationFinding
+
+// HasErrors returns true if any of the findings is a ValidationError.
+func (fs ValidationFindings) HasErrors() bool {
+	for _, f := range fs {
+		if f.Severity == ValidationError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns an error listing the findings that are errors, or nil if
+// there are none.
+func (fs ValidationFindings) Err() error {
+	var errs []error
+	for _, f := range fs {
+		if f.Severity == ValidationError {
+			errs = append(errs, errors.New(f.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate performs deep validation of a trusted root, beyond the checks
+// made when it is parsed, and returns the problems it finds. It checks that:
+//
+//   - log IDs are the SHA-256 digest of the log's public key, and are not
+//     shared by logs with overlapping validity periods
+//   - certificate chains verify, and end in a self-signed root
+//   - validity periods end after they start
+//   - URIs are well-formed
+//
+// Validate does not contact any of the services in the trusted root.
+func Validate(tr *TrustedRoot) ValidationFindings {
+	v := &validator{now: time.Now()}
+	if tr == nil || tr.trustedRoot == nil {
+		v.errorf("", "trusted root is empty")
+		return v.findings
+	}
+	pb := tr.trustedRoot
+
+	if pb.GetMediaType() != TrustedRootMediaType01 {
+		v.errorf("mediaType", "unsupported media type %q", pb.GetMediaType())
+	}
+	v.validateTransparencyLogs("tlogs", pb.GetTlogs())
+	v.validateTransparencyLogs("ctlogs", pb.GetCtlogs())
+	v.validateCertificateAuthorities("certificateAuthorities", pb.GetCertificateAuthorities(), false)
+	v.validateCertificateAuthorities("timestampAuthorities", pb.GetTimestampAuthorities(), true)
+
+	return v.findings
+}
+
+type validator struct {
+	now      time.Time
+	findings ValidationFindings
+}
+
+func (v *validator) errorf(path, format string, args ...any) {
+	v.findings = append(v.findings, ValidationFinding{Severity: ValidationError, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path, format string, args ...any) {
+	v.findings = append(v.findings, ValidationFinding{Severity: ValidationWarning, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validateTransparencyLogs(field string, tlogs []*prototrustroot.TransparencyLogInstance) {
+	type seenLog struct {
+		path string
+		log  *TransparencyLog
+	}
+	var seen []seenLog
+
+	for i, tlog := range tlogs {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		v.validateURL(path+".baseUrl", tlog.GetBaseUrl())
+
+		parsed, err := ParseTransparencyLogs([]*prototrustroot.TransparencyLogInstance{tlog})
+		if err != nil {
+			v.errorf(path, "invalid log: %v", err)
+			continue
+		}
+		var log *TransparencyLog
+		for _, l := range parsed {
+			log = l
+		}
+
+		keyID, err := SHA256SPKIHexKeyHint(log.PublicKey)
+		switch {
+		case err != nil:
+			v.errorf(path+".publicKey", "failed to compute key ID: %v", err)
+		case !strings.EqualFold(keyID, fmt.Sprintf("%x", log.ID)):
+			v.errorf(path+".logId", "log ID %x does not match the SHA-256 digest of the public key %s", log.ID, keyID)
+		}
+
+		v.validateValidityPeriod(path+".publicKey.validFor", log.ValidityPeriodStart, log.ValidityPeriodEnd)
+
+		for _, other := range seen {
+			if bytes.Equal(other.log.ID, log.ID) && periodsOverlap(other.log, log) {
+				v.errorf(path+".logId", "log ID %x is also used by %s with an overlapping validity period", log.ID, other.path)
+			}
+		}
+		seen = append(seen, seenLog{path: path, log: log})
+	}
+}
+
+func periodsOverlap(a, b *TransparencyLog) bool {
+	aEndsBeforeB := !a.ValidityPeriodEnd.IsZero() && !a.ValidityPeriodEnd.After(b.ValidityPeriodStart)
+	bEndsBeforeA := !b.ValidityPeriodEnd.IsZero() && !b.ValidityPeriodEnd.After(a.ValidityPeriodStart)
+	return !aEndsBeforeB && !bEndsBeforeA
+}
+
+func (v *validator) validateCertificateAuthorities(field string, cas []*prototrustroot.CertificateAuthority, requireLeaf bool) {
+	for i, ca := range cas {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		v.validateURL(path+".uri", ca.GetUri())
+
+		parsed, err := ParseCertificateAuthority(ca)
+		if err != nil {
+			v.errorf(path, "invalid certificate authority: %v", err)
+			continue
+		}
+		if requireLeaf && parsed.Leaf == nil {
+			v.errorf(path+".certChain", "timestamping authority has no leaf certificate")
+		}
+
+		var chain []*x509.Certificate
+		if parsed.Leaf != nil {
+			chain = append(chain, parsed.Leaf)
+		}
+		chain = append(chain, parsed.Intermediates...)
+		chain = append(chain, parsed.Root)
+
+		for j, cert := range chain {
+			certPath := fmt.Sprintf("%s.certChain[%d]", path, j)
+			if j < len(chain)-1 {
+				if err := cert.CheckSignatureFrom(chain[j+1]); err != nil {
+					v.errorf(certPath, "certificate is not signed by the next certificate in the chain: %v", err)
+				}
+			} else if err := cert.CheckSignatureFrom(cert); err != nil {
+				v.errorf(certPath, "root certificate is not self-signed: %v", err)
+			}
+			if cert != parsed.Leaf && !cert.IsCA {
+				v.errorf(certPath, "issuing certificate is not a CA")
+			}
+			if !cert.NotAfter.After(cert.NotBefore) {
+				v.errorf(certPath, "certificate expires before it is valid")
+			}
+		}
+
+		v.validateValidityPeriod(path+".validFor", parsed.ValidityPeriodStart, parsed.ValidityPeriodEnd)
+		if parsed.ValidityPeriodEnd.IsZero() && parsed.Root.NotAfter.Before(v.now) {
+			v.warnf(path+".validFor", "validity period is open-ended but the root certificate expired at %s", parsed.Root.NotAfter.Format(time.RFC3339))
+		}
+	}
+}
+
+func (v *validator) validateValidityPeriod(path string, start, end time.Time) {
+	if !end.IsZero() && !end.After(start) {
+		v.errorf(path, "validity period ends at %s, before it starts at %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	if start.After(v.now) {
+		v.warnf(path, "validity period starts in the future, at %s", start.Format(time.RFC3339))
+	}
+}
+
+func (v *validator) validateURL(path, rawURL string) {
+	if rawURL == "" {
+		v.warnf(path, "URI is empty")
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		v.errorf(path, "URI is malformed: %v", err)
+		return
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		v.errorf(path, "URI %q does not have an http or https scheme", rawURL)
+	} else if u.Host == "" {
+		v.errorf(path, "URI %q has no host", rawURL)
+	}
+}
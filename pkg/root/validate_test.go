@@ -0,0 +1,104 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestValidate(t *testing.T) {
+	trustedRootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	require.NoError(t, err)
+	original, err := NewTrustedRootProtobuf(trustedRootJSON)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(*prototrustroot.TrustedRoot)
+		path   string
+	}{
+		{
+			name:   "public good",
+			mutate: func(*prototrustroot.TrustedRoot) {},
+		},
+		{
+			name: "log ID does not match key",
+			mutate: func(tr *prototrustroot.TrustedRoot) {
+				tr.Tlogs[0].LogId.KeyId = []byte("not the key ID")
+			},
+			path: "tlogs[0].logId",
+		},
+		{
+			name: "malformed base URL",
+			mutate: func(tr *prototrustroot.TrustedRoot) {
+				tr.Ctlogs[0].BaseUrl = "ctfe.sigstore.dev/2022"
+			},
+			path: "ctlogs[0].baseUrl",
+		},
+		{
+			name: "validity period ends before it starts",
+			mutate: func(tr *prototrustroot.TrustedRoot) {
+				validFor := tr.Tlogs[0].PublicKey.ValidFor
+				validFor.End = timestamppb.New(validFor.Start.AsTime().Add(-time.Hour))
+			},
+			path: "tlogs[0].publicKey.validFor",
+		},
+		{
+			name: "duplicate log ID",
+			mutate: func(tr *prototrustroot.TrustedRoot) {
+				tr.Ctlogs = append(tr.Ctlogs, proto.Clone(tr.Ctlogs[len(tr.Ctlogs)-1]).(*prototrustroot.TransparencyLogInstance))
+			},
+			path: "ctlogs[2].logId",
+		},
+		{
+			name: "broken certificate chain",
+			mutate: func(tr *prototrustroot.TrustedRoot) {
+				certs := tr.CertificateAuthorities[1].CertChain.Certificates
+				tr.CertificateAuthorities[1].CertChain.Certificates = []*protocommon.X509Certificate{certs[0], tr.CertificateAuthorities[0].CertChain.Certificates[0]}
+			},
+			path: "certificateAuthorities[1].certChain[0]",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pb := proto.Clone(original).(*prototrustroot.TrustedRoot)
+			tc.mutate(pb)
+			tr, err := NewTrustedRootFromProtobuf(pb)
+			require.NoError(t, err)
+
+			findings := Validate(tr)
+			if tc.path == "" {
+				assert.False(t, findings.HasErrors(), findings)
+				assert.NoError(t, findings.Err())
+				return
+			}
+			assert.True(t, findings.HasErrors())
+			assert.Error(t, findings.Err())
+			var paths []string
+			for _, f := range findings {
+				paths = append(paths, f.Path)
+			}
+			assert.Contains(t, paths, tc.path)
+		})
+	}
+}
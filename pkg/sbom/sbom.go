@@ -0,0 +1,242 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom converts the results of bundle verification into the
+// structures that CycloneDX and SPDX documents use to reference external
+// evidence, so that SBOM tooling can record that an artifact's signature
+// was verified, by whom it was signed and where the bundle is published.
+package sbom
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// Subject is the digest of the artifact a verification result applies to,
+// e.g. the digest given to verify.WithArtifactDigest.
+type Subject struct {
+	// DigestAlgorithm is the in-toto name of the digest algorithm, such as
+	// sha256
+	DigestAlgorithm string
+	Digest          []byte
+}
+
+// Options configures the evidence built from a verification result.
+type Options struct {
+	// Optional location where the verified bundle is published, which is
+	// referenced as the subject's attestation
+	BundleURL string
+}
+
+// CycloneDXExternalReference is an entry of a CycloneDX component's
+// externalReferences.
+type CycloneDXExternalReference struct {
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// CycloneDXProperty is an entry of a CycloneDX component's properties.
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CycloneDXHash is an entry of a CycloneDX component's hashes.
+type CycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+// CycloneDXEvidence is the verification evidence for a CycloneDX component
+// describing the subject, to be merged into the component's fields of the
+// same names.
+type CycloneDXEvidence struct {
+	Hashes             []CycloneDXHash              `json:"hashes"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []CycloneDXProperty          `json:"properties"`
+}
+
+// SPDXExternalRef is an entry of an SPDX 2.3 package's externalRefs.
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+	Comment           string `json:"comment,omitempty"`
+}
+
+// cycloneDXHashAlgorithms maps in-toto digest algorithm names to the names
+// CycloneDX uses.
+var cycloneDXHashAlgorithms = map[string]string{
+	"sha1":     "SHA-1",
+	"sha256":   "SHA-256",
+	"sha384":   "SHA-384",
+	"sha512":   "SHA-512",
+	"sha3-256": "SHA3-256",
+	"sha3-384": "SHA3-384",
+	"sha3-512": "SHA3-512",
+}
+
+// propertyPrefix namespaces the CycloneDX properties set by this package.
+const propertyPrefix = "sigstore:"
+
+// reference is an external reference common to both formats.
+type reference struct {
+	cycloneDXType string
+	url           string
+	comment       string
+}
+
+// CycloneDX returns the CycloneDX evidence that the subject was verified
+// with the result: the subject's hash, references to the bundle and to the
+// source repository and build recorded in the signing certificate, and
+// properties naming the verified identity and timestamps.
+func CycloneDX(result *verify.VerificationResult, subject Subject, opts Options) (*CycloneDXEvidence, error) {
+	if err := checkSubject(result, subject); err != nil {
+		return nil, err
+	}
+	alg, ok := cycloneDXHashAlgorithms[subject.DigestAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm %q", subject.DigestAlgorithm)
+	}
+	evidence := &CycloneDXEvidence{
+		Hashes: []CycloneDXHash{{Algorithm: alg, Content: hex.EncodeToString(subject.Digest)}},
+	}
+	for _, ref := range references(result, opts) {
+		evidence.ExternalReferences = append(evidence.ExternalReferences, CycloneDXExternalReference{
+			Type:    ref.cycloneDXType,
+			URL:     ref.url,
+			Comment: ref.comment,
+		})
+	}
+	for _, property := range properties(result, subject) {
+		evidence.Properties = append(evidence.Properties, CycloneDXProperty{
+			Name:  propertyPrefix + property[0],
+			Value: property[1],
+		})
+	}
+	return evidence, nil
+}
+
+// SPDXExternalRefs returns the SPDX external references recording that the
+// subject was verified with the result: a "sigstore-verification"
+// reference to the subject's digest, whose comment names the verified
+// identity and timestamps, followed by references to the bundle and to the
+// source repository and build recorded in the signing certificate. SPDX
+// 2.3 has no reference types for these, so they are all in the OTHER
+// category, with the type names CycloneDX uses.
+func SPDXExternalRefs(result *verify.VerificationResult, subject Subject, opts Options) ([]SPDXExternalRef, error) {
+	if err := checkSubject(result, subject); err != nil {
+		return nil, err
+	}
+	var details []string
+	for _, property := range properties(result, subject)[1:] {
+		details = append(details, property[0]+"="+property[1])
+	}
+	refs := []SPDXExternalRef{{
+		ReferenceCategory: "OTHER",
+		ReferenceType:     "sigstore-verification",
+		ReferenceLocator:  subject.DigestAlgorithm + ":" + hex.EncodeToString(subject.Digest),
+		Comment:           "Signature verified by sigstore-go: " + strings.Join(details, ", "),
+	}}
+	for _, ref := range references(result, opts) {
+		refs = append(refs, SPDXExternalRef{
+			ReferenceCategory: "OTHER",
+			ReferenceType:     ref.cycloneDXType,
+			ReferenceLocator:  ref.url,
+			Comment:           ref.comment,
+		})
+	}
+	return refs, nil
+}
+
+// checkSubject checks that the subject is one the result could apply to:
+// if the result has a statement, the subject must be one of its subjects.
+func checkSubject(result *verify.VerificationResult, subject Subject) error {
+	if result == nil {
+		return errors.New("verification result is required")
+	}
+	if subject.DigestAlgorithm == "" || len(subject.Digest) == 0 {
+		return errors.New("subject digest is required")
+	}
+	if result.Statement == nil {
+		return nil
+	}
+	hexDigest := hex.EncodeToString(subject.Digest)
+	for _, statementSubject := range result.Statement.Subject {
+		if digest, ok := statementSubject.Digest[subject.DigestAlgorithm]; ok && strings.EqualFold(digest, hexDigest) {
+			return nil
+		}
+	}
+	return fmt.Errorf("subject %s:%s is not a subject of the verified statement", subject.DigestAlgorithm, hexDigest)
+}
+
+// references returns the external references of the result.
+func references(result *verify.VerificationResult, opts Options) []reference {
+	var refs []reference
+	if opts.BundleURL != "" {
+		refs = append(refs, reference{cycloneDXType: "attestation", url: opts.BundleURL, comment: "Sigstore bundle"})
+	}
+	if result.Signature == nil || result.Signature.Certificate == nil {
+		return refs
+	}
+	extensions := result.Signature.Certificate.Extensions
+	if extensions.SourceRepositoryURI != "" {
+		comment := "Source repository of the signing certificate"
+		if extensions.SourceRepositoryDigest != "" {
+			comment += " at " + extensions.SourceRepositoryDigest
+		}
+		refs = append(refs, reference{cycloneDXType: "vcs", url: extensions.SourceRepositoryURI, comment: comment})
+	}
+	if extensions.RunInvocationURI != "" {
+		refs = append(refs, reference{cycloneDXType: "build-meta", url: extensions.RunInvocationURI, comment: "Build that requested the signing certificate"})
+	}
+	if extensions.BuildSignerURI != "" {
+		refs = append(refs, reference{cycloneDXType: "build-system", url: extensions.BuildSignerURI, comment: "Build instructions that signed the subject"})
+	}
+	return refs
+}
+
+// properties returns the name and value of each fact of the result, the
+// subject's digest first.
+func properties(result *verify.VerificationResult, subject Subject) [][2]string {
+	props := [][2]string{
+		{"subject", subject.DigestAlgorithm + ":" + hex.EncodeToString(subject.Digest)},
+	}
+	if result.Signature != nil {
+		if cert := result.Signature.Certificate; cert != nil {
+			props = append(props,
+				[2]string{"certificate.issuer", cert.Issuer},
+				[2]string{"certificate.subjectAlternativeName", cert.SubjectAlternativeName.Value},
+			)
+		}
+		if result.Signature.PublicKeyID != nil {
+			props = append(props, [2]string{"publicKeyHint", string(*result.Signature.PublicKeyID)})
+		}
+	}
+	timestamps := append([]verify.TimestampVerificationResult{}, result.VerifiedTimestamps...)
+	sort.SliceStable(timestamps, func(i, j int) bool {
+		return timestamps[i].Timestamp.Before(timestamps[j].Timestamp)
+	})
+	for _, ts := range timestamps {
+		props = append(props, [2]string{"timestamp." + ts.Type, ts.Timestamp.UTC().Format(time.RFC3339)})
+	}
+	return props
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/sbom"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestEvidence(t *testing.T) {
+	digest := sha256.Sum256([]byte("artifact"))
+	subject := sbom.Subject{DigestAlgorithm: "sha256", Digest: digest[:]}
+	hexDigest := hex.EncodeToString(digest[:])
+
+	result := verify.NewVerificationResult()
+	result.Statement = &in_toto.Statement{StatementHeader: in_toto.StatementHeader{
+		Subject: []in_toto.Subject{{Name: "artifact", Digest: map[string]string{"sha256": hexDigest}}},
+	}}
+	result.Signature = &verify.SignatureVerificationResult{Certificate: &certificate.Summary{
+		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: certificate.SubjectAlternativeNameTypeURI, Value: "https://github.com/sigstore/sigstore-go/.github/workflows/release.yml@refs/heads/main"},
+		Extensions: certificate.Extensions{
+			Issuer:                 "https://token.actions.githubusercontent.com",
+			SourceRepositoryURI:    "https://github.com/sigstore/sigstore-go",
+			SourceRepositoryDigest: "abc123",
+			RunInvocationURI:       "https://github.com/sigstore/sigstore-go/actions/runs/1/attempts/1",
+		},
+	}}
+	integratedTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	result.VerifiedTimestamps = []verify.TimestampVerificationResult{{Type: "Tlog", URI: "https://rekor.sigstore.dev", Timestamp: integratedTime}}
+	opts := sbom.Options{BundleURL: "https://example.com/artifact.sigstore.json"}
+
+	cdx, err := sbom.CycloneDX(result, subject, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []sbom.CycloneDXHash{{Algorithm: "SHA-256", Content: hexDigest}}, cdx.Hashes)
+	require.Len(t, cdx.ExternalReferences, 3)
+	assert.Equal(t, sbom.CycloneDXExternalReference{Type: "attestation", URL: opts.BundleURL, Comment: "Sigstore bundle"}, cdx.ExternalReferences[0])
+	assert.Equal(t, "vcs", cdx.ExternalReferences[1].Type)
+	assert.Equal(t, "https://github.com/sigstore/sigstore-go", cdx.ExternalReferences[1].URL)
+	assert.Contains(t, cdx.ExternalReferences[1].Comment, "abc123")
+	assert.Equal(t, "build-meta", cdx.ExternalReferences[2].Type)
+	assert.Equal(t, []sbom.CycloneDXProperty{
+		{Name: "sigstore:subject", Value: "sha256:" + hexDigest},
+		{Name: "sigstore:certificate.issuer", Value: "https://token.actions.githubusercontent.com"},
+		{Name: "sigstore:certificate.subjectAlternativeName", Value: "https://github.com/sigstore/sigstore-go/.github/workflows/release.yml@refs/heads/main"},
+		{Name: "sigstore:timestamp.Tlog", Value: "2024-01-02T03:04:05Z"},
+	}, cdx.Properties)
+
+	spdx, err := sbom.SPDXExternalRefs(result, subject, opts)
+	require.NoError(t, err)
+	require.Len(t, spdx, 4)
+	assert.Equal(t, "sigstore-verification", spdx[0].ReferenceType)
+	assert.Equal(t, "sha256:"+hexDigest, spdx[0].ReferenceLocator)
+	assert.Contains(t, spdx[0].Comment, "certificate.issuer=https://token.actions.githubusercontent.com")
+	assert.Equal(t, sbom.SPDXExternalRef{ReferenceCategory: "OTHER", ReferenceType: "attestation", ReferenceLocator: opts.BundleURL, Comment: "Sigstore bundle"}, spdx[1])
+
+	otherDigest := sha256.Sum256([]byte("other artifact"))
+	_, err = sbom.CycloneDX(result, sbom.Subject{DigestAlgorithm: "sha256", Digest: otherDigest[:]}, opts)
+	assert.Error(t, err)
+	_, err = sbom.SPDXExternalRefs(result, sbom.Subject{}, opts)
+	assert.Error(t, err)
+	_, err = sbom.CycloneDX(nil, subject, opts)
+	assert.Error(t, err)
+}
+
+func TestEvidenceKeySignedMessage(t *testing.T) {
+	digest := sha256.Sum256([]byte("artifact"))
+	hint := []byte("my-key")
+	result := verify.NewVerificationResult()
+	result.Signature = &verify.SignatureVerificationResult{PublicKeyID: &hint}
+
+	cdx, err := sbom.CycloneDX(result, sbom.Subject{DigestAlgorithm: "sha256", Digest: digest[:]}, sbom.Options{})
+	require.NoError(t, err)
+	assert.Empty(t, cdx.ExternalReferences)
+	assert.Contains(t, cdx.Properties, sbom.CycloneDXProperty{Name: "sigstore:publicKeyHint", Value: "my-key"})
+
+	_, err = sbom.CycloneDX(result, sbom.Subject{DigestAlgorithm: "md5", Digest: digest[:]}, sbom.Options{})
+	assert.Error(t, err)
+}
@@ -16,21 +16,35 @@ package sign
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
 )
 
 type Fulcio struct {
-	options *FulcioOptions
+	baseURL string
+	config  clientConfig
 }
 
+// FulcioOptions configures a Fulcio client created by NewFulcio. A zero or
+// negative Timeout means the default timeout.
+//
+// Deprecated: Use NewFulcioClient and ClientOption instead.
 type FulcioOptions struct {
 	// URL of Fulcio instance
 	BaseURL string
@@ -38,10 +52,14 @@ type FulcioOptions struct {
 	Timeout time.Duration
 	// Optional version string for user agent
 	LibraryVersion string
-}
-
-type jsonWebToken struct {
-	Sub string `json:"sub"`
+	// Optional User-Agent configuration, allowing an application to add its
+	// own product token or to hide version information
+	UserAgent *util.UserAgent
+	// Optional options for the pre-flight identity token validation
+	// performed before contacting Fulcio
+	IDTokenValidation *IDTokenValidationOptions
+	// Optional logger for debug logs of requests
+	Logger *slog.Logger
 }
 
 type fulcioCertRequest struct {
@@ -60,91 +78,180 @@ type publicKey struct {
 
 type fulcioResponse struct {
 	SctCertWithChain signedCertificateEmbeddedSct `json:"signedCertificateEmbeddedSct"`
+	SctCertDetached  signedCertificateDetachedSct `json:"signedCertificateDetachedSct"`
 }
 
 type signedCertificateEmbeddedSct struct {
 	Chain chain `json:"chain"`
 }
 
+type signedCertificateDetachedSct struct {
+	Chain                      chain  `json:"chain"`
+	SignedCertificateTimestamp []byte `json:"signedCertificateTimestamp"`
+}
+
 type chain struct {
 	Certificates []string `json:"certificates"`
 }
 
+// SigningCertificate is a code signing certificate issued by Fulcio.
+type SigningCertificate struct {
+	// DER-encoded leaf certificate
+	Certificate []byte
+	// DER-encoded intermediate and root certificates returned by Fulcio with
+	// the leaf certificate, in order from the leaf's issuer to the root
+	Chain [][]byte
+	// SCT returned separately from the certificate by Fulcio deployments
+	// that do not embed SCTs, or nil. See bundle.SetDetachedSCTs.
+	DetachedSCT []byte
+}
+
+// NewFulcioClient returns a client for the Fulcio instance at baseURL.
+func NewFulcioClient(baseURL string, opts ...ClientOption) (*Fulcio, error) {
+	config, err := newClientConfig("Fulcio", baseURL, 0, opts)
+	if err != nil {
+		return nil, err
+	}
+	if config.circuitBreaker != nil {
+		return nil, errors.New("failover only applies to Rekor and timestamp authority clients")
+	}
+	return &Fulcio{baseURL: baseURL, config: config}, nil
+}
+
+// NewFulcio returns a Fulcio client configured by opts.
+//
+// Deprecated: Use NewFulcioClient instead, which validates its options.
 func NewFulcio(opts *FulcioOptions) *Fulcio {
-	return &Fulcio{options: opts}
+	f := &Fulcio{}
+	if opts != nil {
+		f.baseURL = opts.BaseURL
+		f.config = clientConfig{
+			libraryVersion:    opts.LibraryVersion,
+			userAgent:         opts.UserAgent,
+			logger:            opts.Logger,
+			idTokenValidation: opts.IDTokenValidation,
+		}
+		if opts.Timeout > 0 {
+			f.config.timeout = opts.Timeout
+		}
+	}
+	return f
 }
 
 // Returns DER-encoded code signing certificate
+//
+// Any detached SCT returned by Fulcio is discarded; use GetSigningCertificate
+// to retrieve it.
 func (f *Fulcio) GetCertificate(keypair Keypair, identityToken string) ([]byte, error) {
-	// Get JWT from identity token
-	//
-	// Note that the contents of this token are untrusted. Fulcio will perform
-	// the token verification.
-	tokenParts := strings.Split(identityToken, ".")
-	if len(tokenParts) < 2 {
-		return nil, errors.New("Unable to get subject from identity token")
-	}
-
-	jwtString, err := base64.RawURLEncoding.DecodeString(tokenParts[1])
+	signingCert, err := f.GetSigningCertificate(keypair, identityToken)
 	if err != nil {
 		return nil, err
 	}
+	return signingCert.Certificate, nil
+}
 
-	var jwt jsonWebToken
-	err = json.Unmarshal([]byte(jwtString), &jwt)
+// GetSigningCertificate requests a code signing certificate from Fulcio,
+// accepting responses with either embedded or detached SCTs.
+func (f *Fulcio) GetSigningCertificate(keypair Keypair, identityToken string) (*SigningCertificate, error) {
+	claims, requestJSON, err := f.certificateRequest(keypair, identityToken)
 	if err != nil {
 		return nil, err
 	}
+	return f.requestSigningCertificate(claims, identityToken, requestJSON)
+}
 
-	// Sign JWT subject for proof of possession
-	subjectSignature, _, err := keypair.SignData([]byte(jwt.Sub))
+// CertificateRequest requests a code signing certificate for a public key
+// whose private key is held by the caller, for example in a KMS or HSM, for
+// tools that manage their own signing flow.
+type CertificateRequest struct {
+	// IdentityToken is the OIDC identity token of the certificate's subject
+	IdentityToken string
+	// PublicKey is the RSA, ECDSA or Ed25519 public key to certify
+	PublicKey crypto.PublicKey
+	// ProofOfPossession is the signature of the private key over the
+	// identity token's subject claim, as returned by ParseIDToken, e.g.
+	// over the SHA-256 digest of the claim for an ECDSA P-256 key, or over
+	// the claim itself for an Ed25519 key.
+	ProofOfPossession []byte
+}
+
+// RequestCertificate requests a code signing certificate from Fulcio for
+// the public key in req, returning the certificate, its chain and any
+// detached SCT. Unlike GetSigningCertificate, it does not need access to the
+// private key, and the certificate is not added to a bundle.
+func (f *Fulcio) RequestCertificate(req CertificateRequest) (*SigningCertificate, error) {
+	if req.PublicKey == nil {
+		return nil, errors.New("public key must be provided")
+	}
+	if len(req.ProofOfPossession) == 0 {
+		return nil, errors.New("proof of possession must be provided")
+	}
+	keyAlgorithm, err := publicKeyAlgorithm(req.PublicKey)
 	if err != nil {
 		return nil, err
 	}
-
-	// Make Fulcio certificate request
-	keypairPem, err := keypair.GetPublicKeyPem()
+	publicKeyPEM, err := cryptoutils.MarshalPublicKeyToPEM(req.PublicKey)
 	if err != nil {
 		return nil, err
 	}
 
-	certRequest := fulcioCertRequest{
-		PublicKeyRequest: publicKeyRequest{
-			PublicKey: publicKey{
-				Algorithm: keypair.GetKeyAlgorithm(),
-				Content:   keypairPem,
-			},
-			ProofOfPossession: base64.StdEncoding.EncodeToString(subjectSignature),
-		},
+	claims, err := f.validateIDToken(req.IdentityToken)
+	if err != nil {
+		return nil, err
 	}
-
-	requestJSON, err := json.Marshal(&certRequest)
+	requestJSON, err := marshalCertificateRequest(keyAlgorithm, string(publicKeyPEM), req.ProofOfPossession)
 	if err != nil {
 		return nil, err
 	}
+	return f.requestSigningCertificate(claims, req.IdentityToken, requestJSON)
+}
+
+// publicKeyAlgorithm returns the algorithm of a public key, as named in
+// Fulcio certificate requests.
+func publicKeyAlgorithm(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return "RSA", nil
+	case *ecdsa.PublicKey:
+		return "ECDSA", nil
+	case ed25519.PublicKey:
+		return "ED25519", nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// requestSigningCertificate sends a certificate request to Fulcio.
+func (f *Fulcio) requestSigningCertificate(claims *IDTokenClaims, identityToken string, requestJSON []byte) (*SigningCertificate, error) {
 	requestBytes := bytes.NewBuffer(requestJSON)
 
 	// TODO: For now we are using our own HTTP client
 	//
 	// https://github.com/sigstore/fulcio/pkg/api's client could be used in the
 	// future, when it supports the v2 API
-	var client http.Client
-	if f.options.Timeout != 0 {
-		client.Timeout = f.options.Timeout
+	ctx := context.Background()
+	if f.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.config.timeout)
+		defer cancel()
 	}
 
-	request, err := http.NewRequest("POST", f.options.BaseURL+"/api/v2/signingCert", requestBytes)
+	request, err := http.NewRequestWithContext(ctx, "POST", f.baseURL+"/api/v2/signingCert", requestBytes)
 	if err != nil {
 		return nil, err
 	}
 	request.Header.Add("Authorization", "Bearer "+identityToken)
 	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add("User-Agent", constructUserAgent(f.options.LibraryVersion))
 
-	response, err := client.Do(request)
+	logger := util.Logger(f.config.logger)
+	logger.Debug("requesting signing certificate from Fulcio", "url", request.URL.String(), "issuer", claims.Issuer, "subject", claims.ExpectedSubjectAlternativeName())
+	start := time.Now()
+	response, err := f.config.client().Do(request)
 	if err != nil {
+		logger.Debug("Fulcio request failed", "url", request.URL.String(), "error", err)
 		return nil, err
 	}
+	logger.Debug("Fulcio responded", "status", response.StatusCode, "duration", time.Since(start))
 
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
@@ -162,15 +269,85 @@ func (f *Fulcio) GetCertificate(keypair Keypair, identityToken string) ([]byte,
 		return nil, err
 	}
 
+	var signingCert SigningCertificate
 	certs := fulcioResp.SctCertWithChain.Chain.Certificates
+	if len(certs) == 0 {
+		certs = fulcioResp.SctCertDetached.Chain.Certificates
+		signingCert.DetachedSCT = fulcioResp.SctCertDetached.SignedCertificateTimestamp
+		logger.Debug("Fulcio returned a certificate with a detached SCT")
+	}
 	if len(certs) == 0 {
 		return nil, errors.New("Fulcio returned no certificates")
 	}
 
-	certBlock, _ := pem.Decode([]byte(certs[0]))
-	if certBlock == nil {
-		return nil, errors.New("unable to parse Fulcio certificate")
+	for i, cert := range certs {
+		certBlock, _ := pem.Decode([]byte(cert))
+		if certBlock == nil {
+			return nil, errors.New("unable to parse Fulcio certificate")
+		}
+		if i == 0 {
+			signingCert.Certificate = certBlock.Bytes
+		} else {
+			signingCert.Chain = append(signingCert.Chain, certBlock.Bytes)
+		}
+	}
+
+	return &signingCert, nil
+}
+
+// certificateRequest validates the identity token and returns its claims,
+// together with the JSON body of a certificate request for the keypair.
+func (f *Fulcio) certificateRequest(keypair Keypair, identityToken string) (*IDTokenClaims, []byte, error) {
+	claims, err := f.validateIDToken(identityToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sign JWT subject for proof of possession
+	subjectSignature, _, err := keypair.SignData([]byte(claims.Subject))
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return certBlock.Bytes, nil
+	// Make Fulcio certificate request
+	keypairPem, err := keypair.GetPublicKeyPem()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requestJSON, err := marshalCertificateRequest(keypair.GetKeyAlgorithm(), keypairPem, subjectSignature)
+	if err != nil {
+		return nil, nil, err
+	}
+	return claims, requestJSON, nil
+}
+
+// validateIDToken returns the claims of the identity token, failing if they
+// are invalid.
+//
+// Note that the contents of this token are untrusted. Fulcio will perform
+// the token verification; we only check the claims here to fail early with
+// an actionable error.
+func (f *Fulcio) validateIDToken(identityToken string) (*IDTokenClaims, error) {
+	claims, err := ParseIDToken(identityToken)
+	if err != nil {
+		return nil, err
+	}
+	if err = claims.Validate(f.config.idTokenValidation); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func marshalCertificateRequest(keyAlgorithm, publicKeyPEM string, proofOfPossession []byte) ([]byte, error) {
+	certRequest := fulcioCertRequest{
+		PublicKeyRequest: publicKeyRequest{
+			PublicKey: publicKey{
+				Algorithm: keyAlgorithm,
+				Content:   publicKeyPEM,
+			},
+			ProofOfPossession: base64.StdEncoding.EncodeToString(proofOfPossession),
+		},
+	}
+	return json.Marshal(&certRequest)
 }
@@ -0,0 +1,151 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+func Test_FulcioDetachedSCT(t *testing.T) {
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("leaf")}))
+	sct := []byte(`{"sct_version":0}`)
+
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		resp := fulcioResponse{
+			SctCertDetached: signedCertificateDetachedSct{
+				Chain:                      chain{Certificates: []string{certPEM}},
+				SignedCertificateTimestamp: sct,
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := makeIDToken(fmt.Sprintf(`{"sub":"1234","aud":"sigstore","exp":%d}`, exp))
+
+	fulcio := NewFulcio(&FulcioOptions{BaseURL: server.URL})
+	signingCert, err := fulcio.GetSigningCertificate(keypair, token)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("leaf"), signingCert.Certificate)
+	assert.Equal(t, sct, signingCert.DetachedSCT)
+
+	assert.Equal(t, "sigstore-go", userAgent)
+
+	fulcio = NewFulcio(&FulcioOptions{
+		BaseURL:        server.URL,
+		LibraryVersion: "1.0.0",
+		UserAgent:      (&util.UserAgent{}).WithProduct("my-app", "2.1"),
+	})
+	cert, err := fulcio.GetCertificate(keypair, token)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("leaf"), cert)
+	assert.Equal(t, "sigstore-go/1.0.0 my-app/2.1", userAgent)
+
+	// The detached SCT is kept in the bundle, and in its JSON encoding on
+	// request
+	pb, err := Bundle(&PlainData{Data: []byte("hello")}, keypair, BundleOptions{Fulcio: fulcio, IDToken: token})
+	require.NoError(t, err)
+	detachedSCTs, err := bundle.GetDetachedSCTs(pb)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{sct}, detachedSCTs)
+	bundleJSON, err := MarshalBundleJSON(pb, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, string(bundleJSON), "detachedScts")
+	bundleJSON, err = MarshalBundleJSON(pb, &BundleJSONOptions{DetachedSCTs: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(bundleJSON), `,"detachedScts":["`+base64.StdEncoding.EncodeToString(sct)+`"]}`)
+}
+
+func TestFulcioRequestCertificate(t *testing.T) {
+	leafPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("leaf")}))
+	rootPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("root")}))
+
+	var certRequest fulcioCertRequest
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&certRequest)
+		resp := fulcioResponse{
+			SctCertWithChain: signedCertificateEmbeddedSct{
+				Chain: chain{Certificates: []string{leafPEM, rootPEM}},
+			},
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	// The private key is only used to prove possession, as a KMS would
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	exp := time.Now().Add(time.Hour).Unix()
+	token := makeIDToken(fmt.Sprintf(`{"sub":"1234","aud":"sigstore","exp":%d}`, exp))
+	claims, err := ParseIDToken(token)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(claims.Subject))
+	proof, err := key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+
+	fulcio, err := NewFulcioClient(server.URL)
+	require.NoError(t, err)
+	signingCert, err := fulcio.RequestCertificate(CertificateRequest{
+		IdentityToken:     token,
+		PublicKey:         key.Public(),
+		ProofOfPossession: proof,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("leaf"), signingCert.Certificate)
+	assert.Equal(t, [][]byte{[]byte("root")}, signingCert.Chain)
+	assert.Nil(t, signingCert.DetachedSCT)
+
+	assert.Equal(t, "Bearer "+token, authorization)
+	assert.Equal(t, "ECDSA", certRequest.PublicKeyRequest.PublicKey.Algorithm)
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(certRequest.PublicKeyRequest.PublicKey.Content))
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(pub))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(proof), certRequest.PublicKeyRequest.ProofOfPossession)
+
+	_, err = fulcio.RequestCertificate(CertificateRequest{IdentityToken: token, PublicKey: key.Public()})
+	assert.ErrorContains(t, err, "proof of possession")
+	_, err = fulcio.RequestCertificate(CertificateRequest{IdentityToken: token, PublicKey: "key", ProofOfPossession: proof})
+	assert.ErrorContains(t, err, "unsupported public key type")
+	expired := makeIDToken(fmt.Sprintf(`{"sub":"1234","aud":"sigstore","exp":%d}`, time.Now().Add(-time.Hour).Unix()))
+	_, err = fulcio.RequestCertificate(CertificateRequest{IdentityToken: expired, PublicKey: key.Public(), ProofOfPossession: proof})
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// CertificateChainPolicy chooses which of the certificates issued by Fulcio
+// are included in a bundle.
+type CertificateChainPolicy int
+
+const (
+	// CertificateChainLeafOnly includes only the leaf certificate, as
+	// required by v0.3 bundles. Verifiers find the rest of the chain in
+	// their trusted root.
+	CertificateChainLeafOnly CertificateChainPolicy = iota
+	// CertificateChainFull includes the leaf certificate followed by the
+	// intermediate and root certificates returned by Fulcio. As v0.3
+	// bundles cannot hold a certificate chain, the bundle is a v0.2 bundle.
+	CertificateChainFull
+)
+
+func (p CertificateChainPolicy) String() string {
+	switch p {
+	case CertificateChainLeafOnly:
+		return "leaf-only"
+	case CertificateChainFull:
+		return "full"
+	default:
+		return fmt.Sprintf("CertificateChainPolicy(%d)", int(p))
+	}
+}
+
+// verificationMaterial returns the verification material for a certificate
+// issued by Fulcio under the policy, and the media type of bundles that can
+// hold it.
+func (p CertificateChainPolicy) verificationMaterial(signingCert *SigningCertificate) (*protobundle.VerificationMaterial, string, error) {
+	switch p {
+	case CertificateChainLeafOnly:
+		return &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_Certificate{
+				Certificate: &protocommon.X509Certificate{
+					RawBytes: signingCert.Certificate,
+				},
+			},
+		}, bundleV03MediaType, nil
+	case CertificateChainFull:
+		if len(signingCert.Chain) == 0 {
+			return nil, "", errors.New("Fulcio did not return the certificate chain of the signing certificate")
+		}
+		certs := []*protocommon.X509Certificate{{RawBytes: signingCert.Certificate}}
+		for _, cert := range signingCert.Chain {
+			certs = append(certs, &protocommon.X509Certificate{RawBytes: cert})
+		}
+		mediaType, err := bundle.MediaTypeString("0.2")
+		if err != nil {
+			return nil, "", err
+		}
+		return &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_X509CertificateChain{
+				X509CertificateChain: &protocommon.X509CertificateChain{
+					Certificates: certs,
+				},
+			},
+		}, mediaType, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported certificate chain policy %s", p)
+	}
+}
+
+// verifySigningCertificate checks that a certificate issued by Fulcio
+// chains to a Fulcio certificate authority in the trusted material that was
+// valid when the certificate was issued. If the bundle includes the full
+// chain, the chain must also be the one in the trusted material, so that the
+// bundle verifies against it.
+func verifySigningCertificate(signingCert *SigningCertificate, policy CertificateChainPolicy, trustedMaterial root.TrustedMaterial) error {
+	leaf, err := x509.ParseCertificate(signingCert.Certificate)
+	if err != nil {
+		return fmt.Errorf("failed to parse signing certificate: %w", err)
+	}
+	expected := append([][]byte{signingCert.Certificate}, signingCert.Chain...)
+
+	for _, ca := range trustedMaterial.FulcioCertificateAuthorities() {
+		if !ca.ValidityPeriodStart.IsZero() && leaf.NotBefore.Before(ca.ValidityPeriodStart) {
+			continue
+		}
+		if !ca.ValidityPeriodEnd.IsZero() && leaf.NotBefore.After(ca.ValidityPeriodEnd) {
+			continue
+		}
+
+		roots, intermediates := ca.CertPools()
+		chains, err := leaf.Verify(x509.VerifyOptions{
+			CurrentTime:   leaf.NotBefore,
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		})
+		if err != nil {
+			continue
+		}
+		if policy != CertificateChainFull {
+			return nil
+		}
+		for _, verified := range chains {
+			if chainEqual(verified, expected) {
+				return nil
+			}
+		}
+	}
+
+	if policy == CertificateChainFull {
+		return errors.New("certificate chain returned by Fulcio does not match a certificate authority in the trusted material")
+	}
+	return errors.New("signing certificate does not chain to a certificate authority in the trusted material")
+}
+
+func chainEqual(certs []*x509.Certificate, expected [][]byte) bool {
+	if len(certs) != len(expected) {
+		return false
+	}
+	for i, cert := range certs {
+		if !bytes.Equal(cert.Raw, expected[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+)
+
+type testFulcioCA struct {
+	root             *x509.Certificate
+	intermediate     *x509.Certificate
+	intermediateKey  *ecdsa.PrivateKey
+	returnChainCerts bool
+}
+
+func newTestFulcioCA(t *testing.T) *testFulcioCA {
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	require.NoError(t, err)
+	intermediate, intermediateKey, err := ca.GenerateFulcioIntermediate(rootCert, rootKey)
+	require.NoError(t, err)
+	return &testFulcioCA{root: rootCert, intermediate: intermediate, intermediateKey: intermediateKey, returnChainCerts: true}
+}
+
+func (f *testFulcioCA) trustedMaterial() root.TrustedMaterial {
+	return &testFulcioTrustedMaterial{cas: []root.CertificateAuthority{{
+		Root:                f.root,
+		Intermediates:       []*x509.Certificate{f.intermediate},
+		ValidityPeriodStart: time.Now().Add(-time.Hour),
+	}}}
+}
+
+func (f *testFulcioCA) server(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		leaf, err := ca.GenerateLeafCert("user@example.com", "https://issuer.example.com", time.Now(), leafKey, f.intermediate, f.intermediateKey)
+		require.NoError(t, err)
+
+		certs := []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}))}
+		if f.returnChainCerts {
+			for _, cert := range []*x509.Certificate{f.intermediate, f.root} {
+				certs = append(certs, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+			}
+		}
+		_ = json.NewEncoder(w).Encode(fulcioResponse{
+			SctCertWithChain: signedCertificateEmbeddedSct{Chain: chain{Certificates: certs}},
+		})
+	}))
+}
+
+type testFulcioTrustedMaterial struct {
+	root.BaseTrustedMaterial
+	cas []root.CertificateAuthority
+}
+
+func (tm *testFulcioTrustedMaterial) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	return tm.cas
+}
+
+func Test_BundleCertificateChain(t *testing.T) {
+	fulcioCA := newTestFulcioCA(t)
+	server := fulcioCA.server(t)
+	defer server.Close()
+
+	keypair, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	content := &PlainData{Data: []byte("qwerty")}
+	token := makeIDToken(fmt.Sprintf(`{"sub":"1234","aud":"sigstore","exp":%d}`, time.Now().Add(time.Hour).Unix()))
+	v02MediaType, err := bundle.MediaTypeString("0.2")
+	require.NoError(t, err)
+
+	t.Run("leaf only", func(t *testing.T) {
+		pb, err := Bundle(content, keypair, BundleOptions{
+			Fulcio:          NewFulcio(&FulcioOptions{BaseURL: server.URL}),
+			IDToken:         token,
+			TrustedMaterial: fulcioCA.trustedMaterial(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, bundleV03MediaType, pb.MediaType)
+		assert.NotNil(t, pb.VerificationMaterial.GetCertificate())
+		assert.Nil(t, pb.VerificationMaterial.GetX509CertificateChain())
+	})
+
+	t.Run("full chain", func(t *testing.T) {
+		pb, err := Bundle(content, keypair, BundleOptions{
+			Fulcio:           NewFulcio(&FulcioOptions{BaseURL: server.URL}),
+			IDToken:          token,
+			CertificateChain: CertificateChainFull,
+			TrustedMaterial:  fulcioCA.trustedMaterial(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, v02MediaType, pb.MediaType)
+		certs := pb.VerificationMaterial.GetX509CertificateChain().GetCertificates()
+		require.Len(t, certs, 3)
+		assert.Equal(t, fulcioCA.intermediate.Raw, certs[1].RawBytes)
+		assert.Equal(t, fulcioCA.root.Raw, certs[2].RawBytes)
+
+		_, err = bundle.NewProtobufBundle(pb)
+		assert.NoError(t, err)
+	})
+
+	t.Run("untrusted certificate authority", func(t *testing.T) {
+		for _, policy := range []CertificateChainPolicy{CertificateChainLeafOnly, CertificateChainFull} {
+			_, err := Bundle(content, keypair, BundleOptions{
+				Fulcio:           NewFulcio(&FulcioOptions{BaseURL: server.URL}),
+				IDToken:          token,
+				CertificateChain: policy,
+				TrustedMaterial:  newTestFulcioCA(t).trustedMaterial(),
+			})
+			assert.Error(t, err, policy.String())
+		}
+	})
+
+	t.Run("chain not returned", func(t *testing.T) {
+		leafOnlyCA := *fulcioCA
+		leafOnlyCA.returnChainCerts = false
+		leafOnlyServer := leafOnlyCA.server(t)
+		defer leafOnlyServer.Close()
+
+		_, err := Bundle(content, keypair, BundleOptions{
+			Fulcio:           NewFulcio(&FulcioOptions{BaseURL: leafOnlyServer.URL}),
+			IDToken:          token,
+			CertificateChain: CertificateChainFull,
+		})
+		assert.Error(t, err)
+
+		// The leaf still verifies against the trusted material's chain
+		pb, err := Bundle(content, keypair, BundleOptions{
+			Fulcio:          NewFulcio(&FulcioOptions{BaseURL: leafOnlyServer.URL}),
+			IDToken:         token,
+			TrustedMaterial: leafOnlyCA.trustedMaterial(),
+		})
+		require.NoError(t, err)
+		assert.NotNil(t, pb.VerificationMaterial.GetCertificate())
+	})
+
+	t.Run("chain differs from trusted material", func(t *testing.T) {
+		// A trusted root with the intermediate as its root, as in a
+		// distribution of trust material that trusts only the intermediate
+		tm := &testFulcioTrustedMaterial{cas: []root.CertificateAuthority{{
+			Root:                fulcioCA.intermediate,
+			ValidityPeriodStart: time.Now().Add(-time.Hour),
+		}}}
+		_, err := Bundle(content, keypair, BundleOptions{
+			Fulcio:          NewFulcio(&FulcioOptions{BaseURL: server.URL}),
+			IDToken:         token,
+			TrustedMaterial: tm,
+		})
+		assert.NoError(t, err)
+
+		_, err = Bundle(content, keypair, BundleOptions{
+			Fulcio:           NewFulcio(&FulcioOptions{BaseURL: server.URL}),
+			IDToken:          token,
+			CertificateChain: CertificateChainFull,
+			TrustedMaterial:  tm,
+		})
+		assert.Error(t, err)
+	})
+}
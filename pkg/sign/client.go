@@ -0,0 +1,206 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+// defaultRekorRetries is the number of times requests to Rekor are retried
+// unless WithRetries is given, matching the Rekor client's default.
+const defaultRekorRetries = 3
+
+// ClientOption configures a client created by NewFulcioClient, NewRekorClient
+// or NewTimestampAuthorityClient.
+type ClientOption func(*clientConfig) error
+
+type clientConfig struct {
+	// timeout for each request, including retries; zero means the
+	// service client's default
+	timeout        time.Duration
+	retries        int
+	httpClient     *http.Client
+	libraryVersion string
+	userAgent      *util.UserAgent
+	logger         *slog.Logger
+	// idTokenValidation is only used by Fulcio
+	idTokenValidation *IDTokenValidationOptions
+	// baseURL and failoverURLs are the endpoints requests are sent to
+	baseURL        string
+	failoverURLs   []string
+	circuitBreaker *CircuitBreaker
+}
+
+// WithTimeout sets the time allowed for each request to the service,
+// including any retries. The timeout must be positive.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be positive, got %s", timeout)
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetries sets the number of times a request that fails with a
+// connection error or a retryable status code, such as 503, is retried.
+// By default, requests to Rekor are retried 3 times and requests to other
+// services are not retried.
+func WithRetries(retries int) ClientOption {
+	return func(c *clientConfig) error {
+		if retries < 0 {
+			return fmt.Errorf("retries must not be negative, got %d", retries)
+		}
+		c.retries = retries
+		return nil
+	}
+}
+
+// WithClient sets the HTTP client used to send requests, for example to
+// configure a proxy or TLS settings. Any timeout set on the client applies
+// to each attempt, while WithTimeout applies to the request as a whole.
+func WithClient(client *http.Client) ClientOption {
+	return func(c *clientConfig) error {
+		if client == nil {
+			return errors.New("HTTP client must not be nil")
+		}
+		c.httpClient = client
+		return nil
+	}
+}
+
+// WithLibraryVersion sets the version string included in the User-Agent
+// header.
+func WithLibraryVersion(version string) ClientOption {
+	return func(c *clientConfig) error {
+		c.libraryVersion = version
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent configuration, allowing an application
+// to add its own product token or to hide version information.
+func WithUserAgent(userAgent *util.UserAgent) ClientOption {
+	return func(c *clientConfig) error {
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithLogger sets the logger for debug logs of requests.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *clientConfig) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithIDTokenValidation sets the options for the pre-flight identity token
+// validation performed before contacting Fulcio. It may only be given to
+// NewFulcioClient.
+func WithIDTokenValidation(opts *IDTokenValidationOptions) ClientOption {
+	return func(c *clientConfig) error {
+		if opts == nil {
+			return errors.New("identity token validation options must not be nil")
+		}
+		c.idTokenValidation = opts
+		return nil
+	}
+}
+
+// newClientConfig validates the base URL of a service, and applies the
+// options on top of the given default number of retries.
+func newClientConfig(service, baseURL string, retries int, opts []ClientOption) (clientConfig, error) {
+	config := clientConfig{retries: retries, baseURL: baseURL}
+
+	if err := validateServiceURL(baseURL); err != nil {
+		return config, fmt.Errorf("invalid %s URL: %w", service, err)
+	}
+
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return config, fmt.Errorf("failed to configure %s client: %w", service, err)
+		}
+	}
+	if len(config.failoverURLs) > 0 && config.circuitBreaker == nil {
+		config.circuitBreaker, _ = NewCircuitBreaker(0, 0)
+	}
+	return config, nil
+}
+
+func validateServiceURL(serviceURL string) error {
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q must be an absolute http or https URL", serviceURL)
+	}
+	return nil
+}
+
+// client returns the HTTP client to send requests with, which sets the
+// User-Agent header, fails over to other endpoints and retries failed
+// requests.
+func (c *clientConfig) client() *http.Client {
+	client := &http.Client{}
+	if c.httpClient != nil {
+		*client = *c.httpClient
+	}
+	client.Transport = &userAgentTransport{
+		userAgent: util.ResolveUserAgent(c.userAgent, c.libraryVersion),
+		next:      client.Transport,
+	}
+	if c.circuitBreaker != nil {
+		// The URLs were validated by newClientConfig
+		if failover, err := newFailoverTransport(c.baseURL, c.failoverURLs, c.circuitBreaker, util.Logger(c.logger), client.Transport); err == nil {
+			client.Transport = failover
+		}
+	}
+
+	if c.retries > 0 {
+		retryableClient := retryablehttp.NewClient()
+		retryableClient.HTTPClient = client
+		retryableClient.RetryMax = c.retries
+		retryableClient.Logger = util.Logger(c.logger)
+		client = retryableClient.StandardClient()
+	}
+	return client
+}
+
+type userAgentTransport struct {
+	userAgent string
+	next      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return next.RoundTrip(req)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptions(t *testing.T) {
+	fulcio, err := NewFulcioClient("https://fulcio.example.com", WithTimeout(time.Minute), WithIDTokenValidation(&IDTokenValidationOptions{}))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, fulcio.config.timeout)
+	assert.Equal(t, 0, fulcio.config.retries)
+
+	rekor, err := NewRekorClient("https://rekor.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRekorRetries, rekor.config.retries)
+
+	rekor, err = NewRekorClient("https://rekor.example.com", WithRetries(0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rekor.config.retries)
+
+	for name, opts := range map[string][]ClientOption{
+		"zero timeout":     {WithTimeout(0)},
+		"negative timeout": {WithTimeout(-time.Second)},
+		"negative retries": {WithRetries(-1)},
+		"nil client":       {WithClient(nil)},
+	} {
+		_, err = NewTimestampAuthorityClient("https://tsa.example.com/api/v1/timestamp", opts...)
+		assert.Error(t, err, name)
+	}
+
+	_, err = NewTimestampAuthorityClient("https://tsa.example.com/api/v1/timestamp", WithIDTokenValidation(&IDTokenValidationOptions{}))
+	assert.Error(t, err)
+
+	_, err = NewRekorClient("")
+	assert.Error(t, err)
+	_, err = NewRekorClient("rekor.example.com")
+	assert.Error(t, err)
+
+	// The deprecated options treat a negative timeout like an unset one
+	assert.Equal(t, time.Duration(0), NewTimestampAuthority(&TimestampAuthorityOptions{Timeout: -time.Second}).config.timeout)
+	assert.Equal(t, defaultRekorRetries, NewRekor(&RekorOptions{}).config.retries)
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientRetries(t *testing.T) {
+	var userAgent string
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	config, err := newClientConfig("test", server.URL, 0, []ClientOption{
+		WithClient(&http.Client{Transport: transport}),
+		WithRetries(1),
+		WithLibraryVersion("1.0.0"),
+	})
+	assert.NoError(t, err)
+
+	resp, err := config.client().Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, transport.requests)
+	assert.Equal(t, "sigstore-go/1.0.0", userAgent)
+}
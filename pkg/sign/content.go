@@ -15,20 +15,49 @@
 package sign
 
 import (
-	"fmt"
+	"bytes"
+	"errors"
 
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
 )
 
+// Content is something that can be signed and placed into a bundle.
+//
+// Bundle calls PreAuthEncoding to get the bytes to sign, signs them with the
+// Keypair, and then calls Content.Bundle to record the signature as the
+// bundle's content. The transparency log entry type, if any, is chosen from
+// the kind of content that was recorded.
+//
+// Implementations outside this package can support new kinds of content, such
+// as git commits or OCI image manifests, by deciding what bytes are signed and
+// how they are presented. In most cases this means embedding PlainData (to
+// produce a MessageSignature over the raw bytes) or DSSEData (to produce a
+// DSSE envelope with a given payload type), and overriding PreAuthEncoding or
+// Bundle where the content's encoding differs.
 type Content interface {
-	// Return the data to be signed
+	// PreAuthEncoding returns the exact bytes that are passed to
+	// Keypair.SignData. For DSSE content this must be the DSSE
+	// pre-authentication encoding of the payload, not the payload itself.
 	PreAuthEncoding() []byte
-	// Add something that satisfies protobundle.isBundle_Content to bundle
+	// Bundle records the signature in bundle.Content. Since a bundle's
+	// content must be either a MessageSignature or a DSSE envelope,
+	// implementations must set one of protobundle.Bundle_MessageSignature or
+	// protobundle.Bundle_DsseEnvelope.
+	//
+	// signature is the signature over PreAuthEncoding, and digest is the
+	// digest of PreAuthEncoding computed with hashAlgorithm.
 	Bundle(bundle *protobundle.Bundle, signature, digest []byte, hashAlgorithm protocommon.HashAlgorithm)
 }
 
+// Ensure types implement interfaces
+var _ Content = &PlainData{}
+var _ Content = &DSSEData{}
+
+// PlainData is content that is signed as-is, and recorded in the bundle as
+// a MessageSignature.
 type PlainData struct {
 	Data []byte
 }
@@ -49,14 +78,15 @@ func (pd *PlainData) Bundle(bundle *protobundle.Bundle, signature, digest []byte
 	}
 }
 
+// DSSEData is content that is wrapped in a DSSE envelope with the given
+// payload type, e.g. "application/vnd.in-toto+json" for in-toto statements.
 type DSSEData struct {
 	Data        []byte
 	PayloadType string
 }
 
 func (d *DSSEData) PreAuthEncoding() []byte {
-	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(d.PayloadType), d.PayloadType, len(d.Data), d.Data)
-	return []byte(pae)
+	return dsse.PAE(d.PayloadType, d.Data)
 }
 
 func (d *DSSEData) Bundle(bundle *protobundle.Bundle, signature, _ []byte, _ protocommon.HashAlgorithm) {
@@ -72,3 +102,22 @@ func (d *DSSEData) Bundle(bundle *protobundle.Bundle, signature, _ []byte, _ pro
 		},
 	}
 }
+
+// ErrPreAuthEncodingMismatch is returned by Bundle when
+// BundleOptions.VerifyDSSEPreAuthEncoding is set and the content signed is
+// not the pre-authentication encoding of the DSSE envelope it recorded.
+var ErrPreAuthEncodingMismatch = errors.New("signed bytes are not the DSSE pre-authentication encoding of the envelope")
+
+// verifyPreAuthEncoding checks that the bytes content signed are the DSSE
+// v1 pre-authentication encoding of the envelope recorded in the bundle.
+// Bundles with a MessageSignature are not checked.
+func verifyPreAuthEncoding(bundle *protobundle.Bundle, content Content) error {
+	envelope := bundle.GetDsseEnvelope()
+	if envelope == nil {
+		return nil
+	}
+	if !bytes.Equal(content.PreAuthEncoding(), dsse.PAE(envelope.PayloadType, envelope.Payload)) {
+		return ErrPreAuthEncodingMismatch
+	}
+	return nil
+}
@@ -42,9 +42,39 @@ func Test_DSSEData(t *testing.T) {
 
 	pae := dsseData.PreAuthEncoding()
 	assert.True(t, strings.HasPrefix(string(pae), "DSSE"))
+	assert.Equal(t, "DSSEv1 9 something 6 qwerty", string(pae))
 
 	bundle := &protobundle.Bundle{}
 	dsseData.Bundle(bundle, data, data, protocommon.HashAlgorithm_SHA2_256)
 	assert.Nil(t, bundle.GetMessageSignature())
 	assert.NotNil(t, bundle.GetDsseEnvelope())
 }
+
+// rawPayloadContent signs a DSSE payload itself rather than its
+// pre-authentication encoding, as a buggy custom content type might.
+type rawPayloadContent struct {
+	DSSEData
+}
+
+func (c *rawPayloadContent) PreAuthEncoding() []byte {
+	return c.Data
+}
+
+func Test_VerifyDSSEPreAuthEncoding(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+	opts := BundleOptions{VerifyDSSEPreAuthEncoding: true}
+
+	_, err = Bundle(&DSSEData{Data: data, PayloadType: "something"}, keypair, opts)
+	assert.NoError(t, err)
+	_, err = Bundle(&PlainData{Data: data}, keypair, opts)
+	assert.NoError(t, err)
+
+	raw := &rawPayloadContent{DSSEData{Data: data, PayloadType: "something"}}
+	_, err = Bundle(raw, keypair, opts)
+	assert.ErrorIs(t, err, ErrPreAuthEncodingMismatch)
+
+	// The check is opt-in
+	_, err = Bundle(raw, keypair, BundleOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/sign"
+)
+
+// manifestContent is a custom content type, implemented outside of the
+// sign package, that signs a manifest as a DSSE envelope with a fixed
+// payload type.
+type manifestContent struct {
+	sign.DSSEData
+}
+
+func newManifestContent(manifest []byte) *manifestContent {
+	return &manifestContent{sign.DSSEData{Data: manifest, PayloadType: "application/vnd.oci.image.manifest.v1+json"}}
+}
+
+func Test_CustomContent(t *testing.T) {
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	var content sign.Content = newManifestContent([]byte(`{"schemaVersion":2}`))
+	bundle, err := sign.Bundle(content, keypair, sign.BundleOptions{})
+	assert.NoError(t, err)
+
+	envelope := bundle.GetDsseEnvelope()
+	assert.NotNil(t, envelope)
+	assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", envelope.PayloadType)
+	assert.Equal(t, []byte(`{"schemaVersion":2}`), envelope.Payload)
+}
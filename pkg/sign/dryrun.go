@@ -0,0 +1,110 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+)
+
+// DryRunReport describes the requests that Bundle would have made, had
+// BundleOptions.DryRun not been set.
+type DryRunReport struct {
+	// IDToken holds the claims of the identity token, which have been
+	// validated as they would be before contacting Fulcio, or nil if no
+	// Fulcio instance was configured
+	IDToken *IDTokenClaims
+	// Fulcio is the certificate request, or nil if no Fulcio instance was
+	// configured. Its body includes the proof of possession, but not the
+	// identity token, which is sent as a header.
+	Fulcio *DryRunRequest
+	// TimestampAuthorities are the DER-encoded RFC 3161 timestamp requests,
+	// one per timestamp authority that would be asked for a timestamp
+	TimestampAuthorities []DryRunRequest
+	// Rekors are the JSON-encoded proposed entries, one per Rekor instance.
+	// Without a certificate from Fulcio, the entries are rendered with the
+	// keypair's public key where the certificate would otherwise be.
+	Rekors []DryRunRequest
+	// Witnesses is the number of witnesses that would have been called
+	Witnesses int
+}
+
+// DryRunRequest is a request that was prepared, but not sent.
+type DryRunRequest struct {
+	URL  string
+	Body []byte
+}
+
+// dryRun validates the options and prepares the requests for a signed
+// bundle, without sending them. The bundle is given public key verification
+// material, which a certificate from Fulcio would otherwise replace.
+func dryRun(bundle *protobundle.Bundle, signature []byte, keypair Keypair, opts BundleOptions) (*DryRunReport, error) {
+	report := &DryRunReport{Witnesses: len(opts.Witnesses)}
+
+	if opts.Fulcio != nil {
+		if opts.Fulcio.baseURL == "" {
+			return nil, errors.New("Fulcio URL must be provided")
+		}
+		claims, requestJSON, err := opts.Fulcio.certificateRequest(keypair, opts.IDToken)
+		if err != nil {
+			return nil, err
+		}
+		report.IDToken = claims
+		report.Fulcio = &DryRunRequest{URL: opts.Fulcio.baseURL + "/api/v2/signingCert", Body: requestJSON}
+	}
+
+	bundle.VerificationMaterial = publicKeyVerificationMaterial(keypair)
+
+	timestampThreshold := opts.timestampAuthorityThreshold()
+	if timestampThreshold > len(opts.TimestampAuthorities) {
+		return nil, fmt.Errorf("timestamp authority threshold %d is greater than the number of timestamp authorities, %d", timestampThreshold, len(opts.TimestampAuthorities))
+	}
+	for _, timestampAuthority := range opts.TimestampAuthorities[:timestampThreshold] {
+		if timestampAuthority.baseURL == "" {
+			return nil, errors.New("timestamp authority URL must be provided")
+		}
+		_, reqBytes, err := timestampRequest(signature)
+		if err != nil {
+			return nil, err
+		}
+		report.TimestampAuthorities = append(report.TimestampAuthorities, DryRunRequest{URL: timestampAuthority.baseURL, Body: reqBytes})
+	}
+
+	if len(opts.Rekors) > 0 {
+		pubKeyPEM, err := keypair.GetPublicKeyPem()
+		if err != nil {
+			return nil, err
+		}
+		proposedEntry, err := newProposedEntry([]byte(pubKeyPEM), bundle)
+		if err != nil {
+			return nil, err
+		}
+		entryJSON, err := json.Marshal(proposedEntry)
+		if err != nil {
+			return nil, err
+		}
+		for _, rekor := range opts.Rekors {
+			if rekor.baseURL == "" {
+				return nil, errors.New("Rekor URL must be provided")
+			}
+			report.Rekors = append(report.Rekors, DryRunRequest{URL: rekor.baseURL + "/api/v1/log/entries", Body: entryJSON})
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BundleDryRun(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	content := &PlainData{Data: []byte("qwerty")}
+
+	// None of these URLs are reachable, so any request would fail
+	exp := time.Now().Add(time.Hour).Unix()
+	token := makeIDToken(fmt.Sprintf(`{"iss":"https://accounts.example.com","sub":"1234","email":"jdoe@example.com","aud":"sigstore","exp":%d}`, exp))
+	opts := BundleOptions{
+		Fulcio:               NewFulcio(&FulcioOptions{BaseURL: "http://127.0.0.1:0"}),
+		IDToken:              token,
+		TimestampAuthorities: []*TimestampAuthority{NewTimestampAuthority(&TimestampAuthorityOptions{BaseURL: "http://127.0.0.1:0/api/v1/timestamp"})},
+		Rekors:               []*Rekor{NewRekor(&RekorOptions{BaseURL: "http://127.0.0.1:0"})},
+		Witnesses:            []Witness{&testWitness{err: errors.New("witness called")}},
+		DryRun:               true,
+	}
+
+	result, err := BundleWithResult(content, keypair, opts)
+	require.NoError(t, err)
+	report := result.DryRun
+	require.NotNil(t, report)
+
+	assert.Equal(t, "jdoe@example.com", report.IDToken.ExpectedSubjectAlternativeName())
+	assert.Equal(t, "http://127.0.0.1:0/api/v2/signingCert", report.Fulcio.URL)
+	var certRequest fulcioCertRequest
+	require.NoError(t, json.Unmarshal(report.Fulcio.Body, &certRequest))
+	assert.Equal(t, "ECDSA", certRequest.PublicKeyRequest.PublicKey.Algorithm)
+
+	require.Len(t, report.TimestampAuthorities, 1)
+	tsReq, err := timestamp.ParseRequest(report.TimestampAuthorities[0].Body)
+	require.NoError(t, err)
+	assert.Len(t, tsReq.HashedMessage, 32)
+
+	require.Len(t, report.Rekors, 1)
+	assert.Equal(t, "http://127.0.0.1:0/api/v1/log/entries", report.Rekors[0].URL)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(report.Rekors[0].Body, &entry))
+	assert.Equal(t, "hashedrekord", entry["kind"])
+	assert.Equal(t, 1, report.Witnesses)
+
+	assert.NotNil(t, result.Bundle.GetMessageSignature())
+	assert.NotNil(t, result.Bundle.GetVerificationMaterial().GetPublicKey())
+	assert.Nil(t, result.Certificate)
+	assert.Empty(t, result.TransparencyLogEntries)
+	assert.Empty(t, result.Timestamps)
+
+	// The identity token is still validated
+	opts.IDToken = makeIDToken(`{"sub":"1234","aud":"sigstore","exp":1700000000}`)
+	_, err = Bundle(content, keypair, opts)
+	assert.True(t, errors.Is(err, ErrIDTokenExpired))
+
+	// as is the configuration
+	opts.IDToken = token
+	opts.TimestampAuthorityThreshold = 2
+	_, err = Bundle(content, keypair, opts)
+	assert.ErrorContains(t, err, "threshold")
+}
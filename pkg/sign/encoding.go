@@ -0,0 +1,90 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"encoding/json"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// BundleJSONOptions configures how MarshalBundleJSON encodes a bundle.
+type BundleJSONOptions struct {
+	// Optional string used for each level of indentation. If empty, the
+	// bundle is encoded as compact JSON.
+	Indent string
+	// If true, the bundle's annotations, if any, are added as an
+	// "annotations" member, after the members defined by the bundle
+	// format and with sorted keys. Other Sigstore clients may reject such
+	// bundles, so annotations are omitted by default.
+	Annotations bool
+	// If true, the bundle's detached SCTs, if any, are added as a
+	// "detachedScts" member, after the members defined by the bundle
+	// format. Other Sigstore clients may reject such bundles, so detached
+	// SCTs are omitted by default; see bundle.SetDetachedSCTs.
+	DetachedSCTs bool
+}
+
+// MarshalBundleJSON encodes a bundle as JSON, producing the same bytes every
+// time for the same bundle.
+//
+// protojson deliberately varies its whitespace between runs, so its output
+// can't be used where bundles need to be reproduced byte-for-byte, e.g. for
+// reproducible-build attestations. Fields are emitted in protobuf field
+// order and byte fields, including the DSSE payload and signatures, are
+// encoded as padded standard base64.
+func MarshalBundleJSON(b *protobundle.Bundle, opts *BundleJSONOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &BundleJSONOptions{}
+	}
+
+	bundleJSON, err := protojson.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = json.Compact(&buf, bundleJSON)
+	if err != nil {
+		return nil, err
+	}
+	compacted := buf.Bytes()
+	if opts.DetachedSCTs {
+		compacted, err = bundle.AppendDetachedSCTsJSON(compacted, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Annotations {
+		compacted, err = bundle.AppendAnnotationsJSON(compacted, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Indent == "" {
+		return compacted, nil
+	}
+
+	var indented bytes.Buffer
+	err = json.Indent(&indented, compacted, "", opts.Indent)
+	if err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func Test_MarshalBundleJSON(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	content := &DSSEData{Data: []byte(`{"_type":"https://in-toto.io/Statement/v1"}`), PayloadType: "application/vnd.in-toto+json"}
+	bundle, err := Bundle(content, keypair, BundleOptions{})
+	assert.NoError(t, err)
+
+	compact, err := MarshalBundleJSON(bundle, nil)
+	assert.NoError(t, err)
+
+	// repeated encodings are byte-identical
+	for i := 0; i < 10; i++ {
+		again, err := MarshalBundleJSON(bundle, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, compact, again)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, json.Compact(&buf, compact))
+	assert.Equal(t, buf.Bytes(), compact)
+
+	indented, err := MarshalBundleJSON(bundle, &BundleJSONOptions{Indent: "  "})
+	assert.NoError(t, err)
+	assert.Contains(t, string(indented), "\n  \"mediaType\"")
+
+	// both encodings round-trip to the same bundle
+	for _, b := range [][]byte{compact, indented} {
+		var decoded protobundle.Bundle
+		assert.NoError(t, protojson.Unmarshal(b, &decoded))
+		assert.True(t, proto.Equal(bundle, &decoded))
+	}
+}
+
+func Test_MarshalBundleJSONAnnotations(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	annotations := map[string]string{"buildID": "1234", "pipeline": "https://ci.example.com/run/1"}
+	pb, err := Bundle(&PlainData{Data: []byte("hello")}, keypair, BundleOptions{Annotations: annotations})
+	assert.NoError(t, err)
+
+	// Annotations are omitted by default
+	encoded, err := MarshalBundleJSON(pb, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "annotations")
+
+	encoded, err = MarshalBundleJSON(pb, &BundleJSONOptions{Annotations: true})
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `,"annotations":{"buildID":"1234","pipeline":"https://ci.example.com/run/1"}}`)
+
+	for _, opts := range []*BundleJSONOptions{{Annotations: true}, {Indent: "  ", Annotations: true}} {
+		encoded, err := MarshalBundleJSON(pb, opts)
+		assert.NoError(t, err)
+		var decoded bundle.ProtobufBundle
+		assert.NoError(t, decoded.UnmarshalJSON(encoded))
+		got, err := decoded.Annotations()
+		assert.NoError(t, err)
+		assert.Equal(t, annotations, got)
+	}
+}
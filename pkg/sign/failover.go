@@ -0,0 +1,274 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCircuitBreakerFailureThreshold is the number of consecutive
+	// failures after which a CircuitBreaker skips an endpoint by default
+	DefaultCircuitBreakerFailureThreshold = 3
+	// DefaultCircuitBreakerCooldown is how long a CircuitBreaker skips an
+	// endpoint for by default
+	DefaultCircuitBreakerCooldown = 30 * time.Second
+)
+
+// CircuitBreaker tracks the health of service endpoints, so that clients
+// with failover URLs skip endpoints that keep failing. An endpoint is
+// skipped once it fails the failure threshold number of times in a row,
+// until the cooldown has passed; it is then tried again, and skipped for
+// another cooldown if it fails.
+//
+// A CircuitBreaker is safe for concurrent use, and may be shared by the
+// clients of a process so that they all learn which endpoints are down.
+type CircuitBreaker struct {
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointHealth
+}
+
+type endpointHealth struct {
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+// NewCircuitBreaker returns a circuit breaker that skips an endpoint for
+// cooldown after failureThreshold consecutive failures. Zero values mean
+// DefaultCircuitBreakerFailureThreshold and DefaultCircuitBreakerCooldown.
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) (*CircuitBreaker, error) {
+	if failureThreshold < 0 {
+		return nil, fmt.Errorf("failure threshold must not be negative, got %d", failureThreshold)
+	}
+	if cooldown < 0 {
+		return nil, fmt.Errorf("cooldown must not be negative, got %s", cooldown)
+	}
+	if failureThreshold == 0 {
+		failureThreshold = DefaultCircuitBreakerFailureThreshold
+	}
+	if cooldown == 0 {
+		cooldown = DefaultCircuitBreakerCooldown
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		now:              time.Now,
+		endpoints:        make(map[string]*endpointHealth),
+	}, nil
+}
+
+// Available reports whether requests should be sent to the endpoint: it
+// has not failed too often, or its cooldown has passed.
+func (cb *CircuitBreaker) Available(endpoint string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	health, ok := cb.endpoints[endpoint]
+	return !ok || !cb.now().Before(health.openUntil)
+}
+
+// RecordSuccess records that a request to the endpoint succeeded, which
+// closes its circuit.
+func (cb *CircuitBreaker) RecordSuccess(endpoint string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.endpoints, endpoint)
+}
+
+// RecordFailure records that a request to the endpoint failed, opening its
+// circuit if it has failed too often.
+func (cb *CircuitBreaker) RecordFailure(endpoint string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	health, ok := cb.endpoints[endpoint]
+	if !ok {
+		health = &endpointHealth{}
+		cb.endpoints[endpoint] = health
+	}
+	health.consecutiveFailures++
+	if health.consecutiveFailures >= cb.failureThreshold {
+		health.openUntil = cb.now().Add(cb.cooldown)
+	}
+}
+
+// WithFailoverURLs sets the base URLs of replicas of the service, such as
+// deployments in other regions, which are tried in order when a request to
+// the client's base URL fails with a connection error, including a failure
+// to resolve its host name, or with a 429 or 5xx status code. The URLs
+// must have the same form as the base URL. Endpoints that keep failing are
+// skipped, as tracked by the client's CircuitBreaker.
+//
+// Failover stops when the request's context is done. It may only be given
+// to NewRekorClient and NewTimestampAuthorityClient.
+func WithFailoverURLs(urls ...string) ClientOption {
+	return func(c *clientConfig) error {
+		for _, u := range urls {
+			if err := validateServiceURL(u); err != nil {
+				return fmt.Errorf("invalid failover URL: %w", err)
+			}
+		}
+		c.failoverURLs = append(c.failoverURLs, urls...)
+		return nil
+	}
+}
+
+// WithCircuitBreaker sets the circuit breaker tracking the health of the
+// client's endpoints. By default, each client with failover URLs has its
+// own circuit breaker, with the default threshold and cooldown.
+func WithCircuitBreaker(cb *CircuitBreaker) ClientOption {
+	return func(c *clientConfig) error {
+		if cb == nil {
+			return errors.New("circuit breaker must not be nil")
+		}
+		c.circuitBreaker = cb
+		return nil
+	}
+}
+
+// failoverTransport sends requests for the base URL to the first available
+// endpoint, failing over to the next one on connection errors and server
+// errors.
+type failoverTransport struct {
+	baseURL        *url.URL
+	endpoints      []*url.URL
+	circuitBreaker *CircuitBreaker
+	logger         *slog.Logger
+	next           http.RoundTripper
+}
+
+func newFailoverTransport(baseURL string, failoverURLs []string, cb *CircuitBreaker, logger *slog.Logger, next http.RoundTripper) (*failoverTransport, error) {
+	t := &failoverTransport{circuitBreaker: cb, logger: logger, next: next}
+	var err error
+	if t.baseURL, err = url.Parse(baseURL); err != nil {
+		return nil, err
+	}
+	t.endpoints = append(t.endpoints, t.baseURL)
+	for _, u := range failoverURLs {
+		endpoint, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		t.endpoints = append(t.endpoints, endpoint)
+	}
+	return t, nil
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if req.URL.Scheme != t.baseURL.Scheme || req.URL.Host != t.baseURL.Host {
+		return next.RoundTrip(req)
+	}
+
+	// The body is sent to each endpoint tried
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	endpoints := t.orderedEndpoints()
+	var resp *http.Response
+	var err error
+	for i, endpoint := range endpoints {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			if err == nil {
+				err = ctxErr
+			}
+			return nil, err
+		}
+
+		attempt := req.Clone(req.Context())
+		attempt.URL = t.endpointURL(req.URL, endpoint)
+		attempt.Host = ""
+		if body != nil {
+			attempt.Body = io.NopCloser(bytes.NewReader(body))
+			attempt.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+
+		resp, err = next.RoundTrip(attempt)
+		if err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			t.circuitBreaker.RecordSuccess(endpoint.String())
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			// The endpoint is not at fault for the request being cancelled
+			return resp, err
+		}
+		t.circuitBreaker.RecordFailure(endpoint.String())
+		if i == len(endpoints)-1 {
+			break
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			_ = resp.Body.Close()
+		}
+		t.logger.Debug("failing over to next endpoint", "url", endpoint.String(), "next", endpoints[i+1].String(), "reason", reason)
+	}
+	return resp, err
+}
+
+// orderedEndpoints returns the available endpoints in order, followed by
+// the unavailable ones, which are still tried as a last resort.
+func (t *failoverTransport) orderedEndpoints() []*url.URL {
+	available := make([]*url.URL, 0, len(t.endpoints))
+	var unavailable []*url.URL
+	for _, endpoint := range t.endpoints {
+		if t.circuitBreaker.Available(endpoint.String()) {
+			available = append(available, endpoint)
+		} else {
+			unavailable = append(unavailable, endpoint)
+		}
+	}
+	return append(available, unavailable...)
+}
+
+// endpointURL returns the URL of a request for the base URL, rewritten for
+// the endpoint.
+func (t *failoverTransport) endpointURL(u *url.URL, endpoint *url.URL) *url.URL {
+	rewritten := *u
+	rewritten.Scheme = endpoint.Scheme
+	rewritten.Host = endpoint.Host
+	basePath := strings.TrimSuffix(t.baseURL.Path, "/")
+	if strings.HasPrefix(u.Path, basePath) {
+		rewritten.Path = strings.TrimSuffix(endpoint.Path, "/") + strings.TrimPrefix(u.Path, basePath)
+		rewritten.RawPath = ""
+	}
+	return &rewritten
+}
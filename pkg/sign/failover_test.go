@@ -0,0 +1,167 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatusServer(t *testing.T, status int, requests *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(r.URL.Path + " " + string(body)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFailover(t *testing.T) {
+	// Connections to a closed server are refused
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	var unavailableRequests, healthyRequests atomic.Int32
+	unavailable := newStatusServer(t, http.StatusServiceUnavailable, &unavailableRequests)
+	healthy := newStatusServer(t, http.StatusOK, &healthyRequests)
+
+	cb, err := NewCircuitBreaker(2, time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	cb.now = func() time.Time { return now }
+
+	config, err := newClientConfig("test", down.URL+"/rekor", 0, []ClientOption{
+		WithFailoverURLs(unavailable.URL+"/rekor", healthy.URL+"/other/"),
+		WithCircuitBreaker(cb),
+	})
+	require.NoError(t, err)
+	client := config.client()
+
+	post := func() string {
+		resp, err := client.Post(down.URL+"/rekor/api/v1/log/entries", "text/plain", strings.NewReader("entry"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	// The request is rewritten for the endpoint, and its body is resent
+	assert.Equal(t, "/other/api/v1/log/entries entry", post())
+	assert.Equal(t, int32(1), unavailableRequests.Load())
+	assert.True(t, cb.Available(down.URL+"/rekor"))
+
+	// After two failures, the failing endpoints are skipped
+	post()
+	assert.Equal(t, int32(2), unavailableRequests.Load())
+	assert.False(t, cb.Available(down.URL+"/rekor"))
+	assert.False(t, cb.Available(unavailable.URL+"/rekor"))
+	post()
+	assert.Equal(t, int32(2), unavailableRequests.Load())
+	assert.Equal(t, int32(3), healthyRequests.Load())
+
+	// After the cooldown, they are tried again
+	now = now.Add(time.Minute)
+	post()
+	assert.Equal(t, int32(3), unavailableRequests.Load())
+}
+
+func TestFailoverLastResort(t *testing.T) {
+	var requests atomic.Int32
+	unavailable := newStatusServer(t, http.StatusServiceUnavailable, &requests)
+	other := newStatusServer(t, http.StatusInternalServerError, &requests)
+
+	config, err := newClientConfig("test", unavailable.URL, 0, []ClientOption{WithFailoverURLs(other.URL)})
+	require.NoError(t, err)
+	client := config.client()
+
+	// With every endpoint failing, the last response is returned, and the
+	// endpoints are still tried once their circuits are open
+	for i := 1; i <= DefaultCircuitBreakerFailureThreshold+1; i++ {
+		resp, err := client.Get(unavailable.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, int32(2*i), requests.Load())
+	}
+	assert.False(t, config.circuitBreaker.Available(unavailable.URL))
+}
+
+func TestFailoverContext(t *testing.T) {
+	var requests atomic.Int32
+	healthy := newStatusServer(t, http.StatusOK, &requests)
+	cb, err := NewCircuitBreaker(1, time.Minute)
+	require.NoError(t, err)
+
+	config, err := newClientConfig("test", healthy.URL, 0, []ClientOption{WithFailoverURLs(healthy.URL + "/failover"), WithCircuitBreaker(cb)})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthy.URL, nil)
+	require.NoError(t, err)
+
+	_, err = config.client().Do(req) //nolint:bodyclose
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(0), requests.Load())
+	assert.True(t, cb.Available(healthy.URL))
+}
+
+func TestFailoverTimestampAuthority(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	tsa, err := NewTimestampAuthorityClient(down.URL+"/api/v1/timestamp", WithFailoverURLs(newTestTSA(t, false).URL+"/api/v1/timestamp"))
+	require.NoError(t, err)
+
+	_, err = tsa.GetTimestamp([]byte("signature"))
+	assert.NoError(t, err)
+}
+
+func TestFailoverOptions(t *testing.T) {
+	_, err := NewRekorClient("https://rekor.example.com", WithFailoverURLs("rekor.example.org"))
+	assert.ErrorContains(t, err, "invalid failover URL")
+	_, err = NewRekorClient("https://rekor.example.com", WithCircuitBreaker(nil))
+	assert.Error(t, err)
+	_, err = NewFulcioClient("https://fulcio.example.com", WithFailoverURLs("https://fulcio.example.org"))
+	assert.Error(t, err)
+
+	_, err = NewCircuitBreaker(-1, 0)
+	assert.Error(t, err)
+	_, err = NewCircuitBreaker(0, -time.Second)
+	assert.Error(t, err)
+
+	rekor, err := NewRekorClient("https://rekor.example.com", WithFailoverURLs("https://rekor.example.org"))
+	require.NoError(t, err)
+	assert.NotNil(t, rekor.config.circuitBreaker)
+
+	transport, err := newFailoverTransport("https://tsa.example.com/api/v1/timestamp", nil, rekor.config.circuitBreaker, nil, nil)
+	require.NoError(t, err)
+	u, err := url.Parse("https://tsa.example.com/api/v1/timestamp")
+	require.NoError(t, err)
+	endpoint, err := url.Parse("https://tsa.example.org/timestamp")
+	require.NoError(t, err)
+	assert.Equal(t, "https://tsa.example.org/timestamp", transport.endpointURL(u, endpoint).String())
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"github.com/sigstore/sigstore-go/pkg/git"
+)
+
+// GitObjectData is a git commit or tag object to be signed.
+//
+// The signature covers the object without any existing signature, which is
+// what git passes to its signing program, and is recorded in the bundle as a
+// MessageSignature. The result is a Sigstore bundle to be stored alongside
+// the object, not a signature git can embed in it: it is not compatible with
+// gitsign, whose signatures are CMS messages.
+type GitObjectData struct {
+	PlainData
+	ObjectType git.ObjectType
+}
+
+var _ Content = &GitObjectData{}
+
+// NewGitObjectData returns content for the given raw commit or tag object,
+// as output by `git cat-file commit|tag`, or as passed by git to a signing
+// program configured with gpg.x509.program.
+func NewGitObjectData(object []byte) (*GitObjectData, error) {
+	objectType, err := git.ParseObjectType(object)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := git.SignedPayload(object)
+	if err != nil {
+		return nil, err
+	}
+	return &GitObjectData{PlainData: PlainData{Data: payload}, ObjectType: objectType}, nil
+}
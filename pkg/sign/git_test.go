@@ -0,0 +1,42 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/git"
+)
+
+func Test_GitObjectData(t *testing.T) {
+	tag := "object 2a1c8a1c0d9b1ce3b7ab0f4c0b6d1e3f1cbd2f0a\ntype commit\ntag v1.0.0\ntagger Jane Doe <jane@example.com> 1700000000 +0000\n\nRelease v1.0.0\n"
+	signature := "-----BEGIN SIGNED MESSAGE-----\nMIIE\n-----END SIGNED MESSAGE-----\n"
+
+	content, err := NewGitObjectData([]byte(tag + signature))
+	assert.NoError(t, err)
+	assert.Equal(t, git.ObjectTypeTag, content.ObjectType)
+	assert.Equal(t, []byte(tag), content.PreAuthEncoding())
+
+	bundle := &protobundle.Bundle{}
+	content.Bundle(bundle, data, data, protocommon.HashAlgorithm_SHA2_256)
+	assert.NotNil(t, bundle.GetMessageSignature())
+
+	_, err = NewGitObjectData([]byte("not a git object"))
+	assert.Error(t, err)
+}
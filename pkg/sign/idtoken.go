@@ -0,0 +1,198 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultIDTokenAudience is the audience Fulcio expects identity tokens to
+// be issued for by default.
+const DefaultIDTokenAudience = "sigstore"
+
+var (
+	ErrIDTokenMalformed      = errors.New("malformed identity token")
+	ErrIDTokenExpired        = errors.New("identity token expired")
+	ErrIDTokenNotYetValid    = errors.New("identity token not yet valid")
+	ErrIDTokenAudience       = errors.New("identity token audience mismatch")
+	ErrIDTokenMissingSubject = errors.New("identity token has no subject")
+)
+
+// IDTokenClaims holds the claims of an OIDC identity token that are relevant
+// to requesting a code signing certificate.
+//
+// The claims are parsed without verifying the token's signature; Fulcio
+// performs the token verification. They are only used to catch mistakes
+// before a request is sent.
+type IDTokenClaims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Audience      []string
+	ExpiresAt     time.Time
+	NotBefore     time.Time
+	IssuedAt      time.Time
+}
+
+// IDTokenValidationOptions configures IDTokenClaims.Validate.
+type IDTokenValidationOptions struct {
+	// Audience the token must be issued for, e.g. DefaultIDTokenAudience.
+	// Optional; if empty, the audience is left for Fulcio to check, as
+	// deployments may be configured to accept other audiences.
+	Audience string
+	// Tolerated difference between the local clock and the issuer's clock.
+	ClockSkew time.Duration
+	// Time to validate the token against. Defaults to the current time.
+	CurrentTime time.Time
+}
+
+type idTokenJSON struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	Audience      json.RawMessage `json:"aud"`
+	ExpiresAt     *json.Number    `json:"exp"`
+	NotBefore     *json.Number    `json:"nbf"`
+	IssuedAt      *json.Number    `json:"iat"`
+}
+
+// ParseIDToken decodes the claims of a compact-serialized JWT.
+func ParseIDToken(identityToken string) (*IDTokenClaims, error) {
+	tokenParts := strings.Split(identityToken, ".")
+	if len(tokenParts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrIDTokenMalformed, len(tokenParts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(tokenParts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIDTokenMalformed, err)
+	}
+
+	var raw idTokenJSON
+	if err = json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIDTokenMalformed, err)
+	}
+
+	claims := &IDTokenClaims{
+		Issuer:  raw.Issuer,
+		Subject: raw.Subject,
+		Email:   raw.Email,
+	}
+
+	// Some issuers encode email_verified as a string
+	switch string(raw.EmailVerified) {
+	case "true", `"true"`:
+		claims.EmailVerified = true
+	}
+
+	if len(raw.Audience) > 0 {
+		var aud string
+		if err = json.Unmarshal(raw.Audience, &aud); err == nil {
+			claims.Audience = []string{aud}
+		} else if err = json.Unmarshal(raw.Audience, &claims.Audience); err != nil {
+			return nil, fmt.Errorf("%w: invalid aud claim", ErrIDTokenMalformed)
+		}
+	}
+
+	for _, c := range []struct {
+		name  string
+		value *json.Number
+		out   *time.Time
+	}{
+		{"exp", raw.ExpiresAt, &claims.ExpiresAt},
+		{"nbf", raw.NotBefore, &claims.NotBefore},
+		{"iat", raw.IssuedAt, &claims.IssuedAt},
+	} {
+		if c.value == nil {
+			continue
+		}
+		seconds, err := c.value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s claim", ErrIDTokenMalformed, c.name)
+		}
+		*c.out = time.Unix(0, int64(seconds*float64(time.Second)))
+	}
+
+	return claims, nil
+}
+
+// ExpectedSubjectAlternativeName returns the identity Fulcio is expected to
+// encode in the certificate's SAN: the email address when the token carries
+// one, otherwise the token subject.
+//
+// This is a best-effort prediction; some issuers (for example CI providers)
+// are mapped to URI SANs derived from other claims.
+func (c *IDTokenClaims) ExpectedSubjectAlternativeName() string {
+	if c.Email != "" {
+		return c.Email
+	}
+	return c.Subject
+}
+
+// ExpectedIssuer returns the OIDC issuer Fulcio will record in the
+// certificate's issuer extension.
+func (c *IDTokenClaims) ExpectedIssuer() string {
+	return c.Issuer
+}
+
+// Validate checks that the token is currently valid and, if an audience is
+// configured, that it was issued for that audience, returning an actionable
+// error otherwise.
+func (c *IDTokenClaims) Validate(opts *IDTokenValidationOptions) error {
+	if opts == nil {
+		opts = &IDTokenValidationOptions{}
+	}
+
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	if c.Subject == "" {
+		return ErrIDTokenMissingSubject
+	}
+
+	if !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt.Add(opts.ClockSkew)) {
+		return fmt.Errorf("%w %s ago (at %s)", ErrIDTokenExpired, roundDuration(now.Sub(c.ExpiresAt)), c.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	if !c.NotBefore.IsZero() && now.Add(opts.ClockSkew).Before(c.NotBefore) {
+		return fmt.Errorf("%w for another %s (at %s)", ErrIDTokenNotYetValid, roundDuration(c.NotBefore.Sub(now)), c.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	if opts.Audience == "" {
+		return nil
+	}
+	for _, aud := range c.Audience {
+		if aud == opts.Audience {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: expected %q, token was issued for %q", ErrIDTokenAudience, opts.Audience, c.Audience)
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d > time.Minute {
+		return d.Round(time.Minute)
+	}
+	return d.Round(time.Second)
+}
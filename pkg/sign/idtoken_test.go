@@ -0,0 +1,104 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func makeIDToken(payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func Test_ParseIDToken(t *testing.T) {
+	token := makeIDToken(`{"iss":"https://accounts.example.com","sub":"1234","email":"jdoe@example.com","email_verified":"true","aud":"sigstore","exp":1700000300,"iat":1700000000}`)
+
+	claims, err := ParseIDToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://accounts.example.com", claims.ExpectedIssuer())
+	assert.Equal(t, "jdoe@example.com", claims.ExpectedSubjectAlternativeName())
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, []string{"sigstore"}, claims.Audience)
+	assert.Equal(t, time.Unix(1700000300, 0), claims.ExpiresAt)
+
+	claims, err = ParseIDToken(makeIDToken(`{"sub":"repo:foo/bar","aud":["other","sigstore"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "repo:foo/bar", claims.ExpectedSubjectAlternativeName())
+	assert.Equal(t, []string{"other", "sigstore"}, claims.Audience)
+
+	_, err = ParseIDToken("not-a-token")
+	assert.True(t, errors.Is(err, ErrIDTokenMalformed))
+
+	_, err = ParseIDToken("a.!!!.c")
+	assert.True(t, errors.Is(err, ErrIDTokenMalformed))
+
+	_, err = ParseIDToken(makeIDToken(`{"sub":"1234","aud":1}`))
+	assert.True(t, errors.Is(err, ErrIDTokenMalformed))
+}
+
+func Test_IDTokenClaimsValidate(t *testing.T) {
+	issued := time.Unix(1700000000, 0)
+	claims := &IDTokenClaims{
+		Subject:   "1234",
+		Audience:  []string{"sigstore"},
+		IssuedAt:  issued,
+		NotBefore: issued,
+		ExpiresAt: issued.Add(5 * time.Minute),
+	}
+
+	assert.NoError(t, claims.Validate(&IDTokenValidationOptions{CurrentTime: issued.Add(time.Minute)}))
+
+	err := claims.Validate(&IDTokenValidationOptions{CurrentTime: issued.Add(8 * time.Minute)})
+	assert.True(t, errors.Is(err, ErrIDTokenExpired))
+	assert.Contains(t, err.Error(), "identity token expired 3m0s ago")
+
+	// Clock skew tolerance
+	assert.NoError(t, claims.Validate(&IDTokenValidationOptions{CurrentTime: issued.Add(6 * time.Minute), ClockSkew: 2 * time.Minute}))
+
+	err = claims.Validate(&IDTokenValidationOptions{CurrentTime: issued.Add(-30 * time.Second)})
+	assert.True(t, errors.Is(err, ErrIDTokenNotYetValid))
+	assert.Contains(t, err.Error(), "for another 30s")
+
+	err = claims.Validate(&IDTokenValidationOptions{CurrentTime: issued, Audience: "other"})
+	assert.True(t, errors.Is(err, ErrIDTokenAudience))
+	assert.NoError(t, claims.Validate(&IDTokenValidationOptions{CurrentTime: issued, Audience: DefaultIDTokenAudience}))
+
+	// Audience is only checked when configured
+	claims.Audience = []string{"other"}
+	assert.NoError(t, claims.Validate(&IDTokenValidationOptions{CurrentTime: issued}))
+	claims.Audience = nil
+	assert.NoError(t, claims.Validate(&IDTokenValidationOptions{CurrentTime: issued}))
+
+	claims.Subject = ""
+	assert.True(t, errors.Is(claims.Validate(&IDTokenValidationOptions{CurrentTime: issued}), ErrIDTokenMissingSubject))
+}
+
+func Test_FulcioRejectsExpiredToken(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	// BaseURL is unreachable; the token must be rejected before any request
+	fulcio := NewFulcio(&FulcioOptions{BaseURL: "http://127.0.0.1:0"})
+	token := makeIDToken(`{"sub":"1234","aud":"sigstore","exp":1700000000}`)
+
+	_, err = fulcio.GetCertificate(keypair, token)
+	assert.True(t, errors.Is(err, ErrIDTokenExpired))
+}
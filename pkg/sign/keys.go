@@ -19,14 +19,15 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha256"
 	_ "crypto/sha512" // if user chooses SHA2-384 or SHA2-512 for hash
-	"crypto/x509"
-	"encoding/base64"
 	"errors"
+	"fmt"
 
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/util"
 )
 
 type Keypair interface {
@@ -40,6 +41,9 @@ type Keypair interface {
 type EphemeralKeypairOptions struct {
 	// Optional hint of for signing key
 	Hint []byte
+	// Optional scheme computing the hint when Hint is not set. Defaults to
+	// root.SHA256SPKIKeyHint
+	HintScheme root.KeyHintScheme
 	// TODO: support additional key algorithms
 }
 
@@ -60,12 +64,10 @@ func NewEphemeralKeypair(opts *EphemeralKeypairOptions) (*EphemeralKeypair, erro
 	}
 
 	if opts.Hint == nil {
-		pubKeyBytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		opts.Hint, err = keyHint(privateKey.Public(), opts.HintScheme)
 		if err != nil {
 			return nil, err
 		}
-		hashedBytes := sha256.Sum256(pubKeyBytes)
-		opts.Hint = []byte(base64.StdEncoding.EncodeToString(hashedBytes[:]))
 	}
 
 	ephemeralKeypair := EphemeralKeypair{
@@ -77,6 +79,19 @@ func NewEphemeralKeypair(opts *EphemeralKeypairOptions) (*EphemeralKeypair, erro
 	return &ephemeralKeypair, nil
 }
 
+// keyHint computes the hint of a keypair's public key under scheme, or
+// root.SHA256SPKIKeyHint if scheme is nil.
+func keyHint(pub crypto.PublicKey, scheme root.KeyHintScheme) ([]byte, error) {
+	if scheme == nil {
+		scheme = root.SHA256SPKIKeyHint
+	}
+	hint, err := scheme(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key hint: %w", err)
+	}
+	return []byte(hint), nil
+}
+
 func (e *EphemeralKeypair) GetHashAlgorithm() protocommon.HashAlgorithm {
 	return e.hashAlgorithm
 }
@@ -89,6 +104,12 @@ func (e *EphemeralKeypair) GetKeyAlgorithm() string {
 	return "ECDSA"
 }
 
+// GetKeyDetails returns the key details value describing the key and the
+// signature algorithm used with it.
+func (e *EphemeralKeypair) GetKeyDetails() protocommon.PublicKeyDetails {
+	return protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256
+}
+
 func (e *EphemeralKeypair) GetPublicKeyPem() (string, error) {
 	pubKeyBytes, err := cryptoutils.MarshalPublicKeyToPEM(e.privateKey.Public())
 	if err != nil {
@@ -126,6 +147,10 @@ func (e *EphemeralKeypair) SignData(data []byte) ([]byte, []byte, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	signature, err = util.NormalizeECDSASignature(signature, e.privateKey.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	return signature, digest, nil
 }
@@ -18,7 +18,10 @@ import (
 	"testing"
 
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
 )
 
 func Test_EphemeralKeypair(t *testing.T) {
@@ -53,3 +56,31 @@ func Test_EphemeralKeypair(t *testing.T) {
 	hint = defaultEphemeralKeypair.GetHint()
 	assert.NotEqual(t, hint, []byte(""))
 }
+
+func Test_EphemeralKeypairHintScheme(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(&EphemeralKeypairOptions{HintScheme: root.SubjectKeyIDKeyHint})
+	assert.NoError(t, err)
+	pem, err := keypair.GetPublicKeyPem()
+	assert.NoError(t, err)
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(pem))
+	assert.NoError(t, err)
+	expected, err := root.SubjectKeyIDKeyHint(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(expected), keypair.GetHint())
+
+	// An explicit hint takes precedence over the scheme
+	keypair, err = NewEphemeralKeypair(&EphemeralKeypairOptions{Hint: []byte("asdf"), HintScheme: root.SubjectKeyIDKeyHint})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("asdf"), keypair.GetHint())
+
+	// The default hint is unchanged
+	keypair, err = NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+	pem, err = keypair.GetPublicKeyPem()
+	assert.NoError(t, err)
+	pub, err = cryptoutils.UnmarshalPEMToPublicKey([]byte(pem))
+	assert.NoError(t, err)
+	expected, err = root.SHA256SPKIKeyHint(pub)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(expected), keypair.GetHint())
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/sha256"
+	"encoding/pem"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/mldsa"
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+type MLDSAKeypairOptions struct {
+	// Optional hint of for signing key
+	Hint []byte
+	// Optional scheme computing the hint when Hint is not set. Defaults to
+	// root.SHA256SPKIKeyHint
+	HintScheme root.KeyHintScheme
+	// Optional parameter set; defaults to mldsa.MLDSA65
+	ParameterSet mldsa.ParameterSet
+}
+
+// MLDSAKeypair is an experimental ephemeral ML-DSA keypair. ML-DSA must be
+// enabled with mldsa.Enable before it is used.
+//
+// ML-DSA signs the content itself rather than its digest, so bundles signed
+// with an MLDSAKeypair can only be verified with the artifact.
+type MLDSAKeypair struct {
+	options    *MLDSAKeypairOptions
+	privateKey mldsa.PrivateKey
+}
+
+func NewMLDSAKeypair(opts *MLDSAKeypairOptions) (*MLDSAKeypair, error) {
+	if opts == nil {
+		opts = &MLDSAKeypairOptions{}
+	}
+	if opts.ParameterSet == "" {
+		opts.ParameterSet = mldsa.MLDSA65
+	}
+
+	backend, err := mldsa.Enabled()
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := backend.GenerateKey(opts.ParameterSet)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Hint == nil {
+		opts.Hint, err = keyHint(privateKey.Public(), opts.HintScheme)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &MLDSAKeypair{options: opts, privateKey: privateKey}, nil
+}
+
+// GetHashAlgorithm returns the algorithm of the digest recorded in message
+// signature bundles; the signature itself is over the content.
+func (m *MLDSAKeypair) GetHashAlgorithm() protocommon.HashAlgorithm {
+	return protocommon.HashAlgorithm_SHA2_256
+}
+
+func (m *MLDSAKeypair) GetHint() []byte {
+	return m.options.Hint
+}
+
+func (m *MLDSAKeypair) GetKeyAlgorithm() string {
+	return string(m.options.ParameterSet)
+}
+
+func (m *MLDSAKeypair) GetPublicKeyPem() (string, error) {
+	pubKeyBytes, err := mldsa.MarshalPKIXPublicKey(m.privateKey.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubKeyBytes})), nil
+}
+
+// PublicKey returns the keypair's public key, e.g. to build trusted
+// material for verification.
+func (m *MLDSAKeypair) PublicKey() *mldsa.PublicKey {
+	return m.privateKey.Public()
+}
+
+func (m *MLDSAKeypair) SignData(data []byte) ([]byte, []byte, error) {
+	signature, err := m.privateKey.Sign(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := sha256.Sum256(data)
+	return signature, digest[:], nil
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// InTotoStatementV01 is the statement type of npm publish attestations.
+	InTotoStatementV01 = "https://in-toto.io/Statement/v0.1"
+	// NpmPublishPredicateType is the predicate type of the attestation a
+	// registry signs when a package version is published.
+	NpmPublishPredicateType = "https://github.com/npm/attestation/tree/main/specs/publish/v0.1"
+	// SLSAProvenanceV1PredicateType is the predicate type of npm provenance
+	// statements.
+	SLSAProvenanceV1PredicateType = "https://slsa.dev/provenance/v1"
+	// NpmRegistry is the URL of the public npm registry.
+	NpmRegistry = "https://registry.npmjs.org"
+
+	githubActionsBuildType     = "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1"
+	githubActionsBuilderPrefix = "https://github.com/actions/runner"
+)
+
+// NpmPackage identifies a published npm package version by its tarball.
+type NpmPackage struct {
+	// Name is the package name, including its scope if any, e.g.
+	// "@sigstore/sign"
+	Name    string
+	Version string
+	// SHA512 is the SHA-512 digest of the package tarball, as in the
+	// "integrity" field of the registry's package metadata
+	SHA512 []byte
+}
+
+// NewNpmPackage returns the npm package version with the tarball read from
+// r.
+func NewNpmPackage(name, version string, tarball io.Reader) (NpmPackage, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, tarball); err != nil {
+		return NpmPackage{}, fmt.Errorf("failed to read package tarball: %w", err)
+	}
+	return NpmPackage{Name: name, Version: version, SHA512: h.Sum(nil)}, nil
+}
+
+// PackageURL returns the package URL the npm registry expects as the
+// subject name of attestations, e.g. "pkg:npm/%40sigstore/sign@1.0.0".
+func (p NpmPackage) PackageURL() (string, error) {
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	return "pkg:npm/" + strings.Replace(p.Name, "@", "%40", 1) + "@" + p.Version, nil
+}
+
+func (p NpmPackage) validate() error {
+	if p.Name == "" || p.Version == "" {
+		return errors.New("npm package must have a name and version")
+	}
+	if strings.ContainsAny(p.Name+p.Version, " \t\n?#") {
+		return fmt.Errorf("invalid npm package %s@%s", p.Name, p.Version)
+	}
+	if strings.HasPrefix(p.Name, "@") {
+		scope, name, ok := strings.Cut(p.Name[1:], "/")
+		if !ok || scope == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid scoped npm package name %q", p.Name)
+		}
+	} else if strings.ContainsAny(p.Name, "@/") {
+		return fmt.Errorf("invalid npm package name %q", p.Name)
+	}
+	if strings.Contains(p.Version, "@") {
+		return fmt.Errorf("invalid npm package version %q", p.Version)
+	}
+	if len(p.SHA512) != sha512.Size {
+		return fmt.Errorf("npm package %s@%s must have a SHA-512 digest", p.Name, p.Version)
+	}
+	return nil
+}
+
+func (p NpmPackage) statementBuilder(predicateType string) *StatementBuilder {
+	b := NewStatementBuilder(predicateType)
+	purl, err := p.PackageURL()
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.AddSubjectDigest(purl, "sha512", p.SHA512)
+}
+
+type npmPublishPredicate struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Registry string `json:"registry"`
+}
+
+// NpmPublishAttestation returns the publish attestation for the package, as
+// signed by a registry with its own key when the package is published.
+// registry defaults to NpmRegistry.
+func NpmPublishAttestation(pkg NpmPackage, registry string) (*DSSEData, error) {
+	if registry == "" {
+		registry = NpmRegistry
+	}
+	b := pkg.statementBuilder(NpmPublishPredicateType).WithPredicate(npmPublishPredicate{
+		Name:     pkg.Name,
+		Version:  pkg.Version,
+		Registry: registry,
+	})
+	b.statementType = InTotoStatementV01
+	return b.DSSEData()
+}
+
+// GitHubActionsBuild describes the GitHub Actions workflow run that built
+// a package, from the environment variables of the same names.
+type GitHubActionsBuild struct {
+	ServerURL         string // GITHUB_SERVER_URL
+	Repository        string // GITHUB_REPOSITORY
+	RepositoryID      string // GITHUB_REPOSITORY_ID
+	RepositoryOwnerID string // GITHUB_REPOSITORY_OWNER_ID
+	EventName         string // GITHUB_EVENT_NAME
+	Ref               string // GITHUB_REF
+	SHA               string // GITHUB_SHA
+	WorkflowRef       string // GITHUB_WORKFLOW_REF
+	RunID             string // GITHUB_RUN_ID
+	RunAttempt        string // GITHUB_RUN_ATTEMPT
+	RunnerEnvironment string // RUNNER_ENVIRONMENT
+}
+
+// GitHubActionsBuildFromEnv reads the build from the environment of a
+// GitHub Actions workflow run, e.g. with os.Getenv.
+func GitHubActionsBuildFromEnv(getenv func(string) string) (GitHubActionsBuild, error) {
+	build := GitHubActionsBuild{
+		ServerURL:         getenv("GITHUB_SERVER_URL"),
+		Repository:        getenv("GITHUB_REPOSITORY"),
+		RepositoryID:      getenv("GITHUB_REPOSITORY_ID"),
+		RepositoryOwnerID: getenv("GITHUB_REPOSITORY_OWNER_ID"),
+		EventName:         getenv("GITHUB_EVENT_NAME"),
+		Ref:               getenv("GITHUB_REF"),
+		SHA:               getenv("GITHUB_SHA"),
+		WorkflowRef:       getenv("GITHUB_WORKFLOW_REF"),
+		RunID:             getenv("GITHUB_RUN_ID"),
+		RunAttempt:        getenv("GITHUB_RUN_ATTEMPT"),
+		RunnerEnvironment: getenv("RUNNER_ENVIRONMENT"),
+	}
+	if build.ServerURL == "" || build.Repository == "" || build.WorkflowRef == "" || build.SHA == "" || build.RunID == "" {
+		return build, errors.New("not running in a GitHub Actions workflow")
+	}
+	return build, nil
+}
+
+// workflowPathAndRef splits the workflow ref, e.g.
+// "owner/repo/.github/workflows/release.yml@refs/heads/main", into the
+// workflow's path in the repository and the ref it ran from.
+func (b GitHubActionsBuild) workflowPathAndRef() (string, string, error) {
+	workflowRef, hasRepository := strings.CutPrefix(b.WorkflowRef, b.Repository+"/")
+	workflow, ref, ok := strings.Cut(workflowRef, "@")
+	if !hasRepository || !ok || workflow == "" || ref == "" {
+		return "", "", fmt.Errorf("invalid workflow ref %q for repository %s", b.WorkflowRef, b.Repository)
+	}
+	return workflow, ref, nil
+}
+
+type slsaResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+type npmProvenancePredicate struct {
+	BuildDefinition struct {
+		BuildType          string `json:"buildType"`
+		ExternalParameters struct {
+			Workflow struct {
+				Ref        string `json:"ref"`
+				Repository string `json:"repository"`
+				Path       string `json:"path"`
+			} `json:"workflow"`
+		} `json:"externalParameters"`
+		InternalParameters struct {
+			GitHub struct {
+				EventName         string `json:"event_name"`          //nolint:tagliatelle
+				RepositoryID      string `json:"repository_id"`       //nolint:tagliatelle
+				RepositoryOwnerID string `json:"repository_owner_id"` //nolint:tagliatelle
+			} `json:"github"`
+		} `json:"internalParameters"`
+		ResolvedDependencies []slsaResourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Metadata struct {
+			InvocationID string `json:"invocationId"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// NpmProvenance returns the SLSA v1 provenance statement for a package
+// built by a GitHub Actions workflow, in the shape that npm publishes and
+// verifies. It is signed with a Fulcio certificate for the workflow's
+// identity, obtained with the workflow's GitHub Actions OIDC token.
+func NpmProvenance(pkg NpmPackage, build GitHubActionsBuild) (*DSSEData, error) {
+	workflowPath, workflowRef, err := build.workflowPathAndRef()
+	if err != nil {
+		return nil, err
+	}
+	repositoryURL := build.ServerURL + "/" + build.Repository
+
+	var predicate npmProvenancePredicate
+	definition := &predicate.BuildDefinition
+	definition.BuildType = githubActionsBuildType
+	definition.ExternalParameters.Workflow.Ref = workflowRef
+	definition.ExternalParameters.Workflow.Repository = repositoryURL
+	definition.ExternalParameters.Workflow.Path = workflowPath
+	definition.InternalParameters.GitHub.EventName = build.EventName
+	definition.InternalParameters.GitHub.RepositoryID = build.RepositoryID
+	definition.InternalParameters.GitHub.RepositoryOwnerID = build.RepositoryOwnerID
+	definition.ResolvedDependencies = []slsaResourceDescriptor{{
+		URI:    "git+" + repositoryURL + "@" + build.Ref,
+		Digest: map[string]string{"gitCommit": build.SHA},
+	}}
+	predicate.RunDetails.Builder.ID = githubActionsBuilderPrefix + "/" + build.RunnerEnvironment
+	predicate.RunDetails.Metadata.InvocationID = fmt.Sprintf("%s/actions/runs/%s/attempts/%s", repositoryURL, build.RunID, build.RunAttempt)
+
+	return pkg.statementBuilder(SLSAProvenanceV1PredicateType).WithPredicate(predicate).DSSEData()
+}
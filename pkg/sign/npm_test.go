@@ -0,0 +1,154 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func testNpmPackage(t *testing.T) NpmPackage {
+	pkg, err := NewNpmPackage("@sigstore/sign", "2.3.0", strings.NewReader("tarball"))
+	require.NoError(t, err)
+	return pkg
+}
+
+func Test_NpmPackageURL(t *testing.T) {
+	pkg := testNpmPackage(t)
+	digest := sha512.Sum512([]byte("tarball"))
+	assert.Equal(t, digest[:], pkg.SHA512)
+
+	purl, err := pkg.PackageURL()
+	assert.NoError(t, err)
+	assert.Equal(t, "pkg:npm/%40sigstore/sign@2.3.0", purl)
+
+	purl, err = NpmPackage{Name: "sigstore", Version: "1.0.0", SHA512: pkg.SHA512}.PackageURL()
+	assert.NoError(t, err)
+	assert.Equal(t, "pkg:npm/sigstore@1.0.0", purl)
+
+	for _, invalid := range []NpmPackage{
+		{Name: "", Version: "1.0.0", SHA512: pkg.SHA512},
+		{Name: "sigstore", Version: "", SHA512: pkg.SHA512},
+		{Name: "@sigstore", Version: "1.0.0", SHA512: pkg.SHA512},
+		{Name: "@/sign", Version: "1.0.0", SHA512: pkg.SHA512},
+		{Name: "sig/store", Version: "1.0.0", SHA512: pkg.SHA512},
+		{Name: "sigstore", Version: "1.0.0 beta", SHA512: pkg.SHA512},
+		{Name: "sigstore", Version: "1.0.0"},
+	} {
+		_, err = invalid.PackageURL()
+		assert.Error(t, err, "%+v", invalid)
+	}
+}
+
+func Test_NpmPublishAttestation(t *testing.T) {
+	pkg := testNpmPackage(t)
+	content, err := NpmPublishAttestation(pkg, "")
+	require.NoError(t, err)
+	assert.Equal(t, bundle.IntotoMediaType, content.PayloadType)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(content.Data, &decoded))
+	assert.Equal(t, map[string]any{
+		"_type": InTotoStatementV01,
+		"subject": []any{map[string]any{
+			"name":   "pkg:npm/%40sigstore/sign@2.3.0",
+			"digest": map[string]any{"sha512": hex.EncodeToString(pkg.SHA512)},
+		}},
+		"predicateType": NpmPublishPredicateType,
+		"predicate": map[string]any{
+			"name":     "@sigstore/sign",
+			"version":  "2.3.0",
+			"registry": NpmRegistry,
+		},
+	}, decoded)
+
+	// Registries sign publish attestations with their own key
+	keypair, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	_, err = Bundle(content, keypair, BundleOptions{})
+	assert.NoError(t, err)
+
+	_, err = NpmPublishAttestation(NpmPackage{Name: "sigstore", Version: "1.0.0"}, "")
+	assert.ErrorContains(t, err, "SHA-512")
+}
+
+func Test_NpmProvenance(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_SERVER_URL":          "https://github.com",
+		"GITHUB_REPOSITORY":          "sigstore/sigstore-js",
+		"GITHUB_REPOSITORY_ID":       "495574555",
+		"GITHUB_REPOSITORY_OWNER_ID": "71096353",
+		"GITHUB_EVENT_NAME":          "push",
+		"GITHUB_REF":                 "refs/heads/main",
+		"GITHUB_SHA":                 "26c7b9ed1ee3eb6e1ac3f0fb3b5ad94ff1cfd9c1",
+		"GITHUB_WORKFLOW_REF":        "sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main",
+		"GITHUB_RUN_ID":              "5961397045",
+		"GITHUB_RUN_ATTEMPT":         "1",
+		"RUNNER_ENVIRONMENT":         "github-hosted",
+	}
+	build, err := GitHubActionsBuildFromEnv(func(key string) string { return env[key] })
+	require.NoError(t, err)
+
+	content, err := NpmProvenance(testNpmPackage(t), build)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(content.Data, &decoded))
+	assert.Equal(t, InTotoStatementV1, decoded["_type"])
+	assert.Equal(t, SLSAProvenanceV1PredicateType, decoded["predicateType"])
+	assert.Equal(t, "pkg:npm/%40sigstore/sign@2.3.0", decoded["subject"].([]any)[0].(map[string]any)["name"])
+	assert.Equal(t, map[string]any{
+		"buildDefinition": map[string]any{
+			"buildType": "https://slsa-framework.github.io/github-actions-buildtypes/workflow/v1",
+			"externalParameters": map[string]any{
+				"workflow": map[string]any{
+					"ref":        "refs/heads/main",
+					"repository": "https://github.com/sigstore/sigstore-js",
+					"path":       ".github/workflows/release.yml",
+				},
+			},
+			"internalParameters": map[string]any{
+				"github": map[string]any{
+					"event_name":          "push",
+					"repository_id":       "495574555",
+					"repository_owner_id": "71096353",
+				},
+			},
+			"resolvedDependencies": []any{map[string]any{
+				"uri":    "git+https://github.com/sigstore/sigstore-js@refs/heads/main",
+				"digest": map[string]any{"gitCommit": "26c7b9ed1ee3eb6e1ac3f0fb3b5ad94ff1cfd9c1"},
+			}},
+		},
+		"runDetails": map[string]any{
+			"builder":  map[string]any{"id": "https://github.com/actions/runner/github-hosted"},
+			"metadata": map[string]any{"invocationId": "https://github.com/sigstore/sigstore-js/actions/runs/5961397045/attempts/1"},
+		},
+	}, decoded["predicate"])
+
+	build.WorkflowRef = "other/repo/.github/workflows/release.yml@refs/heads/main"
+	_, err = NpmProvenance(testNpmPackage(t), build)
+	assert.ErrorContains(t, err, "invalid workflow ref")
+
+	_, err = GitHubActionsBuildFromEnv(func(string) string { return "" })
+	assert.ErrorContains(t, err, "not running in a GitHub Actions workflow")
+}
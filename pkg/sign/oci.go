@@ -0,0 +1,54 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/oci"
+)
+
+// OCIImageData is a cosign simple signing payload for a container image, to
+// be signed.
+//
+// The signature covers the payload, which binds the image's manifest
+// digest, and is recorded in the bundle as a MessageSignature. When a Rekor
+// instance is provided, this results in a hashedrekord entry over the
+// payload, as with cosign sign. The bundle can be attached to the image as
+// a cosign signature layer with bundle.ProtobufBundle.CosignLayerAnnotations and
+// the payload as the layer's content.
+type OCIImageData struct {
+	PlainData
+	Payload *oci.SimpleSigningPayload
+}
+
+var _ Content = &OCIImageData{}
+
+// NewOCIImageData returns content for the image with the given manifest
+// digest, e.g. "sha256:<hex>", in the repository dockerReference, e.g.
+// "ghcr.io/sigstore/sigstore-go". optional holds annotations to sign along
+// with the image, as with cosign sign -a, and may be nil.
+func NewOCIImageData(dockerReference, manifestDigest string, optional map[string]any) (*OCIImageData, error) {
+	payload, err := oci.NewSimpleSigningPayload(dockerReference, manifestDigest, optional)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode simple signing payload: %w", err)
+	}
+	return &OCIImageData{PlainData: PlainData{Data: data}, Payload: payload}, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/oci"
+)
+
+func Test_OCIImageData(t *testing.T) {
+	digest := "sha256:4c4b5e1a2d5b3c6a8e1f0d9c7b6a5e4d3c2b1a09f8e7d6c5b4a392817263544f"
+	content, err := NewOCIImageData("ghcr.io/sigstore/sigstore-go", digest, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, content.Payload.Critical.Image.DockerManifestDigest)
+
+	payload, err := oci.ParseSimpleSigningPayload(content.PreAuthEncoding())
+	assert.NoError(t, err)
+	assert.Equal(t, content.Payload, payload)
+
+	bundle := &protobundle.Bundle{}
+	content.Bundle(bundle, data, data, protocommon.HashAlgorithm_SHA2_256)
+	assert.NotNil(t, bundle.GetMessageSignature())
+
+	_, err = NewOCIImageData("ghcr.io/sigstore/sigstore-go", "latest", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,336 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+// ErrIncorrectPassphrase is returned when an encrypted private key cannot be
+// decrypted with the passphrase provided.
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase for encrypted private key")
+
+// PassphraseFunc returns the passphrase of an encrypted private key, for
+// example by prompting the user. It is only called if the key is encrypted.
+type PassphraseFunc func() ([]byte, error)
+
+// Passphrase returns a PassphraseFunc that always returns passphrase.
+func Passphrase(passphrase []byte) PassphraseFunc {
+	return func() ([]byte, error) {
+		return passphrase, nil
+	}
+}
+
+type PrivateKeyKeypairOptions struct {
+	// Optional hint of for signing key
+	Hint []byte
+	// Optional scheme computing the hint when Hint is not set. Defaults to
+	// root.SHA256SPKIKeyHint
+	HintScheme root.KeyHintScheme
+	// Optional hash algorithm. Defaults to SHA2_256, or to SHA2_384 or
+	// SHA2_512 for ECDSA keys on P-384 or P-521.
+	HashAlgorithm protocommon.HashAlgorithm
+	// Optional: sign with RSASSA-PSS rather than PKCS #1 v1.5. Only valid
+	// for RSA keys.
+	RSAPSS bool
+	// Optional: sign the SHA-512 digest of the content with Ed25519ph
+	// rather than the content itself with pure Ed25519. Only valid for
+	// Ed25519 keys, with the SHA2_512 hash algorithm.
+	Ed25519ph bool
+}
+
+// PrivateKeyKeypair signs with an existing RSA, ECDSA or Ed25519 private
+// key, such as one loaded with LoadPrivateKeyKeypair.
+//
+// RSA keys sign with PKCS #1 v1.5. Ed25519 signs the content itself rather
+// than its digest, so message signature bundles signed with an Ed25519 key
+// can only be verified with the artifact, which must be read into memory.
+// Bundles signed with Ed25519ph can be verified with the artifact's digest
+// or by streaming the artifact, by a verifier for PKIX_ED25519_PH keys such
+// as one loaded with root.LoadVerifierWithKeyDetails.
+type PrivateKeyKeypair struct {
+	options       *PrivateKeyKeypairOptions
+	signer        crypto.Signer
+	keyAlgorithm  string
+	hashAlgorithm protocommon.HashAlgorithm
+}
+
+func NewPrivateKeyKeypair(signer crypto.Signer, opts *PrivateKeyKeypairOptions) (*PrivateKeyKeypair, error) {
+	if opts == nil {
+		opts = &PrivateKeyKeypairOptions{}
+	}
+
+	hashAlgorithm := opts.HashAlgorithm
+	var keyAlgorithm string
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		keyAlgorithm = "RSA"
+	case *ecdsa.PublicKey:
+		keyAlgorithm = "ECDSA"
+		if hashAlgorithm == protocommon.HashAlgorithm_HASH_ALGORITHM_UNSPECIFIED {
+			switch pub.Curve {
+			case elliptic.P384():
+				hashAlgorithm = protocommon.HashAlgorithm_SHA2_384
+			case elliptic.P521():
+				hashAlgorithm = protocommon.HashAlgorithm_SHA2_512
+			}
+		}
+	case ed25519.PublicKey:
+		keyAlgorithm = "ED25519"
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", pub)
+	}
+	if opts.RSAPSS && keyAlgorithm != "RSA" {
+		return nil, fmt.Errorf("RSASSA-PSS requires an RSA key, not %s", keyAlgorithm)
+	}
+	if opts.Ed25519ph {
+		if keyAlgorithm != "ED25519" {
+			return nil, fmt.Errorf("signing with Ed25519ph requires an Ed25519 key, not %s", keyAlgorithm)
+		}
+		switch hashAlgorithm {
+		case protocommon.HashAlgorithm_HASH_ALGORITHM_UNSPECIFIED:
+			hashAlgorithm = protocommon.HashAlgorithm_SHA2_512
+		case protocommon.HashAlgorithm_SHA2_512:
+		default:
+			return nil, fmt.Errorf("signing with Ed25519ph requires SHA2_512, not %s", hashAlgorithm)
+		}
+	}
+	if hashAlgorithm == protocommon.HashAlgorithm_HASH_ALGORITHM_UNSPECIFIED {
+		hashAlgorithm = protocommon.HashAlgorithm_SHA2_256
+	}
+	if _, err := getHashFunc(hashAlgorithm); err != nil {
+		return nil, err
+	}
+
+	if opts.Hint == nil {
+		hint, err := keyHint(signer.Public(), opts.HintScheme)
+		if err != nil {
+			return nil, err
+		}
+		opts.Hint = hint
+	}
+
+	return &PrivateKeyKeypair{
+		options:       opts,
+		signer:        signer,
+		keyAlgorithm:  keyAlgorithm,
+		hashAlgorithm: hashAlgorithm,
+	}, nil
+}
+
+// LoadPrivateKeyKeypair returns a keypair for a PEM-encoded private key.
+// Encrypted keys are decrypted with the passphrase returned by passphrase,
+// which may be nil if the key is not encrypted.
+//
+// The following PEM blocks are supported:
+//
+//   - "ENCRYPTED PRIVATE KEY": PKCS #8 encrypted with PBES2, using PBKDF2
+//     and AES-CBC or 3DES, as written by "openssl pkcs8 -topk8"
+//   - "ENCRYPTED SIGSTORE PRIVATE KEY" and "ENCRYPTED COSIGN PRIVATE KEY",
+//     as written by cosign
+//   - "RSA PRIVATE KEY" and "EC PRIVATE KEY", optionally encrypted with the
+//     legacy OpenSSL "Proc-Type: 4,ENCRYPTED" headers
+//   - "PRIVATE KEY": unencrypted PKCS #8
+func LoadPrivateKeyKeypair(pemBytes []byte, passphrase PassphraseFunc, opts *PrivateKeyKeypairOptions) (*PrivateKeyKeypair, error) {
+	privateKey, err := ParsePrivateKeyPEM(pemBytes, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	return NewPrivateKeyKeypair(signer, opts)
+}
+
+// LoadPrivateKeyKeypairFromPath is like LoadPrivateKeyKeypair, but reads the
+// key from a file.
+func LoadPrivateKeyKeypairFromPath(path string, passphrase PassphraseFunc, opts *PrivateKeyKeypairOptions) (*PrivateKeyKeypair, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPrivateKeyKeypair(pemBytes, passphrase, opts)
+}
+
+// ParsePrivateKeyPEM parses a PEM-encoded private key in any of the formats
+// supported by LoadPrivateKeyKeypair.
+func ParsePrivateKeyPEM(pemBytes []byte, passphrase PassphraseFunc) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	getPassphrase := func() ([]byte, error) {
+		if passphrase == nil {
+			return nil, errors.New("private key is encrypted, but no passphrase was provided")
+		}
+		return passphrase()
+	}
+
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		password, err := getPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		der, err := decryptPKCS8(block.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			// Rarely, a wrong passphrase results in valid padding
+			return nil, fmt.Errorf("%w: %w", ErrIncorrectPassphrase, err)
+		}
+		return privateKey, nil
+	case string(cryptoutils.EncryptedSigstorePrivateKeyPEMType), "ENCRYPTED COSIGN PRIVATE KEY":
+		password, err := getPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := cryptoutils.UnmarshalPEMToPrivateKey(pemBytes, func(bool) ([]byte, error) {
+			return password, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrIncorrectPassphrase, err)
+		}
+		return privateKey, nil
+	case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY":
+		der := block.Bytes
+		if x509.IsEncryptedPEMBlock(block) { //nolint:staticcheck
+			password, err := getPassphrase()
+			if err != nil {
+				return nil, err
+			}
+			// Legacy PEM encryption is insecure, but is still written by
+			// older versions of OpenSSL, so keys must be readable
+			der, err = x509.DecryptPEMBlock(block, password) //nolint:staticcheck
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrIncorrectPassphrase, err)
+			}
+		}
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(der)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(der)
+		default:
+			return x509.ParsePKCS8PrivateKey(der)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key PEM type %q", block.Type)
+	}
+}
+
+func (p *PrivateKeyKeypair) GetHashAlgorithm() protocommon.HashAlgorithm {
+	return p.hashAlgorithm
+}
+
+func (p *PrivateKeyKeypair) GetHint() []byte {
+	return p.options.Hint
+}
+
+func (p *PrivateKeyKeypair) GetKeyAlgorithm() string {
+	return p.keyAlgorithm
+}
+
+// GetKeyDetails returns the key details value describing the key and the
+// signature algorithm used with it, or PUBLIC_KEY_DETAILS_UNSPECIFIED if
+// there is no such value, for example for RSA keys used with SHA-512.
+func (p *PrivateKeyKeypair) GetKeyDetails() protocommon.PublicKeyDetails {
+	switch pub := p.signer.Public().(type) {
+	case *rsa.PublicKey:
+		if p.hashAlgorithm == protocommon.HashAlgorithm_SHA2_256 {
+			return root.RSAKeyDetails(pub, p.options.RSAPSS)
+		}
+	case *ecdsa.PublicKey:
+		switch {
+		case pub.Curve == elliptic.P256() && p.hashAlgorithm == protocommon.HashAlgorithm_SHA2_256:
+			return protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256
+		case pub.Curve == elliptic.P384() && p.hashAlgorithm == protocommon.HashAlgorithm_SHA2_384:
+			return protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384
+		case pub.Curve == elliptic.P521() && p.hashAlgorithm == protocommon.HashAlgorithm_SHA2_512:
+			return protocommon.PublicKeyDetails_PKIX_ECDSA_P521_SHA_512
+		}
+	case ed25519.PublicKey:
+		if p.options.Ed25519ph {
+			return protocommon.PublicKeyDetails_PKIX_ED25519_PH
+		}
+		return protocommon.PublicKeyDetails_PKIX_ED25519
+	}
+	return protocommon.PublicKeyDetails_PUBLIC_KEY_DETAILS_UNSPECIFIED
+}
+
+func (p *PrivateKeyKeypair) GetPublicKeyPem() (string, error) {
+	pubKeyBytes, err := cryptoutils.MarshalPublicKeyToPEM(p.signer.Public())
+	if err != nil {
+		return "", err
+	}
+
+	return string(pubKeyBytes), nil
+}
+
+func (p *PrivateKeyKeypair) SignData(data []byte) ([]byte, []byte, error) {
+	hashFunc, err := getHashFunc(p.hashAlgorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hasher := hashFunc.New()
+	hasher.Write(data)
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch {
+	case p.options.Ed25519ph:
+		signature, err = p.signer.Sign(rand.Reader, digest, &ed25519.Options{Hash: crypto.SHA512})
+	case p.keyAlgorithm == "ED25519":
+		signature, err = p.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	case p.options.RSAPSS:
+		signature, err = p.signer.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hashFunc})
+	default:
+		signature, err = p.signer.Sign(rand.Reader, digest, hashFunc)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Signers such as HSMs may return high-s signatures, which verifiers
+	// that require low-s signatures reject
+	if pub, ok := p.signer.Public().(*ecdsa.PublicKey); ok {
+		signature, err = util.NormalizeECDSASignature(signature, pub.Curve)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return signature, digest, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"os"
+	"testing"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPrivateKeyKeypair(t *testing.T) {
+	cosignKey, _, err := cryptoutils.GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), func(bool) ([]byte, error) {
+		return []byte("correct-horse"), nil
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name         string
+		path         string
+		pem          []byte
+		keyAlgorithm string
+	}{
+		{name: "PKCS8 ECDSA AES-256", path: "testdata/ecdsa-p256-encrypted.pem", keyAlgorithm: "ECDSA"},
+		{name: "PKCS8 RSA 3DES", path: "testdata/rsa-2048-encrypted.pem", keyAlgorithm: "RSA"},
+		{name: "PKCS8 Ed25519 AES-128", path: "testdata/ed25519-encrypted.pem", keyAlgorithm: "ED25519"},
+		{name: "legacy encrypted PEM", path: "testdata/ecdsa-p256-legacy-encrypted.pem", keyAlgorithm: "ECDSA"},
+		{name: "sigstore encrypted", pem: cosignKey, keyAlgorithm: "ECDSA"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pemBytes := tc.pem
+			if tc.path != "" {
+				pemBytes, err = os.ReadFile(tc.path)
+				require.NoError(t, err)
+			}
+
+			_, err := LoadPrivateKeyKeypair(pemBytes, nil, nil)
+			assert.ErrorContains(t, err, "no passphrase")
+
+			_, err = LoadPrivateKeyKeypair(pemBytes, Passphrase([]byte("battery-staple")), nil)
+			assert.ErrorIs(t, err, ErrIncorrectPassphrase)
+
+			keypair, err := LoadPrivateKeyKeypair(pemBytes, Passphrase([]byte("correct-horse")), nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.keyAlgorithm, keypair.GetKeyAlgorithm())
+			assert.NotEmpty(t, keypair.GetHint())
+
+			data := []byte("qwerty")
+			sig, _, err := keypair.SignData(data)
+			require.NoError(t, err)
+
+			pubKeyPEM, err := keypair.GetPublicKeyPem()
+			require.NoError(t, err)
+			pubKey, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(pubKeyPEM))
+			require.NoError(t, err)
+			verifier, err := signature.LoadVerifier(pubKey, crypto.SHA256)
+			require.NoError(t, err)
+			assert.NoError(t, verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data), options.NoOpOptionImpl{}))
+		})
+	}
+}
+
+func TestBundleWithPrivateKeyKeypair(t *testing.T) {
+	keypair, err := LoadPrivateKeyKeypairFromPath("testdata/ecdsa-p256-encrypted.pem", Passphrase([]byte("correct-horse")), nil)
+	require.NoError(t, err)
+
+	bundle, err := Bundle(&PlainData{Data: []byte("qwerty")}, keypair, BundleOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, string(keypair.GetHint()), bundle.GetVerificationMaterial().GetPublicKey().GetHint())
+}
+
+func TestPrivateKeyKeypairRSAPSS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keypair, err := NewPrivateKeyKeypair(key, &PrivateKeyKeypairOptions{RSAPSS: true})
+	require.NoError(t, err)
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256, keypair.GetKeyDetails())
+
+	sig, digest, err := keypair.SignData([]byte("hello"))
+	require.NoError(t, err)
+	assert.NoError(t, rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, digest, sig, nil))
+	assert.Error(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest, sig))
+
+	keypair, err = NewPrivateKeyKeypair(key, nil)
+	require.NoError(t, err)
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256, keypair.GetKeyDetails())
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	keypair, err = NewPrivateKeyKeypair(ecKey, nil)
+	require.NoError(t, err)
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384, keypair.GetKeyDetails())
+	_, err = NewPrivateKeyKeypair(ecKey, &PrivateKeyKeypairOptions{RSAPSS: true})
+	assert.Error(t, err)
+}
+
+func TestPrivateKeyKeypairEd25519ph(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keypair, err := NewPrivateKeyKeypair(key, &PrivateKeyKeypairOptions{Ed25519ph: true})
+	require.NoError(t, err)
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_ED25519_PH, keypair.GetKeyDetails())
+	assert.Equal(t, protocommon.HashAlgorithm_SHA2_512, keypair.GetHashAlgorithm())
+
+	sig, digest, err := keypair.SignData([]byte("hello"))
+	require.NoError(t, err)
+	expectedDigest := sha512.Sum512([]byte("hello"))
+	assert.Equal(t, expectedDigest[:], digest)
+	pub := key.Public().(ed25519.PublicKey)
+	assert.NoError(t, ed25519.VerifyWithOptions(pub, digest, sig, &ed25519.Options{Hash: crypto.SHA512}))
+	assert.False(t, ed25519.Verify(pub, []byte("hello"), sig))
+
+	keypair, err = NewPrivateKeyKeypair(key, nil)
+	require.NoError(t, err)
+	assert.Equal(t, protocommon.PublicKeyDetails_PKIX_ED25519, keypair.GetKeyDetails())
+
+	_, err = NewPrivateKeyKeypair(key, &PrivateKeyKeypairOptions{Ed25519ph: true, HashAlgorithm: protocommon.HashAlgorithm_SHA2_256})
+	assert.Error(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = NewPrivateKeyKeypair(ecKey, &PrivateKeyKeypairOptions{Ed25519ph: true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+// BundleResult is a bundle together with metadata about how it was signed,
+// so that callers can link to the transparency log entry or warn about the
+// certificate's lifetime without parsing the bundle themselves.
+type BundleResult struct {
+	Bundle *protobundle.Bundle
+	// Certificate is the signing certificate, or nil if the bundle was
+	// signed with a public key
+	Certificate *x509.Certificate
+	// CertificateNotAfter is when the signing certificate expires, or the
+	// zero time if the bundle was signed with a public key
+	CertificateNotAfter time.Time
+	// TransparencyLogEntries are the bundle's transparency log entries
+	TransparencyLogEntries []TransparencyLogEntryResult
+	// Timestamps are the bundle's signed timestamps
+	Timestamps []TimestampResult
+	// TimestampAuthorityFailures are the timestamp authorities that were
+	// contacted but returned no usable timestamp, and were replaced by the
+	// next timestamp authority
+	TimestampAuthorityFailures []TimestampAuthorityFailure
+	// DryRun describes the requests that would have been made, if
+	// BundleOptions.DryRun was set
+	DryRun *DryRunReport
+}
+
+// TransparencyLogEntryResult describes a transparency log entry of a bundle.
+type TransparencyLogEntryResult struct {
+	LogIndex int64
+	// LogID is the hex-encoded ID of the log, which is the SHA-256 hash of
+	// its public key
+	LogID string
+	// UUID is the hex-encoded Merkle leaf hash of the entry, which can be
+	// used to look up the entry in the log
+	UUID string
+	// EntryID is the UUID prefixed with the ID of the log shard's tree, as
+	// returned by tlog.Entry.EntryID, or empty if the entry has no
+	// inclusion proof to take the tree ID from
+	EntryID        string
+	IntegratedTime time.Time
+	Kind           string
+	Version        string
+}
+
+// TimestampResult describes a signed timestamp of a bundle.
+type TimestampResult struct {
+	// GenTime is the time the timestamp authority issued the timestamp
+	GenTime time.Time
+	// Accuracy is the timestamp's accuracy, if set by the timestamp
+	// authority
+	Accuracy time.Duration
+	// SerialNumber is the timestamp's serial number, in decimal
+	SerialNumber string
+}
+
+// BundleWithResult is like Bundle, but also returns metadata about the
+// signing certificate, transparency log entries and signed timestamps.
+func BundleWithResult(content Content, keypair Keypair, opts BundleOptions) (*BundleResult, error) {
+	bundle, details, err := signBundle(content, keypair, opts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := NewBundleResult(bundle)
+	if err != nil {
+		return nil, err
+	}
+	result.DryRun = details.dryRun
+	result.TimestampAuthorityFailures = details.timestampFailures
+	return result, nil
+}
+
+// NewBundleResult returns the metadata of a bundle, which need not have
+// been created by this package.
+func NewBundleResult(bundle *protobundle.Bundle) (*BundleResult, error) {
+	if bundle == nil || bundle.VerificationMaterial == nil {
+		return nil, errors.New("bundle has no verification material")
+	}
+	result := &BundleResult{Bundle: bundle}
+	material := bundle.VerificationMaterial
+
+	var rawCert []byte
+	switch content := material.Content.(type) {
+	case *protobundle.VerificationMaterial_Certificate:
+		rawCert = content.Certificate.GetRawBytes()
+	case *protobundle.VerificationMaterial_X509CertificateChain:
+		if certs := content.X509CertificateChain.GetCertificates(); len(certs) > 0 {
+			rawCert = certs[0].GetRawBytes()
+		}
+	}
+	if rawCert != nil {
+		cert, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+		}
+		result.Certificate = cert
+		result.CertificateNotAfter = cert.NotAfter
+	}
+
+	for _, entry := range material.TlogEntries {
+		leafHash := sha256.Sum256(append([]byte{0}, entry.GetCanonicalizedBody()...))
+		var entryID string
+		if parsed, err := tlog.ParseEntry(entry); err == nil {
+			entryID, _ = parsed.EntryID()
+		}
+		result.TransparencyLogEntries = append(result.TransparencyLogEntries, TransparencyLogEntryResult{
+			LogIndex:       entry.GetLogIndex(),
+			LogID:          hex.EncodeToString(entry.GetLogId().GetKeyId()),
+			UUID:           hex.EncodeToString(leafHash[:]),
+			EntryID:        entryID,
+			IntegratedTime: time.Unix(entry.GetIntegratedTime(), 0),
+			Kind:           entry.GetKindVersion().GetKind(),
+			Version:        entry.GetKindVersion().GetVersion(),
+		})
+	}
+
+	for _, signedTimestamp := range material.GetTimestampVerificationData().GetRfc3161Timestamps() {
+		ts, err := timestamp.ParseResponse(signedTimestamp.GetSignedTimestamp())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed timestamp: %w", err)
+		}
+		result.Timestamps = append(result.Timestamps, TimestampResult{
+			GenTime:      ts.Time,
+			Accuracy:     ts.Accuracy,
+			SerialNumber: ts.SerialNumber.String(),
+		})
+	}
+
+	return result, nil
+}
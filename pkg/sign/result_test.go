@@ -0,0 +1,69 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func Test_NewBundleResult(t *testing.T) {
+	bundleJSON, err := os.ReadFile("../testing/data/sigstoreBundle.json")
+	assert.NoError(t, err)
+	var bundle protobundle.Bundle
+	assert.NoError(t, protojson.Unmarshal(bundleJSON, &bundle))
+
+	result, err := NewBundleResult(&bundle)
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Certificate)
+	assert.Equal(t, result.Certificate.NotAfter, result.CertificateNotAfter)
+
+	assert.Len(t, result.TransparencyLogEntries, 1)
+	entry := result.TransparencyLogEntries[0]
+	assert.Equal(t, int64(6800908), entry.LogIndex)
+	assert.Equal(t, time.Unix(1668034836, 0), entry.IntegratedTime)
+	assert.Equal(t, "intoto", entry.Kind)
+	assert.Len(t, entry.UUID, 64)
+	assert.Len(t, entry.LogID, 64)
+	// v0.1 bundles have no inclusion proof to take the tree ID from
+	assert.Empty(t, entry.EntryID)
+
+	_, err = NewBundleResult(&protobundle.Bundle{})
+	assert.Error(t, err)
+}
+
+func Test_BundleWithResult(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	tsa := NewTimestampAuthority(&TimestampAuthorityOptions{BaseURL: newTestTSA(t, false).URL})
+
+	before := time.Now().Add(-time.Second)
+	result, err := BundleWithResult(&PlainData{Data: []byte("qwerty")}, keypair, BundleOptions{
+		TimestampAuthorities: []*TimestampAuthority{tsa},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Bundle)
+	assert.Nil(t, result.Certificate)
+	assert.True(t, result.CertificateNotAfter.IsZero())
+	assert.Empty(t, result.TransparencyLogEntries)
+	assert.Len(t, result.Timestamps, 1)
+	assert.True(t, result.Timestamps[0].GenTime.After(before))
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// RetimestampOptions configures Retimestamp.
+type RetimestampOptions struct {
+	// TimestampAuthorities are contacted in order until
+	// TimestampAuthorityThreshold timestamps are obtained
+	TimestampAuthorities []*TimestampAuthority
+	// TimestampAuthorityThreshold defaults to the number of timestamp
+	// authorities
+	TimestampAuthorityThreshold int
+	// RenewWithin is how long before the bundle's timestamps stop being
+	// verifiable new timestamps are obtained. If zero, new timestamps are
+	// always obtained.
+	RenewWithin time.Duration
+	// Now is the current time, for testing; defaults to time.Now
+	Now func() time.Time
+}
+
+// TimestampsValidUntil returns the time until which the bundle's RFC 3161
+// timestamps can be verified: the latest expiry of a timestamp's
+// certificate chain, as embedded in the timestamp. Timestamps that don't
+// embed their certificates are treated as already expired, since their
+// expiry is not known.
+//
+// It returns the zero time if the bundle has no timestamps.
+func TimestampsValidUntil(b *bundle.ProtobufBundle) (time.Time, error) {
+	signedTimestamps, err := b.Timestamps()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var validUntil time.Time
+	for _, signedTimestamp := range signedTimestamps {
+		ts, err := timestamp.ParseResponse(signedTimestamp)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		// A timestamp can only be verified while every certificate of its
+		// chain is valid
+		var chainExpiry time.Time
+		for _, cert := range ts.Certificates {
+			if chainExpiry.IsZero() || cert.NotAfter.Before(chainExpiry) {
+				chainExpiry = cert.NotAfter
+			}
+		}
+		if chainExpiry.After(validUntil) {
+			validUntil = chainExpiry
+		}
+	}
+	return validUntil, nil
+}
+
+// Retimestamp obtains new RFC 3161 timestamps over the bundle's signature
+// and adds them to the bundle, so that the signature can still be verified
+// once the certificates of its existing timestamps expire. The artifact is
+// not signed again.
+//
+// If opts.RenewWithin is set, new timestamps are only obtained once the
+// existing timestamps are valid for less than that long, as reported by
+// TimestampsValidUntil. Retimestamp reports whether timestamps were added.
+func Retimestamp(b *bundle.ProtobufBundle, opts RetimestampOptions) (bool, error) {
+	if len(opts.TimestampAuthorities) == 0 {
+		return false, errors.New("no timestamp authorities to retimestamp with")
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	if opts.RenewWithin > 0 {
+		validUntil, err := TimestampsValidUntil(b)
+		if err != nil {
+			return false, err
+		}
+		if validUntil.After(now().Add(opts.RenewWithin)) {
+			return false, nil
+		}
+	}
+
+	signature, err := bundleSignature(b.Bundle)
+	if err != nil {
+		return false, err
+	}
+	threshold := opts.TimestampAuthorityThreshold
+	if threshold == 0 {
+		threshold = len(opts.TimestampAuthorities)
+	}
+	timestamps, _, err := GetTimestamps(opts.TimestampAuthorities, signature, threshold)
+	if err != nil {
+		return false, err
+	}
+
+	// The new timestamps are merged from a copy of the bundle, which checks
+	// that the result is still a valid bundle
+	retimestamped, ok := proto.Clone(b.Bundle).(*protobundle.Bundle)
+	if !ok {
+		return false, errors.New("failed to copy bundle")
+	}
+	timestampData := &protobundle.TimestampVerificationData{}
+	for _, timestampBytes := range timestamps {
+		timestampData.Rfc3161Timestamps = append(timestampData.Rfc3161Timestamps, &protocommon.RFC3161SignedTimestamp{
+			SignedTimestamp: timestampBytes,
+		})
+	}
+	retimestamped.VerificationMaterial.TimestampVerificationData = timestampData
+	src, err := bundle.NewProtobufBundle(retimestamped)
+	if err != nil {
+		return false, err
+	}
+	if err = bundle.MergeVerificationMaterial(b, src); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// bundleSignature returns the signature that the bundle's timestamps are
+// over.
+func bundleSignature(b *protobundle.Bundle) ([]byte, error) {
+	if b.GetVerificationMaterial() == nil {
+		return nil, bundle.ErrMissingVerificationMaterial
+	}
+	if envelope := b.GetDsseEnvelope(); envelope != nil {
+		if len(envelope.GetSignatures()) != 1 {
+			return nil, fmt.Errorf("DSSE envelope must have exactly one signature, got %d", len(envelope.GetSignatures()))
+		}
+		return envelope.GetSignatures()[0].GetSig(), nil
+	}
+	if messageSignature := b.GetMessageSignature(); messageSignature != nil {
+		return messageSignature.GetSignature(), nil
+	}
+	return nil, bundle.ErrMissingVerificationMaterial
+}
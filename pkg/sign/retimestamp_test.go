@@ -0,0 +1,93 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func Test_Retimestamp(t *testing.T) {
+	tsa := NewTimestampAuthority(&TimestampAuthorityOptions{BaseURL: newTestTSA(t, false).URL})
+	keypair, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	pb, err := Bundle(&DSSEData{Data: []byte("hello"), PayloadType: "text/plain"}, keypair, BundleOptions{
+		TimestampAuthorities: []*TimestampAuthority{tsa},
+	})
+	require.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	// The test TSA's certificate expires in an hour
+	validUntil, err := TimestampsValidUntil(b)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), validUntil, time.Minute)
+
+	opts := RetimestampOptions{
+		TimestampAuthorities: []*TimestampAuthority{tsa},
+		RenewWithin:          30 * time.Minute,
+	}
+	retimestamped, err := Retimestamp(b, opts)
+	require.NoError(t, err)
+	assert.False(t, retimestamped)
+
+	opts.Now = func() time.Time { return time.Now().Add(45 * time.Minute) }
+	retimestamped, err = Retimestamp(b, opts)
+	require.NoError(t, err)
+	assert.True(t, retimestamped)
+
+	// The new timestamp is over the same signature
+	timestamps, err := b.Timestamps()
+	require.NoError(t, err)
+	require.Len(t, timestamps, 2)
+	original, err := timestamp.ParseResponse(timestamps[0])
+	require.NoError(t, err)
+	renewed, err := timestamp.ParseResponse(timestamps[1])
+	require.NoError(t, err)
+	assert.Equal(t, original.HashedMessage, renewed.HashedMessage)
+
+	_, err = Retimestamp(b, RetimestampOptions{})
+	assert.ErrorContains(t, err, "no timestamp authorities")
+}
+
+func Test_RetimestampWithoutTimestamps(t *testing.T) {
+	tsa := NewTimestampAuthority(&TimestampAuthorityOptions{BaseURL: newTestTSA(t, false).URL})
+	keypair, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	pb, err := Bundle(&PlainData{Data: []byte("hello")}, keypair, BundleOptions{})
+	require.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	validUntil, err := TimestampsValidUntil(b)
+	require.NoError(t, err)
+	assert.True(t, validUntil.IsZero())
+
+	retimestamped, err := Retimestamp(b, RetimestampOptions{
+		TimestampAuthorities: []*TimestampAuthority{tsa},
+		RenewWithin:          time.Hour,
+	})
+	require.NoError(t, err)
+	assert.True(t, retimestamped)
+	timestamps, err := b.Timestamps()
+	require.NoError(t, err)
+	assert.Len(t, timestamps, 1)
+}
@@ -15,11 +15,15 @@
 package sign
 
 import (
+	"context"
 	"encoding/pem"
 	"errors"
 
 	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
 )
 
 const bundleV03MediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
@@ -28,25 +32,92 @@ type BundleOptions struct {
 	// Optional Fulcio instance to get code signing certificate from.
 	//
 	// Resulting bundle will contain a certificate for its verification
-	// material content, instead of a public key.
+	// material content, instead of a public key. If Fulcio returns a
+	// detached SCT, it is added to the bundle; see bundle.SetDetachedSCTs.
 	Fulcio *Fulcio
 	// Optional OIDC JWT to send to Fulcio; required if using Fulcio
 	IDToken string
+	// Optional choice of the certificates from Fulcio to include in the
+	// bundle. Defaults to CertificateChainLeafOnly.
+	CertificateChain CertificateChainPolicy
+	// Optional trusted material to check the certificate from Fulcio
+	// against before signing, so that the bundle is known to verify with
+	// it. With CertificateChainFull, the chain returned by Fulcio must also
+	// be the chain of one of its certificate authorities.
+	TrustedMaterial root.TrustedMaterial
 	// Optional list of timestamp authorities to contact for inclusion in bundle
 	TimestampAuthorities []*TimestampAuthority
+	// Optional number of signed timestamps to include in the bundle. If set,
+	// TimestampAuthorities are contacted in order until this many timestamps
+	// are obtained, so that a timestamp authority returning a timestamp for
+	// the wrong message can be replaced by the next one. Defaults to one
+	// timestamp, from the first timestamp authority that returns one; the
+	// timestamp authorities that failed are reported by BundleWithResult.
+	TimestampAuthorityThreshold int
 	// Optional list of Rekor instances to get transparency log entry from.
 	//
 	// Supports hashedrekord and dsse entry types
 	Rekors []*Rekor
+	// Optional list of other transparency services to record the bundle in.
+	// Witnesses are called in order after the bundle has its transparency
+	// log entries, and their evidence is attached to the bundle with
+	// AddWitnessEvidence. The evidence is only kept in the binary protobuf
+	// encoding of the bundle, not in its JSON encoding.
+	Witnesses []Witness
+	// Optional context for requests to witnesses
+	Context context.Context
+	// Optional dry run, which signs the content and prepares every request
+	// without contacting Fulcio, timestamp authorities, Rekor or witnesses.
+	// The identity token and configuration are still validated. The bundle
+	// returned has only the signature and a public key as verification
+	// material; use BundleWithResult to inspect the requests.
+	DryRun bool
+	// Optional application-defined metadata to attach to the bundle, such
+	// as a build ID or the URL of a CI pipeline. Annotations are not signed
+	// and are NOT authenticated: anyone can change them without
+	// invalidating the bundle. Metadata that verifiers rely on must be part
+	// of the signed content instead. Annotations are omitted from the JSON
+	// encoding unless BundleJSONOptions.Annotations is set. See
+	// bundle.SetAnnotations.
+	Annotations map[string]string
+	// Optional signing policy, checked before anything is signed. If it
+	// denies the request, Bundle returns an error wrapping ErrSigningDenied.
+	Validator Validator
+	// Optional check that the bytes signed for a DSSE envelope are the
+	// DSSE v1 pre-authentication encoding of the envelope's payload type
+	// and payload, so that custom Content implementations can't produce
+	// envelopes whose signature verifiers reject. If they differ, Bundle
+	// returns an error wrapping ErrPreAuthEncodingMismatch.
+	VerifyDSSEPreAuthEncoding bool
 }
 
 func Bundle(content Content, keypair Keypair, opts BundleOptions) (*protobundle.Bundle, error) {
+	bundle, _, err := signBundle(content, keypair, opts)
+	return bundle, err
+}
+
+// signingDetails is what signBundle reports about signing besides the
+// bundle itself.
+type signingDetails struct {
+	// dryRun is the report of a dry run
+	dryRun *DryRunReport
+	// timestampFailures are the timestamp authorities that failed to
+	// return a timestamp
+	timestampFailures []TimestampAuthorityFailure
+}
+
+// signBundle is Bundle, also returning details of how it was signed.
+func signBundle(content Content, keypair Keypair, opts BundleOptions) (*protobundle.Bundle, *signingDetails, error) {
 	if keypair == nil {
-		return nil, errors.New("Must provide a keypair for signing, like EphemeralKeypair")
+		return nil, nil, errors.New("Must provide a keypair for signing, like EphemeralKeypair")
 	}
 
 	if opts.Fulcio != nil && opts.IDToken == "" {
-		return nil, errors.New("If opts.Fulcio is provided, must also supply opts.IDToken")
+		return nil, nil, errors.New("If opts.Fulcio is provided, must also supply opts.IDToken")
+	}
+
+	if err := validateSigningRequest(content, opts); err != nil {
+		return nil, nil, err
 	}
 
 	bundle := &protobundle.Bundle{MediaType: bundleV03MediaType}
@@ -54,55 +125,68 @@ func Bundle(content Content, keypair Keypair, opts BundleOptions) (*protobundle.
 	// Sign content and add to bundle
 	signature, digest, err := keypair.SignData(content.PreAuthEncoding())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	content.Bundle(bundle, signature, digest, keypair.GetHashAlgorithm())
+	if opts.VerifyDSSEPreAuthEncoding {
+		if err := verifyPreAuthEncoding(bundle, content); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Annotations are set first so that witnesses see them
+	annotate(bundle, opts.Annotations)
+
+	if opts.DryRun {
+		report, err := dryRun(bundle, signature, keypair, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bundle, &signingDetails{dryRun: report}, nil
+	}
 
 	// Add verification information to bundle
 	var verifierPEM []byte
 	if opts.Fulcio != nil && opts.IDToken != "" {
-		pubKeyBytes, err := opts.Fulcio.GetCertificate(keypair, opts.IDToken)
+		signingCert, err := opts.Fulcio.GetSigningCertificate(keypair, opts.IDToken)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		bundle.VerificationMaterial = &protobundle.VerificationMaterial{
-			Content: &protobundle.VerificationMaterial_Certificate{
-				Certificate: &protocommon.X509Certificate{
-					RawBytes: pubKeyBytes,
-				},
-			},
+		if opts.TrustedMaterial != nil {
+			if err = verifySigningCertificate(signingCert, opts.CertificateChain, opts.TrustedMaterial); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		bundle.VerificationMaterial, bundle.MediaType, err = opts.CertificateChain.verificationMaterial(signingCert)
+		if err != nil {
+			return nil, nil, err
+		}
+		if signingCert.DetachedSCT != nil {
+			attachDetachedSCT(bundle, signingCert.DetachedSCT)
 		}
 
 		verifierPEM = pem.EncodeToMemory(&pem.Block{
 			Type:  "CERTIFICATE",
-			Bytes: pubKeyBytes,
+			Bytes: signingCert.Certificate,
 		})
-
-		// TODO: do verification of Fulcio certificate
 	} else {
-		bundle.VerificationMaterial = &protobundle.VerificationMaterial{
-			Content: &protobundle.VerificationMaterial_PublicKey{
-				PublicKey: &protocommon.PublicKeyIdentifier{
-					Hint: string(keypair.GetHint()),
-				},
-			},
-		}
+		bundle.VerificationMaterial = publicKeyVerificationMaterial(keypair)
 
 		pubKeyStr, err := keypair.GetPublicKeyPem()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		verifierPEM = []byte(pubKeyStr)
 	}
 
-	for _, timestampAuthority := range opts.TimestampAuthorities {
-		timestampBytes, err := timestampAuthority.GetTimestamp(signature)
-		if err != nil {
-			return nil, err
-		}
+	timestamps, timestampFailures, err := GetTimestamps(opts.TimestampAuthorities, signature, opts.timestampAuthorityThreshold())
+	if err != nil {
+		return nil, nil, err
+	}
 
+	for _, timestampBytes := range timestamps {
 		signedTimestamp := &protocommon.RFC3161SignedTimestamp{
 			SignedTimestamp: timestampBytes,
 		}
@@ -118,10 +202,56 @@ func Bundle(content Content, keypair Keypair, opts BundleOptions) (*protobundle.
 		for _, rekor := range opts.Rekors {
 			err = rekor.GetTransparencyLogEntry(verifierPEM, bundle)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 	}
 
-	return bundle, nil
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, witness := range opts.Witnesses {
+		evidence, err := witness.GetEvidence(ctx, bundle)
+		if err != nil {
+			return nil, nil, err
+		}
+		AddWitnessEvidence(bundle, evidence)
+	}
+
+	return bundle, &signingDetails{timestampFailures: timestampFailures}, nil
+}
+
+// timestampAuthorityThreshold returns the number of timestamps to obtain:
+// TimestampAuthorityThreshold, or one if there are timestamp authorities
+// to obtain it from.
+func (opts *BundleOptions) timestampAuthorityThreshold() int {
+	if opts.TimestampAuthorityThreshold == 0 && len(opts.TimestampAuthorities) > 0 {
+		return 1
+	}
+	return opts.TimestampAuthorityThreshold
+}
+
+// annotate attaches annotations to a bundle; signBundle's bundle variable
+// shadows the bundle package.
+func annotate(b *protobundle.Bundle, annotations map[string]string) {
+	if len(annotations) > 0 {
+		bundle.SetAnnotations(b, annotations)
+	}
+}
+
+// attachDetachedSCT adds a detached SCT from Fulcio to a bundle, as
+// annotate does for annotations.
+func attachDetachedSCT(b *protobundle.Bundle, sct []byte) {
+	bundle.SetDetachedSCTs(b, [][]byte{sct})
+}
+
+func publicKeyVerificationMaterial(keypair Keypair) *protobundle.VerificationMaterial {
+	return &protobundle.VerificationMaterial{
+		Content: &protobundle.VerificationMaterial_PublicKey{
+			PublicKey: &protocommon.PublicKeyIdentifier{
+				Hint: string(keypair.GetHint()),
+			},
+		},
+	}
 }
@@ -0,0 +1,194 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+// Major API versions of each service type that this package can talk to
+var (
+	fulcioAPIVersions             = []uint32{1}
+	rekorAPIVersions              = []uint32{1}
+	timestampAuthorityAPIVersions = []uint32{1}
+)
+
+// SigningServices are the services selected from a signing config.
+type SigningServices struct {
+	// Certificate authority to request a signing certificate from; nil if
+	// the signing config lists none
+	Fulcio *root.Service
+	// Transparency logs to upload entries to
+	Rekors []root.Service
+	// Major API version of the selected transparency logs; zero if none
+	// were selected
+	RekorAPIVersion uint32
+	// Timestamp authorities to request signed timestamps from
+	TimestampAuthorities []root.Service
+}
+
+// SelectOption configures SelectSigningServices.
+type SelectOption func(*selectConfig) error
+
+type selectConfig struct {
+	rekorAPIVersions []uint32
+}
+
+// WithRekorAPIVersion pins the major API version of the transparency logs
+// to select, instead of negotiating the highest version that both this
+// package and the signing config support.
+func WithRekorAPIVersion(version uint32) SelectOption {
+	return func(c *selectConfig) error {
+		if !slices.Contains(rekorAPIVersions, version) {
+			return fmt.Errorf("unsupported Rekor API version %d, supported versions are %v", version, rekorAPIVersions)
+		}
+		c.rekorAPIVersions = []uint32{version}
+		return nil
+	}
+}
+
+// SelectSigningServices picks the services to sign with at the given time,
+// following the signing config's service selection configuration. A zero
+// currentTime means the current time.
+//
+// All selected transparency logs share a major API version: the highest one
+// supported here for which the signing config lists enough logs, so that
+// signing moves to a new Rekor API version once the signing config lists
+// its logs.
+func SelectSigningServices(signingConfig *root.SigningConfig, currentTime time.Time, opts ...SelectOption) (*SigningServices, error) {
+	if currentTime.IsZero() {
+		currentTime = time.Now()
+	}
+	config := &selectConfig{rekorAPIVersions: rekorAPIVersions}
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+
+	services := &SigningServices{}
+	if len(signingConfig.FulcioCertificateAuthorityURLs()) > 0 {
+		fulcio, err := root.SelectService(signingConfig.FulcioCertificateAuthorityURLs(), fulcioAPIVersions, currentTime)
+		if err != nil {
+			return nil, fmt.Errorf("selecting Fulcio: %w", err)
+		}
+		services.Fulcio = &fulcio
+	}
+
+	var err error
+	services.Rekors, services.RekorAPIVersion, err = root.NegotiateServices(signingConfig.RekorLogURLs(), signingConfig.RekorLogURLsConfig(), config.rekorAPIVersions, currentTime)
+	if err != nil {
+		return nil, fmt.Errorf("selecting Rekor: %w", err)
+	}
+
+	services.TimestampAuthorities, err = root.SelectServices(signingConfig.TimestampAuthorityURLs(), signingConfig.TimestampAuthorityURLsConfig(), timestampAuthorityAPIVersions, currentTime)
+	if err != nil {
+		return nil, fmt.Errorf("selecting timestamp authority: %w", err)
+	}
+
+	return services, nil
+}
+
+// SigningServicesClientOptions configures the clients created by
+// SigningServices.BundleOptions.
+//
+// Deprecated: Use SigningServices.NewBundleOptions and ClientOption instead.
+type SigningServicesClientOptions struct {
+	// Optional timeout for network requests
+	Timeout time.Duration
+	// Optional version string for user agent
+	LibraryVersion string
+	// Optional User-Agent configuration
+	UserAgent *util.UserAgent
+	// Optional logger for debug logs of requests
+	Logger *slog.Logger
+}
+
+// NewBundleOptions returns options for Bundle that use the selected
+// services, with clients configured by opts. The identity token is only used
+// if a Fulcio instance was selected.
+func (s *SigningServices) NewBundleOptions(idToken string, opts ...ClientOption) (BundleOptions, error) {
+	bundleOpts := BundleOptions{}
+	if s.Fulcio != nil {
+		fulcio, err := NewFulcioClient(s.Fulcio.URL, opts...)
+		if err != nil {
+			return bundleOpts, err
+		}
+		bundleOpts.Fulcio = fulcio
+		bundleOpts.IDToken = idToken
+	}
+	for _, service := range s.Rekors {
+		rekor, err := NewRekorClient(service.URL, opts...)
+		if err != nil {
+			return bundleOpts, err
+		}
+		bundleOpts.Rekors = append(bundleOpts.Rekors, rekor)
+	}
+	for _, service := range s.TimestampAuthorities {
+		tsa, err := NewTimestampAuthorityClient(service.URL, opts...)
+		if err != nil {
+			return bundleOpts, err
+		}
+		bundleOpts.TimestampAuthorities = append(bundleOpts.TimestampAuthorities, tsa)
+	}
+	return bundleOpts, nil
+}
+
+// BundleOptions returns options for Bundle that use the selected services.
+// The identity token is only used if a Fulcio instance was selected.
+//
+// Deprecated: Use NewBundleOptions instead, which validates its options.
+func (s *SigningServices) BundleOptions(idToken string, opts *SigningServicesClientOptions) BundleOptions {
+	if opts == nil {
+		opts = &SigningServicesClientOptions{}
+	}
+
+	bundleOpts := BundleOptions{}
+	if s.Fulcio != nil {
+		bundleOpts.Fulcio = NewFulcio(&FulcioOptions{
+			BaseURL:        s.Fulcio.URL,
+			Timeout:        opts.Timeout,
+			LibraryVersion: opts.LibraryVersion,
+			UserAgent:      opts.UserAgent,
+			Logger:         opts.Logger,
+		})
+		bundleOpts.IDToken = idToken
+	}
+	for _, rekor := range s.Rekors {
+		bundleOpts.Rekors = append(bundleOpts.Rekors, NewRekor(&RekorOptions{
+			BaseURL:        rekor.URL,
+			Timeout:        opts.Timeout,
+			LibraryVersion: opts.LibraryVersion,
+			UserAgent:      opts.UserAgent,
+			Logger:         opts.Logger,
+		}))
+	}
+	for _, tsa := range s.TimestampAuthorities {
+		bundleOpts.TimestampAuthorities = append(bundleOpts.TimestampAuthorities, NewTimestampAuthority(&TimestampAuthorityOptions{
+			BaseURL:        tsa.URL,
+			Timeout:        opts.Timeout,
+			LibraryVersion: opts.LibraryVersion,
+			UserAgent:      opts.UserAgent,
+			Logger:         opts.Logger,
+		}))
+	}
+	return bundleOpts
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+func Test_SelectSigningServices(t *testing.T) {
+	signingConfig, err := root.NewSigningConfigFromJSON([]byte(`{
+  "mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json",
+  "caUrls": [{"url": "https://fulcio.example.com", "majorApiVersion": 1, "validFor": {"start": "2023-01-01T00:00:00Z"}, "operator": "example.com"}],
+  "rekorTlogUrls": [
+    {"url": "https://rekor.example.com", "majorApiVersion": 1, "validFor": {"start": "2023-01-01T00:00:00Z"}, "operator": "example.com"},
+    {"url": "https://rekor.other.com", "majorApiVersion": 1, "validFor": {"start": "2023-01-01T00:00:00Z"}, "operator": "other.com"}
+  ],
+  "rekorTlogConfig": {"selector": "ANY"},
+  "tsaUrls": [
+    {"url": "https://tsa.example.com/api/v1/timestamp", "majorApiVersion": 1, "validFor": {"start": "2023-01-01T00:00:00Z"}, "operator": "example.com"},
+    {"url": "https://tsa.other.com/api/v1/timestamp", "majorApiVersion": 1, "validFor": {"start": "2023-01-01T00:00:00Z"}, "operator": "other.com"}
+  ],
+  "tsaConfig": {"selector": "ALL"}
+}`))
+	assert.NoError(t, err)
+
+	services, err := SelectSigningServices(signingConfig, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://fulcio.example.com", services.Fulcio.URL)
+	assert.Len(t, services.Rekors, 1)
+	assert.Equal(t, uint32(1), services.RekorAPIVersion)
+	assert.Len(t, services.TimestampAuthorities, 2)
+
+	opts := services.BundleOptions("token", &SigningServicesClientOptions{Timeout: time.Minute})
+	assert.NotNil(t, opts.Fulcio)
+	assert.Equal(t, "token", opts.IDToken)
+	assert.Len(t, opts.Rekors, 1)
+	assert.Len(t, opts.TimestampAuthorities, 2)
+	assert.Equal(t, "https://tsa.other.com/api/v1/timestamp", opts.TimestampAuthorities[1].baseURL)
+
+	opts, err = services.NewBundleOptions("token", WithTimeout(time.Minute), WithRetries(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://fulcio.example.com", opts.Fulcio.baseURL)
+	assert.Len(t, opts.Rekors, 1)
+	assert.Equal(t, 1, opts.Rekors[0].config.retries)
+	assert.Len(t, opts.TimestampAuthorities, 2)
+	assert.Equal(t, time.Minute, opts.TimestampAuthorities[0].config.timeout)
+
+	_, err = services.NewBundleOptions("token", WithTimeout(-time.Minute))
+	assert.Error(t, err)
+
+	_, err = SelectSigningServices(signingConfig, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, root.ErrNoValidService)
+}
+
+func Test_SelectSigningServicesRekorAPIVersion(t *testing.T) {
+	signingConfig, err := root.NewSigningConfigFromJSON([]byte(`{
+  "mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json",
+  "rekorTlogUrls": [
+    {"url": "https://rekor-v2.example.com", "majorApiVersion": 2, "validFor": {"start": "2024-01-01T00:00:00Z"}, "operator": "example.com"},
+    {"url": "https://rekor.example.com", "majorApiVersion": 1, "validFor": {"start": "2023-01-01T00:00:00Z"}, "operator": "example.com"}
+  ],
+  "rekorTlogConfig": {"selector": "ANY"}
+}`))
+	assert.NoError(t, err)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Logs with API versions this package cannot talk to are skipped
+	services, err := SelectSigningServices(signingConfig, now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), services.RekorAPIVersion)
+	assert.Len(t, services.Rekors, 1)
+	assert.Equal(t, "https://rekor.example.com", services.Rekors[0].URL)
+
+	services, err = SelectSigningServices(signingConfig, now, WithRekorAPIVersion(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://rekor.example.com", services.Rekors[0].URL)
+
+	_, err = SelectSigningServices(signingConfig, now, WithRekorAPIVersion(3))
+	assert.ErrorContains(t, err, "unsupported Rekor API version 3")
+
+	// No logs at all select no version
+	signingConfig, err = root.NewSigningConfigFromJSON([]byte(`{"mediaType": "application/vnd.dev.sigstore.signingconfig.v0.2+json"}`))
+	assert.NoError(t, err)
+	services, err = SelectSigningServices(signingConfig, now)
+	assert.NoError(t, err)
+	assert.Empty(t, services.Rekors)
+	assert.Zero(t, services.RekorAPIVersion)
+}
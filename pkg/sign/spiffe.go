@@ -0,0 +1,128 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
+)
+
+// SPIFFEEndpointSocketEnv is the environment variable that holds the address
+// of the SPIFFE Workload API, e.g. "unix:///run/spire/sockets/agent.sock".
+const SPIFFEEndpointSocketEnv = workloadapi.SocketEnv
+
+// SPIFFEOptions configures a SPIFFETokenProvider.
+type SPIFFEOptions struct {
+	// Address of the SPIFFE Workload API, as a "unix://" or "tcp://" URL.
+	// Defaults to the value of SPIFFEEndpointSocketEnv.
+	EndpointSocket string
+	// Audience to request the JWT-SVID for. Defaults to
+	// DefaultIDTokenAudience, which Fulcio expects.
+	Audience string
+	// Optional SPIFFE ID of the JWT-SVID to request, for workloads that are
+	// entitled to more than one. If empty, the Workload API's default
+	// SPIFFE ID is used.
+	SPIFFEID string
+	// Optional timeout for requests to the Workload API
+	Timeout time.Duration
+	// Optional logger for debug logs of requests
+	Logger *slog.Logger
+}
+
+// SPIFFETokenProvider fetches JWT-SVIDs from the SPIFFE Workload API, such
+// as a SPIRE agent, for use as the identity token of a Fulcio certificate
+// request. This lets workloads in a service mesh sign without an external
+// OIDC provider; Fulcio must be configured to trust the SPIFFE trust
+// domain's OIDC discovery endpoint.
+type SPIFFETokenProvider struct {
+	options  *SPIFFEOptions
+	endpoint string
+	spiffeID spiffeid.ID
+}
+
+// NewSPIFFETokenProvider returns a SPIFFETokenProvider that connects to the
+// Workload API at opts.EndpointSocket.
+func NewSPIFFETokenProvider(opts *SPIFFEOptions) (*SPIFFETokenProvider, error) {
+	if opts == nil {
+		opts = &SPIFFEOptions{}
+	}
+	endpoint := opts.EndpointSocket
+	if endpoint == "" {
+		endpoint = os.Getenv(SPIFFEEndpointSocketEnv)
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("SPIFFE Workload API address not configured; set %s", SPIFFEEndpointSocketEnv)
+	}
+	if err := workloadapi.ValidateAddress(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE Workload API address %q: %w", endpoint, err)
+	}
+
+	provider := &SPIFFETokenProvider{options: opts, endpoint: endpoint}
+	if opts.SPIFFEID != "" {
+		spiffeID, err := spiffeid.FromString(opts.SPIFFEID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE ID %q: %w", opts.SPIFFEID, err)
+		}
+		provider.spiffeID = spiffeID
+	}
+	return provider, nil
+}
+
+// IDToken fetches a JWT-SVID from the Workload API. The JWT-SVID is short
+// lived, so a new one should be fetched for each certificate request.
+func (p *SPIFFETokenProvider) IDToken(ctx context.Context) (string, error) {
+	audience := p.options.Audience
+	if audience == "" {
+		audience = DefaultIDTokenAudience
+	}
+	if p.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.options.Timeout)
+		defer cancel()
+	}
+
+	client, err := workloadapi.New(ctx, workloadapi.WithAddr(p.endpoint))
+	if err != nil {
+		return "", fmt.Errorf("failed to contact SPIFFE Workload API: %w", err)
+	}
+	defer client.Close()
+
+	logger := util.Logger(p.options.Logger)
+	logger.Debug("fetching JWT-SVID from SPIFFE Workload API", "audience", audience, "spiffeID", p.options.SPIFFEID)
+	svids, err := client.FetchJWTSVIDs(ctx, jwtsvid.Params{Audience: audience, Subject: p.spiffeID})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch JWT-SVID from SPIFFE Workload API: %w", err)
+	}
+	for _, svid := range svids {
+		if p.spiffeID.IsZero() || svid.ID == p.spiffeID {
+			logger.Debug("fetched JWT-SVID", "spiffeID", svid.ID.String())
+			return svid.Marshal(), nil
+		}
+	}
+	if !p.spiffeID.IsZero() {
+		return "", fmt.Errorf("SPIFFE Workload API returned no JWT-SVID for %s", p.spiffeID)
+	}
+	return "", errors.New("SPIFFE Workload API returned no JWT-SVIDs")
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type workloadAPIRequest struct {
+	audiences []string
+	spiffeID  string
+	header    []string
+}
+
+// fakeWorkloadAPI is a SPIFFE Workload API that issues JWT-SVIDs for a
+// fixed set of SPIFFE IDs, or fails with err.
+type fakeWorkloadAPI struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+	t         *testing.T
+	key       *ecdsa.PrivateKey
+	spiffeIDs []string
+	err       error
+	requests  []workloadAPIRequest
+}
+
+func (f *fakeWorkloadAPI) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest) (*workload.JWTSVIDResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.requests = append(f.requests, workloadAPIRequest{
+		audiences: req.Audience,
+		spiffeID:  req.SpiffeId,
+		header:    md.Get("workload.spiffe.io"),
+	})
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: f.key}, nil)
+	assert.NoError(f.t, err)
+	resp := &workload.JWTSVIDResponse{}
+	for _, spiffeID := range f.spiffeIDs {
+		token, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Subject:  spiffeID,
+			Audience: req.Audience,
+			Expiry:   jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+		}).Serialize()
+		assert.NoError(f.t, err)
+		resp.Svids = append(resp.Svids, &workload.JWTSVID{SpiffeId: spiffeID, Svid: token})
+	}
+	return resp, nil
+}
+
+// startWorkloadAPI serves a fake SPIFFE Workload API on a unix socket and
+// returns its address.
+func startWorkloadAPI(t *testing.T, api *fakeWorkloadAPI) string {
+	dir, err := os.MkdirTemp("", "spiffe")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "agent.sock")
+
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+
+	api.t = t
+	api.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, api)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return "unix://" + socket
+}
+
+// tokenSubject returns the subject of a JWT-SVID.
+func tokenSubject(t *testing.T, token string) string {
+	parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{jose.ES256})
+	assert.NoError(t, err)
+	var claims jwt.Claims
+	assert.NoError(t, parsed.UnsafeClaimsWithoutVerification(&claims))
+	return claims.Subject
+}
+
+func Test_SPIFFETokenProvider(t *testing.T) {
+	api := &fakeWorkloadAPI{spiffeIDs: []string{
+		"spiffe://example.org/ns/default/sa/builder",
+		"spiffe://example.org/ns/default/sa/releaser",
+	}}
+	endpoint := startWorkloadAPI(t, api)
+
+	provider, err := NewSPIFFETokenProvider(&SPIFFEOptions{EndpointSocket: endpoint})
+	assert.NoError(t, err)
+	token, err := provider.IDToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://example.org/ns/default/sa/builder", tokenSubject(t, token))
+
+	assert.Len(t, api.requests, 1)
+	assert.Equal(t, []string{DefaultIDTokenAudience}, api.requests[0].audiences)
+	assert.Empty(t, api.requests[0].spiffeID)
+	assert.Equal(t, []string{"true"}, api.requests[0].header)
+
+	provider, err = NewSPIFFETokenProvider(&SPIFFEOptions{
+		EndpointSocket: endpoint,
+		Audience:       "fulcio.example.org",
+		SPIFFEID:       "spiffe://example.org/ns/default/sa/releaser",
+	})
+	assert.NoError(t, err)
+	token, err = provider.IDToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "spiffe://example.org/ns/default/sa/releaser", tokenSubject(t, token))
+	assert.Equal(t, []string{"fulcio.example.org"}, api.requests[1].audiences)
+	assert.Equal(t, "spiffe://example.org/ns/default/sa/releaser", api.requests[1].spiffeID)
+
+	provider, err = NewSPIFFETokenProvider(&SPIFFEOptions{EndpointSocket: endpoint, SPIFFEID: "spiffe://example.org/other"})
+	assert.NoError(t, err)
+	_, err = provider.IDToken(context.Background())
+	assert.ErrorContains(t, err, "no JWT-SVID for spiffe://example.org/other")
+
+	_, err = NewSPIFFETokenProvider(&SPIFFEOptions{EndpointSocket: endpoint, SPIFFEID: "not a SPIFFE ID"})
+	assert.ErrorContains(t, err, "invalid SPIFFE ID")
+}
+
+func Test_SPIFFETokenProviderGRPCError(t *testing.T) {
+	endpoint := startWorkloadAPI(t, &fakeWorkloadAPI{err: status.Error(codes.PermissionDenied, "no identity issued")})
+
+	provider, err := NewSPIFFETokenProvider(&SPIFFEOptions{EndpointSocket: endpoint})
+	assert.NoError(t, err)
+	_, err = provider.IDToken(context.Background())
+	assert.ErrorContains(t, err, "no identity issued")
+	assert.Equal(t, codes.PermissionDenied, status.Code(errors.Unwrap(err)))
+}
+
+func Test_SPIFFETokenProviderEndpoint(t *testing.T) {
+	t.Setenv(SPIFFEEndpointSocketEnv, "")
+	_, err := NewSPIFFETokenProvider(nil)
+	assert.ErrorContains(t, err, SPIFFEEndpointSocketEnv)
+
+	t.Setenv(SPIFFEEndpointSocketEnv, "unix:///run/spire/sockets/agent.sock")
+	_, err = NewSPIFFETokenProvider(nil)
+	assert.NoError(t, err)
+
+	for endpoint, valid := range map[string]bool{
+		"unix:///run/spire/sockets/agent.sock": true,
+		"tcp://127.0.0.1:8081":                 true,
+		"unix://":                              false,
+		"tcp://":                               false,
+		"http://127.0.0.1:8081":                false,
+		"/run/spire/sockets/agent.sock":        false,
+	} {
+		_, err := NewSPIFFETokenProvider(&SPIFFEOptions{EndpointSocket: endpoint})
+		assert.Equal(t, valid, err == nil, endpoint)
+	}
+}
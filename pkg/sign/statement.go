@@ -0,0 +1,168 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// InTotoStatementV1 is the statement type of statements built by
+// StatementBuilder.
+const InTotoStatementV1 = "https://in-toto.io/Statement/v1"
+
+type statementSubject struct {
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type statement struct {
+	Type          string             `json:"_type"` //nolint:tagliatelle
+	Subject       []statementSubject `json:"subject"`
+	PredicateType string             `json:"predicateType"`
+	Predicate     json.RawMessage    `json:"predicate,omitempty"`
+}
+
+// StatementBuilder builds an in-toto v1 Statement to be signed as DSSE
+// content. Errors from the Add and With methods are deferred until Build
+// is called, so that calls can be chained:
+//
+//	content, err := sign.NewStatementBuilder("https://slsa.dev/provenance/v1").
+//		AddSubjectFromFile("dist/app.tar.gz").
+//		WithPredicate(provenance).
+//		DSSEData()
+type StatementBuilder struct {
+	// statementType defaults to InTotoStatementV1
+	statementType string
+	predicateType string
+	predicate     json.RawMessage
+	subjects      []statementSubject
+	err           error
+}
+
+func NewStatementBuilder(predicateType string) *StatementBuilder {
+	return &StatementBuilder{predicateType: predicateType}
+}
+
+// AddSubject adds a subject with the given hex-encoded digests, keyed by
+// algorithm name (e.g. "sha256").
+func (b *StatementBuilder) AddSubject(name string, digests map[string]string) *StatementBuilder {
+	if len(digests) == 0 {
+		return b.fail(fmt.Errorf("subject %q has no digests", name))
+	}
+	subject := statementSubject{Name: name, Digest: make(map[string]string, len(digests))}
+	for algorithm, digest := range digests {
+		if algorithm == "" || algorithm != strings.ToLower(algorithm) {
+			return b.fail(fmt.Errorf("subject %q: digest algorithm %q must be lowercase", name, algorithm))
+		}
+		if _, err := hex.DecodeString(digest); err != nil || digest == "" {
+			return b.fail(fmt.Errorf("subject %q: %s digest is not hex-encoded", name, algorithm))
+		}
+		subject.Digest[algorithm] = strings.ToLower(digest)
+	}
+	b.subjects = append(b.subjects, subject)
+	return b
+}
+
+// AddSubjectDigest adds a subject with a single raw digest.
+func (b *StatementBuilder) AddSubjectDigest(name, algorithm string, digest []byte) *StatementBuilder {
+	return b.AddSubject(name, map[string]string{algorithm: hex.EncodeToString(digest)})
+}
+
+// AddSubjectFromReader adds a subject with the SHA-256 digest of the
+// reader's contents.
+func (b *StatementBuilder) AddSubjectFromReader(name string, r io.Reader) *StatementBuilder {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return b.fail(fmt.Errorf("subject %q: %w", name, err))
+	}
+	return b.AddSubjectDigest(name, "sha256", h.Sum(nil))
+}
+
+// AddSubjectFromFile adds a subject named after the file's base name, with
+// the SHA-256 digest of its contents.
+func (b *StatementBuilder) AddSubjectFromFile(path string) *StatementBuilder {
+	f, err := os.Open(path)
+	if err != nil {
+		return b.fail(err)
+	}
+	defer f.Close()
+	return b.AddSubjectFromReader(filepath.Base(path), f)
+}
+
+// WithPredicate sets the predicate, which must marshal to a JSON object.
+// Raw JSON may be passed as a json.RawMessage.
+func (b *StatementBuilder) WithPredicate(predicate any) *StatementBuilder {
+	predicateJSON, err := json.Marshal(predicate)
+	if err != nil {
+		return b.fail(fmt.Errorf("failed to marshal predicate: %w", err))
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(predicateJSON), []byte("{")) {
+		return b.fail(errors.New("predicate must be a JSON object"))
+	}
+	b.predicate = predicateJSON
+	return b
+}
+
+func (b *StatementBuilder) fail(err error) *StatementBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Build returns the JSON-encoded statement.
+func (b *StatementBuilder) Build() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.predicateType == "" {
+		return nil, errors.New("statement must have a predicate type")
+	}
+	if len(b.subjects) == 0 {
+		return nil, errors.New("statement must have at least one subject")
+	}
+
+	statementType := b.statementType
+	if statementType == "" {
+		statementType = InTotoStatementV1
+	}
+	return json.Marshal(statement{
+		Type:          statementType,
+		Subject:       b.subjects,
+		PredicateType: b.predicateType,
+		Predicate:     b.predicate,
+	})
+}
+
+// DSSEData returns the statement as content for Bundle, with the in-toto
+// payload type.
+func (b *StatementBuilder) DSSEData() (*DSSEData, error) {
+	statementJSON, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &DSSEData{Data: statementJSON, PayloadType: bundle.IntotoMediaType}, nil
+}
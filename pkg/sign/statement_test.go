@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+func Test_StatementBuilder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.tar.gz")
+	assert.NoError(t, os.WriteFile(path, []byte("hello"), 0o600))
+
+	type provenance struct {
+		BuildType string `json:"buildType"`
+	}
+
+	statementJSON, err := NewStatementBuilder("https://slsa.dev/provenance/v1").
+		AddSubjectFromFile(path).
+		AddSubjectFromReader("readme", strings.NewReader("hello")).
+		AddSubject("image", map[string]string{"sha256": "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824"}).
+		WithPredicate(provenance{BuildType: "make"}).
+		Build()
+	assert.NoError(t, err)
+
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(statementJSON, &decoded))
+	assert.Equal(t, InTotoStatementV1, decoded["_type"])
+	assert.Equal(t, "https://slsa.dev/provenance/v1", decoded["predicateType"])
+	assert.Equal(t, map[string]any{"buildType": "make"}, decoded["predicate"])
+	subjects := decoded["subject"].([]any)
+	assert.Len(t, subjects, 3)
+	assert.Equal(t, map[string]any{
+		"name":   "app.tar.gz",
+		"digest": map[string]any{"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+	}, subjects[0])
+	// Digests are normalized to lowercase
+	assert.Equal(t, subjects[0].(map[string]any)["digest"], subjects[2].(map[string]any)["digest"])
+
+	// The statement can be signed and read back from the bundle
+	content, err := NewStatementBuilder("https://example.com/predicate").AddSubjectFromFile(path).DSSEData()
+	assert.NoError(t, err)
+	assert.Equal(t, bundle.IntotoMediaType, content.PayloadType)
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+	pb, err := Bundle(content, keypair, BundleOptions{})
+	assert.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	assert.NoError(t, err)
+	sigContent, err := b.SignatureContent()
+	assert.NoError(t, err)
+	statement, err := sigContent.EnvelopeContent().Statement()
+	assert.NoError(t, err)
+	assert.Equal(t, "app.tar.gz", statement.Subject[0].Name)
+}
+
+func Test_StatementBuilderErrors(t *testing.T) {
+	_, err := NewStatementBuilder("").AddSubject("a", map[string]string{"sha256": "abcd"}).Build()
+	assert.ErrorContains(t, err, "predicate type")
+
+	_, err = NewStatementBuilder("type").Build()
+	assert.ErrorContains(t, err, "at least one subject")
+
+	_, err = NewStatementBuilder("type").AddSubject("a", map[string]string{"sha256": "xyz"}).Build()
+	assert.ErrorContains(t, err, "not hex-encoded")
+
+	_, err = NewStatementBuilder("type").AddSubject("a", map[string]string{"SHA256": "abcd"}).Build()
+	assert.ErrorContains(t, err, "must be lowercase")
+
+	_, err = NewStatementBuilder("type").AddSubject("a", nil).Build()
+	assert.ErrorContains(t, err, "has no digests")
+
+	_, err = NewStatementBuilder("type").AddSubject("a", map[string]string{"sha256": "abcd"}).WithPredicate([]string{"x"}).Build()
+	assert.ErrorContains(t, err, "must be a JSON object")
+
+	// The first error is reported
+	_, err = NewStatementBuilder("type").AddSubjectFromFile("/does/not/exist").AddSubject("a", nil).Build()
+	assert.ErrorContains(t, err, "no such file")
+}
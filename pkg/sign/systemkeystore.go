@@ -0,0 +1,126 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrSystemKeyStoreUnsupported is returned by LoadSystemIdentity on
+// platforms without a supported key store, or when built without cgo on
+// macOS.
+var ErrSystemKeyStoreUnsupported = errors.New("system key store is not supported on this platform")
+
+// ErrSystemIdentityNotFound is returned by LoadSystemIdentity when no
+// certificate with a private key in the key store matches its options.
+var ErrSystemIdentityNotFound = errors.New("no matching identity found in the system key store")
+
+// SystemKeyStoreOptions selects an identity, a certificate and its private
+// key, from the key store of the operating system. At least one of
+// SubjectCommonName and CertificateFingerprint must be set.
+type SystemKeyStoreOptions struct {
+	// Optional common name of the certificate's subject. If several valid
+	// certificates match, e.g. after a machine certificate was renewed,
+	// the most recently issued one is used.
+	SubjectCommonName string
+	// Optional hex-encoded SHA-256 digest of the DER encoded certificate
+	CertificateFingerprint string
+	// Optional name of the Windows certificate store to search. Defaults
+	// to "MY", the personal store. Ignored on macOS, where the default
+	// keychain search list is used.
+	StoreName string
+	// Optional: search the Windows certificate store of the local machine
+	// rather than the current user's. Ignored on macOS.
+	LocalMachine bool
+}
+
+// SystemIdentity is a certificate and a private key held by the operating
+// system: the Windows certificate store, using CNG, or the macOS keychain.
+// The private key never leaves the key store; it signs through the
+// operating system, which may require the user's consent.
+//
+// The key is used for key-based signing with Keypair: the certificate is
+// not included in bundles, so verifiers must trust its public key.
+type SystemIdentity struct {
+	// Certificate is the identity's certificate
+	Certificate *x509.Certificate
+	signer      systemSigner
+}
+
+// systemSigner is a crypto.Signer backed by a key store, which holds
+// handles that must be released.
+type systemSigner interface {
+	crypto.Signer
+	Close() error
+}
+
+// LoadSystemIdentity finds an identity in the key store of the operating
+// system. The identity must be closed when no longer used.
+func LoadSystemIdentity(opts *SystemKeyStoreOptions) (*SystemIdentity, error) {
+	if opts == nil || (opts.SubjectCommonName == "" && opts.CertificateFingerprint == "") {
+		return nil, errors.New("a subject common name or certificate fingerprint is required to select a system identity")
+	}
+	if opts.CertificateFingerprint != "" {
+		fingerprint, err := hex.DecodeString(opts.CertificateFingerprint)
+		if err != nil || len(fingerprint) != sha256.Size {
+			return nil, fmt.Errorf("invalid certificate fingerprint %q: must be a hex-encoded SHA-256 digest", opts.CertificateFingerprint)
+		}
+	}
+	return loadSystemIdentity(opts)
+}
+
+// Keypair returns a keypair that signs with the identity's private key.
+func (id *SystemIdentity) Keypair(opts *PrivateKeyKeypairOptions) (*PrivateKeyKeypair, error) {
+	return NewPrivateKeyKeypair(id.signer, opts)
+}
+
+// Close releases the key store handles of the identity.
+func (id *SystemIdentity) Close() error {
+	return id.signer.Close()
+}
+
+// selectSystemIdentity returns the index of the certificate that opts
+// selects among the certificates with private keys in a key store.
+func selectSystemIdentity(certs []*x509.Certificate, opts *SystemKeyStoreOptions, now time.Time) (int, error) {
+	selected := -1
+	for i, cert := range certs {
+		if opts.SubjectCommonName != "" && cert.Subject.CommonName != opts.SubjectCommonName {
+			continue
+		}
+		if opts.CertificateFingerprint != "" {
+			fingerprint := sha256.Sum256(cert.Raw)
+			if !strings.EqualFold(hex.EncodeToString(fingerprint[:]), opts.CertificateFingerprint) {
+				continue
+			}
+		}
+		if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			continue
+		}
+		if selected == -1 || cert.NotBefore.After(certs[selected].NotBefore) {
+			selected = i
+		}
+	}
+	if selected == -1 {
+		return -1, ErrSystemIdentityNotFound
+	}
+	return selected, nil
+}
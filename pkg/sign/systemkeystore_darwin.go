@@ -0,0 +1,203 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin && cgo
+
+package sign
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation -framework Security
+#include <CoreFoundation/CoreFoundation.h>
+#include <Security/Security.h>
+
+enum { sigECDSA, sigPKCS1v15, sigPSS };
+
+static CFArrayRef copyIdentities(OSStatus *status) {
+	const void *keys[] = { kSecClass, kSecReturnRef, kSecMatchLimit };
+	const void *values[] = { kSecClassIdentity, kCFBooleanTrue, kSecMatchLimitAll };
+	CFDictionaryRef query = CFDictionaryCreate(kCFAllocatorDefault, keys, values, 3,
+		&kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	CFTypeRef result = NULL;
+	*status = SecItemCopyMatching(query, &result);
+	CFRelease(query);
+	return (CFArrayRef)result;
+}
+
+static CFDataRef copyIdentityCertificate(CFArrayRef identities, CFIndex i) {
+	SecIdentityRef identity = (SecIdentityRef)CFArrayGetValueAtIndex(identities, i);
+	SecCertificateRef cert = NULL;
+	if (SecIdentityCopyCertificate(identity, &cert) != errSecSuccess) {
+		return NULL;
+	}
+	CFDataRef data = SecCertificateCopyData(cert);
+	CFRelease(cert);
+	return data;
+}
+
+static SecKeyRef copyIdentityKey(CFArrayRef identities, CFIndex i, OSStatus *status) {
+	SecIdentityRef identity = (SecIdentityRef)CFArrayGetValueAtIndex(identities, i);
+	SecKeyRef key = NULL;
+	*status = SecIdentityCopyPrivateKey(identity, &key);
+	return key;
+}
+
+static SecKeyAlgorithm signatureAlgorithm(int kind, int hashSize) {
+	switch (kind) {
+	case sigECDSA:
+		switch (hashSize) {
+		case 32: return kSecKeyAlgorithmECDSASignatureDigestX962SHA256;
+		case 48: return kSecKeyAlgorithmECDSASignatureDigestX962SHA384;
+		case 64: return kSecKeyAlgorithmECDSASignatureDigestX962SHA512;
+		}
+		break;
+	case sigPKCS1v15:
+		switch (hashSize) {
+		case 32: return kSecKeyAlgorithmRSASignatureDigestPKCS1v15SHA256;
+		case 48: return kSecKeyAlgorithmRSASignatureDigestPKCS1v15SHA384;
+		case 64: return kSecKeyAlgorithmRSASignatureDigestPKCS1v15SHA512;
+		}
+		break;
+	case sigPSS:
+		switch (hashSize) {
+		case 32: return kSecKeyAlgorithmRSASignatureDigestPSSSHA256;
+		case 48: return kSecKeyAlgorithmRSASignatureDigestPSSSHA384;
+		case 64: return kSecKeyAlgorithmRSASignatureDigestPSSSHA512;
+		}
+		break;
+	}
+	return NULL;
+}
+
+static CFDataRef signDigest(SecKeyRef key, int kind, const UInt8 *digest, CFIndex length, CFIndex *errorCode) {
+	*errorCode = 0;
+	SecKeyAlgorithm algorithm = signatureAlgorithm(kind, (int)length);
+	if (algorithm == NULL || !SecKeyIsAlgorithmSupported(key, kSecKeyOperationTypeSign, algorithm)) {
+		*errorCode = errSecParam;
+		return NULL;
+	}
+	CFDataRef data = CFDataCreate(kCFAllocatorDefault, digest, length);
+	CFErrorRef error = NULL;
+	CFDataRef signature = SecKeyCreateSignature(key, algorithm, data, &error);
+	CFRelease(data);
+	if (error != NULL) {
+		*errorCode = CFErrorGetCode(error);
+		CFRelease(error);
+	}
+	return signature;
+}
+*/
+import "C"
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"time"
+	"unsafe"
+)
+
+func loadSystemIdentity(opts *SystemKeyStoreOptions) (*SystemIdentity, error) {
+	var status C.OSStatus
+	identities := C.copyIdentities(&status)
+	if status == C.errSecItemNotFound {
+		return nil, ErrSystemIdentityNotFound
+	}
+	if status != C.errSecSuccess {
+		return nil, fmt.Errorf("failed to search keychain: OSStatus %d", int(status))
+	}
+	defer C.CFRelease(C.CFTypeRef(identities))
+
+	// Keep the index of each parsed certificate in the search results to
+	// get the key of the one selected
+	var certs []*x509.Certificate
+	var indexes []C.CFIndex
+	count := C.CFArrayGetCount(identities)
+	for i := C.CFIndex(0); i < count; i++ {
+		data := C.copyIdentityCertificate(identities, i)
+		if data == 0 {
+			continue
+		}
+		der := C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(data)), C.int(C.CFDataGetLength(data)))
+		C.CFRelease(C.CFTypeRef(data))
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+		indexes = append(indexes, i)
+	}
+
+	selected, err := selectSystemIdentity(certs, opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	cert := certs[selected]
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported system key type %T", cert.PublicKey)
+	}
+	key := C.copyIdentityKey(identities, indexes[selected], &status)
+	if status != C.errSecSuccess {
+		return nil, fmt.Errorf("failed to get private key from keychain: OSStatus %d", int(status))
+	}
+	return &SystemIdentity{Certificate: cert, signer: &keychainSigner{key: key, public: cert.PublicKey}}, nil
+}
+
+// keychainSigner signs with a private key in the keychain.
+type keychainSigner struct {
+	key    C.SecKeyRef
+	public crypto.PublicKey
+}
+
+func (s *keychainSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *keychainSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if len(digest) == 0 || len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf("unsupported hash function %s for system key", opts.HashFunc())
+	}
+	kind := C.sigECDSA
+	if _, ok := s.public.(*rsa.PublicKey); ok {
+		kind = C.sigPKCS1v15
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			// The keychain uses salts as long as the digest
+			if pss.SaltLength != rsa.PSSSaltLengthEqualsHash && pss.SaltLength != rsa.PSSSaltLengthAuto && pss.SaltLength != len(digest) {
+				return nil, fmt.Errorf("unsupported RSASSA-PSS salt length %d for system key", pss.SaltLength)
+			}
+			kind = C.sigPSS
+		}
+	}
+
+	var errorCode C.CFIndex
+	signature := C.signDigest(s.key, C.int(kind), (*C.UInt8)(unsafe.Pointer(&digest[0])), C.CFIndex(len(digest)), &errorCode)
+	if signature == 0 {
+		return nil, fmt.Errorf("failed to sign with keychain key: error %d", int(errorCode))
+	}
+	defer C.CFRelease(C.CFTypeRef(signature))
+	// ECDSA signatures are DER-encoded, as returned by Go's signers
+	return C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(signature)), C.int(C.CFDataGetLength(signature))), nil
+}
+
+func (s *keychainSigner) Close() error {
+	if s.key != 0 {
+		C.CFRelease(C.CFTypeRef(s.key))
+		s.key = 0
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectSystemIdentity(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	newCert := func(commonName string, notBefore time.Time) *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: commonName},
+			NotBefore:    notBefore,
+			NotAfter:     notBefore.AddDate(1, 0, 0),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}
+	certs := []*x509.Certificate{
+		newCert("build01.example.com", now.AddDate(-2, 0, 0)), // expired
+		newCert("build01.example.com", now.AddDate(0, -6, 0)),
+		newCert("build01.example.com", now.AddDate(0, -1, 0)), // renewed
+		newCert("build02.example.com", now.AddDate(0, -1, 0)),
+	}
+	fingerprint := func(cert *x509.Certificate) string {
+		digest := sha256.Sum256(cert.Raw)
+		return hex.EncodeToString(digest[:])
+	}
+
+	i, err := selectSystemIdentity(certs, &SystemKeyStoreOptions{SubjectCommonName: "build01.example.com"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	i, err = selectSystemIdentity(certs, &SystemKeyStoreOptions{CertificateFingerprint: strings.ToUpper(fingerprint(certs[1]))}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i)
+
+	_, err = selectSystemIdentity(certs, &SystemKeyStoreOptions{SubjectCommonName: "build02.example.com", CertificateFingerprint: fingerprint(certs[1])}, now)
+	assert.ErrorIs(t, err, ErrSystemIdentityNotFound)
+
+	_, err = selectSystemIdentity(certs, &SystemKeyStoreOptions{CertificateFingerprint: fingerprint(certs[0])}, now)
+	assert.ErrorIs(t, err, ErrSystemIdentityNotFound)
+
+	_, err = selectSystemIdentity(nil, &SystemKeyStoreOptions{SubjectCommonName: "build01.example.com"}, now)
+	assert.ErrorIs(t, err, ErrSystemIdentityNotFound)
+}
+
+func TestLoadSystemIdentity(t *testing.T) {
+	_, err := LoadSystemIdentity(nil)
+	assert.Error(t, err)
+	_, err = LoadSystemIdentity(&SystemKeyStoreOptions{StoreName: "MY"})
+	assert.Error(t, err)
+	_, err = LoadSystemIdentity(&SystemKeyStoreOptions{CertificateFingerprint: "abcd"})
+	assert.ErrorContains(t, err, "invalid certificate fingerprint")
+
+	if runtime.GOOS == "linux" {
+		_, err = LoadSystemIdentity(&SystemKeyStoreOptions{SubjectCommonName: "build01.example.com"})
+		assert.ErrorIs(t, err, ErrSystemKeyStoreUnsupported)
+	}
+}
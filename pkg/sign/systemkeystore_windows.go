@@ -0,0 +1,245 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	crypt32                               = windows.NewLazySystemDLL("crypt32.dll")
+	procCertGetCertificateContextProperty = crypt32.NewProc("CertGetCertificateContextProperty")
+
+	ncrypt               = windows.NewLazySystemDLL("ncrypt.dll")
+	procNCryptSignHash   = ncrypt.NewProc("NCryptSignHash")
+	procNCryptFreeObject = ncrypt.NewProc("NCryptFreeObject")
+)
+
+const (
+	certKeyProvInfoPropID = 2 // CERT_KEY_PROV_INFO_PROP_ID
+
+	bcryptPadPKCS1 = 0x2 // BCRYPT_PAD_PKCS1
+	bcryptPadPSS   = 0x8 // BCRYPT_PAD_PSS
+)
+
+// BCRYPT_PKCS1_PADDING_INFO and BCRYPT_PSS_PADDING_INFO
+type bcryptPKCS1PaddingInfo struct {
+	algID *uint16
+}
+
+type bcryptPSSPaddingInfo struct {
+	algID      *uint16
+	saltLength uint32
+}
+
+func loadSystemIdentity(opts *SystemKeyStoreOptions) (*SystemIdentity, error) {
+	storeName := opts.StoreName
+	if storeName == "" {
+		storeName = "MY"
+	}
+	storeNamePtr, err := windows.UTF16PtrFromString(storeName)
+	if err != nil {
+		return nil, err
+	}
+	location := uint32(windows.CERT_SYSTEM_STORE_CURRENT_USER)
+	if opts.LocalMachine {
+		location = windows.CERT_SYSTEM_STORE_LOCAL_MACHINE
+	}
+	store, err := windows.CertOpenStore(windows.CERT_STORE_PROV_SYSTEM_W, 0, 0,
+		location|windows.CERT_STORE_OPEN_EXISTING_FLAG|windows.CERT_STORE_READONLY_FLAG,
+		uintptr(unsafe.Pointer(storeNamePtr)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open certificate store %q: %w", storeName, err)
+	}
+	defer windows.CertCloseStore(store, 0) //nolint:errcheck
+
+	// Collect the certificates that have a private key, keeping their
+	// contexts to acquire the key of the one selected
+	var certs []*x509.Certificate
+	var contexts []*windows.CertContext
+	defer func() {
+		for _, ctx := range contexts {
+			windows.CertFreeCertificateContext(ctx) //nolint:errcheck
+		}
+	}()
+	var ctx *windows.CertContext
+	for {
+		ctx, err = windows.CertEnumCertificatesInStore(store, ctx)
+		if err != nil {
+			break
+		}
+		if !hasPrivateKey(ctx) {
+			continue
+		}
+		cert, err := x509.ParseCertificate(unsafe.Slice(ctx.EncodedCert, ctx.Length))
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+		contexts = append(contexts, windows.CertDuplicateCertificateContext(ctx))
+	}
+
+	i, err := selectSystemIdentity(certs, opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newNCryptSigner(contexts[i], certs[i].PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &SystemIdentity{Certificate: certs[i], signer: signer}, nil
+}
+
+func hasPrivateKey(ctx *windows.CertContext) bool {
+	var size uint32
+	r, _, _ := procCertGetCertificateContextProperty.Call(uintptr(unsafe.Pointer(ctx)), certKeyProvInfoPropID, 0, uintptr(unsafe.Pointer(&size)))
+	return r != 0
+}
+
+// ncryptSigner signs with a CNG key. Keys held by legacy CryptoAPI
+// providers are not supported.
+type ncryptSigner struct {
+	key      windows.Handle
+	mustFree bool
+	public   crypto.PublicKey
+}
+
+func newNCryptSigner(ctx *windows.CertContext, public crypto.PublicKey) (*ncryptSigner, error) {
+	switch public.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported system key type %T", public)
+	}
+	var key windows.Handle
+	var keySpec uint32
+	var mustFree bool
+	err := windows.CryptAcquireCertificatePrivateKey(ctx,
+		windows.CRYPT_ACQUIRE_CACHE_FLAG|windows.CRYPT_ACQUIRE_ONLY_NCRYPT_KEY_FLAG,
+		nil, &key, &keySpec, &mustFree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire private key: %w", err)
+	}
+	if keySpec != windows.CERT_NCRYPT_KEY_SPEC {
+		return nil, fmt.Errorf("private key is not a CNG key")
+	}
+	return &ncryptSigner{key: key, mustFree: mustFree, public: public}, nil
+}
+
+func (s *ncryptSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+func (s *ncryptSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var padding unsafe.Pointer
+	var flags uint32
+	if _, ok := s.public.(*rsa.PublicKey); ok {
+		algID, err := cngHashAlgorithm(opts.HashFunc())
+		if err != nil {
+			return nil, err
+		}
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			saltLength := pss.SaltLength
+			if saltLength == rsa.PSSSaltLengthEqualsHash || saltLength == rsa.PSSSaltLengthAuto {
+				saltLength = opts.HashFunc().Size()
+			}
+			padding = unsafe.Pointer(&bcryptPSSPaddingInfo{algID: algID, saltLength: uint32(saltLength)})
+			flags = bcryptPadPSS
+		} else {
+			padding = unsafe.Pointer(&bcryptPKCS1PaddingInfo{algID: algID})
+			flags = bcryptPadPKCS1
+		}
+	}
+
+	var size uint32
+	if err := s.signHash(padding, digest, nil, &size, flags); err != nil {
+		return nil, err
+	}
+	signature := make([]byte, size)
+	if err := s.signHash(padding, digest, signature, &size, flags); err != nil {
+		return nil, err
+	}
+	signature = signature[:size]
+
+	if _, ok := s.public.(*ecdsa.PublicKey); ok {
+		// CNG returns r and s concatenated, rather than DER-encoded
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(signature[:half]),
+			S: new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+func (s *ncryptSigner) signHash(padding unsafe.Pointer, digest, signature []byte, size *uint32, flags uint32) error {
+	var signaturePtr *byte
+	if len(signature) > 0 {
+		signaturePtr = &signature[0]
+	}
+	status, _, _ := procNCryptSignHash.Call(
+		uintptr(s.key),
+		uintptr(padding),
+		uintptr(unsafe.Pointer(&digest[0])),
+		uintptr(len(digest)),
+		uintptr(unsafe.Pointer(signaturePtr)),
+		uintptr(len(signature)),
+		uintptr(unsafe.Pointer(size)),
+		uintptr(flags),
+	)
+	if status != 0 {
+		return fmt.Errorf("NCryptSignHash failed with status 0x%x", status)
+	}
+	return nil
+}
+
+func (s *ncryptSigner) Close() error {
+	if !s.mustFree {
+		return nil
+	}
+	status, _, _ := procNCryptFreeObject.Call(uintptr(s.key))
+	if status != 0 {
+		return fmt.Errorf("NCryptFreeObject failed with status 0x%x", status)
+	}
+	s.mustFree = false
+	return nil
+}
+
+func cngHashAlgorithm(hash crypto.Hash) (*uint16, error) {
+	var name string
+	switch hash {
+	case crypto.SHA256:
+		name = "SHA256"
+	case crypto.SHA384:
+		name = "SHA384"
+	case crypto.SHA512:
+		name = "SHA512"
+	default:
+		return nil, fmt.Errorf("unsupported hash function %s for system key", hash)
+	}
+	return windows.UTF16PtrFromString(name)
+}
@@ -18,74 +18,198 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/digitorus/timestamp"
 	tsaclient "github.com/sigstore/timestamp-authority/pkg/client"
 	tsagenclient "github.com/sigstore/timestamp-authority/pkg/generated/client/timestamp"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
 )
 
+var ErrTimestampImprintMismatch = errors.New("timestamp message imprint does not match signature")
+
+// TimestampAuthorityOptions configures a timestamp authority client created
+// by NewTimestampAuthority. A zero or negative Timeout means the default
+// timeout.
+//
+// Deprecated: Use NewTimestampAuthorityClient and ClientOption instead.
 type TimestampAuthorityOptions struct {
 	BaseURL        string
 	Timeout        time.Duration
 	LibraryVersion string
+	// Optional User-Agent configuration, allowing an application to add its
+	// own product token or to hide version information
+	UserAgent *util.UserAgent
+	// Optional logger for debug logs of requests
+	Logger *slog.Logger
 }
 
 type TimestampAuthority struct {
-	options *TimestampAuthorityOptions
+	baseURL string
+	config  clientConfig
+}
+
+// NewTimestampAuthorityClient returns a client for the timestamp authority
+// whose timestamp endpoint is baseURL.
+func NewTimestampAuthorityClient(baseURL string, opts ...ClientOption) (*TimestampAuthority, error) {
+	config, err := newClientConfig("timestamp authority", baseURL, 0, opts)
+	if err != nil {
+		return nil, err
+	}
+	if config.idTokenValidation != nil {
+		return nil, errors.New("identity token validation only applies to Fulcio clients")
+	}
+	return &TimestampAuthority{baseURL: baseURL, config: config}, nil
 }
 
+// NewTimestampAuthority returns a timestamp authority client configured by
+// opts.
+//
+// Deprecated: Use NewTimestampAuthorityClient instead, which validates its
+// options.
 func NewTimestampAuthority(opts *TimestampAuthorityOptions) *TimestampAuthority {
-	return &TimestampAuthority{
-		options: opts,
+	ta := &TimestampAuthority{}
+	if opts != nil {
+		ta.baseURL = opts.BaseURL
+		ta.config = clientConfig{
+			libraryVersion: opts.LibraryVersion,
+			userAgent:      opts.UserAgent,
+			logger:         opts.Logger,
+		}
+		if opts.Timeout > 0 {
+			ta.config.timeout = opts.Timeout
+		}
 	}
+	return ta
 }
 
 func (ta *TimestampAuthority) GetTimestamp(signature []byte) ([]byte, error) {
-	signatureHash := sha256.Sum256(signature)
-
-	req := &timestamp.Request{
-		Certificates:  true,
-		HashAlgorithm: crypto.SHA256,
-		HashedMessage: signatureHash[:],
-	}
-	reqBytes, err := req.Marshal()
+	req, reqBytes, err := timestampRequest(signature)
 	if err != nil {
 		return nil, err
 	}
 
-	client, err := tsaclient.GetTimestampClient(ta.options.BaseURL, tsaclient.WithUserAgent(constructUserAgent(ta.options.LibraryVersion)), tsaclient.WithContentType(tsaclient.TimestampQueryMediaType))
+	client, err := tsaclient.GetTimestampClient(ta.baseURL, tsaclient.WithContentType(tsaclient.TimestampQueryMediaType))
 	if err != nil {
 		return nil, err
 	}
 
 	clientParams := tsagenclient.NewGetTimestampResponseParams()
-	if ta.options.Timeout != 0 {
-		clientParams.SetTimeout(ta.options.Timeout)
+	if ta.config.timeout > 0 {
+		clientParams.SetTimeout(ta.config.timeout)
 	}
+	clientParams.SetHTTPClient(ta.config.client())
 	clientParams.Request = io.NopCloser(bytes.NewReader(reqBytes))
 
+	logger := util.Logger(ta.config.logger)
+	logger.Debug("requesting timestamp", "url", ta.baseURL)
+	start := time.Now()
+
 	var respBytes bytes.Buffer
 	_, err = client.Timestamp.GetTimestampResponse(clientParams, &respBytes)
 	if err != nil {
+		logger.Debug("timestamp request failed", "url", ta.baseURL, "error", err)
 		return nil, err
 	}
+	logger.Debug("timestamp authority responded", "url", ta.baseURL, "duration", time.Since(start))
 
-	_, err = timestamp.ParseResponse(respBytes.Bytes())
+	ts, err := timestamp.ParseResponse(respBytes.Bytes())
 	if err != nil {
 		return nil, err
 	}
 
+	if ts.HashAlgorithm != req.HashAlgorithm || !bytes.Equal(ts.HashedMessage, req.HashedMessage) {
+		logger.Debug("timestamp message imprint does not match request", "url", ta.baseURL)
+		return nil, fmt.Errorf("%w from %s", ErrTimestampImprintMismatch, ta.baseURL)
+	}
+
 	return respBytes.Bytes(), nil
 }
 
-func constructUserAgent(version string) string {
-	userAgent := "sigstore-go"
-	if version != "" {
-		userAgent += "/"
-		userAgent += version
+// timestampRequest returns an RFC 3161 timestamp request for the signature,
+// and its DER encoding.
+func timestampRequest(signature []byte) (*timestamp.Request, []byte, error) {
+	signatureHash := sha256.Sum256(signature)
+
+	req := &timestamp.Request{
+		Certificates:  true,
+		HashAlgorithm: crypto.SHA256,
+		HashedMessage: signatureHash[:],
+	}
+	reqBytes, err := req.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, reqBytes, nil
+}
+
+// TimestampAuthorityFailure records a timestamp authority that did not
+// provide a usable timestamp.
+type TimestampAuthorityFailure struct {
+	URL string
+	Err error
+}
+
+// TimestampAuthorityError is returned when fewer signed timestamps than
+// required could be obtained. It lists every timestamp authority that
+// failed.
+type TimestampAuthorityError struct {
+	Threshold int
+	Obtained  int
+	Failures  []TimestampAuthorityFailure
+}
+
+func (e *TimestampAuthorityError) Error() string {
+	failures := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		failures = append(failures, fmt.Sprintf("%s: %v", failure.URL, failure.Err))
 	}
+	return fmt.Sprintf("obtained %d of %d required signed timestamps; failed timestamp authorities: %s", e.Obtained, e.Threshold, strings.Join(failures, "; "))
+}
 
-	return userAgent
+func (e *TimestampAuthorityError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// GetTimestamps requests signed timestamps for the signature from the
+// timestamp authorities in order, until threshold timestamps are obtained.
+//
+// A timestamp authority that returns a timestamp for a different message
+// imprint is misbehaving; its timestamp is discarded and the next timestamp
+// authority is asked instead. Any other error is returned immediately, as
+// are a TimestampAuthorityError if the timestamp authorities run out before
+// the threshold is met. The misbehaving timestamp authorities are reported
+// either way.
+func GetTimestamps(timestampAuthorities []*TimestampAuthority, signature []byte, threshold int) ([][]byte, []TimestampAuthorityFailure, error) {
+	var timestamps [][]byte
+	var failures []TimestampAuthorityFailure
+	for _, timestampAuthority := range timestampAuthorities {
+		if len(timestamps) == threshold {
+			break
+		}
+		timestampBytes, err := timestampAuthority.GetTimestamp(signature)
+		if errors.Is(err, ErrTimestampImprintMismatch) {
+			failures = append(failures, TimestampAuthorityFailure{URL: timestampAuthority.baseURL, Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, failures, err
+		}
+		timestamps = append(timestamps, timestampBytes)
+	}
+
+	if len(timestamps) < threshold {
+		return nil, failures, &TimestampAuthorityError{Threshold: threshold, Obtained: len(timestamps), Failures: failures}
+	}
+	return timestamps, failures, nil
 }
@@ -0,0 +1,131 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTSA starts a timestamp authority that, if misbehaving, timestamps
+// a different message than the one requested.
+func newTestTSA(t *testing.T, misbehaving bool) *httptest.Server {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test tsa"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req, err := timestamp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		hashedMessage := req.HashedMessage
+		if misbehaving {
+			hashedMessage = make([]byte, len(hashedMessage))
+		}
+		ts := timestamp.Timestamp{
+			HashAlgorithm: req.HashAlgorithm,
+			HashedMessage: hashedMessage,
+			Time:          time.Now(),
+			Policy:        asn1.ObjectIdentifier{1, 2, 3},
+			// Embed the certificate, so its expiry is known
+			AddTSACertificate: true,
+		}
+		resp, err := ts.CreateResponseWithOpts(cert, key, crypto.SHA256)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_GetTimestampsImprintMismatch(t *testing.T) {
+	bad := NewTimestampAuthority(&TimestampAuthorityOptions{BaseURL: newTestTSA(t, true).URL})
+	good := NewTimestampAuthority(&TimestampAuthorityOptions{BaseURL: newTestTSA(t, false).URL})
+	signature := []byte("signature")
+
+	_, err := bad.GetTimestamp(signature)
+	assert.True(t, errors.Is(err, ErrTimestampImprintMismatch))
+
+	timestamps, failures, err := GetTimestamps([]*TimestampAuthority{bad, good}, signature, 1)
+	assert.NoError(t, err)
+	assert.Len(t, timestamps, 1)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, bad.baseURL, failures[0].URL)
+
+	_, _, err = GetTimestamps([]*TimestampAuthority{good, bad}, signature, 2)
+	var tsaErr *TimestampAuthorityError
+	assert.True(t, errors.As(err, &tsaErr))
+	assert.Equal(t, 1, tsaErr.Obtained)
+	assert.Contains(t, err.Error(), bad.baseURL)
+	assert.True(t, errors.Is(err, ErrTimestampImprintMismatch))
+
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+	bundle, err := Bundle(&PlainData{Data: []byte("hello")}, keypair, BundleOptions{
+		TimestampAuthorities:        []*TimestampAuthority{bad, good},
+		TimestampAuthorityThreshold: 1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, bundle.VerificationMaterial.TimestampVerificationData.Rfc3161Timestamps, 1)
+
+	// By default, one timestamp is needed, and the failed timestamp
+	// authorities are reported
+	result, err := BundleWithResult(&PlainData{Data: []byte("hello")}, keypair, BundleOptions{
+		TimestampAuthorities: []*TimestampAuthority{bad, good},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result.Timestamps, 1)
+	assert.Len(t, result.TimestampAuthorityFailures, 1)
+	assert.Equal(t, bad.baseURL, result.TimestampAuthorityFailures[0].URL)
+
+	_, err = Bundle(&PlainData{Data: []byte("hello")}, keypair, BundleOptions{
+		TimestampAuthorities:        []*TimestampAuthority{bad, good},
+		TimestampAuthorityThreshold: 2,
+	})
+	assert.True(t, errors.Is(err, ErrTimestampImprintMismatch))
+}
@@ -19,6 +19,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
@@ -31,7 +33,9 @@ import (
 	"github.com/sigstore/rekor/pkg/types"
 	"github.com/sigstore/rekor/pkg/types/dsse"
 	"github.com/sigstore/rekor/pkg/types/hashedrekord"
-	"github.com/sigstore/rekor/pkg/util"
+	rekorutil "github.com/sigstore/rekor/pkg/util"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
 
 	// To initialize rekor types
 	_ "github.com/sigstore/rekor/pkg/types/dsse/v0.0.1"
@@ -43,9 +47,14 @@ type Transparency interface {
 }
 
 type Rekor struct {
-	options *RekorOptions
+	baseURL string
+	config  clientConfig
 }
 
+// RekorOptions configures a Rekor client created by NewRekor. A zero or
+// negative Timeout means the default timeout.
+//
+// Deprecated: Use NewRekorClient and ClientOption instead.
 type RekorOptions struct {
 	// URL of Fulcio instance
 	BaseURL string
@@ -53,21 +62,128 @@ type RekorOptions struct {
 	Timeout time.Duration
 	// Optional version string for user agent
 	LibraryVersion string
+	// Optional User-Agent configuration, allowing an application to add its
+	// own product token or to hide version information
+	UserAgent *util.UserAgent
+	// Optional logger for debug logs of requests
+	Logger *slog.Logger
 }
 
+// NewRekorClient returns a client for the Rekor instance at baseURL.
+func NewRekorClient(baseURL string, opts ...ClientOption) (*Rekor, error) {
+	config, err := newClientConfig("Rekor", baseURL, defaultRekorRetries, opts)
+	if err != nil {
+		return nil, err
+	}
+	if config.idTokenValidation != nil {
+		return nil, errors.New("identity token validation only applies to Fulcio clients")
+	}
+	return &Rekor{baseURL: baseURL, config: config}, nil
+}
+
+// NewRekor returns a Rekor client configured by opts.
+//
+// Deprecated: Use NewRekorClient instead, which validates its options.
 func NewRekor(opts *RekorOptions) *Rekor {
-	return &Rekor{options: opts}
+	r := &Rekor{config: clientConfig{retries: defaultRekorRetries}}
+	if opts != nil {
+		r.baseURL = opts.BaseURL
+		r.config.libraryVersion = opts.LibraryVersion
+		r.config.userAgent = opts.UserAgent
+		r.config.logger = opts.Logger
+		if opts.Timeout > 0 {
+			r.config.timeout = opts.Timeout
+		}
+	}
+	return r
 }
 
 func (r *Rekor) GetTransparencyLogEntry(pubKeyPEM []byte, b *protobundle.Bundle) error {
+	verificationMaterial := b.GetVerificationMaterial()
+	if b.GetMessageSignature() != nil && verificationMaterial.GetCertificate() == nil && verificationMaterial.GetX509CertificateChain() == nil {
+		return errors.New("hashedrekord requires X.509 certificate")
+	}
+
+	proposedEntry, err := newProposedEntry(pubKeyPEM, b)
+	if err != nil {
+		return err
+	}
+
+	params := entries.NewCreateLogEntryParams()
+	if r.config.timeout > 0 {
+		params.SetTimeout(r.config.timeout)
+	}
+	params.SetHTTPClient(r.config.client())
+	params.SetProposedEntry(proposedEntry)
+
+	client, err := client.GetRekorClient(r.baseURL)
+	if err != nil {
+		return err
+	}
+
+	logger := util.Logger(r.config.logger)
+	logger.Debug("creating transparency log entry", "url", r.baseURL, "kind", proposedEntry.Kind())
+	start := time.Now()
+
+	resp, err := client.Entries.CreateLogEntry(params)
+	if err != nil {
+		logger.Debug("transparency log request failed", "url", r.baseURL, "error", err)
+		return err
+	}
+	logger.Debug("transparency log entry created", "url", r.baseURL, "uuid", resp.ETag, "duration", time.Since(start))
+
+	entry := resp.Payload[resp.ETag]
+	tlogEntry, err := tle.GenerateTransparencyLogEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	if b.VerificationMaterial.TlogEntries == nil {
+		b.VerificationMaterial.TlogEntries = []*protorekor.TransparencyLogEntry{}
+	}
+
+	b.VerificationMaterial.TlogEntries = append(b.VerificationMaterial.TlogEntries, tlogEntry)
+
+	return nil
+}
+
+// GetLogEntryByIndex returns the UUID and the entry at the index of the
+// log, including its inclusion proof.
+func (r *Rekor) GetLogEntryByIndex(ctx context.Context, logIndex int64) (string, models.LogEntryAnon, error) {
+	params := entries.NewGetLogEntryByIndexParamsWithContext(ctx)
+	if r.config.timeout > 0 {
+		params.SetTimeout(r.config.timeout)
+	}
+	params.SetHTTPClient(r.config.client())
+	params.SetLogIndex(logIndex)
+
+	client, err := client.GetRekorClient(r.baseURL)
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+	resp, err := client.Entries.GetLogEntryByIndex(params)
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+	if len(resp.Payload) != 1 {
+		return "", models.LogEntryAnon{}, fmt.Errorf("expected one log entry, got %d", len(resp.Payload))
+	}
+	for uuid, entry := range resp.Payload {
+		return uuid, entry, nil
+	}
+	return "", models.LogEntryAnon{}, nil
+}
+
+// newProposedEntry returns the Rekor entry for the bundle's signature: a
+// dsse entry for DSSE envelopes, or a hashedrekord entry for message
+// signatures.
+func newProposedEntry(pubKeyPEM []byte, b *protobundle.Bundle) (models.ProposedEntry, error) {
 	artifactProperties := types.ArtifactProperties{
 		PublicKeyBytes: [][]byte{pubKeyPEM},
 	}
 
 	dsseEnvelope := b.GetDsseEnvelope()
 	messageSignature := b.GetMessageSignature()
-	verificationMaterial := b.GetVerificationMaterial()
-	bundleCertificate := verificationMaterial.GetCertificate()
 
 	var proposedEntry models.ProposedEntry
 
@@ -77,64 +193,31 @@ func (r *Rekor) GetTransparencyLogEntry(pubKeyPEM []byte, b *protobundle.Bundle)
 
 		artifactBytes, err := json.Marshal(dsseEnvelope)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		artifactProperties.ArtifactBytes = artifactBytes
 
 		proposedEntry, err = dsseType.CreateProposedEntry(context.TODO(), "", artifactProperties)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	case messageSignature != nil:
 		hashedrekordType := hashedrekord.New()
 
-		if bundleCertificate == nil {
-			return errors.New("hashedrekord requires X.509 certificate")
-		}
-
 		hexDigest := hex.EncodeToString(messageSignature.MessageDigest.Digest)
 
 		artifactProperties.PKIFormat = string(pki.X509)
 		artifactProperties.SignatureBytes = messageSignature.Signature
-		artifactProperties.ArtifactHash = util.PrefixSHA(hexDigest)
+		artifactProperties.ArtifactHash = rekorutil.PrefixSHA(hexDigest)
 
 		var err error
 		proposedEntry, err = hashedrekordType.CreateProposedEntry(context.TODO(), "", artifactProperties)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	default:
-		return errors.New("unable to find signature in bundle")
+		return nil, errors.New("unable to find signature in bundle")
 	}
-
-	params := entries.NewCreateLogEntryParams()
-	if r.options.Timeout > 0 {
-		params.SetTimeout(r.options.Timeout)
-	}
-	params.SetProposedEntry(proposedEntry)
-
-	client, err := client.GetRekorClient(r.options.BaseURL, client.WithUserAgent(constructUserAgent(r.options.LibraryVersion)))
-	if err != nil {
-		return err
-	}
-
-	resp, err := client.Entries.CreateLogEntry(params)
-	if err != nil {
-		return err
-	}
-
-	entry := resp.Payload[resp.ETag]
-	tlogEntry, err := tle.GenerateTransparencyLogEntry(entry)
-	if err != nil {
-		return err
-	}
-
-	if b.VerificationMaterial.TlogEntries == nil {
-		b.VerificationMaterial.TlogEntries = []*protorekor.TransparencyLogEntry{}
-	}
-
-	b.VerificationMaterial.TlogEntries = append(b.VerificationMaterial.TlogEntries, tlogEntry)
-
-	return nil
+	return proposedEntry, nil
 }
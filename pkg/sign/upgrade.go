@@ -0,0 +1,175 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/tle"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/audit"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+// UpgradeInclusionProofsOptions configures UpgradeInclusionProofs.
+type UpgradeInclusionProofsOptions struct {
+	// Optional context for requests to transparency logs
+	Context context.Context
+	// Optional function returning the fetcher for a log. Defaults to a
+	// Rekor client for the log's base URL.
+	Fetcher func(log *root.TransparencyLog) (audit.LogEntryFetcher, error)
+}
+
+// UpgradeInclusionProofs adds inclusion proofs to the log entries of a
+// bundle that only have inclusion promises (signed entry timestamps), such
+// as v0.1 bundles, and upgrades the bundle to v0.3.
+//
+// Each entry's promise is first verified with the trusted material. The
+// entry is then retrieved from its log, and its inclusion proof is verified
+// against the log's signed checkpoint before it is added to the entry. As
+// v0.3 bundles hold a single certificate, an X.509 certificate chain is
+// replaced by its leaf certificate, which is all that verification uses.
+//
+// If upgrading fails, the bundle is left unchanged. UpgradeInclusionProofs
+// reports whether the bundle was changed.
+func UpgradeInclusionProofs(b *bundle.ProtobufBundle, trustedMaterial root.TrustedMaterial, opts UpgradeInclusionProofsOptions) (bool, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	newFetcher := opts.Fetcher
+	if newFetcher == nil {
+		newFetcher = newRekorFetcher
+	}
+
+	entries, err := b.TlogEntries()
+	if err != nil {
+		return false, err
+	}
+	upgraded, ok := proto.Clone(b.Bundle).(*protobundle.Bundle)
+	if !ok {
+		return false, errors.New("failed to copy bundle")
+	}
+
+	changed := false
+	for i, entry := range entries {
+		if entry.HasInclusionProof() {
+			continue
+		}
+		if err := tlog.VerifySET(entry, trustedMaterial.RekorLogs()); err != nil {
+			return false, fmt.Errorf("verifying inclusion promise of log entry %d: %w", entry.LogIndex(), err)
+		}
+		log, err := root.FindTransparencyLog(trustedMaterial.RekorLogs(), hex.EncodeToString([]byte(entry.LogKeyID())), entry.IntegratedTime())
+		if err != nil {
+			return false, err
+		}
+		fetcher, err := newFetcher(log)
+		if err != nil {
+			return false, err
+		}
+		uuid, response, err := fetcher.GetLogEntryByIndex(ctx, entry.LogIndex())
+		if err != nil {
+			return false, fmt.Errorf("retrieving log entry %d from %s: %w", entry.LogIndex(), log.BaseURL, err)
+		}
+		if err := checkRetrievedEntry(entry, uuid, response, log); err != nil {
+			return false, err
+		}
+
+		retrieved, err := tle.GenerateTransparencyLogEntry(response)
+		if err != nil {
+			return false, err
+		}
+		upgraded.VerificationMaterial.TlogEntries[i].InclusionProof = retrieved.GetInclusionProof()
+		changed = true
+	}
+
+	if !b.MinVersion("v0.3") {
+		if upgraded.MediaType, err = bundle.MediaTypeString("0.3"); err != nil {
+			return false, err
+		}
+		if chain := upgraded.GetVerificationMaterial().GetX509CertificateChain(); chain != nil {
+			if len(chain.GetCertificates()) == 0 {
+				return false, bundle.ErrMissingVerificationMaterial
+			}
+			upgraded.VerificationMaterial.Content = &protobundle.VerificationMaterial_Certificate{
+				Certificate: chain.GetCertificates()[0],
+			}
+		}
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if _, err := bundle.NewProtobufBundle(upgraded); err != nil {
+		return false, fmt.Errorf("upgraded bundle is invalid: %w", err)
+	}
+	// Replace the bundle's contents rather than the bundle, which keeps
+	// its detached SCTs, and refresh what it records about its entries
+	b.Bundle = upgraded
+	if _, err := b.TlogEntries(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkRetrievedEntry checks that an entry retrieved from a log is the
+// bundle's entry, and that its inclusion proof verifies against the log's
+// signed checkpoint.
+func checkRetrievedEntry(bundleEntry *tlog.Entry, uuid string, response models.LogEntryAnon, log *root.TransparencyLog) error {
+	entry, err := tlog.NewEntryFromLogEntry(uuid, response)
+	if err != nil {
+		return fmt.Errorf("invalid log entry %d: %w", bundleEntry.LogIndex(), err)
+	}
+	if entry.LogKeyID() != bundleEntry.LogKeyID() || entry.LogIndex() != bundleEntry.LogIndex() || !entry.IntegratedTime().Equal(bundleEntry.IntegratedTime()) {
+		return fmt.Errorf("log returned an entry other than log entry %d of the bundle", bundleEntry.LogIndex())
+	}
+	body, err := entry.CanonicalizedBody()
+	if err != nil {
+		return err
+	}
+	bundleBody, err := bundleEntry.CanonicalizedBody()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(body, bundleBody) {
+		return fmt.Errorf("log entry %d does not match the bundle's log entry body", bundleEntry.LogIndex())
+	}
+
+	if _, _, err := entry.InclusionProof(); err != nil {
+		return fmt.Errorf("log returned no inclusion proof for log entry %d: %w", bundleEntry.LogIndex(), err)
+	}
+	verifier, err := log.Verifier()
+	if err != nil {
+		return err
+	}
+	if err := tlog.VerifyInclusion(entry, verifier); err != nil {
+		return fmt.Errorf("verifying inclusion proof of log entry %d: %w", bundleEntry.LogIndex(), err)
+	}
+	return nil
+}
+
+func newRekorFetcher(log *root.TransparencyLog) (audit.LogEntryFetcher, error) {
+	return NewRekorClient(log.BaseURL)
+}
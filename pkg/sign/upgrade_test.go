@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/audit"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	testdata "github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+type fakeLogEntryFetcher struct {
+	entries map[int64]models.LogEntryAnon
+}
+
+func (f *fakeLogEntryFetcher) GetLogEntryByIndex(_ context.Context, logIndex int64) (string, models.LogEntryAnon, error) {
+	entry, ok := f.entries[logIndex]
+	if !ok {
+		return "", models.LogEntryAnon{}, errors.New("not found")
+	}
+	return "", entry, nil
+}
+
+func Test_UpgradeInclusionProofs(t *testing.T) {
+	tr := testdata.PublicGoodTrustedMaterialRoot(t)
+	b := testdata.SigstoreJS200ProvenanceBundle(t)
+
+	// The log's response is the bundle's entry, which has an inclusion
+	// proof; the bundle keeps only its inclusion promise
+	fetcher := &fakeLogEntryFetcher{entries: map[int64]models.LogEntryAnon{}}
+	for _, tle := range b.VerificationMaterial.TlogEntries {
+		proof := tle.InclusionProof
+		var hashes []string
+		for _, h := range proof.Hashes {
+			hashes = append(hashes, hex.EncodeToString(h))
+		}
+		fetcher.entries[tle.LogIndex] = models.LogEntryAnon{
+			Body:           base64.StdEncoding.EncodeToString(tle.CanonicalizedBody),
+			IntegratedTime: swag.Int64(tle.IntegratedTime),
+			LogIndex:       swag.Int64(tle.LogIndex),
+			LogID:          swag.String(hex.EncodeToString(tle.LogId.KeyId)),
+			Verification: &models.LogEntryAnonVerification{
+				SignedEntryTimestamp: tle.InclusionPromise.SignedEntryTimestamp,
+				InclusionProof: &models.InclusionProof{
+					LogIndex:   swag.Int64(proof.LogIndex),
+					TreeSize:   swag.Int64(proof.TreeSize),
+					RootHash:   swag.String(hex.EncodeToString(proof.RootHash)),
+					Hashes:     hashes,
+					Checkpoint: swag.String(proof.Checkpoint.Envelope),
+				},
+			},
+		}
+		tle.InclusionProof = nil
+	}
+	opts := UpgradeInclusionProofsOptions{
+		Fetcher: func(*root.TransparencyLog) (audit.LogEntryFetcher, error) { return fetcher, nil },
+	}
+	original := proto.Clone(b.Bundle).(*protobundle.Bundle)
+
+	t.Run("tampered inclusion proof", func(t *testing.T) {
+		tampered := testdata.SigstoreJS200ProvenanceBundle(t)
+		tampered.VerificationMaterial.TlogEntries[0].InclusionProof = nil
+		tamperedFetcher := &fakeLogEntryFetcher{entries: map[int64]models.LogEntryAnon{}}
+		for index, entry := range fetcher.entries {
+			proof := *entry.Verification.InclusionProof
+			proof.RootHash = swag.String(hex.EncodeToString(make([]byte, 32)))
+			entry.Verification = &models.LogEntryAnonVerification{InclusionProof: &proof}
+			tamperedFetcher.entries[index] = entry
+		}
+		_, err := UpgradeInclusionProofs(tampered, tr, UpgradeInclusionProofsOptions{
+			Fetcher: func(*root.TransparencyLog) (audit.LogEntryFetcher, error) { return tamperedFetcher, nil },
+		})
+		assert.ErrorContains(t, err, "verifying inclusion proof")
+		assert.False(t, tampered.MinVersion("v0.3"))
+	})
+
+	t.Run("tampered inclusion promise", func(t *testing.T) {
+		tampered := testdata.SigstoreJS200ProvenanceBundle(t)
+		tampered.VerificationMaterial.TlogEntries[0].InclusionProof = nil
+		tampered.VerificationMaterial.TlogEntries[0].IntegratedTime++
+		_, err := UpgradeInclusionProofs(tampered, tr, opts)
+		assert.ErrorContains(t, err, "verifying inclusion promise")
+	})
+
+	upgraded, err := UpgradeInclusionProofs(b, tr, opts)
+	require.NoError(t, err)
+	assert.True(t, upgraded)
+	assert.True(t, b.MinVersion("v0.3"))
+	assert.True(t, b.HasInclusionProof())
+	assert.NotNil(t, b.VerificationMaterial.GetCertificate())
+	assert.True(t, proto.Equal(original.GetDsseEnvelope(), b.GetDsseEnvelope()))
+
+	verifier, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	require.NoError(t, err)
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()))
+	require.NoError(t, err)
+
+	// Upgrading again changes nothing
+	upgraded, err = UpgradeInclusionProofs(b, tr, opts)
+	require.NoError(t, err)
+	assert.False(t, upgraded)
+}
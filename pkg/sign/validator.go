@@ -0,0 +1,84 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// ErrSigningDenied is returned when a Validator denies a signing request.
+var ErrSigningDenied = errors.New("signing denied")
+
+// SigningRequest is what is about to be signed, for a Validator to inspect.
+type SigningRequest struct {
+	// Content to be signed
+	Content Content
+	// Statement is the in-toto statement of DSSEData content with the
+	// in-toto payload type, or nil for other content
+	Statement *in_toto.Statement
+	// IDToken holds the claims of BundleOptions.IDToken, or nil if there is
+	// no identity token. Its signature has not been verified, so claims are
+	// only trustworthy once Fulcio has issued a certificate for them; the
+	// certificate's identity is checked again at verification time.
+	IDToken *IDTokenClaims
+}
+
+// Validator enforces a signing policy, such as only signing provenance for
+// approved repositories. Bundle calls it before any signature is produced,
+// including the proof of possession sent to Fulcio, and aborts if it
+// returns an error.
+type Validator interface {
+	ValidateSigningRequest(request *SigningRequest) error
+}
+
+// ValidatorFunc adapts a function to a Validator.
+type ValidatorFunc func(request *SigningRequest) error
+
+func (f ValidatorFunc) ValidateSigningRequest(request *SigningRequest) error {
+	return f(request)
+}
+
+// validateSigningRequest builds the signing request for content, and checks
+// it with opts.Validator.
+func validateSigningRequest(content Content, opts BundleOptions) error {
+	if opts.Validator == nil {
+		return nil
+	}
+
+	request := &SigningRequest{Content: content}
+	if dsseData, ok := content.(*DSSEData); ok && dsseData.PayloadType == bundle.IntotoMediaType {
+		if err := json.Unmarshal(dsseData.Data, &request.Statement); err != nil {
+			return fmt.Errorf("failed to parse in-toto statement: %w", err)
+		}
+	}
+	if opts.IDToken != "" {
+		claims, err := ParseIDToken(opts.IDToken)
+		if err != nil {
+			return err
+		}
+		request.IDToken = claims
+	}
+
+	if err := opts.Validator.ValidateSigningRequest(request); err != nil {
+		return fmt.Errorf("%w: %w", ErrSigningDenied, err)
+	}
+	return nil
+}
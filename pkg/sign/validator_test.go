@@ -0,0 +1,88 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+type countingKeypair struct {
+	Keypair
+	signatures int
+}
+
+func (k *countingKeypair) SignData(data []byte) ([]byte, []byte, error) {
+	k.signatures++
+	return k.Keypair.SignData(data)
+}
+
+func TestValidator(t *testing.T) {
+	ephemeral, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	keypair := &countingKeypair{Keypair: ephemeral}
+
+	approvedRepository := ValidatorFunc(func(request *SigningRequest) error {
+		if request.Statement == nil {
+			return errors.New("only in-toto statements may be signed")
+		}
+		if request.Statement.PredicateType != "https://slsa.dev/provenance/v1" {
+			return errors.New("only provenance may be signed")
+		}
+		if request.IDToken == nil || request.IDToken.Subject != "repo:example/project" {
+			return errors.New("repository is not approved")
+		}
+		return nil
+	})
+	statement := &DSSEData{
+		Data:        []byte(`{"_type": "https://in-toto.io/Statement/v1", "subject": [{"name": "app", "digest": {"sha256": "00"}}], "predicateType": "https://slsa.dev/provenance/v1", "predicate": {}}`),
+		PayloadType: bundle.IntotoMediaType,
+	}
+
+	// The request is denied before anything is signed, even with Fulcio
+	// configured
+	opts := BundleOptions{
+		Fulcio:    NewFulcio(&FulcioOptions{BaseURL: "http://127.0.0.1:0"}),
+		IDToken:   makeIDToken(`{"iss": "https://token.actions.githubusercontent.com", "sub": "repo:other/project"}`),
+		Validator: approvedRepository,
+	}
+	_, err = Bundle(statement, keypair, opts)
+	assert.ErrorIs(t, err, ErrSigningDenied)
+	assert.ErrorContains(t, err, "repository is not approved")
+	assert.Equal(t, 0, keypair.signatures)
+
+	_, err = Bundle(&PlainData{Data: []byte("artifact")}, keypair, opts)
+	assert.ErrorIs(t, err, ErrSigningDenied)
+	assert.Equal(t, 0, keypair.signatures)
+
+	// An invalid statement is rejected too
+	_, err = Bundle(&DSSEData{Data: []byte("{"), PayloadType: bundle.IntotoMediaType}, keypair, opts)
+	assert.Error(t, err)
+	assert.Equal(t, 0, keypair.signatures)
+
+	// An approved request is signed
+	opts = BundleOptions{
+		IDToken:   makeIDToken(`{"iss": "https://token.actions.githubusercontent.com", "sub": "repo:example/project"}`),
+		Validator: approvedRepository,
+	}
+	_, err = Bundle(statement, keypair, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, keypair.signatures)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"github.com/sigstore/sigstore-go/pkg/wasm"
+)
+
+// WasmModuleData is a WebAssembly module to be signed, without the custom
+// sections excluded by its wasm.Options. The signature is recorded in the
+// bundle as a MessageSignature, and can be verified with
+// verify.WithWasmModule and the same options.
+//
+// WasmModuleData is experimental and may change in backwards incompatible
+// ways.
+type WasmModuleData struct {
+	PlainData
+}
+
+var _ Content = &WasmModuleData{}
+
+// NewWasmModuleData returns content for the module, leaving out the custom
+// sections that opts excludes.
+func NewWasmModuleData(module []byte, opts *wasm.Options) (*WasmModuleData, error) {
+	canonical, err := wasm.Canonicalize(module, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &WasmModuleData{PlainData: PlainData{Data: canonical}}, nil
+}
+
+// WasmModuleStatement returns a statement with the given predicate about
+// the module, e.g. SLSA provenance with SLSAProvenanceV1PredicateType, as
+// content for Bundle. The subject has the name given, such as the module's
+// reference in a registry, and the SHA-256 digest of the module without the
+// custom sections that opts excludes.
+//
+// WasmModuleStatement is experimental and may change in backwards
+// incompatible ways.
+func WasmModuleStatement(name string, module []byte, opts *wasm.Options, predicateType string, predicate any) (*DSSEData, error) {
+	digest, err := wasm.Digest(module, opts)
+	if err != nil {
+		return nil, err
+	}
+	b := NewStatementBuilder(predicateType).AddSubjectDigest(name, "sha256", digest)
+	if predicate != nil {
+		b = b.WithPredicate(predicate)
+	}
+	return b.DSSEData()
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/wasm"
+)
+
+// testWasmModule has a type section and a "signature" custom section
+var testWasmModule = []byte("\x00asm\x01\x00\x00\x00" +
+	"\x01\x04\x01\x60\x00\x00" +
+	"\x00\x0d\x09signature\x01\x02\x03")
+
+func Test_WasmModuleData(t *testing.T) {
+	opts := &wasm.Options{ExcludeCustomSections: []string{"signature"}}
+	content, err := NewWasmModuleData(testWasmModule, opts)
+	require.NoError(t, err)
+	assert.Equal(t, testWasmModule[:14], content.PreAuthEncoding())
+
+	content, err = NewWasmModuleData(testWasmModule, nil)
+	require.NoError(t, err)
+	assert.Equal(t, testWasmModule, content.PreAuthEncoding())
+
+	_, err = NewWasmModuleData([]byte("not wasm"), nil)
+	assert.Error(t, err)
+}
+
+func Test_WasmModuleStatement(t *testing.T) {
+	opts := &wasm.Options{ExcludeCustomSections: []string{"signature"}}
+	content, err := WasmModuleStatement("registry.example.com/app:1.0.0", testWasmModule, opts, SLSAProvenanceV1PredicateType, map[string]any{"buildDefinition": map[string]any{}})
+	require.NoError(t, err)
+
+	var stmt statement
+	require.NoError(t, json.Unmarshal(content.Data, &stmt))
+	digest := sha256.Sum256(testWasmModule[:14])
+	assert.Equal(t, []statementSubject{{Name: "registry.example.com/app:1.0.0", Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}}}, stmt.Subject)
+	assert.Equal(t, SLSAProvenanceV1PredicateType, stmt.PredicateType)
+
+	_, err = WasmModuleStatement("app", []byte("not wasm"), nil, SLSAProvenanceV1PredicateType, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// Witness records a signature in a transparency service other than Rekor,
+// such as an internal append-only database or a notarization service, and
+// returns evidence that it did so.
+type Witness interface {
+	// GetEvidence is called with the bundle once it has its signature,
+	// verification material, timestamps and transparency log entries.
+	GetEvidence(ctx context.Context, bundle *protobundle.Bundle) (*WitnessEvidence, error)
+}
+
+// WitnessEvidence is the evidence returned by a Witness. sigstore-go does not
+// interpret it; verifiers that trust the witness must check it themselves.
+type WitnessEvidence struct {
+	// Witness identifies the witness, for example by its URL
+	Witness string
+	// MediaType describes the format of Data
+	MediaType string
+	Data      []byte
+}
+
+// witnessEvidenceFieldNumber is the field of the Bundle message that holds
+// witness evidence, as extension material; see bundle.AddExtensionField.
+const witnessEvidenceFieldNumber protowire.Number = 1000
+
+const (
+	witnessEvidenceWitnessField   protowire.Number = 1
+	witnessEvidenceMediaTypeField protowire.Number = 2
+	witnessEvidenceDataField      protowire.Number = 3
+)
+
+// AddWitnessEvidence attaches evidence to the bundle as extension material.
+//
+// Extension material is only kept when the bundle is serialized as a binary
+// protobuf. The JSON encoding of bundles has no place for it, so the
+// evidence is dropped when the bundle is serialized as JSON, including by
+// MarshalBundleJSON, and bundles read from JSON never have any. Evidence
+// that must be distributed with JSON bundles should be stored alongside
+// them.
+func AddWitnessEvidence(b *protobundle.Bundle, evidence *WitnessEvidence) {
+	bundle.AddExtensionField(b, witnessEvidenceFieldNumber, bundle.MarshalExtensionMessage(map[protowire.Number][]byte{
+		witnessEvidenceWitnessField:   []byte(evidence.Witness),
+		witnessEvidenceMediaTypeField: []byte(evidence.MediaType),
+		witnessEvidenceDataField:      evidence.Data,
+	}))
+}
+
+// GetWitnessEvidence returns the witness evidence attached to the bundle with
+// AddWitnessEvidence, in the order it was added.
+func GetWitnessEvidence(b *protobundle.Bundle) ([]*WitnessEvidence, error) {
+	messages, err := bundle.GetExtensionFields(b, witnessEvidenceFieldNumber)
+	if err != nil {
+		return nil, err
+	}
+	var evidence []*WitnessEvidence
+	for _, message := range messages {
+		fields, err := bundle.UnmarshalExtensionMessage(message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid witness evidence: %w", err)
+		}
+		evidence = append(evidence, &WitnessEvidence{
+			Witness:   string(fields[witnessEvidenceWitnessField]),
+			MediaType: string(fields[witnessEvidenceMediaTypeField]),
+			Data:      fields[witnessEvidenceDataField],
+		})
+	}
+	return evidence, nil
+}
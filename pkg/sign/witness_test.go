@@ -0,0 +1,82 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type testWitness struct {
+	name string
+	err  error
+}
+
+func (w *testWitness) GetEvidence(_ context.Context, bundle *protobundle.Bundle) (*WitnessEvidence, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	return &WitnessEvidence{
+		Witness:   w.name,
+		MediaType: "application/octet-stream",
+		Data:      bundle.GetMessageSignature().GetSignature(),
+	}, nil
+}
+
+func TestBundleWithWitnesses(t *testing.T) {
+	keypair, err := NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	content := &PlainData{Data: []byte("qwerty")}
+
+	opts := BundleOptions{
+		Witnesses: []Witness{&testWitness{name: "first"}, &testWitness{name: "second"}},
+	}
+	bundle, err := Bundle(content, keypair, opts)
+	require.NoError(t, err)
+
+	// Evidence survives the binary encoding
+	encoded, err := proto.Marshal(bundle)
+	require.NoError(t, err)
+	decoded := &protobundle.Bundle{}
+	require.NoError(t, proto.Unmarshal(encoded, decoded))
+
+	evidence, err := GetWitnessEvidence(decoded)
+	require.NoError(t, err)
+	require.Len(t, evidence, 2)
+	assert.Equal(t, "first", evidence[0].Witness)
+	assert.Equal(t, "second", evidence[1].Witness)
+	assert.Equal(t, "application/octet-stream", evidence[1].MediaType)
+	assert.Equal(t, bundle.GetMessageSignature().GetSignature(), evidence[0].Data)
+
+	// but is dropped from the JSON encoding without making it invalid
+	jsonBundle, err := protojson.Marshal(bundle)
+	require.NoError(t, err)
+	decoded = &protobundle.Bundle{}
+	require.NoError(t, protojson.Unmarshal(jsonBundle, decoded))
+	evidence, err = GetWitnessEvidence(decoded)
+	require.NoError(t, err)
+	assert.Empty(t, evidence)
+
+	opts.Witnesses = []Witness{&testWitness{err: errors.New("witness unavailable")}}
+	_, err = Bundle(content, keypair, opts)
+	assert.ErrorContains(t, err, "witness unavailable")
+}
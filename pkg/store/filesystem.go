@@ -0,0 +1,125 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// FilesystemStore stores bundles as files in a directory, laid out as
+// "<algorithm>/<hex>/<predicate>/<id>.sigstore.json", where predicate is the
+// unpadded base64url encoding of the predicate type, or "_" for bundles
+// with message signatures.
+type FilesystemStore struct {
+	dir string
+}
+
+var _ BundleStore = &FilesystemStore{}
+
+// NewFilesystemStore returns a store of bundles in dir, which is created
+// when the first bundle is stored.
+func NewFilesystemStore(dir string) *FilesystemStore {
+	return &FilesystemStore{dir: dir}
+}
+
+// Put writes the bundle to a file for each of its subject digests.
+func (s *FilesystemStore) Put(_ context.Context, b *bundle.ProtobufBundle) error {
+	subjects, predicateType, err := Subjects(b)
+	if err != nil {
+		return err
+	}
+	bundleJSON, err := b.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	id := bundleID(bundleJSON)
+
+	for _, subject := range subjects {
+		path := filepath.Join(s.dir, filepath.FromSlash(entryKey(Entry{Subject: subject, PredicateType: predicateType, ID: id})))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return err
+		}
+		// Write to a temporary file first, so that readers never see a
+		// partially written bundle
+		tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(bundleJSON)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+			return err
+		}
+	}
+	return nil
+}
+
+// Get reads the bundles stored for the subject digest.
+func (s *FilesystemStore) Get(ctx context.Context, subject, predicateType string) ([]*bundle.ProtobufBundle, error) {
+	return getAll(ctx, s, subject, predicateType, s.get)
+}
+
+func (s *FilesystemStore) get(_ context.Context, entry Entry) (*bundle.ProtobufBundle, error) {
+	bundleJSON, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(entryKey(entry))))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalBundle(bundleJSON)
+}
+
+// List lists the files of the bundles stored for the subject digest.
+func (s *FilesystemStore) List(_ context.Context, subject, predicateType string) ([]Entry, error) {
+	if err := ValidateDigest(subject); err != nil {
+		return nil, err
+	}
+	subjectDir := filepath.Join(s.dir, filepath.FromSlash(subjectPrefix(subject)))
+	pattern := filepath.Join(subjectDir, "*", "*"+bundleFileSuffix)
+	if predicateType != "" {
+		pattern = filepath.Join(subjectDir, predicateDirectory(predicateType), "*"+bundleFileSuffix)
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var entries []Entry
+	for _, path := range paths {
+		relative, err := filepath.Rel(subjectDir, path)
+		if err != nil {
+			return nil, err
+		}
+		if entry, ok := parseEntryKey(subject, filepath.ToSlash(relative)); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
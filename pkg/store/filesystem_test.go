@@ -0,0 +1,85 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// testBundleStore stores and retrieves attestations and message signatures
+// with a store.
+func testBundleStore(t *testing.T, s BundleStore) {
+	t.Helper()
+	ctx := context.Background()
+	subject := "sha256:" + testDigest("artifact")
+
+	provenance := testAttestation(t, testPredicateType, map[string]string{"sha256": testDigest("artifact")})
+	sbom := testAttestation(t, otherTestPredicateType, map[string]string{"sha256": testDigest("artifact")})
+	require.NoError(t, s.Put(ctx, provenance))
+	require.NoError(t, s.Put(ctx, sbom))
+	// Storing a bundle again does not duplicate it
+	require.NoError(t, s.Put(ctx, provenance))
+
+	entries, err := s.List(ctx, subject, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, subject, entry.Subject)
+		assert.NotEmpty(t, entry.ID)
+	}
+
+	entries, err = s.List(ctx, subject, testPredicateType)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, testPredicateType, entries[0].PredicateType)
+
+	bundles, err := s.Get(ctx, subject, testPredicateType)
+	require.NoError(t, err)
+	require.Len(t, bundles, 1)
+	assert.True(t, proto.Equal(provenance.Bundle, bundles[0].Bundle))
+
+	bundles, err = s.Get(ctx, subject, "")
+	require.NoError(t, err)
+	assert.Len(t, bundles, 2)
+
+	bundles, err = s.Get(ctx, "sha256:"+testDigest("other artifact"), "")
+	require.NoError(t, err)
+	assert.Empty(t, bundles)
+
+	_, err = s.List(ctx, "artifact", "")
+	assert.Error(t, err)
+}
+
+func TestFilesystemStore(t *testing.T) {
+	s := NewFilesystemStore(t.TempDir())
+	testBundleStore(t, s)
+
+	ctx := context.Background()
+	message := testMessageSignature(t, "message")
+	require.NoError(t, s.Put(ctx, message))
+	entries, err := s.List(ctx, "sha256:"+testDigest("message"), "")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Empty(t, entries[0].PredicateType)
+
+	_, err = s.get(ctx, Entry{Subject: "sha256:" + testDigest("message"), ID: "missing"})
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,343 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// Annotations of the OCI manifests of stored bundles, as set by cosign.
+const (
+	// OCIPredicateTypeAnnotation is the predicate type of the bundle's
+	// statement
+	OCIPredicateTypeAnnotation = "dev.sigstore.bundle.predicateType"
+	// OCIContentAnnotation is "dsse-envelope" or "message-signature"
+	OCIContentAnnotation = "dev.sigstore.bundle.content"
+)
+
+const (
+	ociEmptyMediaType      types.MediaType = "application/vnd.oci.empty.v1+json"
+	bundleArtifactTypeBase                 = "application/vnd.dev.sigstore.bundle"
+)
+
+// ociEmptyConfig is the empty config blob of artifact manifests.
+var ociEmptyConfig = []byte("{}")
+
+// OCIOptions configures an OCIStore.
+type OCIOptions struct {
+	// Registry is the host of the registry, e.g. "ghcr.io"
+	Registry string
+	// Repository is the repository of the images bundles are for, e.g.
+	// "sigstore/sigstore-go"
+	Repository string
+	// Optional credentials, used with HTTP basic authentication or to
+	// obtain a bearer token. Anonymous tokens are obtained without them.
+	Username string
+	Password string
+	// Insecure uses plain HTTP, for local test registries
+	Insecure bool
+	// Optional HTTP client, whose transport is used for requests to the
+	// registry; defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// OCIStore stores bundles in an OCI registry as referrers of the images
+// they are for, as cosign does: each bundle is the only layer of an
+// artifact manifest whose subject is the image's manifest, and whose
+// artifact type is the bundle's media type.
+//
+// Only sha256 subject digests of manifests in the repository can be used.
+// Referrers are listed with the referrers API of the OCI distribution
+// specification v1.1, or with the referrers tag schema for registries that
+// don't support it.
+type OCIStore struct {
+	repository name.Repository
+	options    []remote.Option
+}
+
+var _ BundleStore = &OCIStore{}
+
+// NewOCIStore returns a store of bundles in an OCI repository.
+func NewOCIStore(opts OCIOptions) (*OCIStore, error) {
+	if opts.Registry == "" || strings.ContainsAny(opts.Registry, "/ ") {
+		return nil, fmt.Errorf("invalid OCI registry %q", opts.Registry)
+	}
+	var nameOpts []name.Option
+	if opts.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	repository, err := name.NewRepository(opts.Registry+"/"+opts.Repository, nameOpts...)
+	if err != nil || opts.Repository == "" {
+		return nil, fmt.Errorf("invalid OCI repository %q", opts.Repository)
+	}
+
+	auth := authn.Anonymous
+	if opts.Username != "" {
+		auth = authn.FromConfig(authn.AuthConfig{Username: opts.Username, Password: opts.Password})
+	}
+	roundTripper := http.DefaultTransport
+	if opts.HTTPClient != nil && opts.HTTPClient.Transport != nil {
+		roundTripper = opts.HTTPClient.Transport
+	}
+	return &OCIStore{
+		repository: repository,
+		options:    []remote.Option{remote.WithAuth(auth), remote.WithTransport(roundTripper)},
+	}, nil
+}
+
+// bundleManifest is an artifact manifest holding a bundle. It is not a
+// v1.Manifest, which has no artifact type.
+type bundleManifest struct {
+	SchemaVersion int64             `json:"schemaVersion"`
+	MediaType     types.MediaType   `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        v1.Descriptor     `json:"config"`
+	Layers        []v1.Descriptor   `json:"layers"`
+	Subject       *v1.Descriptor    `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// rawManifest is a manifest to push with remote.Put.
+type rawManifest struct {
+	manifest  []byte
+	mediaType types.MediaType
+}
+
+func (m rawManifest) RawManifest() ([]byte, error) { return m.manifest, nil }
+
+func (m rawManifest) MediaType() (types.MediaType, error) { return m.mediaType, nil }
+
+// remoteOptions returns the options of requests to the registry.
+func (s *OCIStore) remoteOptions(ctx context.Context) []remote.Option {
+	return append([]remote.Option{remote.WithContext(ctx)}, s.options...)
+}
+
+// Put pushes the bundle as a referrer of each of its sha256 subject digests.
+func (s *OCIStore) Put(ctx context.Context, b *bundle.ProtobufBundle) error {
+	subjects, predicateType, err := Subjects(b)
+	if err != nil {
+		return err
+	}
+	bundleJSON, err := b.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	content := "dsse-envelope"
+	if b.GetMessageSignature() != nil {
+		content = "message-signature"
+	}
+	annotations := map[string]string{OCIContentAnnotation: content}
+	if predicateType != "" {
+		annotations[OCIPredicateTypeAnnotation] = predicateType
+	}
+
+	config := static.NewLayer(ociEmptyConfig, ociEmptyMediaType)
+	layer := static.NewLayer(bundleJSON, types.MediaType(b.MediaType))
+	configDescriptor, err := layerDescriptor(config)
+	if err != nil {
+		return err
+	}
+	layerDescriptor, err := layerDescriptor(layer)
+	if err != nil {
+		return err
+	}
+
+	pushed := false
+	for _, subject := range subjects {
+		if !strings.HasPrefix(subject, "sha256:") {
+			continue
+		}
+		subjectDescriptor, err := remote.Head(s.repository.Digest(subject), s.remoteOptions(ctx)...)
+		if isNotFound(err) {
+			return fmt.Errorf("subject %s is not a manifest in repository %s", subject, s.repository)
+		}
+		if err != nil {
+			return err
+		}
+		for _, blob := range []v1.Layer{config, layer} {
+			if err = remote.WriteLayer(s.repository, blob, s.remoteOptions(ctx)...); err != nil {
+				return fmt.Errorf("pushing blob: %w", err)
+			}
+		}
+
+		manifest, err := json.Marshal(bundleManifest{
+			SchemaVersion: 2,
+			MediaType:     types.OCIManifestSchema1,
+			ArtifactType:  b.MediaType,
+			Config:        configDescriptor,
+			Layers:        []v1.Descriptor{layerDescriptor},
+			Subject:       &v1.Descriptor{MediaType: subjectDescriptor.MediaType, Digest: subjectDescriptor.Digest, Size: subjectDescriptor.Size},
+			Annotations:   annotations,
+		})
+		if err != nil {
+			return err
+		}
+		digest, _, err := v1.SHA256(bytes.NewReader(manifest))
+		if err != nil {
+			return err
+		}
+		err = remote.Put(s.repository.Digest(digest.String()), rawManifest{manifest, types.OCIManifestSchema1}, s.remoteOptions(ctx)...)
+		if err != nil {
+			return fmt.Errorf("pushing bundle manifest: %w", err)
+		}
+		pushed = true
+	}
+	if !pushed {
+		return errors.New("bundle has no sha256 subject digests to refer to")
+	}
+	return nil
+}
+
+// layerDescriptor returns the descriptor of a blob.
+func layerDescriptor(layer v1.Layer) (v1.Descriptor, error) {
+	digest, err := layer.Digest()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	size, err := layer.Size()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	return v1.Descriptor{MediaType: mediaType, Digest: digest, Size: size}, nil
+}
+
+// Get pulls the bundles that refer to the subject digest.
+func (s *OCIStore) Get(ctx context.Context, subject, predicateType string) ([]*bundle.ProtobufBundle, error) {
+	return getAll(ctx, s, subject, predicateType, s.get)
+}
+
+func (s *OCIStore) get(ctx context.Context, entry Entry) (*bundle.ProtobufBundle, error) {
+	manifest, err := s.manifest(ctx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) != 1 || !strings.HasPrefix(string(manifest.Layers[0].MediaType), bundleArtifactTypeBase) {
+		return nil, errors.New("manifest does not hold a bundle")
+	}
+
+	layer, err := remote.Layer(s.repository.Digest(manifest.Layers[0].Digest.String()), s.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	// The layer's content is checked against its digest as it is read
+	content, err := layer.Compressed()
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer content.Close()
+	bundleJSON, err := io.ReadAll(io.LimitReader(content, maxBundleSize))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalBundle(bundleJSON)
+}
+
+// manifest returns the bundle manifest with the digest.
+func (s *OCIStore) manifest(ctx context.Context, digest string) (*bundleManifest, error) {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported digest %q", digest)
+	}
+	// The manifest is checked against the digest it is fetched by
+	descriptor, err := remote.Get(s.repository.Digest(digest), s.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	var manifest bundleManifest
+	if err = json.Unmarshal(descriptor.Manifest, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// List lists the bundle manifests that refer to the subject digest.
+func (s *OCIStore) List(ctx context.Context, subject, predicateType string) ([]Entry, error) {
+	if err := ValidateDigest(subject); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(subject, "sha256:") {
+		return nil, nil
+	}
+
+	referrers, err := remote.Referrers(s.repository.Digest(subject), s.remoteOptions(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	index, err := referrers.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("invalid referrers response: %w", err)
+	}
+
+	var entries []Entry
+	for _, descriptor := range index.Manifests {
+		artifactType, annotations := descriptor.ArtifactType, descriptor.Annotations
+		// Registries that take the artifact type from the config media type,
+		// and the referrers tag schema, don't describe artifact manifests
+		// fully, so their manifests are fetched instead
+		if artifactType == "" || artifactType == string(ociEmptyMediaType) {
+			manifest, err := s.manifest(ctx, descriptor.Digest.String())
+			if err != nil {
+				return nil, err
+			}
+			artifactType, annotations = manifest.ArtifactType, manifest.Annotations
+		}
+		if !strings.HasPrefix(artifactType, bundleArtifactTypeBase) {
+			continue
+		}
+		entryPredicateType := annotations[OCIPredicateTypeAnnotation]
+		if predicateType != "" && entryPredicateType != predicateType {
+			continue
+		}
+		entries = append(entries, Entry{Subject: subject, PredicateType: entryPredicateType, ID: descriptor.Digest.String()})
+	}
+	return entries, nil
+}
+
+// isNotFound reports whether an error is the registry responding with 404
+// Not Found.
+func isNotFound(err error) bool {
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
+
+// notFound returns ErrNotFound for errors that are the registry responding
+// with 404 Not Found, and the error otherwise.
+func notFound(err error) error {
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// authenticatedRegistry serves an in-memory registry to clients that
+// authenticate with HTTP basic authentication.
+type authenticatedRegistry struct {
+	registry http.Handler
+
+	mu            sync.Mutex
+	authenticated int
+}
+
+func (r *authenticatedRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if username, password, _ := req.BasicAuth(); username != "user" || password != "password" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry.test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.mu.Lock()
+	r.authenticated++
+	r.mu.Unlock()
+	r.registry.ServeHTTP(w, req)
+}
+
+func TestOCIStore(t *testing.T) {
+	for _, referrersAPI := range []bool{true, false} {
+		fake := &authenticatedRegistry{registry: registry.New(
+			registry.WithReferrersSupport(referrersAPI),
+			registry.Logger(log.New(io.Discard, "", 0)),
+		)}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		host := strings.TrimPrefix(server.URL, "http://")
+
+		// The subject image is pushed to the registry first
+		image, err := random.Image(64, 1)
+		require.NoError(t, err)
+		imageRef, err := name.ParseReference(host+"/sigstore/image:latest", name.Insecure)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(imageRef, image, remote.WithAuth(&authn.Basic{Username: "user", Password: "password"})))
+		imageDigest, err := image.Digest()
+		require.NoError(t, err)
+		imageManifest, err := image.RawManifest()
+		require.NoError(t, err)
+
+		s, err := NewOCIStore(OCIOptions{
+			Registry:   host,
+			Repository: "sigstore/image",
+			Username:   "user",
+			Password:   "password",
+			Insecure:   true,
+		})
+		require.NoError(t, err)
+
+		ctx := context.Background()
+		provenance := testAttestation(t, testPredicateType, map[string]string{"sha256": imageDigest.Hex})
+		sbom := testAttestation(t, otherTestPredicateType, map[string]string{"sha256": imageDigest.Hex})
+		require.NoError(t, s.Put(ctx, provenance))
+		require.NoError(t, s.Put(ctx, sbom))
+
+		entries, err := s.List(ctx, imageDigest.String(), "")
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		entries, err = s.List(ctx, imageDigest.String(), testPredicateType)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, testPredicateType, entries[0].PredicateType)
+
+		bundles, err := s.Get(ctx, imageDigest.String(), testPredicateType)
+		require.NoError(t, err)
+		require.Len(t, bundles, 1)
+		assert.True(t, proto.Equal(provenance.Bundle, bundles[0].Bundle))
+
+		manifest, err := s.manifest(ctx, entries[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, provenance.MediaType, manifest.ArtifactType)
+		assert.Equal(t, "dsse-envelope", manifest.Annotations[OCIContentAnnotation])
+		assert.Equal(t, int64(len(imageManifest)), manifest.Subject.Size)
+		assert.Positive(t, fake.authenticated)
+
+		// Bundles can only refer to manifests in the repository
+		err = s.Put(ctx, testAttestation(t, testPredicateType, map[string]string{"sha256": testDigest("artifact")}))
+		assert.Error(t, err)
+
+		entries, err = s.List(ctx, "sha256:"+testDigest("artifact"), "")
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		_, err = s.get(ctx, Entry{ID: "sha256:" + testDigest("missing")})
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+}
+
+func TestNewOCIStore(t *testing.T) {
+	for _, opts := range []OCIOptions{
+		{Repository: "sigstore/image"},
+		{Registry: "ghcr.io/sigstore", Repository: "image"},
+		{Registry: "ghcr.io"},
+		{Registry: "ghcr.io", Repository: "sigstore/image:latest"},
+		{Registry: "ghcr.io", Repository: "Sigstore/Image"},
+	} {
+		_, err := NewOCIStore(opts)
+		assert.Error(t, err, opts)
+	}
+}
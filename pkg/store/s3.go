@@ -0,0 +1,181 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// maxBundleSize bounds the size of bundles read from remote stores.
+const maxBundleSize = 32 << 20
+
+// S3Options configures an S3Store.
+type S3Options struct {
+	// Endpoint is the URL of the S3-compatible service, e.g.
+	// "https://s3.eu-west-1.amazonaws.com", or
+	// "https://storage.googleapis.com" for Google Cloud Storage with HMAC
+	// keys. Buckets are addressed by path.
+	Endpoint string
+	Bucket   string
+	// Optional prefix of the keys of stored bundles, e.g. "attestations/"
+	Prefix string
+	// Region requests are signed for; defaults to "us-east-1". Google Cloud
+	// Storage accepts "auto".
+	Region string
+	// AccessKeyID and SecretAccessKey sign requests with AWS Signature
+	// Version 4. Requests are not signed if they are empty, which allows
+	// reading public buckets.
+	AccessKeyID     string
+	SecretAccessKey string
+	// Optional session token of temporary credentials
+	SessionToken string
+	// Optional HTTP client; defaults to the AWS SDK's client
+	HTTPClient *http.Client
+}
+
+// S3Store stores bundles as objects in a bucket of an S3-compatible object
+// storage service, with the same layout as FilesystemStore.
+type S3Store struct {
+	opts   S3Options
+	client *s3.Client
+}
+
+var _ BundleStore = &S3Store{}
+
+// NewS3Store returns a store of bundles in an S3-compatible bucket.
+func NewS3Store(opts S3Options) (*S3Store, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", opts.Endpoint)
+	}
+	if opts.Bucket == "" {
+		return nil, errors.New("S3 bucket must be provided")
+	}
+	if (opts.AccessKeyID == "") != (opts.SecretAccessKey == "") {
+		return nil, errors.New("both or neither of the S3 access key ID and secret access key must be provided")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+
+	clientOpts := s3.Options{
+		BaseEndpoint: aws.String(strings.TrimSuffix(opts.Endpoint, "/")),
+		Region:       opts.Region,
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	}
+	if opts.AccessKeyID != "" {
+		clientOpts.Credentials = credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, opts.SessionToken)
+	}
+	if opts.HTTPClient != nil {
+		clientOpts.HTTPClient = opts.HTTPClient
+	}
+	return &S3Store{opts: opts, client: s3.New(clientOpts)}, nil
+}
+
+// Put uploads the bundle as an object for each of its subject digests.
+func (s *S3Store) Put(ctx context.Context, b *bundle.ProtobufBundle) error {
+	subjects, predicateType, err := Subjects(b)
+	if err != nil {
+		return err
+	}
+	bundleJSON, err := b.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	id := bundleID(bundleJSON)
+
+	for _, subject := range subjects {
+		key := s.opts.Prefix + entryKey(Entry{Subject: subject, PredicateType: predicateType, ID: id})
+		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(s.opts.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(bundleJSON),
+			ContentType: aws.String("application/json"),
+		})
+		if err != nil {
+			return fmt.Errorf("S3 put %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Get downloads the bundles stored for the subject digest.
+func (s *S3Store) Get(ctx context.Context, subject, predicateType string) ([]*bundle.ProtobufBundle, error) {
+	return getAll(ctx, s, subject, predicateType, s.get)
+}
+
+func (s *S3Store) get(ctx context.Context, entry Entry) (*bundle.ProtobufBundle, error) {
+	key := s.opts.Prefix + entryKey(entry)
+	object, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		var responseErr *smithyhttp.ResponseError
+		if errors.As(err, &noSuchKey) || (errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("S3 get %s: %w", key, err)
+	}
+	defer object.Body.Close()
+	bundleJSON, err := io.ReadAll(io.LimitReader(object.Body, maxBundleSize))
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalBundle(bundleJSON)
+}
+
+// List lists the objects of the bundles stored for the subject digest.
+func (s *S3Store) List(ctx context.Context, subject, predicateType string) ([]Entry, error) {
+	if err := ValidateDigest(subject); err != nil {
+		return nil, err
+	}
+	subjectKeyPrefix := s.opts.Prefix + subjectPrefix(subject)
+
+	var entries []Entry
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.opts.Bucket),
+		Prefix: aws.String(s.opts.Prefix + entryPrefix(subject, predicateType)),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("S3 list: %w", err)
+		}
+		for _, object := range page.Contents {
+			if entry, ok := parseEntryKey(subject, strings.TrimPrefix(aws.ToString(object.Key), subjectKeyPrefix)); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
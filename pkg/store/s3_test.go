@@ -0,0 +1,137 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listBucketResult is the response to a ListObjectsV2 request.
+type listBucketResult struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// fakeS3 serves a single bucket from memory, returning one key per list
+// page to exercise pagination.
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	switch {
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case r.Method == http.MethodGet && key != "":
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		_, _ = w.Write(object)
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		var keys []string
+		for key := range f.objects {
+			if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+
+		var result listBucketResult
+		if start < len(keys) {
+			result.Contents = append(result.Contents, struct {
+				Key string `xml:"Key"`
+			}{Key: keys[start]})
+		}
+		if start+1 < len(keys) {
+			result.IsTruncated = true
+			result.NextContinuationToken = strconv.Itoa(start + 1)
+		}
+		_ = xml.NewEncoder(w).Encode(result)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{bucket: "bucket", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewS3Store(S3Options{
+		Endpoint:        server.URL,
+		Bucket:          "bucket",
+		Prefix:          "attestations/",
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+	})
+	require.NoError(t, err)
+	testBundleStore(t, s)
+
+	for key := range fake.objects {
+		assert.True(t, strings.HasPrefix(key, "attestations/sha256/"), key)
+	}
+
+	_, err = s.get(context.Background(), Entry{Subject: "sha256:" + testDigest("missing"), ID: "missing"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestNewS3Store(t *testing.T) {
+	for _, opts := range []S3Options{
+		{Bucket: "bucket"},
+		{Endpoint: "s3.amazonaws.com", Bucket: "bucket"},
+		{Endpoint: "https://s3.amazonaws.com"},
+		{Endpoint: "https://s3.amazonaws.com", Bucket: "bucket", AccessKeyID: "access-key"},
+	} {
+		_, err := NewS3Store(opts)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package store persists Sigstore bundles, indexed by the digests of the
+// artifacts they are for and the predicate types of their attestations, so
+// that the tools producing bundles and the tools verifying them can share
+// storage.
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+)
+
+// ErrNotFound is returned when a stored bundle does not exist.
+var ErrNotFound = errors.New("bundle not found")
+
+// BundleStore stores bundles by subject digest and predicate type.
+//
+// A subject digest is the digest of an artifact that a bundle is for, in the
+// form "algorithm:hex", e.g. "sha256:2cf2...". The predicate type is that of
+// the in-toto statement in a bundle's DSSE envelope; bundles with message
+// signatures have an empty predicate type.
+type BundleStore interface {
+	// Put stores the bundle under each of its subject digests
+	Put(ctx context.Context, b *bundle.ProtobufBundle) error
+	// Get returns the bundles stored for the subject digest with the
+	// predicate type, or with any predicate type if it is empty
+	Get(ctx context.Context, subject, predicateType string) ([]*bundle.ProtobufBundle, error)
+	// List returns the entries of the bundles stored for the subject digest
+	// with the predicate type, or with any predicate type if it is empty
+	List(ctx context.Context, subject, predicateType string) ([]Entry, error)
+}
+
+// Entry describes a stored bundle.
+type Entry struct {
+	// Subject is the subject digest the bundle is stored under
+	Subject string
+	// PredicateType is the predicate type of the bundle's statement, or
+	// empty for bundles with message signatures
+	PredicateType string
+	// ID identifies the bundle among those stored for the subject, e.g. the
+	// digest of its JSON encoding
+	ID string
+}
+
+// Subjects returns the subject digests and predicate type a bundle is
+// stored under: the digests of the subjects of the statement in its DSSE
+// envelope, or the digest of the artifact its message signature is over.
+func Subjects(b *bundle.ProtobufBundle) ([]string, string, error) {
+	sigContent, err := b.SignatureContent()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if envelope := sigContent.EnvelopeContent(); envelope != nil {
+		statement, err := envelope.Statement()
+		if err != nil {
+			return nil, "", err
+		}
+		var subjects []string
+		for _, subject := range statement.Subject {
+			algorithms := make([]string, 0, len(subject.Digest))
+			for algorithm := range subject.Digest {
+				algorithms = append(algorithms, algorithm)
+			}
+			sort.Strings(algorithms)
+			for _, algorithm := range algorithms {
+				digest := algorithm + ":" + strings.ToLower(subject.Digest[algorithm])
+				if err := ValidateDigest(digest); err != nil {
+					return nil, "", err
+				}
+				subjects = appendUnique(subjects, digest)
+			}
+		}
+		if len(subjects) == 0 {
+			return nil, "", errors.New("bundle statement has no subject digests")
+		}
+		return subjects, statement.PredicateType, nil
+	}
+
+	msg := sigContent.MessageSignatureContent()
+	if msg == nil {
+		return nil, "", errors.New("bundle has neither an envelope nor a message signature")
+	}
+	var algorithm string
+	switch msg.DigestAlgorithm() {
+	case "SHA2_256":
+		algorithm = "sha256"
+	case "SHA2_384":
+		algorithm = "sha384"
+	case "SHA2_512":
+		algorithm = "sha512"
+	default:
+		return nil, "", fmt.Errorf("unsupported message digest algorithm %s", msg.DigestAlgorithm())
+	}
+	return []string{algorithm + ":" + hex.EncodeToString(msg.Digest())}, "", nil
+}
+
+// ValidateDigest checks that a subject digest has the form "algorithm:hex",
+// with a lowercase algorithm name and hex encoding.
+func ValidateDigest(digest string) error {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok || algorithm == "" || encoded == "" {
+		return fmt.Errorf("invalid digest %q: must be algorithm:hex", digest)
+	}
+	for _, c := range algorithm {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return fmt.Errorf("invalid digest algorithm %q", algorithm)
+		}
+	}
+	if _, err := hex.DecodeString(encoded); err != nil || strings.ToLower(encoded) != encoded {
+		return fmt.Errorf("invalid digest %q: must be lowercase hex", digest)
+	}
+	return nil
+}
+
+// bundleID returns the ID of a bundle, the hex-encoded SHA-256 digest of
+// its JSON encoding.
+func bundleID(bundleJSON []byte) string {
+	digest := sha256.Sum256(bundleJSON)
+	return hex.EncodeToString(digest[:])
+}
+
+// Stores that hold bundles as files or objects lay them out as
+// "<algorithm>/<hex>/<predicate>/<id>.sigstore.json", where predicate is
+// the unpadded base64url encoding of the predicate type, or "_" for message
+// signatures.
+const (
+	bundleFileSuffix     = ".sigstore.json"
+	noPredicateDirectory = "_"
+)
+
+func subjectPrefix(subject string) string {
+	algorithm, encoded, _ := strings.Cut(subject, ":")
+	return algorithm + "/" + encoded + "/"
+}
+
+func predicateDirectory(predicateType string) string {
+	if predicateType == "" {
+		return noPredicateDirectory
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(predicateType))
+}
+
+// entryPrefix returns the prefix of the keys of the bundles stored for the
+// subject digest with the predicate type, or any predicate type if empty.
+func entryPrefix(subject, predicateType string) string {
+	prefix := subjectPrefix(subject)
+	if predicateType != "" {
+		prefix += predicateDirectory(predicateType) + "/"
+	}
+	return prefix
+}
+
+func entryKey(entry Entry) string {
+	return subjectPrefix(entry.Subject) + predicateDirectory(entry.PredicateType) + "/" + entry.ID + bundleFileSuffix
+}
+
+// parseEntryKey returns the entry stored at a key, which must be relative
+// to the subject's prefix.
+func parseEntryKey(subject, key string) (Entry, bool) {
+	directory, file := path.Split(key)
+	directory = strings.TrimSuffix(directory, "/")
+	id, ok := strings.CutSuffix(file, bundleFileSuffix)
+	if !ok || id == "" || directory == "" || strings.Contains(directory, "/") {
+		return Entry{}, false
+	}
+	entry := Entry{Subject: subject, ID: id}
+	if directory != noPredicateDirectory {
+		predicateType, err := base64.RawURLEncoding.DecodeString(directory)
+		if err != nil || len(predicateType) == 0 {
+			return Entry{}, false
+		}
+		entry.PredicateType = string(predicateType)
+	}
+	return entry, true
+}
+
+// getAll returns the bundles of the entries listed by list, fetched by get.
+func getAll(ctx context.Context, s BundleStore, subject, predicateType string, get func(context.Context, Entry) (*bundle.ProtobufBundle, error)) ([]*bundle.ProtobufBundle, error) {
+	entries, err := s.List(ctx, subject, predicateType)
+	if err != nil {
+		return nil, err
+	}
+	bundles := make([]*bundle.ProtobufBundle, 0, len(entries))
+	for _, entry := range entries {
+		b, err := get(ctx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("getting bundle %s for %s: %w", entry.ID, entry.Subject, err)
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles, nil
+}
+
+func unmarshalBundle(bundleJSON []byte) (*bundle.ProtobufBundle, error) {
+	var b bundle.ProtobufBundle
+	if err := b.UnmarshalJSON(bundleJSON); err != nil {
+		return nil, fmt.Errorf("invalid stored bundle: %w", err)
+	}
+	return &b, nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+)
+
+const (
+	testPredicateType      = "https://slsa.dev/provenance/v1"
+	otherTestPredicateType = "https://spdx.dev/Document"
+)
+
+// testAttestation returns a bundle with a statement about the subject
+// digests, e.g. {"sha256": "..."}.
+func testAttestation(t *testing.T, predicateType string, digests ...map[string]string) *bundle.ProtobufBundle {
+	t.Helper()
+	b := sign.NewStatementBuilder(predicateType)
+	for i, digest := range digests {
+		for algorithm, value := range digest {
+			raw, err := hex.DecodeString(value)
+			require.NoError(t, err)
+			b = b.AddSubjectDigest(fmt.Sprintf("artifact-%d", i), algorithm, raw)
+		}
+	}
+	content, err := b.DSSEData()
+	require.NoError(t, err)
+	return testBundle(t, content)
+}
+
+func testMessageSignature(t *testing.T, artifact string) *bundle.ProtobufBundle {
+	t.Helper()
+	return testBundle(t, &sign.PlainData{Data: []byte(artifact)})
+}
+
+func testBundle(t *testing.T, content sign.Content) *bundle.ProtobufBundle {
+	t.Helper()
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	pb, err := sign.Bundle(content, keypair, sign.BundleOptions{})
+	require.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+	return b
+}
+
+func testDigest(s string) string {
+	digest := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(digest[:])
+}
+
+func TestSubjects(t *testing.T) {
+	first, second := testDigest("first"), testDigest("second")
+	b := testAttestation(t, testPredicateType,
+		map[string]string{"sha256": first, "sha512": first + first},
+		map[string]string{"sha256": second},
+	)
+	subjects, predicateType, err := Subjects(b)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"sha256:" + first, "sha512:" + first + first, "sha256:" + second}, subjects)
+	assert.Equal(t, testPredicateType, predicateType)
+
+	subjects, predicateType, err = Subjects(testMessageSignature(t, "artifact"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sha256:" + testDigest("artifact")}, subjects)
+	assert.Empty(t, predicateType)
+}
+
+func TestValidateDigest(t *testing.T) {
+	assert.NoError(t, ValidateDigest("sha256:"+testDigest("a")))
+	for _, digest := range []string{
+		"",
+		testDigest("a"),
+		"sha256:",
+		"SHA256:" + testDigest("a"),
+		"sha256:" + testDigest("a")[1:],
+		"sha256:ABCD",
+		"sha/256:abcd",
+	} {
+		assert.Error(t, ValidateDigest(digest), digest)
+	}
+}
+
+func TestEntryKeys(t *testing.T) {
+	subject := "sha256:" + testDigest("a")
+	for _, entry := range []Entry{
+		{Subject: subject, PredicateType: testPredicateType, ID: "abc"},
+		{Subject: subject, ID: "abc"},
+	} {
+		key := entryKey(entry)
+		parsed, ok := parseEntryKey(subject, key[len(subjectPrefix(subject)):])
+		assert.True(t, ok)
+		assert.Equal(t, entry, parsed)
+	}
+
+	for _, key := range []string{"", "_/abc.json", "abc.sigstore.json", "_/_/abc.sigstore.json", "!!/abc.sigstore.json"} {
+		_, ok := parseEntryKey(subject, key)
+		assert.False(t, ok, key)
+	}
+}
@@ -31,26 +31,39 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/digitorus/timestamp"
 	"github.com/go-openapi/runtime"
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/pki"
 	"github.com/sigstore/rekor/pkg/types"
+	rekordsse "github.com/sigstore/rekor/pkg/types/dsse"
 	"github.com/sigstore/rekor/pkg/types/hashedrekord"
 	"github.com/sigstore/rekor/pkg/types/intoto"
 	"github.com/sigstore/rekor/pkg/types/rekord"
+	rekorutil "github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/util"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
 	tsx509 "github.com/sigstore/timestamp-authority/pkg/x509"
+	"github.com/transparency-dev/merkle/rfc6962"
 )
 
 type VirtualSigstore struct {
@@ -61,49 +74,98 @@ type VirtualSigstore struct {
 	rekorKey              *ecdsa.PrivateKey
 	ctlogKey              *ecdsa.PrivateKey
 	publicKeyVerifier     map[string]root.TimeConstrainedVerifier
+	publicKeyMu           sync.Mutex
+	// publicKeys are the keys created by SignWithKey, by key ID
+	publicKeys map[string]signature.Signer
+	opts       VirtualSigstoreOptions
+	// leafKeys counts the leaf keys derived from opts.Seed
+	leafKeys atomic.Uint64
+}
+
+// VirtualSigstoreOptions configures NewVirtualSigstoreWithOptions.
+type VirtualSigstoreOptions struct {
+	// Optional seed to derive keys from. If set, the keys of the virtual
+	// Sigstore and of the leaf certificates it issues are derived from the
+	// seed, and ECDSA signatures are deterministic (RFC 6979), so that the
+	// same options produce the same certificates, log entries and
+	// signatures. RFC 3161 timestamps have random serial numbers and are
+	// never deterministic. Deterministic signing requires Go 1.24 or later.
+	Seed []byte
+	// Optional time the virtual Sigstore's certificates are issued and its
+	// signatures are made at. Defaults to the current time.
+	Time time.Time
+	// If true, transparency log entries have an inclusion proof against a
+	// signed checkpoint, as well as a signed entry timestamp.
+	InclusionProofs bool
+	// If true, leaf certificates have an SCT from the virtual CT log
+	// embedded, as Fulcio issues them.
+	EmbeddedSCTs bool
 }
 
 func NewVirtualSigstore() (*VirtualSigstore, error) {
-	ss := &VirtualSigstore{fulcioCA: root.CertificateAuthority{}, tsaCA: root.CertificateAuthority{}}
+	return NewVirtualSigstoreWithOptions(VirtualSigstoreOptions{})
+}
+
+// NewVirtualSigstoreWithOptions is like NewVirtualSigstore, but can derive
+// the virtual Sigstore's keys from a seed, fix the time it signs at, and
+// include inclusion proofs and embedded SCTs; see VirtualSigstoreOptions.
+func NewVirtualSigstoreWithOptions(opts VirtualSigstoreOptions) (*VirtualSigstore, error) {
+	ss := &VirtualSigstore{fulcioCA: root.CertificateAuthority{}, tsaCA: root.CertificateAuthority{}, opts: opts}
+	now := ss.now()
 
-	rootCert, rootKey, err := GenerateRootCa()
+	rootKey, err := ss.generateKey("root")
+	if err != nil {
+		return nil, err
+	}
+	rootCert, err := generateRootCA(now, rootKey, ss.signer(rootKey))
 	if err != nil {
 		return nil, err
 	}
 	ss.fulcioCA.Root = rootCert
 	ss.tsaCA.Root = rootCert
 
-	intermediateCert, intermediateKey, _ := GenerateFulcioIntermediate(rootCert, rootKey)
+	intermediateKey, err := ss.generateKey("fulcio intermediate")
+	if err != nil {
+		return nil, err
+	}
+	intermediateCert, err := generateFulcioIntermediate(now, intermediateKey, rootCert, ss.signer(rootKey))
+	if err != nil {
+		return nil, err
+	}
 	ss.fulcioCA.Intermediates = []*x509.Certificate{intermediateCert}
 	ss.fulcioIntermediateKey = intermediateKey
 
-	tsaIntermediateCert, tsaIntermediateKey, err := GenerateTSAIntermediate(rootCert, rootKey)
+	tsaIntermediateKey, err := ss.generateKey("tsa intermediate")
+	if err != nil {
+		return nil, err
+	}
+	tsaIntermediateCert, err := generateTSAIntermediate(now, tsaIntermediateKey, rootCert, ss.signer(rootKey))
 	if err != nil {
 		return nil, err
 	}
 	ss.tsaCA.Intermediates = []*x509.Certificate{tsaIntermediateCert}
-	tsaLeafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tsaLeafKey, err := ss.generateKey("tsa leaf")
 	if err != nil {
 		return nil, err
 	}
-	tsaLeafCert, err := GenerateTSALeafCert(time.Now().Add(-5*time.Minute), tsaLeafKey, tsaIntermediateCert, tsaIntermediateKey)
+	tsaLeafCert, err := GenerateTSALeafCert(now.Add(-5*time.Minute), tsaLeafKey, tsaIntermediateCert, ss.signer(tsaIntermediateKey))
 	if err != nil {
 		return nil, err
 	}
 	ss.tsaCA.Leaf = tsaLeafCert
 	ss.tsaLeafKey = tsaLeafKey
 
-	ss.fulcioCA.ValidityPeriodStart = time.Now().Add(-5 * time.Hour)
-	ss.fulcioCA.ValidityPeriodEnd = time.Now().Add(time.Hour)
-	ss.tsaCA.ValidityPeriodStart = time.Now().Add(-5 * time.Hour)
-	ss.tsaCA.ValidityPeriodEnd = time.Now().Add(time.Hour)
+	ss.fulcioCA.ValidityPeriodStart = now.Add(-5 * time.Hour)
+	ss.fulcioCA.ValidityPeriodEnd = now.Add(time.Hour)
+	ss.tsaCA.ValidityPeriodStart = now.Add(-5 * time.Hour)
+	ss.tsaCA.ValidityPeriodEnd = now.Add(time.Hour)
 
-	ss.rekorKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ss.rekorKey, err = ss.generateKey("rekor")
 	if err != nil {
 		return nil, err
 	}
 
-	ss.ctlogKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ss.ctlogKey, err = ss.generateKey("ctlog")
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +173,14 @@ func NewVirtualSigstore() (*VirtualSigstore, error) {
 	return ss, nil
 }
 
+// now returns the time the virtual Sigstore signs at.
+func (ca *VirtualSigstore) now() time.Time {
+	if ca.opts.Time.IsZero() {
+		return time.Now()
+	}
+	return ca.opts.Time
+}
+
 // getLogID calculates the digest of a PKIX-encoded public key
 func getLogID(pub crypto.PublicKey) (string, error) {
 	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
@@ -122,19 +192,11 @@ func getLogID(pub crypto.PublicKey) (string, error) {
 }
 
 func (ca *VirtualSigstore) rekorSignPayload(payload tlog.RekorPayload) ([]byte, error) {
-	jsonPayload, err := json.Marshal(payload)
+	canonicalized, err := util.MarshalCanonicalJSON(payload)
 	if err != nil {
 		return nil, err
 	}
-	canonicalized, err := jsoncanonicalizer.Transform(jsonPayload)
-	if err != nil {
-		return nil, err
-	}
-	signer, err := signature.LoadECDSASignerVerifier(ca.rekorKey, crypto.SHA256)
-	if err != nil {
-		return nil, err
-	}
-	bundleSig, err := signer.SignMessage(bytes.NewReader(canonicalized))
+	bundleSig, err := ca.messageSigner(ca.rekorKey).SignMessage(bytes.NewReader(canonicalized))
 	if err != nil {
 		return nil, err
 	}
@@ -142,11 +204,16 @@ func (ca *VirtualSigstore) rekorSignPayload(payload tlog.RekorPayload) ([]byte,
 }
 
 func (ca *VirtualSigstore) GenerateLeafCert(identity, issuer string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
-	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privKey, err := ca.generateKey(fmt.Sprintf("leaf %d", ca.leafKeys.Add(1)))
 	if err != nil {
 		return nil, nil, err
 	}
-	leafCert, err := GenerateLeafCert(identity, issuer, time.Now(), privKey, ca.fulcioCA.Intermediates[0], ca.fulcioIntermediateKey)
+	var leafCert *x509.Certificate
+	if ca.opts.EmbeddedSCTs {
+		leafCert, err = ca.generateLeafCertWithSCT(identity, issuer, privKey)
+	} else {
+		leafCert, err = GenerateLeafCert(identity, issuer, ca.now(), privKey, ca.fulcioCA.Intermediates[0], ca.signer(ca.fulcioIntermediateKey))
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -156,22 +223,27 @@ func (ca *VirtualSigstore) GenerateLeafCert(identity, issuer string) (*x509.Cert
 func (ca *VirtualSigstore) Attest(identity, issuer string, envelopeBody []byte) (*TestEntity, error) {
 	// The timing here is important. We need to attest at a time when the leaf
 	// certificate is valid, so we match what GenerateLeafCert() does, above
-	return ca.AttestAtTime(identity, issuer, envelopeBody, time.Now().Add(5*time.Minute))
+	return ca.AttestAtTime(identity, issuer, envelopeBody, ca.now().Add(5*time.Minute))
 }
 
 func (ca *VirtualSigstore) AttestAtTime(identity, issuer string, envelopeBody []byte, integratedTime time.Time) (*TestEntity, error) {
-	leafCert, leafPrivKey, err := ca.GenerateLeafCert(identity, issuer)
-	if err != nil {
-		return nil, err
-	}
+	return ca.attestAtTime(identity, issuer, envelopeBody, integratedTime, intoto.KIND)
+}
+
+// AttestDSSE is like Attest, but records the envelope in the transparency log
+// as a dsse entry rather than an intoto entry.
+func (ca *VirtualSigstore) AttestDSSE(identity, issuer string, envelopeBody []byte) (*TestEntity, error) {
+	return ca.attestAtTime(identity, issuer, envelopeBody, ca.now().Add(5*time.Minute), rekordsse.KIND)
+}
 
-	signer, err := signature.LoadECDSASignerVerifier(leafPrivKey, crypto.SHA256)
+func (ca *VirtualSigstore) attestAtTime(identity, issuer string, envelopeBody []byte, integratedTime time.Time, kind string) (*TestEntity, error) {
+	leafCert, leafPrivKey, err := ca.GenerateLeafCert(identity, issuer)
 	if err != nil {
 		return nil, err
 	}
 
 	dsseSigner, err := dsse.NewEnvelopeSigner(&sigdsse.SignerAdapter{
-		SignatureSigner: signer,
+		SignatureSigner: ca.messageSigner(leafPrivKey),
 		Pub:             leafCert.PublicKey.(*ecdsa.PublicKey),
 	})
 	if err != nil {
@@ -188,26 +260,27 @@ func (ca *VirtualSigstore) AttestAtTime(identity, issuer string, envelopeBody []
 		return nil, err
 	}
 
-	tsr, err := generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey)
+	tsr, err := ca.generateTimestampingResponse(sig)
 	if err != nil {
 		return nil, err
 	}
 
-	entry, err := ca.generateTlogEntry(leafCert, envelope, sig, integratedTime.Unix())
+	protoEntry, entry, err := ca.generateTlogEntry(kind, leafCert, envelope, sig, integratedTime.Unix())
 	if err != nil {
 		return nil, err
 	}
 
 	return &TestEntity{
-		certChain:   []*x509.Certificate{leafCert, ca.fulcioCA.Intermediates[0], ca.fulcioCA.Root},
-		timestamps:  [][]byte{tsr},
-		envelope:    envelope,
-		tlogEntries: []*tlog.Entry{entry},
+		certChain:        []*x509.Certificate{leafCert, ca.fulcioCA.Intermediates[0], ca.fulcioCA.Root},
+		timestamps:       [][]byte{tsr},
+		envelope:         envelope,
+		tlogEntries:      []*tlog.Entry{entry},
+		protoTlogEntries: []*protorekor.TransparencyLogEntry{protoEntry},
 	}, nil
 }
 
 func (ca *VirtualSigstore) Sign(identity, issuer string, artifact []byte) (*TestEntity, error) {
-	return ca.SignAtTime(identity, issuer, artifact, time.Now().Add(5*time.Minute))
+	return ca.SignAtTime(identity, issuer, artifact, ca.now().Add(5*time.Minute))
 }
 
 func (ca *VirtualSigstore) SignAtTime(identity, issuer string, artifact []byte, integratedTime time.Time) (*TestEntity, error) {
@@ -216,23 +289,18 @@ func (ca *VirtualSigstore) SignAtTime(identity, issuer string, artifact []byte,
 		return nil, err
 	}
 
-	signer, err := signature.LoadECDSASignerVerifier(leafPrivKey, crypto.SHA256)
-	if err != nil {
-		return nil, err
-	}
-
 	digest := sha256.Sum256(artifact)
-	sig, err := signer.SignMessage(bytes.NewReader(artifact))
+	sig, err := ca.messageSigner(leafPrivKey).SignMessage(bytes.NewReader(artifact))
 	if err != nil {
 		return nil, err
 	}
 
-	tsr, err := generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey)
+	tsr, err := ca.generateTimestampingResponse(sig)
 	if err != nil {
 		return nil, err
 	}
 
-	entry, err := ca.generateTlogEntryHashedRekord(leafCert, artifact, sig, integratedTime.Unix())
+	protoEntry, entry, err := ca.generateTlogEntryHashedRekord(leafCert, artifact, sig, integratedTime.Unix())
 	if err != nil {
 		return nil, err
 	}
@@ -242,70 +310,64 @@ func (ca *VirtualSigstore) SignAtTime(identity, issuer string, artifact []byte,
 		timestamps:       [][]byte{tsr},
 		messageSignature: bundle.NewMessageSignature(digest[:], "SHA2_256", sig),
 		tlogEntries:      []*tlog.Entry{entry},
+		protoTlogEntries: []*protorekor.TransparencyLogEntry{protoEntry},
 	}, nil
 }
 
-func (ca *VirtualSigstore) generateTlogEntry(leafCert *x509.Certificate, envelope *dsse.Envelope, sig []byte, integratedTime int64) (*tlog.Entry, error) {
+func (ca *VirtualSigstore) generateTlogEntry(kind string, leafCert *x509.Certificate, envelope *dsse.Envelope, sig []byte, integratedTime int64) (*protorekor.TransparencyLogEntry, *tlog.Entry, error) {
 	leafCertPem, err := cryptoutils.MarshalCertificateToPEM(leafCert)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	envelopeBytes, err := json.Marshal(envelope)
 	if err != nil {
-		return nil, err
-	}
-
-	rekorBody, err := generateRekorEntry(intoto.KIND, intoto.New().DefaultVersion(), envelopeBytes, leafCertPem, sig)
-	if err != nil {
-		return nil, err
-	}
-
-	rekorLogID, err := getLogID(ca.rekorKey.Public())
-	if err != nil {
-		return nil, err
-	}
-
-	rekorLogIDRaw, err := hex.DecodeString(rekorLogID)
-	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	logIndex := int64(1000)
-
-	b := createRekorBundle(rekorLogID, integratedTime, logIndex, rekorBody)
-	set, err := ca.rekorSignPayload(*b)
-	if err != nil {
-		return nil, err
+	var version string
+	switch kind {
+	case rekordsse.KIND:
+		version = rekordsse.New().DefaultVersion()
+	default:
+		version = intoto.New().DefaultVersion()
 	}
 
-	rekorBodyRaw, err := base64.StdEncoding.DecodeString(rekorBody)
+	rekorBody, err := generateRekorEntry(kind, version, envelopeBytes, leafCertPem, sig)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return tlog.NewEntry(rekorBodyRaw, integratedTime, logIndex, rekorLogIDRaw, set, nil)
+	return ca.logEntry(kind, version, rekorBody, integratedTime)
 }
 
-func (ca *VirtualSigstore) generateTlogEntryHashedRekord(leafCert *x509.Certificate, artifact []byte, sig []byte, integratedTime int64) (*tlog.Entry, error) {
+func (ca *VirtualSigstore) generateTlogEntryHashedRekord(leafCert *x509.Certificate, artifact []byte, sig []byte, integratedTime int64) (*protorekor.TransparencyLogEntry, *tlog.Entry, error) {
 	leafCertPem, err := cryptoutils.MarshalCertificateToPEM(leafCert)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	rekorBody, err := generateRekorEntry(hashedrekord.KIND, hashedrekord.New().DefaultVersion(), artifact, leafCertPem, sig)
+	version := hashedrekord.New().DefaultVersion()
+	rekorBody, err := generateRekorEntry(hashedrekord.KIND, version, artifact, leafCertPem, sig)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	return ca.logEntry(hashedrekord.KIND, version, rekorBody, integratedTime)
+}
+
+// logEntry has the virtual Rekor log a base64-encoded entry body, returning
+// the log entry with its signed entry timestamp and, with InclusionProofs
+// set, its inclusion proof.
+func (ca *VirtualSigstore) logEntry(kind, version, rekorBody string, integratedTime int64) (*protorekor.TransparencyLogEntry, *tlog.Entry, error) {
 	rekorLogID, err := getLogID(ca.rekorKey.Public())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rekorLogIDRaw, err := hex.DecodeString(rekorLogID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	logIndex := int64(1000)
@@ -313,18 +375,39 @@ func (ca *VirtualSigstore) generateTlogEntryHashedRekord(leafCert *x509.Certific
 	b := createRekorBundle(rekorLogID, integratedTime, logIndex, rekorBody)
 	set, err := ca.rekorSignPayload(*b)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rekorBodyRaw, err := base64.StdEncoding.DecodeString(rekorBody)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return tlog.NewEntry(rekorBodyRaw, integratedTime, logIndex, rekorLogIDRaw, set, nil)
+	protoEntry := &protorekor.TransparencyLogEntry{
+		LogIndex:          logIndex,
+		LogId:             &protocommon.LogId{KeyId: rekorLogIDRaw},
+		KindVersion:       &protorekor.KindVersion{Kind: kind, Version: version},
+		IntegratedTime:    integratedTime,
+		InclusionPromise:  &protorekor.InclusionPromise{SignedEntryTimestamp: set},
+		CanonicalizedBody: rekorBodyRaw,
+	}
+	if ca.opts.InclusionProofs {
+		protoEntry.InclusionProof, err = ca.inclusionProof(rekorBodyRaw, logIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entry, err := tlog.ParseEntry(protoEntry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return protoEntry, entry, nil
 }
 
 func (ca *VirtualSigstore) PublicKeyVerifier(keyID string) (root.TimeConstrainedVerifier, error) {
+	ca.publicKeyMu.Lock()
+	defer ca.publicKeyMu.Unlock()
 	v, ok := ca.publicKeyVerifier[keyID]
 	if !ok {
 		return nil, fmt.Errorf("public key not found for keyID: %s", keyID)
@@ -354,6 +437,8 @@ func createEntry(ctx context.Context, kind, apiVersion string, blobBytes, certBy
 	case rekord.KIND, intoto.KIND:
 		props.ArtifactBytes = blobBytes
 		props.SignatureBytes = sigBytes
+	case rekordsse.KIND:
+		props.ArtifactBytes = blobBytes
 	case hashedrekord.KIND:
 		blobHash := sha256.Sum256(blobBytes)
 		props.ArtifactHash = strings.ToLower(hex.EncodeToString(blobHash[:]))
@@ -391,9 +476,9 @@ func createRekorBundle(logID string, integratedTime int64, logIndex int64, rekor
 	}
 }
 
-func generateTimestampingResponse(sig []byte, tsaCert *x509.Certificate, tsaKey *ecdsa.PrivateKey) ([]byte, error) {
+func (ca *VirtualSigstore) generateTimestampingResponse(sig []byte) ([]byte, error) {
 	var hash crypto.Hash
-	switch tsaKey.Curve {
+	switch ca.tsaLeafKey.Curve {
 	case elliptic.P256():
 		hash = crypto.SHA256
 	case elliptic.P384():
@@ -416,14 +501,14 @@ func generateTimestampingResponse(sig []byte, tsaCert *x509.Certificate, tsaKey
 	tsTemplate := timestamp.Timestamp{
 		HashAlgorithm:   req.HashAlgorithm,
 		HashedMessage:   req.HashedMessage,
-		Time:            time.Now(),
+		Time:            ca.now(),
 		Policy:          asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 2},
 		Ordering:        false,
 		Qualified:       false,
 		ExtraExtensions: req.Extensions,
 	}
 
-	return tsTemplate.CreateResponseWithOpts(tsaCert, tsaKey, hash)
+	return tsTemplate.CreateResponseWithOpts(ca.tsaCA.Leaf, ca.signer(ca.tsaLeafKey), hash)
 }
 
 func (ca *VirtualSigstore) TimestampingAuthorities() []root.CertificateAuthority {
@@ -443,8 +528,8 @@ func (ca *VirtualSigstore) RekorLogs() map[string]*root.TransparencyLog {
 	verifiers[logID] = &root.TransparencyLog{
 		BaseURL:             "test",
 		ID:                  []byte(logID),
-		ValidityPeriodStart: time.Now().Add(-time.Hour),
-		ValidityPeriodEnd:   time.Now().Add(time.Hour),
+		ValidityPeriodStart: ca.now().Add(-time.Hour),
+		ValidityPeriodEnd:   ca.now().Add(time.Hour),
 		HashFunc:            crypto.SHA256,
 		PublicKey:           ca.rekorKey.Public(),
 	}
@@ -460,24 +545,348 @@ func (ca *VirtualSigstore) CTLogs() map[string]*root.TransparencyLog {
 	verifiers[logID] = &root.TransparencyLog{
 		BaseURL:             "test",
 		ID:                  []byte(logID),
-		ValidityPeriodStart: time.Now().Add(-time.Hour),
-		ValidityPeriodEnd:   time.Now().Add(time.Hour),
+		ValidityPeriodStart: ca.now().Add(-time.Hour),
+		ValidityPeriodEnd:   ca.now().Add(time.Hour),
 		HashFunc:            crypto.SHA256,
 		PublicKey:           ca.ctlogKey.Public(),
 	}
 	return verifiers
 }
 
+// AddDetachedSCT has the virtual CT log issue an SCT for the entity's leaf
+// certificate and attaches it to the entity's verification content as a
+// detached SCT, as some Fulcio deployments return them.
+func (ca *VirtualSigstore) AddDetachedSCT(entity *TestEntity) error {
+	return ca.addDetachedSCT(entity, false)
+}
+
+// AddDetachedPrecertificateSCT is like AddDetachedSCT, but has the virtual
+// CT log issue the SCT for the leaf certificate's precertificate, as it
+// does for SCTs that are embedded in the final certificate.
+func (ca *VirtualSigstore) AddDetachedPrecertificateSCT(entity *TestEntity) error {
+	return ca.addDetachedSCT(entity, true)
+}
+
+func (ca *VirtualSigstore) addDetachedSCT(entity *TestEntity, precertificate bool) error {
+	logID, err := getLogID(ca.ctlogKey.Public())
+	if err != nil {
+		return err
+	}
+	logIDBytes, err := hex.DecodeString(logID)
+	if err != nil {
+		return err
+	}
+
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		Timestamp:  uint64(ca.now().UnixMilli()), // nolint: gosec
+	}
+	copy(sct.LogID.KeyID[:], logIDBytes)
+
+	var leaf *ct.MerkleTreeLeaf
+	if precertificate {
+		// The virtual Fulcio does not embed SCTs, so the precertificate
+		// differs from the certificate only by its poison extension
+		leaf = &ct.MerkleTreeLeaf{
+			Version:  ct.V1,
+			LeafType: ct.TimestampedEntryLeafType,
+			TimestampedEntry: &ct.TimestampedEntry{
+				EntryType: ct.PrecertLogEntryType,
+				Timestamp: sct.Timestamp,
+				PrecertEntry: &ct.PreCert{
+					IssuerKeyHash:  sha256.Sum256(entity.certChain[1].RawSubjectPublicKeyInfo),
+					TBSCertificate: entity.certChain[0].RawTBSCertificate,
+				},
+			},
+		}
+	} else {
+		leaf, err = ct.MerkleTreeLeafFromChain([]*ctx509.Certificate{{Raw: entity.certChain[0].Raw}}, ct.X509LogEntryType, sct.Timestamp)
+		if err != nil {
+			return err
+		}
+	}
+	if err := ca.signSCT(&sct, *leaf); err != nil {
+		return err
+	}
+	digitallySigned, err := cttls.Marshal(sct.Signature)
+	if err != nil {
+		return err
+	}
+
+	resp, err := json.Marshal(ct.AddChainResponse{
+		SCTVersion: sct.SCTVersion,
+		ID:         logIDBytes,
+		Timestamp:  sct.Timestamp,
+		Signature:  digitallySigned,
+	})
+	if err != nil {
+		return err
+	}
+
+	entity.detachedSCTs = append(entity.detachedSCTs, resp)
+	return nil
+}
+
+// signSCT has the virtual CT log sign an SCT for a log entry.
+func (ca *VirtualSigstore) signSCT(sct *ct.SignedCertificateTimestamp, leaf ct.MerkleTreeLeaf) error {
+	signatureInput, err := ct.SerializeSCTSignatureInput(*sct, ct.LogEntry{Leaf: leaf})
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(signatureInput)
+	signature, err := ca.signer(ca.ctlogKey).Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	sct.Signature = ct.DigitallySigned{
+		Algorithm: cttls.SignatureAndHashAlgorithm{
+			Hash:      cttls.SHA256,
+			Signature: cttls.ECDSA,
+		},
+		Signature: signature,
+	}
+	return nil
+}
+
+// generateLeafCertWithSCT issues a leaf certificate with an SCT embedded
+// through the precertificate flow Fulcio uses: a precertificate with the
+// poison extension is logged, and the final certificate carries the SCT in
+// place of the poison, so that their TBS certificates match once both
+// extensions are removed.
+func (ca *VirtualSigstore) generateLeafCertWithSCT(identity, issuer string, priv *ecdsa.PrivateKey) (*x509.Certificate, error) {
+	parent := ca.fulcioCA.Intermediates[0]
+	template := leafCertTemplate(identity, issuer, ca.now())
+	baseExtensions := template.ExtraExtensions
+	template.ExtraExtensions = append(baseExtensions, pkix.Extension{
+		Id:       asn1.ObjectIdentifier(ctx509.OIDExtensionCTPoison),
+		Critical: true,
+		Value:    asn1.NullBytes,
+	})
+	precert, err := createCertificate(template, parent, &priv.PublicKey, ca.signer(ca.fulcioIntermediateKey))
+	if err != nil {
+		return nil, err
+	}
+	tbs, err := ctx509.RemoveCTPoison(precert.RawTBSCertificate)
+	if err != nil {
+		return nil, err
+	}
+
+	logID, err := getLogID(ca.ctlogKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	logIDBytes, err := hex.DecodeString(logID)
+	if err != nil {
+		return nil, err
+	}
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		Timestamp:  uint64(ca.now().UnixMilli()), // nolint: gosec
+	}
+	copy(sct.LogID.KeyID[:], logIDBytes)
+	err = ca.signSCT(&sct, ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			EntryType: ct.PrecertLogEntryType,
+			Timestamp: sct.Timestamp,
+			PrecertEntry: &ct.PreCert{
+				IssuerKeyHash:  sha256.Sum256(parent.RawSubjectPublicKeyInfo),
+				TBSCertificate: tbs,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serializedSCT, err := cttls.Marshal(sct)
+	if err != nil {
+		return nil, err
+	}
+	sctList, err := cttls.Marshal(ctx509.SignedCertificateTimestampList{
+		SCTList: []ctx509.SerializedSCT{{Val: serializedSCT}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sctExtension, err := asn1.Marshal(sctList)
+	if err != nil {
+		return nil, err
+	}
+	template.ExtraExtensions = append(baseExtensions, pkix.Extension{
+		Id:    asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT),
+		Value: sctExtension,
+	})
+	return createCertificate(template, parent, &priv.PublicKey, ca.signer(ca.fulcioIntermediateKey))
+}
+
+// inclusionProof returns a proof of inclusion of an entry body at logIndex
+// in a small log, whose other entries are fixed filler entries, against a
+// checkpoint signed by the virtual Rekor log.
+func (ca *VirtualSigstore) inclusionProof(body []byte, logIndex int64) (*protorekor.InclusionProof, error) {
+	treeSize := logIndex + 2
+	leaves := make([][]byte, treeSize)
+	for i := range leaves {
+		leaves[i] = rfc6962.DefaultHasher.HashLeaf([]byte(fmt.Sprintf("filler entry %d", i)))
+	}
+	leaves[logIndex] = rfc6962.DefaultHasher.HashLeaf(body)
+	rootHash := merkleTreeHash(leaves)
+
+	checkpoint, err := rekorutil.CreateAndSignCheckpoint(context.Background(), "rekor.example.com", 1, uint64(treeSize), rootHash, ca.messageSigner(ca.rekorKey)) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	return &protorekor.InclusionProof{
+		LogIndex:   logIndex,
+		RootHash:   rootHash,
+		TreeSize:   treeSize,
+		Hashes:     inclusionPath(uint64(logIndex), leaves), // nolint: gosec
+		Checkpoint: &protorekor.Checkpoint{Envelope: string(checkpoint)},
+	}, nil
+}
+
+// merkleTreeHash returns the RFC 6962 hash of a tree of leaf hashes.
+func merkleTreeHash(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	k := splitPoint(len(leaves))
+	return rfc6962.DefaultHasher.HashChildren(merkleTreeHash(leaves[:k]), merkleTreeHash(leaves[k:]))
+}
+
+// inclusionPath returns the RFC 6962 audit path of the leaf at index.
+func inclusionPath(index uint64, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := splitPoint(len(leaves))
+	if index < uint64(k) {
+		return append(inclusionPath(index, leaves[:k]), merkleTreeHash(leaves[k:]))
+	}
+	return append(inclusionPath(index-uint64(k), leaves[k:]), merkleTreeHash(leaves[:k]))
+}
+
+// splitPoint returns the largest power of two smaller than n.
+func splitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// TrustedRoot returns the virtual Sigstore's certificate authorities and
+// logs as a trusted root, e.g. to write it to a file for other clients.
+func (ca *VirtualSigstore) TrustedRoot() (*root.TrustedRoot, error) {
+	trustedRoot, err := root.NewTrustedRootFromProtobuf(&prototrustroot.TrustedRoot{MediaType: root.TrustedRootMediaType01})
+	if err != nil {
+		return nil, err
+	}
+	if err = trustedRoot.AddCertificateAuthority(ca.fulcioCA); err != nil {
+		return nil, err
+	}
+	if err = trustedRoot.AddTimestampingAuthority(ca.tsaCA); err != nil {
+		return nil, err
+	}
+	// Trusted roots identify logs by their raw key hash, where RekorLogs and
+	// CTLogs use its hex encoding
+	for _, key := range []*ecdsa.PrivateKey{ca.rekorKey, ca.ctlogKey} {
+		logID, err := getLogID(key.Public())
+		if err != nil {
+			return nil, err
+		}
+		logIDBytes, err := hex.DecodeString(logID)
+		if err != nil {
+			return nil, err
+		}
+		tlog := &root.TransparencyLog{
+			BaseURL:             "test",
+			ID:                  logIDBytes,
+			ValidityPeriodStart: ca.now().Add(-time.Hour),
+			ValidityPeriodEnd:   ca.now().Add(time.Hour),
+			HashFunc:            crypto.SHA256,
+			PublicKey:           key.Public(),
+		}
+		if key == ca.rekorKey {
+			err = trustedRoot.AddRekorLog(tlog)
+		} else {
+			err = trustedRoot.AddCTLog(tlog)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return trustedRoot, nil
+}
+
 type TestEntity struct {
 	certChain        []*x509.Certificate
+	detachedSCTs     [][]byte
 	envelope         *dsse.Envelope
 	messageSignature *bundle.MessageSignature
 	timestamps       [][]byte
 	tlogEntries      []*tlog.Entry
+	protoTlogEntries []*protorekor.TransparencyLogEntry
+}
+
+// Bundle returns the entity as a bundle, with its leaf certificate as
+// verification material: a v0.3 bundle if its log entries have inclusion
+// proofs, and a v0.1 bundle otherwise.
+func (e *TestEntity) Bundle() (*protobundle.Bundle, error) {
+	version := "0.1"
+	material := &protobundle.VerificationMaterial{
+		Content: &protobundle.VerificationMaterial_X509CertificateChain{
+			X509CertificateChain: &protocommon.X509CertificateChain{
+				Certificates: []*protocommon.X509Certificate{{RawBytes: e.certChain[0].Raw}},
+			},
+		},
+		TlogEntries: e.protoTlogEntries,
+	}
+	if e.HasInclusionProof() {
+		version = "0.3"
+		material.Content = &protobundle.VerificationMaterial_Certificate{
+			Certificate: &protocommon.X509Certificate{RawBytes: e.certChain[0].Raw},
+		}
+	}
+	if len(e.timestamps) > 0 {
+		material.TimestampVerificationData = &protobundle.TimestampVerificationData{}
+		for _, ts := range e.timestamps {
+			material.TimestampVerificationData.Rfc3161Timestamps = append(material.TimestampVerificationData.Rfc3161Timestamps, &protocommon.RFC3161SignedTimestamp{SignedTimestamp: ts})
+		}
+	}
+	mediaType, err := bundle.MediaTypeString(version)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &protobundle.Bundle{MediaType: mediaType, VerificationMaterial: material}
+	if e.envelope != nil {
+		payload, err := base64.StdEncoding.DecodeString(e.envelope.Payload)
+		if err != nil {
+			return nil, err
+		}
+		envelope := &protodsse.Envelope{Payload: payload, PayloadType: e.envelope.PayloadType}
+		for _, sig := range e.envelope.Signatures {
+			raw, err := base64.StdEncoding.DecodeString(sig.Sig)
+			if err != nil {
+				return nil, err
+			}
+			envelope.Signatures = append(envelope.Signatures, &protodsse.Signature{Sig: raw, Keyid: sig.KeyID})
+		}
+		pb.Content = &protobundle.Bundle_DsseEnvelope{DsseEnvelope: envelope}
+	} else {
+		pb.Content = &protobundle.Bundle_MessageSignature{MessageSignature: &protocommon.MessageSignature{
+			MessageDigest: &protocommon.HashOutput{Algorithm: protocommon.HashAlgorithm_SHA2_256, Digest: e.messageSignature.Digest()},
+			Signature:     e.messageSignature.Signature(),
+		}}
+	}
+	bundle.SetDetachedSCTs(pb, e.detachedSCTs)
+	return pb, nil
 }
 
 func (e *TestEntity) VerificationContent() (verify.VerificationContent, error) {
-	return &bundle.Certificate{Certificate: e.certChain[0]}, nil
+	return &bundle.Certificate{Certificate: e.certChain[0], DetachedSCTs: e.detachedSCTs}, nil
 }
 
 func (e *TestEntity) HasInclusionPromise() bool {
@@ -485,7 +894,12 @@ func (e *TestEntity) HasInclusionPromise() bool {
 }
 
 func (e *TestEntity) HasInclusionProof() bool {
-	return false
+	for _, entry := range e.tlogEntries {
+		if !entry.HasInclusionProof() {
+			return false
+		}
+	}
+	return len(e.tlogEntries) > 0
 }
 
 func (e *TestEntity) SignatureContent() (verify.SignatureContent, error) {
@@ -519,25 +933,43 @@ func createCertificate(template *x509.Certificate, parent *x509.Certificate, pub
 }
 
 func GenerateRootCa() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := generateRootCA(time.Now(), priv, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, priv, nil
+}
+
+func generateRootCA(now time.Time, priv *ecdsa.PrivateKey, signer crypto.Signer) (*x509.Certificate, error) {
 	rootTemplate := &x509.Certificate{
 		SerialNumber: big.NewInt(1),
 		Subject: pkix.Name{
 			CommonName:   "sigstore",
 			Organization: []string{"sigstore.dev"},
 		},
-		NotBefore:             time.Now().Add(-5 * time.Hour),
-		NotAfter:              time.Now().Add(5 * time.Hour),
+		NotBefore:             now.Add(-5 * time.Hour),
+		NotAfter:              now.Add(5 * time.Hour),
 		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 	}
 
+	return createCertificate(rootTemplate, rootTemplate, &priv.PublicKey, signer)
+}
+
+func GenerateFulcioIntermediate(rootTemplate *x509.Certificate, rootPriv crypto.Signer) (*x509.Certificate, *ecdsa.PrivateKey, error) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	cert, err := createCertificate(rootTemplate, rootTemplate, &priv.PublicKey, priv)
+	cert, err := generateFulcioIntermediate(time.Now(), priv, rootTemplate, rootPriv)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -545,27 +977,31 @@ func GenerateRootCa() (*x509.Certificate, *ecdsa.PrivateKey, error) {
 	return cert, priv, nil
 }
 
-func GenerateFulcioIntermediate(rootTemplate *x509.Certificate, rootPriv crypto.Signer) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func generateFulcioIntermediate(now time.Time, priv *ecdsa.PrivateKey, rootTemplate *x509.Certificate, rootPriv crypto.Signer) (*x509.Certificate, error) {
 	subTemplate := &x509.Certificate{
 		SerialNumber: big.NewInt(1),
 		Subject: pkix.Name{
 			CommonName:   "sigstore-intermediate",
 			Organization: []string{"sigstore.dev"},
 		},
-		NotBefore:             time.Now().Add(-2 * time.Minute),
-		NotAfter:              time.Now().Add(2 * time.Hour),
+		NotBefore:             now.Add(-2 * time.Minute),
+		NotAfter:              now.Add(2 * time.Hour),
 		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 	}
 
+	return createCertificate(subTemplate, rootTemplate, &priv.PublicKey, rootPriv)
+}
+
+func GenerateTSAIntermediate(rootTemplate *x509.Certificate, rootPriv crypto.Signer) (*x509.Certificate, *ecdsa.PrivateKey, error) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	cert, err := createCertificate(subTemplate, rootTemplate, &priv.PublicKey, rootPriv)
+	cert, err := generateTSAIntermediate(time.Now(), priv, rootTemplate, rootPriv)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -573,37 +1009,36 @@ func GenerateFulcioIntermediate(rootTemplate *x509.Certificate, rootPriv crypto.
 	return cert, priv, nil
 }
 
-func GenerateTSAIntermediate(rootTemplate *x509.Certificate, rootPriv crypto.Signer) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+func generateTSAIntermediate(now time.Time, priv *ecdsa.PrivateKey, rootTemplate *x509.Certificate, rootPriv crypto.Signer) (*x509.Certificate, error) {
 	subTemplate := &x509.Certificate{
 		SerialNumber: big.NewInt(1),
 		Subject: pkix.Name{
 			CommonName:   "sigstore-tsa-intermediate",
 			Organization: []string{"sigstore.dev"},
 		},
-		NotBefore:             time.Now().Add(-2 * time.Minute),
-		NotAfter:              time.Now().Add(2 * time.Hour),
+		NotBefore:             now.Add(-2 * time.Minute),
+		NotAfter:              now.Add(2 * time.Hour),
 		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 	}
 
-	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		return nil, nil, err
-	}
+	return createCertificate(subTemplate, rootTemplate, &priv.PublicKey, rootPriv)
+}
 
-	cert, err := createCertificate(subTemplate, rootTemplate, &priv.PublicKey, rootPriv)
+func GenerateLeafCert(subject string, oidcIssuer string, expiration time.Time, priv *ecdsa.PrivateKey,
+	parentTemplate *x509.Certificate, parentPriv crypto.Signer) (*x509.Certificate, error) {
+	cert, err := createCertificate(leafCertTemplate(subject, oidcIssuer, expiration), parentTemplate, &priv.PublicKey, parentPriv)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return cert, priv, nil
+	return cert, nil
 }
 
-func GenerateLeafCert(subject string, oidcIssuer string, expiration time.Time, priv *ecdsa.PrivateKey,
-	parentTemplate *x509.Certificate, parentPriv crypto.Signer) (*x509.Certificate, error) {
-	certTemplate := &x509.Certificate{
+func leafCertTemplate(subject string, oidcIssuer string, expiration time.Time) *x509.Certificate {
+	return &x509.Certificate{
 		SerialNumber:   big.NewInt(1),
 		EmailAddresses: []string{subject},
 		NotBefore:      expiration,
@@ -619,13 +1054,6 @@ func GenerateLeafCert(subject string, oidcIssuer string, expiration time.Time, p
 		},
 		},
 	}
-
-	cert, err := createCertificate(certTemplate, parentTemplate, &priv.PublicKey, parentPriv)
-	if err != nil {
-		return nil, err
-	}
-
-	return cert, nil
 }
 
 func GenerateTSALeafCert(expiration time.Time, priv *ecdsa.PrivateKey, parentTemplate *x509.Certificate, parentPriv crypto.Signer) (*x509.Certificate, error) {
@@ -0,0 +1,104 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// generateKey returns a new P-256 key, derived from the seed and label if
+// the virtual Sigstore has a seed.
+func (ca *VirtualSigstore) generateKey(label string) (*ecdsa.PrivateKey, error) {
+	if ca.opts.Seed == nil {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+
+	// Candidate scalars are drawn until one is in range, which all but
+	// certainly the first is
+	for counter := byte(0); counter < 255; counter++ {
+		mac := hmac.New(sha256.New, ca.opts.Seed)
+		mac.Write([]byte(label))
+		mac.Write([]byte{counter})
+		scalar := mac.Sum(nil)
+
+		key, err := ecdh.P256().NewPrivateKey(scalar)
+		if err != nil {
+			continue
+		}
+		// The uncompressed point is 0x04 || X || Y
+		point := key.PublicKey().Bytes()
+		return &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(point[1:33]),
+				Y:     new(big.Int).SetBytes(point[33:]),
+			},
+			D: new(big.Int).SetBytes(scalar),
+		}, nil
+	}
+	return nil, errors.New("failed to derive key from seed")
+}
+
+// signer returns a signer for key, which signs deterministically if the
+// virtual Sigstore has a seed.
+func (ca *VirtualSigstore) signer(key *ecdsa.PrivateKey) crypto.Signer {
+	if ca.opts.Seed == nil {
+		return key
+	}
+	return deterministicSigner{key}
+}
+
+// messageSigner returns a signer of messages for key, over their SHA-256
+// digest, as Rekor and Fulcio keys sign.
+func (ca *VirtualSigstore) messageSigner(key *ecdsa.PrivateKey) signature.Signer {
+	return messageSigner{ca.signer(key)}
+}
+
+// deterministicSigner makes RFC 6979 ECDSA signatures, ignoring the
+// source of randomness it is given.
+type deterministicSigner struct {
+	*ecdsa.PrivateKey
+}
+
+func (s deterministicSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.PrivateKey.Sign(nil, digest, opts)
+}
+
+type messageSigner struct {
+	crypto.Signer
+}
+
+func (s messageSigner) PublicKey(_ ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return s.Public(), nil
+}
+
+func (s messageSigner) SignMessage(message io.Reader, _ ...signature.SignOption) ([]byte, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, message); err != nil {
+		return nil, err
+	}
+	return s.Sign(rand.Reader, hasher.Sum(nil), crypto.SHA256)
+}
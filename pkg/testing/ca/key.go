@@ -0,0 +1,94 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/signature"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Content is content to be signed, as implemented by sign.Content.
+type Content interface {
+	PreAuthEncoding() []byte
+	Bundle(bundle *protobundle.Bundle, signature, digest []byte, hashAlgorithm protocommon.HashAlgorithm)
+}
+
+// SignWithKey signs content with the public key trusted under keyID,
+// rather than with a certificate, creating the key the first time keyID is
+// used. The returned bundle's verification material is a hint of keyID,
+// and it has no transparency log entries or timestamps.
+func (ca *VirtualSigstore) SignWithKey(keyID string, content Content) (*bundle.ProtobufBundle, error) {
+	signer, err := ca.keySigner(keyID)
+	if err != nil {
+		return nil, err
+	}
+	data := content.PreAuthEncoding()
+	sig, err := signer.SignMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(data)
+
+	mediaType, err := bundle.MediaTypeString("0.3")
+	if err != nil {
+		return nil, err
+	}
+	pb := &protobundle.Bundle{
+		MediaType: mediaType,
+		VerificationMaterial: &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_PublicKey{
+				PublicKey: &protocommon.PublicKeyIdentifier{Hint: keyID},
+			},
+		},
+	}
+	content.Bundle(pb, sig, digest[:], protocommon.HashAlgorithm_SHA2_256)
+	return bundle.NewProtobufBundle(pb)
+}
+
+// keySigner returns a signer for the key trusted under keyID, creating the
+// key if there is none.
+func (ca *VirtualSigstore) keySigner(keyID string) (signature.Signer, error) {
+	ca.publicKeyMu.Lock()
+	defer ca.publicKeyMu.Unlock()
+	if ca.publicKeyVerifier == nil {
+		ca.publicKeyVerifier = make(map[string]root.TimeConstrainedVerifier)
+		ca.publicKeys = make(map[string]signature.Signer)
+	}
+	if signer, ok := ca.publicKeys[keyID]; ok {
+		return signer, nil
+	}
+
+	key, err := ca.generateKey("key " + keyID)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := signature.LoadVerifier(key.Public(), crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	signer := ca.messageSigner(key)
+	ca.publicKeys[keyID] = signer
+	ca.publicKeyVerifier[keyID] = root.NewExpiringKey(verifier, time.Time{}, time.Time{})
+	return signer, nil
+}
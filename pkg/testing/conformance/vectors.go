@@ -0,0 +1,291 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance generates test vectors for cross-client conformance
+// testing: a trusted root, an artifact, and a matrix of valid and
+// deliberately broken bundles, with a manifest describing the expected
+// verification outcome of each.
+//
+// The vectors are signed by a virtual Sigstore whose keys are derived from a
+// fixed seed, with deterministic ECDSA signatures, so that the same options
+// always generate the same trusted root and bundles.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+)
+
+// Outcome is the expected result of verifying a test vector.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+const (
+	// ManifestFile, TrustedRootFile and ArtifactFile are the names of the
+	// files written by Vectors.WriteDir; bundles are written to
+	// "<case name>.sigstore.json".
+	ManifestFile    = "manifest.json"
+	TrustedRootFile = "trusted_root.json"
+	ArtifactFile    = "artifact.txt"
+
+	// DefaultIdentity and DefaultIssuer are the identity the test vectors'
+	// certificates are issued for.
+	DefaultIdentity = "conformance@example.com"
+	DefaultIssuer   = "https://oidc.example.com"
+)
+
+// seed is the seed the virtual Sigstore's keys are derived from.
+var seed = []byte("sigstore-go conformance test vectors")
+
+// DefaultTime is the time the test vectors are signed at by default.
+var DefaultTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Options configures Generate.
+type Options struct {
+	// Time the test vectors are signed at. Defaults to DefaultTime.
+	Time time.Time
+	// Artifact to sign. Defaults to a fixed message.
+	Artifact []byte
+}
+
+// VerificationRequirements are the verifier settings a client must use to
+// obtain the expected outcomes: the minimum number of each kind of verified
+// evidence.
+type VerificationRequirements struct {
+	TransparencyLogEntries      int `json:"transparencyLogEntries"`
+	IntegratedTimestamps        int `json:"integratedTimestamps"`
+	SignedCertificateTimestamps int `json:"signedCertificateTimestamps"`
+}
+
+// ManifestCase describes one test vector.
+type ManifestCase struct {
+	Name                  string  `json:"name"`
+	Description           string  `json:"description"`
+	Bundle                string  `json:"bundle"`
+	Artifact              string  `json:"artifact"`
+	CertificateIdentity   string  `json:"certificateIdentity"`
+	CertificateOIDCIssuer string  `json:"certificateOidcIssuer"`
+	ExpectedOutcome       Outcome `json:"expectedOutcome"`
+}
+
+// Manifest describes a set of test vectors and their expected outcomes.
+type Manifest struct {
+	TrustedRoot  string                   `json:"trustedRoot"`
+	Requirements VerificationRequirements `json:"requirements"`
+	Cases        []ManifestCase           `json:"cases"`
+}
+
+// Vectors is a generated set of test vectors.
+type Vectors struct {
+	Manifest    Manifest
+	TrustedRoot []byte
+	Artifact    []byte
+	// Bundles holds the JSON-encoded bundle of each case, by case name
+	Bundles map[string][]byte
+}
+
+type caseSpec struct {
+	name        string
+	description string
+	outcome     Outcome
+	// signing options
+	expired       bool
+	noSCT         bool
+	otherArtifact bool
+	// tamper modifies the bundle after it has been assembled
+	tamper func(*protobundle.Bundle)
+}
+
+var cases = []caseSpec{
+	{
+		name:        "valid",
+		description: "certificate with an embedded SCT, log entry with a valid SET and inclusion proof",
+		outcome:     OutcomeSuccess,
+	},
+	{
+		name:        "bad-set",
+		description: "signed entry timestamp does not verify against the log's key",
+		outcome:     OutcomeFailure,
+		tamper: func(b *protobundle.Bundle) {
+			set := b.VerificationMaterial.TlogEntries[0].InclusionPromise.SignedEntryTimestamp
+			set[len(set)-1] ^= 0xff
+		},
+	},
+	{
+		name:          "wrong-digest",
+		description:   "message digest, signature and log entry are for a different artifact",
+		outcome:       OutcomeFailure,
+		otherArtifact: true,
+	},
+	{
+		name:        "expired-certificate",
+		description: "signing certificate expired before the entry was integrated into the log",
+		outcome:     OutcomeFailure,
+		expired:     true,
+	},
+	{
+		name:        "missing-sct",
+		description: "signing certificate has no SCT",
+		outcome:     OutcomeFailure,
+		noSCT:       true,
+	},
+	{
+		name:        "tampered-inclusion-proof",
+		description: "inclusion proof hash was modified, so the proof does not lead to the checkpoint's root hash",
+		outcome:     OutcomeFailure,
+		tamper: func(b *protobundle.Bundle) {
+			hashes := b.VerificationMaterial.TlogEntries[0].InclusionProof.Hashes
+			hashes[0][0] ^= 0xff
+		},
+	},
+}
+
+// Generate creates a trusted root and signs the artifact once for each test
+// vector, breaking the resulting bundles as each case describes.
+func Generate(opts Options) (*Vectors, error) {
+	now := opts.Time
+	if now.IsZero() {
+		now = DefaultTime
+	}
+	artifact := opts.Artifact
+	if artifact == nil {
+		artifact = []byte("sigstore-go conformance test vector\n")
+	}
+
+	sigstoreOpts := ca.VirtualSigstoreOptions{Seed: seed, Time: now, InclusionProofs: true, EmbeddedSCTs: true}
+	sigstore, err := ca.NewVirtualSigstoreWithOptions(sigstoreOpts)
+	if err != nil {
+		return nil, err
+	}
+	// The same seed derives the same keys, so certificates issued without
+	// an SCT chain to the same trusted root
+	sigstoreOpts.EmbeddedSCTs = false
+	sigstoreWithoutSCTs, err := ca.NewVirtualSigstoreWithOptions(sigstoreOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedRoot, err := sigstore.TrustedRoot()
+	if err != nil {
+		return nil, err
+	}
+	trustedRootJSON, err := trustedRoot.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := &Vectors{
+		Manifest: Manifest{
+			TrustedRoot: TrustedRootFile,
+			Requirements: VerificationRequirements{
+				TransparencyLogEntries:      1,
+				IntegratedTimestamps:        1,
+				SignedCertificateTimestamps: 1,
+			},
+		},
+		TrustedRoot: trustedRootJSON,
+		Artifact:    artifact,
+		Bundles:     make(map[string][]byte, len(cases)),
+	}
+	for _, c := range cases {
+		signer := sigstore
+		if c.noSCT {
+			signer = sigstoreWithoutSCTs
+		}
+		b, err := caseBundle(signer, c, artifact, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s: %w", c.name, err)
+		}
+		vectors.Bundles[c.name] = b
+		vectors.Manifest.Cases = append(vectors.Manifest.Cases, ManifestCase{
+			Name:                  c.name,
+			Description:           c.description,
+			Bundle:                c.name + ".sigstore.json",
+			Artifact:              ArtifactFile,
+			CertificateIdentity:   DefaultIdentity,
+			CertificateOIDCIssuer: DefaultIssuer,
+			ExpectedOutcome:       c.outcome,
+		})
+	}
+	return vectors, nil
+}
+
+// WriteDir writes the manifest, trusted root, artifact and bundles to dir,
+// creating it if needed.
+func (v *Vectors) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	manifest, err := json.MarshalIndent(v.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		ManifestFile:    append(manifest, '\n'),
+		TrustedRootFile: v.TrustedRoot,
+		ArtifactFile:    v.Artifact,
+	}
+	for _, c := range v.Manifest.Cases {
+		files[c.Bundle] = v.Bundles[c.Name]
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func caseBundle(sigstore *ca.VirtualSigstore, c caseSpec, artifact []byte, now time.Time) ([]byte, error) {
+	if c.otherArtifact {
+		artifact = append([]byte("not "), artifact...)
+	}
+	// Leaf certificates are valid for ten minutes
+	integratedTime := now.Add(time.Minute)
+	if c.expired {
+		integratedTime = now.Add(11 * time.Minute)
+	}
+	entity, err := sigstore.SignAtTime(DefaultIdentity, DefaultIssuer, artifact, integratedTime)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := entity.Bundle()
+	if err != nil {
+		return nil, err
+	}
+	// RFC 3161 timestamps are never deterministic, so the log entry's
+	// integrated time is the only timestamp
+	pb.VerificationMaterial.TimestampVerificationData = nil
+	if c.tamper != nil {
+		c.tamper(pb)
+	}
+
+	b, err := bundle.NewProtobufBundle(pb)
+	if err != nil {
+		return nil, err
+	}
+	return b.MarshalJSON()
+}
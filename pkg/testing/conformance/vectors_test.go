@@ -0,0 +1,89 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/conformance"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestGeneratedVectorsHaveExpectedOutcomes(t *testing.T) {
+	vectors, err := conformance.Generate(conformance.Options{})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, vectors.WriteDir(dir))
+
+	manifestJSON, err := os.ReadFile(filepath.Join(dir, conformance.ManifestFile))
+	require.NoError(t, err)
+	var manifest conformance.Manifest
+	require.NoError(t, json.Unmarshal(manifestJSON, &manifest))
+	assert.Equal(t, vectors.Manifest, manifest)
+
+	var names []string
+	for _, c := range manifest.Cases {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"valid", "bad-set", "wrong-digest", "expired-certificate", "missing-sct", "tampered-inclusion-proof"}, names)
+
+	trustedRoot, err := root.NewTrustedRootFromPath(filepath.Join(dir, manifest.TrustedRoot))
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(trustedRoot,
+		verify.WithTransparencyLog(manifest.Requirements.TransparencyLogEntries),
+		verify.WithIntegratedTimestamps(manifest.Requirements.IntegratedTimestamps),
+		verify.WithSignedCertificateTimestamps(manifest.Requirements.SignedCertificateTimestamps))
+	require.NoError(t, err)
+
+	for _, c := range manifest.Cases {
+		t.Run(c.Name, func(t *testing.T) {
+			artifact, err := os.ReadFile(filepath.Join(dir, c.Artifact))
+			require.NoError(t, err)
+			identity, err := verify.NewShortCertificateIdentity(c.CertificateOIDCIssuer, c.CertificateIdentity, "", "")
+			require.NoError(t, err)
+			policy := verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity))
+
+			b, err := bundle.LoadJSONFromPath(filepath.Join(dir, c.Bundle))
+			if err == nil {
+				_, err = verifier.Verify(b, policy)
+			}
+			if c.ExpectedOutcome == conformance.OutcomeSuccess {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGeneratedVectorsAreDeterministic(t *testing.T) {
+	first, err := conformance.Generate(conformance.Options{})
+	require.NoError(t, err)
+	second, err := conformance.Generate(conformance.Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, first.TrustedRoot, second.TrustedRoot)
+	assert.Equal(t, first.Bundles, second.Bundles)
+}
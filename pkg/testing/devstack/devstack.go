@@ -0,0 +1,291 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package devstack runs a private Sigstore deployment in process, for
+// prototyping integrations and for end-to-end tests: a Fulcio-like
+// certificate authority with a CT log, a Rekor-like transparency log and a
+// timestamp authority, served over HTTP from a freshly generated PKI.
+//
+// The stack's trusted root and signing config describe its services, so
+// clients configured with them sign and verify as they would with a real
+// deployment:
+//
+//	stack, err := devstack.New(nil)
+//	...
+//	defer stack.Close()
+//	err = stack.WriteFiles(dir) // trusted_root.json and signing_config.json
+//
+// The certificate authority trusts identity tokens from its issuer without
+// verifying their signatures, so the stack must never be used to sign
+// anything real. IDToken returns tokens it accepts.
+package devstack
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+const (
+	// DefaultIssuer is the OIDC issuer whose identity tokens the stack
+	// accepts by default
+	DefaultIssuer = "https://oidc.devstack.example.com"
+	// DefaultValidity is how long the stack's certificate authorities and
+	// keys are valid for by default
+	DefaultValidity = 24 * time.Hour
+
+	// TrustedRootFileName and SigningConfigFileName are the names of the
+	// files written by WriteFiles
+	TrustedRootFileName   = "trusted_root.json"
+	SigningConfigFileName = "signing_config.json"
+)
+
+// Options configures a stack created by New.
+type Options struct {
+	// Optional OIDC issuer whose identity tokens the certificate authority
+	// accepts. Defaults to DefaultIssuer.
+	Issuer string
+	// Optional validity of the certificate authorities and keys. Defaults
+	// to DefaultValidity.
+	Validity time.Duration
+}
+
+// Stack is a running private Sigstore deployment.
+type Stack struct {
+	issuer string
+	pki    *pki
+
+	fulcio *httptest.Server
+	rekor  *httptest.Server
+	tsa    *httptest.Server
+
+	trustedRoot       *root.TrustedRoot
+	signingConfigJSON []byte
+}
+
+// New generates a PKI and starts the stack's services on local ports. The
+// stack must be closed with Close.
+func New(opts *Options) (*Stack, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &Stack{issuer: opts.Issuer}
+	if s.issuer == "" {
+		s.issuer = DefaultIssuer
+	}
+	validity := opts.Validity
+	if validity <= 0 {
+		validity = DefaultValidity
+	}
+
+	// Allow for clock skew between the stack and its clients
+	start := time.Now().Add(-time.Minute)
+	var err error
+	if s.pki, err = newPKI(start, start.Add(validity)); err != nil {
+		return nil, err
+	}
+	rekor, err := newRekor(s.pki)
+	if err != nil {
+		return nil, err
+	}
+	s.fulcio = httptest.NewServer(&fulcio{issuer: s.issuer, pki: s.pki})
+	s.rekor = httptest.NewServer(rekor)
+	s.tsa = httptest.NewServer(&tsa{pki: s.pki})
+
+	if s.trustedRoot, err = s.newTrustedRoot(start); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.signingConfigJSON, err = s.newSigningConfig(start); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close stops the stack's services.
+func (s *Stack) Close() {
+	s.fulcio.Close()
+	s.rekor.Close()
+	s.tsa.Close()
+}
+
+// Issuer returns the OIDC issuer whose identity tokens the stack accepts.
+func (s *Stack) Issuer() string {
+	return s.issuer
+}
+
+// FulcioURL returns the base URL of the certificate authority.
+func (s *Stack) FulcioURL() string {
+	return s.fulcio.URL
+}
+
+// RekorURL returns the base URL of the transparency log.
+func (s *Stack) RekorURL() string {
+	return s.rekor.URL
+}
+
+// TimestampAuthorityURL returns the URL of the timestamp authority.
+func (s *Stack) TimestampAuthorityURL() string {
+	return s.tsa.URL
+}
+
+// TrustedRoot returns the trusted root for verifying entities signed with
+// the stack.
+func (s *Stack) TrustedRoot() *root.TrustedRoot {
+	return s.trustedRoot
+}
+
+// SigningConfig returns the signing config listing the stack's services.
+func (s *Stack) SigningConfig() (*root.SigningConfig, error) {
+	return root.NewSigningConfigFromJSON(s.signingConfigJSON)
+}
+
+// WriteFiles writes the stack's trusted root and signing config to dir, as
+// TrustedRootFileName and SigningConfigFileName.
+func (s *Stack) WriteFiles(dir string) error {
+	trustedRootJSON, err := s.trustedRoot.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, TrustedRootFileName), trustedRootJSON, 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, SigningConfigFileName), s.signingConfigJSON, 0o600)
+}
+
+// IDToken returns an unsigned identity token for the email address, issued
+// by the stack's issuer for the sigstore audience and valid for an hour.
+// The stack's certificate authority issues certificates for it.
+func (s *Stack) IDToken(email string) string {
+	now := time.Now()
+	claims, _ := json.Marshal(map[string]any{
+		"iss":            s.issuer,
+		"sub":            email,
+		"email":          email,
+		"email_verified": true,
+		"aud":            "sigstore",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	})
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(claims) + "."
+}
+
+func (s *Stack) newTrustedRoot(start time.Time) (*root.TrustedRoot, error) {
+	tr, err := root.NewTrustedRootFromProtobuf(&prototrustroot.TrustedRoot{MediaType: root.TrustedRootMediaType01})
+	if err != nil {
+		return nil, err
+	}
+	if err := tr.AddCertificateAuthority(root.CertificateAuthority{
+		Root:                s.pki.root,
+		Intermediates:       []*x509.Certificate{s.pki.fulcioIntermediate},
+		URI:                 s.fulcio.URL,
+		ValidityPeriodStart: start,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tr.AddTimestampingAuthority(root.CertificateAuthority{
+		Root:                s.pki.root,
+		Intermediates:       []*x509.Certificate{s.pki.tsaIntermediate},
+		Leaf:                s.pki.tsaLeaf,
+		URI:                 s.tsa.URL,
+		ValidityPeriodStart: start,
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, l := range []struct {
+		add     func(*root.TransparencyLog) error
+		baseURL string
+		key     crypto.PublicKey
+	}{
+		{tr.AddRekorLog, s.rekor.URL, s.pki.rekorKey.Public()},
+		// The CT log is run by the certificate authority
+		{tr.AddCTLog, s.fulcio.URL, s.pki.ctlogKey.Public()},
+	} {
+		id, err := logID(l.key)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.add(&root.TransparencyLog{
+			BaseURL:             l.baseURL,
+			ID:                  id,
+			HashFunc:            crypto.SHA256,
+			PublicKey:           l.key,
+			ValidityPeriodStart: start,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return tr, nil
+}
+
+type signingConfigService struct {
+	URL             string `json:"url"`
+	MajorAPIVersion uint32 `json:"majorApiVersion"`
+	ValidFor        struct {
+		Start time.Time `json:"start"`
+	} `json:"validFor"`
+	Operator string `json:"operator"`
+}
+
+type serviceConfiguration struct {
+	Selector string `json:"selector"`
+}
+
+// newSigningConfig returns the v0.2 signing config listing the stack's
+// services.
+func (s *Stack) newSigningConfig(start time.Time) ([]byte, error) {
+	service := func(url string) []signingConfigService {
+		svc := signingConfigService{URL: url, MajorAPIVersion: 1, Operator: "devstack"}
+		svc.ValidFor.Start = start.UTC()
+		return []signingConfigService{svc}
+	}
+	signingConfigJSON, err := json.MarshalIndent(struct {
+		MediaType       string                 `json:"mediaType"`
+		CAURLs          []signingConfigService `json:"caUrls"`
+		OIDCURLs        []signingConfigService `json:"oidcUrls"`
+		RekorTlogURLs   []signingConfigService `json:"rekorTlogUrls"`
+		RekorTlogConfig serviceConfiguration   `json:"rekorTlogConfig"`
+		TSAURLs         []signingConfigService `json:"tsaUrls"`
+		TSAConfig       serviceConfiguration   `json:"tsaConfig"`
+	}{
+		MediaType:       root.SigningConfigMediaType02,
+		CAURLs:          service(s.fulcio.URL),
+		OIDCURLs:        service(s.issuer),
+		RekorTlogURLs:   service(s.rekor.URL),
+		RekorTlogConfig: serviceConfiguration{Selector: root.ServiceSelectorAny.String()},
+		TSAURLs:         service(s.tsa.URL),
+		TSAConfig:       serviceConfiguration{Selector: root.ServiceSelectorAny.String()},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Check that the config is one clients accept
+	if _, err := root.NewSigningConfigFromJSON(signingConfigJSON); err != nil {
+		return nil, fmt.Errorf("invalid signing config: %w", err)
+	}
+	return signingConfigJSON, nil
+}
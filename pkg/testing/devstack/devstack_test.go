@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devstack
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestStack(t *testing.T) {
+	stack, err := New(nil)
+	require.NoError(t, err)
+	defer stack.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, stack.WriteFiles(dir))
+	trustedRoot, err := root.NewTrustedRootFromPath(filepath.Join(dir, TrustedRootFileName))
+	require.NoError(t, err)
+	signingConfig, err := root.NewSigningConfigFromPath(filepath.Join(dir, SigningConfigFileName))
+	require.NoError(t, err)
+
+	services, err := sign.SelectSigningServices(signingConfig, time.Time{})
+	require.NoError(t, err)
+	bundleOpts, err := services.NewBundleOptions(stack.IDToken("jdoe@example.com"))
+	require.NoError(t, err)
+	bundleOpts.TrustedMaterial = trustedRoot
+
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	artifact := []byte("hello, world")
+	attestation, err := sign.NewStatementBuilder("https://example.com/predicate").AddSubjectFromReader("artifact", bytes.NewReader(artifact)).DSSEData()
+	require.NoError(t, err)
+	for _, content := range []sign.Content{&sign.PlainData{Data: artifact}, attestation} {
+		pb, err := sign.Bundle(content, keypair, bundleOpts)
+		require.NoError(t, err)
+		b, err := bundle.NewProtobufBundle(pb)
+		require.NoError(t, err)
+
+		verifier, err := verify.NewSignedEntityVerifier(trustedRoot, verify.WithSignedCertificateTimestamps(1), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+		require.NoError(t, err)
+		identity, err := verify.NewShortCertificateIdentity(DefaultIssuer, "jdoe@example.com", "", "")
+		require.NoError(t, err)
+		_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+		require.NoError(t, err)
+	}
+
+	// Entries can be retrieved with inclusion proofs for the current tree
+	rekor, err := sign.NewRekorClient(stack.RekorURL())
+	require.NoError(t, err)
+	_, entry, err := rekor.GetLogEntryByIndex(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), *entry.Verification.InclusionProof.TreeSize)
+	_, _, err = rekor.GetLogEntryByIndex(context.Background(), 3)
+	assert.Error(t, err)
+}
+
+func TestStackRejectsOtherIssuers(t *testing.T) {
+	stack, err := New(&Options{Issuer: "https://issuer.example.com"})
+	require.NoError(t, err)
+	defer stack.Close()
+
+	other, err := New(nil)
+	require.NoError(t, err)
+	defer other.Close()
+
+	fulcio, err := sign.NewFulcioClient(stack.FulcioURL())
+	require.NoError(t, err)
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+
+	_, err = fulcio.GetSigningCertificate(keypair, other.IDToken("jdoe@example.com"))
+	assert.ErrorContains(t, err, "untrusted issuer")
+	cert, err := fulcio.GetSigningCertificate(keypair, stack.IDToken("jdoe@example.com"))
+	require.NoError(t, err)
+	assert.Len(t, cert.Chain, 2)
+}
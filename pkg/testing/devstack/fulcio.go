@@ -0,0 +1,223 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devstack
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+
+	"github.com/sigstore/sigstore-go/pkg/sign"
+)
+
+// Validity of the code signing certificates issued by the stack, as with
+// Fulcio
+const certificateValidity = 10 * time.Minute
+
+var (
+	// OID of the deprecated issuer extension, holding the raw issuer URL
+	oidIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// OID of the issuer extension, holding the DER-encoded issuer URL
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+type fulcioRequest struct {
+	PublicKeyRequest struct {
+		PublicKey struct {
+			Algorithm string `json:"algorithm"`
+			Content   string `json:"content"`
+		} `json:"publicKey"`
+		ProofOfPossession string `json:"proofOfPossession"`
+	} `json:"publicKeyRequest"`
+}
+
+type fulcioResponse struct {
+	SignedCertificateEmbeddedSct struct {
+		Chain struct {
+			Certificates []string `json:"certificates"`
+		} `json:"chain"`
+	} `json:"signedCertificateEmbeddedSct"`
+}
+
+// fulcio is a Fulcio-like certificate authority, serving the v2 signing
+// certificate API. It issues certificates for the email address, or
+// otherwise the URI subject, of identity tokens from its issuer, with an
+// SCT from its CT log embedded.
+//
+// Identity tokens are trusted without verifying their signatures, and the
+// proof of possession of the private key is not checked.
+type fulcio struct {
+	issuer string
+	pki    *pki
+}
+
+func (f *fulcio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v2/signingCert" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, err := sign.ParseIDToken(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if err == nil {
+		err = claims.Validate(nil)
+	}
+	if err == nil && claims.Issuer != f.issuer {
+		err = fmt.Errorf("untrusted issuer %q", claims.Issuer)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req fulcioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(req.PublicKeyRequest.PublicKey.Content))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cert, err := f.issueCertificate(claims, pub)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp fulcioResponse
+	for _, c := range []*x509.Certificate{cert, f.pki.fulcioIntermediate, f.pki.root} {
+		resp.SignedCertificateEmbeddedSct.Chain.Certificates = append(resp.SignedCertificateEmbeddedSct.Chain.Certificates,
+			string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// issueCertificate issues a code signing certificate for the public key
+// and identity, logging its precertificate to the CT log and embedding the
+// SCT.
+func (f *fulcio) issueCertificate(claims *sign.IDTokenClaims, pub crypto.PublicKey) (*x509.Certificate, error) {
+	issuerV2, err := asn1.MarshalWithParams(claims.Issuer, "utf8")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		NotBefore:   now,
+		NotAfter:    now.Add(certificateValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidIssuerV1, Value: []byte(claims.Issuer)},
+			{Id: oidIssuerV2, Value: issuerV2},
+		},
+	}
+	switch {
+	case claims.Email != "":
+		template.EmailAddresses = []string{claims.Email}
+	default:
+		uri, err := url.Parse(claims.Subject)
+		if err != nil || uri.Scheme == "" {
+			return nil, errors.New("identity token has neither an email nor a URI subject")
+		}
+		template.URIs = []*url.URL{uri}
+	}
+
+	// The SCT is over the certificate without the SCT list extension, which
+	// is then issued again with the extension appended
+	precert, err := newCertificate(template, f.pki.fulcioIntermediate, pub, f.pki.fulcioIntermediateKey)
+	if err != nil {
+		return nil, err
+	}
+	sctList, err := f.signedCertificateTimestamps(precert)
+	if err != nil {
+		return nil, err
+	}
+	template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: asn1.ObjectIdentifier(ctx509.OIDExtensionCTSCT), Value: sctList})
+	return newCertificate(template, f.pki.fulcioIntermediate, pub, f.pki.fulcioIntermediateKey)
+}
+
+// signedCertificateTimestamps has the CT log issue an SCT for the
+// precertificate, and returns the value of the SCT list extension holding
+// it.
+func (f *fulcio) signedCertificateTimestamps(precert *x509.Certificate) ([]byte, error) {
+	id, err := logID(f.pki.ctlogKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		Timestamp:  uint64(time.Now().UnixMilli()), // nolint: gosec
+	}
+	copy(sct.LogID.KeyID[:], id)
+
+	leaf := ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			EntryType: ct.PrecertLogEntryType,
+			Timestamp: sct.Timestamp,
+			PrecertEntry: &ct.PreCert{
+				IssuerKeyHash:  sha256.Sum256(f.pki.fulcioIntermediate.RawSubjectPublicKeyInfo),
+				TBSCertificate: precert.RawTBSCertificate,
+			},
+		},
+	}
+	signatureInput, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{Leaf: leaf})
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(signatureInput)
+	signature, err := f.pki.ctlogKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	sct.Signature = ct.DigitallySigned{
+		Algorithm: cttls.SignatureAndHashAlgorithm{Hash: cttls.SHA256, Signature: cttls.ECDSA},
+		Signature: signature,
+	}
+
+	serializedSCT, err := cttls.Marshal(sct)
+	if err != nil {
+		return nil, err
+	}
+	list, err := cttls.Marshal(ctx509.SignedCertificateTimestampList{SCTList: []ctx509.SerializedSCT{{Val: serializedSCT}}})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(list)
+}
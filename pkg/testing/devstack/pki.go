@@ -0,0 +1,166 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devstack
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// pki holds the keys and certificates of a stack: a root certificate
+// authority, with intermediates for code signing certificates and for
+// timestamping, and the keys of the transparency and CT logs.
+type pki struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+
+	fulcioIntermediate    *x509.Certificate
+	fulcioIntermediateKey *ecdsa.PrivateKey
+
+	tsaIntermediate *x509.Certificate
+	tsaLeaf         *x509.Certificate
+	tsaLeafKey      *ecdsa.PrivateKey
+
+	rekorKey *ecdsa.PrivateKey
+	ctlogKey *ecdsa.PrivateKey
+}
+
+func newPKI(notBefore, notAfter time.Time) (*pki, error) {
+	p := &pki{}
+	var err error
+	if p.rootKey, err = newKey(); err != nil {
+		return nil, err
+	}
+	p.root, err = newCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "devstack", Organization: []string{"devstack"}},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, p.rootKey.Public(), p.rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.fulcioIntermediateKey, err = newKey(); err != nil {
+		return nil, err
+	}
+	p.fulcioIntermediate, err = newCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "devstack-intermediate", Organization: []string{"devstack"}},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, p.root, p.fulcioIntermediateKey.Public(), p.rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	tsaIntermediateKey, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	p.tsaIntermediate, err = newCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "devstack-tsa-intermediate", Organization: []string{"devstack"}},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, p.root, tsaIntermediateKey.Public(), p.rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tsaLeafKey, err = newKey(); err != nil {
+		return nil, err
+	}
+	// Timestamping certificates must mark their extended key usage critical,
+	// which the template's ExtKeyUsage does not
+	timestampingEKU, err := asn1.Marshal([]asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 8}})
+	if err != nil {
+		return nil, err
+	}
+	p.tsaLeaf, err = newCertificate(&x509.Certificate{
+		Subject:   pkix.Name{CommonName: "devstack-tsa", Organization: []string{"devstack"}},
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{
+			Id:       asn1.ObjectIdentifier{2, 5, 29, 37},
+			Critical: true,
+			Value:    timestampingEKU,
+		}},
+	}, p.tsaIntermediate, p.tsaLeafKey.Public(), tsaIntermediateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.rekorKey, err = newKey(); err != nil {
+		return nil, err
+	}
+	if p.ctlogKey, err = newKey(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func newKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// newCertificate issues a certificate from template, which is self-signed
+// if parent is nil. A serial number is generated if the template has none.
+func newCertificate(template, parent *x509.Certificate, pub crypto.PublicKey, parentKey crypto.Signer) (*x509.Certificate, error) {
+	if template.SerialNumber == nil {
+		serial, err := cryptoutils.GenerateSerialNumber()
+		if err != nil {
+			return nil, err
+		}
+		template.SerialNumber = serial
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// logID returns the ID of a log with the public key: the SHA-256 digest of
+// the PKIX-encoded key.
+func logID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(der)
+	return digest[:], nil
+}
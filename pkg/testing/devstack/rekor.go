@@ -0,0 +1,209 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package devstack
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/swag"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/types"
+	rekorutil "github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/transparency-dev/merkle/rfc6962"
+	"github.com/transparency-dev/merkle/testonly"
+
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/util"
+
+	// To initialize rekor types
+	_ "github.com/sigstore/rekor/pkg/types/dsse/v0.0.1"
+	_ "github.com/sigstore/rekor/pkg/types/hashedrekord/v0.0.1"
+	_ "github.com/sigstore/rekor/pkg/types/intoto/v0.0.2"
+)
+
+// Origin of the transparency log's checkpoints
+const rekorOrigin = "devstack"
+
+// rekor is a Rekor-like transparency log, serving the v1 API for creating
+// entries and retrieving them by index. Entries are held in memory, and
+// returned with an inclusion promise and an inclusion proof.
+type rekor struct {
+	signer signature.Signer
+	logID  string
+
+	mu      sync.Mutex
+	tree    *testonly.Tree
+	entries []rekorEntry
+}
+
+type rekorEntry struct {
+	body           []byte
+	integratedTime int64
+}
+
+func newRekor(p *pki) (*rekor, error) {
+	signer, err := signature.LoadECDSASigner(p.rekorKey, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	id, err := logID(p.rekorKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	l := &rekor{signer: signer, logID: hex.EncodeToString(id), tree: testonly.New(rfc6962.DefaultHasher)}
+	// Bundles cannot hold entries at log index 0, which is left empty
+	l.tree.AppendData(nil)
+	l.entries = append(l.entries, rekorEntry{})
+	return l, nil
+}
+
+func (l *rekor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/log/entries" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		l.createEntry(w, r)
+	case http.MethodGet:
+		l.getEntryByIndex(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (l *rekor) createEntry(w http.ResponseWriter, r *http.Request) {
+	proposedEntry, err := models.UnmarshalProposedEntry(r.Body, runtime.JSONConsumer())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Creating the entry validates it, including its signature
+	entry, err := types.CreateVersionedEntry(proposedEntry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := types.CanonicalizeEntry(r.Context(), entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	l.mu.Lock()
+	l.tree.AppendData(body)
+	l.entries = append(l.entries, rekorEntry{body: body, integratedTime: time.Now().Unix()})
+	uuid, anon, err := l.logEntry(int64(len(l.entries) - 1))
+	l.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", uuid)
+	w.Header().Set("Location", "/api/v1/log/entries/"+uuid)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(models.LogEntry{uuid: anon})
+}
+
+func (l *rekor) getEntryByIndex(w http.ResponseWriter, r *http.Request) {
+	logIndex, err := strconv.ParseInt(r.URL.Query().Get("logIndex"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid logIndex", http.StatusBadRequest)
+		return
+	}
+
+	l.mu.Lock()
+	if logIndex <= 0 || logIndex >= int64(len(l.entries)) {
+		l.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	uuid, anon, err := l.logEntry(logIndex)
+	l.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(models.LogEntry{uuid: anon})
+}
+
+// logEntry returns the UUID and the response for the entry at the index,
+// with an inclusion proof for the current tree. l.mu must be held.
+func (l *rekor) logEntry(logIndex int64) (string, models.LogEntryAnon, error) {
+	entry := l.entries[logIndex]
+	encodedBody := base64.StdEncoding.EncodeToString(entry.body)
+
+	canonicalized, err := util.MarshalCanonicalJSON(tlog.RekorPayload{
+		Body:           encodedBody,
+		IntegratedTime: entry.integratedTime,
+		LogIndex:       logIndex,
+		LogID:          l.logID,
+	})
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+	set, err := l.signer.SignMessage(bytes.NewReader(canonicalized))
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+
+	treeSize := l.tree.Size()
+	proof, err := l.tree.InclusionProof(uint64(logIndex), treeSize) // nolint: gosec
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+	hashes := make([]string, 0, len(proof))
+	for _, hash := range proof {
+		hashes = append(hashes, hex.EncodeToString(hash))
+	}
+	rootHash := l.tree.Hash()
+	checkpoint, err := rekorutil.CreateAndSignCheckpoint(context.Background(), rekorOrigin, 0, treeSize, rootHash, l.signer)
+	if err != nil {
+		return "", models.LogEntryAnon{}, err
+	}
+
+	uuid := hex.EncodeToString(l.tree.LeafHash(uint64(logIndex))) // nolint: gosec
+	return uuid, models.LogEntryAnon{
+		Body:           encodedBody,
+		IntegratedTime: swag.Int64(entry.integratedTime),
+		LogID:          swag.String(l.logID),
+		LogIndex:       swag.Int64(logIndex),
+		Verification: &models.LogEntryAnonVerification{
+			SignedEntryTimestamp: set,
+			InclusionProof: &models.InclusionProof{
+				Checkpoint: swag.String(string(checkpoint)),
+				Hashes:     hashes,
+				LogIndex:   swag.Int64(logIndex),
+				RootHash:   swag.String(hex.EncodeToString(rootHash)),
+				TreeSize:   swag.Int64(int64(treeSize)), // nolint: gosec
+			},
+		},
+	}, nil
+}
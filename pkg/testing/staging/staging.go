@@ -0,0 +1,247 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package staging provides helpers to sign and verify with the Sigstore
+// staging instance, so that applications can check their configuration of
+// this library against real, non-production Fulcio, Rekor and timestamp
+// authority services.
+//
+// Bundles signed with the staging instance do not verify against the
+// production trusted root, and must never be used outside of testing.
+package staging
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+const (
+	FulcioURL             = "https://fulcio.sigstage.dev"
+	RekorURL              = "https://rekor.sigstage.dev"
+	TimestampAuthorityURL = "https://timestamp.sigstage.dev"
+	TUFRepositoryURL      = "https://tuf-repo-cdn.sigstage.dev"
+
+	// IDTokenEnv is the environment variable IDToken reads a token from
+	IDTokenEnv = "SIGSTORE_ID_TOKEN"
+
+	// PublicTestingIDTokenURL serves a short-lived token, refreshed every
+	// few minutes, that the Sigstore conformance project publishes for
+	// testing against the staging instance.
+	PublicTestingIDTokenURL = "https://raw.githubusercontent.com/sigstore-conformance/extremely-dangerous-public-oidc-beacon/refs/heads/current-token/oidc-token.txt"
+)
+
+// The initial root of the staging TUF repository. Newer roots are fetched
+// from the repository.
+//
+//go:embed root.json
+var tufRoot []byte
+
+// TUFRoot returns the trust anchor of the staging TUF repository.
+func TUFRoot() []byte {
+	return tufRoot
+}
+
+// TUFOptions returns options for a TUF client of the staging repository,
+// caching metadata in cachePath. An empty cachePath disables the cache.
+func TUFOptions(cachePath string) *tuf.Options {
+	opts := tuf.DefaultOptions()
+	opts.Root = TUFRoot()
+	opts.RepositoryBaseURL = TUFRepositoryURL
+	if cachePath == "" {
+		opts.DisableLocalCache = true
+	} else {
+		opts.CachePath = cachePath
+	}
+	return opts
+}
+
+// Options configures an Environment.
+type Options struct {
+	// Optional directory to cache TUF metadata in. Defaults to
+	// $HOME/.sigstore/staging.
+	CachePath string
+	// Optional timeout for network requests
+	Timeout time.Duration
+	// Optional logger for debug logs of requests
+	Logger *slog.Logger
+}
+
+// Environment is the staging instance, with the trusted root distributed
+// by its TUF repository.
+type Environment struct {
+	TrustedRoot *root.TrustedRoot
+	options     Options
+}
+
+// NewEnvironment fetches the staging trusted root.
+func NewEnvironment(opts *Options) (*Environment, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	cachePath := opts.CachePath
+	if cachePath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		cachePath = filepath.Join(home, ".sigstore", "staging")
+	}
+
+	tufOpts := TUFOptions(cachePath)
+	tufOpts.Logger = opts.Logger
+	trustedRoot, err := root.FetchTrustedRootWithOptions(tufOpts)
+	if err != nil {
+		return nil, fmt.Errorf("fetching staging trusted root: %w", err)
+	}
+	return &Environment{TrustedRoot: trustedRoot, options: *opts}, nil
+}
+
+// BundleOptions returns options for sign.Bundle that request a certificate
+// from staging Fulcio, a signed timestamp from the staging timestamp
+// authority and an entry in staging Rekor. The certificate is checked
+// against the staging trusted root before the bundle is created.
+func (e *Environment) BundleOptions(idToken string) (sign.BundleOptions, error) {
+	clientOpts := []sign.ClientOption{sign.WithLogger(e.options.Logger)}
+	if e.options.Timeout > 0 {
+		clientOpts = append(clientOpts, sign.WithTimeout(e.options.Timeout))
+	}
+
+	opts := sign.BundleOptions{IDToken: idToken, TrustedMaterial: e.TrustedRoot}
+	fulcio, err := sign.NewFulcioClient(FulcioURL, clientOpts...)
+	if err != nil {
+		return opts, err
+	}
+	opts.Fulcio = fulcio
+	tsa, err := sign.NewTimestampAuthorityClient(TimestampAuthorityURL, clientOpts...)
+	if err != nil {
+		return opts, err
+	}
+	opts.TimestampAuthorities = []*sign.TimestampAuthority{tsa}
+	rekor, err := sign.NewRekorClient(RekorURL, clientOpts...)
+	if err != nil {
+		return opts, err
+	}
+	opts.Rekors = []*sign.Rekor{rekor}
+	return opts, nil
+}
+
+// Verifier returns a verifier for bundles signed with BundleOptions, which
+// requires an SCT, a transparency log entry and a signed timestamp.
+func (e *Environment) Verifier() (*verify.SignedEntityVerifier, error) {
+	return verify.NewSignedEntityVerifier(e.TrustedRoot,
+		verify.WithSignedCertificateTimestamps(1),
+		verify.WithTransparencyLog(1),
+		verify.WithSignedTimestamps(1),
+		verify.WithLogger(e.options.Logger))
+}
+
+// SignAndVerify signs content with a fresh ephemeral key using the staging
+// services, then verifies the resulting bundle against the staging trusted
+// root with the artifact policy and the identity of the token.
+func (e *Environment) SignAndVerify(content sign.Content, artifactPolicy verify.ArtifactPolicyOption, idToken string) (*protobundle.Bundle, *verify.VerificationResult, error) {
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	bundleOpts, err := e.BundleOptions(idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	pb, err := sign.Bundle(content, keypair, bundleOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("signing: %w", err)
+	}
+
+	b, err := bundle.NewProtobufBundle(pb)
+	if err != nil {
+		return pb, nil, err
+	}
+	identity, err := CertificateIdentity(idToken)
+	if err != nil {
+		return pb, nil, err
+	}
+	verifier, err := e.Verifier()
+	if err != nil {
+		return pb, nil, err
+	}
+	result, err := verifier.Verify(b, verify.NewPolicy(artifactPolicy, verify.WithCertificateIdentity(identity)))
+	if err != nil {
+		return pb, nil, fmt.Errorf("verifying: %w", err)
+	}
+	return pb, result, nil
+}
+
+// CertificateIdentity returns the identity that Fulcio certifies for the
+// token. The issuer must match exactly. The subject alternative name must
+// match the token's email address if it has one; otherwise, as issuers
+// such as CI providers are mapped to SANs derived from other claims, any
+// SAN is accepted.
+func CertificateIdentity(idToken string) (verify.CertificateIdentity, error) {
+	claims, err := sign.ParseIDToken(idToken)
+	if err != nil {
+		return verify.CertificateIdentity{}, err
+	}
+	if claims.Email != "" {
+		return verify.NewShortCertificateIdentity(claims.ExpectedIssuer(), "", "", "^"+regexp.QuoteMeta(claims.Email)+"$")
+	}
+	return verify.NewShortCertificateIdentity(claims.ExpectedIssuer(), "", "", ".+")
+}
+
+// IDToken returns the identity token in $SIGSTORE_ID_TOKEN, or otherwise
+// the public testing token served at PublicTestingIDTokenURL.
+func IDToken(ctx context.Context) (string, error) {
+	if token := os.Getenv(IDTokenEnv); token != "" {
+		return token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, PublicTestingIDTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching public testing token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching public testing token: status %d", resp.StatusCode)
+	}
+	token := strings.TrimSpace(string(body))
+	if token == "" {
+		return "", errors.New("public testing token is empty")
+	}
+	return token, nil
+}
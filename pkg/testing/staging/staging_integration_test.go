@@ -0,0 +1,112 @@
+//go:build integration
+
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package staging_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/staging"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// These tests use the Sigstore staging instance, and only run with
+//
+//	go test -tags integration ./pkg/testing/staging/...
+//
+// They use the token in $SIGSTORE_ID_TOKEN if set, or the public testing
+// token otherwise.
+
+func newEnvironment(t *testing.T) (*staging.Environment, string) {
+	t.Helper()
+	env, err := staging.NewEnvironment(&staging.Options{
+		CachePath: t.TempDir(),
+		Timeout:   30 * time.Second,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	idToken, err := staging.IDToken(ctx)
+	require.NoError(t, err)
+	return env, idToken
+}
+
+func TestStagingMessageSignature(t *testing.T) {
+	env, idToken := newEnvironment(t)
+
+	artifact := []byte("hello from sigstore-go")
+	pb, result, err := env.SignAndVerify(&sign.PlainData{Data: artifact}, verify.WithArtifact(bytes.NewReader(artifact)), idToken)
+	require.NoError(t, err)
+	assert.NotNil(t, pb.GetMessageSignature())
+	assert.NotNil(t, result.Signature.Certificate)
+	assert.NotEmpty(t, result.VerifiedTimestamps)
+}
+
+func TestStagingDSSE(t *testing.T) {
+	env, idToken := newEnvironment(t)
+
+	artifact := []byte("hello from sigstore-go")
+	digest := sha256.Sum256(artifact)
+	statement, err := json.Marshal(&in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          verify.StatementInTotoV1,
+			PredicateType: "https://sigstore.dev/test/staging",
+			Subject:       []in_toto.Subject{{Name: "artifact", Digest: map[string]string{"sha256": hex.EncodeToString(digest[:])}}},
+		},
+		Predicate: map[string]string{"test": "staging"},
+	})
+	require.NoError(t, err)
+
+	content := &sign.DSSEData{Data: statement, PayloadType: "application/vnd.in-toto+json"}
+	pb, result, err := env.SignAndVerify(content, verify.WithArtifactDigest("sha256", digest[:]), idToken)
+	require.NoError(t, err)
+	assert.NotNil(t, pb.GetDsseEnvelope())
+	assert.NotNil(t, result.Statement)
+}
+
+func TestStagingWrongIdentity(t *testing.T) {
+	env, idToken := newEnvironment(t)
+
+	artifact := []byte("hello from sigstore-go")
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	bundleOpts, err := env.BundleOptions(idToken)
+	require.NoError(t, err)
+	pb, err := sign.Bundle(&sign.PlainData{Data: artifact}, keypair, bundleOpts)
+	require.NoError(t, err)
+
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+	verifier, err := env.Verifier()
+	require.NoError(t, err)
+	identity, err := verify.NewShortCertificateIdentity("https://accounts.example.com", "", "nobody@example.com", "")
+	require.NoError(t, err)
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.Error(t, err)
+}
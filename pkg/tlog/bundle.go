@@ -0,0 +1,178 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	hashedrekord_v001 "github.com/sigstore/rekor/pkg/types/hashedrekord/v0.0.1"
+)
+
+const (
+	bundleV01MediaType = "application/vnd.dev.sigstore.bundle+json;version=0.1"
+	bundleV03MediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+)
+
+// ErrMissingSignedContent is returned by EntryToBundle for entries that do
+// not record what was signed, such as dsse and intoto entries, which only
+// hold the hash of the envelope's payload.
+var ErrMissingSignedContent = errors.New("transparency log entry does not record the signed content")
+
+var hashedRekordAlgorithms = map[string]protocommon.HashAlgorithm{
+	models.HashedrekordV001SchemaDataHashAlgorithmSha256: protocommon.HashAlgorithm_SHA2_256,
+	models.HashedrekordV001SchemaDataHashAlgorithmSha384: protocommon.HashAlgorithm_SHA2_384,
+	models.HashedrekordV001SchemaDataHashAlgorithmSha512: protocommon.HashAlgorithm_SHA2_512,
+}
+
+// EntryToBundle reconstitutes a bundle from a transparency log entry, such
+// as one fetched from Rekor with NewEntryFromLogEntry, so that log monitors
+// can verify new entries with the same policies as other bundles. The
+// bundle holds the entry's signature, as a MessageSignature over the
+// artifact's digest, its certificate or public key, and the entry itself.
+//
+// Only hashedrekord entries record everything that was signed. The bundle
+// has version 0.3 if the entry has an inclusion proof, and 0.1 if it only
+// has an inclusion promise. Wrap it with bundle.NewProtobufBundle, and
+// verify it with verify.WithArtifactDigest and the digest of the bundle's
+// MessageSignature, or the artifact if it is available. Bundles with a
+// public key have an empty key hint, which the trusted material must
+// resolve.
+func EntryToBundle(entry *Entry) (*protobundle.Bundle, error) {
+	e, ok := entry.rekorEntry.(*hashedrekord_v001.V001Entry)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s entries are not supported", ErrMissingSignedContent, entry.kind)
+	}
+	obj := e.HashedRekordObj
+	if obj.Signature == nil || obj.Signature.PublicKey == nil || obj.Data == nil || obj.Data.Hash == nil ||
+		obj.Data.Hash.Algorithm == nil || obj.Data.Hash.Value == nil {
+		return nil, ErrNilValue
+	}
+
+	algorithm, ok := hashedRekordAlgorithms[*obj.Data.Hash.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hashedrekord hash algorithm: %s", *obj.Data.Hash.Algorithm)
+	}
+	digest, err := hex.DecodeString(*obj.Data.Hash.Value)
+	if err != nil {
+		return nil, fmt.Errorf("decoding hashedrekord digest: %w", err)
+	}
+
+	tlogEntry, err := entry.transparencyLogEntry()
+	if err != nil {
+		return nil, err
+	}
+	mediaType := bundleV03MediaType
+	if tlogEntry.InclusionProof == nil {
+		if tlogEntry.InclusionPromise == nil {
+			return nil, errors.New("transparency log entry has neither an inclusion proof nor an inclusion promise")
+		}
+		mediaType = bundleV01MediaType
+	}
+
+	verificationMaterial := &protobundle.VerificationMaterial{
+		TlogEntries: []*protorekor.TransparencyLogEntry{tlogEntry},
+	}
+	block, _ := pem.Decode(obj.Signature.PublicKey.Content)
+	switch {
+	case block == nil:
+		return nil, errors.New("hashedrekord entry has an invalid public key")
+	case block.Type != "CERTIFICATE":
+		verificationMaterial.Content = &protobundle.VerificationMaterial_PublicKey{
+			PublicKey: &protocommon.PublicKeyIdentifier{},
+		}
+	case mediaType == bundleV03MediaType:
+		verificationMaterial.Content = &protobundle.VerificationMaterial_Certificate{
+			Certificate: &protocommon.X509Certificate{RawBytes: block.Bytes},
+		}
+	default:
+		verificationMaterial.Content = &protobundle.VerificationMaterial_X509CertificateChain{
+			X509CertificateChain: &protocommon.X509CertificateChain{
+				Certificates: []*protocommon.X509Certificate{{RawBytes: block.Bytes}},
+			},
+		}
+	}
+
+	return &protobundle.Bundle{
+		MediaType:            mediaType,
+		VerificationMaterial: verificationMaterial,
+		Content: &protobundle.Bundle_MessageSignature{
+			MessageSignature: &protocommon.MessageSignature{
+				MessageDigest: &protocommon.HashOutput{
+					Algorithm: algorithm,
+					Digest:    digest,
+				},
+				Signature: obj.Signature.Content,
+			},
+		},
+	}, nil
+}
+
+// transparencyLogEntry returns the entry in its protobuf form, the inverse
+// of ParseEntry.
+func (entry *Entry) transparencyLogEntry() (*protorekor.TransparencyLogEntry, error) {
+	encodedBody, ok := entry.logEntryAnon.Body.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected body type %T", entry.logEntryAnon.Body)
+	}
+	body, err := base64.StdEncoding.DecodeString(encodedBody)
+	if err != nil {
+		return nil, fmt.Errorf("decoding body: %w", err)
+	}
+
+	tlogEntry := &protorekor.TransparencyLogEntry{
+		LogIndex:          *entry.logEntryAnon.LogIndex,
+		LogId:             &protocommon.LogId{KeyId: []byte(*entry.logEntryAnon.LogID)},
+		KindVersion:       &protorekor.KindVersion{Kind: entry.kind, Version: entry.version},
+		IntegratedTime:    *entry.logEntryAnon.IntegratedTime,
+		CanonicalizedBody: body,
+	}
+	if len(entry.signedEntryTimestamp) > 0 {
+		tlogEntry.InclusionPromise = &protorekor.InclusionPromise{SignedEntryTimestamp: entry.signedEntryTimestamp}
+	}
+
+	if entry.logEntryAnon.Verification == nil || entry.logEntryAnon.Verification.InclusionProof == nil {
+		return tlogEntry, nil
+	}
+	proof := entry.logEntryAnon.Verification.InclusionProof
+	if proof.LogIndex == nil || proof.RootHash == nil || proof.TreeSize == nil || proof.Checkpoint == nil {
+		return nil, ErrNilValue
+	}
+	rootHash, err := hex.DecodeString(*proof.RootHash)
+	if err != nil {
+		return nil, fmt.Errorf("decoding inclusion proof root hash: %w", err)
+	}
+	hashes := make([][]byte, len(proof.Hashes))
+	for i, h := range proof.Hashes {
+		if hashes[i], err = hex.DecodeString(h); err != nil {
+			return nil, fmt.Errorf("decoding inclusion proof hash: %w", err)
+		}
+	}
+	tlogEntry.InclusionProof = &protorekor.InclusionProof{
+		LogIndex:   *proof.LogIndex,
+		RootHash:   rootHash,
+		TreeSize:   *proof.TreeSize,
+		Hashes:     hashes,
+		Checkpoint: &protorekor.Checkpoint{Envelope: *proof.Checkpoint},
+	}
+	return tlogEntry, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestEntryToBundle(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("monitored@example.com", "https://issuer.example.com", artifact)
+	require.NoError(t, err)
+	entries, err := entity.TlogEntries()
+	require.NoError(t, err)
+
+	// An entry with only an inclusion promise becomes a v0.1 bundle, which
+	// verifies like the signed entity it was logged for
+	pb, err := tlog.EntryToBundle(entries[0])
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.dev.sigstore.bundle+json;version=0.1", pb.MediaType)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	require.NoError(t, err)
+	identity, err := verify.NewShortCertificateIdentity("https://issuer.example.com", "monitored@example.com", "", "")
+	require.NoError(t, err)
+	digest := sha256.Sum256(artifact)
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithCertificateIdentity(identity)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.NoError(t, err)
+
+	other, err := verify.NewShortCertificateIdentity("https://issuer.example.com", "other@example.com", "", "")
+	require.NoError(t, err)
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithCertificateIdentity(other)))
+	assert.Error(t, err)
+
+	// An entry with an inclusion proof becomes a v0.3 bundle
+	tlogEntry := pb.VerificationMaterial.TlogEntries[0]
+	withProof, err := tlog.NewEntry(tlogEntry.CanonicalizedBody, tlogEntry.IntegratedTime, tlogEntry.LogIndex, tlogEntry.LogId.KeyId, nil, &models.InclusionProof{
+		LogIndex:   swag.Int64(tlogEntry.LogIndex),
+		RootHash:   swag.String("00ff"),
+		TreeSize:   swag.Int64(tlogEntry.LogIndex + 1),
+		Hashes:     []string{"0102", "0304"},
+		Checkpoint: swag.String("checkpoint"),
+	})
+	require.NoError(t, err)
+	pb, err = tlog.EntryToBundle(withProof)
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.dev.sigstore.bundle.v0.3+json", pb.MediaType)
+	assert.NotNil(t, pb.VerificationMaterial.GetCertificate())
+	proof := pb.VerificationMaterial.TlogEntries[0].InclusionProof
+	require.NotNil(t, proof)
+	assert.Equal(t, []byte{0x00, 0xff}, proof.RootHash)
+	assert.Equal(t, [][]byte{{0x01, 0x02}, {0x03, 0x04}}, proof.Hashes)
+	assert.Equal(t, "checkpoint", proof.Checkpoint.Envelope)
+	_, err = bundle.NewProtobufBundle(pb)
+	assert.NoError(t, err)
+
+	// DSSE entries do not record the payload
+	attestation, err := virtualSigstore.Attest("monitored@example.com", "https://issuer.example.com", []byte(`{"_type": "https://in-toto.io/Statement/v0.1"}`))
+	require.NoError(t, err)
+	entries, err = attestation.TlogEntries()
+	require.NoError(t, err)
+	_, err = tlog.EntryToBundle(entries[0])
+	assert.ErrorIs(t, err, tlog.ErrMissingSignedContent)
+}
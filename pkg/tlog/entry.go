@@ -16,32 +16,34 @@ package tlog
 
 import (
 	"bytes"
-	"context"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
-	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	v1 "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/types"
 	dsse_v001 "github.com/sigstore/rekor/pkg/types/dsse/v0.0.1"
 	hashedrekord_v001 "github.com/sigstore/rekor/pkg/types/hashedrekord/v0.0.1"
 	intoto_v002 "github.com/sigstore/rekor/pkg/types/intoto/v0.0.2"
-	rekorVerify "github.com/sigstore/rekor/pkg/verify"
 	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/transparency-dev/merkle/rfc6962"
 
 	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/util"
 )
 
 type Entry struct {
@@ -50,6 +52,8 @@ type Entry struct {
 	rekorEntry           types.EntryImpl
 	logEntryAnon         models.LogEntryAnon
 	signedEntryTimestamp []byte
+	// uuid is the UUID the log returned the entry under, if known
+	uuid string
 }
 
 type RekorPayload struct {
@@ -61,6 +65,18 @@ type RekorPayload struct {
 
 var ErrNilValue = errors.New("validation error: nil value in transaction log entry")
 
+// ErrInvalidIndex is returned when a log index or tree size is negative, or
+// when a log index falls outside of the tree it claims to be included in.
+var ErrInvalidIndex = errors.New("validation error: invalid log index or tree size in transaction log entry")
+
+// InclusionProofIndex holds the position of an entry within a log's Merkle
+// tree, as recorded in its inclusion proof. Values are unsigned 64-bit so that
+// they are represented identically on 32- and 64-bit platforms.
+type InclusionProofIndex struct {
+	LogIndex uint64
+	TreeSize uint64
+}
+
 func NewEntry(body []byte, integratedTime int64, logIndex int64, logID []byte, signedEntryTimestamp []byte, inclusionProof *models.InclusionProof) (*Entry, error) {
 	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(body), runtime.JSONConsumer())
 	if err != nil {
@@ -108,6 +124,10 @@ func ParseEntry(protoEntry *v1.TransparencyLogEntry) (entry *Entry, err error) {
 		return nil, ErrNilValue
 	}
 
+	if protoEntry.LogIndex < 0 {
+		return nil, ErrInvalidIndex
+	}
+
 	signedEntryTimestamp := []byte{}
 	if protoEntry.InclusionPromise != nil && protoEntry.InclusionPromise.SignedEntryTimestamp != nil {
 		signedEntryTimestamp = protoEntry.InclusionPromise.SignedEntryTimestamp
@@ -116,6 +136,13 @@ func ParseEntry(protoEntry *v1.TransparencyLogEntry) (entry *Entry, err error) {
 	var inclusionProof *models.InclusionProof
 
 	if protoEntry.InclusionProof != nil {
+		if protoEntry.InclusionProof.Checkpoint == nil {
+			return nil, ErrNilValue
+		}
+		if _, err := checkInclusionProofIndex(protoEntry.InclusionProof.LogIndex, protoEntry.InclusionProof.TreeSize); err != nil {
+			return nil, err
+		}
+
 		var hashes []string
 
 		for _, v := range protoEntry.InclusionProof.Hashes {
@@ -169,6 +196,70 @@ func ValidateEntry(entry *Entry) error {
 	return nil
 }
 
+// VerifyDSSEEnvelope checks that the given envelope is the content recorded
+// in a dsse v0.0.1 entry. The payload hash is recomputed from the envelope and
+// the envelope's signatures are compared against the logged signatures, so
+// that a valid entry can't be paired with a different envelope. Entries of
+// other kinds are not checked.
+func VerifyDSSEEnvelope(entry *Entry, envelope *dsse.Envelope) error {
+	e, ok := entry.rekorEntry.(*dsse_v001.V001Entry)
+	if !ok {
+		return nil
+	}
+
+	if envelope == nil {
+		return errors.New("dsse entry requires an envelope")
+	}
+
+	payloadHash := e.DSSEObj.PayloadHash
+	if payloadHash == nil || payloadHash.Algorithm == nil || payloadHash.Value == nil {
+		return errors.New("dsse entry missing payload hash")
+	}
+	if *payloadHash.Algorithm != models.DSSEV001SchemaPayloadHashAlgorithmSha256 {
+		return fmt.Errorf("unsupported dsse payload hash algorithm: %s", *payloadHash.Algorithm)
+	}
+
+	payload, err := envelope.DecodeB64Payload()
+	if err != nil {
+		return fmt.Errorf("decoding envelope payload: %w", err)
+	}
+	digest := sha256.Sum256(payload)
+	loggedDigest, err := hex.DecodeString(*payloadHash.Value)
+	if err != nil {
+		return fmt.Errorf("decoding dsse payload hash: %w", err)
+	}
+	if !bytes.Equal(digest[:], loggedDigest) {
+		return errors.New("dsse payload hash does not match envelope payload")
+	}
+
+	if len(e.DSSEObj.Signatures) != len(envelope.Signatures) {
+		return fmt.Errorf("dsse entry has %d signatures, envelope has %d", len(e.DSSEObj.Signatures), len(envelope.Signatures))
+	}
+
+	loggedSigs := make(map[string]bool, len(e.DSSEObj.Signatures))
+	for _, sig := range e.DSSEObj.Signatures {
+		if sig == nil || sig.Signature == nil {
+			return errors.New("dsse entry missing signature")
+		}
+		sigBytes, err := base64.StdEncoding.DecodeString(*sig.Signature)
+		if err != nil {
+			return fmt.Errorf("decoding dsse entry signature: %w", err)
+		}
+		loggedSigs[string(sigBytes)] = true
+	}
+	for _, sig := range envelope.Signatures {
+		sigBytes, err := base64.StdEncoding.DecodeString(sig.Sig)
+		if err != nil {
+			return fmt.Errorf("decoding envelope signature: %w", err)
+		}
+		if !loggedSigs[string(sigBytes)] {
+			return errors.New("envelope signature not found in dsse entry")
+		}
+	}
+
+	return nil
+}
+
 func (entry *Entry) IntegratedTime() time.Time {
 	return time.Unix(*entry.logEntryAnon.IntegratedTime, 0)
 }
@@ -230,10 +321,56 @@ func (entry *Entry) LogIndex() int64 {
 	return *entry.logEntryAnon.LogIndex
 }
 
+// LogIndexUint64 returns the entry's log index as an unsigned 64-bit value,
+// returning an error if the recorded index is negative.
+func (entry *Entry) LogIndexUint64() (uint64, error) {
+	logIndex := entry.LogIndex()
+	if logIndex < 0 {
+		return 0, ErrInvalidIndex
+	}
+	return uint64(logIndex), nil
+}
+
+// InclusionProofIndex returns the log index and tree size recorded in the
+// entry's inclusion proof. An error is returned if the entry has no inclusion
+// proof, or if the values are negative or inconsistent with each other.
+func (entry *Entry) InclusionProofIndex() (*InclusionProofIndex, error) {
+	if !entry.HasInclusionProof() || entry.logEntryAnon.Verification.InclusionProof == nil {
+		return nil, errors.New("entry does not have an inclusion proof")
+	}
+	proof := entry.logEntryAnon.Verification.InclusionProof
+	if proof.LogIndex == nil || proof.TreeSize == nil {
+		return nil, ErrNilValue
+	}
+	return checkInclusionProofIndex(*proof.LogIndex, *proof.TreeSize)
+}
+
+func checkInclusionProofIndex(logIndex, treeSize int64) (*InclusionProofIndex, error) {
+	if logIndex < 0 || treeSize <= 0 || logIndex >= treeSize {
+		return nil, fmt.Errorf("%w: index %d, tree size %d", ErrInvalidIndex, logIndex, treeSize)
+	}
+	return &InclusionProofIndex{LogIndex: uint64(logIndex), TreeSize: uint64(treeSize)}, nil
+}
+
+// IndexToInt converts a 64-bit log index or tree size to a platform int, returning
+// an error rather than silently truncating on 32-bit platforms.
+func IndexToInt(v uint64) (int, error) {
+	if v > uint64(math.MaxInt) {
+		return 0, fmt.Errorf("%w: %d overflows int", ErrInvalidIndex, v)
+	}
+	return int(v), nil
+}
+
 func (entry *Entry) Body() any {
 	return entry.logEntryAnon.Body
 }
 
+// SignedEntryTimestamp returns the entry's inclusion promise, signed by the
+// log, or nil if the entry has none.
+func (entry *Entry) SignedEntryTimestamp() []byte {
+	return entry.signedEntryTimestamp
+}
+
 func (entry *Entry) HasInclusionPromise() bool {
 	return entry.signedEntryTimestamp != nil
 }
@@ -242,54 +379,142 @@ func (entry *Entry) HasInclusionProof() bool {
 	return entry.logEntryAnon.Verification != nil
 }
 
+// VerifyInclusion verifies the entry's inclusion proof and checkpoint with
+// VerifyInclusionProof.
 func VerifyInclusion(entry *Entry, verifier signature.Verifier) error {
-	err := rekorVerify.VerifyInclusion(context.TODO(), &entry.logEntryAnon)
+	inclusionProof, checkpoint, err := entry.InclusionProof()
 	if err != nil {
 		return err
 	}
 
-	err = rekorVerify.VerifyCheckpointSignature(&entry.logEntryAnon, verifier)
+	body, err := entry.CanonicalizedBody()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return VerifyInclusionProof(body, inclusionProof, checkpoint, verifier)
 }
 
+// Errors returned by VerifySignedEntryTimestamp. VerifySET also returns
+// ErrSETMissing and ErrSETInvalidSignature.
+var (
+	ErrSETMissing = errors.New("transparency log entry has no signed entry timestamp")
+	// ErrSETWrongKey is returned when the log key's ID is not the log ID
+	// of the entry, so the key cannot have signed it
+	ErrSETWrongKey = errors.New("log key does not match the log ID of the transparency log entry")
+	// ErrSETBodyMismatch is returned when the entry body does not match
+	// the UUID the log returned the entry under, which usually means it was
+	// modified or re-encoded after it was returned by the log
+	ErrSETBodyMismatch = errors.New("transparency log entry body does not match its UUID")
+	// ErrSETInvalidSignature is returned when the signed entry timestamp
+	// does not verify
+	ErrSETInvalidSignature = errors.New("unable to verify SET")
+)
+
 func VerifySET(entry *Entry, verifiers map[string]*root.TransparencyLog) error {
+	logID := hex.EncodeToString([]byte(*entry.logEntryAnon.LogID))
+	verifier, err := root.FindTransparencyLog(verifiers, logID, entry.IntegratedTime())
+	if err != nil {
+		return fmt.Errorf("rekor log public key not found for payload: %w", err)
+	}
+	if verifier.ValidityPeriodStart.IsZero() {
+		return errors.New("rekor validity period start time not set")
+	}
+
+	return verifySET(entry, verifier.PublicKey)
+}
+
+// VerifySignedEntryTimestamp verifies the signed entry timestamp (SET) of a
+// transparency log entry with the log's public key, for tools that store
+// Rekor responses rather than bundles. An entry can be created from a Rekor
+// response with NewEntryFromLogEntry.
+//
+// Unlike bundle verification, this does not check that the log is trusted,
+// or that the key was valid when the entry was integrated. Errors wrap
+// ErrSETMissing, ErrSETWrongKey, ErrSETBodyMismatch or
+// ErrSETInvalidSignature.
+func VerifySignedEntryTimestamp(entry *Entry, logKey crypto.PublicKey) error {
+	keyID, err := root.SHA256SPKIHexKeyHint(logKey)
+	if err != nil {
+		return fmt.Errorf("computing log key ID: %w", err)
+	}
+	if logID := hex.EncodeToString([]byte(*entry.logEntryAnon.LogID)); logID != keyID {
+		return fmt.Errorf("%w: log ID %s, key ID %s", ErrSETWrongKey, logID, keyID)
+	}
+	if entry.uuid != "" {
+		body, err := base64.StdEncoding.DecodeString(entry.logEntryAnon.Body.(string))
+		if err != nil {
+			return fmt.Errorf("decoding body: %w", err)
+		}
+		leafHash := hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(body))
+		// UUIDs of sharded logs are prefixed with the 16 hex character tree ID
+		uuid := strings.ToLower(entry.uuid)
+		if len(uuid) > len(leafHash) {
+			uuid = uuid[len(uuid)-len(leafHash):]
+		}
+		if uuid != leafHash {
+			return fmt.Errorf("%w: UUID %s, body leaf hash %s", ErrSETBodyMismatch, entry.uuid, leafHash)
+		}
+	}
+	return verifySET(entry, logKey)
+}
+
+func verifySET(entry *Entry, logKey crypto.PublicKey) error {
+	if len(entry.signedEntryTimestamp) == 0 {
+		return ErrSETMissing
+	}
+
 	rekorPayload := RekorPayload{
 		Body:           entry.logEntryAnon.Body,
 		IntegratedTime: *entry.logEntryAnon.IntegratedTime,
 		LogIndex:       *entry.logEntryAnon.LogIndex,
 		LogID:          hex.EncodeToString([]byte(*entry.logEntryAnon.LogID)),
 	}
+	canonicalized, err := util.MarshalCanonicalJSON(rekorPayload)
+	if err != nil {
+		return fmt.Errorf("canonicalizing: %w", err)
+	}
 
-	verifier, ok := verifiers[hex.EncodeToString([]byte(*entry.logEntryAnon.LogID))]
-	if !ok {
-		return errors.New("rekor log public key not found for payload")
+	hash := sha256.Sum256(canonicalized)
+	if ecdsaPublicKey, ok := logKey.(*ecdsa.PublicKey); !ok {
+		return fmt.Errorf("unsupported public key type: %T", logKey)
+	} else if !ecdsa.VerifyASN1(ecdsaPublicKey, hash[:], entry.signedEntryTimestamp) {
+		return ErrSETInvalidSignature
 	}
-	if verifier.ValidityPeriodStart.IsZero() {
-		return errors.New("rekor validity period start time not set")
+	return nil
+}
+
+// NewEntryFromLogEntry returns an entry for a log entry returned by the
+// Rekor API under the given UUID, so that its signed entry timestamp and
+// inclusion proof can be verified. The UUID may be empty if it is unknown,
+// in which case VerifySignedEntryTimestamp cannot check it against the body.
+func NewEntryFromLogEntry(uuid string, logEntry models.LogEntryAnon) (*Entry, error) {
+	if logEntry.Body == nil || logEntry.IntegratedTime == nil || logEntry.LogIndex == nil || logEntry.LogID == nil {
+		return nil, ErrNilValue
 	}
-	if (verifier.ValidityPeriodStart.After(entry.IntegratedTime())) ||
-		(!verifier.ValidityPeriodEnd.IsZero() && verifier.ValidityPeriodEnd.Before(entry.IntegratedTime())) {
-		return errors.New("rekor log public key not valid at payload integrated time")
+	encodedBody, ok := logEntry.Body.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected body type %T", logEntry.Body)
 	}
-
-	contents, err := json.Marshal(rekorPayload)
+	body, err := base64.StdEncoding.DecodeString(encodedBody)
 	if err != nil {
-		return fmt.Errorf("marshaling: %w", err)
+		return nil, fmt.Errorf("decoding body: %w", err)
 	}
-	canonicalized, err := jsoncanonicalizer.Transform(contents)
+	logID, err := hex.DecodeString(*logEntry.LogID)
 	if err != nil {
-		return fmt.Errorf("canonicalizing: %w", err)
+		return nil, fmt.Errorf("decoding log ID: %w", err)
 	}
 
-	hash := sha256.Sum256(canonicalized)
-	if ecdsaPublicKey, ok := verifier.PublicKey.(*ecdsa.PublicKey); !ok {
-		return fmt.Errorf("unsupported public key type: %T", verifier.PublicKey)
-	} else if !ecdsa.VerifyASN1(ecdsaPublicKey, hash[:], entry.signedEntryTimestamp) {
-		return errors.New("unable to verify SET")
+	var signedEntryTimestamp []byte
+	var inclusionProof *models.InclusionProof
+	if logEntry.Verification != nil {
+		signedEntryTimestamp = logEntry.Verification.SignedEntryTimestamp
+		inclusionProof = logEntry.Verification.InclusionProof
 	}
-	return nil
+	entry, err := NewEntry(body, *logEntry.IntegratedTime, *logEntry.LogIndex, logID, signedEntryTimestamp, inclusionProof)
+	if err != nil {
+		return nil, err
+	}
+	entry.uuid = uuid
+	return entry, nil
 }
@@ -0,0 +1,157 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	v1 "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+func testTlogEntry(t *testing.T) *v1.TransparencyLogEntry {
+	b := data.SigstoreJS200ProvenanceBundle(t)
+	return proto.Clone(b.VerificationMaterial.TlogEntries[0]).(*v1.TransparencyLogEntry)
+}
+
+func TestParseEntryLargeIndex(t *testing.T) {
+	protoEntry := testTlogEntry(t)
+	protoEntry.LogIndex = 1<<32 + 5
+	protoEntry.InclusionProof.LogIndex = 1<<33 + 7
+	protoEntry.InclusionProof.TreeSize = 1<<33 + 8
+
+	entry, err := tlog.ParseEntry(protoEntry)
+	assert.NoError(t, err)
+
+	logIndex, err := entry.LogIndexUint64()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1<<32+5), logIndex)
+
+	index, err := entry.InclusionProofIndex()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1<<33+7), index.LogIndex)
+	assert.Equal(t, uint64(1<<33+8), index.TreeSize)
+}
+
+func TestParseEntryInvalidIndex(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		logIndex int64
+		proofIdx int64
+		treeSize int64
+	}{
+		{"negative log index", -1, 1, 2},
+		{"negative proof index", 1, -1, 2},
+		{"zero tree size", 1, 0, 0},
+		{"index beyond tree", 1, math.MaxInt32 + 1, math.MaxInt32 + 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			protoEntry := testTlogEntry(t)
+			protoEntry.LogIndex = tc.logIndex
+			protoEntry.InclusionProof.LogIndex = tc.proofIdx
+			protoEntry.InclusionProof.TreeSize = tc.treeSize
+
+			_, err := tlog.ParseEntry(protoEntry)
+			assert.ErrorIs(t, err, tlog.ErrInvalidIndex)
+		})
+	}
+}
+
+func TestParseEntryMissingCheckpoint(t *testing.T) {
+	protoEntry := testTlogEntry(t)
+	protoEntry.InclusionProof.Checkpoint = nil
+
+	_, err := tlog.ParseEntry(protoEntry)
+	assert.ErrorIs(t, err, tlog.ErrNilValue)
+}
+
+func TestIndexToInt(t *testing.T) {
+	i, err := tlog.IndexToInt(1 << 31)
+	if math.MaxInt == math.MaxInt32 {
+		assert.ErrorIs(t, err, tlog.ErrInvalidIndex)
+	} else {
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1<<31), uint64(i))
+	}
+
+	_, err = tlog.IndexToInt(math.MaxUint64)
+	assert.ErrorIs(t, err, tlog.ErrInvalidIndex)
+}
+
+func TestVerifySignedEntryTimestamp(t *testing.T) {
+	protoEntry := testTlogEntry(t)
+	logID := hex.EncodeToString(protoEntry.LogId.KeyId)
+	rekorLog, ok := data.PublicGoodTrustedMaterialRoot(t).RekorLogs()[logID]
+	require.True(t, ok)
+
+	// A log entry as returned by the Rekor API, keyed by its UUID
+	logEntry := models.LogEntryAnon{
+		Body:           base64.StdEncoding.EncodeToString(protoEntry.CanonicalizedBody),
+		IntegratedTime: swag.Int64(protoEntry.IntegratedTime),
+		LogIndex:       swag.Int64(protoEntry.LogIndex),
+		LogID:          swag.String(logID),
+		Verification: &models.LogEntryAnonVerification{
+			SignedEntryTimestamp: protoEntry.InclusionPromise.SignedEntryTimestamp,
+		},
+	}
+	leafHash := sha256.Sum256(append([]byte{0}, protoEntry.CanonicalizedBody...))
+	uuid := hex.EncodeToString(leafHash[:])
+
+	entry, err := tlog.NewEntryFromLogEntry(uuid, logEntry)
+	require.NoError(t, err)
+	assert.NoError(t, tlog.VerifySignedEntryTimestamp(entry, rekorLog.PublicKey))
+
+	// UUIDs of sharded logs are prefixed with the tree ID
+	entry, err = tlog.NewEntryFromLogEntry("24296fb24b8ad77a"+uuid, logEntry)
+	require.NoError(t, err)
+	assert.NoError(t, tlog.VerifySignedEntryTimestamp(entry, rekorLog.PublicKey))
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	err = tlog.VerifySignedEntryTimestamp(entry, otherKey.Public())
+	assert.ErrorIs(t, err, tlog.ErrSETWrongKey)
+
+	otherHash := sha256.Sum256([]byte("other entry"))
+	entry, err = tlog.NewEntryFromLogEntry(hex.EncodeToString(otherHash[:]), logEntry)
+	require.NoError(t, err)
+	err = tlog.VerifySignedEntryTimestamp(entry, rekorLog.PublicKey)
+	assert.ErrorIs(t, err, tlog.ErrSETBodyMismatch)
+
+	logEntry.IntegratedTime = swag.Int64(protoEntry.IntegratedTime + 1)
+	entry, err = tlog.NewEntryFromLogEntry(uuid, logEntry)
+	require.NoError(t, err)
+	err = tlog.VerifySignedEntryTimestamp(entry, rekorLog.PublicKey)
+	assert.ErrorIs(t, err, tlog.ErrSETInvalidSignature)
+
+	logEntry.Verification = nil
+	entry, err = tlog.NewEntryFromLogEntry(uuid, logEntry)
+	require.NoError(t, err)
+	err = tlog.VerifySignedEntryTimestamp(entry, rekorLog.PublicKey)
+	assert.ErrorIs(t, err, tlog.ErrSETMissing)
+}
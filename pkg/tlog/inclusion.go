@@ -0,0 +1,129 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	rekorutil "github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// InclusionProof is a Merkle inclusion proof of a log entry, as returned by
+// a transparency log alongside the entry.
+type InclusionProof struct {
+	LogIndex uint64
+	TreeSize uint64
+	RootHash []byte
+	// Hashes of the sibling nodes on the path from the leaf to the root
+	Hashes [][]byte
+}
+
+// VerifyInclusionProof verifies that body, a log entry's canonicalized body,
+// is included in the log: that the inclusion proof is valid for the
+// entry's RFC 6962 leaf hash, and that checkpoint, the log's signed note,
+// is signed by the log's key and commits to the proof's tree size and root
+// hash.
+//
+// This is the check performed for bundle log entries during verification;
+// it is exposed for monitors and other tools that handle log entries
+// outside of bundles.
+func VerifyInclusionProof(body []byte, inclusionProof *InclusionProof, checkpoint string, verifier signature.Verifier) error {
+	if inclusionProof == nil {
+		return errors.New("inclusion proof not provided")
+	}
+	if err := VerifyMerkleInclusion(body, inclusionProof); err != nil {
+		return err
+	}
+
+	signedCheckpoint := &rekorutil.SignedCheckpoint{}
+	if err := signedCheckpoint.UnmarshalText([]byte(checkpoint)); err != nil {
+		return fmt.Errorf("unable to parse checkpoint: %w", err)
+	}
+	if !signedCheckpoint.Verify(verifier) {
+		return errors.New("signature on checkpoint did not verify")
+	}
+	if !bytes.Equal(signedCheckpoint.Hash, inclusionProof.RootHash) {
+		return fmt.Errorf("inclusion proof root hash %s does not match checkpoint root hash %s", hex.EncodeToString(inclusionProof.RootHash), hex.EncodeToString(signedCheckpoint.Hash))
+	}
+	if signedCheckpoint.Size != inclusionProof.TreeSize {
+		return fmt.Errorf("inclusion proof tree size %d does not match checkpoint tree size %d", inclusionProof.TreeSize, signedCheckpoint.Size)
+	}
+
+	return nil
+}
+
+// VerifyMerkleInclusion verifies only that the inclusion proof is valid for
+// body's RFC 6962 leaf hash. The proof's root hash must be checked against a
+// trusted checkpoint separately, as VerifyInclusionProof does.
+func VerifyMerkleInclusion(body []byte, inclusionProof *InclusionProof) error {
+	leafHash := rfc6962.DefaultHasher.HashLeaf(body)
+	if err := proof.VerifyInclusion(rfc6962.DefaultHasher, inclusionProof.LogIndex, inclusionProof.TreeSize, leafHash, inclusionProof.Hashes, inclusionProof.RootHash); err != nil {
+		return fmt.Errorf("invalid inclusion proof: %w", err)
+	}
+	return nil
+}
+
+// InclusionProof returns the entry's inclusion proof and the checkpoint it
+// was issued with.
+func (entry *Entry) InclusionProof() (*InclusionProof, string, error) {
+	if !entry.HasInclusionProof() || entry.logEntryAnon.Verification.InclusionProof == nil {
+		return nil, "", errors.New("inclusion proof not provided")
+	}
+	modelProof := entry.logEntryAnon.Verification.InclusionProof
+	if modelProof.LogIndex == nil || modelProof.TreeSize == nil || modelProof.RootHash == nil || modelProof.Checkpoint == nil {
+		return nil, "", ErrNilValue
+	}
+
+	index, err := checkInclusionProofIndex(*modelProof.LogIndex, *modelProof.TreeSize)
+	if err != nil {
+		return nil, "", err
+	}
+	rootHash, err := hex.DecodeString(*modelProof.RootHash)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid inclusion proof root hash: %w", err)
+	}
+	hashes := make([][]byte, 0, len(modelProof.Hashes))
+	for _, h := range modelProof.Hashes {
+		hash, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid inclusion proof hash: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return &InclusionProof{
+		LogIndex: index.LogIndex,
+		TreeSize: index.TreeSize,
+		RootHash: rootHash,
+		Hashes:   hashes,
+	}, *modelProof.Checkpoint, nil
+}
+
+// CanonicalizedBody returns the entry's body as stored in the log, from
+// which its Merkle leaf hash is computed.
+func (entry *Entry) CanonicalizedBody() ([]byte, error) {
+	body, ok := entry.logEntryAnon.Body.(string)
+	if !ok {
+		return nil, ErrNilValue
+	}
+	return base64.StdEncoding.DecodeString(body)
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+func TestVerifyInclusionProof(t *testing.T) {
+	protoEntry := testTlogEntry(t)
+	trustedRoot := data.PublicGoodTrustedMaterialRoot(t)
+	logID := hex.EncodeToString(protoEntry.LogId.KeyId)
+	verifier, err := trustedRoot.RekorLogs()[logID].Verifier()
+	assert.NoError(t, err)
+
+	inclusionProof := &tlog.InclusionProof{
+		LogIndex: uint64(protoEntry.InclusionProof.LogIndex),
+		TreeSize: uint64(protoEntry.InclusionProof.TreeSize),
+		RootHash: protoEntry.InclusionProof.RootHash,
+		Hashes:   protoEntry.InclusionProof.Hashes,
+	}
+	checkpoint := protoEntry.InclusionProof.Checkpoint.Envelope
+	body := protoEntry.CanonicalizedBody
+
+	assert.NoError(t, tlog.VerifyInclusionProof(body, inclusionProof, checkpoint, verifier))
+
+	// The proof extracted from a parsed entry is the same
+	entry, err := tlog.ParseEntry(protoEntry)
+	assert.NoError(t, err)
+	entryProof, entryCheckpoint, err := entry.InclusionProof()
+	assert.NoError(t, err)
+	assert.Equal(t, inclusionProof, entryProof)
+	assert.Equal(t, checkpoint, entryCheckpoint)
+	assert.NoError(t, tlog.VerifyInclusion(entry, verifier))
+
+	err = tlog.VerifyInclusionProof([]byte("other body"), inclusionProof, checkpoint, verifier)
+	assert.ErrorContains(t, err, "invalid inclusion proof")
+
+	wrongIndex := *inclusionProof
+	wrongIndex.LogIndex++
+	assert.Error(t, tlog.VerifyMerkleInclusion(body, &wrongIndex))
+
+	wrongSize := *inclusionProof
+	wrongSize.TreeSize++
+	err = tlog.VerifyInclusionProof(body, &wrongSize, checkpoint, verifier)
+	assert.Error(t, err)
+
+	err = tlog.VerifyInclusionProof(body, inclusionProof, "not a checkpoint", verifier)
+	assert.ErrorContains(t, err, "unable to parse checkpoint")
+}
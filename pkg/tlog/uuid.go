@@ -0,0 +1,117 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	rekorutil "github.com/sigstore/rekor/pkg/util"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// PublicGoodSearchURL is the URL of the web interface for searching the
+// public good instance of Rekor.
+const PublicGoodSearchURL = "https://search.sigstore.dev"
+
+// ErrNoTreeID is returned by Entry.TreeID when neither the entry's UUID nor
+// its inclusion proof identifies the tree that holds the entry.
+var ErrNoTreeID = errors.New("transparency log entry has no tree ID")
+
+// uuidLength and treeIDLength are the lengths of the hex-encoded leaf hash
+// and tree ID that make up an entry ID.
+const (
+	uuidLength   = 64
+	treeIDLength = 16
+)
+
+// UUID returns the hex-encoded Merkle leaf hash of the entry, which
+// identifies the entry within its tree.
+func (entry *Entry) UUID() (string, error) {
+	encodedBody, ok := entry.logEntryAnon.Body.(string)
+	if !ok {
+		return "", ErrNilValue
+	}
+	body, err := base64.StdEncoding.DecodeString(encodedBody)
+	if err != nil {
+		return "", fmt.Errorf("decoding body: %w", err)
+	}
+	return hex.EncodeToString(rfc6962.DefaultHasher.HashLeaf(body)), nil
+}
+
+// TreeID returns the ID of the log shard's tree that holds the entry. It
+// comes from the UUID the log returned the entry under, if the entry was
+// retrieved from the log with a shard-prefixed UUID, or otherwise from the
+// origin of the checkpoint of its inclusion proof, "<log> - <tree ID>".
+// Entries with only an inclusion promise return ErrNoTreeID.
+func (entry *Entry) TreeID() (int64, error) {
+	if len(entry.uuid) == treeIDLength+uuidLength {
+		treeID, err := strconv.ParseInt(entry.uuid[:treeIDLength], 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid tree ID in UUID %s: %w", entry.uuid, err)
+		}
+		return treeID, nil
+	}
+
+	verification := entry.logEntryAnon.Verification
+	if verification == nil || verification.InclusionProof == nil || verification.InclusionProof.Checkpoint == nil {
+		return 0, ErrNoTreeID
+	}
+	checkpoint := &rekorutil.SignedCheckpoint{}
+	if err := checkpoint.UnmarshalText([]byte(*verification.InclusionProof.Checkpoint)); err != nil {
+		return 0, fmt.Errorf("unable to parse checkpoint: %w", err)
+	}
+	_, treeIDString, found := strings.Cut(checkpoint.Origin, " - ")
+	if !found {
+		return 0, fmt.Errorf("%w: checkpoint origin %q", ErrNoTreeID, checkpoint.Origin)
+	}
+	treeID, err := strconv.ParseInt(treeIDString, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tree ID in checkpoint origin %q: %w", checkpoint.Origin, err)
+	}
+	return treeID, nil
+}
+
+// EntryID returns the shard-aware UUID of the entry: its tree ID as 16 hex
+// characters followed by its UUID. This is the ID Rekor returns entries
+// under, and it finds the entry even after the log has been sharded.
+func (entry *Entry) EntryID() (string, error) {
+	uuid, err := entry.UUID()
+	if err != nil {
+		return "", err
+	}
+	treeID, err := entry.TreeID()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%016x", treeID) + uuid, nil
+}
+
+// EntryURL returns the Rekor API URL of an entry, from the base URL of the
+// log, e.g. https://rekor.sigstore.dev, and an entry ID or UUID.
+func EntryURL(logBaseURL, entryID string) string {
+	return strings.TrimSuffix(logBaseURL, "/") + "/api/v1/log/entries/" + url.PathEscape(entryID)
+}
+
+// SearchURL returns the URL of a log index in a Rekor web interface, such
+// as PublicGoodSearchURL, for links meant to be opened in a browser.
+func SearchURL(searchBaseURL string, logIndex int64) string {
+	return strings.TrimSuffix(searchBaseURL, "/") + "/?logIndex=" + strconv.FormatInt(logIndex, 10)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+func TestEntryID(t *testing.T) {
+	protoEntry := testTlogEntry(t)
+	leafHash := sha256.Sum256(append([]byte{0}, protoEntry.CanonicalizedBody...))
+	uuid := hex.EncodeToString(leafHash[:])
+
+	entry, err := tlog.ParseEntry(protoEntry)
+	require.NoError(t, err)
+	got, err := entry.UUID()
+	require.NoError(t, err)
+	assert.Equal(t, uuid, got)
+
+	// The tree ID comes from the checkpoint origin,
+	// "rekor.sigstore.dev - 2605736670972794746"
+	treeID, err := entry.TreeID()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2605736670972794746), treeID)
+	entryID, err := entry.EntryID()
+	require.NoError(t, err)
+	assert.Equal(t, "24296fb24b8ad77a"+uuid, entryID)
+
+	assert.Equal(t, "https://rekor.sigstore.dev/api/v1/log/entries/"+entryID, tlog.EntryURL("https://rekor.sigstore.dev/", entryID))
+	assert.Equal(t, "https://search.sigstore.dev/?logIndex=31821305", tlog.SearchURL(tlog.PublicGoodSearchURL, entry.LogIndex()))
+
+	// Without an inclusion proof, the tree ID is unknown
+	protoEntry.InclusionProof = nil
+	entry, err = tlog.ParseEntry(protoEntry)
+	require.NoError(t, err)
+	_, err = entry.TreeID()
+	assert.ErrorIs(t, err, tlog.ErrNoTreeID)
+	_, err = entry.EntryID()
+	assert.ErrorIs(t, err, tlog.ErrNoTreeID)
+	got, err = entry.UUID()
+	require.NoError(t, err)
+	assert.Equal(t, uuid, got)
+}
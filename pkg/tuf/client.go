@@ -15,26 +15,56 @@
 package tuf
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/theupdateframework/go-tuf/v2/metadata"
 	"github.com/theupdateframework/go-tuf/v2/metadata/config"
 	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
 )
 
-// Client is a Sigstore TUF client
+// Client is a Sigstore TUF client. It is safe for concurrent use:
+// concurrent calls to Refresh, or to GetTarget for the same target, share a
+// single request to the TUF repository.
 type Client struct {
 	cfg  *config.UpdaterConfig
-	up   *updater.Updater
 	opts *Options
+
+	// mu guards up, which Refresh replaces, and refreshed, when it last
+	// did
+	mu        sync.RWMutex
+	up        *updater.Updater
+	refreshed time.Time
+
+	group   singleflight.Group
+	cacheMu sync.Mutex
+	targets map[string]cachedTarget
+	// revalidating holds the targets being revalidated in the background,
+	// guarded by cacheMu
+	revalidating map[string]bool
+}
+
+// cachedTarget is a target held in memory for Options.TargetCacheTTL.
+type cachedTarget struct {
+	data    []byte
+	fetched time.Time
 }
 
 // New returns a new client with custom options
 func New(opts *Options) (*Client, error) {
 	var c = Client{
-		opts: opts,
+		opts:         opts,
+		targets:      make(map[string]cachedTarget),
+		revalidating: make(map[string]bool),
 	}
 	dir := filepath.Join(opts.CachePath, URLToPath(opts.RepositoryBaseURL))
 	var err error
@@ -56,6 +86,8 @@ func New(opts *Options) (*Client, error) {
 
 	if opts.Fetcher != nil {
 		c.cfg.Fetcher = opts.Fetcher
+	} else if opts.UserAgent != nil {
+		c.cfg.Fetcher = &userAgentFetcher{userAgent: opts.UserAgent.String()}
 	}
 
 	// Upon client creation, we may not perform a full TUF update,
@@ -93,14 +125,18 @@ func DefaultClient() (*Client, error) {
 // may be beneficial to rely on the cache, or in air-gapped deployments it
 // it may not even be possible.
 func (c *Client) loadMetadata() error {
+	logger := util.Logger(c.opts.Logger)
+
 	// Load the metadata into memory and verify it
 	if err := c.up.Refresh(); err != nil {
 		// this is most likely due to the lack of metadata files
 		// on disk. Perform a full update and return.
+		logger.Debug("no valid cached TUF metadata", "path", c.cfg.LocalMetadataDir, "error", err)
 		return c.Refresh()
 	}
 
 	if c.opts.ForceCache {
+		logger.Debug("using cached TUF metadata", "reason", "force cache")
 		return nil
 	} else if c.opts.CacheValidity > 0 {
 		cfg, err := LoadConfig(c.configPath())
@@ -113,6 +149,7 @@ func (c *Client) loadMetadata() error {
 		cacheValidUntil := cfg.LastTimestamp.AddDate(0, 0, c.opts.CacheValidity)
 		if time.Now().Before(cacheValidUntil) {
 			// No need to update
+			logger.Debug("using cached TUF metadata", "reason", "cache valid", "validUntil", cacheValidUntil)
 			return nil
 		}
 	}
@@ -133,18 +170,35 @@ func (c *Client) configPath() string {
 // As the tuf client updater does not support multiple refreshes during
 // its life-time, this will replace the TUF client updater with a new one.
 func (c *Client) Refresh() error {
-	var err error
+	_, err, _ := c.group.Do("refresh", func() (any, error) {
+		return nil, c.refresh()
+	})
+	return err
+}
 
-	c.up, err = updater.New(c.cfg)
+func (c *Client) refresh() error {
+	logger := util.Logger(c.opts.Logger)
+	logger.Debug("refreshing TUF metadata", "url", c.opts.RepositoryBaseURL)
+
+	up, err := updater.New(c.cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create tuf updater: %w", err)
 	}
-	err = c.up.Refresh()
+	err = up.Refresh()
 	if err != nil {
+		logger.Debug("TUF refresh failed", "url", c.opts.RepositoryBaseURL, "error", err)
 		return fmt.Errorf("tuf refresh failed: %w", err)
 	}
+	c.mu.Lock()
+	c.up = up
+	c.refreshed = time.Now()
+	c.mu.Unlock()
 
-	// Update config with last update
+	// Update config with last update, unless the client must not write
+	// to disk
+	if c.cfg.DisableLocalCache {
+		return nil
+	}
 	cfg, err := LoadConfig(c.configPath())
 	if err != nil {
 		// Likely config file did not exit, create it
@@ -157,29 +211,228 @@ func (c *Client) Refresh() error {
 	return nil
 }
 
-// GetTarget returns a target file from the TUF repository
+// GetTarget returns a target file from the TUF repository.
+//
+// If Options.TargetCacheTTL is set, the target is kept in memory for that
+// long. After it expires, the next call refreshes the TUF metadata and
+// fetches the target again, unless it is within Options.TargetCacheStaleTTL
+// of expiring, in which case the cached target is returned while it is
+// refreshed in the background. Cached targets are not returned once the TUF
+// metadata has expired; the metadata is refreshed first, as for a target
+// that isn't cached.
 func (c *Client) GetTarget(target string) ([]byte, error) {
+	ttl := c.opts.TargetCacheTTL
+	if ttl <= 0 {
+		tb, err, _ := c.group.Do("target:"+target, func() (any, error) {
+			return c.getTarget(target)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return bytes.Clone(tb.([]byte)), nil
+	}
+
+	c.cacheMu.Lock()
+	cached, ok := c.targets[target]
+	c.cacheMu.Unlock()
+	if ok && !c.metadataExpired() {
+		age := time.Since(cached.fetched)
+		if age < ttl {
+			return bytes.Clone(cached.data), nil
+		}
+		if age < ttl+c.opts.TargetCacheStaleTTL {
+			c.revalidateTargetInBackground(target)
+			return bytes.Clone(cached.data), nil
+		}
+	}
+
+	tb, err := c.revalidateTarget(target, ok)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(tb), nil
+}
+
+// Prefetch fetches targets concurrently and returns them by name, for
+// clients that need several targets at startup, such as the trusted root
+// and the signing config. Targets are fetched with the TUF metadata from
+// the last refresh, as GetTarget does; if Options.TargetCacheTTL is set,
+// targets that are cached and still valid are not fetched again, and the
+// metadata is refreshed at most once for those that have expired.
+//
+// If ctx is done before all targets are fetched, Prefetch returns its
+// error, and downloads in progress complete in the background.
+func (c *Client) Prefetch(ctx context.Context, targets ...string) (map[string][]byte, error) {
+	fetch := c.GetTarget
+	if c.opts.TargetCacheTTL > 0 {
+		cached, expired := c.cachedTargets(targets)
+		if expired {
+			// Refresh once for all targets, rather than once per target
+			if err := c.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		fetch = func(target string) ([]byte, error) {
+			tb, ok := cached[target]
+			if !ok {
+				var err error
+				if tb, err = c.revalidateTarget(target, false); err != nil {
+					return nil, err
+				}
+			}
+			return bytes.Clone(tb), nil
+		}
+	}
+
+	results := make([][]byte, len(targets))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, target := range targets {
+		i, target := i, target
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			tb, err := fetch(target)
+			if err != nil {
+				return err
+			}
+			results[i] = tb
+			return nil
+		})
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait()
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fetched := make(map[string][]byte, len(targets))
+	for i, target := range targets {
+		fetched[target] = results[i]
+	}
+	return fetched, nil
+}
+
+// cachedTargets returns the targets that are cached and within
+// Options.TargetCacheTTL, and whether any other target is cached but has
+// expired, so that the TUF metadata must be refreshed to fetch it.
+func (c *Client) cachedTargets(targets []string) (map[string][]byte, bool) {
+	metadataExpired := c.metadataExpired()
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	cached := make(map[string][]byte)
+	expired := false
+	for _, target := range targets {
+		entry, ok := c.targets[target]
+		if !ok {
+			continue
+		}
+		if time.Since(entry.fetched) < c.opts.TargetCacheTTL && !metadataExpired {
+			cached[target] = entry.data
+		} else {
+			expired = true
+		}
+	}
+	return cached, expired
+}
+
+// revalidateTargetInBackground refreshes the TUF metadata and fetches a
+// target again in a new goroutine, unless that is already in progress.
+func (c *Client) revalidateTargetInBackground(target string) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.revalidating[target] {
+		return
+	}
+	c.revalidating[target] = true
+	go func() {
+		if _, err := c.revalidateTarget(target, true); err != nil {
+			util.Logger(c.opts.Logger).Debug("failed to revalidate TUF target", "target", target, "error", err)
+		}
+		c.cacheMu.Lock()
+		delete(c.revalidating, target)
+		c.cacheMu.Unlock()
+	}()
+}
+
+// metadataExpired returns true if any of the top-level TUF metadata the
+// client trusts has expired, as reported by CacheStatus().Expires(), after
+// which cached targets must not be used until the metadata is refreshed.
+func (c *Client) metadataExpired() bool {
+	c.mu.RLock()
+	trusted := c.up.GetTrustedMetadataSet()
+	c.mu.RUnlock()
+
+	targets := trusted.Targets[metadata.TARGETS]
+	if trusted.Root == nil || trusted.Timestamp == nil || trusted.Snapshot == nil || targets == nil {
+		return true
+	}
+	now := time.Now()
+	return trusted.Root.Signed.IsExpired(now) || trusted.Timestamp.Signed.IsExpired(now) ||
+		trusted.Snapshot.Signed.IsExpired(now) || targets.Signed.IsExpired(now)
+}
+
+// revalidateTarget fetches a target and caches it, first refreshing the TUF
+// metadata if refresh is set. Concurrent calls for a target are shared.
+func (c *Client) revalidateTarget(target string, refresh bool) ([]byte, error) {
+	tb, err, _ := c.group.Do("revalidate:"+target, func() (any, error) {
+		if refresh {
+			if err := c.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		tb, err := c.getTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		c.cacheMu.Lock()
+		c.targets[target] = cachedTarget{data: tb, fetched: time.Now()}
+		c.cacheMu.Unlock()
+		return tb, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tb.([]byte), nil
+}
+
+func (c *Client) getTarget(target string) ([]byte, error) {
+	c.mu.RLock()
+	up := c.up
+	c.mu.RUnlock()
+
 	// Set filepath to the empty string. When we get targets,
 	// we rely in the target info struct instead.
 	const filePath = ""
-	ti, err := c.up.GetTargetInfo(target)
+	ti, err := up.GetTargetInfo(target)
 	if err != nil {
 		return nil, fmt.Errorf("getting info for target \"%s\": %w", target, err)
 	}
 
-	path, tb, err := c.up.FindCachedTarget(ti, filePath)
+	path, tb, err := up.FindCachedTarget(ti, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("getting target cache: %w", err)
 	}
+	logger := util.Logger(c.opts.Logger)
 	if path != "" {
 		// Cached version found
+		logger.Debug("using cached TUF target", "target", target, "path", path)
 		return tb, nil
 	}
 
+	logger.Debug("downloading TUF target", "target", target)
+
 	// Download of target is needed
 	// Ignore targetsBaseURL, set to empty string
 	const targetsBaseURL = ""
-	_, tb, err = c.up.DownloadTarget(ti, filePath, targetsBaseURL)
+	_, tb, err = up.DownloadTarget(ti, filePath, targetsBaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download target file %s - %w", target, err)
 	}
@@ -15,12 +15,16 @@
 package tuf
 
 import (
+	"context"
 	"crypto"
 	"crypto/sha256"
 	"net/url"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -79,6 +83,204 @@ func TestRefresh(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, target)
 	assert.Equal(t, target, []byte("foo version 2"))
+
+	// With the local cache disabled, nothing is written to disk
+	assert.NoFileExists(t, "testing.local.json")
+}
+
+// countingFetcher counts downloads of timestamp.json, which are made once
+// per metadata refresh, and can hold them until released.
+type countingFetcher struct {
+	*testRepo
+	refreshes atomic.Int32
+	hold      chan struct{}
+}
+
+func (f *countingFetcher) DownloadFile(urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	if strings.HasSuffix(urlPath, "/timestamp.json") {
+		f.refreshes.Add(1)
+		if f.hold != nil {
+			<-f.hold
+		}
+	}
+	return f.testRepo.DownloadFile(urlPath, maxLength, timeout)
+}
+
+func newCachingTestClient(t *testing.T, ttl, staleTTL time.Duration) (*Client, *testRepo, *countingFetcher) {
+	r := newTestRepo(t)
+	r.AddTarget("foo", []byte("foo version 1"))
+	rootJSON, err := r.roles.Root().ToBytes(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &countingFetcher{testRepo: r}
+	opt := DefaultOptions().
+		WithRepositoryBaseURL("https://testing.local").
+		WithRoot(rootJSON).
+		WithCachePath(t.TempDir()).
+		WithFetcher(f).
+		WithDisableLocalCache().
+		WithTargetCacheTTL(ttl).
+		WithTargetCacheStaleTTL(staleTTL)
+	c, err := New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, r, f
+}
+
+func TestGetTargetCacheTTL(t *testing.T) {
+	c, r, f := newCachingTestClient(t, 100*time.Millisecond, 0)
+
+	target, err := c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+	refreshes := f.refreshes.Load()
+
+	// Within the TTL, the cached target is returned without a refresh
+	r.AddTarget("foo", []byte("foo version 2"))
+	target, err = c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+	assert.Equal(t, refreshes, f.refreshes.Load())
+
+	// Once it expires, the metadata is refreshed before returning
+	time.Sleep(150 * time.Millisecond)
+	target, err = c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 2"), target)
+	assert.Equal(t, refreshes+1, f.refreshes.Load())
+}
+
+func TestGetTargetStaleWhileRevalidate(t *testing.T) {
+	c, r, _ := newCachingTestClient(t, 50*time.Millisecond, time.Hour)
+
+	target, err := c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+
+	r.AddTarget("foo", []byte("foo version 2"))
+	time.Sleep(100 * time.Millisecond)
+
+	// The expired target is returned while it is refreshed
+	target, err = c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+
+	assert.Eventually(t, func() bool {
+		target, err := c.GetTarget("foo")
+		return err == nil && string(target) == "foo version 2"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestGetTargetRevalidatesOnce(t *testing.T) {
+	c, _, f := newCachingTestClient(t, 50*time.Millisecond, time.Hour)
+
+	_, err := c.GetTarget("foo")
+	assert.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	// Concurrent callers of a stale target share one background
+	// revalidation, held until released
+	f.hold = make(chan struct{})
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		target, err := c.GetTarget("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("foo version 1"), target)
+	}
+	assert.Less(t, runtime.NumGoroutine()-goroutines, 10)
+	c.cacheMu.Lock()
+	assert.True(t, c.revalidating["foo"])
+	c.cacheMu.Unlock()
+
+	close(f.hold)
+	assert.Eventually(t, func() bool {
+		c.cacheMu.Lock()
+		defer c.cacheMu.Unlock()
+		return !c.revalidating["foo"]
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestGetTargetExpiredMetadata(t *testing.T) {
+	c, r, _ := newCachingTestClient(t, time.Hour, time.Hour)
+	r.SetTimestamp(time.Now().Add(time.Second))
+	assert.NoError(t, c.Refresh())
+
+	target, err := c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+
+	// Once the timestamp expires, the cached target is no longer served,
+	// and refreshing fails while the repository's timestamp is expired
+	time.Sleep(1500 * time.Millisecond)
+	_, err = c.GetTarget("foo")
+	assert.Error(t, err)
+	_, err = c.Prefetch(context.Background(), "foo")
+	assert.Error(t, err)
+
+	r.SetTimestamp(time.Now().AddDate(0, 0, 1))
+	target, err = c.GetTarget("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	c, _, f := newCachingTestClient(t, 0, 0)
+	refreshes := f.refreshes.Load()
+	f.hold = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Refresh())
+		}()
+	}
+	// Give the goroutines time to join the in-flight refresh
+	time.Sleep(100 * time.Millisecond)
+	close(f.hold)
+	wg.Wait()
+
+	assert.Equal(t, refreshes+1, f.refreshes.Load())
+}
+
+func TestPrefetch(t *testing.T) {
+	c, r, f := newCachingTestClient(t, 100*time.Millisecond, 0)
+	r.AddTarget("bar", []byte("bar version 1"))
+	r.AddTarget("baz", []byte("baz version 1"))
+	assert.NoError(t, c.Refresh())
+
+	targets, err := c.Prefetch(context.Background(), "foo", "bar", "baz")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("foo version 1"),
+		"bar": []byte("bar version 1"),
+		"baz": []byte("baz version 1"),
+	}, targets)
+
+	// Prefetched targets are cached
+	refreshes := f.refreshes.Load()
+	target, err := c.GetTarget("bar")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bar version 1"), target)
+
+	// Expired targets share a single refresh
+	r.AddTarget("foo", []byte("foo version 2"))
+	time.Sleep(150 * time.Millisecond)
+	targets, err = c.Prefetch(context.Background(), "foo", "bar", "baz")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("foo version 2"), targets["foo"])
+	assert.Equal(t, refreshes+1, f.refreshes.Load())
+
+	_, err = c.Prefetch(context.Background(), "foo", "missing")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Prefetch(ctx, "foo")
+	assert.ErrorIs(t, err, context.Canceled)
 }
 
 func TestInvalidRoot(t *testing.T) {
@@ -254,14 +456,17 @@ type testRepo struct {
 	roles repo
 	dir   string
 	t     *testing.T
+	// oldRoots are the signed root versions replaced by RotateRoot
+	oldRoots map[int64][]byte
 }
 
 func newTestRepo(t *testing.T) *testRepo {
 	var err error
 	r := &testRepo{
-		keys:  make(map[string]ed25519.PrivateKey),
-		roles: repository.New(),
-		t:     t,
+		keys:     make(map[string]ed25519.PrivateKey),
+		roles:    repository.New(),
+		t:        t,
+		oldRoots: make(map[int64][]byte),
 	}
 	tomorrow := time.Now().AddDate(0, 0, 1).UTC()
 	targets := metadata.Targets(tomorrow)
@@ -362,9 +567,11 @@ func (r *testRepo) DownloadFile(urlPath string, _ int64, _ time.Duration) ([]byt
 	}
 	switch role {
 	case metadata.ROOT:
-		// TODO: handle all versions of signed root
 		meta := r.roles.Root()
 		if meta.Signed.Version != int64(version) {
+			if old, ok := r.oldRoots[int64(version)]; ok {
+				return old, nil
+			}
 			return []byte{}, &metadata.ErrDownloadHTTP{StatusCode: 404}
 		}
 		return meta.ToBytes(false)
@@ -446,3 +653,45 @@ func (r *testRepo) SetTimestamp(date time.Time) {
 		r.t.Fatal(err)
 	}
 }
+
+// RotateRoot replaces the root key with a new one, in a new version of the
+// root signed by both the old and new keys.
+func (r *testRepo) RotateRoot() {
+	root := r.roles.Root()
+	old, err := root.ToBytes(false)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.oldRoots[root.Signed.Version] = old
+
+	oldKey, err := metadata.KeyFromPublicKey(r.keys[metadata.ROOT].Public())
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	newKey, err := metadata.KeyFromPublicKey(private.Public())
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if err = root.Signed.AddKey(newKey, metadata.ROOT); err != nil {
+		r.t.Fatal(err)
+	}
+	if err = root.Signed.RevokeKey(oldKey.ID(), metadata.ROOT); err != nil {
+		r.t.Fatal(err)
+	}
+	root.Signed.Version++
+	root.ClearSignatures()
+	for _, key := range []ed25519.PrivateKey{r.keys[metadata.ROOT], private} {
+		signer, err := signature.LoadSigner(key, crypto.Hash(0))
+		if err != nil {
+			r.t.Fatal(err)
+		}
+		if _, err = root.Sign(signer); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	r.keys[metadata.ROOT] = private
+}
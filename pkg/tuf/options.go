@@ -16,11 +16,15 @@ package tuf
 
 import (
 	"embed"
+	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+
+	"github.com/sigstore/sigstore-go/pkg/util"
 )
 
 //go:embed repository
@@ -59,6 +63,19 @@ type Options struct {
 	DisableConsistentSnapshot bool
 	// Fetcher is the metadata fetcher
 	Fetcher fetcher.Fetcher
+	// UserAgent configures the User-Agent header sent to the TUF
+	// repository. It is ignored if Fetcher is set.
+	UserAgent *util.UserAgent
+	// Logger receives debug logs of metadata refreshes and cache usage
+	Logger *slog.Logger
+	// TargetCacheTTL is how long targets are kept in memory by GetTarget
+	// before the metadata is refreshed and they are fetched again. Zero
+	// disables the in-memory cache.
+	TargetCacheTTL time.Duration
+	// TargetCacheStaleTTL is how long after TargetCacheTTL a cached target
+	// is still returned by GetTarget while it is refreshed in the
+	// background.
+	TargetCacheStaleTTL time.Duration
 }
 
 // WithCacheValidity sets the cache validity period in days
@@ -109,6 +126,32 @@ func (o *Options) WithFetcher(f fetcher.Fetcher) *Options {
 	return o
 }
 
+// WithUserAgent sets the User-Agent header sent to the TUF repository
+func (o *Options) WithUserAgent(ua *util.UserAgent) *Options {
+	o.UserAgent = ua
+	return o
+}
+
+// WithLogger sets the logger for debug logs of metadata refreshes and cache
+// usage
+func (o *Options) WithLogger(logger *slog.Logger) *Options {
+	o.Logger = logger
+	return o
+}
+
+// WithTargetCacheTTL sets how long targets are kept in memory
+func (o *Options) WithTargetCacheTTL(ttl time.Duration) *Options {
+	o.TargetCacheTTL = ttl
+	return o
+}
+
+// WithTargetCacheStaleTTL sets how long expired targets are returned while
+// they are refreshed in the background
+func (o *Options) WithTargetCacheStaleTTL(ttl time.Duration) *Options {
+	o.TargetCacheStaleTTL = ttl
+	return o
+}
+
 // DefaultOptions returns an options struct for the public good instance
 func DefaultOptions() *Options {
 	var opts Options
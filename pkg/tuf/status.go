@@ -0,0 +1,151 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// MetadataStatus is the version and expiry of a role's trusted metadata.
+type MetadataStatus struct {
+	Version int64     `json:"version"`
+	Expires time.Time `json:"expires"`
+}
+
+// CachedTargetStatus is a target held in memory by GetTarget.
+type CachedTargetStatus struct {
+	Name    string    `json:"name"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// CacheStatus describes the TUF metadata a Client trusts and the targets it
+// holds, for health checks of the trusted root's freshness.
+type CacheStatus struct {
+	RepositoryBaseURL string `json:"repositoryBaseUrl"`
+	// LastRefresh is when the metadata was last refreshed from the
+	// repository, by this client or, for metadata loaded from the local
+	// cache, by the client that wrote it. It is zero if unknown.
+	LastRefresh time.Time      `json:"lastRefresh,omitempty"`
+	Root        MetadataStatus `json:"root"`
+	Timestamp   MetadataStatus `json:"timestamp"`
+	Snapshot    MetadataStatus `json:"snapshot"`
+	Targets     MetadataStatus `json:"targets"`
+	// CachedTargets lists the targets held in memory when
+	// Options.TargetCacheTTL is set, sorted by name
+	CachedTargets []CachedTargetStatus `json:"cachedTargets,omitempty"`
+}
+
+// Age returns how long ago the metadata was last refreshed, or zero if
+// LastRefresh is unknown.
+func (s *CacheStatus) Age() time.Duration {
+	if s.LastRefresh.IsZero() {
+		return 0
+	}
+	return time.Since(s.LastRefresh)
+}
+
+// Expires returns the earliest expiry of the top-level metadata. Targets,
+// including the trusted root, can't be fetched after it until the client
+// is refreshed, as the TUF specification requires.
+func (s *CacheStatus) Expires() time.Time {
+	expires := s.Root.Expires
+	for _, role := range []MetadataStatus{s.Timestamp, s.Snapshot, s.Targets} {
+		if role.Expires.Before(expires) {
+			expires = role.Expires
+		}
+	}
+	return expires
+}
+
+// CacheStatus returns the versions and expiries of the metadata the client
+// trusts, when it was last refreshed, and the targets it holds in memory.
+func (c *Client) CacheStatus() (*CacheStatus, error) {
+	c.mu.RLock()
+	trusted := c.up.GetTrustedMetadataSet()
+	refreshed := c.refreshed
+	c.mu.RUnlock()
+
+	if trusted.Root == nil || trusted.Timestamp == nil || trusted.Snapshot == nil || trusted.Targets[metadata.TARGETS] == nil {
+		return nil, errors.New("TUF metadata has not been loaded")
+	}
+	if refreshed.IsZero() && !c.cfg.DisableLocalCache {
+		if cfg, err := LoadConfig(c.configPath()); err == nil {
+			refreshed = cfg.LastTimestamp
+		}
+	}
+
+	targets := trusted.Targets[metadata.TARGETS]
+	status := &CacheStatus{
+		RepositoryBaseURL: c.opts.RepositoryBaseURL,
+		LastRefresh:       refreshed,
+		Root:              MetadataStatus{Version: trusted.Root.Signed.Version, Expires: trusted.Root.Signed.Expires},
+		Timestamp:         MetadataStatus{Version: trusted.Timestamp.Signed.Version, Expires: trusted.Timestamp.Signed.Expires},
+		Snapshot:          MetadataStatus{Version: trusted.Snapshot.Signed.Version, Expires: trusted.Snapshot.Signed.Expires},
+		Targets:           MetadataStatus{Version: targets.Signed.Version, Expires: targets.Signed.Expires},
+	}
+
+	c.cacheMu.Lock()
+	for name, cached := range c.targets {
+		status.CachedTargets = append(status.CachedTargets, CachedTargetStatus{Name: name, Fetched: cached.fetched})
+	}
+	c.cacheMu.Unlock()
+	sort.Slice(status.CachedTargets, func(i, j int) bool {
+		return status.CachedTargets[i].Name < status.CachedTargets[j].Name
+	})
+	return status, nil
+}
+
+// InvalidateTarget removes a target from the client's in-memory and
+// on-disk caches, so that the next GetTarget refreshes the metadata, if
+// Options.TargetCacheTTL is set, and downloads the target again.
+func (c *Client) InvalidateTarget(target string) error {
+	c.cacheMu.Lock()
+	delete(c.targets, target)
+	c.cacheMu.Unlock()
+
+	if c.cfg.DisableLocalCache {
+		return nil
+	}
+	path := filepath.Join(c.cfg.LocalTargetsDir, url.QueryEscape(target))
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cached target %s: %w", target, err)
+	}
+	return nil
+}
+
+// RefreshTarget invalidates a target, refreshes the TUF metadata and
+// downloads the target again, returning it. Operators can use it to pick up
+// a new trusted root as soon as it is published, e.g. after a key
+// compromise is announced, rather than waiting for cached copies to expire.
+func (c *Client) RefreshTarget(target string) ([]byte, error) {
+	if err := c.InvalidateTarget(target); err != nil {
+		return nil, err
+	}
+	if err := c.Refresh(); err != nil {
+		return nil, err
+	}
+	if c.opts.TargetCacheTTL > 0 {
+		return c.GetTarget(target)
+	}
+	return c.getTarget(target)
+}
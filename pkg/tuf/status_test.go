@@ -0,0 +1,106 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheStatus(t *testing.T) {
+	c, r, _ := newCachingTestClient(t, time.Hour, 0)
+	_, err := c.GetTarget("foo")
+	require.NoError(t, err)
+
+	status, err := c.CacheStatus()
+	require.NoError(t, err)
+	assert.Equal(t, "https://testing.local", status.RepositoryBaseURL)
+	assert.WithinDuration(t, time.Now(), status.LastRefresh, time.Minute)
+	assert.Less(t, status.Age(), time.Minute)
+	assert.Equal(t, r.roles.Root().Signed.Version, status.Root.Version)
+	assert.Equal(t, r.roles.Targets("targets").Signed.Version, status.Targets.Version)
+	assert.Equal(t, r.roles.Timestamp().Signed.Expires, status.Timestamp.Expires)
+	for _, role := range []MetadataStatus{status.Root, status.Timestamp, status.Snapshot, status.Targets} {
+		assert.False(t, role.Expires.Before(status.Expires()))
+	}
+	require.Len(t, status.CachedTargets, 1)
+	assert.Equal(t, "foo", status.CachedTargets[0].Name)
+
+	r.AddTarget("foo", []byte("foo version 2"))
+	require.NoError(t, c.Refresh())
+	refreshed, err := c.CacheStatus()
+	require.NoError(t, err)
+	assert.Greater(t, refreshed.Targets.Version, status.Targets.Version)
+	assert.Greater(t, refreshed.Timestamp.Version, status.Timestamp.Version)
+}
+
+func TestRefreshTarget(t *testing.T) {
+	c, r, _ := newCachingTestClient(t, time.Hour, 0)
+	target, err := c.GetTarget("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+
+	// the cached target is returned until it is refreshed
+	r.AddTarget("foo", []byte("foo version 2"))
+	target, err = c.GetTarget("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo version 1"), target)
+
+	target, err = c.RefreshTarget("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo version 2"), target)
+	target, err = c.GetTarget("foo")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo version 2"), target)
+
+	require.NoError(t, c.InvalidateTarget("foo"))
+	status, err := c.CacheStatus()
+	require.NoError(t, err)
+	assert.Empty(t, status.CachedTargets)
+}
+
+func TestInvalidateTargetOnDisk(t *testing.T) {
+	r := newTestRepo(t)
+	r.AddTarget("foo", []byte("foo version 1"))
+	rootJSON, err := r.roles.Root().ToBytes(false)
+	require.NoError(t, err)
+	cachePath := t.TempDir()
+	c, err := New(DefaultOptions().
+		WithRepositoryBaseURL("https://testing.local").
+		WithRoot(rootJSON).
+		WithCachePath(cachePath).
+		WithFetcher(r))
+	require.NoError(t, err)
+
+	_, err = c.GetTarget("foo")
+	require.NoError(t, err)
+	targetPath := filepath.Join(cachePath, URLToPath("https://testing.local"), "targets", "foo")
+	assert.FileExists(t, targetPath)
+
+	status, err := c.CacheStatus()
+	require.NoError(t, err)
+	assert.False(t, status.LastRefresh.IsZero())
+
+	require.NoError(t, c.InvalidateTarget("foo"))
+	_, err = os.Stat(targetPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	// invalidating a target that isn't cached is not an error
+	require.NoError(t, c.InvalidateTarget("foo"))
+}
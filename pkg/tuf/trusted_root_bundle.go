@@ -0,0 +1,293 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/config"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// TrustedRootBundleMediaType is the media type of a TrustedRootBundle
+// encoded as JSON.
+const TrustedRootBundleMediaType = "application/vnd.dev.sigstore.trustedrootbundle.v0.1+json"
+
+// TrustedRootTarget is the name of the TUF target of the Sigstore trusted
+// root.
+const TrustedRootTarget = "trusted_root.json"
+
+// bundleRepositoryURL is the repository URL given to the updater that
+// verifies a bundle. Nothing is downloaded from it.
+const bundleRepositoryURL = "https://trusted-root-bundle.invalid"
+
+// TrustedRootBundle is a TUF repository's metadata and some of its target
+// files, such as the trusted root, exported so that they can be verified
+// offline against an initial root, without contacting the repository.
+//
+// Metadata is kept exactly as it was downloaded, as the timestamp and
+// snapshot may sign the hashes of the metadata they refer to.
+type TrustedRootBundle struct {
+	MediaType string `json:"mediaType"`
+	// Roots are the versions of root.json that followed the exporting
+	// client's initial root, in order
+	Roots [][]byte `json:"roots"`
+	// Timestamp and Snapshot are timestamp.json and snapshot.json
+	Timestamp []byte `json:"timestamp"`
+	Snapshot  []byte `json:"snapshot"`
+	// Targets are the metadata of the top-level targets role and of the
+	// delegated roles that were used to find the target files, by role
+	// name
+	Targets map[string][]byte `json:"targets"`
+	// TargetFiles are the exported target files, by target name
+	TargetFiles map[string][]byte `json:"targetFiles"`
+}
+
+// ExportTrustedRootBundle exports the repository's current metadata and the
+// given targets, usually TrustedRootTarget, as a TrustedRootBundle.
+//
+// The metadata is downloaded from the repository, starting from the
+// client's initial root, Options.Root, even if the client has cached
+// metadata. Verifiers of the bundle must have the same initial root or a
+// later version of it that is in the bundle.
+func (c *Client) ExportTrustedRootBundle(targets ...string) (*TrustedRootBundle, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("no targets to export")
+	}
+	b := &TrustedRootBundle{
+		MediaType:   TrustedRootBundleMediaType,
+		Roots:       [][]byte{},
+		Targets:     make(map[string][]byte),
+		TargetFiles: make(map[string][]byte),
+	}
+
+	cfg := *c.cfg
+	cfg.DisableLocalCache = true
+	cfg.UnsafeLocalMode = false
+	cfg.Fetcher = &recordingFetcher{fetcher: c.cfg.Fetcher, targetsURL: targetsURLPrefix(cfg.RemoteTargetsURL), bundle: b}
+	up, err := updater.New(&cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tuf updater: %w", err)
+	}
+	if err = up.Refresh(); err != nil {
+		return nil, fmt.Errorf("tuf refresh failed: %w", err)
+	}
+	for _, target := range targets {
+		ti, err := up.GetTargetInfo(target)
+		if err != nil {
+			return nil, fmt.Errorf("getting info for target \"%s\": %w", target, err)
+		}
+		_, data, err := up.DownloadTarget(ti, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to download target file %s - %w", target, err)
+		}
+		b.TargetFiles[target] = data
+	}
+	return b, nil
+}
+
+// ParseTrustedRootBundle parses a TrustedRootBundle encoded as JSON. The
+// bundle is not verified.
+func ParseTrustedRootBundle(data []byte) (*TrustedRootBundle, error) {
+	var b TrustedRootBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted root bundle: %w", err)
+	}
+	if b.MediaType != TrustedRootBundleMediaType {
+		return nil, fmt.Errorf("unsupported trusted root bundle media type %q", b.MediaType)
+	}
+	return &b, nil
+}
+
+// Verify verifies the bundle's metadata following the TUF client workflow,
+// starting from initialRoot instead of contacting the repository, and
+// returns its target files once they are verified against the metadata.
+//
+// As when updating from the repository, the metadata must not have
+// expired, so bundles must be exported again before their timestamp
+// metadata expires.
+func (b *TrustedRootBundle) Verify(initialRoot []byte) (map[string][]byte, error) {
+	if len(b.TargetFiles) == 0 {
+		return nil, errors.New("trusted root bundle has no target files")
+	}
+	cfg, err := config.New(bundleRepositoryURL, initialRoot)
+	if err != nil {
+		return nil, err
+	}
+	cfg.DisableLocalCache = true
+	// Target files are served by name
+	cfg.PrefixTargetsWithHash = false
+	roots, err := rootVersions(b.Roots)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Fetcher = &bundleFetcher{bundle: b, roots: roots, targetsURL: targetsURLPrefix(cfg.RemoteTargetsURL)}
+
+	up, err := updater.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tuf updater: %w", err)
+	}
+	if err = up.Refresh(); err != nil {
+		return nil, fmt.Errorf("failed to verify trusted root bundle metadata: %w", err)
+	}
+	targets := make(map[string][]byte, len(b.TargetFiles))
+	for target := range b.TargetFiles {
+		ti, err := up.GetTargetInfo(target)
+		if err != nil {
+			return nil, fmt.Errorf("getting info for target \"%s\": %w", target, err)
+		}
+		_, data, err := up.DownloadTarget(ti, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify target file %s: %w", target, err)
+		}
+		targets[target] = data
+	}
+	return targets, nil
+}
+
+// GetTarget verifies the bundle against initialRoot, as Verify does, and
+// returns one of its target files.
+func (b *TrustedRootBundle) GetTarget(initialRoot []byte, target string) ([]byte, error) {
+	if _, ok := b.TargetFiles[target]; !ok {
+		return nil, fmt.Errorf("trusted root bundle has no target %s", target)
+	}
+	targets, err := b.Verify(initialRoot)
+	if err != nil {
+		return nil, err
+	}
+	return targets[target], nil
+}
+
+func rootVersions(roots [][]byte) (map[int64][]byte, error) {
+	versions := make(map[int64][]byte, len(roots))
+	for _, root := range roots {
+		var versioned struct {
+			Signed struct {
+				Version int64 `json:"version"`
+			} `json:"signed"`
+		}
+		if err := json.Unmarshal(root, &versioned); err != nil {
+			return nil, fmt.Errorf("failed to parse root in trusted root bundle: %w", err)
+		}
+		versions[versioned.Signed.Version] = root
+	}
+	return versions, nil
+}
+
+// targetsURLPrefix returns the prefix of target file URLs, which must not
+// match e.g. targets.json next to the targets directory.
+func targetsURLPrefix(targetsURL string) string {
+	return strings.TrimSuffix(targetsURL, "/") + "/"
+}
+
+// metadataFileRegexp matches the file names of metadata, with a version
+// prefix when the repository uses consistent snapshots.
+var metadataFileRegexp = regexp.MustCompile(`^(?:(\d+)\.)?(.+)\.json$`)
+
+// parseMetadataURL returns the role and version, if any, of a metadata
+// URL.
+func parseMetadataURL(urlPath string) (string, int64, bool) {
+	matches := metadataFileRegexp.FindStringSubmatch(path.Base(urlPath))
+	if matches == nil {
+		return "", 0, false
+	}
+	role, err := url.QueryUnescape(matches[2])
+	if err != nil {
+		return "", 0, false
+	}
+	var version int64
+	if matches[1] != "" {
+		if version, err = strconv.ParseInt(matches[1], 10, 64); err != nil {
+			return "", 0, false
+		}
+	}
+	return role, version, true
+}
+
+// recordingFetcher records the metadata downloaded by an updater in a
+// bundle.
+type recordingFetcher struct {
+	fetcher    fetcher.Fetcher
+	targetsURL string
+	bundle     *TrustedRootBundle
+}
+
+func (f *recordingFetcher) DownloadFile(urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	data, err := f.fetcher.DownloadFile(urlPath, maxLength, timeout)
+	if err != nil || strings.HasPrefix(urlPath, f.targetsURL) {
+		return data, err
+	}
+	role, _, ok := parseMetadataURL(urlPath)
+	if !ok {
+		return data, nil
+	}
+	switch role {
+	case metadata.ROOT:
+		f.bundle.Roots = append(f.bundle.Roots, data)
+	case metadata.TIMESTAMP:
+		f.bundle.Timestamp = data
+	case metadata.SNAPSHOT:
+		f.bundle.Snapshot = data
+	default:
+		f.bundle.Targets[role] = data
+	}
+	return data, nil
+}
+
+// bundleFetcher serves a bundle's metadata and target files to an updater
+// in place of the repository.
+type bundleFetcher struct {
+	bundle     *TrustedRootBundle
+	roots      map[int64][]byte
+	targetsURL string
+}
+
+func (f *bundleFetcher) DownloadFile(urlPath string, maxLength int64, _ time.Duration) ([]byte, error) {
+	var data []byte
+	if target, ok := strings.CutPrefix(urlPath, f.targetsURL); ok {
+		data = f.bundle.TargetFiles[target]
+	} else if role, version, ok := parseMetadataURL(urlPath); ok {
+		switch role {
+		case metadata.ROOT:
+			// A missing version tells the updater that the previous
+			// version is the latest
+			data = f.roots[version]
+		case metadata.TIMESTAMP:
+			data = f.bundle.Timestamp
+		case metadata.SNAPSHOT:
+			data = f.bundle.Snapshot
+		default:
+			data = f.bundle.Targets[role]
+		}
+	}
+	if data == nil {
+		return nil, &metadata.ErrDownloadHTTP{StatusCode: http.StatusNotFound, URL: urlPath}
+	}
+	if int64(len(data)) > maxLength {
+		return nil, &metadata.ErrDownloadLengthMismatch{Msg: fmt.Sprintf("download failed for %s, length %d is larger than expected %d", urlPath, len(data), maxLength)}
+	}
+	return data, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuf
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedRootBundle(t *testing.T) {
+	r := newTestRepo(t)
+	r.AddTarget(TrustedRootTarget, []byte(`{"mediaType":"trusted root"}`))
+	r.AddTarget("other.json", []byte("other"))
+	initialRoot, err := r.roles.Root().ToBytes(false)
+	require.NoError(t, err)
+	r.RotateRoot()
+	currentRoot, err := r.roles.Root().ToBytes(false)
+	require.NoError(t, err)
+
+	opt := DefaultOptions().
+		WithRepositoryBaseURL("https://testing.local").
+		WithRoot(initialRoot).
+		WithFetcher(r).
+		WithDisableLocalCache()
+	c, err := New(opt)
+	require.NoError(t, err)
+
+	_, err = c.ExportTrustedRootBundle()
+	assert.Error(t, err)
+	_, err = c.ExportTrustedRootBundle("missing.json")
+	assert.Error(t, err)
+
+	exported, err := c.ExportTrustedRootBundle(TrustedRootTarget)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{currentRoot}, exported.Roots)
+	assert.Contains(t, exported.Targets, "targets")
+	assert.NotContains(t, exported.TargetFiles, "other.json")
+
+	bundleJSON, err := json.Marshal(exported)
+	require.NoError(t, err)
+	b, err := ParseTrustedRootBundle(bundleJSON)
+	require.NoError(t, err)
+
+	// Verified from the initial root through the rotated root, or from the
+	// rotated root itself
+	for _, root := range [][]byte{initialRoot, currentRoot} {
+		targets, err := b.Verify(root)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{TrustedRootTarget: []byte(`{"mediaType":"trusted root"}`)}, targets)
+	}
+	target, err := b.GetTarget(initialRoot, TrustedRootTarget)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"mediaType":"trusted root"}`), target)
+	_, err = b.GetTarget(initialRoot, "other.json")
+	assert.Error(t, err)
+
+	// Roots of another repository do not verify the metadata
+	otherRoot, err := newTestRepo(t).roles.Root().ToBytes(false)
+	require.NoError(t, err)
+	_, err = b.Verify(otherRoot)
+	assert.Error(t, err)
+
+	tamperedTarget := *b
+	tamperedTarget.TargetFiles = map[string][]byte{TrustedRootTarget: []byte(`{"mediaType":"tampered"}`)}
+	_, err = tamperedTarget.Verify(initialRoot)
+	assert.Error(t, err)
+
+	// Target files must be signed by the targets metadata
+	extraTarget := *b
+	extraTarget.TargetFiles = map[string][]byte{TrustedRootTarget: b.TargetFiles[TrustedRootTarget], "extra.json": []byte("extra")}
+	_, err = extraTarget.Verify(initialRoot)
+	assert.Error(t, err)
+
+	missingSnapshot := *b
+	missingSnapshot.Snapshot = nil
+	_, err = missingSnapshot.Verify(initialRoot)
+	assert.Error(t, err)
+
+	_, err = ParseTrustedRootBundle([]byte(`{"mediaType":"application/json"}`))
+	assert.Error(t, err)
+}
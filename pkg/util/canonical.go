@@ -0,0 +1,85 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+)
+
+// CanonicalizeJSON returns the RFC 8785 (JCS) canonical form of a JSON
+// document: object members sorted by their UTF-16 code units, numbers
+// serialized as ECMAScript does, and no insignificant whitespace. Documents
+// with duplicate object keys are rejected. This is the canonicalization
+// used by Rekor for entry bodies and SignedEntryTimestamp payloads.
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	canonicalized, err := jsoncanonicalizer.Transform(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize JSON: %w", err)
+	}
+	return canonicalized, nil
+}
+
+// MarshalCanonicalJSON returns the RFC 8785 canonical JSON encoding of v.
+func MarshalCanonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return CanonicalizeJSON(data)
+}
+
+// CanonicalJSONEncoder writes the RFC 8785 canonical JSON encoding of
+// values to an output stream, one document per line, like json.Encoder.
+// Each document is canonicalized in memory, since canonical ordering
+// requires the whole document.
+type CanonicalJSONEncoder struct {
+	w io.Writer
+}
+
+func NewCanonicalJSONEncoder(w io.Writer) *CanonicalJSONEncoder {
+	return &CanonicalJSONEncoder{w: w}
+}
+
+// Encode writes the canonical JSON encoding of v, followed by a newline.
+func (e *CanonicalJSONEncoder) Encode(v any) error {
+	canonicalized, err := MarshalCanonicalJSON(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(canonicalized, '\n'))
+	return err
+}
+
+// CanonicalizeJSONStream reads a stream of JSON documents from r, such as
+// JSON Lines, and writes the canonical form of each to w, one per line.
+func CanonicalizeJSONStream(r io.Reader, w io.Writer) error {
+	decoder := json.NewDecoder(r)
+	encoder := NewCanonicalJSONEncoder(w)
+	for {
+		var document json.RawMessage
+		if err := decoder.Decode(&document); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read JSON document: %w", err)
+		}
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	// Example from RFC 8785 section 3.2.2
+	input := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	canonicalized, err := CanonicalizeJSON([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(canonicalized))
+
+	// Members are sorted by UTF-16 code units
+	canonicalized, err = CanonicalizeJSON([]byte(`{"\u20ac":1,"\ud83d\ude00":2,"\r":3,"1":4,"\u00f6":5}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"\\r\":3,\"1\":4,\"ö\":5,\"€\":1,\"😀\":2}", string(canonicalized))
+
+	_, err = CanonicalizeJSON([]byte(`{"a":1,"a":2}`))
+	assert.ErrorContains(t, err, "Duplicate key")
+	_, err = CanonicalizeJSON([]byte(`{"a":`))
+	assert.Error(t, err)
+}
+
+func TestMarshalCanonicalJSON(t *testing.T) {
+	canonicalized, err := MarshalCanonicalJSON(struct {
+		B string  `json:"b"`
+		A float64 `json:"a"`
+	}{B: "<&>", A: 1.0})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1,"b":"<&>"}`, string(canonicalized))
+}
+
+func TestCanonicalizeJSONStream(t *testing.T) {
+	var out bytes.Buffer
+	err := CanonicalizeJSONStream(strings.NewReader("{\"b\": 2, \"a\": 1}\n{\"c\": [1.0, 2.50]}\n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"a\":1,\"b\":2}\n{\"c\":[1,2.5]}\n", out.String())
+
+	err = CanonicalizeJSONStream(strings.NewReader(`{"a":1} {"b":`), &out)
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// ErrNonCanonicalECDSASignature is returned for ECDSA signatures that are
+// not the DER encoding of an ASN.1 SEQUENCE of two integers in range, e.g.
+// because they have non-minimal lengths or integers, or trailing data.
+var ErrNonCanonicalECDSASignature = errors.New("ECDSA signature is not strictly DER-encoded")
+
+// ErrHighSECDSASignature is returned for ECDSA signatures whose s value is
+// greater than half the order of the curve. For every valid signature
+// (r, s), (r, n-s) is also valid; accepting only the low-s form makes
+// signatures non-malleable.
+var ErrHighSECDSASignature = errors.New("ECDSA signature has a high s value")
+
+// ParseECDSASignature parses an ECDSA signature, rejecting any encoding
+// other than strict DER with ErrNonCanonicalECDSASignature.
+func ParseECDSASignature(sig []byte, curve elliptic.Curve) (r, s *big.Int, err error) {
+	r, s = new(big.Int), new(big.Int)
+	input := cryptobyte.String(sig)
+	var inner cryptobyte.String
+	if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(r) || !inner.ReadASN1Integer(s) || !inner.Empty() {
+		return nil, nil, ErrNonCanonicalECDSASignature
+	}
+	n := curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("%w: integer out of range", ErrNonCanonicalECDSASignature)
+	}
+	return r, s, nil
+}
+
+// CheckECDSASignature checks that an ECDSA signature is strictly
+// DER-encoded and, if requireLowS is set, that its s value is low. It does
+// not verify the signature.
+func CheckECDSASignature(sig []byte, curve elliptic.Curve, requireLowS bool) error {
+	_, s, err := ParseECDSASignature(sig, curve)
+	if err != nil {
+		return err
+	}
+	if requireLowS && isHighS(s, curve) {
+		return ErrHighSECDSASignature
+	}
+	return nil
+}
+
+// NormalizeECDSASignature returns the strict DER encoding of an ECDSA
+// signature with a low s value, which verifies wherever the original
+// signature does and is also accepted by verifiers that require low-s
+// signatures.
+func NormalizeECDSASignature(sig []byte, curve elliptic.Curve) ([]byte, error) {
+	r, s := new(big.Int), new(big.Int)
+	input := cryptobyte.String(sig)
+	var inner cryptobyte.String
+	if !input.ReadASN1(&inner, cryptobyte_asn1.SEQUENCE) || !input.Empty() ||
+		!inner.ReadASN1Integer(r) || !inner.ReadASN1Integer(s) || !inner.Empty() {
+		return nil, ErrNonCanonicalECDSASignature
+	}
+	if isHighS(s, curve) {
+		s.Sub(curve.Params().N, s)
+	}
+	return marshalECDSASignature(r, s), nil
+}
+
+func isHighS(s *big.Int, curve elliptic.Curve) bool {
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	return s.Cmp(halfOrder) > 0
+}
+
+func marshalECDSASignature(r, s *big.Int) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.BytesOrPanic()
+}
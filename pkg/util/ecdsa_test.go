@@ -0,0 +1,66 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECDSASignatureChecks(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("hello"))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	r, s, err := ParseECDSASignature(sig, elliptic.P256())
+	require.NoError(t, err)
+	n := elliptic.P256().Params().N
+	lowS, highS := s, new(big.Int).Sub(n, s)
+	if isHighS(s, elliptic.P256()) {
+		lowS, highS = highS, lowS
+	}
+	lowSig := marshalECDSASignature(r, lowS)
+	highSig := marshalECDSASignature(r, highS)
+	require.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], highSig))
+
+	assert.NoError(t, CheckECDSASignature(lowSig, elliptic.P256(), true))
+	assert.NoError(t, CheckECDSASignature(highSig, elliptic.P256(), false))
+	assert.ErrorIs(t, CheckECDSASignature(highSig, elliptic.P256(), true), ErrHighSECDSASignature)
+
+	normalized, err := NormalizeECDSASignature(highSig, elliptic.P256())
+	require.NoError(t, err)
+	assert.Equal(t, lowSig, normalized)
+	assert.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], normalized))
+	normalized, err = NormalizeECDSASignature(lowSig, elliptic.P256())
+	require.NoError(t, err)
+	assert.Equal(t, lowSig, normalized)
+
+	longForm := append([]byte{lowSig[0], 0x81}, lowSig[1:]...)
+	assert.ErrorIs(t, CheckECDSASignature(longForm, elliptic.P256(), false), ErrNonCanonicalECDSASignature)
+	assert.ErrorIs(t, CheckECDSASignature(append(lowSig, 0), elliptic.P256(), false), ErrNonCanonicalECDSASignature)
+	outOfRange := marshalECDSASignature(r, new(big.Int).Add(n, lowS))
+	assert.ErrorIs(t, CheckECDSASignature(outOfRange, elliptic.P256(), false), ErrNonCanonicalECDSASignature)
+	_, err = NormalizeECDSASignature([]byte("not a signature"), elliptic.P256())
+	assert.ErrorIs(t, err, ErrNonCanonicalECDSASignature)
+}
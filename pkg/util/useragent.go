@@ -0,0 +1,98 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+)
+
+const libraryProductName = "sigstore-go"
+
+// Product is a product token in a User-Agent header, as described in
+// RFC 9110 §10.1.5.
+type Product struct {
+	Name    string
+	Version string
+}
+
+// UserAgent describes the User-Agent header sent with requests to Sigstore
+// services such as Fulcio, Rekor, timestamp authorities, and TUF
+// repositories.
+type UserAgent struct {
+	// LibraryVersion is the version of sigstore-go reported in the
+	// sigstore-go product token
+	LibraryVersion string
+	// Products are appended after the sigstore-go product token, allowing
+	// embedding applications to identify themselves
+	Products []Product
+	// DisableVersions omits all product versions from the header, including
+	// those of Products
+	DisableVersions bool
+}
+
+// ConstructUserAgent returns the default User-Agent header value, with an
+// optional sigstore-go version.
+func ConstructUserAgent(version string) string {
+	return (&UserAgent{LibraryVersion: version}).String()
+}
+
+// WithProduct returns a copy of the UserAgent with an additional product
+// token appended.
+func (u *UserAgent) WithProduct(name, version string) *UserAgent {
+	ua := UserAgent{}
+	if u != nil {
+		ua = *u
+	}
+	ua.Products = append(append([]Product{}, ua.Products...), Product{Name: name, Version: version})
+	return &ua
+}
+
+// String renders the User-Agent header value. A nil UserAgent renders the
+// default header value.
+func (u *UserAgent) String() string {
+	if u == nil {
+		return libraryProductName
+	}
+
+	products := append([]Product{{Name: libraryProductName, Version: u.LibraryVersion}}, u.Products...)
+	tokens := make([]string, 0, len(products))
+	for _, product := range products {
+		if product.Name == "" {
+			continue
+		}
+		token := product.Name
+		if product.Version != "" && !u.DisableVersions {
+			token += "/" + product.Version
+		}
+		tokens = append(tokens, token)
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// ResolveUserAgent returns the User-Agent header value to use for a client configured
+// with both a legacy library version and an optional UserAgent, where
+// userAgent takes precedence.
+func ResolveUserAgent(userAgent *UserAgent, libraryVersion string) string {
+	if userAgent == nil {
+		return ConstructUserAgent(libraryVersion)
+	}
+	if userAgent.LibraryVersion == "" {
+		ua := *userAgent
+		ua.LibraryVersion = libraryVersion
+		return ua.String()
+	}
+	return userAgent.String()
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAgent(t *testing.T) {
+	assert.Equal(t, "sigstore-go", ConstructUserAgent(""))
+	assert.Equal(t, "sigstore-go/1.0.0", ConstructUserAgent("1.0.0"))
+
+	var nilUA *UserAgent
+	assert.Equal(t, "sigstore-go", nilUA.String())
+	assert.Equal(t, "sigstore-go my-app/2.1", nilUA.WithProduct("my-app", "2.1").String())
+
+	ua := &UserAgent{LibraryVersion: "1.0.0"}
+	withApp := ua.WithProduct("my-app", "2.1").WithProduct("plugin", "")
+	assert.Equal(t, "sigstore-go/1.0.0", ua.String())
+	assert.Equal(t, "sigstore-go/1.0.0 my-app/2.1 plugin", withApp.String())
+
+	withApp.DisableVersions = true
+	assert.Equal(t, "sigstore-go my-app plugin", withApp.String())
+
+	assert.Equal(t, "sigstore-go/1.0.0", ResolveUserAgent(nil, "1.0.0"))
+	assert.Equal(t, "sigstore-go/1.0.0 my-app/2.1", ResolveUserAgent(nilUA.WithProduct("my-app", "2.1"), "1.0.0"))
+	assert.Equal(t, "sigstore-go/3.0.0", ResolveUserAgent(&UserAgent{LibraryVersion: "3.0.0"}, "1.0.0"))
+	assert.Equal(t, "sigstore-go", ResolveUserAgent(&UserAgent{DisableVersions: true}, "1.0.0"))
+}
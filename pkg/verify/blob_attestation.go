@@ -0,0 +1,172 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// cosignPredicateTypes maps the predicate type names accepted by cosign's
+// --type flag to predicate type URIs.
+var cosignPredicateTypes = map[string]string{
+	"custom":           "https://cosign.sigstore.dev/attestation/v1",
+	"slsaprovenance":   "https://slsa.dev/provenance/v0.2",
+	"slsaprovenance02": "https://slsa.dev/provenance/v0.2",
+	"slsaprovenance1":  "https://slsa.dev/provenance/v1",
+	"spdx":             "https://spdx.dev/Document",
+	"spdxjson":         "https://spdx.dev/Document",
+	"cyclonedx":        "https://cyclonedx.org/bom",
+	"link":             "https://in-toto.io/Link/v1",
+	"vuln":             "https://cosign.sigstore.dev/attestation/vuln/v1",
+	"openvex":          "https://openvex.dev/ns",
+}
+
+// BlobAttestationOptions configures VerifyBlobAttestation, mirroring the
+// flags of `cosign verify-blob-attestation`.
+type BlobAttestationOptions struct {
+	// The attested blob, given as exactly one of a path, a reader, or a
+	// digest with its algorithm (e.g. "sha256")
+	BlobPath        string
+	Blob            io.Reader
+	Digest          []byte
+	DigestAlgorithm string
+
+	// The identity expected in the signing certificate: the subject
+	// alternative name, or a regular expression matching it, and the OIDC
+	// issuer
+	CertificateIdentity       string
+	CertificateIdentityRegexp string
+	CertificateOIDCIssuer     string
+
+	// Expected predicate type, as a URI or as one of the names accepted by
+	// cosign (e.g. "slsaprovenance1", "spdxjson", "cyclonedx")
+	PredicateType string
+}
+
+// BlobAttestationResult is the result of a successful blob attestation
+// verification.
+type BlobAttestationResult struct {
+	*VerificationResult
+	// PredicateType is the verified predicate type URI
+	PredicateType string
+	// Predicate is the attestation's JSON-encoded predicate
+	Predicate json.RawMessage
+}
+
+// DecodePredicate unmarshals the predicate into v.
+func (r *BlobAttestationResult) DecodePredicate(v any) error {
+	return json.Unmarshal(r.Predicate, v)
+}
+
+// VerifyBlobAttestation verifies a bundle containing a DSSE attestation over
+// a blob in one call: the attestation must be signed by the expected
+// certificate identity, have the blob among its subjects, and have the
+// expected predicate type.
+//
+// If no verifier options are given, the defaults of cosign are used: one
+// SCT, one transparency log entry and one observer timestamp are required.
+func VerifyBlobAttestation(entity SignedEntity, trustedMaterial root.TrustedMaterial, opts BlobAttestationOptions, verifierOpts ...VerifierOption) (*BlobAttestationResult, error) {
+	if opts.PredicateType == "" {
+		return nil, errors.New("a predicate type is required")
+	}
+	predicateType := opts.PredicateType
+	if uri, ok := cosignPredicateTypes[predicateType]; ok {
+		predicateType = uri
+	}
+
+	artifactPolicy, closeBlob, err := blobArtifactPolicy(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBlob()
+
+	certID, err := NewShortCertificateIdentity(opts.CertificateOIDCIssuer, opts.CertificateIdentity, "", opts.CertificateIdentityRegexp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate identity: %w", err)
+	}
+
+	sigContent, err := entity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	if sigContent.EnvelopeContent() == nil {
+		return nil, errors.New("bundle does not contain an attestation")
+	}
+
+	if len(verifierOpts) == 0 {
+		verifierOpts = []VerifierOption{WithSignedCertificateTimestamps(1), WithTransparencyLog(1), WithObserverTimestamps(1)}
+	}
+	verifier, err := NewSignedEntityVerifier(trustedMaterial, verifierOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := verifier.Verify(entity, NewPolicy(artifactPolicy, WithCertificateIdentity(certID)))
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Statement == nil {
+		return nil, errors.New("attestation does not contain an in-toto statement")
+	}
+	if result.Statement.PredicateType != predicateType {
+		return nil, fmt.Errorf("attestation has predicate type %q, expected %q", result.Statement.PredicateType, predicateType)
+	}
+
+	predicate, err := json.Marshal(result.Statement.Predicate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode predicate: %w", err)
+	}
+
+	return &BlobAttestationResult{
+		VerificationResult: result,
+		PredicateType:      predicateType,
+		Predicate:          predicate,
+	}, nil
+}
+
+func blobArtifactPolicy(opts BlobAttestationOptions) (ArtifactPolicyOption, func(), error) {
+	provided := 0
+	for _, set := range []bool{opts.BlobPath != "", opts.Blob != nil, len(opts.Digest) > 0} {
+		if set {
+			provided++
+		}
+	}
+	if provided != 1 {
+		return nil, nil, errors.New("exactly one of a blob path, blob reader or blob digest is required")
+	}
+
+	switch {
+	case opts.BlobPath != "":
+		f, err := os.Open(opts.BlobPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return WithArtifact(f), func() { f.Close() }, nil
+	case opts.Blob != nil:
+		return WithArtifact(opts.Blob), func() {}, nil
+	default:
+		if opts.DigestAlgorithm == "" {
+			return nil, nil, errors.New("a digest algorithm is required with a blob digest")
+		}
+		return WithArtifactDigest(opts.DigestAlgorithm, opts.Digest), func() {}, nil
+	}
+}
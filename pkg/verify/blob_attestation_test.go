@@ -0,0 +1,109 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestVerifyBlobAttestation(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	blob := []byte("release artifact")
+	digest := sha256.Sum256(blob)
+	statement := `{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://slsa.dev/provenance/v1","subject":[{"name":"release.tar.gz","digest":{"sha256":"` + hex.EncodeToString(digest[:]) + `"}}],"predicate":{"buildDefinition":{"buildType":"https://example.com/make"}}}`
+
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", []byte(statement))
+	assert.NoError(t, err)
+
+	blobPath := filepath.Join(t.TempDir(), "release.tar.gz")
+	assert.NoError(t, os.WriteFile(blobPath, blob, 0o600))
+
+	verifierOpts := []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1)}
+	opts := verify.BlobAttestationOptions{
+		BlobPath:              blobPath,
+		CertificateIdentity:   "foo@example.com",
+		CertificateOIDCIssuer: "issuer",
+		PredicateType:         "slsaprovenance1",
+	}
+
+	result, err := verify.VerifyBlobAttestation(entity, virtualSigstore, opts, verifierOpts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://slsa.dev/provenance/v1", result.PredicateType)
+	var predicate struct {
+		BuildDefinition struct {
+			BuildType string `json:"buildType"`
+		} `json:"buildDefinition"`
+	}
+	assert.NoError(t, result.DecodePredicate(&predicate))
+	assert.Equal(t, "https://example.com/make", predicate.BuildDefinition.BuildType)
+
+	// By digest, with the predicate type URI
+	_, err = verify.VerifyBlobAttestation(entity, virtualSigstore, verify.BlobAttestationOptions{
+		Digest:                    digest[:],
+		DigestAlgorithm:           "sha256",
+		CertificateIdentityRegexp: "@example.com$",
+		CertificateOIDCIssuer:     "issuer",
+		PredicateType:             "https://slsa.dev/provenance/v1",
+	}, verifierOpts...)
+	assert.NoError(t, err)
+
+	// Wrong predicate type
+	wrongType := opts
+	wrongType.PredicateType = "spdxjson"
+	_, err = verify.VerifyBlobAttestation(entity, virtualSigstore, wrongType, verifierOpts...)
+	assert.ErrorContains(t, err, `expected "https://spdx.dev/Document"`)
+
+	// Wrong blob
+	wrongBlob := opts
+	wrongBlob.BlobPath = ""
+	wrongBlob.Blob = strings.NewReader("other artifact")
+	_, err = verify.VerifyBlobAttestation(entity, virtualSigstore, wrongBlob, verifierOpts...)
+	assert.Error(t, err)
+
+	// Wrong identity
+	wrongIdentity := opts
+	wrongIdentity.CertificateIdentity = "bar@example.com"
+	_, err = verify.VerifyBlobAttestation(entity, virtualSigstore, wrongIdentity, verifierOpts...)
+	assert.Error(t, err)
+
+	// Missing options
+	noBlob := opts
+	noBlob.BlobPath = ""
+	_, err = verify.VerifyBlobAttestation(entity, virtualSigstore, noBlob, verifierOpts...)
+	assert.ErrorContains(t, err, "exactly one of")
+
+	noIssuer := opts
+	noIssuer.CertificateOIDCIssuer = ""
+	_, err = verify.VerifyBlobAttestation(entity, virtualSigstore, noIssuer, verifierOpts...)
+	assert.ErrorContains(t, err, "invalid certificate identity")
+
+	// Message signatures are not attestations
+	signed, err := virtualSigstore.Sign("foo@example.com", "issuer", blob)
+	assert.NoError(t, err)
+	_, err = verify.VerifyBlobAttestation(signed, virtualSigstore, opts, verifierOpts...)
+	assert.ErrorContains(t, err, "does not contain an attestation")
+}
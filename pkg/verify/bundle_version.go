@@ -0,0 +1,122 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// BundleVersionError is returned when a bundle's version is outside the
+// range configured with WithMinBundleVersion and WithMaxBundleVersion, or
+// can't be determined.
+type BundleVersionError struct {
+	// MediaType is the bundle's media type, or empty if the entity is not a
+	// bundle
+	MediaType string
+	// Version is the bundle's version, e.g. "v0.1", or empty if it could
+	// not be determined
+	Version    string
+	MinVersion string
+	MaxVersion string
+}
+
+func (e *BundleVersionError) Error() string {
+	switch {
+	case e.MediaType == "":
+		return "bundle version required by verifier, but the entity is not a bundle"
+	case e.Version == "":
+		return fmt.Sprintf("bundle media type %q has no version", e.MediaType)
+	case e.MinVersion != "" && semver.Compare(e.Version, e.MinVersion) < 0:
+		return fmt.Sprintf("bundle media type %q is older than the minimum version %s", e.MediaType, e.MinVersion)
+	default:
+		return fmt.Sprintf("bundle media type %q is newer than the maximum version %s", e.MediaType, e.MaxVersion)
+	}
+}
+
+// WithMinBundleVersion configures the SignedEntityVerifier to reject
+// bundles older than version, e.g. "v0.2" to reject v0.1 bundles, whose
+// log entries have inclusion promises but no inclusion proofs. Such
+// bundles fail with a policy error wrapping a *BundleVersionError, as do
+// entities that are not bundles.
+func WithMinBundleVersion(version string) VerifierOption {
+	return func(c *VerifierConfig) error {
+		v, err := parseBundleVersion(version)
+		if err != nil {
+			return err
+		}
+		c.minBundleVersion = v
+		return c.checkBundleVersionRange()
+	}
+}
+
+// WithMaxBundleVersion configures the SignedEntityVerifier to reject
+// bundles newer than version, e.g. "v0.3", so that bundles of versions
+// that were not reviewed are rejected explicitly. Such bundles fail with a
+// policy error wrapping a *BundleVersionError, as do entities that are not
+// bundles.
+func WithMaxBundleVersion(version string) VerifierOption {
+	return func(c *VerifierConfig) error {
+		v, err := parseBundleVersion(version)
+		if err != nil {
+			return err
+		}
+		c.maxBundleVersion = v
+		return c.checkBundleVersionRange()
+	}
+}
+
+// parseBundleVersion returns the canonical form of a bundle version given
+// with or without a leading "v".
+func parseBundleVersion(version string) (string, error) {
+	v := "v" + strings.TrimPrefix(version, "v")
+	if !semver.IsValid(v) {
+		return "", fmt.Errorf("invalid bundle version %q", version)
+	}
+	return v, nil
+}
+
+func (c *VerifierConfig) checkBundleVersionRange() error {
+	if c.minBundleVersion != "" && c.maxBundleVersion != "" && semver.Compare(c.minBundleVersion, c.maxBundleVersion) > 0 {
+		return fmt.Errorf("minimum bundle version %s is newer than the maximum %s", c.minBundleVersion, c.maxBundleVersion)
+	}
+	return nil
+}
+
+// checkBundleVersion returns an error if the verifier has a bundle version
+// range and the entity is not a bundle within it.
+func (c *VerifierConfig) checkBundleVersion(entity SignedEntity) error {
+	if c.minBundleVersion == "" && c.maxBundleVersion == "" {
+		return nil
+	}
+	versionErr := &BundleVersionError{MinVersion: c.minBundleVersion, MaxVersion: c.maxBundleVersion}
+	b, ok := entity.(BundleVersionProvider)
+	if !ok {
+		return policyNotSatisfied(withReason(ReasonBundleVersion, versionErr))
+	}
+	versionErr.MediaType = b.GetMediaType()
+	version, err := b.Version()
+	if err != nil {
+		return policyNotSatisfied(withReason(ReasonBundleVersion, versionErr))
+	}
+	versionErr.Version = version
+	if (c.minBundleVersion != "" && semver.Compare(version, c.minBundleVersion) < 0) ||
+		(c.maxBundleVersion != "" && semver.Compare(version, c.maxBundleVersion) > 0) {
+		return policyNotSatisfied(withReason(ReasonBundleVersion, versionErr))
+	}
+	return nil
+}
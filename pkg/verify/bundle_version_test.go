@@ -0,0 +1,83 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestBundleVersionRange(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("key", &sign.PlainData{Data: []byte("hello world")})
+	require.NoError(t, err)
+	v02MediaType, err := bundle.MediaTypeString("0.2")
+	require.NoError(t, err)
+	pb := proto.Clone(entity.Bundle).(*protobundle.Bundle)
+	pb.MediaType = v02MediaType
+	v02Entity, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	verifyWith := func(entity verify.SignedEntity, options ...verify.VerifierOption) error {
+		verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, append(options, verify.WithoutAnyObserverTimestampsInsecure())...)
+		require.NoError(t, err)
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+
+	assert.NoError(t, verifyWith(entity, verify.WithMinBundleVersion("0.3"), verify.WithMaxBundleVersion("v0.3")))
+	assert.NoError(t, verifyWith(v02Entity, verify.WithMinBundleVersion("0.2")))
+
+	err = verifyWith(v02Entity, verify.WithMinBundleVersion("0.3"))
+	var versionErr *verify.BundleVersionError
+	require.True(t, errors.As(err, &versionErr))
+	assert.Equal(t, v02MediaType, versionErr.MediaType)
+	assert.Equal(t, "v0.2", versionErr.Version)
+	assert.Contains(t, err.Error(), "older than the minimum version v0.3")
+	assert.Equal(t, verify.ErrorClassPolicyNotSatisfied, verify.ClassifyError(err))
+	assert.Contains(t, verify.Reasons(err), verify.ReasonBundleVersion)
+
+	err = verifyWith(entity, verify.WithMaxBundleVersion("0.2"))
+	require.True(t, errors.As(err, &versionErr))
+	assert.Contains(t, err.Error(), "newer than the maximum version v0.2")
+
+	// entities that are not bundles have no version
+	testEntity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("hello world"))
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithMinBundleVersion("0.1"))
+	require.NoError(t, err)
+	_, err = verifier.Verify(testEntity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithoutIdentitiesUnsafe()))
+	require.True(t, errors.As(err, &versionErr))
+	assert.Empty(t, versionErr.MediaType)
+}
+
+func TestBundleVersionOptions(t *testing.T) {
+	_, err := verify.NewSignedEntityVerifier(nil, verify.WithMinBundleVersion("latest"))
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(nil, verify.WithMinBundleVersion("0.3"), verify.WithMaxBundleVersion("0.2"))
+	assert.Error(t, err)
+}
@@ -23,7 +23,24 @@ import (
 )
 
 func VerifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificate, trustedMaterial root.TrustedMaterial) error { // nolint: revive
-	for _, ca := range trustedMaterial.FulcioCertificateAuthorities() {
+	_, err := verifyLeafCertificate(observerTimestamp, leafCert, trustedMaterial, nil)
+	return err
+}
+
+// verifyLeafCertificate is VerifyLeafCertificate, also returning the
+// certificate authority that issued the certificate. If checkChain is not
+// nil, the certificate is only valid if one of its verified chains passes
+// the check.
+func verifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificate, trustedMaterial root.TrustedMaterial, checkChain func([]*x509.Certificate) error) (*root.CertificateAuthority, error) {
+	// Set if a certificate authority valid at the time found the
+	// certificate invalid for a reason other than not having issued it
+	invalid := false
+	// Set if a certificate authority issued the certificate, but none of
+	// its chains passed the check
+	var chainErr error
+	certAuthorities := trustedMaterial.FulcioCertificateAuthorities()
+	for i := range certAuthorities {
+		ca := &certAuthorities[i]
 		if !ca.ValidityPeriodStart.IsZero() && observerTimestamp.Before(ca.ValidityPeriodStart) {
 			continue
 		}
@@ -31,12 +48,7 @@ func VerifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificat
 			continue
 		}
 
-		rootCertPool := x509.NewCertPool()
-		rootCertPool.AddCert(ca.Root)
-		intermediateCertPool := x509.NewCertPool()
-		for _, cert := range ca.Intermediates {
-			intermediateCertPool.AddCert(cert)
-		}
+		rootCertPool, intermediateCertPool := ca.CertPools()
 
 		// From spec:
 		// > ## Certificate
@@ -51,11 +63,30 @@ func VerifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificat
 			},
 		}
 
-		_, err := leafCert.Verify(opts)
+		chains, err := leafCert.Verify(opts)
 		if err == nil {
-			return nil
+			if checkChain == nil {
+				return ca, nil
+			}
+			for _, chain := range chains {
+				if chainErr = checkChain(chain); chainErr == nil {
+					return ca, nil
+				}
+			}
+			continue
+		}
+		var unknownAuthority x509.UnknownAuthorityError
+		if !errors.As(err, &unknownAuthority) {
+			invalid = true
 		}
 	}
 
-	return errors.New("leaf certificate verification failed")
+	if chainErr != nil {
+		return nil, policyNotSatisfied(withReason(ReasonCertificatePolicy, chainErr))
+	}
+	err := errors.New("leaf certificate verification failed")
+	if invalid {
+		return nil, cryptographicFailure(err)
+	}
+	return nil, untrustedMaterial(err)
 }
@@ -22,14 +22,22 @@ import (
 	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
 )
 
+// SubjectAlternativeNameMatcher matches any of a certificate's subject
+// alternative names. An empty Type matches SANs of every type; set it to
+// restrict the match to email, URI, DNS or OtherName SANs.
 type SubjectAlternativeNameMatcher struct {
 	certificate.SubjectAlternativeName
 	Regexp regexp.Regexp `json:"regexp,omitempty"`
+	// Optional SPIFFE ID criteria, which only URI SANs can match
+	SPIFFE *SPIFFEIDMatcher `json:"spiffe,omitempty"`
 }
 
 type CertificateIdentity struct {
 	SubjectAlternativeName SubjectAlternativeNameMatcher `json:"subjectAlternativeName"`
 	certificate.Extensions
+	// Expected values of custom extensions, keyed by the names they are
+	// registered under in a certificate.ExtensionRegistry
+	CustomExtensions map[string]string `json:"customExtensions,omitempty"`
 }
 
 type CertificateIdentities []CertificateIdentity
@@ -50,48 +58,82 @@ func NewSANMatcher(sanValue string, sanType string, regexpStr string) (SubjectAl
 		Regexp: *r}, nil
 }
 
+// NewEmailSANMatcher returns a SubjectAlternativeNameMatcher that only
+// matches email SANs.
+func NewEmailSANMatcher(sanValue string, regexpStr string) (SubjectAlternativeNameMatcher, error) {
+	return NewSANMatcher(sanValue, string(certificate.SubjectAlternativeNameTypeEmail), regexpStr)
+}
+
+// NewURISANMatcher returns a SubjectAlternativeNameMatcher that only
+// matches URI SANs.
+func NewURISANMatcher(sanValue string, regexpStr string) (SubjectAlternativeNameMatcher, error) {
+	return NewSANMatcher(sanValue, string(certificate.SubjectAlternativeNameTypeURI), regexpStr)
+}
+
+// NewDNSSANMatcher returns a SubjectAlternativeNameMatcher that only
+// matches DNS name SANs.
+func NewDNSSANMatcher(sanValue string, regexpStr string) (SubjectAlternativeNameMatcher, error) {
+	return NewSANMatcher(sanValue, string(certificate.SubjectAlternativeNameTypeDNS), regexpStr)
+}
+
+// NewOtherNameSANMatcher returns a SubjectAlternativeNameMatcher that only
+// matches Fulcio OtherName SANs, which hold usernames.
+func NewOtherNameSANMatcher(sanValue string, regexpStr string) (SubjectAlternativeNameMatcher, error) {
+	return NewSANMatcher(sanValue, string(certificate.SubjectAlternativeNameTypeOther), regexpStr)
+}
+
 // The default Regexp json marshal is quite ugly, so we override it here.
 func (s *SubjectAlternativeNameMatcher) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		certificate.SubjectAlternativeName
-		Regexp string `json:"regexp,omitempty"`
+		Regexp string           `json:"regexp,omitempty"`
+		SPIFFE *SPIFFEIDMatcher `json:"spiffe,omitempty"`
 	}{
 		SubjectAlternativeName: s.SubjectAlternativeName,
 		Regexp:                 s.Regexp.String(),
+		SPIFFE:                 s.SPIFFE,
 	})
 }
 
-// Verify checks if the actualCert matches the SANMatcher's Type, Value, and
-// Regexp – if those values have been provided.
+// Verify checks if any of the actualCert's SANs matches the SANMatcher's
+// Type, Value, Regexp and SPIFFE criteria – if those values have been
+// provided.
 func (s SubjectAlternativeNameMatcher) Verify(actualCert certificate.Summary) bool {
-	var typeMatches bool
-	var valueMatches bool
-	var regexMatches bool
+	_, ok := s.Match(actualCert)
+	return ok
+}
 
-	// if a {SAN Type, Value, Regexp} was not specified, default to true
-	if s.SubjectAlternativeName.Type != "" {
-		typeMatches = s.Type == actualCert.SubjectAlternativeName.Type
-	} else {
-		typeMatches = true
+// Match returns the first of the actualCert's SANs that matches the
+// SANMatcher's Type, Value, Regexp and SPIFFE criteria, and whether one was
+// found.
+func (s SubjectAlternativeNameMatcher) Match(actualCert certificate.Summary) (certificate.SubjectAlternativeName, bool) {
+	for _, san := range actualCert.AllSubjectAlternativeNames() {
+		if s.matches(san) {
+			return san, true
+		}
 	}
+	return certificate.SubjectAlternativeName{}, false
+}
 
-	if s.SubjectAlternativeName.Value != "" {
-		valueMatches = s.Value == actualCert.SubjectAlternativeName.Value
-	} else {
-		valueMatches = true
+func (s SubjectAlternativeNameMatcher) matches(san certificate.SubjectAlternativeName) bool {
+	// if a {SAN Type, Value, Regexp} was not specified, default to true
+	if s.SubjectAlternativeName.Type != "" && s.Type != san.Type {
+		return false
 	}
-
-	if s.Regexp.String() != "" {
-		regexMatches = s.Regexp.MatchString(actualCert.SubjectAlternativeName.Value)
-	} else {
-		regexMatches = true
+	if s.SubjectAlternativeName.Value != "" && s.Value != san.Value {
+		return false
 	}
-
-	return typeMatches && valueMatches && regexMatches
+	if s.Regexp.String() != "" && !s.Regexp.MatchString(san.Value) {
+		return false
+	}
+	if s.SPIFFE != nil && (san.Type != certificate.SubjectAlternativeNameTypeURI || !s.SPIFFE.Matches(san.Value)) {
+		return false
+	}
+	return true
 }
 
 func NewCertificateIdentity(sanMatcher SubjectAlternativeNameMatcher, extensions certificate.Extensions) (CertificateIdentity, error) {
-	if sanMatcher.SubjectAlternativeName.Value == "" && sanMatcher.Regexp.String() == "" {
+	if sanMatcher.SubjectAlternativeName.Value == "" && sanMatcher.Regexp.String() == "" && sanMatcher.SPIFFE == nil {
 		return CertificateIdentity{}, errors.New("when verifying a certificate identity, there must be subject alternative name criteria")
 	}
 
@@ -104,6 +146,21 @@ func NewCertificateIdentity(sanMatcher SubjectAlternativeNameMatcher, extensions
 	return certID, nil
 }
 
+// WithCustomExtensions returns a copy of the identity that also requires the
+// given custom extension values, keyed by the names they are registered
+// under in the verifier's certificate.ExtensionRegistry.
+func (c CertificateIdentity) WithCustomExtensions(customExtensions map[string]string) CertificateIdentity {
+	merged := make(map[string]string, len(c.CustomExtensions)+len(customExtensions))
+	for name, value := range c.CustomExtensions {
+		merged[name] = value
+	}
+	for name, value := range customExtensions {
+		merged[name] = value
+	}
+	c.CustomExtensions = merged
+	return c
+}
+
 // NewShortCertificateIdentity provides a more convenient way of initializing
 // a CertificiateIdentity with a SAN and the Issuer OID extension. If you need
 // to check more OID extensions, use NewCertificateIdentity instead.
@@ -131,6 +188,7 @@ func (i CertificateIdentities) Verify(cert certificate.Summary) (*CertificateIde
 func (c CertificateIdentity) Verify(actualCert certificate.Summary) bool {
 	sanMatches := c.SubjectAlternativeName.Verify(actualCert)
 	extensionsMatch := certificate.CompareExtensions(c.Extensions, actualCert.Extensions)
+	customExtensionsMatch := certificate.CompareCustomExtensions(c.CustomExtensions, actualCert.CustomExtensions)
 
-	return sanMatches && extensionsMatch
+	return sanMatches && extensionsMatch && customExtensionsMatch
 }
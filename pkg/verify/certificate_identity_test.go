@@ -90,6 +90,25 @@ func TestCertificateIdentityVerify(t *testing.T) {
 	assert.Nil(t, ci)
 }
 
+func TestCertificateIdentityCustomExtensions(t *testing.T) {
+	actualCert := certificate.Summary{
+		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "URI", Value: SigstoreSanValue},
+		Extensions:             certificate.Extensions{Issuer: ActionsIssuerValue},
+		CustomExtensions:       map[string]string{"department": "release-engineering"},
+	}
+
+	certID, err := NewShortCertificateIdentity(ActionsIssuerValue, SigstoreSanValue, "", "")
+	assert.NoError(t, err)
+	assert.True(t, certID.Verify(actualCert))
+
+	assert.True(t, certID.WithCustomExtensions(map[string]string{"department": "release-engineering"}).Verify(actualCert))
+	assert.False(t, certID.WithCustomExtensions(map[string]string{"department": "security"}).Verify(actualCert))
+	assert.False(t, certID.WithCustomExtensions(map[string]string{"costCenter": "cc-42"}).Verify(actualCert))
+
+	// WithCustomExtensions does not modify the original identity
+	assert.Empty(t, certID.CustomExtensions)
+}
+
 func TestThatCertIDsAreFullySpecified(t *testing.T) {
 	_, err := NewShortCertificateIdentity("", "", "", "")
 	assert.Error(t, err)
@@ -112,3 +131,69 @@ func certIDForTesting(sanValue, sanType, sanRegex, issuer, runnerEnv string) (Ce
 
 	return CertificateIdentity{SubjectAlternativeName: san, Extensions: certificate.Extensions{Issuer: issuer, RunnerEnvironment: runnerEnv}}, nil
 }
+
+func TestSANMatcherMultipleTypes(t *testing.T) {
+	actualCert := certificate.Summary{
+		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "URI", Value: SigstoreSanValue},
+		SubjectAlternativeNames: []certificate.SubjectAlternativeName{
+			{Type: "URI", Value: SigstoreSanValue},
+			{Type: "Email", Value: "releases@example.com"},
+			{Type: "Other", Value: "releaser!oidc.example.com"},
+			{Type: "DNS", Value: "builder.example.com"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		matcher  func() (SubjectAlternativeNameMatcher, error)
+		expected *certificate.SubjectAlternativeName
+	}{
+		{
+			name:     "email",
+			matcher:  func() (SubjectAlternativeNameMatcher, error) { return NewEmailSANMatcher("releases@example.com", "") },
+			expected: &certificate.SubjectAlternativeName{Type: "Email", Value: "releases@example.com"},
+		},
+		{
+			name:     "uri regexp",
+			matcher:  func() (SubjectAlternativeNameMatcher, error) { return NewURISANMatcher("", SigstoreSanRegex) },
+			expected: &certificate.SubjectAlternativeName{Type: "URI", Value: SigstoreSanValue},
+		},
+		{
+			name:     "dns regexp",
+			matcher:  func() (SubjectAlternativeNameMatcher, error) { return NewDNSSANMatcher("", `\.example\.com$`) },
+			expected: &certificate.SubjectAlternativeName{Type: "DNS", Value: "builder.example.com"},
+		},
+		{
+			name: "other name",
+			matcher: func() (SubjectAlternativeNameMatcher, error) {
+				return NewOtherNameSANMatcher("releaser!oidc.example.com", "")
+			},
+			expected: &certificate.SubjectAlternativeName{Type: "Other", Value: "releaser!oidc.example.com"},
+		},
+		{
+			name:     "any type",
+			matcher:  func() (SubjectAlternativeNameMatcher, error) { return NewSANMatcher("builder.example.com", "", "") },
+			expected: &certificate.SubjectAlternativeName{Type: "DNS", Value: "builder.example.com"},
+		},
+		{
+			name:    "value of another type",
+			matcher: func() (SubjectAlternativeNameMatcher, error) { return NewEmailSANMatcher("builder.example.com", "") },
+		},
+		{
+			name:    "regexp of another type",
+			matcher: func() (SubjectAlternativeNameMatcher, error) { return NewDNSSANMatcher("", "^https://") },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			matcher, err := tc.matcher()
+			assert.NoError(t, err)
+
+			san, ok := matcher.Match(actualCert)
+			assert.Equal(t, tc.expected != nil, ok)
+			assert.Equal(t, tc.expected != nil, matcher.Verify(actualCert))
+			if tc.expected != nil {
+				assert.Equal(t, *tc.expected, san)
+			}
+		})
+	}
+}
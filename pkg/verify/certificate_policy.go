@@ -0,0 +1,136 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/dsa" //nolint:staticcheck // DSA keys are detected to be rejected
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// MinCertificateRSAKeySize is the minimum size in bits of RSA keys in
+// certificates accepted by WithoutWeakCertificateAlgorithms.
+const MinCertificateRSAKeySize = 2048
+
+// ChainDepthError is returned when the certificate chain of the signing
+// certificate has more certificates than allowed by
+// WithMaxCertificateChainDepth.
+type ChainDepthError struct {
+	// Depth is the number of certificates in the chain, from the leaf to
+	// the root inclusive
+	Depth    int
+	MaxDepth int
+}
+
+func (e *ChainDepthError) Error() string {
+	return fmt.Sprintf("certificate chain has %d certificates, more than the maximum of %d", e.Depth, e.MaxDepth)
+}
+
+// WeakAlgorithmError is returned when the signing certificate or an
+// intermediate certificate of its chain uses an algorithm rejected by
+// WithoutWeakCertificateAlgorithms.
+type WeakAlgorithmError struct {
+	// Subject is the subject of the certificate, which is empty for
+	// Fulcio-issued leaf certificates
+	Subject string
+	// Algorithm is the weak signature or public key algorithm, e.g.
+	// "SHA1-RSA" or "RSA-1024"
+	Algorithm string
+}
+
+func (e *WeakAlgorithmError) Error() string {
+	if e.Subject == "" {
+		return fmt.Sprintf("certificate uses weak algorithm %s", e.Algorithm)
+	}
+	return fmt.Sprintf("certificate %q uses weak algorithm %s", e.Subject, e.Algorithm)
+}
+
+// WithMaxCertificateChainDepth configures the SignedEntityVerifier to
+// reject signing certificates whose chain to a trusted root has more than
+// maxDepth certificates, counting the leaf and the root. A leaf issued
+// directly by a root has a depth of 2.
+func WithMaxCertificateChainDepth(maxDepth int) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxDepth < 2 {
+			return errors.New("maximum certificate chain depth must be at least 2")
+		}
+		c.maxCertificateChainDepth = maxDepth
+		return nil
+	}
+}
+
+// WithoutWeakCertificateAlgorithms configures the SignedEntityVerifier to
+// reject signing certificates if they or the intermediate certificates of
+// their chain are signed with MD5 or SHA-1, or have DSA keys, RSA keys
+// smaller than MinCertificateRSAKeySize or P-224 keys. Trusted roots are
+// not checked, as their signatures are not relied on.
+func WithoutWeakCertificateAlgorithms() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.rejectWeakCertificateAlgorithms = true
+		return nil
+	}
+}
+
+// certificateChainCheck returns the check of the verifier's chain policy,
+// or nil if it has none.
+func (c *VerifierConfig) certificateChainCheck() func([]*x509.Certificate) error {
+	if c.maxCertificateChainDepth == 0 && !c.rejectWeakCertificateAlgorithms {
+		return nil
+	}
+	return c.checkCertificateChain
+}
+
+// checkCertificateChain returns an error if the verified chain, from the
+// leaf to the root, does not satisfy the verifier's chain policy.
+func (c *VerifierConfig) checkCertificateChain(chain []*x509.Certificate) error {
+	if c.maxCertificateChainDepth > 0 && len(chain) > c.maxCertificateChainDepth {
+		return &ChainDepthError{Depth: len(chain), MaxDepth: c.maxCertificateChainDepth}
+	}
+	if c.rejectWeakCertificateAlgorithms {
+		// The last certificate is the trusted root
+		for _, cert := range chain[:len(chain)-1] {
+			if alg := weakCertificateAlgorithm(cert); alg != "" {
+				return &WeakAlgorithmError{Subject: cert.Subject.String(), Algorithm: alg}
+			}
+		}
+	}
+	return nil
+}
+
+// weakCertificateAlgorithm returns the weak algorithm used by the
+// certificate, or "" if it uses none.
+func weakCertificateAlgorithm(cert *x509.Certificate) string {
+	switch cert.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return cert.SignatureAlgorithm.String()
+	}
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < MinCertificateRSAKeySize {
+			return fmt.Sprintf("RSA-%d", pub.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		if pub.Curve == elliptic.P224() {
+			return "ECDSA-P224"
+		}
+	case *dsa.PublicKey:
+		return "DSA"
+	}
+	return ""
+}
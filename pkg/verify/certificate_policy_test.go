@@ -0,0 +1,93 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCertificateChain(t *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p224Key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	rsa1024Key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	cert := func(subject string, sigAlg x509.SignatureAlgorithm, pub any) *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: subject}, SignatureAlgorithm: sigAlg, PublicKey: pub}
+	}
+	leaf := cert("", x509.ECDSAWithSHA256, &p256Key.PublicKey)
+	intermediate := cert("intermediate", x509.ECDSAWithSHA384, &p256Key.PublicKey)
+	root := cert("root", x509.SHA1WithRSA, &rsa1024Key.PublicKey)
+
+	config := &VerifierConfig{}
+	assert.Nil(t, config.certificateChainCheck())
+
+	config = &VerifierConfig{maxCertificateChainDepth: 2, rejectWeakCertificateAlgorithms: true}
+	var depthErr *ChainDepthError
+	require.ErrorAs(t, config.checkCertificateChain([]*x509.Certificate{leaf, intermediate, root}), &depthErr)
+	assert.Equal(t, 3, depthErr.Depth)
+	assert.Equal(t, 2, depthErr.MaxDepth)
+
+	// The root's algorithms are not checked
+	config.maxCertificateChainDepth = 3
+	assert.NoError(t, config.checkCertificateChain([]*x509.Certificate{leaf, intermediate, root}))
+
+	for _, tc := range []struct {
+		name      string
+		chain     []*x509.Certificate
+		subject   string
+		algorithm string
+	}{
+		{
+			name:      "sha1 leaf signature",
+			chain:     []*x509.Certificate{cert("", x509.ECDSAWithSHA1, &p256Key.PublicKey), root},
+			algorithm: "ECDSA-SHA1",
+		},
+		{
+			name:      "small rsa intermediate key",
+			chain:     []*x509.Certificate{leaf, cert("intermediate", x509.SHA256WithRSA, &rsa1024Key.PublicKey), root},
+			subject:   "CN=intermediate",
+			algorithm: "RSA-1024",
+		},
+		{
+			name:      "p224 leaf key",
+			chain:     []*x509.Certificate{cert("", x509.ECDSAWithSHA256, &p224Key.PublicKey), intermediate, root},
+			algorithm: "ECDSA-P224",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := config.checkCertificateChain(tc.chain)
+			var weakErr *WeakAlgorithmError
+			require.True(t, errors.As(err, &weakErr))
+			assert.Equal(t, tc.subject, weakErr.Subject)
+			assert.Equal(t, tc.algorithm, weakErr.Algorithm)
+		})
+	}
+
+	_, err = NewSignedEntityVerifier(nil, WithMaxCertificateChainDepth(1), WithObserverTimestamps(1))
+	assert.Error(t, err)
+}
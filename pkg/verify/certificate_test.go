@@ -15,9 +15,13 @@
 package verify_test
 
 import (
+	"crypto/x509"
 	"testing"
 	"time"
 
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
@@ -59,3 +63,63 @@ func TestVerifyValidityPeriod(t *testing.T) {
 		})
 	}
 }
+
+// parsedCATrustedMaterial serves the Fulcio CA of a VirtualSigstore as parsed
+// from a trusted root, so that its certificate pools are cached.
+type parsedCATrustedMaterial struct {
+	*ca.VirtualSigstore
+	fulcioCAs []root.CertificateAuthority
+}
+
+func (p *parsedCATrustedMaterial) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	return p.fulcioCAs
+}
+
+func newParsedCATrustedMaterial(b *testing.B, virtualSigstore *ca.VirtualSigstore) *parsedCATrustedMaterial {
+	fulcioCA := virtualSigstore.FulcioCertificateAuthorities()[0]
+	chain := []*x509.Certificate{}
+	chain = append(chain, fulcioCA.Intermediates...)
+	chain = append(chain, fulcioCA.Root)
+
+	protoCA := &prototrustroot.CertificateAuthority{CertChain: &protocommon.X509CertificateChain{}}
+	for _, cert := range chain {
+		protoCA.CertChain.Certificates = append(protoCA.CertChain.Certificates, &protocommon.X509Certificate{RawBytes: cert.Raw})
+	}
+	parsed, err := root.ParseCertificateAuthority(protoCA)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return &parsedCATrustedMaterial{VirtualSigstore: virtualSigstore, fulcioCAs: []root.CertificateAuthority{*parsed}}
+}
+
+func BenchmarkVerifyLeafCertificate(b *testing.B) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	if err != nil {
+		b.Fatal(err)
+	}
+	leaf, _, err := virtualSigstore.GenerateLeafCert("example@example.com", "issuer")
+	if err != nil {
+		b.Fatal(err)
+	}
+	now := time.Now()
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := verify.VerifyLeafCertificate(now, *leaf, virtualSigstore); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		tm := newParsedCATrustedMaterial(b, virtualSigstore)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := verify.VerifyLeafCertificate(now, *leaf, tm); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+// ReasonCode is a machine-readable reason for denying an entity, which
+// callers can aggregate across entities or map to localized messages.
+type ReasonCode string
+
+const (
+	// ReasonIdentityMismatch means the signing certificate matched none of
+	// the policy's certificate identities, or the entity was not signed
+	// with a certificate although identities were required
+	ReasonIdentityMismatch ReasonCode = "identityMismatch"
+	// ReasonKeyMismatch means the entity was not signed with the policy's
+	// key
+	ReasonKeyMismatch ReasonCode = "keyMismatch"
+	// ReasonUntrustedOIDCProvider means the certificate was issued for an
+	// OIDC provider that the trusted material does not trust
+	ReasonUntrustedOIDCProvider ReasonCode = "untrustedOIDCProvider"
+	// ReasonArtifactMismatch means the entity was not signed over the
+	// policy's artifact or artifact digest
+	ReasonArtifactMismatch ReasonCode = "artifactMismatch"
+	// ReasonInsufficientEvidence means the entity does not have enough
+	// verifiable timestamps, log entries or SCTs to meet the verifier's
+	// thresholds or evidence requirement
+	ReasonInsufficientEvidence ReasonCode = "insufficientEvidence"
+	// ReasonCertificatePolicy means the signing certificate's chain is
+	// deeper than allowed or uses a weak algorithm; the error wraps a
+	// ChainDepthError or WeakAlgorithmError
+	ReasonCertificatePolicy ReasonCode = "certificatePolicy"
+	// ReasonBundleVersion means the bundle's version is outside the range
+	// the verifier accepts; the error wraps a BundleVersionError
+	ReasonBundleVersion ReasonCode = "bundleVersion"
+	// ReasonDenylisted means the signing certificate or key is on a
+	// denylist of the trusted material; the error wraps root.ErrDenylisted
+	ReasonDenylisted ReasonCode = "denylisted"
+	// ReasonTimestampDisagreement means the entity's log entry integrated
+	// timestamps and signed timestamps differ by more than the verifier
+	// allows
+	ReasonTimestampDisagreement ReasonCode = "timestampDisagreement"
+	// ReasonStaleTrustedMaterial means the trusted material was fetched
+	// longer ago than the verifier allows, its TUF metadata has expired, or
+	// it was not fetched from TUF; the error wraps
+	// root.ErrStaleTrustedMaterial or root.ErrUnknownFreshness
+	ReasonStaleTrustedMaterial ReasonCode = "staleTrustedMaterial"
+
+	// The reasons of denials without a more specific reason, one for each
+	// ErrorClass
+
+	ReasonUntrustedMaterial    ReasonCode = "untrustedMaterial"
+	ReasonCryptographicFailure ReasonCode = "cryptographicFailure"
+	ReasonPolicyNotSatisfied   ReasonCode = "policyNotSatisfied"
+	// ReasonInvalidEntity is the reason for errors of no class, such as
+	// malformed entities or policies
+	ReasonInvalidEntity ReasonCode = "invalidEntity"
+)
+
+// Decision is the outcome of verifying an entity against a policy, for
+// systems such as admission controllers that allow or deny entities and
+// need to report why.
+type Decision struct {
+	Allowed bool `json:"allowed"`
+	// DeniedReasons lists why the entity was denied, from most to least
+	// specific; it is empty if the entity was allowed
+	DeniedReasons []ReasonCode `json:"deniedReasons,omitempty"`
+	// Warnings lists the checks that failed without failing verification,
+	// which callers may still deny on
+	Warnings []VerificationWarning `json:"warnings,omitempty"`
+	// Message describes the denial in English
+	Message string `json:"message,omitempty"`
+	// Result is the verification result of an allowed entity
+	Result *VerificationResult `json:"result,omitempty"`
+	// Err is the verification error of a denied entity
+	Err error `json:"-"`
+}
+
+// NewDecision returns the decision for the outcome of verification, as
+// returned by Verify.
+func NewDecision(result *VerificationResult, err error) *Decision {
+	if err != nil {
+		return &Decision{DeniedReasons: Reasons(err), Message: err.Error(), Err: err}
+	}
+	decision := &Decision{Allowed: true, Result: result}
+	if result != nil {
+		decision.Warnings = result.Warnings
+	}
+	return decision
+}
+
+// Decide verifies the entity against the policy, like Verify, and returns
+// the decision.
+func (v *SignedEntityVerifier) Decide(entity SignedEntity, pb PolicyBuilder) *Decision {
+	return NewDecision(v.Verify(entity, pb))
+}
+
+// Decide verifies the entity against the policy, like Verify, and returns
+// the decision.
+func (v *MultiTrustedMaterialVerifier) Decide(entity SignedEntity, pb PolicyBuilder) *Decision {
+	return NewDecision(v.Verify(entity, pb))
+}
+
+// Reasons returns the reasons for a verification error: the specific
+// reasons it wraps, followed by the reason for its ErrorClass.
+func Reasons(err error) []ReasonCode {
+	if err == nil {
+		return nil
+	}
+	var reasons []ReasonCode
+	collectReasons(err, &reasons)
+	return appendReason(reasons, classReason(ClassifyError(err)))
+}
+
+func collectReasons(err error, reasons *[]ReasonCode) {
+	if r, ok := err.(*reasonError); ok { //nolint:errorlint // the tree is walked explicitly
+		*reasons = appendReason(*reasons, r.reason)
+	}
+	switch e := err.(type) { //nolint:errorlint // the tree is walked explicitly
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			collectReasons(inner, reasons)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			collectReasons(inner, reasons)
+		}
+	}
+}
+
+func appendReason(reasons []ReasonCode, reason ReasonCode) []ReasonCode {
+	for _, existing := range reasons {
+		if existing == reason {
+			return reasons
+		}
+	}
+	return append(reasons, reason)
+}
+
+func classReason(class ErrorClass) ReasonCode {
+	switch class {
+	case ErrorClassUntrustedMaterial:
+		return ReasonUntrustedMaterial
+	case ErrorClassCryptographicFailure:
+		return ReasonCryptographicFailure
+	case ErrorClassPolicyNotSatisfied:
+		return ReasonPolicyNotSatisfied
+	default:
+		return ReasonInvalidEntity
+	}
+}
+
+// reasonError marks an error with a specific reason without changing its
+// message.
+type reasonError struct {
+	err    error
+	reason ReasonCode
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+func withReason(reason ReasonCode, err error) error {
+	return &reasonError{err, reason}
+}
+
+// insufficientEvidence marks an error for an unmet threshold or evidence
+// requirement.
+func insufficientEvidence(err error) error {
+	return policyNotSatisfied(withReason(ReasonInsufficientEvidence, err))
+}
+
+// artifactMismatch marks an error for an entity not signed over the
+// policy's artifact.
+func artifactMismatch(err error) error {
+	return withReason(ReasonArtifactMismatch, err)
+}
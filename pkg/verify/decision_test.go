@@ -0,0 +1,93 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestDecide(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	otherSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	require.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+
+	identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	require.NoError(t, err)
+	wrongIdentity, err := verify.NewShortCertificateIdentity("issuer", "bar@example.com", "", "")
+	require.NoError(t, err)
+	otherDigest := sha256.Sum256([]byte("other"))
+
+	decision := verifier.Decide(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.True(t, decision.Allowed)
+	assert.Empty(t, decision.DeniedReasons)
+	assert.NotNil(t, decision.Result)
+
+	decision = verifier.Decide(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(wrongIdentity)))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []verify.ReasonCode{verify.ReasonIdentityMismatch, verify.ReasonPolicyNotSatisfied}, decision.DeniedReasons)
+	assert.Contains(t, decision.Message, "failed to verify certificate identity")
+
+	decision = verifier.Decide(entity, verify.NewPolicy(verify.WithArtifactDigest("sha256", otherDigest[:]), verify.WithCertificateIdentity(identity)))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []verify.ReasonCode{verify.ReasonArtifactMismatch, verify.ReasonCryptographicFailure}, decision.DeniedReasons)
+
+	decision = verifier.Decide(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithKeyHint("key")))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []verify.ReasonCode{verify.ReasonKeyMismatch, verify.ReasonPolicyNotSatisfied}, decision.DeniedReasons)
+
+	otherVerifier, err := verify.NewSignedEntityVerifier(otherSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	decision = otherVerifier.Decide(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, verify.ReasonUntrustedMaterial, decision.DeniedReasons[len(decision.DeniedReasons)-1])
+
+	// Too few timestamps for the verifier's threshold
+	strictVerifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(2))
+	require.NoError(t, err)
+	decision = strictVerifier.Decide(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, []verify.ReasonCode{verify.ReasonInsufficientEvidence, verify.ReasonPolicyNotSatisfied}, decision.DeniedReasons)
+
+	decisionJSON, err := json.Marshal(decision)
+	require.NoError(t, err)
+	assert.Contains(t, string(decisionJSON), `"deniedReasons":["insufficientEvidence","policyNotSatisfied"]`)
+}
+
+func TestReasons(t *testing.T) {
+	assert.Nil(t, verify.Reasons(nil))
+	assert.Equal(t, []verify.ReasonCode{verify.ReasonInvalidEntity}, verify.Reasons(errors.New("malformed bundle")))
+
+	decision := verify.NewDecision(nil, errors.New("malformed bundle"))
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "malformed bundle", decision.Message)
+}
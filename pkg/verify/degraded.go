@@ -0,0 +1,103 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"fmt"
+)
+
+// DegradableCheck is a verification check that a verifier configured
+// WithDegradedChecks allows to fail without failing verification.
+type DegradableCheck string
+
+const (
+	// DegradableCheckSignedCertificateTimestamps is the verification of the
+	// SCTs of a Fulcio certificate
+	DegradableCheckSignedCertificateTimestamps DegradableCheck = "signedCertificateTimestamps"
+	// DegradableCheckTransparencyLog is the verification of transparency
+	// log entries configured with WithTransparencyLog. Without them, there
+	// are no integrated timestamps, so other observer timestamps are needed
+	// to verify the certificate.
+	DegradableCheckTransparencyLog DegradableCheck = "transparencyLog"
+	// DegradableCheckOIDCProvider is the check of WithTrustedOIDCProviders
+	DegradableCheckOIDCProvider DegradableCheck = "oidcProvider"
+)
+
+// VerificationWarning records a degradable check that failed.
+type VerificationWarning struct {
+	Check   DegradableCheck `json:"check"`
+	Message string          `json:"message"`
+	// Err is the error the check failed with, which may be classified with
+	// ClassifyError
+	Err error `json:"-"`
+}
+
+func (w VerificationWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Check, w.Message)
+}
+
+// WithDegradedChecks configures the SignedEntityVerifier to continue when
+// one of the given checks fails, rather than failing verification. Each
+// failed check is recorded in the result's Warnings, so that callers such as
+// policy engines can apply their own severity to it; a result with warnings
+// must not be treated as fully verified without doing so.
+//
+// Signature, certificate chain and identity checks can never be degraded.
+// The transparency log check can only be degraded with threshold options,
+// not WithEvidenceRequirement, under which log entries are evidence.
+func WithDegradedChecks(checks ...DegradableCheck) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if len(checks) == 0 {
+			return fmt.Errorf("at least one degradable check must be given")
+		}
+		if c.degradedChecks == nil {
+			c.degradedChecks = make(map[DegradableCheck]bool)
+		}
+		for _, check := range checks {
+			switch check {
+			case DegradableCheckSignedCertificateTimestamps, DegradableCheckTransparencyLog, DegradableCheckOIDCProvider:
+				c.degradedChecks[check] = true
+			default:
+				return fmt.Errorf("check %q cannot be degraded", check)
+			}
+		}
+		return nil
+	}
+}
+
+// degrade returns nil and records a warning if check may be degraded, or
+// returns err otherwise.
+func (v *SignedEntityVerifier) degrade(check DegradableCheck, err error, warnings *[]VerificationWarning) error {
+	if err == nil || !v.config.degradedChecks[check] {
+		return err
+	}
+	*warnings = append(*warnings, VerificationWarning{Check: check, Message: err.Error(), Err: err})
+	return nil
+}
+
+// Degraded returns true if verification succeeded despite failed checks,
+// which are listed in Warnings.
+func (r *VerificationResult) Degraded() bool {
+	return len(r.Warnings) > 0
+}
+
+func (r *VerificationResult) failedCheck(check DegradableCheck) bool {
+	for _, w := range r.Warnings {
+		if w.Check == check {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestWithDegradedChecks(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	require.NoError(t, err)
+	policy := func(options ...verify.PolicyOption) verify.PolicyBuilder {
+		return verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), append([]verify.PolicyOption{verify.WithoutIdentitiesUnsafe()}, options...)...)
+	}
+
+	t.Run("missing SCT", func(t *testing.T) {
+		v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithSignedCertificateTimestamps(1),
+			verify.WithDegradedChecks(verify.DegradableCheckSignedCertificateTimestamps))
+		require.NoError(t, err)
+		res, err := v.Verify(entity, policy())
+		require.NoError(t, err)
+		assert.True(t, res.Degraded())
+		require.Len(t, res.Warnings, 1)
+		assert.Equal(t, verify.DegradableCheckSignedCertificateTimestamps, res.Warnings[0].Check)
+		assert.Contains(t, res.Warnings[0].Message, "failed to verify signed certificate timestamp")
+		assert.Contains(t, res.Explain(), "Verification succeeded with warnings")
+		assert.Contains(t, res.Explain(), "[warning] signedCertificateTimestamps check failed")
+	})
+
+	t.Run("transparency log threshold", func(t *testing.T) {
+		v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(2), verify.WithSignedTimestamps(1),
+			verify.WithDegradedChecks(verify.DegradableCheckTransparencyLog))
+		require.NoError(t, err)
+		res, err := v.Verify(entity, policy())
+		require.NoError(t, err)
+		require.Len(t, res.Warnings, 1)
+		assert.Equal(t, verify.DegradableCheckTransparencyLog, res.Warnings[0].Check)
+		assert.Equal(t, verify.ErrorClassPolicyNotSatisfied, verify.ClassifyError(res.Warnings[0].Err))
+	})
+
+	t.Run("untrusted OIDC provider", func(t *testing.T) {
+		tm := root.WithOIDCProviders(virtualSigstore, root.OIDCProvider{Issuer: "https://other.example.com"})
+		v, err := verify.NewSignedEntityVerifier(tm, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1),
+			verify.WithDegradedChecks(verify.DegradableCheckOIDCProvider))
+		require.NoError(t, err)
+		res, err := v.Verify(entity, policy(verify.WithTrustedOIDCProviders()))
+		require.NoError(t, err)
+		require.Len(t, res.Warnings, 1)
+		assert.Equal(t, verify.DegradableCheckOIDCProvider, res.Warnings[0].Check)
+	})
+
+	t.Run("checks that are not degraded still fail", func(t *testing.T) {
+		v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithSignedCertificateTimestamps(1),
+			verify.WithDegradedChecks(verify.DegradableCheckTransparencyLog))
+		require.NoError(t, err)
+		_, err = v.Verify(entity, policy())
+		assert.ErrorContains(t, err, "failed to verify signed certificate timestamp")
+
+		_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("other artifact")), verify.WithoutIdentitiesUnsafe()))
+		assert.Error(t, err)
+	})
+
+	t.Run("no degraded checks", func(t *testing.T) {
+		v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+		require.NoError(t, err)
+		res, err := v.Verify(entity, policy())
+		require.NoError(t, err)
+		assert.False(t, res.Degraded())
+		assert.Contains(t, res.Explain(), "Verification succeeded:")
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithObserverTimestamps(1), verify.WithDegradedChecks())
+		assert.Error(t, err)
+		_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithObserverTimestamps(1), verify.WithDegradedChecks("signature"))
+		assert.Error(t, err)
+		_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.MinTransparencyLogEntries(1)), verify.WithDegradedChecks(verify.DegradableCheckTransparencyLog))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// checkDenylists checks the signing certificate, or the signing key of
+// entities signed with a key, against the denylists of the trusted
+// material, returning an error wrapping root.ErrDenylisted if either is
+// denied.
+func checkDenylists(verificationContent VerificationContent, tm root.TrustedMaterial) error {
+	denylists := root.Denylists(tm)
+	if len(denylists) == 0 {
+		return nil
+	}
+	if leafCert, ok := verificationContent.HasCertificate(); ok {
+		issuer := certificateIssuer(&leafCert, tm)
+		if issuer == nil {
+			return policyNotSatisfied(errors.New("signing certificate was not issued by a trusted certificate authority"))
+		}
+		for _, denylist := range denylists {
+			if err := denylist.CheckCertificate(&leafCert, issuer); err != nil {
+				return policyNotSatisfied(withReason(ReasonDenylisted, fmt.Errorf("signing certificate is not trusted: %w", err)))
+			}
+		}
+		return nil
+	}
+	verifier, err := getSignatureVerifier(verificationContent, tm)
+	if err != nil {
+		return signatureVerifierError(verificationContent, err)
+	}
+	publicKey, err := verifier.PublicKey()
+	if err != nil {
+		return signatureVerifierError(verificationContent, err)
+	}
+	for _, denylist := range denylists {
+		if err := denylist.CheckPublicKey(publicKey); err != nil {
+			return policyNotSatisfied(withReason(ReasonDenylisted, fmt.Errorf("signing key is not trusted: %w", err)))
+		}
+	}
+	return nil
+}
+
+// certificateIssuer returns the certificate of the trusted certificate
+// authority that issued cert, or nil if there is none.
+func certificateIssuer(cert *x509.Certificate, tm root.TrustedMaterial) *x509.Certificate {
+	for _, ca := range tm.FulcioCertificateAuthorities() {
+		for _, candidate := range append([]*x509.Certificate{ca.Root}, ca.Intermediates...) {
+			if candidate != nil && bytes.Equal(cert.RawIssuer, candidate.RawSubject) && cert.CheckSignatureFrom(candidate) == nil {
+				return candidate
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestDenylistedKey(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("key", &sign.PlainData{Data: []byte("hello world")})
+	require.NoError(t, err)
+	keyVerifier, err := virtualSigstore.PublicKeyVerifier("key")
+	require.NoError(t, err)
+	pub, err := keyVerifier.PublicKey()
+	require.NoError(t, err)
+	denylist, err := root.NewDenylist()
+	require.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(root.WithDenylist(virtualSigstore, denylist), verify.WithoutAnyObserverTimestampsInsecure())
+	require.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithoutIdentitiesUnsafe())
+	_, err = verifier.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	require.NoError(t, denylist.AddPublicKey(pub))
+	policy = verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithoutIdentitiesUnsafe())
+	_, err = verifier.Verify(entity, policy)
+	assert.ErrorIs(t, err, root.ErrDenylisted)
+	assert.Equal(t, verify.ErrorClassPolicyNotSatisfied, verify.ClassifyError(err))
+	assert.Contains(t, verify.Reasons(err), verify.ReasonDenylisted)
+}
+
+func TestDenylistedCertificate(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	require.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	require.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	require.True(t, ok)
+
+	denylist, err := root.NewDenylist()
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(root.WithDenylist(virtualSigstore, denylist), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	verifyEntity := func() error {
+		_, err := verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+	assert.NoError(t, verifyEntity())
+
+	// The same serial number from another issuer is not the same
+	// certificate
+	otherSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	require.NoError(t, denylist.AddCertificate(&leafCert, otherSigstore.FulcioCertificateAuthorities()[0].Intermediates[0]))
+	assert.NoError(t, verifyEntity())
+
+	require.NoError(t, denylist.AddCertificate(&leafCert, virtualSigstore.FulcioCertificateAuthorities()[0].Intermediates[0]))
+	err = verifyEntity()
+	assert.ErrorIs(t, err, root.ErrDenylisted)
+	assert.Contains(t, err.Error(), "signing certificate is not trusted")
+	assert.Contains(t, verify.Reasons(err), verify.ReasonDenylisted)
+}
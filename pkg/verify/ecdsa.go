@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// WithStrictECDSASignatures configures the SignedEntityVerifier to reject
+// ECDSA signatures that are not strictly DER-encoded, failing with
+// util.ErrNonCanonicalECDSASignature, for consumers such as some HSMs and
+// TUF implementations that only accept strict encodings. By default,
+// encodings that Go's crypto/ecdsa accepts are accepted.
+func WithStrictECDSASignatures() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.strictECDSASignatures = true
+		return nil
+	}
+}
+
+// WithLowSECDSASignatures configures the SignedEntityVerifier to reject
+// strictly DER-encoded ECDSA signatures with a high s value, failing with
+// util.ErrHighSECDSASignature, so that a signature can't be replaced by the
+// other valid signature of the same content. It implies
+// WithStrictECDSASignatures. Signatures made by sigstore-go's keypairs are
+// low-s; see util.NormalizeECDSASignature for other signers.
+func WithLowSECDSASignatures() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.strictECDSASignatures = true
+		c.lowSECDSASignatures = true
+		return nil
+	}
+}
+
+// checkECDSASignatures checks the encoding of the entity's signatures if
+// the verifier is configured to, and they were made with an ECDSA key. Of
+// a DSSE envelope's signatures, only those made by the verifier's key are
+// checked, since the others may be by keys on other curves.
+func (c *VerifierConfig) checkECDSASignatures(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial) error {
+	if !c.strictECDSASignatures {
+		return nil
+	}
+	verifier, err := getSignatureVerifier(verificationContent, trustedMaterial)
+	if err != nil {
+		return signatureVerifierError(verificationContent, err)
+	}
+	publicKey, err := verifier.PublicKey()
+	if err != nil {
+		return signatureVerifierError(verificationContent, err)
+	}
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil
+	}
+
+	signatures := [][]byte{sigContent.Signature()}
+	if envelope := sigContent.EnvelopeContent(); envelope != nil {
+		signatures, err = envelopeSignaturesByKey(envelope, verifier, publicKey)
+		if err != nil {
+			return cryptographicFailure(err)
+		}
+	}
+	for _, sig := range signatures {
+		if err := util.CheckECDSASignature(sig, ecdsaKey.Curve, c.lowSECDSASignatures); err != nil {
+			return cryptographicFailure(err)
+		}
+	}
+	return nil
+}
+
+// envelopeSignaturesByKey returns the envelope's signatures made by the
+// verifier's key: those with the key's ID, and those without a key ID that
+// the verifier accepts.
+func envelopeSignaturesByKey(envelope EnvelopeContent, verifier signature.Verifier, publicKey crypto.PublicKey) ([][]byte, error) {
+	rawEnvelope := envelope.RawEnvelope()
+	payload, err := rawEnvelope.DecodeB64Payload()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode envelope payload: %w", err)
+	}
+	pae := dsse.PAE(rawEnvelope.PayloadType, payload)
+	keyID, _ := dsse.SHA256KeyID(publicKey)
+
+	var signatures [][]byte
+	for _, sig := range rawEnvelope.Signatures {
+		decoded, err := base64.StdEncoding.DecodeString(sig.Sig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode envelope signature: %w", err)
+		}
+		if sig.KeyID != "" && keyID != "" {
+			if sig.KeyID == keyID {
+				signatures = append(signatures, decoded)
+			}
+			continue
+		}
+		if verifier.VerifySignature(bytes.NewReader(decoded), bytes.NewReader(pae)) == nil {
+			signatures = append(signatures, decoded)
+		}
+	}
+	return signatures, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/util"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+func TestECDSASignatureOptions(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keypair, err := sign.NewPrivateKeyKeypair(key, &sign.PrivateKeyKeypairOptions{Hint: []byte("p256")})
+	require.NoError(t, err)
+	artifact := []byte("Hi, I am an artifact!")
+	pb, err := sign.Bundle(&sign.PlainData{Data: artifact}, keypair, sign.BundleOptions{})
+	require.NoError(t, err)
+
+	sigVerifier, err := root.LoadVerifierWithKeyDetails(&key.PublicKey, protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256)
+	require.NoError(t, err)
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"p256": root.NewExpiringKey(sigVerifier, time.Time{}, time.Time{}),
+	})
+	verifyWithSignature := func(sig []byte, options ...verify.VerifierOption) error {
+		pb.GetMessageSignature().Signature = sig
+		b, err := bundle.NewProtobufBundle(pb)
+		require.NoError(t, err)
+		verifier, err := verify.NewSignedEntityVerifier(tm, append(options, verify.WithoutAnyObserverTimestampsInsecure())...)
+		require.NoError(t, err)
+		_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+
+	// keypairs normalize their signatures to low-s
+	lowSig := pb.GetMessageSignature().GetSignature()
+	assert.NoError(t, verifyWithSignature(lowSig, verify.WithLowSECDSASignatures()))
+
+	r, s, err := util.ParseECDSASignature(lowSig, elliptic.P256())
+	require.NoError(t, err)
+	highSig := marshalECDSASignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+	assert.NoError(t, verifyWithSignature(highSig))
+	assert.NoError(t, verifyWithSignature(highSig, verify.WithStrictECDSASignatures()))
+	assert.ErrorIs(t, verifyWithSignature(highSig, verify.WithLowSECDSASignatures()), util.ErrHighSECDSASignature)
+
+	trailingData := append(append([]byte{}, lowSig...), 0)
+	assert.ErrorIs(t, verifyWithSignature(trailingData, verify.WithStrictECDSASignatures()), util.ErrNonCanonicalECDSASignature)
+}
+
+func TestECDSASignatureOptionsEnvelope(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keypair, err := sign.NewPrivateKeyKeypair(key, &sign.PrivateKeyKeypairOptions{Hint: []byte("p256")})
+	require.NoError(t, err)
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	pb, err := sign.Bundle(&sign.DSSEData{Data: statement, PayloadType: "application/vnd.in-toto+json"}, keypair, sign.BundleOptions{})
+	require.NoError(t, err)
+
+	sigVerifier, err := root.LoadVerifierWithKeyDetails(&key.PublicKey, protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256)
+	require.NoError(t, err)
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"p256": root.NewExpiringKey(sigVerifier, time.Time{}, time.Time{}),
+	})
+	verifyWithSignatures := func(sigs ...[]byte) error {
+		envelope := pb.GetDsseEnvelope()
+		envelope.Signatures = nil
+		for _, sig := range sigs {
+			envelope.Signatures = append(envelope.Signatures, &protodsse.Signature{Sig: sig})
+		}
+		b, err := bundle.NewProtobufBundle(pb)
+		require.NoError(t, err)
+		verifier, err := verify.NewSignedEntityVerifier(tm, verify.WithLowSECDSASignatures(), verify.WithoutAnyObserverTimestampsInsecure())
+		require.NoError(t, err)
+		_, err = verifier.Verify(b, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+	highS := func(sig []byte) []byte {
+		r, s, err := util.ParseECDSASignature(sig, elliptic.P256())
+		require.NoError(t, err)
+		return marshalECDSASignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+	}
+
+	lowSig := pb.GetDsseEnvelope().GetSignatures()[0].GetSig()
+	digest := sha256.Sum256(dsse.PAE("application/vnd.in-toto+json", statement))
+	otherSig, err := ecdsa.SignASN1(rand.Reader, otherKey, digest[:])
+	require.NoError(t, err)
+
+	// Only the signature by the verifier's key is checked
+	assert.NoError(t, verifyWithSignatures(lowSig, highS(otherSig)))
+	assert.ErrorIs(t, verifyWithSignatures(highS(lowSig), otherSig), util.ErrHighSECDSASignature)
+}
+
+func marshalECDSASignature(r, s *big.Int) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(r)
+		b.AddASN1BigInt(s)
+	})
+	return b.BytesOrPanic()
+}
@@ -15,9 +15,121 @@
 package verify
 
 import (
+	"errors"
 	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Verification errors wrap one of the following errors to tell callers why
+// verification failed, which can be checked with errors.Is or
+// ClassifyError. The classes help route failures, e.g. to alert on ones
+// that may be caused by an out of date trusted root, but every class is a
+// failed verification: none of them can be taken as a sign that the entity
+// is genuine.
+var (
+	// ErrUntrustedMaterial means the entity's verification material could
+	// not be tied to the trusted material: no transparency log, CT log,
+	// timestamping authority or public key with the material's key ID, or
+	// no certificate authority that issued the signing certificate, at the
+	// time it was used. An attacker can produce such material at will, e.g.
+	// a self-signed certificate chain or an entry from a log of their own,
+	// so entities that fail with it must be rejected like any other.
+	ErrUntrustedMaterial = errors.New("untrusted verification material")
+	// ErrCryptographicFailure means a check against trusted material
+	// failed: a signature, SET, inclusion proof, SCT or timestamp that does
+	// not verify, a certificate that was not valid when it was used, or
+	// verification material that is inconsistent with the rest of the
+	// entity.
+	ErrCryptographicFailure = errors.New("cryptographic verification failed")
+	// ErrPolicyNotSatisfied means the entity's verification material is
+	// valid, but does not satisfy the verifier's or policy's requirements,
+	// such as an identity, a key hint or a minimum amount of evidence.
+	ErrPolicyNotSatisfied = errors.New("verification policy not satisfied")
+)
+
+// ErrorClass is the reason verification failed, as reported by
+// ClassifyError.
+type ErrorClass int
+
+const (
+	// ErrorClassUnknown is returned for errors that wrap none of the error
+	// classes, such as malformed entities or invalid arguments
+	ErrorClassUnknown ErrorClass = iota
+	ErrorClassUntrustedMaterial
+	ErrorClassCryptographicFailure
+	ErrorClassPolicyNotSatisfied
 )
 
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassUntrustedMaterial:
+		return "untrusted material"
+	case ErrorClassCryptographicFailure:
+		return "cryptographic failure"
+	case ErrorClassPolicyNotSatisfied:
+		return "policy not satisfied"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifyError returns the class of a verification error. An error may
+// wrap more than one class, e.g. when a threshold of log entries is not met
+// because one entry's log is unknown and another's SET is invalid; a
+// cryptographic failure then takes precedence over untrusted material, which
+// takes precedence over an unsatisfied policy.
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ErrorClassUnknown
+	case errors.Is(err, ErrCryptographicFailure):
+		return ErrorClassCryptographicFailure
+	case errors.Is(err, ErrUntrustedMaterial):
+		return ErrorClassUntrustedMaterial
+	case errors.Is(err, ErrPolicyNotSatisfied):
+		return ErrorClassPolicyNotSatisfied
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// classifiedError marks an error with its class without changing its
+// message.
+type classifiedError struct {
+	err   error
+	class error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.err, e.class}
+}
+
+func untrustedMaterial(err error) error {
+	return &classifiedError{err, ErrUntrustedMaterial}
+}
+
+func cryptographicFailure(err error) error {
+	return &classifiedError{err, ErrCryptographicFailure}
+}
+
+func policyNotSatisfied(err error) error {
+	return &classifiedError{err, ErrPolicyNotSatisfied}
+}
+
+// transparencyLogError classifies an error from looking up or verifying
+// against a transparency log of the trusted material.
+func transparencyLogError(err error) error {
+	if errors.Is(err, root.ErrTransparencyLogNotFound) || errors.Is(err, root.ErrTransparencyLogNotValid) {
+		return untrustedMaterial(err)
+	}
+	return cryptographicFailure(err)
+}
+
 type ErrVerification struct {
 	err error
 }
@@ -0,0 +1,71 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestClassifyError(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+
+	identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	assert.NoError(t, err)
+	wrongIdentity, err := verify.NewShortCertificateIdentity("issuer", "bar@example.com", "", "")
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.NoError(t, err)
+	assert.Equal(t, verify.ErrorClassUnknown, verify.ClassifyError(err))
+
+	// the artifact does not match the signature
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader([]byte("other"))), verify.WithCertificateIdentity(identity)))
+	assert.Error(t, err)
+	assert.Equal(t, verify.ErrorClassCryptographicFailure, verify.ClassifyError(err))
+	assert.ErrorIs(t, err, verify.ErrCryptographicFailure)
+
+	// the signature verifies, but the identity is not the expected one
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(wrongIdentity)))
+	assert.Error(t, err)
+	assert.Equal(t, verify.ErrorClassPolicyNotSatisfied, verify.ClassifyError(err))
+
+	// the entity was signed by an instance the trusted material does not know
+	otherVerifier, err := verify.NewSignedEntityVerifier(otherSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	_, err = otherVerifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+	assert.Error(t, err)
+	assert.Equal(t, verify.ErrorClassUntrustedMaterial, verify.ClassifyError(err))
+	assert.ErrorIs(t, err, verify.ErrUntrustedMaterial)
+
+	assert.Equal(t, verify.ErrorClassUnknown, verify.ClassifyError(errors.New("malformed bundle")))
+	assert.Equal(t, "untrusted material", verify.ErrorClassUntrustedMaterial.String())
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ExplanationCheckTimestamp   = "timestamp"
+	ExplanationCheckCertificate = "certificate"
+	ExplanationCheckPublicKey   = "publicKey"
+	ExplanationCheckStatement   = "statement"
+	ExplanationCheckIdentity    = "identity"
+	ExplanationCheckTrustRoot   = "trustedMaterial"
+	ExplanationCheckWarning     = "warning"
+)
+
+// ExplanationStep describes one of the checks that contributed to a
+// successful verification.
+type ExplanationStep struct {
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+}
+
+// Explanation returns a structured account of why verification succeeded:
+// the timestamps that were verified, the certificate or key that signed the
+// entity, the signed statement, and the identity policy that matched. Steps
+// are returned in the order in which Verify performs the checks.
+func (r *VerificationResult) Explanation() []ExplanationStep {
+	steps := []ExplanationStep{}
+
+	for _, ts := range r.VerifiedTimestamps {
+		steps = append(steps, ExplanationStep{Check: ExplanationCheckTimestamp, Detail: explainTimestamp(ts)})
+	}
+
+	if r.Signature != nil {
+		if cert := r.Signature.Certificate; cert != nil {
+			steps = append(steps, ExplanationStep{
+				Check:  ExplanationCheckCertificate,
+				Detail: fmt.Sprintf("certificate chains to a trusted certificate authority, issued by %q", cert.CertificateIssuer),
+			})
+			detail := fmt.Sprintf("certificate subject alternative name is %s %q", cert.SubjectAlternativeName.Type, cert.SubjectAlternativeName.Value)
+			if cert.Issuer != "" {
+				detail += fmt.Sprintf(", OIDC issuer is %q", cert.Issuer)
+			}
+			steps = append(steps, ExplanationStep{Check: ExplanationCheckCertificate, Detail: detail})
+			if r.SCTVerificationSkipped {
+				steps = append(steps, ExplanationStep{
+					Check:  ExplanationCheckCertificate,
+					Detail: "certificate transparency was not verified: SCT verification was explicitly skipped",
+				})
+			}
+		} else if r.Signature.PublicKeyID != nil {
+			steps = append(steps, ExplanationStep{
+				Check:  ExplanationCheckPublicKey,
+				Detail: fmt.Sprintf("signed by trusted public key with hint %q", string(*r.Signature.PublicKeyID)),
+			})
+		}
+	}
+
+	if r.Statement != nil {
+		subjects := make([]string, 0, len(r.Statement.Subject))
+		for _, subject := range r.Statement.Subject {
+			algs := make([]string, 0, len(subject.Digest))
+			for alg := range subject.Digest {
+				algs = append(algs, alg)
+			}
+			sort.Strings(algs)
+			digests := make([]string, 0, len(algs))
+			for _, alg := range algs {
+				digests = append(digests, alg+":"+subject.Digest[alg])
+			}
+			subjects = append(subjects, fmt.Sprintf("%q (%s)", subject.Name, strings.Join(digests, ", ")))
+		}
+		steps = append(steps, ExplanationStep{
+			Check:  ExplanationCheckStatement,
+			Detail: fmt.Sprintf("signed statement has predicate type %q and subjects %s", r.Statement.PredicateType, strings.Join(subjects, ", ")),
+		})
+	}
+
+	if id := r.VerifiedIdentity; id != nil {
+		var matchers []string
+		if id.SubjectAlternativeName.Value != "" {
+			matchers = append(matchers, fmt.Sprintf("subject alternative name %q", id.SubjectAlternativeName.Value))
+		}
+		if regexp := id.SubjectAlternativeName.Regexp.String(); regexp != "" {
+			matchers = append(matchers, fmt.Sprintf("subject alternative name matching /%s/", regexp))
+		}
+		if spiffe := id.SubjectAlternativeName.SPIFFE; spiffe != nil {
+			matchers = append(matchers, fmt.Sprintf("SPIFFE ID %s", spiffe))
+		}
+		if id.Issuer != "" {
+			matchers = append(matchers, fmt.Sprintf("OIDC issuer %q", id.Issuer))
+		}
+		detail := "certificate matched a trusted identity policy"
+		if len(matchers) > 0 {
+			detail += " requiring " + strings.Join(matchers, " and ")
+		}
+		if san := r.VerifiedSubjectAlternativeName; san != nil {
+			detail += fmt.Sprintf(" with %s SAN %q", san.Type, san.Value)
+		}
+		steps = append(steps, ExplanationStep{Check: ExplanationCheckIdentity, Detail: detail})
+	}
+
+	for _, match := range r.TrustedMaterial {
+		steps = append(steps, ExplanationStep{
+			Check:  ExplanationCheckTrustRoot,
+			Detail: fmt.Sprintf("trusted material %q satisfied %s", match.Name, strings.Join(match.Requirements, ", ")),
+		})
+	}
+
+	for _, warning := range r.Warnings {
+		steps = append(steps, ExplanationStep{
+			Check:  ExplanationCheckWarning,
+			Detail: fmt.Sprintf("%s check failed but was allowed to degrade: %s", warning.Check, warning.Message),
+		})
+	}
+
+	return steps
+}
+
+// Explain renders Explanation as a human-readable report, with one line per
+// check, suitable for CLI output.
+func (r *VerificationResult) Explain() string {
+	var b strings.Builder
+	if r.Degraded() {
+		b.WriteString("Verification succeeded with warnings:\n")
+	} else {
+		b.WriteString("Verification succeeded:\n")
+	}
+	for _, step := range r.Explanation() {
+		fmt.Fprintf(&b, "  - [%s] %s\n", step.Check, step.Detail)
+	}
+	return b.String()
+}
+
+func explainTimestamp(ts TimestampVerificationResult) string {
+	at := ts.Timestamp.UTC().Format(time.RFC3339)
+	switch ts.Type {
+	case "Tlog":
+		return fmt.Sprintf("entry integrated into a trusted transparency log at %s", at)
+	case "TimestampAuthority":
+		detail := fmt.Sprintf("signature timestamped by a trusted timestamp authority at %s", at)
+		if ts.RFC3161 != nil {
+			detail += fmt.Sprintf(" (policy %s, serial %s)", ts.RFC3161.Policy, ts.RFC3161.SerialNumber)
+		}
+		return detail
+	case "LeafCert.NotBefore":
+		return fmt.Sprintf("certificate validity start %s used as signing time", at)
+	case "CurrentTime":
+		return fmt.Sprintf("current time %s used as signing time", at)
+	default:
+		return fmt.Sprintf("%s timestamp at %s", ts.Type, at)
+	}
+}
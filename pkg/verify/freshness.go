@@ -0,0 +1,61 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// WithTrustedMaterialMaxAge configures the SignedEntityVerifier to require
+// trusted material that was fetched from TUF at most maxAge ago, and whose
+// TUF metadata has not expired, so that a process whose trusted root stopped
+// refreshing, e.g. a LiveTrustedRoot that can't reach the TUF repository,
+// does not keep trusting keys that may have been rotated or revoked. A
+// maxAge of zero only requires the metadata not to have expired.
+//
+// Entities fail with an untrusted material error wrapping
+// root.ErrStaleTrustedMaterial, or root.ErrUnknownFreshness if the trusted
+// material was not fetched from TUF, such as a trusted root read from a
+// file. The freshness of a root.TrustedMaterialCollection is that of its
+// stalest member.
+func WithTrustedMaterialMaxAge(maxAge time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxAge < 0 {
+			return errors.New("trusted material maximum age must not be negative")
+		}
+		c.requireFreshTrustedMaterial = true
+		c.trustedMaterialMaxAge = maxAge
+		return nil
+	}
+}
+
+// checkFreshness returns an error if the verifier requires fresh trusted
+// material and trustedMaterial is not.
+func (c *VerifierConfig) checkFreshness(trustedMaterial root.TrustedMaterial) error {
+	if !c.requireFreshTrustedMaterial {
+		return nil
+	}
+	freshness := root.TrustedMaterialFreshness(trustedMaterial)
+	if freshness == nil {
+		return untrustedMaterial(withReason(ReasonStaleTrustedMaterial, root.ErrUnknownFreshness))
+	}
+	if err := freshness.Check(time.Now(), c.trustedMaterialMaxAge); err != nil {
+		return untrustedMaterial(withReason(ReasonStaleTrustedMaterial, err))
+	}
+	return nil
+}
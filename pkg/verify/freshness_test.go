@@ -0,0 +1,81 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// fetchedTrustedMaterial is trusted material with a known freshness, as if
+// it had been fetched from TUF.
+type fetchedTrustedMaterial struct {
+	root.TrustedMaterial
+	freshness root.Freshness
+}
+
+func (f *fetchedTrustedMaterial) Freshness() *root.Freshness {
+	return &f.freshness
+}
+
+func TestTrustedMaterialMaxAge(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	require.NoError(t, err)
+
+	verifyEntity := func(trustedMaterial root.TrustedMaterial, options ...verify.VerifierOption) error {
+		options = append(options, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+		verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, options...)
+		require.NoError(t, err)
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+
+	fresh := &fetchedTrustedMaterial{virtualSigstore, root.Freshness{Fetched: time.Now().Add(-time.Hour), Expires: time.Now().Add(time.Hour)}}
+	assert.NoError(t, verifyEntity(fresh, verify.WithTrustedMaterialMaxAge(2*time.Hour)))
+	assert.NoError(t, verifyEntity(fresh, verify.WithTrustedMaterialMaxAge(0)))
+	assert.NoError(t, verifyEntity(root.WithDenylist(fresh, &root.Denylist{}), verify.WithTrustedMaterialMaxAge(2*time.Hour)))
+
+	// Fetched too long ago
+	err = verifyEntity(fresh, verify.WithTrustedMaterialMaxAge(30*time.Minute))
+	assert.ErrorIs(t, err, root.ErrStaleTrustedMaterial)
+	assert.Equal(t, verify.ErrorClassUntrustedMaterial, verify.ClassifyError(err))
+	assert.Contains(t, verify.Reasons(err), verify.ReasonStaleTrustedMaterial)
+
+	// TUF metadata expired
+	expired := &fetchedTrustedMaterial{virtualSigstore, root.Freshness{Fetched: time.Now(), Expires: time.Now().Add(-time.Minute)}}
+	assert.ErrorIs(t, verifyEntity(expired, verify.WithTrustedMaterialMaxAge(0)), root.ErrStaleTrustedMaterial)
+
+	// A collection is as fresh as its stalest member
+	collection := root.TrustedMaterialCollection{fresh, expired}
+	assert.ErrorIs(t, verifyEntity(collection, verify.WithTrustedMaterialMaxAge(2*time.Hour)), root.ErrStaleTrustedMaterial)
+
+	// Trusted material not fetched from TUF
+	assert.NoError(t, verifyEntity(virtualSigstore))
+	assert.ErrorIs(t, verifyEntity(virtualSigstore, verify.WithTrustedMaterialMaxAge(2*time.Hour)), root.ErrUnknownFreshness)
+	assert.ErrorIs(t, verifyEntity(root.TrustedMaterialCollection{fresh, virtualSigstore}, verify.WithTrustedMaterialMaxAge(2*time.Hour)), root.ErrUnknownFreshness)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTrustedMaterialMaxAge(-time.Hour))
+	assert.Error(t, err)
+}
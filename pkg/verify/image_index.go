@@ -0,0 +1,123 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/oci"
+)
+
+// ErrImageIndexVerification is returned by VerifyImageIndex when the
+// attestation of any platform manifest fails to verify.
+var ErrImageIndexVerification = errors.New("image index verification failed")
+
+// ManifestAttestationFunc returns the attestation of a platform manifest
+// in an image index, usually by fetching it from the registry.
+type ManifestAttestationFunc func(manifest oci.Descriptor) (SignedEntity, error)
+
+// PlatformVerificationResult is the outcome of verifying the attestation
+// of one platform manifest in an image index.
+type PlatformVerificationResult struct {
+	// Manifest is the platform manifest, as listed in the index
+	Manifest oci.Descriptor
+	// Result is the verification result, or nil if verification failed
+	Result *VerificationResult
+	// Err is why the attestation could not be fetched or failed to verify
+	Err error
+}
+
+// Platform returns the manifest's platform, e.g. "linux/arm64/v8".
+func (r *PlatformVerificationResult) Platform() string {
+	return r.Manifest.Platform.String()
+}
+
+// ImageIndexVerificationResult holds the outcome of verifying every
+// platform manifest in an image index, in the order of the index.
+type ImageIndexVerificationResult struct {
+	Platforms []PlatformVerificationResult
+}
+
+// Failed returns the platforms whose attestation failed to verify.
+func (r *ImageIndexVerificationResult) Failed() []PlatformVerificationResult {
+	var failed []PlatformVerificationResult
+	for _, platform := range r.Platforms {
+		if platform.Err != nil {
+			failed = append(failed, platform)
+		}
+	}
+	return failed
+}
+
+// VerifyImageIndex verifies the attestations of a multi-arch image, where
+// each platform manifest in the image index has its own attestation, such
+// as build provenance, whose subject is the manifest digest. Each
+// attestation is fetched with attestation and verified against options,
+// which typically hold a certificate identity.
+//
+// Every platform manifest must verify. Nested indexes and the attestation
+// manifests added by docker buildx are not verified. The result holds the
+// outcome of each platform, also when verification fails; the error then
+// wraps ErrImageIndexVerification and the error of each failed platform.
+//
+// The caller is responsible for checking that index is the content of the
+// image index it resolved, i.e. that it matches the index digest.
+func (v *SignedEntityVerifier) VerifyImageIndex(index []byte, attestation ManifestAttestationFunc, options ...PolicyOption) (*ImageIndexVerificationResult, error) {
+	imageIndex, err := oci.ParseImageIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	manifests := imageIndex.PlatformManifests()
+	if len(manifests) == 0 {
+		return nil, errors.New("image index has no platform manifests")
+	}
+
+	result := &ImageIndexVerificationResult{}
+	var errs []error
+	for _, manifest := range manifests {
+		platform := PlatformVerificationResult{Manifest: manifest}
+		platform.Result, platform.Err = v.verifyManifestAttestation(manifest, attestation, options)
+		if platform.Err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", platform.Platform(), manifest.Digest, platform.Err))
+		}
+		result.Platforms = append(result.Platforms, platform)
+	}
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%w: %w", ErrImageIndexVerification, errors.Join(errs...))
+	}
+	return result, nil
+}
+
+func (v *SignedEntityVerifier) verifyManifestAttestation(manifest oci.Descriptor, attestation ManifestAttestationFunc, options []PolicyOption) (*VerificationResult, error) {
+	entity, err := attestation(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("fetching attestation: %w", err)
+	}
+	if entity == nil {
+		return nil, errors.New("manifest has no attestation")
+	}
+
+	// ParseImageIndex has checked that the digest is "<algorithm>:<hex>"
+	algorithm, encoded, _ := strings.Cut(manifest.Digest, ":")
+	digest, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return v.Verify(entity, NewPolicy(WithArtifactDigest(algorithm, digest), options...))
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/oci"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestVerifyImageIndex(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+
+	amd64 := "sha256:" + strings.Repeat("a1", 32)
+	arm64 := "sha256:" + strings.Repeat("b2", 32)
+	index := []byte(`{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": [
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "` + amd64 + `", "size": 100, "platform": {"os": "linux", "architecture": "amd64"}},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "` + arm64 + `", "size": 100, "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:0102", "size": 100, "platform": {"os": "unknown", "architecture": "unknown"}, "annotations": {"vnd.docker.reference.type": "attestation-manifest"}}
+		]
+	}`)
+
+	attest := func(identity, manifestDigest string) verify.SignedEntity {
+		digest, err := hex.DecodeString(strings.TrimPrefix(manifestDigest, "sha256:"))
+		require.NoError(t, err)
+		statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://slsa.dev/provenance/v1","subject":[{"name":"ghcr.io/example/app","digest":{"sha256":"%s"}}],"predicate":{}}`, hex.EncodeToString(digest))
+		entity, err := virtualSigstore.Attest(identity, "issuer", []byte(statement))
+		require.NoError(t, err)
+		return entity
+	}
+	attestations := map[string]verify.SignedEntity{
+		amd64: attest("foo@example.com", amd64),
+		arm64: attest("foo@example.com", arm64),
+	}
+	fetch := func(manifest oci.Descriptor) (verify.SignedEntity, error) {
+		entity, ok := attestations[manifest.Digest]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return entity, nil
+	}
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	require.NoError(t, err)
+
+	// The attestation manifest is not verified
+	result, err := verifier.VerifyImageIndex(index, fetch, verify.WithCertificateIdentity(identity))
+	require.NoError(t, err)
+	require.Len(t, result.Platforms, 2)
+	assert.Equal(t, "linux/amd64", result.Platforms[0].Platform())
+	assert.Equal(t, "linux/arm64/v8", result.Platforms[1].Platform())
+	for _, platform := range result.Platforms {
+		assert.NoError(t, platform.Err)
+		assert.NotNil(t, platform.Result)
+	}
+	assert.Empty(t, result.Failed())
+
+	// An attestation for another manifest, or by another identity, fails
+	// only its platform
+	attestations[arm64] = attestations[amd64]
+	result, err = verifier.VerifyImageIndex(index, fetch, verify.WithCertificateIdentity(identity))
+	assert.ErrorIs(t, err, verify.ErrImageIndexVerification)
+	assert.ErrorContains(t, err, "linux/arm64/v8")
+	require.Len(t, result.Platforms, 2)
+	assert.NoError(t, result.Platforms[0].Err)
+	require.Len(t, result.Failed(), 1)
+	assert.Equal(t, arm64, result.Failed()[0].Manifest.Digest)
+	assert.Nil(t, result.Failed()[0].Result)
+
+	attestations[arm64] = attest("bar@example.com", arm64)
+	_, err = verifier.VerifyImageIndex(index, fetch, verify.WithCertificateIdentity(identity))
+	assert.ErrorIs(t, err, verify.ErrImageIndexVerification)
+
+	// A missing attestation fails its platform
+	delete(attestations, arm64)
+	result, err = verifier.VerifyImageIndex(index, fetch, verify.WithCertificateIdentity(identity))
+	assert.ErrorIs(t, err, verify.ErrImageIndexVerification)
+	assert.ErrorContains(t, result.Failed()[0].Err, "not found")
+
+	// An index without platform manifests is rejected
+	_, err = verifier.VerifyImageIndex([]byte(`{"schemaVersion": 2, "manifests": [{"mediaType": "application/vnd.oci.image.index.v1+json", "digest": "`+amd64+`"}]}`), fetch, verify.WithCertificateIdentity(identity))
+	assert.Error(t, err)
+}
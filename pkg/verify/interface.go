@@ -61,6 +61,14 @@ type SignedEntity interface {
 	VerificationProvider
 }
 
+// BundleVersionProvider is optionally implemented by SignedEntities that
+// are bundles, to report their media type and the version it declares.
+type BundleVersionProvider interface {
+	GetMediaType() string
+	// Version returns the bundle's version, e.g. "v0.3"
+	Version() (string, error)
+}
+
 type VerificationContent interface {
 	CompareKey(any, root.TrustedMaterial) bool
 	ValidAtTime(time.Time, root.TrustedMaterial) bool
@@ -68,6 +76,12 @@ type VerificationContent interface {
 	HasPublicKey() (PublicKeyProvider, bool)
 }
 
+// DetachedSCTProvider is optionally implemented by VerificationContent whose
+// certificate was issued with SCTs that are not embedded in the certificate.
+type DetachedSCTProvider interface {
+	HasDetachedSCTs() ([][]byte, bool)
+}
+
 type SignatureContent interface {
 	Signature() []byte
 	EnvelopeContent() EnvelopeContent
@@ -84,6 +98,14 @@ type MessageSignatureContent interface {
 	Signature() []byte
 }
 
+// DecodedEnvelopeContent is optionally implemented by EnvelopeContent that
+// already holds the envelope's payload and signatures decoded, allowing
+// verification to skip decoding them and the generic DSSE verifier.
+type DecodedEnvelopeContent interface {
+	DecodedPayload() ([]byte, error)
+	DecodedSignatures() ([][]byte, error)
+}
+
 type EnvelopeContent interface {
 	RawEnvelope() *dsse.Envelope
 	Statement() (*in_toto.Statement, error)
@@ -0,0 +1,167 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/metrics"
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Phases of verification, as reported in the phase label of
+// sigstore_verification_phase_duration_seconds.
+const (
+	phaseTransparencyLog = "transparency_log"
+	phaseTimestamps      = "timestamps"
+	phaseCertificate     = "certificate"
+	phaseSignature       = "signature"
+	phasePolicy          = "policy"
+)
+
+// WithMetrics configures the SignedEntityVerifier to record metrics about
+// each call to Verify:
+//
+//   - sigstore_verifications_total, a counter by outcome and policy
+//   - sigstore_verifications_by_log_total, a counter by outcome and the base
+//     URL of each transparency log the entity has an entry in
+//   - sigstore_verifications_by_ca_total, a counter by outcome and the issuer
+//     of the entity's signing certificate
+//   - sigstore_verification_phase_duration_seconds, a histogram of the time
+//     spent in each phase of verification
+//
+// The outcome is "verified" or the failure's class, as returned by
+// ClassifyError, in snake case.
+func WithMetrics(provider metrics.Provider) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if provider == nil {
+			return errors.New("metrics provider must not be nil")
+		}
+		c.metrics = newVerifierMetrics(provider)
+		return nil
+	}
+}
+
+type verifierMetrics struct {
+	verifications      metrics.Counter
+	verificationsByLog metrics.Counter
+	verificationsByCA  metrics.Counter
+	phaseDuration      metrics.Histogram
+}
+
+func newVerifierMetrics(provider metrics.Provider) *verifierMetrics {
+	return &verifierMetrics{
+		verifications: provider.Counter("sigstore_verifications_total",
+			"Signed entity verifications by outcome and policy.", "outcome", "policy"),
+		verificationsByLog: provider.Counter("sigstore_verifications_by_log_total",
+			"Signed entity verifications by outcome and transparency log.", "outcome", "log"),
+		verificationsByCA: provider.Counter("sigstore_verifications_by_ca_total",
+			"Signed entity verifications by outcome and certificate issuer.", "outcome", "ca"),
+		phaseDuration: provider.Histogram("sigstore_verification_phase_duration_seconds",
+			"Time spent in each phase of signed entity verification.", metrics.DurationBuckets, "phase"),
+	}
+}
+
+// observeVerification records the outcome of verifying an entity. The
+// policy is nil if it could not be built.
+func (m *verifierMetrics) observeVerification(entity SignedEntity, policy *PolicyConfig, tm root.TrustedMaterial, err error) {
+	if m == nil {
+		return
+	}
+	outcome := verificationOutcome(err)
+	m.verifications.Add(1, outcome, policyLabel(policy))
+
+	if entries, tlogErr := entity.TlogEntries(); tlogErr == nil {
+		logs := tm.RekorLogs()
+		for _, entry := range entries {
+			log := hex.EncodeToString([]byte(entry.LogKeyID()))
+			if tlog, ok := logs[log]; ok && tlog.BaseURL != "" {
+				log = tlog.BaseURL
+			}
+			m.verificationsByLog.Add(1, outcome, log)
+		}
+	}
+
+	if content, contentErr := entity.VerificationContent(); contentErr == nil {
+		if leafCert, ok := content.HasCertificate(); ok {
+			m.verificationsByCA.Add(1, outcome, leafCert.Issuer.String())
+		}
+	}
+}
+
+func verificationOutcome(err error) string {
+	if err == nil {
+		return "verified"
+	}
+	switch ClassifyError(err) {
+	case ErrorClassUntrustedMaterial:
+		return "untrusted_material"
+	case ErrorClassCryptographicFailure:
+		return "cryptographic_failure"
+	case ErrorClassPolicyNotSatisfied:
+		return "policy_not_satisfied"
+	default:
+		return "error"
+	}
+}
+
+func policyLabel(policy *PolicyConfig) string {
+	switch {
+	case policy == nil:
+		return "invalid"
+	case policy.keyHint != "":
+		return "key_hint"
+	case policy.WeExpectIdentities():
+		return "certificate_identity"
+	default:
+		return "none"
+	}
+}
+
+// phaseTimer measures the duration of consecutive phases of verification.
+// A nil phaseTimer records nothing.
+type phaseTimer struct {
+	histogram metrics.Histogram
+	phase     string
+	start     time.Time
+}
+
+func (m *verifierMetrics) newPhaseTimer() *phaseTimer {
+	if m == nil {
+		return nil
+	}
+	return &phaseTimer{histogram: m.phaseDuration}
+}
+
+// begin ends the current phase, if any, and starts the next.
+func (p *phaseTimer) begin(phase string) {
+	if p == nil {
+		return
+	}
+	p.end()
+	p.phase = phase
+	p.start = time.Now()
+}
+
+// end records the duration of the current phase, if any.
+func (p *phaseTimer) end() {
+	if p == nil || p.phase == "" {
+		return
+	}
+	p.histogram.Observe(time.Since(p.start).Seconds(), p.phase)
+	p.phase = ""
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/metrics"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestVerifierMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	v, err := verify.NewSignedEntityVerifier(data.PublicGoodTrustedMaterialRoot(t), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithMetrics(registry))
+	require.NoError(t, err)
+	_, err = v.Verify(data.SigstoreJS200ProvenanceBundle(t), SkipArtifactAndIdentitiesPolicy)
+	require.NoError(t, err)
+
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("my-key", &sign.PlainData{Data: []byte("hello world")})
+	require.NoError(t, err)
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithMetrics(registry))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("goodbye world")), verify.WithKeyHint("my-key")))
+	require.Error(t, err)
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world"))))
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.WriteText(&buf))
+	text := buf.String()
+	assert.Contains(t, text, `sigstore_verifications_total{outcome="verified",policy="none"} 1`)
+	assert.Contains(t, text, `sigstore_verifications_total{outcome="cryptographic_failure",policy="key_hint"} 1`)
+	assert.Contains(t, text, `sigstore_verifications_total{outcome="error",policy="invalid"} 1`)
+	assert.Contains(t, text, `sigstore_verifications_by_log_total{outcome="verified",log="https://rekor.sigstore.dev"} 1`)
+	assert.Contains(t, text, `sigstore_verifications_by_ca_total{outcome="verified",ca="CN=sigstore-intermediate,O=sigstore.dev"} 1`)
+	assert.Contains(t, text, `sigstore_verification_phase_duration_seconds_count{phase="transparency_log"} 2`)
+	assert.Contains(t, text, `sigstore_verification_phase_duration_seconds_count{phase="certificate"} 1`)
+	// the failed signature phase is measured too, but verification stops
+	// before the policy phase
+	assert.Contains(t, text, `sigstore_verification_phase_duration_seconds_count{phase="signature"} 2`)
+	assert.Contains(t, text, `sigstore_verification_phase_duration_seconds_count{phase="policy"} 1`)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithMetrics(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+const (
+	// RequirementSigner is satisfied by trusted material containing the
+	// certificate authority or public key that signed the entity
+	RequirementSigner = "signer"
+	// RequirementTransparencyLog is satisfied by trusted material containing
+	// the transparency logs the entity's log entries were verified with
+	RequirementTransparencyLog = "transparencyLog"
+	// RequirementSignedTimestamp is satisfied by trusted material containing
+	// the timestamp authorities the entity's timestamps were verified with
+	RequirementSignedTimestamp = "signedTimestamp"
+	// RequirementCertificateTransparency is satisfied by trusted material
+	// containing the CT logs the certificate's SCTs were verified with
+	RequirementCertificateTransparency = "certificateTransparency"
+)
+
+// TrustedMaterialMatch records which verification requirements a trusted
+// material satisfied.
+type TrustedMaterialMatch struct {
+	Name         string   `json:"name"`
+	Requirements []string `json:"requirements"`
+}
+
+// MultiTrustedMaterialVerifier verifies signed entities against several
+// named trusted materials, combined according to a
+// root.TrustedMaterialCollectionMode.
+type MultiTrustedMaterialVerifier struct {
+	mode      root.TrustedMaterialCollectionMode
+	names     []string
+	verifiers []*SignedEntityVerifier
+}
+
+// NewMultiTrustedMaterialVerifier creates a verifier for the given trusted
+// materials. Each trusted material is verified with the same options, which
+// have the same meaning as for NewSignedEntityVerifier.
+func NewMultiTrustedMaterialVerifier(mode root.TrustedMaterialCollectionMode, trustedMaterials []root.NamedTrustedMaterial, options ...VerifierOption) (*MultiTrustedMaterialVerifier, error) {
+	if len(trustedMaterials) == 0 {
+		return nil, errors.New("at least one trusted material is required")
+	}
+
+	v := &MultiTrustedMaterialVerifier{mode: mode}
+	seen := make(map[string]bool)
+	for _, tm := range trustedMaterials {
+		if tm.TrustedMaterial == nil {
+			return nil, fmt.Errorf("trusted material %q is nil", tm.Name)
+		}
+		if seen[tm.Name] {
+			return nil, fmt.Errorf("duplicate trusted material name %q", tm.Name)
+		}
+		seen[tm.Name] = true
+		v.names = append(v.names, tm.Name)
+	}
+
+	switch mode {
+	case root.TrustedMaterialUnion:
+		collection := make(root.TrustedMaterialCollection, 0, len(trustedMaterials))
+		for _, tm := range trustedMaterials {
+			collection = append(collection, tm.TrustedMaterial)
+		}
+		verifier, err := NewSignedEntityVerifier(collection, options...)
+		if err != nil {
+			return nil, err
+		}
+		v.verifiers = []*SignedEntityVerifier{verifier}
+	case root.TrustedMaterialFirstMatch, root.TrustedMaterialAllMustPass:
+		for _, tm := range trustedMaterials {
+			verifier, err := NewSignedEntityVerifier(tm.TrustedMaterial, options...)
+			if err != nil {
+				return nil, err
+			}
+			v.verifiers = append(v.verifiers, verifier)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported trusted material collection mode %s", mode)
+	}
+
+	return v, nil
+}
+
+// Verify verifies the entity as SignedEntityVerifier.Verify does, against
+// the trusted materials as required by the verifier's mode. The returned
+// result's TrustedMaterial field lists the trusted materials that satisfied
+// the requirements. In union mode, where requirements may be satisfied by
+// different members, a single match naming all members is reported.
+//
+// In first match mode, the result is that of the first trusted material to
+// verify the entity. In all must pass mode, it is that of the first trusted
+// material, with matches for every trusted material.
+func (v *MultiTrustedMaterialVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*VerificationResult, error) {
+	policy, err := pb.BuildConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build policy: %w", err)
+	}
+
+	// The artifact may be read once per trusted material
+	var artifact []byte
+	if policy.verifyArtifact && policy.artifact != nil {
+		artifact, err = io.ReadAll(policy.artifact)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read artifact: %w", err)
+		}
+	}
+	policyFor := func() *PolicyConfig {
+		p := *policy
+		if artifact != nil {
+			p.artifact = bytes.NewReader(artifact)
+		}
+		return &p
+	}
+
+	if v.mode == root.TrustedMaterialUnion {
+		result, err := v.verifiers[0].verify(entity, policyFor())
+		if err != nil {
+			return nil, err
+		}
+		result.TrustedMaterial = []TrustedMaterialMatch{{
+			Name:         strings.Join(v.names, "+"),
+			Requirements: v.verifiers[0].satisfiedRequirements(result),
+		}}
+		return result, nil
+	}
+
+	var result *VerificationResult
+	var errs []error
+	for i, verifier := range v.verifiers {
+		memberResult, err := verifier.verify(entity, policyFor())
+		if err != nil {
+			err = fmt.Errorf("trusted material %q: %w", v.names[i], err)
+			if v.mode == root.TrustedMaterialAllMustPass {
+				return nil, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		if result == nil {
+			result = memberResult
+		} else {
+			result.Warnings = append(result.Warnings, memberResult.Warnings...)
+			for _, service := range memberResult.ServicesUsed {
+				result.ServicesUsed = addTrustedService(result.ServicesUsed, service)
+			}
+		}
+		result.TrustedMaterial = append(result.TrustedMaterial, TrustedMaterialMatch{
+			Name:         v.names[i],
+			Requirements: verifier.satisfiedRequirements(memberResult),
+		})
+
+		if v.mode == root.TrustedMaterialFirstMatch {
+			return result, nil
+		}
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("no trusted material verified the entity: %w", errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// satisfiedRequirements lists the requirements a successful verification
+// checked against the verifier's trusted material.
+func (v *SignedEntityVerifier) satisfiedRequirements(result *VerificationResult) []string {
+	requirements := []string{RequirementSigner}
+	if v.config.weExpectTlogEntries && !result.failedCheck(DegradableCheckTransparencyLog) {
+		requirements = append(requirements, RequirementTransparencyLog)
+	}
+	if v.config.weExpectSignedTimestamps {
+		requirements = append(requirements, RequirementSignedTimestamp)
+	}
+	if v.config.weExpectSCTs && result.Signature != nil && result.Signature.Certificate != nil && !result.failedCheck(DegradableCheckSignedCertificateTimestamps) {
+		requirements = append(requirements, RequirementCertificateTransparency)
+	}
+	if v.config.evidenceRequirement != nil {
+		requirements = append(requirements, v.config.evidenceRequirement.String())
+	}
+	return requirements
+}
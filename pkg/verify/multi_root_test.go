@@ -0,0 +1,102 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiTrustedMaterialVerifier(t *testing.T) {
+	publicGood, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	internal, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "test artifact"
+	entity, err := publicGood.Sign("foofighters@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	trustedMaterials := []root.NamedTrustedMaterial{
+		{Name: "internal", TrustedMaterial: internal},
+		{Name: "public-good", TrustedMaterial: publicGood},
+	}
+	policy := func() verify.PolicyBuilder {
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewBufferString(artifact)), verify.WithoutIdentitiesUnsafe())
+	}
+	opts := []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1)}
+
+	verifier, err := verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialFirstMatch, trustedMaterials, opts...)
+	assert.NoError(t, err)
+	result, err := verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+	assert.Equal(t, []verify.TrustedMaterialMatch{{Name: "public-good", Requirements: []string{verify.RequirementSigner, verify.RequirementTransparencyLog}}}, result.TrustedMaterial)
+	assert.Contains(t, result.Explain(), `trusted material "public-good" satisfied signer, transparencyLog`)
+
+	verifier, err = verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialAllMustPass, trustedMaterials, opts...)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy())
+	assert.ErrorContains(t, err, `trusted material "internal"`)
+
+	verifier, err = verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialAllMustPass, trustedMaterials[1:], opts...)
+	assert.NoError(t, err)
+	result, err = verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+	assert.Len(t, result.TrustedMaterial, 1)
+
+	verifier, err = verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialUnion, trustedMaterials, opts...)
+	assert.NoError(t, err)
+	result, err = verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+	assert.Equal(t, "internal+public-good", result.TrustedMaterial[0].Name)
+
+	// No member verifies the entity
+	verifier, err = verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialFirstMatch, trustedMaterials[:1], opts...)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy())
+	assert.ErrorContains(t, err, "no trusted material verified the entity")
+
+	_, err = verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialFirstMatch, nil, opts...)
+	assert.Error(t, err)
+	_, err = verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialFirstMatch, []root.NamedTrustedMaterial{trustedMaterials[0], trustedMaterials[0]}, opts...)
+	assert.ErrorContains(t, err, "duplicate")
+}
+
+func TestMultiTrustedMaterialVerifierAllMustPass(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "test artifact"
+	entity, err := virtualSigstore.Sign("foofighters@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	// The same trusted material under two names; the artifact must be
+	// re-read for each of them
+	verifier, err := verify.NewMultiTrustedMaterialVerifier(root.TrustedMaterialAllMustPass, []root.NamedTrustedMaterial{
+		{Name: "a", TrustedMaterial: virtualSigstore},
+		{Name: "b", TrustedMaterial: virtualSigstore},
+	}, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+
+	result, err := verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewBufferString(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+	assert.Len(t, result.TrustedMaterial, 2)
+	assert.Equal(t, "b", result.TrustedMaterial[1].Name)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// ErrOnlineOption is returned by NewOfflineSignedEntityVerifier when it is
+// given an option or trusted material that would access the network.
+var ErrOnlineOption = errors.New("offline verifier cannot use the network")
+
+// NewOfflineSignedEntityVerifier creates a SignedEntityVerifier that never
+// accesses the network, for air-gapped environments and builds that must
+// show they do not depend on it. It takes the same options as
+// NewSignedEntityVerifier, but fails with ErrOnlineOption if they include
+// WithOnlineVerification, or if the trusted material, any member of a
+// root.TrustedMaterialCollection or any trusted material wrapped by a
+// root.WrappedTrustedMaterial, such as one returned by root.WithDenylist,
+// is a root.LiveTrustedRoot, which refreshes itself from TUF.
+//
+// Everything the verifier checks then comes from the entity and the trusted
+// material: transparency log entries are verified with their inclusion
+// proofs and promises instead of being fetched from the log, and no socket
+// is opened during verification. Trusted material implemented outside this
+// module must not access the network itself.
+func NewOfflineSignedEntityVerifier(trustedMaterial root.TrustedMaterial, options ...VerifierOption) (*SignedEntityVerifier, error) {
+	if accessesNetwork(trustedMaterial) {
+		return nil, fmt.Errorf("%w: trusted material is refreshed from TUF; use a static root.TrustedRoot", ErrOnlineOption)
+	}
+	v, err := NewSignedEntityVerifier(trustedMaterial, options...)
+	if err != nil {
+		return nil, err
+	}
+	if v.config.performOnlineVerification {
+		return nil, fmt.Errorf("%w: WithOnlineVerification() queries transparency logs", ErrOnlineOption)
+	}
+	return v, nil
+}
+
+// accessesNetwork returns true if the trusted material is known to access
+// the network.
+func accessesNetwork(trustedMaterial root.TrustedMaterial) bool {
+	switch tm := trustedMaterial.(type) {
+	case *root.LiveTrustedRoot:
+		return true
+	case root.TrustedMaterialCollection:
+		for _, member := range tm {
+			if accessesNetwork(member) {
+				return true
+			}
+		}
+	case root.WrappedTrustedMaterial:
+		return accessesNetwork(tm.Unwrap())
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestNewOfflineSignedEntityVerifier(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	require.NoError(t, err)
+
+	v, err := verify.NewOfflineSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	_, err = verify.NewOfflineSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithOnlineVerification())
+	assert.ErrorIs(t, err, verify.ErrOnlineOption)
+
+	denylist, err := root.NewDenylist()
+	require.NoError(t, err)
+	liveRoot := &root.LiveTrustedRoot{}
+	for _, trustedMaterial := range []root.TrustedMaterial{
+		liveRoot,
+		root.TrustedMaterialCollection{virtualSigstore, liveRoot},
+		root.TrustedMaterialCollection{root.NamedTrustedMaterial{Name: "live", TrustedMaterial: liveRoot}},
+		&root.NamedTrustedMaterial{Name: "live", TrustedMaterial: liveRoot},
+		root.WithOIDCProviders(liveRoot, root.OIDCProvider{}),
+		root.WithDenylist(liveRoot, denylist),
+		root.WithDenylist(root.WithOIDCProviders(liveRoot), denylist),
+	} {
+		_, err = verify.NewOfflineSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+		assert.ErrorIs(t, err, verify.ErrOnlineOption)
+	}
+
+	// Wrapped static trusted material is accepted
+	_, err = verify.NewOfflineSignedEntityVerifier(root.WithDenylist(root.WithOIDCProviders(virtualSigstore), denylist), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+
+	// Other configuration errors are reported as by NewSignedEntityVerifier
+	_, err = verify.NewOfflineSignedEntityVerifier(virtualSigstore)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, verify.ErrOnlineOption)
+}
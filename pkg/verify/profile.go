@@ -0,0 +1,148 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Names of the profiles returned by GetProfile.
+const (
+	ProfileCosign                     = "cosign"
+	ProfileNPM                        = "npm"
+	ProfileGitHubArtifactAttestations = "github-artifact-attestations"
+)
+
+const (
+	githubActionsIssuer = "https://token.actions.githubusercontent.com"
+	gitlabIssuer        = "https://gitlab.com"
+)
+
+// Profile is the verifier configuration and identity expectations of an
+// ecosystem that produces Sigstore bundles, so that its bundles can be
+// verified without choosing each option, and without forgetting one, such
+// as observer timestamps for bundles that are only timestamped by a TSA.
+type Profile struct {
+	Name        string
+	Description string
+	// VerifierOptions are the options for NewSignedEntityVerifier
+	VerifierOptions []VerifierOption
+	// Issuers are the OIDC issuers of the ecosystem's signing certificates.
+	// If empty, an issuer must be given for each certificate identity.
+	Issuers []string
+}
+
+var profiles = map[string]func() Profile{
+	ProfileCosign: func() Profile {
+		return Profile{
+			Name:        ProfileCosign,
+			Description: "The defaults of cosign verify and verify-blob: an SCT, a transparency log entry and an observer timestamp",
+			VerifierOptions: []VerifierOption{
+				WithSignedCertificateTimestamps(1),
+				WithTransparencyLog(1),
+				WithObserverTimestamps(1),
+			},
+		}
+	},
+	ProfileNPM: func() Profile {
+		return Profile{
+			Name:        ProfileNPM,
+			Description: "npm provenance and publish attestations: an SCT and a transparency log entry with its integrated time, signed in GitHub Actions or GitLab CI",
+			VerifierOptions: []VerifierOption{
+				WithSignedCertificateTimestamps(1),
+				WithTransparencyLog(1),
+				WithIntegratedTimestamps(1),
+			},
+			Issuers: []string{githubActionsIssuer, gitlabIssuer},
+		}
+	},
+	ProfileGitHubArtifactAttestations: func() Profile {
+		return Profile{
+			Name: ProfileGitHubArtifactAttestations,
+			Description: "GitHub artifact attestations: from public repositories, an SCT and a transparency log entry with its integrated time on the public good instance; " +
+				"from private repositories, a signed timestamp from GitHub's instance, which has no transparency log",
+			VerifierOptions: []VerifierOption{
+				WithEvidenceRequirement(AnyOf(
+					AllOf(MinSignedCertificateTimestamps(1), MinTransparencyLogEntries(1), MinIntegratedTimestamps(1)),
+					MinSignedTimestamps(1),
+				)),
+			},
+			Issuers: []string{githubActionsIssuer},
+		}
+	},
+}
+
+// GetProfile returns a profile by name, e.g. ProfileGitHubArtifactAttestations.
+func GetProfile(name string) (Profile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown verification profile %q", name)
+	}
+	return profile(), nil
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewVerifier creates a SignedEntityVerifier with the profile's options,
+// followed by options, which may add e.g. WithLogger but should not
+// repeat the thresholds the profile sets.
+func (p Profile) NewVerifier(trustedMaterial root.TrustedMaterial, options ...VerifierOption) (*SignedEntityVerifier, error) {
+	return NewSignedEntityVerifier(trustedMaterial, append(append([]VerifierOption{}, p.VerifierOptions...), options...)...)
+}
+
+// CertificateIdentities returns a certificate identity with the given SAN
+// value or regular expression for each of the profile's issuers, which
+// are passed to WithCertificateIdentity. The SAN identifies the signer
+// within the ecosystem, e.g. the workflow that built an artifact.
+func (p Profile) CertificateIdentities(sanValue, sanRegex string) (CertificateIdentities, error) {
+	if len(p.Issuers) == 0 {
+		return nil, errors.New("verification profile has no issuers; use NewShortCertificateIdentity with an issuer")
+	}
+	identities := make(CertificateIdentities, 0, len(p.Issuers))
+	for _, issuer := range p.Issuers {
+		identity, err := NewShortCertificateIdentity(issuer, sanValue, "", sanRegex)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// PolicyOptions returns the policy options requiring one of the profile's
+// CertificateIdentities.
+func (p Profile) PolicyOptions(sanValue, sanRegex string) ([]PolicyOption, error) {
+	identities, err := p.CertificateIdentities(sanValue, sanRegex)
+	if err != nil {
+		return nil, err
+	}
+	options := make([]PolicyOption, 0, len(identities))
+	for _, identity := range identities {
+		options = append(options, WithCertificateIdentity(identity))
+	}
+	return options, nil
+}
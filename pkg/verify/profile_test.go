@@ -0,0 +1,85 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestProfiles(t *testing.T) {
+	assert.Equal(t, []string{verify.ProfileCosign, verify.ProfileGitHubArtifactAttestations, verify.ProfileNPM}, verify.ProfileNames())
+	_, err := verify.GetProfile("unknown")
+	assert.Error(t, err)
+
+	// A bundle from the public good instance satisfies every profile
+	trustedMaterial := data.PublicGoodTrustedMaterialRoot(t)
+	entity := data.SigstoreJS200ProvenanceBundle(t)
+	san := "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"
+	for _, name := range verify.ProfileNames() {
+		profile, err := verify.GetProfile(name)
+		require.NoError(t, err)
+		v, err := profile.NewVerifier(trustedMaterial)
+		require.NoError(t, err)
+
+		var policyOptions []verify.PolicyOption
+		if name == verify.ProfileCosign {
+			_, err = profile.CertificateIdentities(san, "")
+			assert.Error(t, err)
+			identity, err := verify.NewShortCertificateIdentity("https://token.actions.githubusercontent.com", san, "", "")
+			require.NoError(t, err)
+			policyOptions = []verify.PolicyOption{verify.WithCertificateIdentity(identity)}
+		} else {
+			policyOptions, err = profile.PolicyOptions(san, "")
+			require.NoError(t, err)
+		}
+		_, err = v.Verify(entity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), policyOptions...))
+		assert.NoError(t, err, name)
+
+		policyOptions, err = profile.PolicyOptions("", "^https://github.com/other/")
+		if err == nil {
+			_, err = v.Verify(entity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), policyOptions...))
+			assert.Error(t, err, name)
+		}
+	}
+
+	// Bundles from GitHub's instance have signed timestamps, but no SCTs
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	tsaOnly, err := virtualSigstore.Sign("https://github.com/owner/repo/.github/workflows/build.yml@refs/heads/main", "https://token.actions.githubusercontent.com", []byte("artifact"))
+	require.NoError(t, err)
+	github, err := verify.GetProfile(verify.ProfileGitHubArtifactAttestations)
+	require.NoError(t, err)
+	v, err := github.NewVerifier(virtualSigstore)
+	require.NoError(t, err)
+	policyOptions, err := github.PolicyOptions("", "^https://github.com/owner/repo/")
+	require.NoError(t, err)
+	_, err = v.Verify(tsaOnly, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), policyOptions...))
+	assert.NoError(t, err)
+
+	cosign, err := verify.GetProfile(verify.ProfileCosign)
+	require.NoError(t, err)
+	v, err = cosign.NewVerifier(virtualSigstore)
+	require.NoError(t, err)
+	_, err = v.Verify(tsaOnly, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// RawSignatureOptions configures VerifyRawSignature.
+type RawSignatureOptions struct {
+	// KeyHint identifies the public key in the trusted material, as in the
+	// public key verification material of a bundle
+	KeyHint string
+	// Signature is the raw signature over the artifact
+	Signature []byte
+
+	// The signed artifact, given as exactly one of a reader or a digest
+	// with its algorithm (e.g. "sha256")
+	Artifact        io.Reader
+	Digest          []byte
+	DigestAlgorithm string
+
+	// Time at which the key must be valid. Without a transparency log entry
+	// or signed timestamp there is no trusted signing time, so this defaults
+	// to the current time.
+	Time time.Time
+}
+
+// VerifyRawSignature verifies a signature that is not in a bundle, using a
+// public key from the trusted material. It is intended for systems that
+// still distribute detached signatures while migrating to bundles.
+//
+// Only the signature is checked: there is no certificate, transparency log
+// entry or signed timestamp, so none of the guarantees they provide apply.
+// Bundles should be verified with a SignedEntityVerifier instead.
+func VerifyRawSignature(trustedMaterial root.TrustedMaterial, opts RawSignatureOptions) error {
+	if opts.KeyHint == "" {
+		return errors.New("key hint must be provided")
+	}
+	if len(opts.Signature) == 0 {
+		return errors.New("signature must be provided")
+	}
+	if (opts.Artifact == nil) == (opts.Digest == nil) {
+		return errors.New("exactly one of an artifact or a digest must be provided")
+	}
+
+	verifier, err := trustedMaterial.PublicKeyVerifier(opts.KeyHint)
+	if err != nil {
+		return untrustedMaterial(fmt.Errorf("could not load signature verifier: %w", err))
+	}
+	verifyTime := opts.Time
+	if verifyTime.IsZero() {
+		verifyTime = time.Now()
+	}
+	if !verifier.ValidAtTime(verifyTime) {
+		return untrustedMaterial(fmt.Errorf("public key %s is not valid at %s", opts.KeyHint, verifyTime.Format(time.RFC3339)))
+	}
+
+	msg := &rawMessageSignature{signature: opts.Signature, digest: opts.Digest, digestAlgorithm: opts.DigestAlgorithm}
+	if opts.Artifact != nil {
+		return signatureError(verifyMessageSignature(verifier, msg, opts.Artifact))
+	}
+	return signatureError(verifyMessageSignatureWithArtifactDigest(verifier, msg, opts.Digest))
+}
+
+// rawMessageSignature is the MessageSignatureContent of a raw signature.
+// Its digest is the caller's, so that it always matches the artifact digest.
+type rawMessageSignature struct {
+	signature       []byte
+	digest          []byte
+	digestAlgorithm string
+}
+
+func (r *rawMessageSignature) Digest() []byte {
+	return r.digest
+}
+
+func (r *rawMessageSignature) DigestAlgorithm() string {
+	return r.digestAlgorithm
+}
+
+func (r *rawMessageSignature) Signature() []byte {
+	return r.signature
+}
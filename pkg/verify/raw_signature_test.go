@@ -0,0 +1,102 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestVerifyRawSignature(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sigVerifier, err := signature.LoadVerifier(privateKey.Public(), crypto.SHA256)
+	require.NoError(t, err)
+
+	artifact := []byte("legacy artifact")
+	digest := sha256.Sum256(artifact)
+	sig, err := ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+	require.NoError(t, err)
+
+	now := time.Now()
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"legacy-key": root.NewExpiringKey(sigVerifier, now.Add(-time.Hour), now.Add(time.Hour)),
+	})
+
+	// with the artifact
+	err = verify.VerifyRawSignature(tm, verify.RawSignatureOptions{
+		KeyHint:   "legacy-key",
+		Signature: sig,
+		Artifact:  bytes.NewReader(artifact),
+	})
+	assert.NoError(t, err)
+
+	// with the artifact digest
+	err = verify.VerifyRawSignature(tm, verify.RawSignatureOptions{
+		KeyHint:         "legacy-key",
+		Signature:       sig,
+		Digest:          digest[:],
+		DigestAlgorithm: "sha256",
+	})
+	assert.NoError(t, err)
+
+	// with the wrong artifact
+	err = verify.VerifyRawSignature(tm, verify.RawSignatureOptions{
+		KeyHint:   "legacy-key",
+		Signature: sig,
+		Artifact:  bytes.NewReader([]byte("other artifact")),
+	})
+	assert.True(t, errors.Is(err, verify.ErrCryptographicFailure))
+
+	// with an unknown key
+	err = verify.VerifyRawSignature(tm, verify.RawSignatureOptions{
+		KeyHint:   "other-key",
+		Signature: sig,
+		Artifact:  bytes.NewReader(artifact),
+	})
+	assert.True(t, errors.Is(err, verify.ErrUntrustedMaterial))
+
+	// at a time the key is not valid
+	err = verify.VerifyRawSignature(tm, verify.RawSignatureOptions{
+		KeyHint:   "legacy-key",
+		Signature: sig,
+		Artifact:  bytes.NewReader(artifact),
+		Time:      now.Add(2 * time.Hour),
+	})
+	assert.True(t, errors.Is(err, verify.ErrUntrustedMaterial))
+
+	// with both an artifact and a digest
+	err = verify.VerifyRawSignature(tm, verify.RawSignatureOptions{
+		KeyHint:   "legacy-key",
+		Signature: sig,
+		Artifact:  bytes.NewReader(artifact),
+		Digest:    digest[:],
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Evidence counts the verification material of an entity that verified
+// against the trusted material.
+type Evidence struct {
+	// TransparencyLogEntries is the number of verified log entries
+	TransparencyLogEntries int
+	// IntegratedTimestamps is the number of log entry integrated timestamps
+	// vouched for by a verified SignedEntryTimestamp
+	IntegratedTimestamps int
+	// SignedTimestamps is the number of verified RFC 3161 timestamps
+	SignedTimestamps int
+	// SignedCertificateTimestamps is the number of verified SCTs of the
+	// signing certificate
+	SignedCertificateTimestamps int
+}
+
+// ObserverTimestamps returns the number of verified timestamps of either
+// kind.
+func (e Evidence) ObserverTimestamps() int {
+	return e.IntegratedTimestamps + e.SignedTimestamps
+}
+
+func (e Evidence) String() string {
+	return fmt.Sprintf("%d transparency log entries, %d integrated timestamps, %d signed timestamps, %d SCTs",
+		e.TransparencyLogEntries, e.IntegratedTimestamps, e.SignedTimestamps, e.SignedCertificateTimestamps)
+}
+
+// EvidenceRequirement decides whether the verified evidence of an entity is
+// sufficient. Requirements are built from thresholds on each kind of
+// evidence, such as MinTransparencyLogEntries, combined with AllOf, AnyOf
+// and AtLeast. For example, requiring a log entry and a signed timestamp, or
+// two signed timestamps:
+//
+//	verify.AnyOf(
+//		verify.AllOf(verify.MinTransparencyLogEntries(1), verify.MinSignedTimestamps(1)),
+//		verify.MinSignedTimestamps(2),
+//	)
+type EvidenceRequirement interface {
+	Satisfied(Evidence) bool
+	String() string
+}
+
+type minEvidence struct {
+	name      string
+	threshold int
+	count     func(Evidence) int
+}
+
+func (r minEvidence) Satisfied(e Evidence) bool {
+	return r.count(e) >= r.threshold
+}
+
+func (r minEvidence) String() string {
+	return fmt.Sprintf("%d %s", r.threshold, r.name)
+}
+
+// MinTransparencyLogEntries requires at least threshold verified
+// transparency log entries.
+func MinTransparencyLogEntries(threshold int) EvidenceRequirement {
+	return minEvidence{"transparency log entries", threshold, func(e Evidence) int { return e.TransparencyLogEntries }}
+}
+
+// MinIntegratedTimestamps requires at least threshold verified log entry
+// integrated timestamps.
+func MinIntegratedTimestamps(threshold int) EvidenceRequirement {
+	return minEvidence{"integrated timestamps", threshold, func(e Evidence) int { return e.IntegratedTimestamps }}
+}
+
+// MinSignedTimestamps requires at least threshold verified RFC 3161
+// timestamps.
+func MinSignedTimestamps(threshold int) EvidenceRequirement {
+	return minEvidence{"signed timestamps", threshold, func(e Evidence) int { return e.SignedTimestamps }}
+}
+
+// MinObserverTimestamps requires at least threshold verified timestamps,
+// counting both integrated and signed timestamps.
+func MinObserverTimestamps(threshold int) EvidenceRequirement {
+	return minEvidence{"observer timestamps", threshold, Evidence.ObserverTimestamps}
+}
+
+// MinSignedCertificateTimestamps requires at least threshold verified SCTs.
+// Entities signed with a key rather than a certificate have none.
+func MinSignedCertificateTimestamps(threshold int) EvidenceRequirement {
+	return minEvidence{"SCTs", threshold, func(e Evidence) int { return e.SignedCertificateTimestamps }}
+}
+
+type atLeast struct {
+	name         string
+	n            int
+	requirements []EvidenceRequirement
+}
+
+func (r atLeast) Satisfied(e Evidence) bool {
+	satisfied := 0
+	for _, req := range r.requirements {
+		if req.Satisfied(e) {
+			satisfied++
+		}
+	}
+	return satisfied >= r.n
+}
+
+func (r atLeast) String() string {
+	names := make([]string, len(r.requirements))
+	for i, req := range r.requirements {
+		names[i] = req.String()
+	}
+	return fmt.Sprintf("%s (%s)", r.name, strings.Join(names, ", "))
+}
+
+// AllOf requires every one of requirements to be satisfied.
+func AllOf(requirements ...EvidenceRequirement) EvidenceRequirement {
+	return atLeast{"all of", len(requirements), requirements}
+}
+
+// AnyOf requires at least one of requirements to be satisfied.
+func AnyOf(requirements ...EvidenceRequirement) EvidenceRequirement {
+	return atLeast{"any of", 1, requirements}
+}
+
+// AtLeast requires at least n of requirements to be satisfied.
+func AtLeast(n int, requirements ...EvidenceRequirement) EvidenceRequirement {
+	return atLeast{fmt.Sprintf("at least %d of", n), n, requirements}
+}
+
+// validateEvidenceRequirement checks the requirements built by this
+// package for thresholds that are always or never satisfied. Other
+// implementations are accepted as they are.
+func validateEvidenceRequirement(req EvidenceRequirement) error {
+	switch r := req.(type) {
+	case nil:
+		return errors.New("evidence requirement must not be nil")
+	case minEvidence:
+		if r.threshold < 1 {
+			return fmt.Errorf("%s threshold must be at least 1", r.name)
+		}
+	case atLeast:
+		if len(r.requirements) == 0 {
+			return fmt.Errorf("%q requires at least one requirement", r.name)
+		}
+		if r.n < 1 || r.n > len(r.requirements) {
+			return fmt.Errorf("cannot require %d of %d requirements", r.n, len(r.requirements))
+		}
+		for _, sub := range r.requirements {
+			if err := validateEvidenceRequirement(sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithEvidenceRequirement configures the SignedEntityVerifier to verify
+// every transparency log entry, RFC 3161 timestamp and SCT of an entity
+// that the trusted material can verify, and to require that the verified
+// evidence satisfies req. It replaces the threshold options
+// WithTransparencyLog, WithSignedTimestamps, WithIntegratedTimestamps,
+// WithObserverTimestamps and WithSignedCertificateTimestamps, and cannot
+// be combined with them.
+//
+// As with the threshold options, at least one verified timestamp is always
+// required to verify the signing certificate.
+func WithEvidenceRequirement(req EvidenceRequirement) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if err := validateEvidenceRequirement(req); err != nil {
+			return err
+		}
+		c.evidenceRequirement = req
+		return nil
+	}
+}
+
+// verifyEvidence verifies the entity's log entries and signed timestamps
+// without thresholds, returning the verified timestamps, which are checked
+// against the evidence requirement once SCTs have been counted, and the log
+// of each verified entry.
+func (v *SignedEntityVerifier) verifyEvidence(entity SignedEntity) (Evidence, []TimestampVerificationResult, []*root.TransparencyLog, error) {
+	logs, logTimestamps, err := verifyArtifactTransparencyLog(entity, v.trustedMaterial, 0, true, v.config.performOnlineVerification)
+	if err != nil {
+		return Evidence{}, nil, nil, fmt.Errorf("failed to verify log inclusion: %w", err)
+	}
+
+	signedTimestamps, err := VerifyTimestampAuthorityWithDetails(entity, v.trustedMaterial)
+	if err != nil {
+		return Evidence{}, nil, nil, fmt.Errorf("failed to verify timestamps: %w", err)
+	}
+
+	verifiedTimestamps := make([]TimestampVerificationResult, 0, len(logTimestamps)+len(signedTimestamps))
+	verifiedTimestamps = append(verifiedTimestamps, logTimestamps...)
+	for _, ts := range signedTimestamps {
+		verifiedTimestamps = append(verifiedTimestamps, signedTimestampResult(ts))
+	}
+	if len(verifiedTimestamps) == 0 {
+		return Evidence{}, nil, nil, insufficientEvidence(errors.New("failed to verify timestamps: no valid observer timestamps found"))
+	}
+
+	return Evidence{
+		TransparencyLogEntries: len(logs),
+		IntegratedTimestamps:   len(logTimestamps),
+		SignedTimestamps:       len(signedTimestamps),
+	}, verifiedTimestamps, logs, nil
+}
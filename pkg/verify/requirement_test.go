@@ -0,0 +1,84 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestEvidenceRequirement(t *testing.T) {
+	evidence := verify.Evidence{TransparencyLogEntries: 1, IntegratedTimestamps: 1, SignedTimestamps: 1}
+
+	req := verify.AnyOf(
+		verify.AllOf(verify.MinTransparencyLogEntries(1), verify.MinSignedTimestamps(1)),
+		verify.MinSignedTimestamps(2),
+	)
+	assert.True(t, req.Satisfied(evidence))
+	assert.Equal(t, "any of (all of (1 transparency log entries, 1 signed timestamps), 2 signed timestamps)", req.String())
+
+	assert.True(t, req.Satisfied(verify.Evidence{SignedTimestamps: 2}))
+	assert.False(t, req.Satisfied(verify.Evidence{TransparencyLogEntries: 2}))
+
+	assert.True(t, verify.MinObserverTimestamps(2).Satisfied(evidence))
+	assert.False(t, verify.MinSignedCertificateTimestamps(1).Satisfied(evidence))
+
+	atLeast := verify.AtLeast(2, verify.MinSignedCertificateTimestamps(1), verify.MinIntegratedTimestamps(1), verify.MinSignedTimestamps(1))
+	assert.True(t, atLeast.Satisfied(evidence))
+	assert.False(t, atLeast.Satisfied(verify.Evidence{SignedTimestamps: 1}))
+}
+
+func TestVerifyWithEvidenceRequirement(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	policy := verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe())
+
+	// The virtual Sigstore issues one log entry and one signed timestamp
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.AnyOf(
+		verify.AllOf(verify.MinTransparencyLogEntries(1), verify.MinSignedTimestamps(1)),
+		verify.MinSignedTimestamps(2),
+	)))
+	assert.NoError(t, err)
+	result, err := verifier.Verify(entity, policy)
+	assert.NoError(t, err)
+	assert.Len(t, result.VerifiedTimestamps, 2)
+
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.AnyOf(
+		verify.MinSignedTimestamps(2),
+		verify.MinSignedCertificateTimestamps(1),
+	)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy)
+	assert.ErrorContains(t, err, "evidence requirement any of (2 signed timestamps, 1 SCTs) not met: verified 1 transparency log entries, 1 integrated timestamps, 1 signed timestamps, 0 SCTs")
+
+	// Requirements cannot be mixed with thresholds, or be unsatisfiable
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.MinSignedTimestamps(1)), verify.WithTransparencyLog(1))
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.AtLeast(3, verify.MinSignedTimestamps(1), verify.MinTransparencyLogEntries(1))))
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.AllOf(verify.MinSignedTimestamps(0))))
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithEvidenceRequirement(verify.AnyOf()))
+	assert.Error(t, err)
+}
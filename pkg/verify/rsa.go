@@ -0,0 +1,103 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha512" // for SHA-384 and SHA-512 RSASSA-PSS signatures
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// rsaPSSHashFuncs are the hash functions accepted for RSASSA-PSS signatures
+// by keys in certificates.
+var rsaPSSHashFuncs = []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512}
+
+// rsaCertificateVerifier verifies signatures by RSA keys in certificates.
+//
+// A certificate's subject public key does not say which signature scheme
+// the key is used with, so PKCS #1 v1.5 signatures with SHA-256 are
+// accepted, as are RSASSA-PSS signatures with SHA-256, SHA-384 or SHA-512
+// and any salt length.
+type rsaCertificateVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+var _ signature.Verifier = &rsaCertificateVerifier{}
+
+func (v *rsaCertificateVerifier) PublicKey(_ ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return v.publicKey, nil
+}
+
+// VerifySignature verifies the signature of the message, or of the digest
+// given with options.WithDigest, whose hash function is inferred from its
+// length.
+func (v *rsaCertificateVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	if sig == nil {
+		return errors.New("nil signature passed to VerifySignature")
+	}
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+
+	var digest []byte
+	for _, opt := range opts {
+		opt.ApplyDigest(&digest)
+	}
+
+	digests := make(map[crypto.Hash][]byte, len(rsaPSSHashFuncs))
+	if len(digest) > 0 {
+		for _, hashFunc := range rsaPSSHashFuncs {
+			if len(digest) == hashFunc.Size() {
+				digests[hashFunc] = digest
+			}
+		}
+	} else {
+		if message == nil {
+			return errors.New("message cannot be nil")
+		}
+		// Hash the message once with every hash function, rather than
+		// reading it into memory
+		hashers := make([]hash.Hash, len(rsaPSSHashFuncs))
+		writers := make([]io.Writer, len(rsaPSSHashFuncs))
+		for i, hashFunc := range rsaPSSHashFuncs {
+			hashers[i] = hashFunc.New()
+			writers[i] = hashers[i]
+		}
+		if _, err := io.Copy(io.MultiWriter(writers...), message); err != nil {
+			return fmt.Errorf("hashing message: %w", err)
+		}
+		for i, hashFunc := range rsaPSSHashFuncs {
+			digests[hashFunc] = hashers[i].Sum(nil)
+		}
+	}
+
+	if d, ok := digests[crypto.SHA256]; ok && rsa.VerifyPKCS1v15(v.publicKey, crypto.SHA256, d, sigBytes) == nil {
+		return nil
+	}
+	for _, hashFunc := range rsaPSSHashFuncs {
+		d, ok := digests[hashFunc]
+		if ok && rsa.VerifyPSS(v.publicKey, hashFunc, d, sigBytes, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil {
+			return nil
+		}
+	}
+	return errors.New("crypto/rsa: verification error")
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// The vectors are DSSE envelopes signed by OpenSSL with RSASSA-PSS, using
+// SHA-256 with a salt as long as the digest, and SHA-512 with the longest
+// possible salt. The certificate is self-signed.
+func TestRSAPSSInteropVectors(t *testing.T) {
+	paths, err := filepath.Glob("testdata/rsa-pss/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			b, err := bundle.LoadJSONFromPath(path)
+			require.NoError(t, err)
+			sigContent, err := b.SignatureContent()
+			require.NoError(t, err)
+			verificationContent, err := b.VerificationContent()
+			require.NoError(t, err)
+
+			err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, nil, strings.NewReader("hello"))
+			assert.NoError(t, err)
+
+			err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, nil, strings.NewReader("goodbye"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRSAPSSSignedEnvelope(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		hashAlgorithm protocommon.HashAlgorithm
+		hashFunc      crypto.Hash
+		keyDetails    protocommon.PublicKeyDetails
+	}{
+		{protocommon.HashAlgorithm_SHA2_256, crypto.SHA256, protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256},
+		{protocommon.HashAlgorithm_SHA2_384, crypto.SHA384, protocommon.PublicKeyDetails_PUBLIC_KEY_DETAILS_UNSPECIFIED},
+		{protocommon.HashAlgorithm_SHA2_512, crypto.SHA512, protocommon.PublicKeyDetails_PUBLIC_KEY_DETAILS_UNSPECIFIED},
+	} {
+		t.Run(tc.hashFunc.String(), func(t *testing.T) {
+			keypair, err := sign.NewPrivateKeyKeypair(key, &sign.PrivateKeyKeypairOptions{
+				Hint:          []byte("rsa-pss"),
+				HashAlgorithm: tc.hashAlgorithm,
+				RSAPSS:        true,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.keyDetails, keypair.GetKeyDetails())
+
+			statement := []byte(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"hello.txt","digest":{"sha256":"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}}],"predicateType":"https://example.com/rsa-pss","predicate":{}}`)
+			pb, err := sign.Bundle(&sign.DSSEData{Data: statement, PayloadType: "application/vnd.in-toto+json"}, keypair, sign.BundleOptions{})
+			require.NoError(t, err)
+			b, err := bundle.NewProtobufBundle(pb)
+			require.NoError(t, err)
+			sigContent, err := b.SignatureContent()
+			require.NoError(t, err)
+			verificationContent, err := b.VerificationContent()
+			require.NoError(t, err)
+
+			var sigVerifier signature.Verifier
+			if tc.keyDetails != protocommon.PublicKeyDetails_PUBLIC_KEY_DETAILS_UNSPECIFIED {
+				sigVerifier, err = root.LoadVerifierWithKeyDetails(&key.PublicKey, tc.keyDetails)
+			} else {
+				sigVerifier, err = signature.LoadRSAPSSVerifier(&key.PublicKey, tc.hashFunc, nil)
+			}
+			require.NoError(t, err)
+			tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+				"rsa-pss": root.NewExpiringKey(sigVerifier, time.Time{}, time.Time{}),
+			})
+			err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, tm, strings.NewReader("hello"))
+			assert.NoError(t, err)
+
+			// A PKCS #1 v1.5 verifier for the same key rejects the signature
+			pkcs1Verifier, err := signature.LoadRSAPKCS1v15Verifier(&key.PublicKey, tc.hashFunc)
+			require.NoError(t, err)
+			tm = root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+				"rsa-pss": root.NewExpiringKey(pkcs1Verifier, time.Time{}, time.Time{}),
+			})
+			err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, tm, strings.NewReader("hello"))
+			assert.Error(t, err)
+		})
+	}
+}
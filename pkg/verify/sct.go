@@ -15,11 +15,18 @@
 package verify
 
 import (
+	"crypto"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	ct "github.com/google/certificate-transparency-go"
 	"github.com/google/certificate-transparency-go/ctutil"
+	"github.com/google/certificate-transparency-go/tls"
 	ctx509 "github.com/google/certificate-transparency-go/x509"
 	"github.com/google/certificate-transparency-go/x509util"
 	"github.com/sigstore/sigstore-go/pkg/root"
@@ -31,25 +38,80 @@ import (
 // CTLogs()
 // TODO(issue#46): Add unit tests
 func VerifySignedCertificateTimestamp(leafCert *x509.Certificate, threshold int, trustedMaterial root.TrustedMaterial) error { // nolint: revive
+	return VerifySignedCertificateTimestampWithDetachedSCTs(leafCert, nil, threshold, trustedMaterial)
+}
+
+// VerifySignedCertificateTimestampWithDetachedSCTs is like
+// VerifySignedCertificateTimestamp, but also verifies SCTs that were issued
+// for the leaf certificate but returned separately from it, as some Fulcio
+// deployments do. Verified embedded and detached SCTs both count towards the
+// threshold, but only once per CT log, so repeating an SCT, or detaching a
+// copy of an embedded one, does not help meet it.
+//
+// Each detached SCT is either the JSON response of a CT log's add-chain
+// endpoint, as returned by Fulcio, or a TLS-encoded SCT.
+func VerifySignedCertificateTimestampWithDetachedSCTs(leafCert *x509.Certificate, detachedSCTs [][]byte, threshold int, trustedMaterial root.TrustedMaterial) error { // nolint: revive
+	_, err := verifySignedCertificateTimestampsWithThreshold(leafCert, detachedSCTs, threshold, trustedMaterial)
+	return err
+}
+
+// verifySignedCertificateTimestampsWithThreshold is
+// VerifySignedCertificateTimestampWithDetachedSCTs, also returning the CT
+// log of each verified SCT.
+func verifySignedCertificateTimestampsWithThreshold(leafCert *x509.Certificate, detachedSCTs [][]byte, threshold int, trustedMaterial root.TrustedMaterial) ([]*root.TransparencyLog, error) {
+	verified, skipped, err := verifySignedCertificateTimestampsWithReasons(leafCert, detachedSCTs, trustedMaterial)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(verified) < threshold {
+		err := fmt.Errorf("only able to verify %d SCT entries; unable to meet threshold of %d", len(verified), threshold)
+		if len(skipped) > 0 {
+			return nil, fmt.Errorf("%w: %w", err, errors.Join(skipped...))
+		}
+		return nil, insufficientEvidence(err)
+	}
+
+	return verified, nil
+}
+
+// verifySignedCertificateTimestamps returns the CT log of each embedded and
+// detached SCT that verified.
+func verifySignedCertificateTimestamps(leafCert *x509.Certificate, detachedSCTs [][]byte, trustedMaterial root.TrustedMaterial) ([]*root.TransparencyLog, error) {
+	verified, _, err := verifySignedCertificateTimestampsWithReasons(leafCert, detachedSCTs, trustedMaterial)
+	return verified, err
+}
+
+// verifySignedCertificateTimestampsWithReasons is like
+// verifySignedCertificateTimestamps, but also returns the reasons SCTs that
+// did not verify were skipped. Each CT log is returned at most once, however
+// many of its SCTs verified.
+func verifySignedCertificateTimestampsWithReasons(leafCert *x509.Certificate, detachedSCTs [][]byte, trustedMaterial root.TrustedMaterial) ([]*root.TransparencyLog, []error, error) {
 	ctlogs := trustedMaterial.CTLogs()
 	fulcioCerts := trustedMaterial.FulcioCertificateAuthorities()
 
 	scts, err := x509util.ParseSCTsFromCertificate(leafCert.Raw)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	leafCTCert, err := ctx509.ParseCertificates(leafCert.Raw)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	verified := 0
+	var verified []*root.TransparencyLog
+	var skipped []error
+	// counted holds the IDs of the CT logs with a verified SCT
+	counted := make(map[[sha256.Size]byte]bool)
 	for _, sct := range scts {
-		encodedKeyID := hex.EncodeToString(sct.LogID.KeyID[:])
-		key, ok := ctlogs[encodedKeyID]
+		if counted[sct.LogID.KeyID] {
+			continue
+		}
+		key, ok := findCTLog(ctlogs, sct)
 		if !ok {
 			// skip entries the trust root cannot verify
+			skipped = append(skipped, untrustedMaterial(fmt.Errorf("CT log %x not found in trusted material", sct.LogID.KeyID)))
 			continue
 		}
 
@@ -73,14 +135,205 @@ func VerifySignedCertificateTimestamp(leafCert *x509.Certificate, threshold int,
 
 			err = ctutil.VerifySCT(key.PublicKey, fulcioChain, sct, true)
 			if err == nil {
-				verified++
+				verified = append(verified, key)
+				counted[sct.LogID.KeyID] = true
+				break
 			}
 		}
+		if !counted[sct.LogID.KeyID] {
+			skipped = append(skipped, cryptographicFailure(fmt.Errorf("embedded SCT from CT log %x does not verify", sct.LogID.KeyID)))
+		}
 	}
 
-	if verified < threshold {
-		return fmt.Errorf("only able to verify %d SCT entries; unable to meet threshold of %d", verified, threshold)
+	for _, rawSCT := range detachedSCTs {
+		sct, err := ParseDetachedSCT(rawSCT)
+		if err != nil {
+			return nil, nil, err
+		}
+		if counted[sct.LogID.KeyID] {
+			continue
+		}
+
+		key, ok := findCTLog(ctlogs, sct)
+		if !ok {
+			// skip entries the trust root cannot verify
+			skipped = append(skipped, untrustedMaterial(fmt.Errorf("CT log %x not found in trusted material", sct.LogID.KeyID)))
+			continue
+		}
+
+		// Detached SCTs are usually issued over the final certificate, but
+		// may have been issued over its precertificate, which is checked
+		// with the key hash of each Fulcio issuer
+		err = verifyDetachedSCT(key.PublicKey, leafCTCert[0], fulcioIssuerChains(fulcioCerts), sct)
+		if err == nil {
+			verified = append(verified, key)
+			counted[sct.LogID.KeyID] = true
+		} else {
+			skipped = append(skipped, cryptographicFailure(fmt.Errorf("detached SCT from CT log %x does not verify: %w", sct.LogID.KeyID, err)))
+		}
+	}
+
+	return verified, skipped, nil
+}
+
+// VerifyPrecertificateSCT verifies an SCT that a CT log issued for a
+// precertificate, as CT logs do for certificates with embedded SCTs (RFC
+// 6962 section 3.1). certChain starts with either the precertificate, with
+// its poison extension, or the final certificate, with or without the SCTs
+// embedded, followed by its issuer. If the precertificate was issued by a
+// precertificate signing certificate, that certificate's issuer must follow
+// it.
+//
+// The precertificate the log signed is reconstructed by removing the poison
+// or SCT list extension, and binding the hash of the issuer's key.
+func VerifyPrecertificateSCT(certChain []*x509.Certificate, sct *ct.SignedCertificateTimestamp, ctLogKey crypto.PublicKey) error {
+	if len(certChain) < 2 {
+		return errors.New("precertificate SCTs can't be verified without the issuer")
+	}
+	chain := make([]*ctx509.Certificate, len(certChain))
+	for i, cert := range certChain {
+		ctCert, err := ctx509.ParseCertificate(cert.Raw)
+		if ctx509.IsFatal(err) {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		chain[i] = ctCert
 	}
+	verifier, err := ct.NewSignatureVerifier(ctLogKey)
+	if err != nil {
+		return err
+	}
+	return verifyPrecertificateSCT(verifier, chain[0], chain[1:], sct)
+}
 
-	return nil
+// verifyDetachedSCT verifies an SCT that was issued either for the
+// certificate or for its precertificate, issued by one of the issuer
+// chains.
+func verifyDetachedSCT(ctLogKey crypto.PublicKey, cert *ctx509.Certificate, issuerChains [][]*ctx509.Certificate, sct *ct.SignedCertificateTimestamp) error {
+	verifier, err := ct.NewSignatureVerifier(ctLogKey)
+	if err != nil {
+		return err
+	}
+	if !cert.IsPrecertificate() {
+		err = ctutil.VerifySCTWithVerifier(verifier, []*ctx509.Certificate{cert}, sct, false)
+		if err == nil {
+			return nil
+		}
+	}
+	for _, issuers := range issuerChains {
+		if precertErr := verifyPrecertificateSCT(verifier, cert, issuers, sct); precertErr == nil {
+			return nil
+		} else if err == nil {
+			err = precertErr
+		}
+	}
+	if err == nil {
+		err = errors.New("no issuer to verify precertificate SCT with")
+	}
+	return err
+}
+
+func verifyPrecertificateSCT(verifier *ct.SignatureVerifier, cert *ctx509.Certificate, issuers []*ctx509.Certificate, sct *ct.SignedCertificateTimestamp) error {
+	var leaf *ct.MerkleTreeLeaf
+	var err error
+	if cert.IsPrecertificate() {
+		// Removes the poison extension, and replaces the issuer of
+		// precertificates from a precertificate signing certificate
+		leaf, err = ct.MerkleTreeLeafFromChain(append([]*ctx509.Certificate{cert}, issuers...), ct.PrecertLogEntryType, sct.Timestamp)
+	} else {
+		leaf, err = precertificateLeafFromCertificate(cert, issuers[0], sct.Timestamp)
+	}
+	if err != nil {
+		return err
+	}
+	return verifier.VerifySCTSignature(*sct, ct.LogEntry{Leaf: *leaf})
+}
+
+// precertificateLeafFromCertificate returns the log entry of the
+// precertificate of a final certificate, which is the certificate without
+// its embedded SCTs, if any.
+func precertificateLeafFromCertificate(cert, issuer *ctx509.Certificate, timestamp uint64) (*ct.MerkleTreeLeaf, error) {
+	tbs := cert.RawTBSCertificate
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(ctx509.OIDExtensionCTSCT) {
+			var err error
+			tbs, err = ctx509.RemoveSCTList(tbs)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return &ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			EntryType: ct.PrecertLogEntryType,
+			Timestamp: timestamp,
+			PrecertEntry: &ct.PreCert{
+				IssuerKeyHash:  sha256.Sum256(issuer.RawSubjectPublicKeyInfo),
+				TBSCertificate: tbs,
+			},
+		},
+	}, nil
+}
+
+// fulcioIssuerChains returns the certificates of each Fulcio certificate
+// authority, from the issuer of leaf certificates to the root.
+func fulcioIssuerChains(fulcioCerts []root.CertificateAuthority) [][]*ctx509.Certificate {
+	var chains [][]*ctx509.Certificate
+	for _, fulcioCa := range fulcioCerts {
+		var chain []*ctx509.Certificate
+		for _, cert := range append(append([]*x509.Certificate{}, fulcioCa.Intermediates...), fulcioCa.Root) {
+			if cert == nil {
+				continue
+			}
+			ctCert, err := ctx509.ParseCertificate(cert.Raw)
+			if ctx509.IsFatal(err) {
+				break
+			}
+			chain = append(chain, ctCert)
+		}
+		if len(chain) > 0 {
+			chains = append(chains, chain)
+		}
+	}
+	return chains
+}
+
+// findCTLog returns the shard of the CT log that issued the SCT that was
+// valid when the SCT was issued. Logs whose validity period has no end are
+// still active; logs whose validity period starts after the SCT's timestamp
+// cannot have issued it.
+func findCTLog(ctlogs map[string]*root.TransparencyLog, sct *ct.SignedCertificateTimestamp) (*root.TransparencyLog, bool) {
+	encodedKeyID := hex.EncodeToString(sct.LogID.KeyID[:])
+	sctTime := time.UnixMilli(int64(sct.Timestamp)) //nolint:gosec
+	tlog, err := root.FindTransparencyLog(ctlogs, encodedKeyID, sctTime)
+	if err != nil {
+		return nil, false
+	}
+	return tlog, true
+}
+
+// ParseDetachedSCT parses an SCT that was returned separately from the
+// certificate it was issued for. The SCT may either be the JSON response of
+// a CT log's add-chain endpoint or a TLS-encoded SCT.
+func ParseDetachedSCT(rawSCT []byte) (*ct.SignedCertificateTimestamp, error) {
+	var addChainResp ct.AddChainResponse
+	if err := json.Unmarshal(rawSCT, &addChainResp); err == nil {
+		sct, err := addChainResp.ToSignedCertificateTimestamp()
+		if err != nil {
+			return nil, fmt.Errorf("invalid detached SCT: %w", err)
+		}
+		return sct, nil
+	}
+
+	var sct ct.SignedCertificateTimestamp
+	rest, err := tls.Unmarshal(rawSCT, &sct)
+	if err != nil {
+		return nil, fmt.Errorf("invalid detached SCT: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("invalid detached SCT: trailing data")
+	}
+	return &sct, nil
 }
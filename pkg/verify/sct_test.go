@@ -0,0 +1,222 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	cttestdata "github.com/google/certificate-transparency-go/testdata"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetachedSCTVerification(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "test artifact"
+	entity, err := virtualSigstore.Sign("foofighters@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedCertificateTimestamps(1), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+
+	// The virtual Fulcio does not embed SCTs
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewBufferString(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.ErrorContains(t, err, "unable to meet threshold of 1")
+
+	assert.NoError(t, virtualSigstore.AddDetachedSCT(entity))
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewBufferString(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// Detached SCTs from an unknown log do not count towards the threshold
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherEntity, err := otherSigstore.Sign("foofighters@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	assert.NoError(t, otherSigstore.AddDetachedSCT(otherEntity))
+
+	verificationContent, err := otherEntity.VerificationContent()
+	assert.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	detachedSCTs, ok := verificationContent.(verify.DetachedSCTProvider).HasDetachedSCTs()
+	assert.True(t, ok)
+
+	err = verify.VerifySignedCertificateTimestampWithDetachedSCTs(&leafCert, detachedSCTs, 1, virtualSigstore)
+	assert.Error(t, err)
+	err = verify.VerifySignedCertificateTimestampWithDetachedSCTs(&leafCert, detachedSCTs, 1, otherSigstore)
+	assert.NoError(t, err)
+}
+
+func TestDetachedSCTsCountOncePerLog(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foofighters@example.com", "issuer", []byte("test artifact"))
+	assert.NoError(t, err)
+	assert.NoError(t, virtualSigstore.AddDetachedSCT(entity))
+
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	detachedSCTs, ok := verificationContent.(verify.DetachedSCTProvider).HasDetachedSCTs()
+	assert.True(t, ok)
+	require.Len(t, detachedSCTs, 1)
+
+	err = verify.VerifySignedCertificateTimestampWithDetachedSCTs(&leafCert, detachedSCTs, 1, virtualSigstore)
+	assert.NoError(t, err)
+
+	// Repeating an SCT does not count its log twice
+	repeated := [][]byte{detachedSCTs[0], detachedSCTs[0]}
+	err = verify.VerifySignedCertificateTimestampWithDetachedSCTs(&leafCert, repeated, 2, virtualSigstore)
+	assert.ErrorContains(t, err, "only able to verify 1 SCT entries; unable to meet threshold of 2")
+
+	// Nor does another SCT from the same log
+	assert.NoError(t, virtualSigstore.AddDetachedPrecertificateSCT(entity))
+	verificationContent, err = entity.VerificationContent()
+	assert.NoError(t, err)
+	detachedSCTs, _ = verificationContent.(verify.DetachedSCTProvider).HasDetachedSCTs()
+	require.Len(t, detachedSCTs, 2)
+	err = verify.VerifySignedCertificateTimestampWithDetachedSCTs(&leafCert, detachedSCTs, 2, virtualSigstore)
+	assert.ErrorContains(t, err, "unable to meet threshold of 2")
+}
+
+func TestDetachedPrecertificateSCTVerification(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "test artifact"
+	entity, err := virtualSigstore.Sign("foofighters@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	assert.NoError(t, virtualSigstore.AddDetachedPrecertificateSCT(entity))
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedCertificateTimestamps(1), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewBufferString(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// The SCT is bound to the key of the Fulcio instance that issued the
+	// certificate
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	detachedSCTs, _ := verificationContent.(verify.DetachedSCTProvider).HasDetachedSCTs()
+	err = verify.VerifySignedCertificateTimestampWithDetachedSCTs(&leafCert, detachedSCTs, 1, root.TrustedMaterialCollection{
+		&ctLogsOnly{virtualSigstore}, &fulcioOnly{otherSigstore},
+	})
+	assert.ErrorContains(t, err, "does not verify")
+}
+
+// ctLogsOnly and fulcioOnly restrict trusted material to its CT logs or
+// Fulcio certificate authorities.
+type ctLogsOnly struct{ root.TrustedMaterial }
+
+func (*ctLogsOnly) FulcioCertificateAuthorities() []root.CertificateAuthority { return nil }
+
+type fulcioOnly struct{ root.TrustedMaterial }
+
+func (*fulcioOnly) CTLogs() map[string]*root.TransparencyLog { return nil }
+
+// The precertificate test vectors of the certificate-transparency-go
+// project: a precertificate, the certificate issued from it with the
+// precertificate's SCT embedded, and an unrelated certificate, all issued
+// by the same CA.
+func TestVerifyPrecertificateSCT(t *testing.T) {
+	logKey, err := ct.PublicKeyFromB64(cttestdata.LogPublicKeyB64)
+	require.NoError(t, err)
+	parseSCT := func(data []byte) *ct.SignedCertificateTimestamp {
+		var sct ct.SignedCertificateTimestamp
+		_, err := cttls.Unmarshal(data, &sct)
+		require.NoError(t, err)
+		return &sct
+	}
+	parseCert := func(pemCert string) *x509.Certificate {
+		cert, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(pemCert))
+		require.NoError(t, err)
+		return cert[0]
+	}
+	caCert := parseCert(cttestdata.CACertPEM)
+
+	for _, tc := range []struct {
+		name    string
+		chain   []*x509.Certificate
+		sct     []byte
+		wantErr bool
+	}{
+		{
+			name:  "precertificate with poison extension",
+			chain: []*x509.Certificate{parseCert(cttestdata.TestPreCertPEM), caCert},
+			sct:   cttestdata.TestPreCertProof,
+		},
+		{
+			name:  "certificate with embedded SCT",
+			chain: []*x509.Certificate{parseCert(cttestdata.TestEmbeddedCertPEM), caCert},
+			sct:   cttestdata.TestPreCertProof,
+		},
+		{
+			name:    "SCT for the final certificate",
+			chain:   []*x509.Certificate{parseCert(cttestdata.TestPreCertPEM), caCert},
+			sct:     cttestdata.TestCertProof,
+			wantErr: true,
+		},
+		{
+			name:    "other certificate",
+			chain:   []*x509.Certificate{parseCert(cttestdata.TestCertPEM), caCert},
+			sct:     cttestdata.TestPreCertProof,
+			wantErr: true,
+		},
+		{
+			name:    "wrong issuer",
+			chain:   []*x509.Certificate{parseCert(cttestdata.TestPreCertPEM), parseCert(cttestdata.TestCertPEM)},
+			sct:     cttestdata.TestPreCertProof,
+			wantErr: true,
+		},
+		{
+			name:    "no issuer",
+			chain:   []*x509.Certificate{parseCert(cttestdata.TestPreCertPEM)},
+			sct:     cttestdata.TestPreCertProof,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verify.VerifyPrecertificateSCT(tc.chain, parseSCT(tc.sct), logKey)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseDetachedSCT(t *testing.T) {
+	_, err := verify.ParseDetachedSCT([]byte(`{"sct_version":0,"id":"AAAA","timestamp":1}`))
+	assert.ErrorContains(t, err, "invalid detached SCT")
+
+	_, err = verify.ParseDetachedSCT([]byte{0x00})
+	assert.ErrorContains(t, err, "invalid detached SCT")
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/hex"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Types of the services of the trusted material that verification can rely
+// on, as reported in TrustedService.Type.
+const (
+	TrustedServiceTransparencyLog      = "transparencyLog"
+	TrustedServiceCTLog                = "ctLog"
+	TrustedServiceCertificateAuthority = "certificateAuthority"
+	TrustedServiceTimestampAuthority   = "timestampAuthority"
+)
+
+// TrustedService identifies a service of the trusted material, such as a
+// Rekor shard or a Fulcio instance, that verification relied on. Operators
+// can use it to track which artifacts depend on infrastructure that is due
+// to be retired.
+type TrustedService struct {
+	Type string `json:"type"`
+	// URI is the base URL of a log, or the URI of a certificate or
+	// timestamp authority, if the trusted material records one
+	URI string `json:"uri,omitempty"`
+	// LogID is the hex-encoded ID of a transparency or CT log
+	LogID string `json:"logId,omitempty"`
+	// Subject is the subject of a certificate or timestamp authority's
+	// root certificate
+	Subject string `json:"subject,omitempty"`
+}
+
+func logService(serviceType string, tlog *root.TransparencyLog) TrustedService {
+	return TrustedService{
+		Type:  serviceType,
+		URI:   tlog.BaseURL,
+		LogID: hex.EncodeToString(tlog.ID),
+	}
+}
+
+func certificateAuthorityService(serviceType string, ca *root.CertificateAuthority) TrustedService {
+	service := TrustedService{Type: serviceType, URI: ca.URI}
+	if ca.Root != nil {
+		service.Subject = ca.Root.Subject.String()
+	}
+	return service
+}
+
+// addTrustedService adds a service to the list unless it is already there.
+func addTrustedService(services []TrustedService, service TrustedService) []TrustedService {
+	for _, existing := range services {
+		if existing == service {
+			return services
+		}
+	}
+	return append(services, service)
+}
@@ -18,13 +18,19 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"hash"
 	"io"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore-go/pkg/mldsa"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
@@ -37,11 +43,11 @@ func VerifySignature(sigContent SignatureContent, verificationContent Verificati
 
 	verifier, err = getSignatureVerifier(verificationContent, trustedMaterial)
 	if err != nil {
-		return fmt.Errorf("could not load signature verifier: %w", err)
+		return signatureVerifierError(verificationContent, err)
 	}
 
 	if envelope := sigContent.EnvelopeContent(); envelope != nil {
-		return verifyEnvelope(verifier, envelope)
+		return signatureError(verifyEnvelope(verifier, envelope))
 	} else if msg := sigContent.MessageSignatureContent(); msg != nil {
 		return errors.New("artifact must be provided to verify message signature")
 	}
@@ -50,23 +56,34 @@ func VerifySignature(sigContent SignatureContent, verificationContent Verificati
 	return fmt.Errorf("signature content has neither an envelope or a message")
 }
 
+// VerifySignatureWithArtifact verifies the signature and that it binds the
+// artifact. The artifact is only hashed with the digest algorithms that the
+// signature content binds it with: that of a message signature's digest, or
+// those of the subjects of an envelope's statement.
 func VerifySignatureWithArtifact(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial, artifact io.Reader) error { // nolint: revive
-	var verifier signature.Verifier
-	var err error
+	_, err := verifySignatureWithArtifact(sigContent, verificationContent, trustedMaterial, artifact)
+	return err
+}
 
-	verifier, err = getSignatureVerifier(verificationContent, trustedMaterial)
+// verifySignatureWithArtifact verifies the signature and artifact as
+// VerifySignatureWithArtifact does, and returns the digest algorithm, such
+// as sha256, with which the artifact was found to be bound, if any.
+func verifySignatureWithArtifact(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial, artifact io.Reader) (string, error) {
+	verifier, err := getSignatureVerifier(verificationContent, trustedMaterial)
 	if err != nil {
-		return fmt.Errorf("could not load signature verifier: %w", err)
+		return "", signatureVerifierError(verificationContent, err)
 	}
 
 	if envelope := sigContent.EnvelopeContent(); envelope != nil {
-		return verifyEnvelopeWithArtifact(verifier, envelope, artifact)
+		algorithm, err := verifyEnvelopeWithArtifact(verifier, envelope, artifact)
+		return algorithm, signatureError(err)
 	} else if msg := sigContent.MessageSignatureContent(); msg != nil {
-		return verifyMessageSignature(verifier, msg, artifact)
+		algorithm, err := verifyMessageSignatureWithArtifact(verifier, msg, artifact)
+		return algorithm, signatureError(err)
 	}
 
 	// handle an invalid signature content message
-	return fmt.Errorf("signature content has neither an envelope or a message")
+	return "", fmt.Errorf("signature content has neither an envelope or a message")
 }
 
 func VerifySignatureWithArtifactDigest(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial, artifactDigest []byte, artifactDigestAlgorithm string) error { // nolint: revive
@@ -75,21 +92,49 @@ func VerifySignatureWithArtifactDigest(sigContent SignatureContent, verification
 
 	verifier, err = getSignatureVerifier(verificationContent, trustedMaterial)
 	if err != nil {
-		return fmt.Errorf("could not load signature verifier: %w", err)
+		return signatureVerifierError(verificationContent, err)
 	}
 
 	if envelope := sigContent.EnvelopeContent(); envelope != nil {
-		return verifyEnvelopeWithArtifactDigest(verifier, envelope, artifactDigest, artifactDigestAlgorithm)
+		return signatureError(verifyEnvelopeWithArtifactDigest(verifier, envelope, artifactDigest, artifactDigestAlgorithm))
 	} else if msg := sigContent.MessageSignatureContent(); msg != nil {
-		return verifyMessageSignatureWithArtifactDigest(verifier, msg, artifactDigest)
+		return signatureError(verifyMessageSignatureWithArtifactDigest(verifier, msg, artifactDigest))
 	}
 
 	// handle an invalid signature content message
 	return fmt.Errorf("signature content has neither an envelope or a message")
 }
 
+// signatureVerifierError classifies a failure to load the verifier of a
+// signature: a public key hint that the trusted material does not know is
+// untrusted material, while an unusable certificate key is a cryptographic
+// failure.
+func signatureVerifierError(verificationContent VerificationContent, err error) error {
+	err = fmt.Errorf("could not load signature verifier: %w", err)
+	if _, ok := verificationContent.HasPublicKey(); ok {
+		return untrustedMaterial(err)
+	}
+	return cryptographicFailure(err)
+}
+
+// signatureError classifies a failure to verify a signature, or the
+// artifact it covers, as a cryptographic failure.
+func signatureError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return cryptographicFailure(err)
+}
+
 func getSignatureVerifier(verificationContent VerificationContent, tm root.TrustedMaterial) (signature.Verifier, error) {
 	if leafCert, ok := verificationContent.HasCertificate(); ok {
+		// ML-DSA keys are only supported once enabled with mldsa.Enable
+		if verifier, ok, err := mldsa.LoadVerifier(leafCert.PublicKey); ok {
+			return verifier, err
+		}
+		if rsaKey, ok := leafCert.PublicKey.(*rsa.PublicKey); ok {
+			return &rsaCertificateVerifier{publicKey: rsaKey}, nil
+		}
 		// TODO: Inspect certificate's SignatureAlgorithm to determine hash function
 		return signature.LoadVerifier(leafCert.PublicKey, crypto.SHA256)
 	} else if pk, ok := verificationContent.HasPublicKey(); ok {
@@ -99,7 +144,92 @@ func getSignatureVerifier(verificationContent VerificationContent, tm root.Trust
 	return nil, fmt.Errorf("no public key or certificate found")
 }
 
+// paeBufferPool holds buffers for DSSE pre-authentication encodings, which
+// are as large as the envelope payload.
+var paeBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledPAEBufferSize bounds the size of buffers returned to
+// paeBufferPool, so that a single large envelope does not pin memory.
+const maxPooledPAEBufferSize = 1 << 20
+
 func verifyEnvelope(verifier signature.Verifier, envelope EnvelopeContent) error {
+	decoded, ok := envelope.(DecodedEnvelopeContent)
+	if !ok {
+		return verifyEnvelopeWithDSSEVerifier(verifier, envelope)
+	}
+
+	rawEnvelope := envelope.RawEnvelope()
+	if rawEnvelope == nil {
+		return errors.New("could not verify envelope: cannot verify a nil envelope")
+	}
+	if len(rawEnvelope.Signatures) == 0 {
+		return fmt.Errorf("could not verify envelope: %w", dsse.ErrNoSignature)
+	}
+
+	payload, err := decoded.DecodedPayload()
+	if err != nil {
+		return fmt.Errorf("could not verify envelope: %w", err)
+	}
+	signatures, err := decoded.DecodedSignatures()
+	if err != nil {
+		return fmt.Errorf("could not verify envelope: %w", err)
+	}
+
+	buf := paeBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledPAEBufferSize {
+			paeBufferPool.Put(buf)
+		}
+	}()
+	buf.Reset()
+	writePAE(buf, rawEnvelope.PayloadType, payload)
+	pae := buf.Bytes()
+
+	// As with the DSSE library's verifier, signatures carrying a key ID are
+	// only checked if it matches the verifier's key
+	var verifierKeyID string
+	var verifierKeyIDComputed bool
+	for i, sig := range signatures {
+		if sigKeyID := rawEnvelope.Signatures[i].KeyID; sigKeyID != "" {
+			if !verifierKeyIDComputed {
+				verifierKeyIDComputed = true
+				if pub, err := verifier.PublicKey(); err == nil {
+					verifierKeyID, _ = dsse.SHA256KeyID(pub)
+				}
+			}
+			if verifierKeyID != "" && sigKeyID != verifierKeyID {
+				continue
+			}
+		}
+
+		if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(pae)); err == nil {
+			return nil
+		}
+	}
+
+	return errors.New("could not verify envelope: no signature could be verified")
+}
+
+// writePAE writes the DSSE v1 pre-authentication encoding of the payload to
+// buf, as dsse.PAE does, without intermediate allocations.
+func writePAE(buf *bytes.Buffer, payloadType string, payload []byte) {
+	var scratch [20]byte
+	buf.Grow(len("DSSEv1") + len(payloadType) + len(payload) + 2*len(scratch) + 4)
+	buf.WriteString("DSSEv1 ")
+	buf.Write(strconv.AppendInt(scratch[:0], int64(len(payloadType)), 10))
+	buf.WriteByte(' ')
+	buf.WriteString(payloadType)
+	buf.WriteByte(' ')
+	buf.Write(strconv.AppendInt(scratch[:0], int64(len(payload)), 10))
+	buf.WriteByte(' ')
+	buf.Write(payload)
+}
+
+// verifyEnvelopeWithDSSEVerifier verifies envelopes that only provide their
+// base64-encoded form, using the DSSE library's verifier.
+func verifyEnvelopeWithDSSEVerifier(verifier signature.Verifier, envelope EnvelopeContent) error {
 	pub, err := verifier.PublicKey()
 	if err != nil {
 		return fmt.Errorf("could not fetch verifier public key: %w", err)
@@ -121,68 +251,73 @@ func verifyEnvelope(verifier signature.Verifier, envelope EnvelopeContent) error
 	return nil
 }
 
-func verifyEnvelopeWithArtifact(verifier signature.Verifier, envelope EnvelopeContent, artifact io.Reader) error {
+// artifactDigestAlgorithms are the digest algorithms with which artifacts
+// are hashed to match the subjects of statements, strongest first.
+var artifactDigestAlgorithms = []struct {
+	name     string
+	hashFunc crypto.Hash
+}{
+	{"sha512", crypto.SHA512},
+	{"sha384", crypto.SHA384},
+	{"sha256", crypto.SHA256},
+}
+
+func verifyEnvelopeWithArtifact(verifier signature.Verifier, envelope EnvelopeContent, artifact io.Reader) (string, error) {
 	err := verifyEnvelope(verifier, envelope)
 	if err != nil {
-		return err
+		return "", err
 	}
 	statement, err := envelope.Statement()
 	if err != nil {
-		return fmt.Errorf("could not verify artifact: unable to extract statement from envelope: %w", err)
+		return "", fmt.Errorf("could not verify artifact: unable to extract statement from envelope: %w", err)
 	}
-	var artifactDigestAlgorithm string
-	var artifactDigest []byte
-
-	// Determine artifact digest algorithm by looking at the first subject's
-	// digests. This assumes that if a statement contains multiple subjects,
-	// they all use the same digest algorithm(s).
 	if len(statement.Subject) == 0 {
-		return errors.New("no subjects found in statement")
-	}
-	if len(statement.Subject[0].Digest) == 0 {
-		return errors.New("no digests found in statement")
+		return "", errors.New("no subjects found in statement")
 	}
 
-	// Select the strongest digest algorithm available.
-	for _, alg := range []string{"sha512", "sha384", "sha256"} {
-		if _, ok := statement.Subject[0].Digest[alg]; ok {
-			artifactDigestAlgorithm = alg
-			continue
+	// Hash the artifact, in a single pass, with only the supported
+	// algorithms that subjects have digests for
+	hashers := make(map[string]hash.Hash)
+	var writers []io.Writer
+	for _, alg := range artifactDigestAlgorithms {
+		for _, subject := range statement.Subject {
+			if _, ok := subject.Digest[alg.name]; ok {
+				hashers[alg.name] = alg.hashFunc.New()
+				writers = append(writers, hashers[alg.name])
+				break
+			}
 		}
 	}
-	if artifactDigestAlgorithm == "" {
-		return errors.New("could not verify artifact: unsupported digest algorithm")
+	if len(writers) == 0 {
+		return "", errors.New("could not verify artifact: no subject digest with a supported digest algorithm")
 	}
-
-	// Compute digest of the artifact.
-	var hasher hash.Hash
-	switch artifactDigestAlgorithm {
-	case "sha512":
-		hasher = crypto.SHA512.New()
-	case "sha384":
-		hasher = crypto.SHA384.New()
-	case "sha256":
-		hasher = crypto.SHA256.New()
-	}
-	_, err = io.Copy(hasher, artifact)
-	if err != nil {
-		return fmt.Errorf("could not verify artifact: unable to calculate digest: %w", err)
+	if _, err = io.Copy(io.MultiWriter(writers...), artifact); err != nil {
+		return "", fmt.Errorf("could not verify artifact: unable to calculate digest: %w", err)
 	}
-	artifactDigest = hasher.Sum(nil)
 
-	// Look for artifact digest in statement
-	for _, subject := range statement.Subject {
-		for alg, digest := range subject.Digest {
+	// Look for artifact digest in statement, reporting the strongest
+	// algorithm that matched
+	for _, alg := range artifactDigestAlgorithms {
+		hasher, ok := hashers[alg.name]
+		if !ok {
+			continue
+		}
+		artifactDigest := hasher.Sum(nil)
+		for _, subject := range statement.Subject {
+			digest, ok := subject.Digest[alg.name]
+			if !ok {
+				continue
+			}
 			hexdigest, err := hex.DecodeString(digest)
 			if err != nil {
-				return fmt.Errorf("could not verify artifact: unable to decode subject digest: %w", err)
+				return "", fmt.Errorf("could not verify artifact: unable to decode subject digest: %w", err)
 			}
-			if alg == artifactDigestAlgorithm && bytes.Equal(artifactDigest, hexdigest) {
-				return nil
+			if bytes.Equal(artifactDigest, hexdigest) {
+				return alg.name, nil
 			}
 		}
 	}
-	return fmt.Errorf("could not verify artifact: unable to confirm artifact digest is present in subject digests: %w", err)
+	return "", artifactMismatch(errors.New("could not verify artifact: unable to confirm artifact digest is present in subject digests"))
 }
 
 func verifyEnvelopeWithArtifactDigest(verifier signature.Verifier, envelope EnvelopeContent, artifactDigest []byte, artifactDigestAlgorithm string) error {
@@ -194,20 +329,14 @@ func verifyEnvelopeWithArtifactDigest(verifier signature.Verifier, envelope Enve
 	if err != nil {
 		return fmt.Errorf("could not verify artifact: unable to extract statement from envelope: %w", err)
 	}
+	// Compare hex encodings rather than decoding every subject digest
+	hexArtifactDigest := hex.EncodeToString(artifactDigest)
 	for _, subject := range statement.Subject {
-		for alg, digest := range subject.Digest {
-			if alg == artifactDigestAlgorithm {
-				hexdigest, err := hex.DecodeString(digest)
-				if err != nil {
-					return fmt.Errorf("could not verify artifact: unable to decode subject digest: %w", err)
-				}
-				if bytes.Equal(hexdigest, artifactDigest) {
-					return nil
-				}
-			}
+		if digest, ok := subject.Digest[artifactDigestAlgorithm]; ok && strings.EqualFold(digest, hexArtifactDigest) {
+			return nil
 		}
 	}
-	return errors.New("provided artifact digest does not match any digest in statement")
+	return artifactMismatch(errors.New("provided artifact digest does not match any digest in statement"))
 }
 
 func verifyMessageSignature(verifier signature.Verifier, msg MessageSignatureContent, artifact io.Reader) error {
@@ -219,9 +348,74 @@ func verifyMessageSignature(verifier signature.Verifier, msg MessageSignatureCon
 	return nil
 }
 
+// messageDigestAlgorithms maps the digest algorithms of message signatures
+// to the hash functions and names with which artifacts are hashed.
+var messageDigestAlgorithms = map[string]struct {
+	name     string
+	hashFunc crypto.Hash
+}{
+	"SHA2_256": {"sha256", crypto.SHA256},
+	"SHA2_384": {"sha384", crypto.SHA384},
+	"SHA2_512": {"sha512", crypto.SHA512},
+}
+
+// verifyMessageSignatureWithArtifact hashes the artifact with the message
+// signature's digest algorithm, checks it against the message digest, and
+// verifies the signature over that digest.
+func verifyMessageSignatureWithArtifact(verifier signature.Verifier, msg MessageSignatureContent, artifact io.Reader) (string, error) {
+	alg, ok := messageDigestAlgorithms[msg.DigestAlgorithm()]
+	if !ok || len(msg.Digest()) == 0 {
+		// Without a known digest, only the signature binds the artifact
+		return "", verifyMessageSignature(verifier, msg, artifact)
+	}
+	hasher := alg.hashFunc.New()
+
+	if signsMessages(verifier) {
+		// The signature is over the artifact itself, so hash it while it
+		// is verified
+		if err := verifyMessageSignature(verifier, msg, io.TeeReader(artifact, hasher)); err != nil {
+			return "", err
+		}
+		if !bytes.Equal(hasher.Sum(nil), msg.Digest()) {
+			return "", artifactMismatch(errors.New("artifact does not match digest"))
+		}
+		return alg.name, nil
+	}
+
+	if _, err := io.Copy(hasher, artifact); err != nil {
+		return "", fmt.Errorf("could not verify artifact: unable to calculate digest: %w", err)
+	}
+	artifactDigest := hasher.Sum(nil)
+	if !bytes.Equal(artifactDigest, msg.Digest()) {
+		return "", artifactMismatch(errors.New("artifact does not match digest"))
+	}
+	err := verifier.VerifySignature(bytes.NewReader(msg.Signature()), bytes.NewReader([]byte{}), options.WithDigest(artifactDigest), options.WithCryptoSignerOpts(alg.hashFunc))
+	if err != nil {
+		return "", fmt.Errorf("could not verify message: %w", err)
+	}
+	return alg.name, nil
+}
+
+// signsMessages reports whether the verifier's signatures are over messages
+// rather than their digests, as for ed25519 and ML-DSA keys.
+func signsMessages(verifier signature.Verifier) bool {
+	if _, ok := verifier.(*signature.ED25519Verifier); ok {
+		return true
+	}
+	pub, err := verifier.PublicKey()
+	if err != nil {
+		return false
+	}
+	if _, ok := pub.(ed25519.PublicKey); ok {
+		return true
+	}
+	_, ok, _ := mldsa.LoadVerifier(pub)
+	return ok
+}
+
 func verifyMessageSignatureWithArtifactDigest(verifier signature.Verifier, msg MessageSignatureContent, artifactDigest []byte) error {
 	if !bytes.Equal(artifactDigest, msg.Digest()) {
-		return errors.New("artifact does not match digest")
+		return artifactMismatch(errors.New("artifact does not match digest"))
 	}
 	if _, ok := verifier.(*signature.ED25519Verifier); ok {
 		return errors.New("message signatures with ed25519 signatures can only be verified with artifacts, and not just their digest")
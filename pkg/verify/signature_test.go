@@ -16,14 +16,27 @@ package verify_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var SkipArtifactAndIdentitiesPolicy = verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe())
@@ -113,3 +126,186 @@ func TestSignatureVerifierMessageSignature(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func BenchmarkVerifyEnvelope(b *testing.B) {
+	var subjects []string
+	for i := 0; i < 200; i++ {
+		digest := sha256.Sum256([]byte(fmt.Sprintf("subject-%d", i)))
+		subjects = append(subjects, fmt.Sprintf(`{"name":"subject-%d","digest":{"sha256":"%s"}}`, i, hex.EncodeToString(digest[:])))
+	}
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","predicateType":"customFoo","subject":[%s],"predicate":{}}`, strings.Join(subjects, ","))
+	lastDigest := sha256.Sum256([]byte("subject-199"))
+
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	if err != nil {
+		b.Fatal(err)
+	}
+	entity, err := virtualSigstore.SignWithKey("bench-key", &sign.DSSEData{Data: []byte(statement), PayloadType: "application/vnd.in-toto+json"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	verificationContent, err := entity.VerificationContent()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("VerifySignatureWithArtifactDigest", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sigContent, err := entity.SignatureContent()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := verify.VerifySignatureWithArtifactDigest(sigContent, verificationContent, virtualSigstore, lastDigest[:], "sha256"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Verify", func(b *testing.B) {
+		verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+		if err != nil {
+			b.Fatal(err)
+		}
+		policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", lastDigest[:]), verify.WithoutIdentitiesUnsafe())
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := verifier.Verify(entity, policy); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestEd25519phMessageSignature(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keypair, err := sign.NewPrivateKeyKeypair(key, &sign.PrivateKeyKeypairOptions{Hint: []byte("ed25519ph"), Ed25519ph: true})
+	require.NoError(t, err)
+
+	artifact := bytes.Repeat([]byte("large artifact "), 1000)
+	pb, err := sign.Bundle(&sign.PlainData{Data: artifact}, keypair, sign.BundleOptions{})
+	require.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+	sigContent, err := b.SignatureContent()
+	require.NoError(t, err)
+	verificationContent, err := b.VerificationContent()
+	require.NoError(t, err)
+
+	verifier, err := root.LoadVerifierWithKeyDetails(pub, protocommon.PublicKeyDetails_PKIX_ED25519_PH)
+	require.NoError(t, err)
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"ed25519ph": root.NewExpiringKey(verifier, time.Time{}, time.Time{}),
+	})
+
+	// The artifact is hashed as it is read
+	err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, tm, bytes.NewReader(artifact))
+	assert.NoError(t, err)
+	err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, tm, strings.NewReader("other artifact"))
+	assert.Error(t, err)
+
+	// Unlike pure Ed25519, the artifact's digest is enough
+	digest := sha512.Sum512(artifact)
+	err = verify.VerifySignatureWithArtifactDigest(sigContent, verificationContent, tm, digest[:], "sha512")
+	assert.NoError(t, err)
+
+	// A pure Ed25519 verifier for the same key rejects the signature
+	pureVerifier, err := signature.LoadED25519Verifier(pub)
+	require.NoError(t, err)
+	tm = root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"ed25519ph": root.NewExpiringKey(pureVerifier, time.Time{}, time.Time{}),
+	})
+	err = verify.VerifySignatureWithArtifact(sigContent, verificationContent, tm, bytes.NewReader(artifact))
+	assert.Error(t, err)
+}
+
+func TestArtifactDigestAlgorithmInference(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	require.NoError(t, err)
+
+	artifact := []byte("Hi, I am an artifact!")
+	digest256 := sha256.Sum256(artifact)
+	digest384 := sha512.Sum384(artifact)
+	digest512 := sha512.Sum512(artifact)
+	otherDigest := sha256.Sum256([]byte("Hi, I am a different artifact!"))
+
+	for _, tc := range []struct {
+		name      string
+		subjects  string
+		algorithm string
+	}{
+		{"strongest algorithm", fmt.Sprintf(`{"name":"a","digest":{"sha256":"%x","sha512":"%x"}}`, digest256, digest512), "sha512"},
+		{"only sha384", fmt.Sprintf(`{"name":"a","digest":{"sha384":"%x"}}`, digest384), "sha384"},
+		{"algorithm of a later subject", fmt.Sprintf(`{"name":"b","digest":{"sha256":"%x"}},{"name":"a","digest":{"sha512":"%x"}}`, otherDigest, digest512), "sha512"},
+		{"unsupported algorithm ignored", fmt.Sprintf(`{"name":"a","digest":{"gitCommit":"abc","sha256":"%x"}}`, digest256), "sha256"},
+		{"no supported algorithm", `{"name":"a","digest":{"gitCommit":"abc"}}`, ""},
+		{"no matching digest", fmt.Sprintf(`{"name":"b","digest":{"sha256":"%x"}}`, otherDigest), ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[` + tc.subjects + `],"predicate":{}}`)
+			entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+			require.NoError(t, err)
+			result, err := verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+			if tc.algorithm == "" {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.algorithm, result.ArtifactDigestAlgorithm)
+		})
+	}
+
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	require.NoError(t, err)
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	require.NoError(t, err)
+	result, err := verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256", result.ArtifactDigestAlgorithm)
+	result, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactDigest("sha256", digest256[:]), verify.WithoutIdentitiesUnsafe()))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256", result.ArtifactDigestAlgorithm)
+
+	// A message signature must bind the artifact with its digest as well as
+	// its signature
+	sigContent, err := entity.SignatureContent()
+	require.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	require.NoError(t, err)
+	msg := sigContent.MessageSignatureContent()
+	wrongDigest := bundle.NewMessageSignature(otherDigest[:], msg.DigestAlgorithm(), msg.Signature())
+	err = verify.VerifySignatureWithArtifact(wrongDigest, verificationContent, virtualSigstore, bytes.NewReader(artifact))
+	assert.ErrorContains(t, err, "artifact does not match digest")
+}
+
+func TestMessageSignatureDigestAlgorithm(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	keypair, err := sign.NewPrivateKeyKeypair(key, &sign.PrivateKeyKeypairOptions{Hint: []byte("p384")})
+	require.NoError(t, err)
+
+	artifact := []byte("Hi, I am an artifact!")
+	pb, err := sign.Bundle(&sign.PlainData{Data: artifact}, keypair, sign.BundleOptions{})
+	require.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	sigVerifier, err := root.LoadVerifierWithKeyDetails(&key.PublicKey, protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384)
+	require.NoError(t, err)
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"p384": root.NewExpiringKey(sigVerifier, time.Time{}, time.Time{}),
+	})
+	verifier, err := verify.NewSignedEntityVerifier(tm, verify.WithoutAnyObserverTimestampsInsecure())
+	require.NoError(t, err)
+
+	result, err := verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	require.NoError(t, err)
+	assert.Equal(t, "sha384", result.ArtifactDigestAlgorithm)
+
+	_, err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifact(strings.NewReader("other artifact")), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+}
@@ -15,14 +15,20 @@
 package verify
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"time"
 
 	"github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/git"
+	"github.com/sigstore/sigstore-go/pkg/oci"
 	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/util"
+	"github.com/sigstore/sigstore-go/pkg/wasm"
 )
 
 const (
@@ -66,10 +72,53 @@ type VerifierConfig struct { // nolint: revive
 	// ctlogEntriesTreshold is the minimum number of verified SCTs in
 	// a Fulcio certificate
 	ctlogEntriesThreshold int
+	// weDoNotExpectSCTs explicitly acknowledges that Fulcio certificates
+	// are not verified against a CT log, as with private Fulcio instances
+	// that have none
+	weDoNotExpectSCTs bool
 	// weDoNotExpectAnyObserverTimestamps uses the certificate's lifetime
 	// rather than a provided signed or log timestamp. Most workflows will
 	// not use this option
 	weDoNotExpectAnyObserverTimestamps bool
+	// logger receives debug logs of verification step outcomes
+	logger *slog.Logger
+	// extensionRegistry decodes custom certificate extensions; nil uses
+	// certificate.DefaultExtensionRegistry
+	extensionRegistry *certificate.ExtensionRegistry
+	// evidenceRequirement replaces the thresholds above with a composite
+	// requirement on the verified evidence
+	evidenceRequirement EvidenceRequirement
+	// degradedChecks are the checks whose failure is recorded as a warning
+	// rather than failing verification
+	degradedChecks map[DegradableCheck]bool
+	// metrics records verification outcomes and phase durations; nil
+	// records nothing
+	metrics *verifierMetrics
+	// maxCertificateChainDepth is the maximum number of certificates in
+	// the signing certificate's chain; 0 means no maximum
+	maxCertificateChainDepth int
+	// rejectWeakCertificateAlgorithms rejects signing certificates whose
+	// chain uses weak signature or key algorithms
+	rejectWeakCertificateAlgorithms bool
+	// strictECDSASignatures rejects ECDSA signatures that are not strictly
+	// DER-encoded, and lowSECDSASignatures also those with a high s value
+	strictECDSASignatures bool
+	lowSECDSASignatures   bool
+	// minBundleVersion and maxBundleVersion are the range of bundle
+	// versions accepted, e.g. "v0.2"; empty means unbounded
+	minBundleVersion string
+	maxBundleVersion string
+	// requireTimestampAgreement requires log entry integrated timestamps
+	// and signed timestamps that differ by at most maxTimestampSkew
+	requireTimestampAgreement bool
+	maxTimestampSkew          time.Duration
+	// requireFreshTrustedMaterial requires trusted material fetched from
+	// TUF at most trustedMaterialMaxAge ago, whose metadata has not expired
+	requireFreshTrustedMaterial bool
+	trustedMaterialMaxAge       time.Duration
+	// keyHintSchemes are the schemes WithKeyHint policies are matched
+	// under; nil uses root.DefaultKeyHintSchemes
+	keyHintSchemes []root.KeyHintScheme
 }
 
 type VerifierOption func(*VerifierConfig) error
@@ -186,6 +235,21 @@ func WithSignedCertificateTimestamps(threshold int) VerifierOption {
 	}
 }
 
+// WithoutSCTRequired configures the SignedEntityVerifier to not verify
+// SignedCertificateTimestamps of Fulcio certificates, for private Fulcio
+// instances that do not log to a CT log. Without SCTs, there is no public
+// record of the certificates a Fulcio instance has issued.
+//
+// Results of verifying an entity signed with a certificate record that SCT
+// verification was skipped. This option cannot be combined with
+// WithSignedCertificateTimestamps.
+func WithoutSCTRequired() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.weDoNotExpectSCTs = true
+		return nil
+	}
+}
+
 // WithoutAnyObserverTimestampsInsecure configures the SignedEntityVerifier to not expect
 // any timestamps from either a Timestamp Authority or a Transparency Log.
 //
@@ -202,7 +266,62 @@ func WithoutAnyObserverTimestampsInsecure() VerifierOption {
 	}
 }
 
+// WithLogger configures the SignedEntityVerifier to log the outcome of each
+// verification step at debug level.
+func WithLogger(logger *slog.Logger) VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithExtensionRegistry configures the SignedEntityVerifier to decode the
+// custom certificate extensions registered in registry, instead of those in
+// certificate.DefaultExtensionRegistry. Decoded extensions are included in
+// the verification result, and can be required by CertificateIdentity
+// policies.
+func WithExtensionRegistry(registry *certificate.ExtensionRegistry) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if registry == nil {
+			return errors.New("extension registry must not be nil")
+		}
+		c.extensionRegistry = registry
+		return nil
+	}
+}
+
+// WithKeyHintSchemes configures the SignedEntityVerifier to match the hints
+// of WithKeyHint policies against the signing key under the given schemes,
+// instead of root.DefaultKeyHintSchemes, for policies that name keys by
+// hints computed as another ecosystem does, such as
+// root.SubjectKeyIDKeyHint.
+func WithKeyHintSchemes(schemes ...root.KeyHintScheme) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if len(schemes) == 0 {
+			return errors.New("at least one key hint scheme is required")
+		}
+		c.keyHintSchemes = schemes
+		return nil
+	}
+}
+
 func (c *VerifierConfig) Validate() error {
+	if c.weExpectSCTs && c.weDoNotExpectSCTs {
+		return errors.New("WithSignedCertificateTimestamps() and WithoutSCTRequired() cannot be combined")
+	}
+
+	if c.evidenceRequirement != nil && c.degradedChecks[DegradableCheckTransparencyLog] {
+		return errors.New("WithEvidenceRequirement() cannot be combined with a degraded transparency log check")
+	}
+
+	if c.evidenceRequirement != nil {
+		if c.weExpectTlogEntries || c.weExpectSignedTimestamps || c.requireIntegratedTimestamps ||
+			c.requireObserverTimestamps || c.weExpectSCTs || c.weDoNotExpectAnyObserverTimestamps {
+			return errors.New("WithEvidenceRequirement() cannot be combined with threshold options")
+		}
+		return nil
+	}
+
 	if !c.requireObserverTimestamps && !c.weExpectSignedTimestamps && !c.requireIntegratedTimestamps && !c.weDoNotExpectAnyObserverTimestamps {
 		return errors.New("when initializing a new SignedEntityVerifier, you must specify at least one of " +
 			"WithObserverTimestamps(), WithSignedTimestamps(), WithIntegratedTimestamps(), or WithoutAnyObserverTimestampsInsecure()")
@@ -217,6 +336,29 @@ type VerificationResult struct {
 	Signature          *SignatureVerificationResult  `json:"signature,omitempty"`
 	VerifiedTimestamps []TimestampVerificationResult `json:"verifiedTimestamps"`
 	VerifiedIdentity   *CertificateIdentity          `json:"verifiedIdentity,omitempty"`
+	// VerifiedSubjectAlternativeName is the certificate SAN that matched
+	// VerifiedIdentity
+	VerifiedSubjectAlternativeName *certificate.SubjectAlternativeName `json:"verifiedSubjectAlternativeName,omitempty"`
+	TrustedMaterial                []TrustedMaterialMatch              `json:"trustedMaterial,omitempty"`
+	// SCTVerificationSkipped is set if the entity was signed with a
+	// certificate whose SCTs were not verified, because the verifier was
+	// configured WithoutSCTRequired
+	SCTVerificationSkipped bool `json:"sctVerificationSkipped,omitempty"`
+	// Warnings lists the checks that failed without failing verification,
+	// because the verifier was configured WithDegradedChecks
+	Warnings []VerificationWarning `json:"warnings,omitempty"`
+	// ServicesUsed lists the transparency logs, CT logs, certificate
+	// authorities and timestamping authorities of the trusted material
+	// that verification relied on
+	ServicesUsed []TrustedService `json:"servicesUsed,omitempty"`
+	// ArtifactDigestAlgorithm is the digest algorithm, such as sha256,
+	// with which the artifact was matched to the signed content: inferred
+	// from the signed content with WithArtifact, or as given to
+	// WithArtifactDigest
+	ArtifactDigestAlgorithm string `json:"artifactDigestAlgorithm,omitempty"`
+	// SubjectMatches reports which subjects of the statement matched the
+	// artifacts of an AnySubjectMatches or AllSubjectsMatch policy
+	SubjectMatches []SubjectMatch `json:"subjectMatches,omitempty"`
 }
 
 type SignatureVerificationResult struct {
@@ -228,6 +370,9 @@ type TimestampVerificationResult struct {
 	Type      string    `json:"type"`
 	URI       string    `json:"uri"`
 	Timestamp time.Time `json:"timestamp"`
+	// RFC3161 is set for timestamps from a timestamping authority, and
+	// contains the fields parsed from the verified timestamp token
+	RFC3161 *RFC3161Timestamp `json:"rfc3161,omitempty"`
 }
 
 func NewVerificationResult() *VerificationResult {
@@ -277,13 +422,23 @@ type PolicyConfig struct {
 	verifyArtifactDigest    bool
 	artifactDigest          []byte
 	artifactDigestAlgorithm string
+	keyHint                 string
+	trustedOIDCProviders    bool
+	// subjectMatchMode and subjectArtifacts are set by AnySubjectMatches
+	// and AllSubjectsMatch
+	subjectMatchMode subjectMatchMode
+	subjectArtifacts []ArtifactDigest
 }
 
 func (p *PolicyConfig) Validate() error {
-	if p.WeExpectIdentities() && len(p.certificateIdentities) == 0 {
+	if p.WeExpectIdentities() && len(p.certificateIdentities) == 0 && p.keyHint == "" {
 		return errors.New("can't verify identities without providing at least one identity")
 	}
 
+	if len(p.certificateIdentities) > 0 && p.keyHint != "" {
+		return errors.New("can't use WithKeyHint while specifying CertificateIdentities")
+	}
+
 	return nil
 }
 
@@ -371,6 +526,48 @@ func WithCertificateIdentity(identity CertificateIdentity) PolicyOption {
 	}
 }
 
+// WithKeyHint allows the caller of Verify to enforce that the SignedEntity
+// being verified was signed with a specific public key, rather than a Fulcio
+// certificate. This is the counterpart to WithCertificateIdentity for
+// entities signed with long-lived keys, and may be used in its place.
+//
+// The hint matches if it is equal to the hint in the entity's verification
+// material, or if it identifies the public key resolved from the trusted
+// material under one of root.DefaultKeyHintSchemes, or the schemes given to
+// WithKeyHintSchemes. If the SignedEntity was signed with a certificate,
+// verification will fail.
+func WithKeyHint(hint string) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if hint == "" {
+			return errors.New("key hint must not be empty")
+		}
+		if p.keyHint != "" {
+			return errors.New("only one invocation of WithKeyHint is allowed")
+		}
+
+		p.keyHint = hint
+		return nil
+	}
+}
+
+// WithTrustedOIDCProviders allows the caller of Verify to enforce that the
+// issuer extension of the SignedEntity's certificate is the issuer URL of
+// one of the OIDC providers listed by the trusted material, as returned by
+// root.OIDCProviders, that was trusted when the certificate was issued.
+//
+// This restricts issuers to those configured for the Fulcio instance, for
+// example from its signing config with root.OIDCProvidersFromSigningConfig,
+// rather than to strings given in each CertificateIdentity. It is checked in
+// addition to, not instead of, the certificate identities. If the
+// SignedEntity does not have a certificate, or the trusted material lists
+// no OIDC providers, verification will fail.
+func WithTrustedOIDCProviders() PolicyOption {
+	return func(p *PolicyConfig) error {
+		p.trustedOIDCProviders = true
+		return nil
+	}
+}
+
 // WithoutArtifactUnsafe allows the caller of Verify to skip checking whether
 // the SignedEntity was created from, or references, an artifact.
 //
@@ -402,10 +599,15 @@ func WithoutArtifactUnsafe() ArtifactPolicyOption {
 // If the SignedEntity contains a DSSE envelope, then the artifact digest is
 // calculated from the given artifact, and compared to the digest in the
 // envelope's statement.
+//
+// The artifact is only hashed with the digest algorithms that the
+// SignedEntity binds it with: those of the statement's subjects, or that of
+// a MessageSignature's digest. The algorithm that matched is reported in
+// VerificationResult.ArtifactDigestAlgorithm.
 func WithArtifact(artifact io.Reader) ArtifactPolicyOption {
 	return func(p *PolicyConfig) error {
-		if p.verifyArtifact || p.verifyArtifactDigest {
-			return errors.New("only one invocation of WithArtifact/WithArtifactDigest is allowed")
+		if p.verifyArtifact || p.verifyArtifactDigest || p.subjectMatchMode != 0 {
+			return errors.New("only one invocation of WithArtifact/WithArtifactDigest/AnySubjectMatches/AllSubjectsMatch is allowed")
 		}
 
 		if p.weDoNotExpectAnArtifact {
@@ -418,6 +620,62 @@ func WithArtifact(artifact io.Reader) ArtifactPolicyOption {
 	}
 }
 
+// WithGitObject allows the caller of Verify to enforce that the SignedEntity
+// being verified is a signature over the given git commit or tag object, as
+// output by `git cat-file commit|tag`. Any signature embedded in the object
+// is ignored, as it is not part of the signed payload.
+func WithGitObject(object []byte) ArtifactPolicyOption {
+	payload, err := git.SignedPayload(object)
+	if err != nil {
+		return func(_ *PolicyConfig) error {
+			return fmt.Errorf("invalid git object: %w", err)
+		}
+	}
+	return WithArtifact(bytes.NewReader(payload))
+}
+
+// WithOCIImage allows the caller of Verify to enforce that the SignedEntity
+// being verified is a cosign signature over the container image with the
+// given manifest digest, e.g. "sha256:<hex>". payload is the signed cosign
+// simple signing payload, the content of the image's signature layer, and
+// must bind the manifest digest.
+//
+// The payload's docker-reference is not checked, as images are commonly
+// copied between registries; callers that require a repository should
+// check it with oci.ParseSimpleSigningPayload.
+func WithOCIImage(payload []byte, manifestDigest string) ArtifactPolicyOption {
+	simpleSigning, err := oci.ParseSimpleSigningPayload(payload)
+	if err != nil {
+		return func(_ *PolicyConfig) error {
+			return fmt.Errorf("invalid simple signing payload: %w", err)
+		}
+	}
+	if simpleSigning.Critical.Image.DockerManifestDigest != manifestDigest {
+		return func(_ *PolicyConfig) error {
+			return fmt.Errorf("simple signing payload is for image %s, not %s", simpleSigning.Critical.Image.DockerManifestDigest, manifestDigest)
+		}
+	}
+	return WithArtifact(bytes.NewReader(payload))
+}
+
+// WithWasmModule allows the caller of Verify to enforce that the
+// SignedEntity being verified is a signature or statement over the given
+// WebAssembly module, leaving out the custom sections that opts excludes,
+// such as sections that hold signatures. opts must match the ones the
+// module was signed with.
+//
+// WithWasmModule is experimental and may change in backwards incompatible
+// ways.
+func WithWasmModule(module []byte, opts *wasm.Options) ArtifactPolicyOption {
+	canonical, err := wasm.Canonicalize(module, opts)
+	if err != nil {
+		return func(_ *PolicyConfig) error {
+			return fmt.Errorf("invalid WebAssembly module: %w", err)
+		}
+	}
+	return WithArtifact(bytes.NewReader(canonical))
+}
+
 // WithArtifactDigest allows the caller of Verify to enforce that the
 // SignedEntity being verified was created for a given artifact digest.
 //
@@ -429,8 +687,8 @@ func WithArtifact(artifact io.Reader) ArtifactPolicyOption {
 // compared to the digest in the envelope's statement.
 func WithArtifactDigest(algorithm string, artifactDigest []byte) ArtifactPolicyOption {
 	return func(p *PolicyConfig) error {
-		if p.verifyArtifact || p.verifyArtifactDigest {
-			return errors.New("only one invocation of WithArtifact/WithArtifactDigest is allowed")
+		if p.verifyArtifact || p.verifyArtifactDigest || p.subjectMatchMode != 0 {
+			return errors.New("only one invocation of WithArtifact/WithArtifactDigest/AnySubjectMatches/AllSubjectsMatch is allowed")
 		}
 
 		if p.weDoNotExpectAnArtifact {
@@ -467,23 +725,97 @@ func WithArtifactDigest(algorithm string, artifactDigest []byte) ArtifactPolicyO
 func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*VerificationResult, error) {
 	policy, err := pb.BuildConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build policy: %w", err)
+		err = fmt.Errorf("failed to build policy: %w", err)
+		v.config.metrics.observeVerification(entity, nil, v.trustedMaterial, err)
+		return nil, err
 	}
 
+	result, err := v.verify(entity, policy)
+	v.config.metrics.observeVerification(entity, policy, v.trustedMaterial, err)
+	return result, err
+}
+
+func (v *SignedEntityVerifier) verify(entity SignedEntity, policy *PolicyConfig) (*VerificationResult, error) {
 	// Let's go by the spec: https://docs.google.com/document/d/1kbhK2qyPPk8SLavHzYSDM8-Ueul9_oxIMVFuWMWKz0E/edit#heading=h.g11ovq2s1jxh
 	// > ## Transparency Log Entry
-	verifiedTlogTimestamps, err := v.VerifyTransparencyLogInclusion(entity)
-	if err != nil {
-		return nil, fmt.Errorf("failed to verify log inclusion: %w", err)
+	logger := util.Logger(v.config.logger)
+	phases := v.config.metrics.newPhaseTimer()
+	defer phases.end()
+
+	if err := v.config.checkBundleVersion(entity); err != nil {
+		logger.Debug("bundle version check failed", "error", err)
+		return nil, err
 	}
 
-	// > ## Establishing a Time for the Signature
-	// > First, establish a time for the signature. This timestamp is required to validate the certificate chain, so this step comes first.
-	verifiedTimestamps, err := v.VerifyObserverTimestamps(entity, verifiedTlogTimestamps)
-	if err != nil {
+	if err := v.config.checkFreshness(v.trustedMaterial); err != nil {
+		logger.Debug("trusted material freshness check failed", "error", err)
+		return nil, err
+	}
+
+	phases.begin(phaseTransparencyLog)
+	var evidence Evidence
+	var verifiedTimestamps []TimestampVerificationResult
+	var warnings []VerificationWarning
+	var tlogs []*root.TransparencyLog
+	// agreementTimestamps are the timestamps checked for agreement, which
+	// include log entry integrated timestamps even when they are not used
+	// to verify the certificate
+	var agreementTimestamps []TimestampVerificationResult
+	if v.config.evidenceRequirement != nil {
+		// The requirement is checked once SCTs have been counted
+		var err error
+		evidence, verifiedTimestamps, tlogs, err = v.verifyEvidence(entity)
+		if err != nil {
+			logger.Debug("evidence verification failed", "error", err)
+			return nil, err
+		}
+		agreementTimestamps = verifiedTimestamps
+	} else {
+		var verifiedTlogTimestamps []TimestampVerificationResult
+		var err error
+		verifiedTlogTimestamps, tlogs, err = v.verifyTransparencyLogInclusion(entity)
+		if err != nil {
+			logger.Debug("transparency log verification failed", "error", err)
+			if err = v.degrade(DegradableCheckTransparencyLog, fmt.Errorf("failed to verify log inclusion: %w", err), &warnings); err != nil {
+				return nil, err
+			}
+		} else if v.config.weExpectTlogEntries {
+			logger.Debug("verified transparency log entries", "threshold", v.config.tlogEntriesThreshold)
+		}
+
+		// > ## Establishing a Time for the Signature
+		// > First, establish a time for the signature. This timestamp is required to validate the certificate chain, so this step comes first.
+		phases.begin(phaseTimestamps)
+		verifiedTimestamps, err = v.VerifyObserverTimestamps(entity, verifiedTlogTimestamps)
+		if err != nil {
+			logger.Debug("timestamp verification failed", "error", err)
+			return nil, fmt.Errorf("failed to verify timestamps: %w", err)
+		}
+		agreementTimestamps = append([]TimestampVerificationResult{}, verifiedTlogTimestamps...)
+		for _, ts := range verifiedTimestamps {
+			if ts.Type != "Tlog" {
+				agreementTimestamps = append(agreementTimestamps, ts)
+			}
+		}
+	}
+	if err := v.config.checkTimestampAgreement(agreementTimestamps); err != nil {
+		logger.Debug("timestamp agreement check failed", "error", err)
 		return nil, fmt.Errorf("failed to verify timestamps: %w", err)
 	}
 
+	var services []TrustedService
+	for _, tlog := range tlogs {
+		if tlog != nil {
+			services = addTrustedService(services, logService(TrustedServiceTransparencyLog, tlog))
+		}
+	}
+	for _, ts := range verifiedTimestamps {
+		logger.Debug("verified timestamp", "type", ts.Type, "timestamp", ts.Timestamp)
+		if ts.RFC3161 != nil {
+			services = addTrustedService(services, ts.RFC3161.authority)
+		}
+	}
+
 	verificationContent, err := entity.VerificationContent()
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch verification content: %w", err)
@@ -491,11 +823,15 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 
 	var signedWithCertificate bool
 	var certSummary certificate.Summary
+	var certNotBefore time.Time
+	var keyHint string
 
 	// If the bundle was signed with a long-lived key, and does not have a Fulcio certificate,
 	// then skip the certificate verification steps
 	if leafCert, ok := verificationContent.HasCertificate(); ok {
+		phases.begin(phaseCertificate)
 		signedWithCertificate = true
+		certNotBefore = leafCert.NotBefore
 
 		// From spec:
 		// > ## Certificate
@@ -504,42 +840,98 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 
 		for _, verifiedTs := range verifiedTimestamps {
 			// verify the leaf certificate against the root
-			err = VerifyLeafCertificate(verifiedTs.Timestamp, leafCert, v.trustedMaterial)
+			ca, err := verifyLeafCertificate(verifiedTs.Timestamp, leafCert, v.trustedMaterial, v.config.certificateChainCheck())
 			if err != nil {
+				logger.Debug("leaf certificate verification failed", "timestamp", verifiedTs.Timestamp, "error", err)
 				return nil, fmt.Errorf("failed to verify leaf certificate: %w", err)
 			}
+			services = addTrustedService(services, certificateAuthorityService(TrustedServiceCertificateAuthority, ca))
 		}
+		logger.Debug("verified leaf certificate chain", "serial", leafCert.SerialNumber.String())
 
 		// From spec:
 		// > Unless performing online verification (see §Alternative Workflows), the Verifier MUST extract the  SignedCertificateTimestamp embedded in the leaf certificate, and verify it as in RFC 9162 §8.1.3, using the verification key from the Certificate Transparency Log.
 
 		if v.config.weExpectSCTs {
-			err = VerifySignedCertificateTimestamp(&leafCert, v.config.ctlogEntriesThreshold, v.trustedMaterial)
+			var detachedSCTs [][]byte
+			if provider, ok := verificationContent.(DetachedSCTProvider); ok {
+				detachedSCTs, _ = provider.HasDetachedSCTs()
+			}
+			ctlogs, err := verifySignedCertificateTimestampsWithThreshold(&leafCert, detachedSCTs, v.config.ctlogEntriesThreshold, v.trustedMaterial)
+			for _, ctlog := range ctlogs {
+				services = addTrustedService(services, logService(TrustedServiceCTLog, ctlog))
+			}
+			if err != nil {
+				logger.Debug("signed certificate timestamp verification failed", "error", err)
+				if err = v.degrade(DegradableCheckSignedCertificateTimestamps, fmt.Errorf("failed to verify signed certificate timestamp: %w", err), &warnings); err != nil {
+					return nil, err
+				}
+			} else {
+				logger.Debug("verified signed certificate timestamps", "threshold", v.config.ctlogEntriesThreshold, "detached", len(detachedSCTs))
+			}
+		} else if v.config.evidenceRequirement != nil && !v.config.weDoNotExpectSCTs {
+			var detachedSCTs [][]byte
+			if provider, ok := verificationContent.(DetachedSCTProvider); ok {
+				detachedSCTs, _ = provider.HasDetachedSCTs()
+			}
+			ctlogs, err := verifySignedCertificateTimestamps(&leafCert, detachedSCTs, v.trustedMaterial)
+			evidence.SignedCertificateTimestamps = len(ctlogs)
+			for _, ctlog := range ctlogs {
+				services = addTrustedService(services, logService(TrustedServiceCTLog, ctlog))
+			}
 			if err != nil {
-				return nil, fmt.Errorf("failed to verify signed certificate timestamp: %w", err)
+				logger.Debug("signed certificate timestamp verification failed", "error", err)
+				if err = v.degrade(DegradableCheckSignedCertificateTimestamps, fmt.Errorf("failed to verify signed certificate timestamp: %w", err), &warnings); err != nil {
+					return nil, err
+				}
 			}
 		}
 
-		certSummary, err = certificate.SummarizeCertificate(&leafCert)
+		extensionRegistry := v.config.extensionRegistry
+		if extensionRegistry == nil {
+			extensionRegistry = certificate.DefaultExtensionRegistry
+		}
+		certSummary, err = certificate.SummarizeCertificateWithRegistry(&leafCert, extensionRegistry)
 		if err != nil {
 			return nil, fmt.Errorf("failed to summarize certificate: %w", err)
 		}
+	} else if pk, ok := verificationContent.HasPublicKey(); ok {
+		keyHint = pk.Hint()
+	}
+
+	if req := v.config.evidenceRequirement; req != nil {
+		if !req.Satisfied(evidence) {
+			logger.Debug("evidence requirement not met", "requirement", req.String(), "evidence", evidence.String())
+			return nil, insufficientEvidence(fmt.Errorf("evidence requirement %s not met: verified %s", req, evidence))
+		}
+		logger.Debug("verified evidence requirement", "requirement", req.String(), "evidence", evidence.String())
 	}
 
 	// From spec:
 	// > ## Signature Verification
 	// > The Verifier MUST verify the provided signature for the constructed payload against the key in the leaf of the certificate chain.
 
+	phases.begin(phaseSignature)
 	sigContent, err := entity.SignatureContent()
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch signature content: %w", err)
 	}
 
+	if err := v.config.checkECDSASignatures(sigContent, verificationContent, v.trustedMaterial); err != nil {
+		logger.Debug("signature encoding check failed", "error", err)
+		return nil, fmt.Errorf("failed to verify signature: %w", err)
+	}
+
+	var artifactDigestAlgorithm string
+	var subjectMatches []SubjectMatch
 	if policy.WeExpectAnArtifact() {
 		switch {
+		case policy.subjectMatchMode != 0:
+			subjectMatches, err = verifySignatureWithSubjects(sigContent, verificationContent, v.trustedMaterial, policy.subjectArtifacts, policy.subjectMatchMode)
 		case policy.verifyArtifact:
-			err = VerifySignatureWithArtifact(sigContent, verificationContent, v.trustedMaterial, policy.artifact)
+			artifactDigestAlgorithm, err = verifySignatureWithArtifact(sigContent, verificationContent, v.trustedMaterial, policy.artifact)
 		case policy.verifyArtifactDigest:
+			artifactDigestAlgorithm = policy.artifactDigestAlgorithm
 			err = VerifySignatureWithArtifactDigest(sigContent, verificationContent, v.trustedMaterial, policy.artifactDigest, policy.artifactDigestAlgorithm)
 		default:
 			// should never happen, but just in case:
@@ -552,16 +944,31 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 	}
 
 	if err != nil {
+		logger.Debug("signature verification failed", "error", err)
 		return nil, fmt.Errorf("failed to verify signature: %w", err)
 	}
+	logger.Debug("verified signature", "withArtifact", policy.WeExpectAnArtifact(), "artifactDigestAlgorithm", artifactDigestAlgorithm)
+
+	if err := checkDenylists(verificationContent, v.trustedMaterial); err != nil {
+		logger.Debug("denylist check failed", "error", err)
+		return nil, err
+	}
 
 	// Hooray! We've verified all of the entity's constituent parts! 🎉 🥳
 	// Now we can construct the results object accordingly.
 	result := NewVerificationResult()
+	result.ArtifactDigestAlgorithm = artifactDigestAlgorithm
+	result.SubjectMatches = subjectMatches
 	if signedWithCertificate {
 		result.Signature = &SignatureVerificationResult{
 			Certificate: &certSummary,
 		}
+		result.SCTVerificationSkipped = v.config.weDoNotExpectSCTs
+	} else if keyHint != "" {
+		publicKeyID := []byte(keyHint)
+		result.Signature = &SignatureVerificationResult{
+			PublicKeyID: &publicKeyID,
+		}
 	}
 
 	// SignatureContent can be either an Envelope or a MessageSignature.
@@ -581,14 +988,25 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 	// result struct has been constructed, we can optionally enforce some
 	// additional policies:
 	// --------------------
+	phases.begin(phasePolicy)
 
 	// From ## Certificate section,
 	// >The Verifier MUST then check the certificate against the verification policy. Details on how to do this depend on the verification policy, but the Verifier SHOULD check the Issuer X.509 extension (OID 1.3.6.1.4.1.57264.1.1) at a minimum, and will in most cases check the SubjectAlternativeName as well. See  Spec: Fulcio §TODO for example checks on the certificate.
-	if policy.WeExpectIdentities() {
+	if policy.keyHint != "" {
+		if signedWithCertificate {
+			return nil, policyNotSatisfied(withReason(ReasonKeyMismatch, errors.New("can't verify key hint: entity was signed with a certificate")))
+		}
+
+		if !keyHintMatches(policy.keyHint, keyHint, verificationContent, v.trustedMaterial, v.config.keyHintSchemes) {
+			logger.Debug("key hint verification failed", "expected", policy.keyHint, "actual", keyHint)
+			return nil, policyNotSatisfied(withReason(ReasonKeyMismatch, fmt.Errorf("failed to verify key hint: entity was not signed with key %s", policy.keyHint)))
+		}
+		logger.Debug("verified key hint", "hint", policy.keyHint)
+	} else if policy.WeExpectIdentities() {
 		if !signedWithCertificate {
 			// We got asked to verify identities, but the entity was not signed with
 			// a certificate. That's a problem!
-			return nil, errors.New("can't verify certificate identities: entity was not signed with a certificate")
+			return nil, policyNotSatisfied(withReason(ReasonIdentityMismatch, errors.New("can't verify certificate identities: entity was not signed with a certificate")))
 		}
 
 		if len(policy.certificateIdentities) == 0 {
@@ -597,36 +1015,91 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 
 		matchingCertID, err := policy.certificateIdentities.Verify(certSummary)
 		if err != nil {
-			return nil, fmt.Errorf("failed to verify certificate identity: %w", err)
+			logger.Debug("certificate identity verification failed", "san", certSummary.SubjectAlternativeName.Value, "issuer", certSummary.Issuer, "error", err)
+			return nil, policyNotSatisfied(withReason(ReasonIdentityMismatch, fmt.Errorf("failed to verify certificate identity: %w", err)))
 		}
+		matchedSAN, _ := matchingCertID.SubjectAlternativeName.Match(certSummary)
+		logger.Debug("verified certificate identity", "san", matchedSAN.Value, "sanType", matchedSAN.Type, "issuer", certSummary.Issuer)
 
 		result.VerifiedIdentity = matchingCertID
+		result.VerifiedSubjectAlternativeName = &matchedSAN
 	}
 
+	if policy.trustedOIDCProviders {
+		err := policyNotSatisfied(withReason(ReasonUntrustedOIDCProvider, errors.New("can't verify OIDC provider: entity was not signed with a certificate")))
+		if signedWithCertificate {
+			err = verifyOIDCProvider(certSummary.Issuer, certNotBefore, v.trustedMaterial)
+		}
+		if err != nil {
+			logger.Debug("OIDC provider verification failed", "issuer", certSummary.Issuer, "error", err)
+			if err = v.degrade(DegradableCheckOIDCProvider, err, &warnings); err != nil {
+				return nil, err
+			}
+		} else {
+			logger.Debug("verified OIDC provider", "issuer", certSummary.Issuer)
+		}
+	}
+
+	result.Warnings = warnings
+	result.ServicesUsed = services
 	return result, nil
 }
 
+func verifyOIDCProvider(issuer string, issuedAt time.Time, tm root.TrustedMaterial) error {
+	providers := root.OIDCProviders(tm)
+	if len(providers) == 0 {
+		return untrustedMaterial(errors.New("can't verify OIDC provider: trusted material has no OIDC providers"))
+	}
+	for _, provider := range providers {
+		if provider.MatchesIssuer(issuer) && provider.ValidAtTime(issuedAt) {
+			return nil
+		}
+	}
+	return policyNotSatisfied(withReason(ReasonUntrustedOIDCProvider, fmt.Errorf("certificate issuer %q is not a trusted OIDC provider", issuer)))
+}
+
+func keyHintMatches(expected, actual string, verificationContent VerificationContent, tm root.TrustedMaterial, schemes []root.KeyHintScheme) bool {
+	if expected == actual {
+		return true
+	}
+	verifier, err := getSignatureVerifier(verificationContent, tm)
+	if err != nil {
+		return false
+	}
+	pub, err := verifier.PublicKey()
+	if err != nil {
+		return false
+	}
+	if schemes == nil {
+		schemes = root.DefaultKeyHintSchemes
+	}
+	return root.KeyHintMatchesAny(expected, pub, schemes)
+}
+
 // VerifyTransparencyLogInclusion verifies TlogEntries if expected. Optionally returns
 // a list of verified timestamps from the log integrated timestamps when verifying
 // with observer timestamps.
 // TODO: Return a different verification result for logs specifically (also for #48)
 func (v *SignedEntityVerifier) VerifyTransparencyLogInclusion(entity SignedEntity) ([]TimestampVerificationResult, error) {
-	verifiedTimestamps := []TimestampVerificationResult{}
-
-	if v.config.weExpectTlogEntries {
-		// log timestamps should be verified if with WithIntegratedTimestamps or WithObserverTimestamps is used
-		verifiedTlogTimestamps, err := VerifyArtifactTransparencyLog(entity, v.trustedMaterial, v.config.tlogEntriesThreshold,
-			v.config.requireIntegratedTimestamps || v.config.requireObserverTimestamps, v.config.performOnlineVerification)
-		if err != nil {
-			return nil, err
-		}
+	verifiedTimestamps, _, err := v.verifyTransparencyLogInclusion(entity)
+	return verifiedTimestamps, err
+}
 
-		for _, vts := range verifiedTlogTimestamps {
-			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "Tlog", URI: "TODO", Timestamp: vts})
-		}
+// verifyTransparencyLogInclusion is VerifyTransparencyLogInclusion, also
+// returning the log of each verified entry.
+func (v *SignedEntityVerifier) verifyTransparencyLogInclusion(entity SignedEntity) ([]TimestampVerificationResult, []*root.TransparencyLog, error) {
+	if !v.config.weExpectTlogEntries {
+		return []TimestampVerificationResult{}, nil, nil
 	}
 
-	return verifiedTimestamps, nil
+	// log timestamps should be verified if with WithIntegratedTimestamps or WithObserverTimestamps is used,
+	// or to check them against signed timestamps with WithTimestampAgreement
+	logs, verifiedTimestamps, err := verifyArtifactTransparencyLog(entity, v.trustedMaterial, v.config.tlogEntriesThreshold,
+		v.config.requireIntegratedTimestamps || v.config.requireObserverTimestamps || v.config.requireTimestampAgreement, v.config.performOnlineVerification)
+	if err != nil {
+		return nil, nil, err
+	}
+	return verifiedTimestamps, logs, nil
 }
 
 // VerifyObserverTimestamps verifies RFC3161 signed timestamps, and verifies
@@ -642,24 +1115,24 @@ func (v *SignedEntityVerifier) VerifyObserverTimestamps(entity SignedEntity, log
 	// From spec:
 	// > … if verification or timestamp parsing fails, the Verifier MUST abort
 	if v.config.weExpectSignedTimestamps {
-		verifiedSignedTimestamps, err := VerifyTimestampAuthorityWithThreshold(entity, v.trustedMaterial, v.config.signedTimestampThreshold)
+		verifiedSignedTimestamps, err := verifyTimestampAuthorityWithThreshold(entity, v.trustedMaterial, v.config.signedTimestampThreshold)
 		if err != nil {
 			return nil, err
 		}
 		for _, vts := range verifiedSignedTimestamps {
-			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "TimestampAuthority", URI: "TODO", Timestamp: vts})
+			verifiedTimestamps = append(verifiedTimestamps, signedTimestampResult(vts))
 		}
 	}
 
 	if v.config.requireIntegratedTimestamps {
 		if len(logTimestamps) < v.config.integratedTimeThreshold {
-			return nil, fmt.Errorf("threshold not met for verified log entry integrated timestamps: %d < %d", len(logTimestamps), v.config.integratedTimeThreshold)
+			return nil, insufficientEvidence(fmt.Errorf("threshold not met for verified log entry integrated timestamps: %d < %d", len(logTimestamps), v.config.integratedTimeThreshold))
 		}
 		verifiedTimestamps = append(verifiedTimestamps, logTimestamps...)
 	}
 
 	if v.config.requireObserverTimestamps {
-		verifiedSignedTimestamps, err := VerifyTimestampAuthority(entity, v.trustedMaterial)
+		verifiedSignedTimestamps, skipped, err := verifyTimestampAuthority(entity, v.trustedMaterial)
 		if err != nil {
 			return nil, err
 		}
@@ -667,14 +1140,14 @@ func (v *SignedEntityVerifier) VerifyObserverTimestamps(entity SignedEntity, log
 		// check threshold for both RFC3161 and log timestamps
 		tsCount := len(verifiedSignedTimestamps) + len(logTimestamps)
 		if tsCount < v.config.observerTimestampThreshold {
-			return nil, fmt.Errorf("threshold not met for verified signed & log entry integrated timestamps: %d < %d",
-				tsCount, v.config.observerTimestampThreshold)
+			return nil, timestampThresholdError(fmt.Errorf("threshold not met for verified signed & log entry integrated timestamps: %d < %d",
+				tsCount, v.config.observerTimestampThreshold), skipped)
 		}
 
 		// append all timestamps
 		verifiedTimestamps = append(verifiedTimestamps, logTimestamps...)
 		for _, vts := range verifiedSignedTimestamps {
-			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "TimestampAuthority", URI: "TODO", Timestamp: vts})
+			verifiedTimestamps = append(verifiedTimestamps, signedTimestampResult(vts))
 		}
 	}
 
@@ -694,7 +1167,7 @@ func (v *SignedEntityVerifier) VerifyObserverTimestamps(entity SignedEntity, log
 	}
 
 	if len(verifiedTimestamps) == 0 {
-		return nil, fmt.Errorf("no valid observer timestamps found")
+		return nil, insufficientEvidence(fmt.Errorf("no valid observer timestamps found"))
 	}
 
 	return verifiedTimestamps, nil
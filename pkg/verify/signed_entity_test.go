@@ -15,13 +15,22 @@
 package verify_test
 
 import (
+	"bytes"
+	"log/slog"
 	"strings"
 	"testing"
+	"time"
 	"unicode"
 
 	"encoding/hex"
 	"encoding/json"
 
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/mldsa"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/testing/data"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
@@ -94,6 +103,11 @@ func TestEntitySignedByPublicGoodWithTlogVerifiesSuccessfully(t *testing.T) {
 	assert.Equal(t, "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main", res.Signature.Certificate.SubjectAlternativeName.Value)
 	assert.NotEmpty(t, res.VerifiedTimestamps)
 
+	explanation := res.Explanation()
+	assert.Equal(t, verify.ExplanationCheckTimestamp, explanation[0].Check)
+	assert.Contains(t, res.Explain(), "[certificate] certificate subject alternative name is URI \"https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main\"")
+	assert.Contains(t, res.Explain(), "predicate type \"https://slsa.dev/provenance/v1\"")
+
 	// verifies with integrated timestamp threshold too
 	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
 	assert.NoError(t, err)
@@ -301,6 +315,8 @@ func TestEntitySignedByPublicGoodWithCertificateIdentityVerifiesSuccessfully(t *
 	assert.Nil(t, err)
 
 	assert.Equal(t, res.VerifiedIdentity.Issuer, verify.ActionsIssuerValue)
+	assert.Equal(t, &certificate.SubjectAlternativeName{Type: certificate.SubjectAlternativeNameTypeURI, Value: verify.SigstoreSanValue}, res.VerifiedSubjectAlternativeName)
+	assert.Contains(t, res.Explain(), "[identity] certificate matched a trusted identity policy requiring subject alternative name matching /"+verify.SigstoreSanRegex+"/ and OIDC issuer")
 
 	// but if only pass in the bad CI, it will fail:
 	res, err = verifier.Verify(entity,
@@ -322,8 +338,322 @@ func TestEntitySignedByPublicGoodWithCertificateIdentityVerifiesSuccessfully(t *
 	assert.Nil(t, res)
 }
 
+func TestEntitySignedByPublicGoodWithTrustedOIDCProviders(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	entity := data.SigstoreJS200ProvenanceBundle(t)
+
+	goodCI, _ := verify.NewShortCertificateIdentity(verify.ActionsIssuerValue, "", "", verify.SigstoreSanRegex)
+	digest, err := hex.DecodeString("46d4e2f74c4877316640000a6fdf8a8b59f1e0847667973e9859f774dd31b8f1e0937813b777fb66a2ac67d50540fe34640966eee9fc2ccca387082b4c85cd3c")
+	assert.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha512", digest), verify.WithCertificateIdentity(goodCI), verify.WithTrustedOIDCProviders())
+
+	for _, tc := range []struct {
+		name      string
+		providers []root.OIDCProvider
+		errClass  verify.ErrorClass
+		wantErr   bool
+	}{
+		{
+			name:      "trusted provider",
+			providers: []root.OIDCProvider{{Issuer: "https://oauth2.sigstore.dev/auth"}, {Issuer: verify.ActionsIssuerValue}},
+		},
+		{
+			name:      "untrusted provider",
+			providers: []root.OIDCProvider{{Issuer: "https://oauth2.sigstore.dev/auth"}},
+			errClass:  verify.ErrorClassPolicyNotSatisfied,
+			wantErr:   true,
+		},
+		{
+			name:      "provider not yet trusted",
+			providers: []root.OIDCProvider{{Issuer: verify.ActionsIssuerValue, ValidityPeriodStart: time.Now()}},
+			errClass:  verify.ErrorClassPolicyNotSatisfied,
+			wantErr:   true,
+		},
+		{
+			name:     "no providers",
+			errClass: verify.ErrorClassUntrustedMaterial,
+			wantErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			verifier, err := verify.NewSignedEntityVerifier(root.WithOIDCProviders(tr, tc.providers...), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+			assert.NoError(t, err)
+
+			_, err = verifier.Verify(entity, policy)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.errClass, verify.ClassifyError(err))
+			}
+		})
+	}
+}
+
+func TestEntitySignedWithKeyHint(t *testing.T) {
+	tm, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := tm.SignWithKey("my-key", &sign.PlainData{Data: []byte("hello world")})
+	assert.NoError(t, err)
+	keyVerifier, err := tm.PublicKeyVerifier("my-key")
+	assert.NoError(t, err)
+	pub, err := keyVerifier.PublicKey()
+	assert.NoError(t, err)
+	spkiHint, err := root.SHA256SPKIKeyHint(pub)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(tm, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+
+	artifact := verify.WithArtifact(strings.NewReader("hello world"))
+
+	res, err := verifier.Verify(entity, verify.NewPolicy(artifact, verify.WithKeyHint("my-key")))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("my-key"), *res.Signature.PublicKeyID)
+	assert.Contains(t, res.Explain(), "[publicKey] signed by trusted public key with hint \"my-key\"")
+
+	// the hint can also be given as a digest of the key
+	artifact = verify.WithArtifact(strings.NewReader("hello world"))
+	_, err = verifier.Verify(entity, verify.NewPolicy(artifact, verify.WithKeyHint(spkiHint)))
+	assert.NoError(t, err)
+
+	artifact = verify.WithArtifact(strings.NewReader("hello world"))
+	_, err = verifier.Verify(entity, verify.NewPolicy(artifact, verify.WithKeyHint("other-key")))
+	assert.Error(t, err)
+
+	// other hint schemes are only matched when the verifier is configured
+	// with them
+	skidHint, err := root.SubjectKeyIDKeyHint(pub)
+	assert.NoError(t, err)
+	artifact = verify.WithArtifact(strings.NewReader("hello world"))
+	_, err = verifier.Verify(entity, verify.NewPolicy(artifact, verify.WithKeyHint(skidHint)))
+	assert.Error(t, err)
+	skidVerifier, err := verify.NewSignedEntityVerifier(tm, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithKeyHintSchemes(root.SubjectKeyIDKeyHint))
+	assert.NoError(t, err)
+	artifact = verify.WithArtifact(strings.NewReader("hello world"))
+	_, err = skidVerifier.Verify(entity, verify.NewPolicy(artifact, verify.WithKeyHint(skidHint)))
+	assert.NoError(t, err)
+	artifact = verify.WithArtifact(strings.NewReader("hello world"))
+	_, err = skidVerifier.Verify(entity, verify.NewPolicy(artifact, verify.WithKeyHint(spkiHint)))
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(tm, verify.WithKeyHintSchemes())
+	assert.Error(t, err)
+
+	// key hints can't be combined with certificate identities
+	certID, err := verify.NewShortCertificateIdentity(verify.ActionsIssuerValue, "", "", verify.SigstoreSanRegex)
+	assert.NoError(t, err)
+	_, err = verify.NewPolicy(artifact, verify.WithKeyHint("my-key"), verify.WithCertificateIdentity(certID)).BuildConfig()
+	assert.Error(t, err)
+
+	// entities signed with a certificate never match a key hint
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	certEntity := data.SigstoreJS200ProvenanceBundle(t)
+	certVerifier, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	_, err = certVerifier.Verify(certEntity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithKeyHint("my-key")))
+	assert.Error(t, err)
+}
+
+func TestEntitySignedWithMLDSA(t *testing.T) {
+	_, err := sign.NewMLDSAKeypair(nil)
+	assert.ErrorIs(t, err, mldsa.ErrNotEnabled)
+
+	backend, ok := mldsa.StandardLibrary()
+	if !ok {
+		t.Skip("crypto/mldsa requires Go 1.27")
+	}
+	mldsa.Enable(backend)
+	defer mldsa.Disable()
+
+	keypair, err := sign.NewMLDSAKeypair(&sign.MLDSAKeypairOptions{Hint: []byte("pq-key"), ParameterSet: mldsa.MLDSA87})
+	assert.NoError(t, err)
+	mldsaVerifier, err := mldsa.NewVerifier(keypair.PublicKey())
+	assert.NoError(t, err)
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"pq-key": root.NewExpiringKey(mldsaVerifier, time.Time{}, time.Time{}),
+	})
+	verifier, err := verify.NewSignedEntityVerifier(tm, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+
+	pb, err := sign.Bundle(&sign.PlainData{Data: []byte("hello world")}, keypair, sign.BundleOptions{})
+	assert.NoError(t, err)
+	entity, err := bundle.NewProtobufBundle(pb)
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithKeyHint("pq-key")))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("goodbye world")), verify.WithKeyHint("pq-key")))
+	assert.Error(t, err)
+
+	// ML-DSA signs the artifact itself, so its digest is not enough
+	digest, err := hex.DecodeString("b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactDigest("sha256", digest), verify.WithKeyHint("pq-key")))
+	assert.ErrorContains(t, err, "not just its digest")
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"}}],"predicate":{}}`)
+	pb, err = sign.Bundle(&sign.DSSEData{Data: statement, PayloadType: "application/vnd.in-toto+json"}, keypair, sign.BundleOptions{})
+	assert.NoError(t, err)
+	entity, err = bundle.NewProtobufBundle(pb)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithKeyHint("pq-key")))
+	assert.NoError(t, err)
+}
+
+func TestEntitySignedWithKeyResolvedByHintScheme(t *testing.T) {
+	// the bundle's hint is the key's SPKI digest, not the key ID we trust it under
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("release-key", &sign.PlainData{Data: []byte("hello world")})
+	assert.NoError(t, err)
+	keyVerifier, err := virtualSigstore.PublicKeyVerifier("release-key")
+	assert.NoError(t, err)
+	pub, err := keyVerifier.PublicKey()
+	assert.NoError(t, err)
+	spkiHint, err := root.SHA256SPKIKeyHint(pub)
+	assert.NoError(t, err)
+	entity.VerificationMaterial.GetPublicKey().Hint = spkiHint
+	tm := root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		"release-key": root.NewExpiringKey(keyVerifier, time.Time{}, time.Time{}),
+	})
+
+	verifier, err := verify.NewSignedEntityVerifier(tm, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+}
+
+func TestEntitySignedOverGitCommit(t *testing.T) {
+	commit := []byte("tree 5d0a5ff8a4b6b8ad6a3ff51fc7d8b4aec2fb4e1b\nauthor Jane Doe <jane@example.com> 1700000000 +0000\ncommitter Jane Doe <jane@example.com> 1700000000 +0000\n\nAdd feature\n")
+
+	content, err := sign.NewGitObjectData(commit)
+	assert.NoError(t, err)
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("git-key", content)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithGitObject(commit), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// the signature embedded in the commit is not part of the signed payload
+	signedCommit := []byte("tree 5d0a5ff8a4b6b8ad6a3ff51fc7d8b4aec2fb4e1b\nauthor Jane Doe <jane@example.com> 1700000000 +0000\ncommitter Jane Doe <jane@example.com> 1700000000 +0000\ngpgsig -----BEGIN SIGNED MESSAGE-----\n -----END SIGNED MESSAGE-----\n\nAdd feature\n")
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithGitObject(signedCommit), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	amendedCommit := []byte("tree 5d0a5ff8a4b6b8ad6a3ff51fc7d8b4aec2fb4e1b\nauthor Jane Doe <jane@example.com> 1700000000 +0000\ncommitter Jane Doe <jane@example.com> 1700000000 +0000\n\nAdd other feature\n")
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithGitObject(amendedCommit), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithGitObject([]byte("not a git object")), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+}
+
+func TestEntitySignedOverOCIImage(t *testing.T) {
+	digest := "sha256:4c4b5e1a2d5b3c6a8e1f0d9c7b6a5e4d3c2b1a09f8e7d6c5b4a392817263544f"
+	content, err := sign.NewOCIImageData("ghcr.io/sigstore/sigstore-go", digest, nil)
+	assert.NoError(t, err)
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("image-key", content)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+
+	payload := content.PreAuthEncoding()
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImage(payload, digest), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// the payload must bind the expected image
+	otherDigest := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImage(payload, otherDigest), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+
+	// and be the signed payload
+	otherContent, err := sign.NewOCIImageData("ghcr.io/sigstore/sigstore-go", otherDigest, nil)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImage(otherContent.PreAuthEncoding(), otherDigest), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImage([]byte("not a payload"), digest), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+}
+
+func TestWithoutSCTRequired(t *testing.T) {
+	// The virtual Sigstore has no CT log, like many private deployments
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe())
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithSignedCertificateTimestamps(1))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, policy)
+	assert.ErrorContains(t, err, "failed to verify signed certificate timestamp")
+
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithoutSCTRequired())
+	assert.NoError(t, err)
+	res, err := v.Verify(entity, policy)
+	assert.NoError(t, err)
+	assert.True(t, res.SCTVerificationSkipped)
+	assert.Contains(t, res.Explain(), "SCT verification was explicitly skipped")
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithSignedCertificateTimestamps(1), verify.WithoutSCTRequired())
+	assert.Error(t, err)
+}
+
+func TestVerificationResultServicesUsed(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	entity := data.SigstoreJS200ProvenanceBundle(t)
+
+	v, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithSignedCertificateTimestamps(1))
+	assert.NoError(t, err)
+	res, err := v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	var types []string
+	for _, service := range res.ServicesUsed {
+		types = append(types, service.Type)
+	}
+	assert.ElementsMatch(t, []string{verify.TrustedServiceTransparencyLog, verify.TrustedServiceCertificateAuthority, verify.TrustedServiceCTLog}, types)
+	assert.Contains(t, res.ServicesUsed, verify.TrustedService{
+		Type:  verify.TrustedServiceTransparencyLog,
+		URI:   "https://rekor.sigstore.dev",
+		LogID: "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+	})
+	for _, service := range res.ServicesUsed {
+		if service.Type == verify.TrustedServiceCertificateAuthority {
+			assert.Equal(t, "https://fulcio.sigstore.dev", service.URI)
+			assert.Equal(t, "CN=sigstore,O=sigstore.dev", service.Subject)
+		}
+	}
+	for _, ts := range res.VerifiedTimestamps {
+		assert.Equal(t, "https://rekor.sigstore.dev", ts.URI)
+	}
+
+	// The timestamping authority is reported for signed timestamps
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	testEntity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithoutSCTRequired())
+	assert.NoError(t, err)
+	res, err = v.Verify(testEntity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	types = nil
+	for _, service := range res.ServicesUsed {
+		types = append(types, service.Type)
+	}
+	assert.ElementsMatch(t, []string{verify.TrustedServiceTimestampAuthority, verify.TrustedServiceCertificateAuthority}, types)
+}
+
 // TODO test bundles:
-// - signed with a key, not a fulcio cert, i.e. npm
 // - with duplicate tlog entries
 // - with duplicate tsa entries
 // - with tlog entries that do not refer to the verification content
@@ -367,3 +697,59 @@ func ensureKeysBeginWithLowercase(t *testing.T, obj interface{}) {
 		}
 	}
 }
+
+func TestSignedEntityVerifierLogging(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("my-key", &sign.PlainData{Data: []byte("hello world")})
+	assert.NoError(t, err)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithLogger(logger))
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithKeyHint("my-key")))
+	assert.NoError(t, err)
+	assert.Contains(t, logs.String(), "verified timestamp")
+	assert.Contains(t, logs.String(), "verified signature")
+	assert.Contains(t, logs.String(), "verified key hint")
+
+	logs.Reset()
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("goodbye world")), verify.WithKeyHint("my-key")))
+	assert.Error(t, err)
+	assert.Contains(t, logs.String(), "signature verification failed")
+
+	// Verifiers without a logger do not log
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+	logs.Reset()
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("hello world")), verify.WithKeyHint("my-key")))
+	assert.NoError(t, err)
+	assert.Empty(t, logs.String())
+}
+
+func TestCertificateChainPolicy(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe())
+
+	// The virtual Sigstore's leaf certificates chain to its root through
+	// one intermediate, using ECDSA P-256 keys
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1),
+		verify.WithMaxCertificateChainDepth(3), verify.WithoutWeakCertificateAlgorithms())
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithMaxCertificateChainDepth(2))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, policy)
+	var depthErr *verify.ChainDepthError
+	assert.ErrorAs(t, err, &depthErr)
+	assert.Equal(t, verify.ErrorClassPolicyNotSatisfied, verify.ClassifyError(err))
+	assert.Equal(t, []verify.ReasonCode{verify.ReasonCertificatePolicy, verify.ReasonPolicyNotSatisfied}, verify.NewDecision(nil, err).DeniedReasons)
+}
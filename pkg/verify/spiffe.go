@@ -0,0 +1,155 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+const spiffeScheme = "spiffe://"
+
+// SPIFFEIDMatcher matches SPIFFE IDs, such as those Fulcio encodes as URI
+// SANs in certificates issued for SPIFFE SVIDs, by trust domain and path.
+//
+// Unlike a regular expression over the URI, it compares the trust domain
+// exactly and the path segment by segment, so a policy for
+// spiffe://example.org/ns/prod matches neither spiffe://example.org.evil.com
+// nor spiffe://example.org/ns/production.
+type SPIFFEIDMatcher struct {
+	// TrustDomain the SPIFFE ID must belong to, e.g. "example.org"
+	TrustDomain string `json:"trustDomain"`
+	// Optional path the SPIFFE ID's path must equal or be below, e.g.
+	// "/ns/prod". An empty prefix matches every SPIFFE ID of the trust
+	// domain.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+}
+
+// NewSPIFFEIDMatcher returns a matcher for the SPIFFE IDs of the trust
+// domain whose path equals or is below pathPrefix, failing if either is not
+// valid in a SPIFFE ID.
+func NewSPIFFEIDMatcher(trustDomain, pathPrefix string) (*SPIFFEIDMatcher, error) {
+	if err := validateSPIFFETrustDomain(trustDomain); err != nil {
+		return nil, err
+	}
+	if pathPrefix != "" {
+		if err := validateSPIFFEPath(pathPrefix); err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE path prefix: %w", err)
+		}
+	}
+	return &SPIFFEIDMatcher{TrustDomain: trustDomain, PathPrefix: pathPrefix}, nil
+}
+
+// Matches returns true if id is a valid SPIFFE ID of the matcher's trust
+// domain, with a path equal to or below its path prefix.
+func (m SPIFFEIDMatcher) Matches(id string) bool {
+	trustDomain, path, err := ParseSPIFFEID(id)
+	if err != nil || trustDomain != m.TrustDomain {
+		return false
+	}
+	return m.PathPrefix == "" || path == m.PathPrefix || strings.HasPrefix(path, m.PathPrefix+"/")
+}
+
+func (m SPIFFEIDMatcher) String() string {
+	if m.PathPrefix == "" {
+		return spiffeScheme + m.TrustDomain + "/*"
+	}
+	return spiffeScheme + m.TrustDomain + m.PathPrefix + "[/*]"
+}
+
+// ParseSPIFFEID splits a SPIFFE ID, spiffe://<trust domain>/<path>, into
+// its trust domain and path, which is empty or starts with "/". It fails if
+// the ID does not conform to the SPIFFE ID specification, for example if it
+// has a port, a query or upper case letters in its trust domain.
+func ParseSPIFFEID(id string) (string, string, error) {
+	if !strings.HasPrefix(id, spiffeScheme) {
+		return "", "", fmt.Errorf("invalid SPIFFE ID %q: scheme must be spiffe", id)
+	}
+	rest := id[len(spiffeScheme):]
+	trustDomain, path := rest, ""
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		trustDomain, path = rest[:i], rest[i:]
+	}
+	if err := validateSPIFFETrustDomain(trustDomain); err != nil {
+		return "", "", fmt.Errorf("invalid SPIFFE ID %q: %w", id, err)
+	}
+	if path != "" {
+		if err := validateSPIFFEPath(path); err != nil {
+			return "", "", fmt.Errorf("invalid SPIFFE ID %q: %w", id, err)
+		}
+	}
+	return trustDomain, path, nil
+}
+
+func validateSPIFFETrustDomain(trustDomain string) error {
+	if trustDomain == "" {
+		return errors.New("SPIFFE trust domain must not be empty")
+	}
+	for _, c := range trustDomain {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '.' && c != '-' && c != '_' {
+			return fmt.Errorf("SPIFFE trust domain %q may only contain lower case letters, digits, dots, dashes and underscores", trustDomain)
+		}
+	}
+	return nil
+}
+
+func validateSPIFFEPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("SPIFFE path %q must start with /", path)
+	}
+	for _, segment := range strings.Split(path[1:], "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("SPIFFE path %q must not have empty segments or a trailing /", path)
+		case ".", "..":
+			return fmt.Errorf("SPIFFE path %q must not have . or .. segments", path)
+		}
+		for _, c := range segment {
+			if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '.' && c != '-' && c != '_' {
+				return fmt.Errorf("SPIFFE path %q may only contain letters, digits, dots, dashes and underscores", path)
+			}
+		}
+	}
+	return nil
+}
+
+// NewSPIFFESANMatcher returns a SubjectAlternativeNameMatcher that only
+// matches URI SANs that are SPIFFE IDs of the trust domain, with a path
+// equal to or below pathPrefix.
+func NewSPIFFESANMatcher(trustDomain, pathPrefix string) (SubjectAlternativeNameMatcher, error) {
+	spiffe, err := NewSPIFFEIDMatcher(trustDomain, pathPrefix)
+	if err != nil {
+		return SubjectAlternativeNameMatcher{}, err
+	}
+	return SubjectAlternativeNameMatcher{
+		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: certificate.SubjectAlternativeNameTypeURI},
+		SPIFFE:                 spiffe,
+	}, nil
+}
+
+// NewSPIFFECertificateIdentity returns an identity for certificates issued
+// for the SPIFFE IDs of the trust domain with a path equal to or below
+// pathPrefix, from identity tokens of the issuer, such as the trust
+// domain's OIDC discovery endpoint.
+func NewSPIFFECertificateIdentity(issuer, trustDomain, pathPrefix string) (CertificateIdentity, error) {
+	sanMatcher, err := NewSPIFFESANMatcher(trustDomain, pathPrefix)
+	if err != nil {
+		return CertificateIdentity{}, err
+	}
+	return NewCertificateIdentity(sanMatcher, certificate.Extensions{Issuer: issuer})
+}
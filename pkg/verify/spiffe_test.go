@@ -0,0 +1,119 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSPIFFEID(t *testing.T) {
+	trustDomain, path, err := ParseSPIFFEID("spiffe://example.org/ns/prod/sa/builder")
+	require.NoError(t, err)
+	assert.Equal(t, "example.org", trustDomain)
+	assert.Equal(t, "/ns/prod/sa/builder", path)
+
+	trustDomain, path, err = ParseSPIFFEID("spiffe://example.org")
+	require.NoError(t, err)
+	assert.Equal(t, "example.org", trustDomain)
+	assert.Empty(t, path)
+
+	for _, id := range []string{
+		"https://example.org/ns/prod",
+		"SPIFFE://example.org/ns/prod",
+		"spiffe://",
+		"spiffe:///ns/prod",
+		"spiffe://Example.org/ns/prod",
+		"spiffe://example.org:8443/ns/prod",
+		"spiffe://user@example.org/ns/prod",
+		"spiffe://example.org/ns/prod/",
+		"spiffe://example.org/ns//prod",
+		"spiffe://example.org/ns/../prod",
+		"spiffe://example.org/ns/prod?x=1",
+		"spiffe://example.org/ns/prod#x",
+	} {
+		_, _, err := ParseSPIFFEID(id)
+		assert.Error(t, err, id)
+	}
+}
+
+func TestSPIFFEIDMatcher(t *testing.T) {
+	matcher, err := NewSPIFFEIDMatcher("example.org", "/ns/prod")
+	require.NoError(t, err)
+	assert.True(t, matcher.Matches("spiffe://example.org/ns/prod"))
+	assert.True(t, matcher.Matches("spiffe://example.org/ns/prod/sa/builder"))
+	assert.False(t, matcher.Matches("spiffe://example.org/ns/production"))
+	assert.False(t, matcher.Matches("spiffe://example.org/ns"))
+	assert.False(t, matcher.Matches("spiffe://example.org.evil.com/ns/prod"))
+	assert.False(t, matcher.Matches("spiffe://evil.com/ns/prod"))
+	assert.False(t, matcher.Matches("https://example.org/ns/prod"))
+
+	matcher, err = NewSPIFFEIDMatcher("example.org", "")
+	require.NoError(t, err)
+	assert.True(t, matcher.Matches("spiffe://example.org"))
+	assert.True(t, matcher.Matches("spiffe://example.org/anything"))
+
+	_, err = NewSPIFFEIDMatcher("", "/ns/prod")
+	assert.Error(t, err)
+	_, err = NewSPIFFEIDMatcher("Example.org", "")
+	assert.Error(t, err)
+	_, err = NewSPIFFEIDMatcher("example.org", "ns/prod")
+	assert.Error(t, err)
+	_, err = NewSPIFFEIDMatcher("example.org", "/ns/prod/")
+	assert.Error(t, err)
+}
+
+func TestSPIFFECertificateIdentity(t *testing.T) {
+	issuer := "https://oidc.example.org"
+	actualCert := certificate.Summary{
+		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "URI", Value: "spiffe://example.org/ns/prod/sa/builder"},
+		Extensions:             certificate.Extensions{Issuer: issuer},
+	}
+
+	certID, err := NewSPIFFECertificateIdentity(issuer, "example.org", "/ns/prod")
+	require.NoError(t, err)
+	assert.True(t, certID.Verify(actualCert))
+
+	otherPath, err := NewSPIFFECertificateIdentity(issuer, "example.org", "/ns/staging")
+	require.NoError(t, err)
+	assert.False(t, otherPath.Verify(actualCert))
+
+	otherIssuer, err := NewSPIFFECertificateIdentity("https://oidc.example.com", "example.org", "/ns/prod")
+	require.NoError(t, err)
+	assert.False(t, otherIssuer.Verify(actualCert))
+
+	// SPIFFE IDs are only matched in URI SANs
+	otherName := actualCert
+	otherName.SubjectAlternativeName.Type = "Other"
+	assert.False(t, certID.Verify(otherName))
+
+	// The criteria can be combined with a regular expression
+	sanMatcher, err := NewSPIFFESANMatcher("example.org", "/ns/prod")
+	require.NoError(t, err)
+	sanMatcher.Regexp = *regexp.MustCompile("/sa/deployer$")
+	assert.False(t, sanMatcher.Verify(actualCert))
+
+	_, err = NewSPIFFECertificateIdentity(issuer, "example.org", "ns")
+	assert.Error(t, err)
+
+	marshaled, err := json.Marshal(&certID.SubjectAlternativeName)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"URI","spiffe":{"trustDomain":"example.org","pathPrefix":"/ns/prod"}}`, string(marshaled))
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// ArtifactDigest identifies an artifact by the digest of its content.
+type ArtifactDigest struct {
+	// Algorithm is the digest algorithm, as named in the subjects of
+	// in-toto statements, e.g. "sha256"
+	Algorithm string
+	Digest    []byte
+}
+
+// SubjectMatch reports whether a subject of the entity's statement matched
+// the artifacts of an AnySubjectMatches or AllSubjectsMatch policy.
+type SubjectMatch struct {
+	Name    string `json:"name"`
+	Matched bool   `json:"matched"`
+	// Artifacts are the indexes of the policy's artifacts that match the
+	// subject
+	Artifacts []int `json:"artifacts,omitempty"`
+}
+
+type subjectMatchMode int
+
+const (
+	anySubjectMatches subjectMatchMode = iota + 1
+	allSubjectsMatch
+)
+
+// AnySubjectMatches allows the caller of Verify to enforce that at least one
+// subject of the SignedEntity's in-toto statement matches one of the given
+// artifacts, e.g. to verify one artifact of a release against a statement
+// about all of them. It is equivalent to WithArtifactDigest for a single
+// artifact, but also reports which subjects matched in
+// VerificationResult.SubjectMatches.
+//
+// It requires the SignedEntity to contain a DSSE envelope.
+func AnySubjectMatches(artifacts ...ArtifactDigest) ArtifactPolicyOption {
+	return withSubjectArtifacts(anySubjectMatches, artifacts)
+}
+
+// AllSubjectsMatch allows the caller of Verify to enforce that every subject
+// of the SignedEntity's in-toto statement matches one of the given
+// artifacts, e.g. to check that a statement says nothing about artifacts
+// other than the ones being verified. Artifacts that match no subject are
+// reported, but do not fail verification; use AnySubjectMatches with each
+// artifact to require that they are all attested.
+//
+// It requires the SignedEntity to contain a DSSE envelope.
+func AllSubjectsMatch(artifacts ...ArtifactDigest) ArtifactPolicyOption {
+	return withSubjectArtifacts(allSubjectsMatch, artifacts)
+}
+
+func withSubjectArtifacts(mode subjectMatchMode, artifacts []ArtifactDigest) ArtifactPolicyOption {
+	return func(p *PolicyConfig) error {
+		if p.verifyArtifact || p.verifyArtifactDigest || p.subjectMatchMode != 0 {
+			return errors.New("only one invocation of WithArtifact/WithArtifactDigest/AnySubjectMatches/AllSubjectsMatch is allowed")
+		}
+
+		if p.weDoNotExpectAnArtifact {
+			return errors.New("can't use AnySubjectMatches/AllSubjectsMatch while using WithoutArtifactUnsafe")
+		}
+
+		if len(artifacts) == 0 {
+			return errors.New("at least one artifact must be provided")
+		}
+		for _, artifact := range artifacts {
+			if artifact.Algorithm == "" || len(artifact.Digest) == 0 {
+				return errors.New("artifacts must have a digest algorithm and digest")
+			}
+		}
+
+		p.subjectMatchMode = mode
+		p.subjectArtifacts = append([]ArtifactDigest{}, artifacts...)
+		return nil
+	}
+}
+
+// verifySignatureWithSubjects verifies the signature on the entity's
+// envelope, and that its statement's subjects match the artifacts as the
+// mode requires.
+func verifySignatureWithSubjects(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial, artifacts []ArtifactDigest, mode subjectMatchMode) ([]SubjectMatch, error) {
+	verifier, err := getSignatureVerifier(verificationContent, trustedMaterial)
+	if err != nil {
+		return nil, signatureVerifierError(verificationContent, err)
+	}
+
+	envelope := sigContent.EnvelopeContent()
+	if envelope == nil {
+		return nil, errors.New("can't match subjects: signature content has no envelope")
+	}
+	if err := verifyEnvelope(verifier, envelope); err != nil {
+		return nil, signatureError(err)
+	}
+	statement, err := envelope.Statement()
+	if err != nil {
+		return nil, fmt.Errorf("could not verify artifact: unable to extract statement from envelope: %w", err)
+	}
+	if len(statement.Subject) == 0 {
+		return nil, errors.New("no subjects found in statement")
+	}
+
+	hexArtifactDigests := make([]string, len(artifacts))
+	for i, artifact := range artifacts {
+		hexArtifactDigests[i] = hex.EncodeToString(artifact.Digest)
+	}
+
+	matches := make([]SubjectMatch, len(statement.Subject))
+	var matched int
+	var unmatched []string
+	for i, subject := range statement.Subject {
+		matches[i].Name = subject.Name
+		for j, artifact := range artifacts {
+			if digest, ok := subject.Digest[artifact.Algorithm]; ok && strings.EqualFold(digest, hexArtifactDigests[j]) {
+				matches[i].Artifacts = append(matches[i].Artifacts, j)
+			}
+		}
+		matches[i].Matched = len(matches[i].Artifacts) > 0
+		if matches[i].Matched {
+			matched++
+		} else {
+			unmatched = append(unmatched, fmt.Sprintf("%q", subject.Name))
+		}
+	}
+
+	switch {
+	case mode == anySubjectMatches && matched == 0:
+		return nil, signatureError(artifactMismatch(errors.New("no subject in statement matches the provided artifacts")))
+	case mode == allSubjectsMatch && len(unmatched) > 0:
+		return nil, signatureError(artifactMismatch(fmt.Errorf("subjects %s in statement match none of the provided artifacts", strings.Join(unmatched, ", "))))
+	}
+	return matches, nil
+}
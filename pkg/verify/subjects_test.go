@@ -0,0 +1,87 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestSubjectMatches(t *testing.T) {
+	digestOf := func(content string) []byte {
+		digest := sha256.Sum256([]byte(content))
+		return digest[:]
+	}
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","predicateType":"https://example.com/predicate","subject":[{"name":"a.tar.gz","digest":{"sha256":"%s"}},{"name":"b.tar.gz","digest":{"sha256":"%s"}}],"predicate":{}}`,
+		hex.EncodeToString(digestOf("a")), strings.ToUpper(hex.EncodeToString(digestOf("b"))))
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.SignWithKey("key", &sign.DSSEData{Data: []byte(statement), PayloadType: "application/vnd.in-toto+json"})
+	require.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	require.NoError(t, err)
+
+	a := verify.ArtifactDigest{Algorithm: "sha256", Digest: digestOf("a")}
+	b := verify.ArtifactDigest{Algorithm: "sha256", Digest: digestOf("b")}
+	c := verify.ArtifactDigest{Algorithm: "sha256", Digest: digestOf("c")}
+	verifyWith := func(artifactPolicy verify.ArtifactPolicyOption) (*verify.VerificationResult, error) {
+		return verifier.Verify(entity, verify.NewPolicy(artifactPolicy, verify.WithoutIdentitiesUnsafe()))
+	}
+
+	result, err := verifyWith(verify.AnySubjectMatches(c, b))
+	require.NoError(t, err)
+	assert.Equal(t, []verify.SubjectMatch{
+		{Name: "a.tar.gz"},
+		{Name: "b.tar.gz", Matched: true, Artifacts: []int{1}},
+	}, result.SubjectMatches)
+
+	_, err = verifyWith(verify.AnySubjectMatches(c))
+	assert.ErrorContains(t, err, "no subject in statement matches")
+	assert.Contains(t, verify.Reasons(err), verify.ReasonArtifactMismatch)
+
+	result, err = verifyWith(verify.AllSubjectsMatch(a, b, c))
+	require.NoError(t, err)
+	assert.Equal(t, []verify.SubjectMatch{
+		{Name: "a.tar.gz", Matched: true, Artifacts: []int{0}},
+		{Name: "b.tar.gz", Matched: true, Artifacts: []int{1}},
+	}, result.SubjectMatches)
+
+	_, err = verifyWith(verify.AllSubjectsMatch(a, c))
+	assert.ErrorContains(t, err, `subjects "b.tar.gz" in statement match none of the provided artifacts`)
+	assert.Equal(t, verify.ErrorClassCryptographicFailure, verify.ClassifyError(err))
+
+	_, err = verifyWith(verify.AllSubjectsMatch())
+	assert.ErrorContains(t, err, "at least one artifact must be provided")
+	_, err = verifyWith(verify.AnySubjectMatches(verify.ArtifactDigest{Algorithm: "sha256"}))
+	assert.Error(t, err)
+
+	// Message signatures have no subjects
+	entity, err = virtualSigstore.SignWithKey("key", &sign.PlainData{Data: []byte("a")})
+	require.NoError(t, err)
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	require.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.AnySubjectMatches(a), verify.WithoutIdentitiesUnsafe()))
+	assert.ErrorContains(t, err, "signature content has no envelope")
+}
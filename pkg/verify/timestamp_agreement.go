@@ -0,0 +1,76 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithTimestampAgreement configures the SignedEntityVerifier to require
+// that the entity has both a verified log entry integrated timestamp and a
+// verified RFC 3161 timestamp, and that every integrated timestamp is
+// within maxSkew of every RFC 3161 timestamp. Two independent observers
+// that disagree on when an entity was signed indicate that one of them was
+// compromised or manipulated. Both kinds of timestamps are always checked
+// against the signing certificate's validity period.
+//
+// It only checks timestamps the verifier is otherwise configured to
+// verify, e.g. with WithTransparencyLog and WithSignedTimestamps. Integrated
+// timestamps checked for agreement are not used to verify the certificate
+// unless the verifier is also configured to do so, e.g. with
+// WithIntegratedTimestamps.
+func WithTimestampAgreement(maxSkew time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxSkew < 0 {
+			return errors.New("timestamp agreement skew must not be negative")
+		}
+		c.requireTimestampAgreement = true
+		c.maxTimestampSkew = maxSkew
+		return nil
+	}
+}
+
+// checkTimestampAgreement checks the entity's verified timestamps if the
+// verifier is configured to.
+func (c *VerifierConfig) checkTimestampAgreement(timestamps []TimestampVerificationResult) error {
+	if !c.requireTimestampAgreement {
+		return nil
+	}
+	var logTimestamps, signedTimestamps []TimestampVerificationResult
+	for _, ts := range timestamps {
+		switch {
+		case ts.RFC3161 != nil:
+			signedTimestamps = append(signedTimestamps, ts)
+		case ts.Type == "Tlog":
+			logTimestamps = append(logTimestamps, ts)
+		}
+	}
+	if len(logTimestamps) == 0 || len(signedTimestamps) == 0 {
+		return insufficientEvidence(fmt.Errorf("timestamp agreement requires both log entry integrated timestamps and signed timestamps: found %d and %d", len(logTimestamps), len(signedTimestamps)))
+	}
+
+	for _, logTs := range logTimestamps {
+		for _, signedTs := range signedTimestamps {
+			skew := logTs.Timestamp.Sub(signedTs.Timestamp).Abs()
+			if skew > c.maxTimestampSkew {
+				return policyNotSatisfied(withReason(ReasonTimestampDisagreement, fmt.Errorf("integrated timestamp %s from %s and signed timestamp %s from %s differ by %s, more than %s",
+					logTs.Timestamp.UTC().Format(time.RFC3339), logTs.URI, signedTs.Timestamp.UTC().Format(time.RFC3339), signedTs.URI, skew, c.maxTimestampSkew)))
+			}
+		}
+	}
+	return nil
+}
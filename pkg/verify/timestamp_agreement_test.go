@@ -0,0 +1,60 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+func TestTimestampAgreement(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+
+	verifyEntity := func(entity verify.SignedEntity, options ...verify.VerifierOption) error {
+		verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, options...)
+		require.NoError(t, err)
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+
+	// The log entry is integrated a minute after the timestamping authority
+	// timestamps the signature
+	entity, err := virtualSigstore.SignAtTime("foo@example.com", "issuer", []byte("artifact"), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.NoError(t, verifyEntity(entity, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithTimestampAgreement(5*time.Minute)))
+	assert.NoError(t, verifyEntity(entity, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithTimestampAgreement(5*time.Minute)))
+	assert.NoError(t, verifyEntity(entity, verify.WithEvidenceRequirement(verify.AllOf(verify.MinIntegratedTimestamps(1), verify.MinSignedTimestamps(1))), verify.WithTimestampAgreement(5*time.Minute)))
+
+	err = verifyEntity(entity, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithTimestampAgreement(10*time.Second))
+	assert.ErrorContains(t, err, "differ by")
+	assert.Equal(t, verify.ErrorClassPolicyNotSatisfied, verify.ClassifyError(err))
+	assert.Contains(t, verify.Reasons(err), verify.ReasonTimestampDisagreement)
+
+	// Both kinds of timestamps are required
+	err = verifyEntity(entity, verify.WithSignedTimestamps(1), verify.WithTimestampAgreement(5*time.Minute))
+	assert.ErrorContains(t, err, "requires both")
+	assert.Contains(t, verify.Reasons(err), verify.ReasonInsufficientEvidence)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithTimestampAgreement(-time.Second))
+	assert.Error(t, err)
+}
@@ -17,7 +17,6 @@ package verify
 import (
 	"bytes"
 	"context"
-	"crypto"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -28,7 +27,6 @@ import (
 	rekorEntries "github.com/sigstore/rekor/pkg/generated/client/entries"
 	rekorModels "github.com/sigstore/rekor/pkg/generated/models"
 	rekorVerify "github.com/sigstore/rekor/pkg/verify"
-	"github.com/sigstore/sigstore/pkg/signature"
 
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/tlog"
@@ -42,91 +40,117 @@ import (
 //
 // If online is true, the log entry is verified against the Rekor server.
 func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.TrustedMaterial, logThreshold int, trustIntegratedTime, online bool) ([]time.Time, error) { //nolint:revive
-	entries, err := entity.TlogEntries()
+	_, verifiedTimestamps, err := verifyArtifactTransparencyLog(entity, trustedMaterial, logThreshold, trustIntegratedTime, online)
 	if err != nil {
 		return nil, err
 	}
+	times := make([]time.Time, len(verifiedTimestamps))
+	for i, ts := range verifiedTimestamps {
+		times[i] = ts.Timestamp
+	}
+	return times, nil
+}
+
+// verifyArtifactTransparencyLog is VerifyArtifactTransparencyLog, but returns
+// the log of each verified entry, and the verified integrated times along
+// with the logs that signed them.
+func verifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.TrustedMaterial, logThreshold int, trustIntegratedTime, online bool) ([]*root.TransparencyLog, []TimestampVerificationResult, error) {
+	entries, err := entity.TlogEntries()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// disallow duplicate entries, as a malicious actor could use duplicates to bypass the threshold
 	for i := 0; i < len(entries); i++ {
 		for j := i + 1; j < len(entries); j++ {
 			if entries[i].LogKeyID() == entries[j].LogKeyID() && entries[i].LogIndex() == entries[j].LogIndex() {
-				return nil, errors.New("duplicate tlog entries found")
+				return nil, nil, cryptographicFailure(errors.New("duplicate tlog entries found"))
 			}
 		}
 	}
 
 	sigContent, err := entity.SignatureContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	entitySignature := sigContent.Signature()
 
 	verificationContent, err := entity.VerificationContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	verifiedTimestamps := []time.Time{}
-	logEntriesVerified := 0
+	verifiedTimestamps := []TimestampVerificationResult{}
+	var verifiedLogs []*root.TransparencyLog
+	// reasons entries were skipped, reported if the threshold is not met
+	var skipped []error
 
 	for _, entry := range entries {
 		err := tlog.ValidateEntry(entry)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
+		keyID := entry.LogKeyID()
+		hex64Key := hex.EncodeToString([]byte(keyID))
+		// the log the entry was verified against
+		var entryLog *root.TransparencyLog
+
 		if !online {
 			if !entry.HasInclusionPromise() && !entry.HasInclusionProof() {
-				return nil, fmt.Errorf("entry must contain an inclusion proof and/or promise")
+				return nil, nil, fmt.Errorf("entry must contain an inclusion proof and/or promise")
 			}
 			if entry.HasInclusionPromise() {
 				err = tlog.VerifySET(entry, trustedMaterial.RekorLogs())
 				if err != nil {
 					// skip entries the trust root cannot verify
+					skipped = append(skipped, transparencyLogError(err))
 					continue
 				}
+				entryLog, err = root.FindTransparencyLog(trustedMaterial.RekorLogs(), hex64Key, entry.IntegratedTime())
+				if err != nil {
+					return nil, nil, untrustedMaterial(err)
+				}
 				if trustIntegratedTime {
-					verifiedTimestamps = append(verifiedTimestamps, entry.IntegratedTime())
+					verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "Tlog", URI: entryLog.BaseURL, Timestamp: entry.IntegratedTime()})
 				}
 			}
 			if entity.HasInclusionProof() {
-				keyID := entry.LogKeyID()
-				hex64Key := hex.EncodeToString([]byte(keyID))
-				tlogVerifier, ok := trustedMaterial.RekorLogs()[hex64Key]
-				if !ok {
+				tlogVerifier, err := root.FindTransparencyLog(trustedMaterial.RekorLogs(), hex64Key, entry.IntegratedTime())
+				if err != nil {
 					// skip entries the trust root cannot verify
+					skipped = append(skipped, untrustedMaterial(err))
 					continue
 				}
 
-				verifier, err := getVerifier(tlogVerifier.PublicKey, tlogVerifier.SignatureHashFunc)
+				verifier, err := tlogVerifier.Verifier()
 				if err != nil {
-					return nil, err
+					return nil, nil, untrustedMaterial(err)
 				}
 
-				err = tlog.VerifyInclusion(entry, *verifier)
+				err = tlog.VerifyInclusion(entry, verifier)
 				if err != nil {
-					return nil, err
+					return nil, nil, cryptographicFailure(err)
 				}
+				entryLog = tlogVerifier
 				// DO NOT use timestamp with only an inclusion proof, because it is not signed metadata
 			}
 		} else {
-			keyID := entry.LogKeyID()
-			hex64Key := hex.EncodeToString([]byte(keyID))
-			tlogVerifier, ok := trustedMaterial.RekorLogs()[hex64Key]
-			if !ok {
+			tlogVerifier, err := root.FindTransparencyLog(trustedMaterial.RekorLogs(), hex64Key, entry.IntegratedTime())
+			if err != nil {
 				// skip entries the trust root cannot verify
+				skipped = append(skipped, untrustedMaterial(err))
 				continue
 			}
 
 			client, err := getRekorClient(tlogVerifier.BaseURL)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			verifier, err := getVerifier(tlogVerifier.PublicKey, tlogVerifier.SignatureHashFunc)
+			verifier, err := tlogVerifier.Verifier()
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			logIndex := entry.LogIndex()
@@ -139,63 +163,70 @@ func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.Tru
 
 			resp, err := client.Entries.SearchLogQuery(searchParams)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			if len(resp.Payload) == 0 {
-				return nil, fmt.Errorf("unable to locate log entry %d", logIndex)
+				return nil, nil, fmt.Errorf("unable to locate log entry %d", logIndex)
 			} else if len(resp.Payload) > 1 {
-				return nil, errors.New("too many log entries returned")
+				return nil, nil, errors.New("too many log entries returned")
 			}
 
 			logEntry := resp.Payload[0]
 
 			for _, v := range logEntry {
 				v := v
-				err = rekorVerify.VerifyLogEntry(context.TODO(), &v, *verifier)
+				err = rekorVerify.VerifyLogEntry(context.TODO(), &v, verifier)
 				if err != nil {
-					return nil, err
+					return nil, nil, cryptographicFailure(err)
 				}
 			}
+			entryLog = tlogVerifier
 			if trustIntegratedTime {
-				verifiedTimestamps = append(verifiedTimestamps, entry.IntegratedTime())
+				verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "Tlog", URI: entryLog.BaseURL, Timestamp: entry.IntegratedTime()})
 			}
 		}
 		// Ensure entry signature matches signature from bundle
 		if !bytes.Equal(entry.Signature(), entitySignature) {
-			return nil, errors.New("transparency log signature does not match")
+			return nil, nil, cryptographicFailure(errors.New("transparency log signature does not match"))
+		}
+
+		// Ensure entry body refers to the same envelope as the bundle
+		if envelope := sigContent.EnvelopeContent(); envelope != nil {
+			err = tlog.VerifyDSSEEnvelope(entry, envelope.RawEnvelope())
+			if err != nil {
+				return nil, nil, cryptographicFailure(fmt.Errorf("transparency log entry does not match envelope: %w", err))
+			}
 		}
 
 		// Ensure entry certificate matches bundle certificate
 		if !verificationContent.CompareKey(entry.PublicKey(), trustedMaterial) {
-			return nil, errors.New("transparency log certificate does not match")
+			return nil, nil, cryptographicFailure(errors.New("transparency log certificate does not match"))
 		}
 
 		// TODO: if you have access to artifact, check that it matches body subject
 
 		// Check tlog entry time against bundle certificates
 		if !verificationContent.ValidAtTime(entry.IntegratedTime(), trustedMaterial) {
-			return nil, errors.New("integrated time outside certificate validity")
+			return nil, nil, cryptographicFailure(errors.New("integrated time outside certificate validity"))
 		}
 
 		// successful log entry verification
-		logEntriesVerified++
-	}
-
-	if logEntriesVerified < logThreshold {
-		return nil, fmt.Errorf("not enough verified log entries from transparency log: %d < %d", logEntriesVerified, logThreshold)
+		verifiedLogs = append(verifiedLogs, entryLog)
 	}
 
-	return verifiedTimestamps, nil
-}
-
-func getVerifier(publicKey crypto.PublicKey, hashFunc crypto.Hash) (*signature.Verifier, error) {
-	verifier, err := signature.LoadVerifier(publicKey, hashFunc)
-	if err != nil {
-		return nil, err
+	if len(verifiedLogs) < logThreshold {
+		err := fmt.Errorf("not enough verified log entries from transparency log: %d < %d", len(verifiedLogs), logThreshold)
+		if len(skipped) > 0 {
+			// the reasons entries were skipped classify the error
+			err = fmt.Errorf("%w: %w", err, errors.Join(skipped...))
+		} else {
+			err = insufficientEvidence(err)
+		}
+		return nil, nil, err
 	}
 
-	return &verifier, nil
+	return verifiedLogs, verifiedTimestamps, nil
 }
 
 func getRekorClient(baseURL string) (*rekorGeneratedClient.Rekor, error) {
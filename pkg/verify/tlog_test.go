@@ -16,10 +16,13 @@ package verify_test
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/tlog"
 	"github.com/sigstore/sigstore-go/pkg/verify"
@@ -193,3 +196,71 @@ func TestDuplicateTlogEntries(t *testing.T) {
 	_, err = verify.VerifyArtifactTransparencyLog(&dupTlogEntity{entity}, virtualSigstore, 1, true, false)
 	assert.Error(t, err) // duplicate tlog entries should fail to verify
 }
+
+// tamperedEnvelopeEntity keeps the signature of the original envelope, but
+// swaps out the payload
+type tamperedEnvelopeEntity struct {
+	*ca.TestEntity
+	payload []byte
+}
+
+func (e *tamperedEnvelopeEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	envelope := *sigContent.EnvelopeContent().RawEnvelope()
+	envelope.Payload = base64.StdEncoding.EncodeToString(e.payload)
+	return &bundle.Envelope{Envelope: &envelope}, nil
+}
+
+func TestDSSETlogEntryMatchesEnvelope(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.AttestDSSE("foo@fighters.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	_, err = verify.VerifyArtifactTransparencyLog(entity, virtualSigstore, 1, true, false)
+	assert.NoError(t, err)
+
+	otherStatement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"0000000000000000000000000000000000000000000000000000000000000000"}}],"predicate":{}}`)
+	_, err = verify.VerifyArtifactTransparencyLog(&tamperedEnvelopeEntity{entity, otherStatement}, virtualSigstore, 1, true, false)
+	assert.ErrorContains(t, err, "dsse payload hash does not match envelope payload")
+}
+
+// extraSignatureEntity adds a signature to the envelope which is not in the
+// transparency log entry
+type extraSignatureEntity struct {
+	*ca.TestEntity
+}
+
+func (e *extraSignatureEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(sigContent.EnvelopeContent().RawEnvelope())
+	if err != nil {
+		return nil, err
+	}
+	var envelope dsse.Envelope
+	if err = json.Unmarshal(raw, &envelope); err != nil {
+		return nil, err
+	}
+	envelope.Signatures = append(envelope.Signatures, dsse.Signature{Sig: base64.StdEncoding.EncodeToString([]byte("extra"))})
+	return &bundle.Envelope{Envelope: &envelope}, nil
+}
+
+func TestDSSETlogEntrySignatureMismatch(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.AttestDSSE("foo@fighters.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	_, err = verify.VerifyArtifactTransparencyLog(&extraSignatureEntity{entity}, virtualSigstore, 1, true, false)
+	assert.ErrorContains(t, err, "dsse entry has 1 signatures, envelope has 2")
+}
@@ -21,53 +21,96 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/digitorus/timestamp"
 	tsaverification "github.com/sigstore/timestamp-authority/pkg/verification"
 
 	"github.com/sigstore/sigstore-go/pkg/root"
 )
 
+// RFC3161Timestamp contains the fields of an RFC 3161 timestamp token that
+// was verified against a trusted timestamping authority. These can be used to
+// enforce timestamp policy requirements, e.g. that a token was issued under a
+// specific TSA policy.
+type RFC3161Timestamp struct {
+	// GenTime is the time at which the timestamp token was created
+	GenTime time.Time `json:"genTime"`
+	// Accuracy is the time deviation around GenTime, if provided by the TSA
+	Accuracy time.Duration `json:"accuracy,omitempty"`
+	// Policy is the OID of the TSA policy under which the token was issued
+	Policy string `json:"policy"`
+	// SerialNumber is the decimal serial number assigned to the token by the TSA
+	SerialNumber string `json:"serialNumber"`
+	// HashAlgorithm is the algorithm used for the token's message imprint
+	HashAlgorithm string `json:"hashAlgorithm"`
+	// Qualified is true if the token declares itself a qualified timestamp
+	Qualified bool `json:"qualified,omitempty"`
+
+	// authority is the timestamping authority the token verified against
+	authority TrustedService
+}
+
 // VerifyTimestampAuthority verifies that the given entity has been timestamped
 // by a trusted timestamp authority and that the timestamp is valid.
 func VerifyTimestampAuthority(entity SignedEntity, trustedMaterial root.TrustedMaterial) ([]time.Time, error) { //nolint:revive
-	signedTimestamps, err := entity.Timestamps()
+	verifiedTimestamps, err := VerifyTimestampAuthorityWithDetails(entity, trustedMaterial)
 	if err != nil {
 		return nil, err
 	}
+	return timestampTimes(verifiedTimestamps), nil
+}
+
+// VerifyTimestampAuthorityWithDetails is like VerifyTimestampAuthority, but
+// returns the parsed fields of each verified timestamp token rather than only
+// the time.
+func VerifyTimestampAuthorityWithDetails(entity SignedEntity, trustedMaterial root.TrustedMaterial) ([]*RFC3161Timestamp, error) {
+	verifiedTimestamps, _, err := verifyTimestampAuthority(entity, trustedMaterial)
+	return verifiedTimestamps, err
+}
+
+// verifyTimestampAuthority verifies the entity's timestamps, also returning
+// the reasons timestamps that did not verify were skipped.
+func verifyTimestampAuthority(entity SignedEntity, trustedMaterial root.TrustedMaterial) ([]*RFC3161Timestamp, []error, error) {
+	signedTimestamps, err := entity.Timestamps()
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// disallow duplicate timestamps, as a malicious actor could use duplicates to bypass the threshold
 	for i := 0; i < len(signedTimestamps); i++ {
 		for j := i + 1; j < len(signedTimestamps); j++ {
 			if bytes.Equal(signedTimestamps[i], signedTimestamps[j]) {
-				return nil, errors.New("duplicate timestamps found")
+				return nil, nil, cryptographicFailure(errors.New("duplicate timestamps found"))
 			}
 		}
 	}
 
 	sigContent, err := entity.SignatureContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	signatureBytes := sigContent.Signature()
 
 	verificationContent, err := entity.VerificationContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	verifiedTimestamps := []time.Time{}
+	verifiedTimestamps := []*RFC3161Timestamp{}
+	var skipped []error
 	for _, timestamp := range signedTimestamps {
 		verifiedSignedTimestamp, err := verifySignedTimestamp(timestamp, signatureBytes, trustedMaterial, verificationContent)
 
 		// Timestamps from unknown source are okay, but don't count as verified
 		if err != nil {
+			skipped = append(skipped, err)
 			continue
 		}
 
 		verifiedTimestamps = append(verifiedTimestamps, verifiedSignedTimestamp)
 	}
 
-	return verifiedTimestamps, nil
+	return verifiedTimestamps, skipped, nil
 }
 
 // VerifyTimestampAuthority verifies that the given entity has been timestamped
@@ -76,21 +119,81 @@ func VerifyTimestampAuthority(entity SignedEntity, trustedMaterial root.TrustedM
 // The threshold parameter is the number of unique timestamps that must be
 // verified.
 func VerifyTimestampAuthorityWithThreshold(entity SignedEntity, trustedMaterial root.TrustedMaterial, threshold int) ([]time.Time, error) { //nolint:revive
-	verifiedTimestamps, err := VerifyTimestampAuthority(entity, trustedMaterial)
+	verifiedTimestamps, err := verifyTimestampAuthorityWithThreshold(entity, trustedMaterial, threshold)
+	if err != nil {
+		return nil, err
+	}
+	return timestampTimes(verifiedTimestamps), nil
+}
+
+func verifyTimestampAuthorityWithThreshold(entity SignedEntity, trustedMaterial root.TrustedMaterial, threshold int) ([]*RFC3161Timestamp, error) {
+	verifiedTimestamps, skipped, err := verifyTimestampAuthority(entity, trustedMaterial)
 	if err != nil {
 		return nil, err
 	}
 	if len(verifiedTimestamps) < threshold {
-		return nil, fmt.Errorf("threshold not met for verified signed timestamps: %d < %d", len(verifiedTimestamps), threshold)
+		return nil, timestampThresholdError(fmt.Errorf("threshold not met for verified signed timestamps: %d < %d", len(verifiedTimestamps), threshold), skipped)
 	}
 	return verifiedTimestamps, nil
 }
 
-func verifySignedTimestamp(signedTimestamp []byte, dsseSignatureBytes []byte, trustedMaterial root.TrustedMaterial, verificationContent VerificationContent) (time.Time, error) {
+// timestampThresholdError adds the reasons timestamps were skipped to a
+// threshold error, which classify it. Without any, the entity simply has too
+// few timestamps.
+func timestampThresholdError(err error, skipped []error) error {
+	if len(skipped) > 0 {
+		return fmt.Errorf("%w: %w", err, errors.Join(skipped...))
+	}
+	return insufficientEvidence(err)
+}
+
+// signedTimestampResult returns the verification result of a verified
+// timestamp token.
+func signedTimestampResult(ts *RFC3161Timestamp) TimestampVerificationResult {
+	return TimestampVerificationResult{Type: "TimestampAuthority", URI: ts.authority.URI, Timestamp: ts.GenTime, RFC3161: ts}
+}
+
+func timestampTimes(timestamps []*RFC3161Timestamp) []time.Time {
+	times := make([]time.Time, len(timestamps))
+	for i, ts := range timestamps {
+		times[i] = ts.GenTime
+	}
+	return times
+}
+
+// VerifyRFC3161Timestamp verifies an RFC 3161 timestamp response over
+// signatureBytes against the trusted material's timestamping authorities,
+// for systems that timestamp signatures outside of Sigstore bundles, such
+// as firmware signing. The response is the DER-encoded TimeStampResp, as in
+// bundles; the caller remains responsible for verifying the signature
+// itself and checking that its key was valid at the returned time.
+func VerifyRFC3161Timestamp(timestampResponse []byte, signatureBytes []byte, trustedMaterial root.TrustedMaterial) (*RFC3161Timestamp, error) { //nolint:revive
+	return verifyRFC3161Timestamp(timestampResponse, signatureBytes, trustedMaterial, nil)
+}
+
+func verifySignedTimestamp(signedTimestamp []byte, dsseSignatureBytes []byte, trustedMaterial root.TrustedMaterial, verificationContent VerificationContent) (*RFC3161Timestamp, error) {
+	return verifyRFC3161Timestamp(signedTimestamp, dsseSignatureBytes, trustedMaterial, func(t time.Time) bool {
+		return verificationContent.ValidAtTime(t, trustedMaterial)
+	})
+}
+
+// verifyRFC3161Timestamp verifies the timestamp against the first
+// timestamping authority that issued it and was valid at its time. If
+// validAt is not nil, authorities whose timestamp validAt rejects are
+// skipped.
+func verifyRFC3161Timestamp(signedTimestamp []byte, signatureBytes []byte, trustedMaterial root.TrustedMaterial, validAt func(time.Time) bool) (*RFC3161Timestamp, error) {
 	certAuthorities := trustedMaterial.TimestampingAuthorities()
+	if len(certAuthorities) == 0 {
+		return nil, untrustedMaterial(errors.New("no timestamping authorities in trusted material"))
+	}
+
+	// Set if the timestamp only verified against authorities that were not
+	// valid at the time of the timestamp
+	outsideValidityPeriod := false
 
 	// Iterate through TSA certificate authorities to find one that verifies
-	for _, ca := range certAuthorities {
+	for i := range certAuthorities {
+		ca := &certAuthorities[i]
 		trustedRootVerificationOptions := tsaverification.VerifyOpts{
 			Roots:          []*x509.Certificate{ca.Root},
 			Intermediates:  ca.Intermediates,
@@ -98,27 +201,60 @@ func verifySignedTimestamp(signedTimestamp []byte, dsseSignatureBytes []byte, tr
 		}
 
 		// Ensure timestamp responses are from trusted sources
-		timestamp, err := tsaverification.VerifyTimestampResponse(signedTimestamp, bytes.NewReader(dsseSignatureBytes), trustedRootVerificationOptions)
+		timestamp, err := tsaverification.VerifyTimestampResponse(signedTimestamp, bytes.NewReader(signatureBytes), trustedRootVerificationOptions)
 		if err != nil {
 			continue
 		}
 
 		if !ca.ValidityPeriodStart.IsZero() && timestamp.Time.Before(ca.ValidityPeriodStart) {
+			outsideValidityPeriod = true
 			continue
 		}
 		if !ca.ValidityPeriodEnd.IsZero() && timestamp.Time.After(ca.ValidityPeriodEnd) {
+			outsideValidityPeriod = true
 			continue
 		}
 
 		// Check tlog entry time against bundle certificates
 		// TODO: technically no longer needed since we check the cert validity period in the main Verify loop
-		if !verificationContent.ValidAtTime(timestamp.Time, trustedMaterial) {
+		if validAt != nil && !validAt(timestamp.Time) {
 			continue
 		}
 
 		// All above verification successful, so return nil
-		return timestamp.Time, nil
+		verified := &RFC3161Timestamp{
+			GenTime:       timestamp.Time,
+			Accuracy:      timestamp.Accuracy,
+			Policy:        timestamp.Policy.String(),
+			HashAlgorithm: timestamp.HashAlgorithm.String(),
+			Qualified:     timestamp.Qualified,
+			authority:     certificateAuthorityService(TrustedServiceTimestampAuthority, ca),
+		}
+		if timestamp.SerialNumber != nil {
+			verified.SerialNumber = timestamp.SerialNumber.String()
+		}
+		return verified, nil
 	}
 
-	return time.Time{}, errors.New("unable to verify signed timestamps")
+	if outsideValidityPeriod {
+		return nil, untrustedMaterial(errors.New("unable to verify signed timestamps: timestamping authority not valid at time of timestamp"))
+	}
+	// A timestamp that is over the signature but verified against no
+	// authority was signed by an authority that is not trusted
+	if !timestampCoversSignature(signedTimestamp, signatureBytes) {
+		return nil, cryptographicFailure(errors.New("unable to verify signed timestamps: timestamp is not over the signature"))
+	}
+	return nil, untrustedMaterial(errors.New("unable to verify signed timestamps: not signed by a trusted timestamping authority"))
+}
+
+// timestampCoversSignature reports whether a timestamp response parses and
+// its message imprint is the digest of signatureBytes.
+func timestampCoversSignature(signedTimestamp []byte, signatureBytes []byte) bool {
+	ts, err := timestamp.ParseResponse(signedTimestamp)
+	if err != nil || !ts.HashAlgorithm.Available() {
+		return false
+	}
+	hasher := ts.HashAlgorithm.New()
+	hasher.Write(signatureBytes)
+	return bytes.Equal(hasher.Sum(nil), ts.HashedMessage)
 }
@@ -73,6 +73,35 @@ func TestTimestampAuthorityVerifierWithoutThreshold(t *testing.T) {
 	assert.Empty(t, ts)
 }
 
+func TestTimestampAuthorityVerifierWithDetails(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	details, err := verify.VerifyTimestampAuthorityWithDetails(entity, virtualSigstore)
+	assert.NoError(t, err)
+	assert.Len(t, details, 1)
+	assert.False(t, details[0].GenTime.IsZero())
+	assert.Equal(t, "1.3.6.1.4.1.57264.2", details[0].Policy)
+	assert.Equal(t, "SHA-256", details[0].HashAlgorithm)
+	assert.NotEmpty(t, details[0].SerialNumber)
+
+	times, err := verify.VerifyTimestampAuthority(entity, virtualSigstore)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{details[0].GenTime}, times)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	res, err := verifier.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+	assert.Len(t, res.VerifiedTimestamps, 1)
+	assert.Equal(t, details[0], res.VerifiedTimestamps[0].RFC3161)
+}
+
 type oneTrustedOneUntrustedTimestampEntity struct {
 	*ca.TestEntity
 	UntrustedTestEntity *ca.TestEntity
@@ -223,3 +252,36 @@ func TestBadTSACertificateChainOutsideValidityPeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyRFC3161Timestamp(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte("firmware"))
+	assert.NoError(t, err)
+
+	timestamps, err := entity.Timestamps()
+	assert.NoError(t, err)
+	assert.Len(t, timestamps, 1)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+	signature := sigContent.Signature()
+
+	ts, err := verify.VerifyRFC3161Timestamp(timestamps[0], signature, virtualSigstore)
+	assert.NoError(t, err)
+	assert.False(t, ts.GenTime.IsZero())
+	assert.Equal(t, "1.3.6.1.4.1.57264.2", ts.Policy)
+
+	// The timestamp must be over the given signature
+	_, err = verify.VerifyRFC3161Timestamp(timestamps[0], []byte("other signature"), virtualSigstore)
+	assert.ErrorIs(t, err, verify.ErrCryptographicFailure)
+
+	_, err = verify.VerifyRFC3161Timestamp(timestamps[0], signature, &root.BaseTrustedMaterial{})
+	assert.ErrorIs(t, err, verify.ErrUntrustedMaterial)
+
+	// A timestamp over the signature by an authority that is not trusted
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	_, err = verify.VerifyRFC3161Timestamp(timestamps[0], signature, otherSigstore)
+	assert.ErrorIs(t, err, verify.ErrUntrustedMaterial)
+	assert.NotErrorIs(t, err, verify.ErrCryptographicFailure)
+}
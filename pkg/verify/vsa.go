@@ -0,0 +1,155 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	in_toto "github.com/in-toto/in-toto-golang/in_toto"
+)
+
+const (
+	// StatementInTotoV1 is the in-toto statement type used for VSAs
+	StatementInTotoV1 = "https://in-toto.io/Statement/v1"
+	// PredicateVSAV1 is the predicate type of a SLSA Verification Summary
+	// Attestation
+	PredicateVSAV1 = "https://slsa.dev/verification_summary/v1"
+	// VSAPayloadType is the DSSE payload type to use when signing a VSA
+	VSAPayloadType = "application/vnd.in-toto+json"
+
+	vsaResultPassed = "PASSED"
+)
+
+// VSAOptions describes the verification that produced a VerificationResult,
+// for inclusion in a Verification Summary Attestation.
+type VSAOptions struct {
+	// VerifierID is a URI identifying the verifier, required
+	VerifierID string
+	// VerifierVersion maps components of the verifier to their versions
+	VerifierVersion map[string]string
+	// ResourceURI identifies the resource that was verified, required
+	ResourceURI string
+	// PolicyURI and PolicyDigest identify the policy that was applied
+	PolicyURI    string
+	PolicyDigest map[string]string
+	// InputAttestations are the attestations that were verified, such as
+	// the bundle, identified by URI and digest
+	InputAttestations []VSAResourceDescriptor
+	// VerifiedLevels are the SLSA levels the subject was verified at
+	VerifiedLevels []string
+	// SLSAVersion of the levels in VerifiedLevels, defaults to "1.0"
+	SLSAVersion string
+	// Subject overrides the VSA subject. By default, the subjects of the
+	// verified in-toto statement are used.
+	Subject []in_toto.Subject
+	// TimeVerified defaults to the current time
+	TimeVerified time.Time
+}
+
+// VSAResourceDescriptor identifies a resource by URI and digest.
+type VSAResourceDescriptor struct {
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// VSAVerifier identifies the verifier in a VSA.
+type VSAVerifier struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// VSAPredicate is a SLSA Verification Summary Attestation v1 predicate.
+type VSAPredicate struct {
+	Verifier           VSAVerifier             `json:"verifier"`
+	TimeVerified       time.Time               `json:"timeVerified"`
+	ResourceURI        string                  `json:"resourceUri"`
+	Policy             VSAResourceDescriptor   `json:"policy"`
+	InputAttestations  []VSAResourceDescriptor `json:"inputAttestations,omitempty"`
+	VerificationResult string                  `json:"verificationResult"`
+	VerifiedLevels     []string                `json:"verifiedLevels"`
+	SLSAVersion        string                  `json:"slsaVersion,omitempty"`
+}
+
+// VerificationSummary returns an in-toto statement carrying a SLSA
+// Verification Summary Attestation of a successful verification, so that
+// downstream consumers can rely on the VSA instead of re-verifying.
+//
+// To sign the VSA, marshal it to JSON and sign it as a DSSE envelope with
+// payload type VSAPayloadType, for example with sign.DSSEData.
+func (r *VerificationResult) VerificationSummary(opts VSAOptions) (*in_toto.Statement, error) {
+	if opts.VerifierID == "" {
+		return nil, errors.New("a verifier ID is required to generate a VSA")
+	}
+	if opts.ResourceURI == "" {
+		return nil, errors.New("a resource URI is required to generate a VSA")
+	}
+
+	subject := opts.Subject
+	if len(subject) == 0 && r.Statement != nil {
+		subject = r.Statement.Subject
+	}
+	if len(subject) == 0 {
+		return nil, errors.New("no subject to generate a VSA for; verified entity has no in-toto statement")
+	}
+
+	timeVerified := opts.TimeVerified
+	if timeVerified.IsZero() {
+		timeVerified = time.Now()
+	}
+	slsaVersion := opts.SLSAVersion
+	if slsaVersion == "" {
+		slsaVersion = "1.0"
+	}
+	verifiedLevels := opts.VerifiedLevels
+	if verifiedLevels == nil {
+		verifiedLevels = []string{}
+	}
+
+	return &in_toto.Statement{
+		StatementHeader: in_toto.StatementHeader{
+			Type:          StatementInTotoV1,
+			PredicateType: PredicateVSAV1,
+			Subject:       subject,
+		},
+		Predicate: VSAPredicate{
+			Verifier: VSAVerifier{
+				ID:      opts.VerifierID,
+				Version: opts.VerifierVersion,
+			},
+			TimeVerified: timeVerified.UTC(),
+			ResourceURI:  opts.ResourceURI,
+			Policy: VSAResourceDescriptor{
+				URI:    opts.PolicyURI,
+				Digest: opts.PolicyDigest,
+			},
+			InputAttestations:  opts.InputAttestations,
+			VerificationResult: vsaResultPassed,
+			VerifiedLevels:     verifiedLevels,
+			SLSAVersion:        slsaVersion,
+		},
+	}, nil
+}
+
+// VerificationSummaryJSON returns the VSA generated by VerificationSummary
+// as JSON, suitable as an unsigned VSA or as the payload of a DSSE envelope.
+func (r *VerificationResult) VerificationSummaryJSON(opts VSAOptions) ([]byte, error) {
+	statement, err := r.VerificationSummary(opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(statement)
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationSummary(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foofighters@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	result, err := verifier.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	timeVerified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("", 3600))
+	opts := verify.VSAOptions{
+		VerifierID:      "https://example.com/verifier",
+		VerifierVersion: map[string]string{"sigstore-go": "v0.3.0"},
+		ResourceURI:     "pkg:example/subject@1.0",
+		PolicyURI:       "https://example.com/policy",
+		VerifiedLevels:  []string{"SLSA_BUILD_LEVEL_2"},
+		TimeVerified:    timeVerified,
+	}
+
+	vsaJSON, err := result.VerificationSummaryJSON(opts)
+	assert.NoError(t, err)
+
+	var vsa map[string]any
+	assert.NoError(t, json.Unmarshal(vsaJSON, &vsa))
+	assert.Equal(t, verify.StatementInTotoV1, vsa["_type"])
+	assert.Equal(t, verify.PredicateVSAV1, vsa["predicateType"])
+	assert.Equal(t, "subject", vsa["subject"].([]any)[0].(map[string]any)["name"])
+
+	predicate := vsa["predicate"].(map[string]any)
+	assert.Equal(t, "PASSED", predicate["verificationResult"])
+	assert.Equal(t, "2024-05-01T11:00:00Z", predicate["timeVerified"])
+	assert.Equal(t, "pkg:example/subject@1.0", predicate["resourceUri"])
+	assert.Equal(t, "1.0", predicate["slsaVersion"])
+	assert.Equal(t, map[string]any{"id": "https://example.com/verifier", "version": map[string]any{"sigstore-go": "v0.3.0"}}, predicate["verifier"])
+
+	// The VSA can be signed and verified like any other attestation
+	vsaEntity, err := virtualSigstore.SignWithKey("vsa-key", &sign.DSSEData{Data: vsaJSON, PayloadType: verify.VSAPayloadType})
+	assert.NoError(t, err)
+	vsaVerifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+	vsaResult, err := vsaVerifier.Verify(vsaEntity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, verify.PredicateVSAV1, vsaResult.Statement.PredicateType)
+
+	_, err = result.VerificationSummary(verify.VSAOptions{ResourceURI: "pkg:example/subject@1.0"})
+	assert.ErrorContains(t, err, "verifier ID")
+	_, err = result.VerificationSummary(verify.VSAOptions{VerifierID: "https://example.com/verifier"})
+	assert.ErrorContains(t, err, "resource URI")
+	_, err = verify.NewVerificationResult().VerificationSummary(opts)
+	assert.ErrorContains(t, err, "no subject")
+}
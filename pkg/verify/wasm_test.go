@@ -0,0 +1,65 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore-go/pkg/wasm"
+)
+
+func TestEntitySignedOverWasmModule(t *testing.T) {
+	module := []byte("\x00asm\x01\x00\x00\x00\x01\x04\x01\x60\x00\x00")
+	// The module as served by a registry, with the signature embedded
+	signedModule := append(append([]byte{}, module...), []byte("\x00\x0d\x09signature\x01\x02\x03")...)
+	opts := &wasm.Options{ExcludeCustomSections: []string{"signature"}}
+
+	for name, newContent := range map[string]func() (sign.Content, error){
+		"message signature": func() (sign.Content, error) {
+			return sign.NewWasmModuleData(module, opts)
+		},
+		"statement": func() (sign.Content, error) {
+			return sign.WasmModuleStatement("app.wasm", module, opts, "https://example.com/predicate", nil)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			content, err := newContent()
+			require.NoError(t, err)
+			virtualSigstore, err := ca.NewVirtualSigstore()
+			require.NoError(t, err)
+			entity, err := virtualSigstore.SignWithKey("key", content)
+			require.NoError(t, err)
+			verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+			require.NoError(t, err)
+
+			_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithWasmModule(signedModule, opts), verify.WithoutIdentitiesUnsafe()))
+			assert.NoError(t, err)
+
+			// Without the exclusion rules, the embedded signature is part of
+			// the module
+			_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithWasmModule(signedModule, nil), verify.WithoutIdentitiesUnsafe()))
+			assert.Error(t, err)
+
+			_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithWasmModule([]byte("not wasm"), opts), verify.WithoutIdentitiesUnsafe()))
+			assert.ErrorContains(t, err, "invalid WebAssembly module")
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wasm computes the digests that WebAssembly modules are signed
+// and attested with. Registries commonly embed signatures in custom
+// sections of the module, or strip debug sections before serving it, so
+// the digest can leave out custom sections that change after signing.
+//
+// This package is experimental and may change in backwards incompatible
+// ways.
+package wasm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// magic is the preamble of WebAssembly modules and components, which is
+// followed by a 4 byte version.
+var magic = []byte("\x00asm")
+
+const customSectionID = 0
+
+// Options configure the digest of a module.
+type Options struct {
+	// ExcludeCustomSections are the names of custom sections that are left
+	// out of the digest, such as sections that hold signatures. A name
+	// ending in "*" matches all sections with that prefix, e.g.
+	// ".debug_*".
+	ExcludeCustomSections []string
+}
+
+func (o *Options) excludes(name string) bool {
+	if o == nil {
+		return false
+	}
+	for _, rule := range o.ExcludeCustomSections {
+		if prefix, ok := strings.CutSuffix(rule, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Section is a section of a module.
+type Section struct {
+	ID byte
+	// Name is the name of a custom section
+	Name string
+	// Offset and Length are the position of the whole section, including
+	// its ID and size, in the module
+	Offset int
+	Length int
+}
+
+// Sections parses the sections of a module, which must be a WebAssembly
+// binary module or component.
+func Sections(module []byte) ([]Section, error) {
+	if len(module) < len(magic)+4 || !bytes.Equal(module[:len(magic)], magic) {
+		return nil, errors.New("not a WebAssembly module")
+	}
+	var sections []Section
+	offset := len(magic) + 4
+	for offset < len(module) {
+		start := offset
+		id := module[offset]
+		offset++
+		size, n, err := readU32(module[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("section at offset %d: %w", start, err)
+		}
+		offset += n
+		if uint64(size) > uint64(len(module)-offset) {
+			return nil, fmt.Errorf("section at offset %d is truncated", start)
+		}
+		section := Section{ID: id, Offset: start, Length: offset - start + int(size)}
+		if id == customSectionID {
+			contents := module[offset : offset+int(size)]
+			nameLength, n, err := readU32(contents)
+			if err != nil || uint64(nameLength) > uint64(len(contents)-n) {
+				return nil, fmt.Errorf("custom section at offset %d has an invalid name", start)
+			}
+			name := contents[n : n+int(nameLength)]
+			if !utf8.Valid(name) {
+				return nil, fmt.Errorf("custom section at offset %d has an invalid name", start)
+			}
+			section.Name = string(name)
+		}
+		sections = append(sections, section)
+		offset += int(size)
+	}
+	return sections, nil
+}
+
+// readU32 reads an unsigned LEB128 integer of at most 32 bits, returning
+// it and the number of bytes read.
+func readU32(b []byte) (uint32, int, error) {
+	var value uint32
+	for i := 0; i < 5; i++ {
+		if i >= len(b) {
+			return 0, 0, errors.New("truncated integer")
+		}
+		value |= uint32(b[i]&0x7f) << (7 * i)
+		if b[i]&0x80 == 0 {
+			if i == 4 && b[i] > 0x0f {
+				return 0, 0, errors.New("integer overflows 32 bits")
+			}
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("integer overflows 32 bits")
+}
+
+// Canonicalize returns the module without the custom sections that opts
+// excludes, which is the content that is signed and digested. The module is
+// returned unchanged if no sections are excluded.
+func Canonicalize(module []byte, opts *Options) ([]byte, error) {
+	sections, err := Sections(module)
+	if err != nil {
+		return nil, err
+	}
+	canonical := module
+	copied := false
+	for i := len(sections) - 1; i >= 0; i-- {
+		section := sections[i]
+		if section.ID != customSectionID || !opts.excludes(section.Name) {
+			continue
+		}
+		if !copied {
+			canonical = bytes.Clone(module)
+			copied = true
+		}
+		canonical = append(canonical[:section.Offset], canonical[section.Offset+section.Length:]...)
+	}
+	return canonical, nil
+}
+
+// Digest returns the SHA-256 digest of the module without the custom
+// sections that opts excludes.
+func Digest(module []byte, opts *Options) ([]byte, error) {
+	canonical, err := Canonicalize(module, opts)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(canonical)
+	return digest[:], nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	header = []byte("\x00asm\x01\x00\x00\x00")
+	// typeSection declares a single function type with no parameters or
+	// results
+	typeSection = []byte{0x01, 0x04, 0x01, 0x60, 0x00, 0x00}
+)
+
+func customSection(name string, payload []byte) []byte {
+	contents := append([]byte{byte(len(name))}, name...)
+	contents = append(contents, payload...)
+	return append([]byte{customSectionID, byte(len(contents))}, contents...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func TestSections(t *testing.T) {
+	module := concat(header, typeSection, customSection("name", []byte{1, 2}), customSection(".debug_info", []byte{3}))
+	sections, err := Sections(module)
+	require.NoError(t, err)
+	assert.Equal(t, []Section{
+		{ID: 1, Offset: 8, Length: 6},
+		{ID: 0, Name: "name", Offset: 14, Length: 9},
+		{ID: 0, Name: ".debug_info", Offset: 23, Length: 15},
+	}, sections)
+
+	sections, err = Sections(header)
+	require.NoError(t, err)
+	assert.Empty(t, sections)
+
+	for name, invalid := range map[string][]byte{
+		"no magic":            []byte("\x00elf\x01\x00\x00\x00"),
+		"short":               []byte("\x00asm"),
+		"truncated section":   concat(header, []byte{0x01, 0x05, 0x01, 0x60, 0x00, 0x00}),
+		"truncated size":      concat(header, []byte{0x01, 0x80}),
+		"oversized integer":   concat(header, []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0x7f}),
+		"truncated name":      concat(header, []byte{0x00, 0x02, 0x05, 'a'}),
+		"invalid UTF-8 name":  concat(header, []byte{0x00, 0x02, 0x01, 0xff}),
+		"missing custom name": concat(header, []byte{0x00, 0x00}),
+	} {
+		_, err := Sections(invalid)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	signature := customSection("signature", []byte("sig"))
+	debug := customSection(".debug_line", []byte{4, 5})
+	module := concat(header, signature, typeSection, customSection("name", []byte{1}), debug)
+
+	canonical, err := Canonicalize(module, nil)
+	require.NoError(t, err)
+	assert.Equal(t, module, canonical)
+
+	opts := &Options{ExcludeCustomSections: []string{"signature", ".debug_*"}}
+	canonical, err = Canonicalize(module, opts)
+	require.NoError(t, err)
+	assert.Equal(t, concat(header, typeSection, customSection("name", []byte{1})), canonical)
+	// The module is not modified
+	assert.Equal(t, concat(header, signature, typeSection, customSection("name", []byte{1}), debug), module)
+
+	// Adding or changing excluded sections does not change the digest
+	digest, err := Digest(module, opts)
+	require.NoError(t, err)
+	expected := sha256.Sum256(canonical)
+	assert.Equal(t, expected[:], digest)
+	resigned, err := Digest(concat(header, customSection("signature", []byte("other")), typeSection, customSection("name", []byte{1})), opts)
+	require.NoError(t, err)
+	assert.Equal(t, digest, resigned)
+
+	// But changing other sections does
+	changed, err := Digest(concat(header, typeSection, customSection("name", []byte{2})), opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, changed)
+
+	_, err = Digest([]byte("not wasm"), opts)
+	assert.Error(t, err)
+}